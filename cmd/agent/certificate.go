@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rancher/rancher/pkg/agent/cluster"
+	"github.com/rancher/rancher/pkg/agent/node"
+	"github.com/sirupsen/logrus"
+)
+
+const certificateCheckInterval = time.Hour
+
+// agentCertificate is the client certificate the agent authenticates the tunnel with once it
+// enrolled. Rancher only requests it on a dedicated listener at address, connections fall back to
+// the server URL with the token and credential headers without one.
+type agentCertificate struct {
+	sync.Mutex
+	cert    *tls.Certificate
+	address string
+}
+
+func (a *agentCertificate) getClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	a.Lock()
+	defer a.Unlock()
+	if a.cert == nil {
+		return &tls.Certificate{}, nil
+	}
+	return a.cert, nil
+}
+
+func (a *agentCertificate) set(certPEM, keyPEM []byte, address string) error {
+	cert, err := tls.X509KeyPair(certPEM, keyPEM)
+	if err != nil {
+		return err
+	}
+	cert.Leaf, err = x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return err
+	}
+
+	a.Lock()
+	a.cert = &cert
+	a.address = address
+	a.Unlock()
+	return nil
+}
+
+// host returns the address to connect to with the certificate, or serverHost if the agent has no
+// certificate or Rancher has no listener for it
+func (a *agentCertificate) host(serverHost string) string {
+	a.Lock()
+	defer a.Unlock()
+	if a.cert == nil || a.address == "" {
+		return serverHost
+	}
+	return a.address
+}
+
+func (a *agentCertificate) load() error {
+	var (
+		certPEM, keyPEM []byte
+		address         string
+		err             error
+	)
+	if isCluster() {
+		certPEM, keyPEM, address, err = cluster.Certificate()
+	} else {
+		certPEM, keyPEM, address, err = node.Certificate()
+	}
+	if err != nil || certPEM == nil {
+		return err
+	}
+	return a.set(certPEM, keyPEM, address)
+}
+
+func (a *agentCertificate) save(certPEM, keyPEM []byte, address string) error {
+	if isCluster() {
+		return cluster.SaveCertificate(certPEM, keyPEM, address)
+	}
+	return node.SaveCertificate(certPEM, keyPEM, address)
+}
+
+// needsRenewal returns true if there is no certificate or two thirds of its lifetime passed
+func (a *agentCertificate) needsRenewal(now time.Time) bool {
+	a.Lock()
+	defer a.Unlock()
+	if a.cert == nil || a.cert.Leaf == nil {
+		return true
+	}
+	lifetime := a.cert.Leaf.NotAfter.Sub(a.cert.Leaf.NotBefore)
+	return now.After(a.cert.Leaf.NotAfter.Add(-lifetime / 3))
+}
+
+func (a *agentCertificate) tlsConfig() *tls.Config {
+	return &tls.Config{
+		GetClientCertificate: a.getClientCertificate,
+	}
+}
+
+func (a *agentCertificate) dialer() *websocket.Dialer {
+	return &websocket.Dialer{
+		Proxy:            http.ProxyFromEnvironment,
+		HandshakeTimeout: 45 * time.Second,
+		TLSClientConfig:  a.tlsConfig(),
+	}
+}
+
+// enroll requests a new certificate for the agent. The current certificate is presented on the
+// dedicated listener if there is one so Rancher revokes it once the new one is issued.
+func (a *agentCertificate) enroll(serverURL *url.URL, headers map[string][]string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	csr, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "cattle-agent"},
+	}, key)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("https://%s/v3/connect/certificate", a.host(serverURL.Host)),
+		bytes.NewReader(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csr})))
+	if err != nil {
+		return err
+	}
+	req.Header = http.Header(headers).Clone()
+
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: a.tlsConfig(),
+		},
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	certPEM, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("invalid response %d: %s", resp.StatusCode, certPEM)
+	}
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	keyPEM := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+
+	address := resp.Header.Get(AgentTLSAddress)
+	if err := a.set(certPEM, keyPEM, address); err != nil {
+		return err
+	}
+	return a.save(certPEM, keyPEM, address)
+}
+
+// renew enrolls the agent and keeps renewing its certificate before it expires
+func (a *agentCertificate) renew(ctx context.Context, serverURL *url.URL, headers map[string][]string) {
+	for {
+		if a.needsRenewal(time.Now()) {
+			if err := a.enroll(serverURL, headers); err != nil {
+				logrus.Warnf("Failed to enroll agent certificate: %v", err)
+			} else {
+				logrus.Infof("Enrolled agent certificate for %s", serverURL.Host)
+			}
+		}
+
+		select {
+		case <-time.After(certificateCheckInterval):
+		case <-ctx.Done():
+			return
+		}
+	}
+}
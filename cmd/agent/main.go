@@ -40,7 +40,10 @@
 )
 
 const (
-	Token = "X-API-Tunnel-Token"
+	Token           = "X-API-Tunnel-Token"
+	AgentCredential = "X-API-Tunnel-Agent-Credential"
+	AgentVersion    = "X-API-Tunnel-Agent-Version"
+	AgentTLSAddress = "X-Cattle-Agent-Tls-Address"
 )
 
 func main() {
@@ -111,6 +114,65 @@ func getTokenAndURL() (string, string, error) {
 	return token, url, nil
 }
 
+func getCredential() (string, error) {
+	if isCluster() {
+		return cluster.Credential()
+	}
+	return node.Credential()
+}
+
+func saveCredential(credential string) error {
+	if isCluster() {
+		return cluster.SaveCredential(credential)
+	}
+	return node.SaveCredential(credential)
+}
+
+// requestCredential exchanges the registration token for a credential of the agent, which
+// keeps working after the registration token expired, was used up or was rotated
+func requestCredential(httpClient *http.Client, serverURL *url.URL, headers map[string][]string) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("https://%s/v3/connect/credential", serverURL.Host), nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header = headers
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("invalid response %d: %s", resp.StatusCode, body)
+	}
+	return strings.TrimSpace(string(body)), nil
+}
+
+func setupCredential(httpClient *http.Client, serverURL *url.URL, headers map[string][]string) error {
+	credential, err := getCredential()
+	if err != nil {
+		return err
+	}
+
+	if credential == "" {
+		credential, err = requestCredential(httpClient, serverURL, headers)
+		if err != nil {
+			return err
+		}
+		if err := saveCredential(credential); err != nil {
+			return err
+		}
+	}
+
+	headers[AgentCredential] = []string{credential}
+	return nil
+}
+
 func isConnect() bool {
 	if os.Getenv("CATTLE_AGENT_CONNECT") == "true" {
 		return true
@@ -191,6 +253,7 @@ func run(ctx context.Context) error {
 
 	headers := map[string][]string{
 		Token:                      {token},
+		AgentVersion:               {VERSION},
 		rkenodeconfigclient.Params: {base64.StdEncoding.EncodeToString(bytes)},
 	}
 
@@ -293,6 +356,17 @@ func run(ctx context.Context) error {
 		}
 	}
 
+	if err := setupCredential(httpClient, serverURL, headers); err != nil {
+		logrus.Warnf("Failed to set up agent credential, connecting with the registration token: %v", err)
+	}
+
+	certificate := &agentCertificate{}
+	if err := certificate.load(); err != nil {
+		logrus.Warnf("Failed to load agent certificate: %v", err)
+	}
+	// Agents enroll before connecting, Rancher may only accept tunnels authenticated with the certificate
+	go certificate.renew(topContext, serverURL, headers)
+
 	onConnect := func(ctx context.Context, _ *remotedialer.Session) error {
 		connected()
 		connectConfig := fmt.Sprintf("https://%s/v3/connect/config", serverURL.Host)
@@ -347,13 +421,13 @@ func run(ctx context.Context) error {
 	}
 
 	for {
-		wsURL := fmt.Sprintf("wss://%s/v3/connect", serverURL.Host)
+		wsURL := fmt.Sprintf("wss://%s/v3/connect", certificate.host(serverURL.Host))
 		if !isConnect() {
 			wsURL += "/register"
 		}
 		logrus.Infof("Connecting to %s with token starting with %s", wsURL, token[:len(token)/2])
 		logrus.Tracef("Connecting to %s with token %s", wsURL, token)
-		remotedialer.ClientConnect(ctx, wsURL, headers, nil, func(proto, address string) bool {
+		remotedialer.ClientConnect(ctx, wsURL, headers, certificate.dialer(), func(proto, address string) bool {
 			switch proto {
 			case "tcp":
 				return true
@@ -147,6 +147,7 @@ require (
 	gopkg.in/ldap.v2 v2.5.1
 	gopkg.in/natefinch/lumberjack.v2 v2.0.0
 	gopkg.in/yaml.v2 v2.4.0
+	gopkg.in/yaml.v3 v3.0.0-20200615113413-eeeca48fe776
 	helm.sh/helm/v3 v3.5.4
 	k8s.io/api v0.21.2
 	k8s.io/apiextensions-apiserver v0.21.0
@@ -91,6 +91,12 @@ func main() {
 			Value:       8443,
 			Destination: &config.HTTPSListenPort,
 		},
+		cli.IntFlag{
+			Name:        "agent-tls-listen-port",
+			EnvVar:      "CATTLE_AGENT_TLS_LISTEN_PORT",
+			Usage:       "HTTPS listen port agents connect to with their client certificate, it has to be exposed without TLS termination (0 to disable)",
+			Destination: &config.AgentTLSListenPort,
+		},
 		cli.StringFlag{
 			Name:        "k8s-mode",
 			Usage:       "Mode to run or access k8s API server for management API (embedded, external, auto)",
@@ -13,6 +13,7 @@
 	"github.com/rancher/rancher/pkg/namespace"
 	"github.com/rancher/wrangler/pkg/kubeconfig"
 	coreV1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
@@ -22,6 +23,10 @@
 	urlFilename              = "url"
 	tokenFilename            = "token"
 	namespaceFilename        = "namespace"
+	credentialSecretName     = "cattle-agent-credential"
+	credentialKey            = "credential"
+	certificateSecretName    = "cattle-agent-certificate"
+	certificateAddressKey    = "address"
 
 	kubernetesServiceHostKey = "KUBERNETES_SERVICE_HOST"
 	kubernetesServicePortKey = "KUBERNETES_SERVICE_PORT"
@@ -48,12 +53,95 @@ func CAChecksum() string {
 	return os.Getenv("CATTLE_CA_CHECKSUM")
 }
 
-func getTokenFromAPI() ([]byte, []byte, error) {
+// Credential returns the credential the agent was issued at registration, if any
+func Credential() (string, error) {
+	k8s, err := newClient()
+	if err != nil {
+		return "", err
+	}
+	secret, err := k8s.CoreV1().Secrets(namespace.System).Get(context.Background(), credentialSecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return string(secret.Data[credentialKey]), nil
+}
+
+// SaveCredential persists the credential in a secret so it survives agent restarts
+func SaveCredential(credential string) error {
+	k8s, err := newClient()
+	if err != nil {
+		return err
+	}
+	secret := &coreV1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      credentialSecretName,
+			Namespace: namespace.System,
+		},
+		Data: map[string][]byte{
+			credentialKey: []byte(credential),
+		},
+	}
+	_, err = k8s.CoreV1().Secrets(namespace.System).Create(context.Background(), secret, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		_, err = k8s.CoreV1().Secrets(namespace.System).Update(context.Background(), secret, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+// Certificate returns the PEM encoded client certificate and key of the agent and the address
+// it connects to with them, if any
+func Certificate() ([]byte, []byte, string, error) {
+	k8s, err := newClient()
+	if err != nil {
+		return nil, nil, "", err
+	}
+	secret, err := k8s.CoreV1().Secrets(namespace.System).Get(context.Background(), certificateSecretName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil, "", nil
+	} else if err != nil {
+		return nil, nil, "", err
+	}
+	return secret.Data[coreV1.TLSCertKey], secret.Data[coreV1.TLSPrivateKeyKey], string(secret.Data[certificateAddressKey]), nil
+}
+
+// SaveCertificate persists the client certificate and key of the agent and the address it
+// connects to with them in a secret
+func SaveCertificate(certPEM, keyPEM []byte, address string) error {
+	k8s, err := newClient()
+	if err != nil {
+		return err
+	}
+	secret := &coreV1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      certificateSecretName,
+			Namespace: namespace.System,
+		},
+		Type: coreV1.SecretTypeTLS,
+		Data: map[string][]byte{
+			coreV1.TLSCertKey:       certPEM,
+			coreV1.TLSPrivateKeyKey: keyPEM,
+			certificateAddressKey:   []byte(address),
+		},
+	}
+	_, err = k8s.CoreV1().Secrets(namespace.System).Create(context.Background(), secret, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) {
+		_, err = k8s.CoreV1().Secrets(namespace.System).Update(context.Background(), secret, metav1.UpdateOptions{})
+	}
+	return err
+}
+
+func newClient() (kubernetes.Interface, error) {
 	cfg, err := kubeconfig.GetNonInteractiveClientConfig("").ClientConfig()
 	if err != nil {
-		return nil, nil, err
+		return nil, err
 	}
-	k8s, err := kubernetes.NewForConfig(cfg)
+	return kubernetes.NewForConfig(cfg)
+}
+
+func getTokenFromAPI() ([]byte, []byte, error) {
+	k8s, err := newClient()
 	if err != nil {
 		return nil, nil, err
 	}
@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"io/ioutil"
 	"os"
 	"strings"
 
@@ -10,10 +11,67 @@
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	credentialFile  = "/etc/kubernetes/.cattle-agent-credential"
+	certificateFile = "/etc/kubernetes/.cattle-agent-cert.pem"
+	keyFile         = "/etc/kubernetes/.cattle-agent-key.pem"
+	addressFile     = "/etc/kubernetes/.cattle-agent-tls-address"
+)
+
 func TokenAndURL() (string, string, error) {
 	return os.Getenv("CATTLE_TOKEN"), os.Getenv("CATTLE_SERVER"), nil
 }
 
+// Credential returns the credential the agent was issued at registration, if any
+func Credential() (string, error) {
+	bytes, err := ioutil.ReadFile(credentialFile)
+	if os.IsNotExist(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(bytes)), nil
+}
+
+// SaveCredential persists the credential on the host so it survives agent restarts
+func SaveCredential(credential string) error {
+	return ioutil.WriteFile(credentialFile, []byte(credential), 0600)
+}
+
+// Certificate returns the PEM encoded client certificate and key of the agent and the address
+// it connects to with them, if any
+func Certificate() ([]byte, []byte, string, error) {
+	certPEM, err := ioutil.ReadFile(certificateFile)
+	if os.IsNotExist(err) {
+		return nil, nil, "", nil
+	} else if err != nil {
+		return nil, nil, "", err
+	}
+	keyPEM, err := ioutil.ReadFile(keyFile)
+	if os.IsNotExist(err) {
+		return nil, nil, "", nil
+	} else if err != nil {
+		return nil, nil, "", err
+	}
+	address, err := ioutil.ReadFile(addressFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, nil, "", err
+	}
+	return certPEM, keyPEM, strings.TrimSpace(string(address)), nil
+}
+
+// SaveCertificate persists the client certificate and key of the agent and the address it
+// connects to with them on the host
+func SaveCertificate(certPEM, keyPEM []byte, address string) error {
+	if err := ioutil.WriteFile(keyFile, keyPEM, 0600); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(certificateFile, certPEM, 0600); err != nil {
+		return err
+	}
+	return ioutil.WriteFile(addressFile, []byte(address), 0600)
+}
+
 func Params() map[string]interface{} {
 	labels := parseLabel(os.Getenv("CATTLE_NODE_LABEL"))
 	taints := split(os.Getenv("CATTLE_NODE_TAINTS"))
@@ -4,6 +4,7 @@
 	"context"
 	"encoding/json"
 	"io/ioutil"
+	"net/http"
 
 	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 
@@ -15,6 +16,7 @@
 	"github.com/rancher/rancher/pkg/notifiers"
 	"github.com/rancher/rancher/pkg/rbac"
 	"github.com/rancher/rancher/pkg/ref"
+	"github.com/rancher/rancher/pkg/settings"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -23,12 +25,14 @@
 func NotifierCollectionFormatter(apiContext *types.APIContext, collection *types.GenericCollection) {
 	if canCreateNotifier(apiContext, nil, "") {
 		collection.AddAction(apiContext, "send")
+		collection.AddAction(apiContext, "preview")
 	}
 }
 
 func NotifierFormatter(apiContext *types.APIContext, resource *types.RawResource) {
 	if canCreateNotifier(apiContext, resource, "") {
 		resource.AddAction(apiContext, "send")
+		resource.AddAction(apiContext, "preview")
 	}
 }
 
@@ -36,6 +40,8 @@ func (h *Handler) NotifierActionHandler(actionName string, action *types.Action,
 	switch actionName {
 	case "send":
 		return h.testNotifier(apiContext.Request.Context(), actionName, action, apiContext)
+	case "preview":
+		return h.previewNotifier(apiContext)
 	}
 
 	return httperror.NewAPIError(httperror.InvalidAction, "invalid action: "+actionName)
@@ -68,6 +74,7 @@ func (h *Handler) testNotifier(ctx context.Context, actionName string, action *t
 	notifier := &v3.Notifier{
 		Spec: input.NotifierSpec,
 	}
+	clusterID := clientNotifier.ClusterID
 	msg := input.Message
 	if apiContext.ID != "" {
 		ns, id := ref.Parse(apiContext.ID)
@@ -75,7 +82,19 @@ func (h *Handler) testNotifier(ctx context.Context, actionName string, action *t
 		if err != nil {
 			return err
 		}
+		clusterID = notifier.Namespace
 	}
+
+	dialer, err := h.DialerFactory.ClusterDialer(clientNotifier.ClusterID)
+	if err != nil {
+		return errors.Wrap(err, "error getting dialer")
+	}
+
+	// A notifier with a message template is tested with its message for sample alerts
+	if notifier.Spec.MessageTemplate != nil {
+		return notifiers.Notify(ctx, notifier, "", settings.ServerURL.Get(), clusterID, notifiers.SampleData(), dialer)
+	}
+
 	notifierMessage := &notifiers.Message{
 		Content: msg,
 	}
@@ -83,11 +102,56 @@ func (h *Handler) testNotifier(ctx context.Context, actionName string, action *t
 		notifierMessage.Title = testSMTPTitle
 	}
 
-	dialer, err := h.DialerFactory.ClusterDialer(clientNotifier.ClusterID)
+	return notifiers.SendMessage(ctx, notifier, "", notifierMessage, dialer)
+}
+
+// previewNotifier renders the messages of the notifier in the input, or of the existing notifier,
+// for sample alerts
+func (h *Handler) previewNotifier(apiContext *types.APIContext) error {
+	data, err := ioutil.ReadAll(apiContext.Request.Body)
 	if err != nil {
-		return errors.Wrap(err, "error getting dialer")
+		return errors.Wrap(err, "reading request body error")
 	}
-	return notifiers.SendMessage(ctx, notifier, "", notifierMessage, dialer)
+	input := &struct {
+		v32.NotifierSpec
+	}{}
+	clientNotifier := &struct {
+		client.NotifierSpec
+	}{}
+
+	if err = json.Unmarshal(data, input); err != nil {
+		return errors.Wrap(err, "unmarshalling input error")
+	}
+	if err = json.Unmarshal(data, clientNotifier); err != nil {
+		return errors.Wrap(err, "unmarshalling input error client")
+	}
+
+	spec := input.NotifierSpec
+	clusterID := clientNotifier.ClusterID
+	if apiContext.ID != "" {
+		ns, id := ref.Parse(apiContext.ID)
+		notifier, err := h.Notifiers.GetNamespaced(ns, id, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		spec = notifier.Spec
+		clusterID = notifier.Namespace
+	}
+	if !canCreateNotifier(apiContext, nil, clusterID) {
+		return httperror.NewAPIError(httperror.NotFound, "not found")
+	}
+
+	output, err := notifiers.Preview(&spec, settings.ServerURL.Get(), clusterID)
+	if err != nil {
+		return httperror.NewAPIError(httperror.InvalidBodyContent, err.Error())
+	}
+
+	apiContext.WriteResponse(http.StatusOK, map[string]interface{}{
+		"type":  client.NotifierPreviewOutputType,
+		"title": output.Title,
+		"text":  output.Text,
+	})
+	return nil
 }
 
 func canCreateNotifier(apiContext *types.APIContext, resource *types.RawResource, clusterID string) bool {
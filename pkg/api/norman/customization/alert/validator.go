@@ -10,6 +10,7 @@
 	"github.com/rancher/norman/types"
 	"github.com/rancher/norman/types/convert"
 	v3client "github.com/rancher/rancher/pkg/client/generated/management/v3"
+	"github.com/rancher/rancher/pkg/notifiers"
 	"github.com/rancher/rancher/pkg/ref"
 )
 
@@ -75,3 +76,15 @@ func ProjectAlertRuleValidator(resquest *types.APIContext, schema *types.Schema,
 
 	return nil
 }
+
+func NotifierValidator(resquest *types.APIContext, schema *types.Schema, data map[string]interface{}) error {
+	var spec v32.NotifierSpec
+	if err := convert.ToObj(data, &spec); err != nil {
+		return httperror.NewAPIError(httperror.InvalidBodyContent, fmt.Sprintf("%v", err))
+	}
+
+	if err := notifiers.ValidateMessageTemplate(spec.MessageTemplate); err != nil {
+		return httperror.NewAPIError(httperror.InvalidBodyContent, err.Error())
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package clusterregistrationtokens
+
+import (
+	"net/http"
+
+	"github.com/rancher/norman/api/access"
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/ref"
+	"github.com/rancher/wrangler/pkg/randomtoken"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type Handler struct {
+	ClusterRegistrationTokens v3.ClusterRegistrationTokenInterface
+}
+
+// ActionHandler rotates the token. Agents that already registered keep working with the credential
+// they were issued when they first connected.
+func (h *Handler) ActionHandler(actionName string, action *types.Action, apiContext *types.APIContext) error {
+	if actionName != v32.ClusterRegistrationTokenActionRotate {
+		return httperror.NewAPIError(httperror.NotFound, "not found")
+	}
+
+	data := map[string]interface{}{}
+	if err := access.ByID(apiContext, apiContext.Version, apiContext.Type, apiContext.ID, &data); err != nil {
+		return err
+	}
+
+	if err := apiContext.AccessControl.CanDo(v3.ClusterRegistrationTokenGroupVersionKind.Group, v3.ClusterRegistrationTokenResource.Name, "update", apiContext, data, apiContext.Schema); err != nil {
+		return err
+	}
+
+	namespace, name := ref.Parse(apiContext.ID)
+	crt, err := h.ClusterRegistrationTokens.GetNamespaced(namespace, name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	crt = crt.DeepCopy()
+	crt.Status.Token, err = randomtoken.Generate()
+	if err != nil {
+		return err
+	}
+	crt.Status.Uses = 0
+
+	if _, err := h.ClusterRegistrationTokens.Update(crt); err != nil {
+		return err
+	}
+
+	if err := access.ByID(apiContext, apiContext.Version, apiContext.Type, apiContext.ID, &data); err != nil {
+		return err
+	}
+
+	apiContext.WriteResponse(http.StatusOK, data)
+	return nil
+}
+
+func (h *Handler) Formatter(apiContext *types.APIContext, resource *types.RawResource) {
+	if err := apiContext.AccessControl.CanDo(v3.ClusterRegistrationTokenGroupVersionKind.Group, v3.ClusterRegistrationTokenResource.Name, "update", apiContext, resource.Values, apiContext.Schema); err == nil {
+		resource.AddAction(apiContext, v32.ClusterRegistrationTokenActionRotate)
+	}
+}
@@ -1,21 +1,26 @@
 package clusterregistrationtokens
 
 import (
+	"crypto/subtle"
 	"net/http"
+	"time"
 
 	"github.com/gorilla/mux"
 	"github.com/rancher/norman/types"
 	"github.com/rancher/norman/urlbuilder"
+	"github.com/rancher/rancher/pkg/clusterregistrationtoken"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/image"
 	schema "github.com/rancher/rancher/pkg/schemas/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/settings"
 	"github.com/rancher/rancher/pkg/systemtemplate"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 type ClusterImport struct {
-	Clusters v3.ClusterInterface
+	Clusters                       v3.ClusterInterface
+	ClusterRegistrationTokenLister v3.ClusterRegistrationTokenLister
 }
 
 func (ch *ClusterImport) ClusterImportHandler(resp http.ResponseWriter, req *http.Request) {
@@ -23,6 +28,12 @@ func (ch *ClusterImport) ClusterImportHandler(resp http.ResponseWriter, req *htt
 	token := mux.Vars(req)["token"]
 	clusterID := mux.Vars(req)["clusterId"]
 
+	if !ch.usableToken(clusterID, token) {
+		resp.WriteHeader(http.StatusUnauthorized)
+		resp.Write([]byte(clusterregistrationtoken.ErrNotUsable.Error()))
+		return
+	}
+
 	urlBuilder, err := urlbuilder.New(req, schema.Version, types.NewSchemas())
 	if err != nil {
 		resp.WriteHeader(500)
@@ -51,3 +62,26 @@ func (ch *ClusterImport) ClusterImportHandler(resp http.ResponseWriter, req *htt
 		resp.Write([]byte(err.Error()))
 	}
 }
+
+// usableToken returns true if token is the value of a registration token of the cluster that can still be used
+// to register agents
+func (ch *ClusterImport) usableToken(clusterID, token string) bool {
+	if clusterID == "" || token == "" {
+		return false
+	}
+
+	crts, err := ch.ClusterRegistrationTokenLister.List(clusterID, labels.Everything())
+	if err != nil {
+		return false
+	}
+
+	var candidates []*v3.ClusterRegistrationToken
+	for _, crt := range crts {
+		if subtle.ConstantTimeCompare([]byte(crt.Status.Token), []byte(token)) == 1 {
+			candidates = append(candidates, crt)
+		}
+	}
+
+	_, err = clusterregistrationtoken.Find(candidates, time.Now())
+	return err == nil
+}
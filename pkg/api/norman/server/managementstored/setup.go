@@ -14,6 +14,7 @@
 	"github.com/rancher/rancher/pkg/api/norman/customization/authn"
 	"github.com/rancher/rancher/pkg/api/norman/customization/catalog"
 	ccluster "github.com/rancher/rancher/pkg/api/norman/customization/cluster"
+	"github.com/rancher/rancher/pkg/api/norman/customization/clusterregistrationtokens"
 	"github.com/rancher/rancher/pkg/api/norman/customization/clusterscan"
 	"github.com/rancher/rancher/pkg/api/norman/customization/clustertemplate"
 	"github.com/rancher/rancher/pkg/api/norman/customization/cred"
@@ -398,6 +399,11 @@ func ClusterRegistrationTokens(schemas *types.Schemas, management *config.Scaled
 	schema.Store = &cluster.RegistrationTokenStore{
 		Store: schema.Store,
 	}
+	handler := &clusterregistrationtokens.Handler{
+		ClusterRegistrationTokens: management.Management.ClusterRegistrationTokens(""),
+	}
+	schema.ActionHandler = handler.ActionHandler
+	schema.Formatter = handler.Formatter
 }
 
 func Tokens(ctx context.Context, schemas *types.Schemas, mgmt *config.ScaledContext) {
@@ -581,6 +587,7 @@ func Alert(schemas *types.Schemas, management *config.ScaledContext) {
 	schema.CollectionFormatter = alert.NotifierCollectionFormatter
 	schema.Formatter = alert.NotifierFormatter
 	schema.ActionHandler = handler.NotifierActionHandler
+	schema.Validator = alert.NotifierValidator
 
 	schema = schemas.Schema(&managementschema.Version, client.ClusterAlertRuleType)
 	schema.Formatter = alert.RuleFormatter
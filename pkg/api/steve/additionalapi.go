@@ -6,6 +6,7 @@
 
 	gmux "github.com/gorilla/mux"
 	"github.com/rancher/rancher/pkg/api/steve/aggregation"
+	"github.com/rancher/rancher/pkg/api/steve/capacityreport"
 	"github.com/rancher/rancher/pkg/api/steve/github"
 	"github.com/rancher/rancher/pkg/api/steve/health"
 	"github.com/rancher/rancher/pkg/api/steve/projects"
@@ -56,6 +57,7 @@ func AdditionalAPIs(ctx context.Context, config *wrangler.Context, steve *steve.
 	mux := gmux.NewRouter()
 	mux.UseEncodedPath()
 	mux.Handle("/v1/github{path:.*}", githubHandler)
+	mux.Handle(capacityreport.Path, capacityreport.New(config, nil)).Methods(http.MethodGet)
 	mux.Handle("/v3/connect", Tunnel(config))
 	health.Register(mux)
 
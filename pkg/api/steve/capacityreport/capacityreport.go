@@ -0,0 +1,368 @@
+package capacityreport
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+
+	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1alpha4"
+	mgmtcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	rocontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/wrangler"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/rancher/wrangler/pkg/data"
+	"github.com/rancher/wrangler/pkg/kv"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+const Path = "/v1/capacityreport"
+
+var (
+	regionFields  = []string{"region", "location", "zone", "datacenter"}
+	sizeFields    = []string{"instanceType", "size", "machineType", "flavorName", "vmSize"}
+	projectFields = []string{"project", "tenantName", "tenantId", "resourceGroup"}
+
+	clusterResource = schema.GroupResource{Group: "management.cattle.io", Resource: "clusters"}
+	nodeTemplateGVK = schema.GroupVersionKind{Group: "management.cattle.io", Version: "v3", Kind: "NodeTemplate"}
+)
+
+// Report is the number of machines created by node drivers, and their estimated monthly cost, grouped by
+// cluster, cloud credential, driver, region, cloud project and instance size.
+type Report struct {
+	Entries          []Entry `json:"entries"`
+	Machines         int     `json:"machines"`
+	UnpricedMachines int     `json:"unpricedMachines"`
+	MonthlyCost      float64 `json:"monthlyCost"`
+	// Warnings lists the machines that are counted without their driver config, which could not be read.
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+type Entry struct {
+	ClusterID       string `json:"clusterId"`
+	ClusterName     string `json:"clusterName"`
+	CloudCredential string `json:"cloudCredential,omitempty"`
+	Driver          string `json:"driver"`
+	Region          string `json:"region,omitempty"`
+	// Project is the project, tenant or resource group of the cloud provider, if the driver has one.
+	Project  string `json:"project,omitempty"`
+	Size     string `json:"size,omitempty"`
+	Machines int    `json:"machines"`
+	// MonthlyPrice is the price of one machine, both prices are only set if the pricing table has the size.
+	MonthlyPrice *float64 `json:"monthlyPrice,omitempty"`
+	MonthlyCost  *float64 `json:"monthlyCost,omitempty"`
+}
+
+// objectCache reads objects of any kind from the caches of the dynamic controller.
+type objectCache interface {
+	Get(gvk schema.GroupVersionKind, namespace, name string) (runtime.Object, error)
+}
+
+type handler struct {
+	asl                  accesscontrol.AccessSetLookup
+	pricing              Pricing
+	dynamic              objectCache
+	clusters             mgmtcontrollers.ClusterCache
+	nodes                mgmtcontrollers.NodeCache
+	provisioningClusters rocontrollers.ClusterCache
+	machines             capicontrollers.MachineCache
+}
+
+// New returns the handler of the capacity report, the pricing defaults to the table of the
+// capacity-report-pricing config map.
+func New(clients *wrangler.Context, pricing Pricing) http.Handler {
+	if pricing == nil {
+		pricing = NewConfigMapPricing(clients.Core.ConfigMap().Cache())
+	}
+	return &handler{
+		asl:                  clients.ASL,
+		pricing:              pricing,
+		dynamic:              clients.Dynamic,
+		clusters:             clients.Mgmt.Cluster().Cache(),
+		nodes:                clients.Mgmt.Node().Cache(),
+		provisioningClusters: clients.Provisioning.Cluster().Cache(),
+		machines:             clients.CAPI.Machine().Cache(),
+	}
+}
+
+func (h *handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	user, ok := request.UserFrom(req.Context())
+	if !ok {
+		http.Error(rw, "must authenticate", http.StatusUnauthorized)
+		return
+	}
+
+	report, err := h.report(h.asl.AccessFor(user))
+	if err != nil {
+		logrus.Errorf("failed to build capacity report: %v", err)
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	if req.URL.Query().Get("format") == "csv" {
+		rw.Header().Set("Content-Type", "text/csv")
+		rw.Header().Set("Content-Disposition", "attachment; filename=capacity-report.csv")
+		if err := writeCSV(rw, report); err != nil {
+			logrus.Errorf("failed to write capacity report: %v", err)
+		}
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(report); err != nil {
+		logrus.Errorf("failed to write capacity report: %v", err)
+	}
+}
+
+type machine struct {
+	clusterID       string
+	cloudCredential string
+	driver          string
+	config          data.Object
+}
+
+func (h *handler) report(access *accesscontrol.AccessSet) (*Report, error) {
+	clusters, err := h.clusters.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	clusterNames := map[string]string{}
+	for _, cluster := range clusters {
+		if access.Grants("get", clusterResource, "", cluster.Name) {
+			clusterNames[cluster.Name] = cluster.Spec.DisplayName
+		}
+	}
+
+	report := &Report{}
+	machines, err := h.provisioningMachines(clusterNames, report)
+	if err != nil {
+		return nil, err
+	}
+	nodes, err := h.nodeTemplateMachines(clusterNames, report)
+	if err != nil {
+		return nil, err
+	}
+	machines = append(machines, nodes...)
+
+	entries := map[Entry]int{}
+	for _, machine := range machines {
+		key := Entry{
+			ClusterID:       machine.clusterID,
+			ClusterName:     clusterNames[machine.clusterID],
+			CloudCredential: machine.cloudCredential,
+			Driver:          machine.driver,
+			Region:          firstString(machine.config, regionFields),
+			Project:         firstString(machine.config, projectFields),
+			Size:            getSize(machine.config),
+		}
+		entries[key]++
+	}
+
+	for entry, count := range entries {
+		entry.Machines = count
+		report.Machines += count
+
+		price, ok, err := h.pricing.MonthlyPrice(entry.Driver, entry.Region, entry.Size)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			cost := price * float64(count)
+			entry.MonthlyPrice = &price
+			entry.MonthlyCost = &cost
+			report.MonthlyCost += cost
+		} else {
+			report.UnpricedMachines += count
+		}
+		report.Entries = append(report.Entries, entry)
+	}
+
+	sort.Slice(report.Entries, func(i, j int) bool {
+		return entryKey(report.Entries[i]) < entryKey(report.Entries[j])
+	})
+	return report, nil
+}
+
+// provisioningMachines returns the machines of provisioning v2 clusters, their size is read from the
+// infrastructure machine, which holds the machine config of the pool.
+func (h *handler) provisioningMachines(clusterNames map[string]string, report *Report) ([]machine, error) {
+	clusters, err := h.provisioningClusters.List("", labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var result []machine
+	for _, cluster := range clusters {
+		if _, ok := clusterNames[cluster.Status.ClusterName]; !ok || cluster.Spec.RKEConfig == nil {
+			continue
+		}
+
+		capiMachines, err := h.machines.List(cluster.Namespace, labels.SelectorFromSet(map[string]string{
+			capi.ClusterLabelName: cluster.Name,
+		}))
+		if err != nil {
+			return nil, err
+		}
+
+		for _, capiMachine := range capiMachines {
+			ref := capiMachine.Spec.InfrastructureRef
+			gvk := schema.FromAPIVersionAndKind(ref.APIVersion, ref.Kind)
+			if gvk.Group != "rke-machine.cattle.io" || gvk.Kind == "CustomMachine" {
+				continue
+			}
+
+			driver := strings.ToLower(strings.TrimSuffix(gvk.Kind, "Machine"))
+			infraMachine, err := h.getObject(gvk, capiMachine.Namespace, ref.Name)
+			if err != nil {
+				report.warn("machine %s/%s is counted without its size, failed to get %s %s: %v",
+					capiMachine.Namespace, capiMachine.Name, gvk.Kind, ref.Name, err)
+				result = append(result, machine{
+					clusterID:       cluster.Status.ClusterName,
+					cloudCredential: cluster.Spec.CloudCredentialSecretName,
+					driver:          driver,
+				})
+				continue
+			}
+
+			cloudCredential := infraMachine.String("status", "cloudCredentialSecretName")
+			if cloudCredential == "" {
+				cloudCredential = infraMachine.String("spec", "common", "cloudCredentialSecretName")
+			}
+			if cloudCredential == "" {
+				cloudCredential = cluster.Spec.CloudCredentialSecretName
+			}
+
+			result = append(result, machine{
+				clusterID:       cluster.Status.ClusterName,
+				cloudCredential: cloudCredential,
+				driver:          driver,
+				config:          infraMachine.Map("spec"),
+			})
+		}
+	}
+
+	return result, nil
+}
+
+func (h *handler) getObject(gvk schema.GroupVersionKind, namespace, name string) (data.Object, error) {
+	obj, err := h.dynamic.Get(gvk, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return data.Convert(obj)
+}
+
+// nodeTemplateMachines returns the nodes of RKE1 node pools, their size is read from the driver config
+// of the node template.
+func (h *handler) nodeTemplateMachines(clusterNames map[string]string, report *Report) ([]machine, error) {
+	nodeTemplates := map[string]data.Object{}
+	var result []machine
+	for clusterID := range clusterNames {
+		nodes, err := h.nodes.List(clusterID, labels.Everything())
+		if err != nil {
+			return nil, err
+		}
+
+		for _, node := range nodes {
+			if node.Spec.NodeTemplateName == "" {
+				continue
+			}
+
+			nodeTemplate, ok := nodeTemplates[node.Spec.NodeTemplateName]
+			if !ok {
+				namespace, name := kv.Split(node.Spec.NodeTemplateName, ":")
+				nodeTemplate, err = h.getObject(nodeTemplateGVK, namespace, name)
+				if err != nil {
+					report.warn("nodes of node template %s are counted without their driver and size, failed to get the node template: %v",
+						node.Spec.NodeTemplateName, err)
+				}
+				nodeTemplates[node.Spec.NodeTemplateName] = nodeTemplate
+			}
+
+			driver := nodeTemplate.String("spec", "driver")
+			result = append(result, machine{
+				clusterID:       clusterID,
+				cloudCredential: nodeTemplate.String("spec", "cloudCredentialName"),
+				driver:          driver,
+				config:          nodeTemplate.Map(driver + "Config"),
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// warn adds a warning to the report, the machine it is about is still counted.
+func (r *Report) warn(format string, args ...interface{}) {
+	warning := fmt.Sprintf(format, args...)
+	logrus.Warnf("capacity report: %s", warning)
+	r.Warnings = append(r.Warnings, warning)
+}
+
+func firstString(config data.Object, fields []string) string {
+	for _, field := range fields {
+		if value := config.String(field); value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+// getSize returns the instance type of the machine or, for drivers without instance types like vSphere,
+// the number of CPUs and memory.
+func getSize(config data.Object) string {
+	if size := firstString(config, sizeFields); size != "" {
+		return size
+	}
+	cpu, memory := config.String("cpuCount"), config.String("memorySize")
+	if cpu == "" && memory == "" {
+		return ""
+	}
+	return fmt.Sprintf("cpu-%s-memory-%s", cpu, memory)
+}
+
+func entryKey(entry Entry) string {
+	return strings.Join([]string{entry.ClusterID, entry.CloudCredential, entry.Driver, entry.Region, entry.Project, entry.Size}, "/")
+}
+
+func writeCSV(rw http.ResponseWriter, report *Report) error {
+	w := csv.NewWriter(rw)
+	if err := w.Write([]string{"clusterId", "clusterName", "cloudCredential", "driver", "region", "project", "size",
+		"machines", "monthlyPrice", "monthlyCost"}); err != nil {
+		return err
+	}
+	for _, entry := range report.Entries {
+		if err := w.Write([]string{
+			entry.ClusterID,
+			entry.ClusterName,
+			entry.CloudCredential,
+			entry.Driver,
+			entry.Region,
+			entry.Project,
+			entry.Size,
+			strconv.Itoa(entry.Machines),
+			formatPrice(entry.MonthlyPrice),
+			formatPrice(entry.MonthlyCost),
+		}); err != nil {
+			return err
+		}
+	}
+	w.Flush()
+	return w.Error()
+}
+
+func formatPrice(price *float64) string {
+	if price == nil {
+		return ""
+	}
+	return strconv.FormatFloat(*price, 'f', 2, 64)
+}
@@ -0,0 +1,225 @@
+package capacityreport
+
+import (
+	"testing"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1alpha4"
+	mgmtcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	rocontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+type fakeClusterCache struct {
+	mgmtcontrollers.ClusterCache
+	clusters []*v3.Cluster
+}
+
+func (f fakeClusterCache) List(selector labels.Selector) ([]*v3.Cluster, error) {
+	return f.clusters, nil
+}
+
+type fakeNodeCache struct {
+	mgmtcontrollers.NodeCache
+	nodes []*v3.Node
+}
+
+func (f fakeNodeCache) List(namespace string, selector labels.Selector) ([]*v3.Node, error) {
+	var result []*v3.Node
+	for _, node := range f.nodes {
+		if node.Namespace == namespace {
+			result = append(result, node)
+		}
+	}
+	return result, nil
+}
+
+type fakeProvisioningClusterCache struct {
+	rocontrollers.ClusterCache
+	clusters []*rancherv1.Cluster
+}
+
+func (f fakeProvisioningClusterCache) List(namespace string, selector labels.Selector) ([]*rancherv1.Cluster, error) {
+	return f.clusters, nil
+}
+
+type fakeMachineCache struct {
+	capicontrollers.MachineCache
+	machines []*capi.Machine
+}
+
+func (f fakeMachineCache) List(namespace string, selector labels.Selector) ([]*capi.Machine, error) {
+	var result []*capi.Machine
+	for _, machine := range f.machines {
+		if machine.Namespace == namespace && selector.Matches(labels.Set(machine.Labels)) {
+			result = append(result, machine)
+		}
+	}
+	return result, nil
+}
+
+type fakeObjectCache map[string]runtime.Object
+
+func (f fakeObjectCache) Get(gvk schema.GroupVersionKind, namespace, name string) (runtime.Object, error) {
+	if obj, ok := f[gvk.Kind+"/"+namespace+"/"+name]; ok {
+		return obj, nil
+	}
+	return nil, apierror.NewNotFound(schema.GroupResource{Group: gvk.Group, Resource: gvk.Kind}, name)
+}
+
+type fakePricing map[string]float64
+
+func (f fakePricing) MonthlyPrice(driver, region, size string) (float64, bool, error) {
+	price, ok := f[priceKey(driver, region, size)]
+	return price, ok, nil
+}
+
+func newCAPIMachine(cluster, name, kind string) *capi.Machine {
+	return &capi.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "fleet-default",
+			Labels:    map[string]string{capi.ClusterLabelName: cluster},
+		},
+		Spec: capi.MachineSpec{
+			InfrastructureRef: corev1.ObjectReference{
+				APIVersion: "rke-machine.cattle.io/v1",
+				Kind:       kind,
+				Name:       name,
+			},
+		},
+	}
+}
+
+func newObject(obj map[string]interface{}) runtime.Object {
+	return &unstructured.Unstructured{Object: obj}
+}
+
+func newHandler() *handler {
+	return &handler{
+		pricing: fakePricing{
+			"amazonec2/us-east-1/t3.medium": 30,
+			"digitalocean/nyc1/s-2vcpu-4gb": 24,
+		},
+		clusters: fakeClusterCache{clusters: []*v3.Cluster{
+			{ObjectMeta: metav1.ObjectMeta{Name: "c-m-1"}, Spec: v3.ClusterSpec{DisplayName: "rke2"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "c-2"}, Spec: v3.ClusterSpec{DisplayName: "rke1"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "c-3"}, Spec: v3.ClusterSpec{DisplayName: "hidden"}},
+		}},
+		provisioningClusters: fakeProvisioningClusterCache{clusters: []*rancherv1.Cluster{
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "rke2", Namespace: "fleet-default"},
+				Spec: rancherv1.ClusterSpec{
+					CloudCredentialSecretName: "cattle-global-data:cc-aws",
+					RKEConfig:                 &rancherv1.RKEConfig{},
+				},
+				Status: rancherv1.ClusterStatus{ClusterName: "c-m-1"},
+			},
+			{
+				ObjectMeta: metav1.ObjectMeta{Name: "hidden", Namespace: "fleet-default"},
+				Spec:       rancherv1.ClusterSpec{RKEConfig: &rancherv1.RKEConfig{}},
+				Status:     rancherv1.ClusterStatus{ClusterName: "c-3"},
+			},
+		}},
+		machines: fakeMachineCache{machines: []*capi.Machine{
+			newCAPIMachine("rke2", "m1", "Amazonec2Machine"),
+			newCAPIMachine("rke2", "m2", "Amazonec2Machine"),
+			newCAPIMachine("rke2", "m3", "Amazonec2Machine"),
+			newCAPIMachine("rke2", "custom", "CustomMachine"),
+			newCAPIMachine("hidden", "h1", "Amazonec2Machine"),
+		}},
+		nodes: fakeNodeCache{nodes: []*v3.Node{
+			{ObjectMeta: metav1.ObjectMeta{Name: "n1", Namespace: "c-2"}, Spec: v3.NodeSpec{NodeTemplateName: "cattle-global-nt:nt-do"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "n2", Namespace: "c-2"}, Spec: v3.NodeSpec{NodeTemplateName: "cattle-global-nt:nt-do"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "n3", Namespace: "c-2"}, Spec: v3.NodeSpec{NodeTemplateName: "cattle-global-nt:nt-missing"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "imported", Namespace: "c-2"}},
+		}},
+		dynamic: fakeObjectCache{
+			"Amazonec2Machine/fleet-default/m1": newObject(map[string]interface{}{
+				"spec": map[string]interface{}{"region": "us-east-1", "instanceType": "t3.medium"},
+			}),
+			"Amazonec2Machine/fleet-default/m2": newObject(map[string]interface{}{
+				"spec":   map[string]interface{}{"region": "us-east-1", "instanceType": "t3.medium"},
+				"status": map[string]interface{}{"cloudCredentialSecretName": "cattle-global-data:cc-aws"},
+			}),
+			"Amazonec2Machine/fleet-default/h1": newObject(map[string]interface{}{
+				"spec": map[string]interface{}{"region": "us-east-1", "instanceType": "t3.medium"},
+			}),
+			"NodeTemplate/cattle-global-nt/nt-do": newObject(map[string]interface{}{
+				"spec":               map[string]interface{}{"driver": "digitalocean", "cloudCredentialName": "cattle-global-data:cc-do"},
+				"digitaloceanConfig": map[string]interface{}{"region": "nyc1", "size": "s-2vcpu-4gb"},
+			}),
+		},
+	}
+}
+
+func TestReport(t *testing.T) {
+	access := &accesscontrol.AccessSet{}
+	access.Add("get", clusterResource, accesscontrol.Access{Namespace: accesscontrol.All, ResourceName: "c-m-1"})
+	access.Add("get", clusterResource, accesscontrol.Access{Namespace: accesscontrol.All, ResourceName: "c-2"})
+
+	report, err := newHandler().report(access)
+	require.NoError(t, err)
+
+	price := func(f float64) *float64 { return &f }
+	assert.Equal(t, []Entry{
+		{
+			ClusterID:   "c-2",
+			ClusterName: "rke1",
+			Machines:    1,
+		},
+		{
+			ClusterID:       "c-2",
+			ClusterName:     "rke1",
+			CloudCredential: "cattle-global-data:cc-do",
+			Driver:          "digitalocean",
+			Region:          "nyc1",
+			Size:            "s-2vcpu-4gb",
+			Machines:        2,
+			MonthlyPrice:    price(24),
+			MonthlyCost:     price(48),
+		},
+		{
+			ClusterID:       "c-m-1",
+			ClusterName:     "rke2",
+			CloudCredential: "cattle-global-data:cc-aws",
+			Driver:          "amazonec2",
+			Machines:        1,
+		},
+		{
+			ClusterID:       "c-m-1",
+			ClusterName:     "rke2",
+			CloudCredential: "cattle-global-data:cc-aws",
+			Driver:          "amazonec2",
+			Region:          "us-east-1",
+			Size:            "t3.medium",
+			Machines:        2,
+			MonthlyPrice:    price(30),
+			MonthlyCost:     price(60),
+		},
+	}, report.Entries)
+	assert.Equal(t, 6, report.Machines)
+	assert.Equal(t, 2, report.UnpricedMachines)
+	assert.Equal(t, float64(108), report.MonthlyCost)
+	// the machines without a config are counted with a warning
+	assert.Len(t, report.Warnings, 2)
+	assert.Contains(t, report.Warnings[0], "fleet-default/m3")
+	assert.Contains(t, report.Warnings[1], "cattle-global-nt:nt-missing")
+}
+
+func TestGetSize(t *testing.T) {
+	assert.Equal(t, "t3.medium", getSize(map[string]interface{}{"instanceType": "t3.medium", "cpuCount": "2"}))
+	assert.Equal(t, "cpu-2-memory-4096", getSize(map[string]interface{}{"cpuCount": "2", "memorySize": "4096"}))
+	assert.Equal(t, "", getSize(map[string]interface{}{}))
+}
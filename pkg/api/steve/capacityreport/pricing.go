@@ -0,0 +1,99 @@
+package capacityreport
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+
+	namespaces "github.com/rancher/rancher/pkg/namespace"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	apierror "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	PricingConfigMapName = "capacity-report-pricing"
+	PricingConfigMapKey  = "pricing.csv"
+)
+
+// Pricing returns the estimated monthly price of a machine of a driver, region and size.
+type Pricing interface {
+	MonthlyPrice(driver, region, size string) (float64, bool, error)
+}
+
+// configMapPricing reads a pricing table in CSV format from the capacity-report-pricing config map in
+// cattle-system. The columns are driver, region, size and monthlyPrice, a region of * matches every region.
+type configMapPricing struct {
+	sync.Mutex
+
+	configMaps      corecontrollers.ConfigMapCache
+	resourceVersion string
+	prices          map[string]float64
+}
+
+func NewConfigMapPricing(configMaps corecontrollers.ConfigMapCache) Pricing {
+	return &configMapPricing{
+		configMaps: configMaps,
+	}
+}
+
+func (c *configMapPricing) MonthlyPrice(driver, region, size string) (float64, bool, error) {
+	configMap, err := c.configMaps.Get(namespaces.System, PricingConfigMapName)
+	if apierror.IsNotFound(err) {
+		return 0, false, nil
+	} else if err != nil {
+		return 0, false, err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if c.resourceVersion != configMap.ResourceVersion {
+		prices, err := parsePricing(strings.NewReader(configMap.Data[PricingConfigMapKey]))
+		if err != nil {
+			return 0, false, fmt.Errorf("invalid pricing table in config map %s/%s: %w", namespaces.System, PricingConfigMapName, err)
+		}
+		c.prices = prices
+		c.resourceVersion = configMap.ResourceVersion
+	}
+	prices := c.prices
+
+	if price, ok := prices[priceKey(driver, region, size)]; ok {
+		return price, true, nil
+	}
+	price, ok := prices[priceKey(driver, "*", size)]
+	return price, ok, nil
+}
+
+func priceKey(driver, region, size string) string {
+	return strings.ToLower(driver + "/" + region + "/" + size)
+}
+
+func parsePricing(r io.Reader) (map[string]float64, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.TrimLeadingSpace = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+
+	prices := map[string]float64{}
+	for i, record := range records {
+		if len(record) != 4 {
+			return nil, fmt.Errorf("line %d: expected driver,region,size,monthlyPrice", i+1)
+		}
+		if i == 0 && strings.EqualFold(record[3], "monthlyPrice") {
+			continue
+		}
+		price, err := strconv.ParseFloat(record[3], 64)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: invalid monthlyPrice %q", i+1, record[3])
+		}
+		prices[priceKey(record[0], record[1], record[2])] = price
+	}
+	return prices, nil
+}
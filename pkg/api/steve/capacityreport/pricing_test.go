@@ -0,0 +1,122 @@
+package capacityreport
+
+import (
+	"strings"
+	"testing"
+
+	namespaces "github.com/rancher/rancher/pkg/namespace"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type fakeConfigMapCache struct {
+	corecontrollers.ConfigMapCache
+	configMap *corev1.ConfigMap
+}
+
+func (f *fakeConfigMapCache) Get(namespace, name string) (*corev1.ConfigMap, error) {
+	if f.configMap == nil || namespace != namespaces.System || name != PricingConfigMapName {
+		return nil, apierror.NewNotFound(schema.GroupResource{Resource: "configmaps"}, name)
+	}
+	return f.configMap, nil
+}
+
+func newPricingConfigMap(resourceVersion, table string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            PricingConfigMapName,
+			Namespace:       namespaces.System,
+			ResourceVersion: resourceVersion,
+		},
+		Data: map[string]string{PricingConfigMapKey: table},
+	}
+}
+
+func TestParsePricing(t *testing.T) {
+	prices, err := parsePricing(strings.NewReader(`driver,region,size,monthlyPrice
+# a comment
+amazonec2, us-east-1, t3.medium, 30.37
+Amazonec2,*,t3.large,60.74
+`))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{
+		"amazonec2/us-east-1/t3.medium": 30.37,
+		"amazonec2/*/t3.large":          60.74,
+	}, prices)
+
+	prices, err = parsePricing(strings.NewReader("digitalocean,nyc1,s-2vcpu-4gb,24\n"))
+	require.NoError(t, err)
+	assert.Equal(t, map[string]float64{"digitalocean/nyc1/s-2vcpu-4gb": 24}, prices)
+
+	prices, err = parsePricing(strings.NewReader(""))
+	require.NoError(t, err)
+	assert.Empty(t, prices)
+}
+
+func TestParsePricingErrors(t *testing.T) {
+	tests := map[string]string{
+		"missing column":   "amazonec2,us-east-1,t3.medium\n",
+		"invalid price":    "amazonec2,us-east-1,t3.medium,thirty\n",
+		"header not first": "amazonec2,us-east-1,t3.medium,30\ndriver,region,size,monthlyPrice\n",
+		"unbalanced quote": "amazonec2,\"us-east-1,t3.medium,30\n",
+	}
+	for name, table := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := parsePricing(strings.NewReader(table))
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMonthlyPrice(t *testing.T) {
+	configMaps := &fakeConfigMapCache{}
+	pricing := NewConfigMapPricing(configMaps)
+
+	// no pricing table
+	_, ok, err := pricing.MonthlyPrice("amazonec2", "us-east-1", "t3.medium")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	configMaps.configMap = newPricingConfigMap("1", `amazonec2,us-east-1,t3.medium,30
+amazonec2,*,t3.medium,35
+amazonec2,eu-west-1,t3.large,70
+`)
+
+	tests := []struct {
+		name   string
+		region string
+		size   string
+		price  float64
+		ok     bool
+	}{
+		{name: "region", region: "us-east-1", size: "t3.medium", price: 30, ok: true},
+		{name: "region is case insensitive", region: "US-EAST-1", size: "T3.Medium", price: 30, ok: true},
+		{name: "fallback to every region", region: "us-west-2", size: "t3.medium", price: 35, ok: true},
+		{name: "no fallback", region: "us-west-2", size: "t3.large"},
+		{name: "unknown size", region: "us-east-1", size: "t3.xlarge"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			price, ok, err := pricing.MonthlyPrice("amazonec2", tt.region, tt.size)
+			require.NoError(t, err)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.price, price)
+		})
+	}
+
+	// the table is parsed again when the config map changes
+	configMaps.configMap = newPricingConfigMap("2", "amazonec2,us-east-1,t3.medium,32\n")
+	price, ok, err := pricing.MonthlyPrice("amazonec2", "us-east-1", "t3.medium")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, float64(32), price)
+
+	configMaps.configMap = newPricingConfigMap("3", "amazonec2,us-east-1\n")
+	_, _, err = pricing.MonthlyPrice("amazonec2", "us-east-1", "t3.medium")
+	assert.Error(t, err)
+}
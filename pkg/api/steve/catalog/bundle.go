@@ -0,0 +1,111 @@
+package catalog
+
+import (
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/rancher/pkg/catalogv2/bundle"
+	corev1controllers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+)
+
+// maxBundleSize limits the size of an uploaded bundle, which is stored in ConfigMaps
+const maxBundleSize = 100 << 20
+
+// bundleImport handles the importBundle action of ClusterRepos. The uploaded bundle is validated,
+// a ClusterRepo serving it is created as the requesting user and the bundle is stored in ConfigMaps
+// owned by the repo, from which every Rancher server extracts it.
+type bundleImport struct {
+	configMaps corev1controllers.ConfigMapClient
+}
+
+func (b *bundleImport) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	apiContext := types.GetAPIContext(req.Context())
+	obj, err := b.importBundle(apiContext, rw, req)
+	if err != nil {
+		apiContext.WriteError(err)
+		return
+	}
+	apiContext.WriteResponse(http.StatusCreated, obj)
+}
+
+func (b *bundleImport) importBundle(apiContext *types.APIRequest, rw http.ResponseWriter, req *http.Request) (types.APIObject, error) {
+	if err := apiContext.AccessControl.CanCreate(apiContext, apiContext.Schema); err != nil {
+		return types.APIObject{}, err
+	}
+
+	name := req.URL.Query().Get("name")
+	if errs := k8svalidation.IsDNS1123Subdomain(name); len(errs) > 0 {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidFormat, "invalid name: "+errs[0])
+	}
+
+	if _, err := apiContext.Schema.Store.ByID(apiContext, apiContext.Schema, name); err == nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.Conflict, "repo "+name+" already exists")
+	}
+
+	// the upload is kept in a temporary file while it is validated by extracting it, so it can be stored once
+	// it is known to be valid
+	upload, err := ioutil.TempFile("", "bundle-")
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	defer func() {
+		upload.Close()
+		os.Remove(upload.Name())
+	}()
+
+	validationDir, err := ioutil.TempDir("", "bundle-")
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	defer os.RemoveAll(validationDir)
+
+	body := io.TeeReader(http.MaxBytesReader(rw, req.Body, maxBundleSize), upload)
+	if err := bundle.Import(body, filepath.Join(validationDir, name)); err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+	// the end of the compressed stream is not read by the extraction
+	if _, err := io.Copy(ioutil.Discard, body); err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+	size, err := upload.Seek(0, io.SeekCurrent)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+
+	obj, err := apiContext.Schema.Store.Create(apiContext, apiContext.Schema, types.APIObject{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name": name,
+			},
+			"spec": map[string]interface{}{
+				"url": bundle.URL(name),
+			},
+		},
+	})
+	if err != nil {
+		return types.APIObject{}, err
+	}
+
+	metadata := &metav1.ObjectMeta{
+		Name: name,
+		UID:  k8stypes.UID(obj.Data().String("metadata", "uid")),
+	}
+	if err := bundle.Store(b.configMaps, metadata, upload, size); err != nil {
+		// the ConfigMaps stored so far are deleted with the repo
+		if _, deleteErr := apiContext.Schema.Store.Delete(apiContext, apiContext.Schema, name); deleteErr != nil {
+			logrus.Errorf("failed to delete repo %s of bundle that could not be stored: %v", name, deleteErr)
+		}
+		return types.APIObject{}, err
+	}
+	return obj, nil
+}
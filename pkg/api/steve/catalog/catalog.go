@@ -13,13 +13,15 @@
 	"github.com/rancher/rancher/pkg/catalogv2/helmop"
 	schema2 "github.com/rancher/steve/pkg/schema"
 	steve "github.com/rancher/steve/pkg/server"
+	corev1controllers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	schemas3 "github.com/rancher/wrangler/pkg/schemas"
 	"github.com/rancher/wrangler/pkg/schemas/validation"
 )
 
 func Register(ctx context.Context, server *steve.Server,
 	helmop *helmop.Operations,
-	contentManager *content.Manager) error {
+	contentManager *content.Manager,
+	configMaps corev1controllers.ConfigMapClient) error {
 	ops := newOperation(helmop)
 	server.ClusterCache.OnAdd(ctx, ops.OnAdd)
 	server.ClusterCache.OnChange(ctx, ops.OnChange)
@@ -28,11 +30,13 @@ func Register(ctx context.Context, server *steve.Server,
 		contentManager: contentManager,
 	}
 
-	addSchemas(server, ops, index)
+	addSchemas(server, ops, index, &bundleImport{
+		configMaps: configMaps,
+	})
 	return nil
 }
 
-func addSchemas(server *steve.Server, ops *operation, index http.Handler) {
+func addSchemas(server *steve.Server, ops *operation, index http.Handler, importBundle http.Handler) {
 	server.BaseSchemas.MustImportAndCustomize(types2.ChartUninstallAction{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(types2.ChartUpgradeAction{}, nil)
 	server.BaseSchemas.MustImportAndCustomize(types2.ChartUpgrade{}, nil)
@@ -98,21 +102,42 @@ func addSchemas(server *steve.Server, ops *operation, index http.Handler) {
 				return handlers.ByIDHandler(request)
 			}
 			apiSchema.LinkHandlers = map[string]http.Handler{
-				"index": index,
-				"info":  index,
-				"chart": index,
-				"icon":  responsewriter.ContentType(index),
+				"index":  index,
+				"info":   index,
+				"chart":  index,
+				"icon":   responsewriter.ContentType(index),
+				"bundle": index,
 			}
 		},
 	}
 	chartRepoTemplate := repoTemplate
 	chartRepoTemplate.Kind = "ClusterRepo"
+	chartRepoTemplate.Customize = func(apiSchema *types.APISchema) {
+		repoTemplate.Customize(apiSchema)
+		apiSchema.ActionHandlers["importBundle"] = importBundle
+		apiSchema.CollectionActions = map[string]schemas3.Action{
+			"importBundle": {
+				Output: "catalog.cattle.io.clusterrepo",
+			},
+		}
+	}
+
+	policyTemplate := schema2.Template{
+		Group: catalog.GroupName,
+		Kind:  "ChartPolicy",
+		StoreFactory: func(innerStore types.Store) types.Store {
+			return &policyStore{
+				Store: innerStore,
+			}
+		},
+	}
 
 	server.SchemaFactory.AddTemplate(
 		operationTemplate,
 		appTemplate,
 		repoTemplate,
-		chartRepoTemplate)
+		chartRepoTemplate,
+		policyTemplate)
 }
 
 func isClusterRepo(typeName string) bool {
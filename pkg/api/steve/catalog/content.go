@@ -7,9 +7,12 @@
 	"net/http"
 	"net/url"
 
+	"github.com/rancher/apiserver/pkg/apierror"
 	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/rancher/pkg/catalogv2/bundle"
 	"github.com/rancher/rancher/pkg/catalogv2/content"
 	"github.com/rancher/wrangler/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
 	"helm.sh/helm/v3/pkg/repo"
 )
 
@@ -36,6 +39,10 @@ func (i *contentDownload) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		if err := i.serveIcon(apiContext, rw, req); err != nil {
 			apiContext.WriteError(err)
 		}
+	case "bundle":
+		if err := i.serveBundle(apiContext, rw, req); err != nil {
+			apiContext.WriteError(err)
+		}
 	}
 }
 
@@ -123,6 +130,31 @@ func (i *contentDownload) serveChart(apiContext *types.APIRequest, rw http.Respo
 	return err
 }
 
+func (i *contentDownload) serveBundle(apiContext *types.APIRequest, rw http.ResponseWriter, req *http.Request) error {
+	index, err := i.getIndex(apiContext)
+	if err != nil {
+		return err
+	}
+
+	namespace, name := nsAndName(apiContext)
+	selection := bundle.ParseSelection(apiContext.Request.URL.Query()["chart"])
+	if err := bundle.Validate(index, selection); err != nil {
+		return apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+
+	// The bundle is streamed, once the headers are written an error can only be logged. The tarball
+	// is left without its gzip trailer so clients fail to read it.
+	rw.Header().Set("Content-Type", "application/gzip")
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=\"%s-bundle.tgz\"", name))
+	err = bundle.Export(rw, index, selection, func(chartName, version string) (io.ReadCloser, error) {
+		return i.contentManager.Chart(namespace, name, chartName, version)
+	})
+	if err != nil {
+		logrus.Errorf("failed to export bundle of repo %s: %v", name, err)
+	}
+	return nil
+}
+
 func (i *contentDownload) getIndex(apiContext *types.APIRequest) (*repo.IndexFile, error) {
 	return i.contentManager.Index(nsAndName(apiContext))
 }
@@ -0,0 +1,40 @@
+package catalog
+
+import (
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/catalogv2/content"
+	"github.com/rancher/wrangler/pkg/data/convert"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+)
+
+// policyStore rejects ChartPolicies with rules that can't be parsed
+type policyStore struct {
+	types.Store
+}
+
+func (s *policyStore) Create(apiOp *types.APIRequest, schema *types.APISchema, data types.APIObject) (types.APIObject, error) {
+	if err := validatePolicy(data); err != nil {
+		return types.APIObject{}, err
+	}
+	return s.Store.Create(apiOp, schema, data)
+}
+
+func (s *policyStore) Update(apiOp *types.APIRequest, schema *types.APISchema, data types.APIObject, id string) (types.APIObject, error) {
+	if err := validatePolicy(data); err != nil {
+		return types.APIObject{}, err
+	}
+	return s.Store.Update(apiOp, schema, data, id)
+}
+
+func validatePolicy(data types.APIObject) error {
+	spec := v1.ChartPolicySpec{}
+	if err := convert.ToObj(data.Data().Map("spec"), &spec); err != nil {
+		return apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+	if err := content.ValidatePolicy(spec); err != nil {
+		return apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+	return nil
+}
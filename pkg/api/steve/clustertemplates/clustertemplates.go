@@ -0,0 +1,60 @@
+package clustertemplates
+
+import (
+	"net/http"
+
+	"github.com/rancher/apiserver/pkg/types"
+	gaccess "github.com/rancher/rancher/pkg/api/norman/customization/globalnamespaceaccess"
+	managementv3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/wrangler"
+	schema2 "github.com/rancher/steve/pkg/schema"
+	steve "github.com/rancher/steve/pkg/server"
+	"github.com/rancher/wrangler/pkg/schemas"
+)
+
+type UpgradeToRevisionInput struct {
+	ClusterTemplateRevisionName string `json:"clusterTemplateRevisionName,omitempty"`
+}
+
+func Register(server *steve.Server, clients *wrangler.Context) error {
+	management, err := managementv3.NewFromControllerFactory(clients.ControllerFactory)
+	if err != nil {
+		return err
+	}
+	memberAccess := &gaccess.MemberAccess{
+		Users:     management.Users(""),
+		GrLister:  management.GlobalRoles("").Controller().Lister(),
+		GrbLister: management.GlobalRoleBindings("").Controller().Lister(),
+	}
+
+	upgrade := &upgradeToRevision{
+		clusters:      clients.Provisioning.Cluster(),
+		revisionCache: clients.Provisioning.ClusterTemplateRevision().Cache(),
+	}
+
+	server.BaseSchemas.MustImportAndCustomize(UpgradeToRevisionInput{}, nil)
+	server.SchemaFactory.AddTemplate(schema2.Template{
+		Group: "provisioning.cattle.io",
+		Kind:  "Cluster",
+		Customize: func(schema *types.APISchema) {
+			if schema.ActionHandlers == nil {
+				schema.ActionHandlers = map[string]http.Handler{}
+			}
+			schema.ActionHandlers["upgradeToRevision"] = upgrade
+			if schema.ResourceActions == nil {
+				schema.ResourceActions = map[string]schemas.Action{}
+			}
+			schema.ResourceActions["upgradeToRevision"] = schemas.Action{
+				Input: "upgradeToRevisionInput",
+			}
+		},
+		StoreFactory: func(innerStore types.Store) types.Store {
+			return &store{
+				Store:         innerStore,
+				revisionCache: clients.Provisioning.ClusterTemplateRevision().Cache(),
+				memberAccess:  memberAccess,
+			}
+		},
+	})
+	return nil
+}
@@ -0,0 +1,161 @@
+package clustertemplates
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	gaccess "github.com/rancher/rancher/pkg/api/norman/customization/globalnamespaceaccess"
+	v1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	rocontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	clustertemplates "github.com/rancher/rancher/pkg/provisioningv2/clustertemplate"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/wrangler/pkg/data/convert"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// store enforces cluster templates for users that are not admins when cluster-template-enforcement is
+// enabled. New clusters must use an enabled revision and are stored with the spec the revision renders.
+// Templated clusters can only change the answers to the questions of their revision.
+type store struct {
+	types.Store
+	revisionCache rocontrollers.ClusterTemplateRevisionCache
+	memberAccess  *gaccess.MemberAccess
+}
+
+func (s *store) Create(apiOp *types.APIRequest, schema *types.APISchema, data types.APIObject) (types.APIObject, error) {
+	enforced, err := s.enforced(apiOp)
+	if err != nil || !enforced {
+		return s.Store.Create(apiOp, schema, data)
+	}
+
+	spec, err := toSpec(data)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	if spec.ClusterTemplateRevisionName == "" {
+		return types.APIObject{}, apierror.NewAPIError(validation.MissingRequired, "a clusterTemplateRevisionName is required to create a cluster")
+	}
+
+	namespace := data.Data().String("metadata", "namespace")
+	if namespace == "" {
+		namespace = apiOp.Namespace
+	}
+	revision, err := s.getRevision(namespace, spec.ClusterTemplateRevisionName)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	if !clustertemplates.Enabled(revision) {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent,
+			fmt.Sprintf("cluster template revision [%s] is disabled", revision.Name))
+	}
+	rendered, err := clustertemplates.Render(revision, spec.ClusterTemplateAnswers)
+	if err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+
+	// the cluster must not be provisioned from a spec the revision hasn't been applied to yet
+	spec, err = clustertemplates.ApplyDiff(spec, nil, rendered)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	specData, err := convert.EncodeToMap(spec)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	data.Data().Set("spec", specData)
+
+	return s.Store.Create(apiOp, schema, data)
+}
+
+func (s *store) Update(apiOp *types.APIRequest, schema *types.APISchema, data types.APIObject, id string) (types.APIObject, error) {
+	enforced, err := s.enforced(apiOp)
+	if err != nil || !enforced {
+		return s.Store.Update(apiOp, schema, data, id)
+	}
+
+	existing, err := s.Store.ByID(apiOp, schema, id)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	oldSpec, err := toSpec(existing)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	spec, err := toSpec(data)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+
+	if oldSpec.ClusterTemplateRevisionName == "" {
+		return s.Store.Update(apiOp, schema, data, id)
+	}
+	if spec.ClusterTemplateRevisionName == "" {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, "the clusterTemplateRevisionName of a cluster can not be removed")
+	}
+
+	namespace := existing.Namespace()
+	revision, err := s.getRevision(namespace, spec.ClusterTemplateRevisionName)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	if _, err := clustertemplates.Render(revision, spec.ClusterTemplateAnswers); err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+
+	if spec.ClusterTemplateRevisionName != oldSpec.ClusterTemplateRevisionName {
+		oldRevision, err := s.getRevision(namespace, oldSpec.ClusterTemplateRevisionName)
+		if err != nil {
+			return types.APIObject{}, err
+		}
+		if err := validateUpgrade(oldRevision, revision); err != nil {
+			return types.APIObject{}, err
+		}
+	}
+
+	if err := clustertemplates.ValidateTemplatedFields(spec, revision); err != nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+	return s.Store.Update(apiOp, schema, data, id)
+}
+
+func (s *store) enforced(apiOp *types.APIRequest) (bool, error) {
+	if !strings.EqualFold(settings.ClusterTemplateEnforcement.Get(), "true") {
+		return false, nil
+	}
+	isAdmin, err := s.memberAccess.IsAdmin(apiOp.GetUser())
+	return !isAdmin, err
+}
+
+func (s *store) getRevision(namespace, name string) (*v1.ClusterTemplateRevision, error) {
+	return getRevision(s.revisionCache, namespace, name)
+}
+
+func getRevision(revisionCache rocontrollers.ClusterTemplateRevisionCache, namespace, name string) (*v1.ClusterTemplateRevision, error) {
+	revision, err := revisionCache.Get(namespace, name)
+	if apierrors.IsNotFound(err) {
+		return nil, apierror.NewAPIError(validation.NotFound, fmt.Sprintf("cluster template revision [%s] is not found", name))
+	}
+	return revision, err
+}
+
+func validateUpgrade(oldRevision, revision *v1.ClusterTemplateRevision) error {
+	if oldRevision.Spec.ClusterTemplateName != revision.Spec.ClusterTemplateName {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("cluster template revision [%s] is not a revision of cluster template [%s]",
+			revision.Name, oldRevision.Spec.ClusterTemplateName))
+	}
+	if !clustertemplates.Enabled(revision) {
+		return apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("cluster template revision [%s] is disabled", revision.Name))
+	}
+	return nil
+}
+
+func toSpec(obj types.APIObject) (v1.ClusterSpec, error) {
+	var spec v1.ClusterSpec
+	if err := convert.ToObj(obj.Data().Map("spec"), &spec); err != nil {
+		return spec, apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+	return spec, nil
+}
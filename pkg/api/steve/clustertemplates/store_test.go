@@ -0,0 +1,214 @@
+package clustertemplates
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	gaccess "github.com/rancher/rancher/pkg/api/norman/customization/globalnamespaceaccess"
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	v1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	rocontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	normanv3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3/fakes"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/wrangler/pkg/data/convert"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+type fakeStore struct {
+	types.Store
+	existing types.APIObject
+	stored   types.APIObject
+}
+
+func (f *fakeStore) ByID(apiOp *types.APIRequest, schema *types.APISchema, id string) (types.APIObject, error) {
+	return f.existing, nil
+}
+
+func (f *fakeStore) Create(apiOp *types.APIRequest, schema *types.APISchema, data types.APIObject) (types.APIObject, error) {
+	f.stored = data
+	return data, nil
+}
+
+func (f *fakeStore) Update(apiOp *types.APIRequest, schema *types.APISchema, data types.APIObject, id string) (types.APIObject, error) {
+	f.stored = data
+	return data, nil
+}
+
+type fakeRevisionCache struct {
+	rocontrollers.ClusterTemplateRevisionCache
+	revisions map[string]*v1.ClusterTemplateRevision
+}
+
+func (f fakeRevisionCache) Get(namespace, name string) (*v1.ClusterTemplateRevision, error) {
+	if revision, ok := f.revisions[name]; ok {
+		return revision, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "clustertemplaterevisions"}, name)
+}
+
+func newRevision(name, kubernetesVersion string) *v1.ClusterTemplateRevision {
+	quantity := int32(1)
+	return &v1.ClusterTemplateRevision{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "fleet-default"},
+		Spec: v1.ClusterTemplateRevisionSpec{
+			ClusterTemplateName: "template",
+			Questions: []v1.ClusterTemplateQuestion{
+				{
+					Variable: "rkeConfig.machinePools.0.quantity",
+					Type:     "int",
+					Default:  "1",
+				},
+			},
+			ClusterConfig: v1.ClusterSpec{
+				KubernetesVersion: kubernetesVersion,
+				RKEConfig: &v1.RKEConfig{
+					MachinePools: []v1.RKEMachinePool{
+						{
+							Name:     "pool",
+							Quantity: &quantity,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func newMemberAccess(admins ...string) *gaccess.MemberAccess {
+	var grbs []*v3.GlobalRoleBinding
+	for _, admin := range admins {
+		grbs = append(grbs, &v3.GlobalRoleBinding{UserName: admin, GlobalRoleName: "admin"})
+	}
+	return &gaccess.MemberAccess{
+		Users: &fakes.UserInterfaceMock{
+			ControllerFunc: func() normanv3.UserController {
+				return &fakes.UserControllerMock{
+					ListerFunc: func() normanv3.UserLister {
+						return &fakes.UserListerMock{
+							GetFunc: func(namespace, name string) (*v3.User, error) {
+								return &v3.User{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+							},
+						}
+					},
+				}
+			},
+		},
+		GrbLister: &fakes.GlobalRoleBindingListerMock{
+			ListFunc: func(namespace string, selector labels.Selector) ([]*v3.GlobalRoleBinding, error) {
+				return grbs, nil
+			},
+		},
+		GrLister: &fakes.GlobalRoleListerMock{
+			GetFunc: func(namespace, name string) (*v3.GlobalRole, error) {
+				return &v3.GlobalRole{
+					ObjectMeta: metav1.ObjectMeta{Name: name},
+					Rules: []rbacv1.PolicyRule{
+						{APIGroups: []string{"*"}, Resources: []string{"*"}, Verbs: []string{"*"}},
+					},
+				}, nil
+			},
+		},
+	}
+}
+
+func newStore(existing *v1.Cluster, admins ...string) (*store, *fakeStore) {
+	inner := &fakeStore{}
+	if existing != nil {
+		inner.existing = toAPIObject(existing)
+	}
+	return &store{
+		Store: inner,
+		revisionCache: fakeRevisionCache{revisions: map[string]*v1.ClusterTemplateRevision{
+			"r1": newRevision("r1", "v1.21.4+rke2r2"),
+			"r2": newRevision("r2", "v1.21.5+rke2r1"),
+		}},
+		memberAccess: newMemberAccess(admins...),
+	}, inner
+}
+
+func newAPIRequest(userName string) *types.APIRequest {
+	req, _ := http.NewRequest(http.MethodPost, "/v1/provisioning.cattle.io.clusters", nil)
+	req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: userName}))
+	return &types.APIRequest{Request: req, Namespace: "fleet-default"}
+}
+
+func toAPIObject(cluster *v1.Cluster) types.APIObject {
+	data, err := convert.EncodeToMap(cluster)
+	if err != nil {
+		panic(err)
+	}
+	return types.APIObject{Object: data}
+}
+
+func newCluster(revisionName string, spec v1.ClusterSpec) *v1.Cluster {
+	spec.ClusterTemplateRevisionName = revisionName
+	return &v1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "fleet-default"},
+		Spec:       spec,
+	}
+}
+
+func enforce(t *testing.T) {
+	old := settings.ClusterTemplateEnforcement.Get()
+	require.NoError(t, settings.ClusterTemplateEnforcement.Set("true"))
+	t.Cleanup(func() {
+		_ = settings.ClusterTemplateEnforcement.Set(old)
+	})
+}
+
+func TestCreateRendersRevision(t *testing.T) {
+	enforce(t)
+	s, inner := newStore(nil)
+
+	cluster := newCluster("r1", v1.ClusterSpec{KubernetesVersion: "v1.20.0+rke2r1"})
+	cluster.Spec.ClusterTemplateAnswers = map[string]string{"rkeConfig.machinePools.0.quantity": "3"}
+	_, err := s.Create(newAPIRequest("user"), nil, toAPIObject(cluster))
+	require.NoError(t, err)
+
+	spec, err := toSpec(inner.stored)
+	require.NoError(t, err)
+	assert.Equal(t, "v1.21.4+rke2r2", spec.KubernetesVersion)
+	assert.Equal(t, int32(3), *spec.RKEConfig.MachinePools[0].Quantity)
+	assert.Equal(t, "r1", spec.ClusterTemplateRevisionName)
+}
+
+func TestCreateRequiresRevision(t *testing.T) {
+	enforce(t)
+	s, inner := newStore(nil)
+
+	_, err := s.Create(newAPIRequest("user"), nil, toAPIObject(newCluster("", v1.ClusterSpec{})))
+	assert.Error(t, err)
+	assert.Nil(t, inner.stored.Object)
+
+	s, inner = newStore(nil, "admin")
+	_, err = s.Create(newAPIRequest("admin"), nil, toAPIObject(newCluster("", v1.ClusterSpec{})))
+	assert.NoError(t, err)
+	assert.NotNil(t, inner.stored.Object)
+}
+
+func TestUpdateRevisionValidatesTemplatedFields(t *testing.T) {
+	enforce(t)
+	existing := newCluster("r1", newRevision("r1", "v1.21.4+rke2r2").Spec.ClusterConfig)
+
+	// switching revisions can't change the fields the new revision sets
+	s, _ := newStore(existing)
+	updated := newCluster("r2", newRevision("r1", "v1.22.0+rke2r1").Spec.ClusterConfig)
+	_, err := s.Update(newAPIRequest("user"), nil, toAPIObject(updated), "fleet-default/c")
+	assert.Error(t, err)
+
+	s, inner := newStore(existing)
+	updated = newCluster("r2", newRevision("r2", "v1.21.5+rke2r1").Spec.ClusterConfig)
+	_, err = s.Update(newAPIRequest("user"), nil, toAPIObject(updated), "fleet-default/c")
+	require.NoError(t, err)
+	assert.NotNil(t, inner.stored.Object)
+}
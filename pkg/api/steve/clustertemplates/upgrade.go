@@ -0,0 +1,78 @@
+package clustertemplates
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	rocontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// upgradeToRevision moves a templated cluster to another revision of its template. The cluster template
+// controller applies the difference between the revisions to the spec of the cluster.
+type upgradeToRevision struct {
+	clusters      rocontrollers.ClusterClient
+	revisionCache rocontrollers.ClusterTemplateRevisionCache
+}
+
+func (u *upgradeToRevision) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	apiRequest := types.GetAPIContext(req.Context())
+	if err := u.upgrade(apiRequest); err != nil {
+		apiRequest.WriteError(err)
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+}
+
+func (u *upgradeToRevision) upgrade(apiRequest *types.APIRequest) error {
+	if err := apiRequest.AccessControl.CanUpdate(apiRequest, types.APIObject{}, apiRequest.Schema); err != nil {
+		return err
+	}
+
+	var input UpgradeToRevisionInput
+	if err := json.NewDecoder(apiRequest.Request.Body).Decode(&input); err != nil {
+		return apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+	if input.ClusterTemplateRevisionName == "" {
+		return apierror.NewAPIError(validation.MissingRequired, "clusterTemplateRevisionName is required")
+	}
+
+	cluster, err := u.clusters.Get(apiRequest.Namespace, apiRequest.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+	if cluster.Spec.ClusterTemplateRevisionName == "" {
+		return apierror.NewAPIError(validation.InvalidAction, "cluster is not created from a cluster template")
+	}
+	if cluster.Spec.ClusterTemplateRevisionName == input.ClusterTemplateRevisionName {
+		return nil
+	}
+
+	oldRevision, err := getRevision(u.revisionCache, cluster.Namespace, cluster.Spec.ClusterTemplateRevisionName)
+	if err != nil {
+		return err
+	}
+	revision, err := getRevision(u.revisionCache, cluster.Namespace, input.ClusterTemplateRevisionName)
+	if err != nil {
+		return err
+	}
+	if err := validateUpgrade(oldRevision, revision); err != nil {
+		return err
+	}
+
+	cluster = cluster.DeepCopy()
+	cluster.Spec.ClusterTemplateRevisionName = revision.Name
+	// answers to questions the new revision doesn't have are dropped
+	answers := map[string]string{}
+	for _, question := range revision.Spec.Questions {
+		if answer, ok := cluster.Spec.ClusterTemplateAnswers[question.Variable]; ok {
+			answers[question.Variable] = answer
+		}
+	}
+	cluster.Spec.ClusterTemplateAnswers = answers
+	_, err = u.clusters.Update(cluster)
+	return err
+}
@@ -24,11 +24,13 @@ func Register(server *steve.Server, clients *wrangler.Context) {
 			}
 			schema.LinkHandlers["shell"] = sshClient
 			schema.LinkHandlers["sshkeys"] = sshClient
+			schema.LinkHandlers["provisionlog"] = sshClient
 			schema.Formatter = func(request *types.APIRequest, resource *types.RawResource) {
 				if err := request.AccessControl.CanUpdate(request, types.APIObject{}, request.Schema); err != nil ||
 					resource.APIObject.Data().String("spec", "infrastructureRef", "apiVersion") != "rke-machine.cattle.io/v1" {
 					delete(resource.Links, "shell")
 					delete(resource.Links, "sshkeys")
+					delete(resource.Links, "provisionlog")
 				}
 			}
 		},
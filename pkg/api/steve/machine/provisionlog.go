@@ -0,0 +1,31 @@
+package machine
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/rancher/pkg/controllers/provisioningv2/rke2/machineprovision"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (s *sshClient) provisionLog(apiContext *types.APIRequest) error {
+	machine, err := s.machines.Get(apiContext.Namespace, apiContext.Name, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	secretName := machineprovision.MachineProvisionLogSecretName(machine.Spec.InfrastructureRef.Name)
+	secret, err := s.secrets.Get(apiContext.Namespace, secretName, metav1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	log := secret.Data["log"]
+	apiContext.Response.Header().Set("Content-Length", strconv.Itoa(len(log)))
+	apiContext.Response.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	apiContext.Response.Header().Set("Cache-Control", "private")
+	apiContext.Response.WriteHeader(http.StatusOK)
+	_, err = apiContext.Response.Write(log)
+	return err
+}
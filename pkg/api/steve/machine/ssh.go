@@ -58,6 +58,11 @@ func (s *sshClient) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 			apiRequest.WriteError(err)
 			return
 		}
+	case "provisionlog":
+		if err := s.provisionLog(apiRequest); err != nil {
+			apiRequest.WriteError(err)
+			return
+		}
 	}
 }
 
@@ -5,6 +5,7 @@
 
 	"github.com/rancher/rancher/pkg/api/steve/catalog"
 	"github.com/rancher/rancher/pkg/api/steve/clusters"
+	"github.com/rancher/rancher/pkg/api/steve/clustertemplates"
 	"github.com/rancher/rancher/pkg/api/steve/disallow"
 	"github.com/rancher/rancher/pkg/api/steve/machine"
 	"github.com/rancher/rancher/pkg/api/steve/navlinks"
@@ -20,11 +21,15 @@ func Setup(ctx context.Context, server *steve.Server, config *wrangler.Context)
 		return err
 	}
 	machine.Register(server, config)
+	if err := clustertemplates.Register(server, config); err != nil {
+		return err
+	}
 	navlinks.Register(ctx, server)
 	settings.Register(server)
 	disallow.Register(server)
 	return catalog.Register(ctx,
 		server,
 		config.HelmOperations,
-		config.CatalogContentManager)
+		config.CatalogContentManager,
+		config.Core.ConfigMap())
 }
@@ -20,6 +20,23 @@ type App struct {
 type ReleaseStatus struct {
 	Summary            Summary `json:"summary,omitempty"`
 	ObservedGeneration int64   `json:"observedGeneration"`
+
+	// DriftedResources the release resources whose live state no longer matches the rendered manifest
+	DriftedResources []DriftedResource `json:"driftedResources,omitempty"`
+	// DriftCheckTime is when the live resources were last compared to the rendered manifest
+	DriftCheckTime *metav1.Time `json:"driftCheckTime,omitempty"`
+	// DriftCheckVersion is the release version the live resources were last compared to
+	DriftCheckVersion int `json:"driftCheckVersion,omitempty"`
+	// DriftCorrectionTime is when an upgrade was last run to correct drift
+	DriftCorrectionTime *metav1.Time `json:"driftCorrectionTime,omitempty"`
+}
+
+type DriftedResource struct {
+	ReleaseResource `json:",inline"`
+	// Missing is true if the resource no longer exists
+	Missing bool `json:"missing,omitempty"`
+	// Fields the paths of the fields whose live value differs from the rendered manifest
+	Fields []string `json:"fields,omitempty"`
 }
 
 type Summary struct {
@@ -68,6 +68,9 @@ type RepoSpec struct {
 const (
 	RepoDownloaded         RepoCondition = "Downloaded"
 	FollowerRepoDownloaded RepoCondition = "FollowerDownloaded"
+	// SystemChartsInstalled is set on the rancher-charts ClusterRepo and is false while a
+	// system chart failed to install or become healthy
+	SystemChartsInstalled RepoCondition = "SystemChartsInstalled"
 )
 
 type RepoStatus struct {
@@ -93,6 +96,51 @@ type RepoStatus struct {
 	Conditions []genericcondition.GenericCondition `json:"conditions,omitempty"`
 }
 
+// +genclient
+// +genclient:nonNamespaced
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+// ChartPolicy restricts which charts from ClusterRepos can be listed and installed
+// in this cluster. A chart version is visible and installable only if every
+// applicable policy allows it.
+type ChartPolicy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ChartPolicySpec `json:"spec"`
+}
+
+type ChartPolicySpec struct {
+	// ClusterRepos the names of the ClusterRepos this policy applies to. If empty the
+	// policy applies to all ClusterRepos.
+	ClusterRepos []string `json:"clusterRepos,omitempty"`
+
+	// ProjectIDs the projects (in the form "c-xxxxx:p-xxxxx") this policy applies to. If empty
+	// the policy applies to the whole cluster and also filters the repo index. If set, the
+	// policy is only enforced when installing into a namespace of one of the projects.
+	ProjectIDs []string `json:"projectIds,omitempty"`
+
+	// Allow if not empty, only chart versions matching at least one rule are allowed
+	Allow []ChartRule `json:"allow,omitempty"`
+
+	// Deny chart versions matching any rule are denied, even if they match an allow rule. A deny
+	// rule with an invalid name pattern or version constraint denies every chart version.
+	Deny []ChartRule `json:"deny,omitempty"`
+}
+
+type ChartRule struct {
+	// Name a glob pattern, as understood by path.Match, matched against the chart name.
+	// If empty all chart names match.
+	Name string `json:"name,omitempty"`
+
+	// Version a semver constraint, such as ">= 1.2.0 < 2.0.0", matched against the chart
+	// version. If empty all versions match.
+	Version string `json:"version,omitempty"`
+
+	// Annotations that must be set on the chart. An empty value only requires the
+	// annotation to be present.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -43,6 +43,23 @@ func NewApp(namespace, name string, obj App) *App {
 
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
+// ChartPolicyList is a list of ChartPolicy resources
+type ChartPolicyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata"`
+
+	Items []ChartPolicy `json:"items"`
+}
+
+func NewChartPolicy(namespace, name string, obj ChartPolicy) *ChartPolicy {
+	obj.APIVersion, obj.Kind = SchemeGroupVersion.WithKind("ChartPolicy").ToAPIVersionAndKind()
+	obj.Name = name
+	obj.Namespace = namespace
+	return &obj
+}
+
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
 // ClusterRepoList is a list of ClusterRepo resources
 type ClusterRepoList struct {
 	metav1.TypeMeta `json:",inline"`
@@ -29,6 +29,7 @@
 
 var (
 	AppResourceName         = "apps"
+	ChartPolicyResourceName = "chartpolicies"
 	ClusterRepoResourceName = "clusterrepos"
 	OperationResourceName   = "operations"
 )
@@ -56,6 +57,8 @@ func addKnownTypes(scheme *runtime.Scheme) error {
 	scheme.AddKnownTypes(SchemeGroupVersion,
 		&App{},
 		&AppList{},
+		&ChartPolicy{},
+		&ChartPolicyList{},
 		&ClusterRepo{},
 		&ClusterRepoList{},
 		&Operation{},
@@ -350,16 +350,21 @@ func (n *Notifier) ObjClusterName() string {
 type NotifierSpec struct {
 	ClusterName string `json:"clusterName" norman:"type=reference[cluster]"`
 
-	DisplayName     string           `json:"displayName,omitempty" norman:"required"`
-	Description     string           `json:"description,omitempty"`
-	SendResolved    bool             `json:"sendResolved,omitempty"`
-	SMTPConfig      *SMTPConfig      `json:"smtpConfig,omitempty"`
-	SlackConfig     *SlackConfig     `json:"slackConfig,omitempty"`
-	PagerdutyConfig *PagerdutyConfig `json:"pagerdutyConfig,omitempty"`
-	WebhookConfig   *WebhookConfig   `json:"webhookConfig,omitempty"`
-	WechatConfig    *WechatConfig    `json:"wechatConfig,omitempty"`
-	DingtalkConfig  *DingtalkConfig  `json:"dingtalkConfig,omitempty"`
-	MSTeamsConfig   *MSTeamsConfig   `json:"msteamsConfig,omitempty"`
+	DisplayName      string            `json:"displayName,omitempty" norman:"required"`
+	Description      string            `json:"description,omitempty"`
+	SendResolved     bool              `json:"sendResolved,omitempty"`
+	SMTPConfig       *SMTPConfig       `json:"smtpConfig,omitempty"`
+	SlackConfig      *SlackConfig      `json:"slackConfig,omitempty"`
+	PagerdutyConfig  *PagerdutyConfig  `json:"pagerdutyConfig,omitempty"`
+	WebhookConfig    *WebhookConfig    `json:"webhookConfig,omitempty"`
+	WechatConfig     *WechatConfig     `json:"wechatConfig,omitempty"`
+	DingtalkConfig   *DingtalkConfig   `json:"dingtalkConfig,omitempty"`
+	MSTeamsConfig    *MSTeamsConfig    `json:"msteamsConfig,omitempty"`
+	OpsgenieConfig   *OpsgenieConfig   `json:"opsgenieConfig,omitempty"`
+	GoogleChatConfig *GoogleChatConfig `json:"googleChatConfig,omitempty"`
+	TelegramConfig   *TelegramConfig   `json:"telegramConfig,omitempty"`
+	MatrixConfig     *MatrixConfig     `json:"matrixConfig,omitempty"`
+	MessageTemplate  *MessageTemplate  `json:"messageTemplate,omitempty"`
 }
 
 func (n *NotifierSpec) ObjClusterName() string {
@@ -367,14 +372,19 @@ func (n *NotifierSpec) ObjClusterName() string {
 }
 
 type Notification struct {
-	Message         string           `json:"message,omitempty"`
-	SMTPConfig      *SMTPConfig      `json:"smtpConfig,omitempty"`
-	SlackConfig     *SlackConfig     `json:"slackConfig,omitempty"`
-	PagerdutyConfig *PagerdutyConfig `json:"pagerdutyConfig,omitempty"`
-	WebhookConfig   *WebhookConfig   `json:"webhookConfig,omitempty"`
-	WechatConfig    *WechatConfig    `json:"wechatConfig,omitempty"`
-	DingtalkConfig  *DingtalkConfig  `json:"dingtalkConfig,omitempty"`
-	MSTeamsConfig   *MSTeamsConfig   `json:"msteamsConfig,omitempty"`
+	Message          string            `json:"message,omitempty"`
+	SMTPConfig       *SMTPConfig       `json:"smtpConfig,omitempty"`
+	SlackConfig      *SlackConfig      `json:"slackConfig,omitempty"`
+	PagerdutyConfig  *PagerdutyConfig  `json:"pagerdutyConfig,omitempty"`
+	WebhookConfig    *WebhookConfig    `json:"webhookConfig,omitempty"`
+	WechatConfig     *WechatConfig     `json:"wechatConfig,omitempty"`
+	DingtalkConfig   *DingtalkConfig   `json:"dingtalkConfig,omitempty"`
+	MSTeamsConfig    *MSTeamsConfig    `json:"msteamsConfig,omitempty"`
+	OpsgenieConfig   *OpsgenieConfig   `json:"opsgenieConfig,omitempty"`
+	GoogleChatConfig *GoogleChatConfig `json:"googleChatConfig,omitempty"`
+	TelegramConfig   *TelegramConfig   `json:"telegramConfig,omitempty"`
+	MatrixConfig     *MatrixConfig     `json:"matrixConfig,omitempty"`
+	MessageTemplate  *MessageTemplate  `json:"messageTemplate,omitempty"`
 }
 
 type SMTPConfig struct {
@@ -424,6 +434,50 @@ type MSTeamsConfig struct {
 	*HTTPClientConfig
 }
 
+type OpsgenieConfig struct {
+	APIKey string `json:"apiKey,omitempty" norman:"type=password,required"`
+	// APIURL defaults to https://api.opsgenie.com, accounts in the EU use https://api.eu.opsgenie.com
+	APIURL string `json:"apiUrl,omitempty"`
+	// DefaultRecipient is the name of the team the alerts are assigned to
+	DefaultRecipient string `json:"defaultRecipient,omitempty"`
+	*HTTPClientConfig
+}
+
+type GoogleChatConfig struct {
+	URL string `json:"url,omitempty" norman:"required"`
+	*HTTPClientConfig
+}
+
+type TelegramConfig struct {
+	BotToken string `json:"botToken,omitempty" norman:"type=password,required"`
+	// DefaultRecipient is the ID of the chat messages are sent to
+	DefaultRecipient string `json:"defaultRecipient,omitempty" norman:"required"`
+	// APIURL defaults to https://api.telegram.org
+	APIURL string `json:"apiUrl,omitempty"`
+	*HTTPClientConfig
+}
+
+type MatrixConfig struct {
+	HomeserverURL string `json:"homeserverUrl,omitempty" norman:"required"`
+	AccessToken   string `json:"accessToken,omitempty" norman:"type=password,required"`
+	// DefaultRecipient is the ID of the room messages are sent to
+	DefaultRecipient string `json:"defaultRecipient,omitempty" norman:"required"`
+	*HTTPClientConfig
+}
+
+// MessageTemplate overrides the default messages of a notifier. The fields are Alertmanager
+// templates, they have access to the alerts and their labels, such as cluster_name and
+// project_name, and to the "rancher.url" template linking back to the cluster in Rancher.
+type MessageTemplate struct {
+	Title string `json:"title,omitempty"`
+	Text  string `json:"text,omitempty"`
+}
+
+type NotifierPreviewOutput struct {
+	Title string `json:"title,omitempty"`
+	Text  string `json:"text,omitempty"`
+}
+
 type NotifierStatus struct {
 }
 
@@ -4,6 +4,7 @@
 	"bytes"
 	"encoding/gob"
 	"strings"
+	"time"
 
 	aksv1 "github.com/rancher/aks-operator/pkg/apis/aks.cattle.io/v1"
 	eksv1 "github.com/rancher/eks-operator/pkg/apis/eks.cattle.io/v1"
@@ -39,6 +40,8 @@ func init() {
 	ClusterActionRunSecurityScan       = "runSecurityScan"
 	ClusterActionSaveAsTemplate        = "saveAsTemplate"
 
+	ClusterRegistrationTokenActionRotate = "rotate"
+
 	// ClusterConditionReady Cluster ready to serve API (healthy when true, unhealthy when false)
 	ClusterConditionReady          condition.Cond = "Ready"
 	ClusterConditionPending        condition.Cond = "Pending"
@@ -180,6 +183,23 @@ type ClusterStatus struct {
 	AKSStatus                            AKSStatus                   `json:"aksStatus,omitempty" norman:"nocreate,noupdate"`
 	EKSStatus                            EKSStatus                   `json:"eksStatus,omitempty" norman:"nocreate,noupdate"`
 	GKEStatus                            GKEStatus                   `json:"gkeStatus,omitempty" norman:"nocreate,noupdate"`
+	// ControllerOwner is the ID of the Rancher replica running the controllers of the cluster
+	ControllerOwner string `json:"controllerOwner,omitempty" norman:"nocreate,noupdate"`
+	// ConnectionHistory has the most recent connections of the cluster agent, oldest first
+	ConnectionHistory []ClusterConnection `json:"connectionHistory,omitempty" norman:"nocreate,noupdate"`
+}
+
+// ClusterConnection is a period the cluster agent was connected to Rancher
+type ClusterConnection struct {
+	// ConnectedAt is empty for a disconnect that wasn't preceded by a recorded connection
+	ConnectedAt    string `json:"connectedAt,omitempty"`
+	DisconnectedAt string `json:"disconnectedAt,omitempty"`
+	// Duration is how long the connection lasted, it is set once the cluster disconnected
+	Duration      string `json:"duration,omitempty"`
+	AgentVersion  string `json:"agentVersion,omitempty"`
+	RemoteAddress string `json:"remoteAddress,omitempty"`
+	// DisconnectNotified is true once notifiers were told the cluster stayed disconnected
+	DisconnectNotified bool `json:"disconnectNotified,omitempty"`
 }
 
 type ClusterComponentStatus struct {
@@ -248,12 +268,26 @@ func (c *ClusterRegistrationToken) ObjClusterName() string {
 
 type ClusterRegistrationTokenSpec struct {
 	ClusterName string `json:"clusterName" norman:"required,type=reference[cluster]"`
+	// ExpiresAt is the time after which the token can no longer be used to register agents
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+	// MaxUses is the number of agents that can register with the token, 0 means unlimited
+	MaxUses int `json:"maxUses,omitempty" norman:"min=0"`
 }
 
 func (c *ClusterRegistrationTokenSpec) ObjClusterName() string {
 	return c.ClusterName
 }
 
+// IsExpired returns whether the token has an expiry time before now
+func (c *ClusterRegistrationToken) IsExpired(now time.Time) bool {
+	return c.Spec.ExpiresAt != nil && !now.Before(c.Spec.ExpiresAt.Time)
+}
+
+// IsExhausted returns whether the maximum number of agents already registered with the token
+func (c *ClusterRegistrationToken) IsExhausted() bool {
+	return c.Spec.MaxUses > 0 && c.Status.Uses >= c.Spec.MaxUses
+}
+
 type ClusterRegistrationTokenStatus struct {
 	InsecureCommand            string `json:"insecureCommand"`
 	Command                    string `json:"command"`
@@ -263,6 +297,10 @@ type ClusterRegistrationTokenStatus struct {
 	InsecureNodeCommand        string `json:"insecureNodeCommand"`
 	ManifestURL                string `json:"manifestUrl"`
 	Token                      string `json:"token"`
+	// Uses is the number of agents that registered with the token
+	Uses int `json:"uses,omitempty" norman:"nocreate,noupdate"`
+	// Expired is true once the expiry time of the token passed
+	Expired bool `json:"expired,omitempty" norman:"nocreate,noupdate"`
 }
 
 type GenerateKubeConfigOutput struct {
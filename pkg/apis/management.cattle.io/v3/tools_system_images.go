@@ -18,6 +18,7 @@
 			Registry:      "registry:2",
 			RegistryProxy: "rancher/pipeline-tools:v0.1.16",
 			KubeApply:     "rancher/pipeline-tools:v0.1.16",
+			MinioClient:   "rancher/mirrored-minio-mc:RELEASE.2020-07-17T02-52-20Z",
 		},
 		AuthSystemImages: AuthSystemImages{
 			KubeAPIAuth: "rancher/kube-api-auth:v0.1.5",
@@ -1780,6 +1780,22 @@ func (in *ClusterCondition) DeepCopy() *ClusterCondition {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ClusterConnection) DeepCopyInto(out *ClusterConnection) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterConnection.
+func (in *ClusterConnection) DeepCopy() *ClusterConnection {
+	if in == nil {
+		return nil
+	}
+	out := new(ClusterConnection)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterGroupSpec) DeepCopyInto(out *ClusterGroupSpec) {
 	*out = *in
@@ -2047,7 +2063,7 @@ func (in *ClusterRegistrationToken) DeepCopyInto(out *ClusterRegistrationToken)
 	out.Namespaced = in.Namespaced
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
-	out.Spec = in.Spec
+	in.Spec.DeepCopyInto(&out.Spec)
 	out.Status = in.Status
 	return
 }
@@ -2106,6 +2122,10 @@ func (in *ClusterRegistrationTokenList) DeepCopyObject() runtime.Object {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ClusterRegistrationTokenSpec) DeepCopyInto(out *ClusterRegistrationTokenSpec) {
 	*out = *in
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
 	return
 }
 
@@ -2552,6 +2572,11 @@ func (in *ClusterStatus) DeepCopyInto(out *ClusterStatus) {
 	in.AKSStatus.DeepCopyInto(&out.AKSStatus)
 	in.EKSStatus.DeepCopyInto(&out.EKSStatus)
 	in.GKEStatus.DeepCopyInto(&out.GKEStatus)
+	if in.ConnectionHistory != nil {
+		in, out := &in.ConnectionHistory, &out.ConnectionHistory
+		*out = make([]ClusterConnection, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -4383,6 +4408,27 @@ func (in *GlobalRoleList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GoogleChatConfig) DeepCopyInto(out *GoogleChatConfig) {
+	*out = *in
+	if in.HTTPClientConfig != nil {
+		in, out := &in.HTTPClientConfig, &out.HTTPClientConfig
+		*out = new(HTTPClientConfig)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GoogleChatConfig.
+func (in *GoogleChatConfig) DeepCopy() *GoogleChatConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(GoogleChatConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GoogleOAuthProvider) DeepCopyInto(out *GoogleOAuthProvider) {
 	*out = *in
@@ -5537,6 +5583,27 @@ func (in MapStringInterface) DeepCopyInto(out *MapStringInterface) {
 	}
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MatrixConfig) DeepCopyInto(out *MatrixConfig) {
+	*out = *in
+	if in.HTTPClientConfig != nil {
+		in, out := &in.HTTPClientConfig, &out.HTTPClientConfig
+		*out = new(HTTPClientConfig)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MatrixConfig.
+func (in *MatrixConfig) DeepCopy() *MatrixConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(MatrixConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Member) DeepCopyInto(out *Member) {
 	*out = *in
@@ -5553,6 +5620,22 @@ func (in *Member) DeepCopy() *Member {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *MessageTemplate) DeepCopyInto(out *MessageTemplate) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new MessageTemplate.
+func (in *MessageTemplate) DeepCopy() *MessageTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(MessageTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *MetadataUpdate) DeepCopyInto(out *MetadataUpdate) {
 	*out = *in
@@ -6679,6 +6762,31 @@ func (in *Notification) DeepCopyInto(out *Notification) {
 		*out = new(MSTeamsConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.OpsgenieConfig != nil {
+		in, out := &in.OpsgenieConfig, &out.OpsgenieConfig
+		*out = new(OpsgenieConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GoogleChatConfig != nil {
+		in, out := &in.GoogleChatConfig, &out.GoogleChatConfig
+		*out = new(GoogleChatConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TelegramConfig != nil {
+		in, out := &in.TelegramConfig, &out.TelegramConfig
+		*out = new(TelegramConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MatrixConfig != nil {
+		in, out := &in.MatrixConfig, &out.MatrixConfig
+		*out = new(MatrixConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MessageTemplate != nil {
+		in, out := &in.MessageTemplate, &out.MessageTemplate
+		*out = new(MessageTemplate)
+		**out = **in
+	}
 	return
 }
 
@@ -6754,6 +6862,22 @@ func (in *NotifierList) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *NotifierPreviewOutput) DeepCopyInto(out *NotifierPreviewOutput) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new NotifierPreviewOutput.
+func (in *NotifierPreviewOutput) DeepCopy() *NotifierPreviewOutput {
+	if in == nil {
+		return nil
+	}
+	out := new(NotifierPreviewOutput)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *NotifierSpec) DeepCopyInto(out *NotifierSpec) {
 	*out = *in
@@ -6792,6 +6916,31 @@ func (in *NotifierSpec) DeepCopyInto(out *NotifierSpec) {
 		*out = new(MSTeamsConfig)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.OpsgenieConfig != nil {
+		in, out := &in.OpsgenieConfig, &out.OpsgenieConfig
+		*out = new(OpsgenieConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GoogleChatConfig != nil {
+		in, out := &in.GoogleChatConfig, &out.GoogleChatConfig
+		*out = new(GoogleChatConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.TelegramConfig != nil {
+		in, out := &in.TelegramConfig, &out.TelegramConfig
+		*out = new(TelegramConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MatrixConfig != nil {
+		in, out := &in.MatrixConfig, &out.MatrixConfig
+		*out = new(MatrixConfig)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.MessageTemplate != nil {
+		in, out := &in.MessageTemplate, &out.MessageTemplate
+		*out = new(MessageTemplate)
+		**out = **in
+	}
 	return
 }
 
@@ -7122,6 +7271,27 @@ func (in *OpenLdapTestAndApplyInput) DeepCopy() *OpenLdapTestAndApplyInput {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OpsgenieConfig) DeepCopyInto(out *OpsgenieConfig) {
+	*out = *in
+	if in.HTTPClientConfig != nil {
+		in, out := &in.HTTPClientConfig, &out.HTTPClientConfig
+		*out = new(HTTPClientConfig)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OpsgenieConfig.
+func (in *OpsgenieConfig) DeepCopy() *OpsgenieConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(OpsgenieConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PagerdutyConfig) DeepCopyInto(out *PagerdutyConfig) {
 	*out = *in
@@ -9680,6 +9850,27 @@ func (in *TargetWorkload) DeepCopy() *TargetWorkload {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TelegramConfig) DeepCopyInto(out *TelegramConfig) {
+	*out = *in
+	if in.HTTPClientConfig != nil {
+		in, out := &in.HTTPClientConfig, &out.HTTPClientConfig
+		*out = new(HTTPClientConfig)
+		**out = **in
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TelegramConfig.
+func (in *TelegramConfig) DeepCopy() *TelegramConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(TelegramConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Template) DeepCopyInto(out *Template) {
 	*out = *in
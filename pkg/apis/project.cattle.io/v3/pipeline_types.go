@@ -29,7 +29,7 @@ type SourceCodeProvider struct {
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
 	ProjectName string `json:"projectName" norman:"type=reference[project]"`
-	Type        string `json:"type" norman:"options=github|gitlab|bitbucketcloud|bitbucketserver"`
+	Type        string `json:"type" norman:"options=github|gitlab|bitbucketcloud|bitbucketserver|gitea|azuredevops"`
 }
 
 func (s *SourceCodeProvider) ObjClusterName() string {
@@ -65,6 +65,14 @@ type BitbucketServerProvider struct {
 	OauthProvider `json:",inline"`
 }
 
+type GiteaProvider struct {
+	OauthProvider `json:",inline"`
+}
+
+type AzureDevOpsProvider struct {
+	OauthProvider `json:",inline"`
+}
+
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
@@ -75,7 +83,7 @@ type SourceCodeProviderConfig struct {
 	metav1.ObjectMeta `json:"metadata,omitempty"`
 
 	ProjectName string `json:"projectName" norman:"required,type=reference[project]"`
-	Type        string `json:"type" norman:"noupdate,options=github|gitlab|bitbucketcloud|bitbucketserver"`
+	Type        string `json:"type" norman:"noupdate,options=github|gitlab|bitbucketcloud|bitbucketserver|gitea|azuredevops"`
 	Enabled     bool   `json:"enabled,omitempty"`
 }
 
@@ -140,6 +148,31 @@ type BitbucketServerPipelineConfig struct {
 // +genclient
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 
+type GiteaPipelineConfig struct {
+	SourceCodeProviderConfig `json:",inline" mapstructure:",squash"`
+
+	Hostname     string `json:"hostname,omitempty" norman:"default=gitea.com" norman:"noupdate"`
+	TLS          bool   `json:"tls,omitempty" norman:"notnullable,default=true" norman:"noupdate"`
+	ClientID     string `json:"clientId,omitempty" norman:"noupdate"`
+	ClientSecret string `json:"clientSecret,omitempty" norman:"noupdate,type=password"`
+	RedirectURL  string `json:"redirectUrl,omitempty" norman:"noupdate"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type AzureDevOpsPipelineConfig struct {
+	SourceCodeProviderConfig `json:",inline" mapstructure:",squash"`
+
+	Organization string `json:"organization,omitempty" norman:"noupdate"`
+	ClientID     string `json:"clientId,omitempty" norman:"noupdate"`
+	ClientSecret string `json:"clientSecret,omitempty" norman:"noupdate,type=password"`
+	RedirectURL  string `json:"redirectUrl,omitempty" norman:"noupdate"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
 type Pipeline struct {
 	types.Namespaced
 
@@ -264,6 +297,15 @@ type PipelineConfig struct {
 	Timeout      int                   `json:"timeout,omitempty" yaml:"timeout,omitempty"`
 	Branch       *Constraint           `json:"branch,omitempty" yaml:"branch,omitempty"`
 	Notification *PipelineNotification `json:"notification,omitempty" yaml:"notification,omitempty"`
+	Caches       []CacheConfig         `json:"caches,omitempty" yaml:"caches,omitempty"`
+}
+
+// CacheConfig is a set of workspace paths restored after the source code is cloned and saved when
+// all stages succeed. Key may use the pipeline environment variables and hashFiles(path, ...) so the
+// cache is invalidated when dependency files change, e.g. go-${CICD_GIT_BRANCH}-hashFiles(go.sum).
+type CacheConfig struct {
+	Key   string   `json:"key,omitempty" yaml:"key,omitempty" norman:"required"`
+	Paths []string `json:"paths,omitempty" yaml:"paths,omitempty" norman:"required"`
 }
 
 type PipelineNotification struct {
@@ -315,6 +357,24 @@ type Step struct {
 	MemoryRequest string            `json:"memoryRequest,omitempty" yaml:"memoryRequest,omitempty"`
 	MemoryLimit   string            `json:"memoryLimit,omitempty" yaml:"memoryLimit,omitempty"`
 	When          *Constraints      `json:"when,omitempty" yaml:"when,omitempty"`
+
+	Artifacts         []Artifact         `json:"artifacts,omitempty" yaml:"artifacts,omitempty"`
+	ArtifactDownloads []ArtifactDownload `json:"artifactDownloads,omitempty" yaml:"artifactDownloads,omitempty"`
+}
+
+// Artifact is a named set of workspace paths uploaded when the step succeeds.
+type Artifact struct {
+	Name  string   `json:"name,omitempty" yaml:"name,omitempty" norman:"required"`
+	Paths []string `json:"paths,omitempty" yaml:"paths,omitempty" norman:"required"`
+}
+
+// ArtifactDownload extracts a named artifact into the workspace before the step runs. The artifact
+// comes from an earlier stage of the same execution, or from the last execution of the pipeline
+// that uploaded it when FromPreviousExecution is set.
+type ArtifactDownload struct {
+	Name                  string `json:"name,omitempty" yaml:"name,omitempty" norman:"required"`
+	Path                  string `json:"path,omitempty" yaml:"path,omitempty"`
+	FromPreviousExecution bool   `json:"fromPreviousExecution,omitempty" yaml:"fromPreviousExecution,omitempty"`
 }
 
 type Constraints struct {
@@ -419,7 +479,7 @@ type StepStatus struct {
 
 type SourceCodeCredentialSpec struct {
 	ProjectName    string `json:"projectName" norman:"type=reference[project]"`
-	SourceCodeType string `json:"sourceCodeType,omitempty" norman:"required,options=github|gitlab|bitbucketcloud|bitbucketserver"`
+	SourceCodeType string `json:"sourceCodeType,omitempty" norman:"required,options=github|gitlab|bitbucketcloud|bitbucketserver|gitea|azuredevops"`
 	UserName       string `json:"userName" norman:"required,type=reference[user]"`
 	DisplayName    string `json:"displayName,omitempty" norman:"required"`
 	AvatarURL      string `json:"avatarUrl,omitempty"`
@@ -445,7 +505,7 @@ type SourceCodeCredentialStatus struct {
 
 type SourceCodeRepositorySpec struct {
 	ProjectName              string   `json:"projectName" norman:"type=reference[project]"`
-	SourceCodeType           string   `json:"sourceCodeType,omitempty" norman:"required,options=github|gitlab|bitbucketcloud|bitbucketserver"`
+	SourceCodeType           string   `json:"sourceCodeType,omitempty" norman:"required,options=github|gitlab|bitbucketcloud|bitbucketserver|gitea|azuredevops"`
 	UserName                 string   `json:"userName" norman:"required,type=reference[user]"`
 	SourceCodeCredentialName string   `json:"sourceCodeCredentialName,omitempty" norman:"required,type=reference[sourceCodeCredential]"`
 	URL                      string   `json:"url,omitempty"`
@@ -476,7 +536,7 @@ type RunPipelineInput struct {
 
 type AuthAppInput struct {
 	InheritGlobal  bool   `json:"inheritGlobal,omitempty"`
-	SourceCodeType string `json:"sourceCodeType,omitempty" norman:"type=string,required,options=github|gitlab|bitbucketcloud|bitbucketserver"`
+	SourceCodeType string `json:"sourceCodeType,omitempty" norman:"type=string,required,options=github|gitlab|bitbucketcloud|bitbucketserver|gitea|azuredevops"`
 	RedirectURL    string `json:"redirectUrl,omitempty" norman:"type=string"`
 	TLS            bool   `json:"tls,omitempty"`
 	Host           string `json:"host,omitempty"`
@@ -486,7 +546,7 @@ type AuthAppInput struct {
 }
 
 type AuthUserInput struct {
-	SourceCodeType string `json:"sourceCodeType,omitempty" norman:"type=string,required,options=github|gitlab|bitbucketcloud|bitbucketserver"`
+	SourceCodeType string `json:"sourceCodeType,omitempty" norman:"type=string,required,options=github|gitlab|bitbucketcloud|bitbucketserver|gitea|azuredevops"`
 	RedirectURL    string `json:"redirectUrl,omitempty" norman:"type=string"`
 	Code           string `json:"code,omitempty" norman:"type=string,required"`
 }
@@ -504,6 +564,7 @@ type PipelineSystemImages struct {
 	Registry      string `json:"registry,omitempty"`
 	RegistryProxy string `json:"registryProxy,omitempty"`
 	KubeApply     string `json:"kubeApply,omitempty"`
+	MinioClient   string `json:"minioClient,omitempty"`
 }
 
 type OauthApplyInput struct {
@@ -528,6 +589,15 @@ type BitbucketCloudApplyInput struct {
 	OauthApplyInput
 }
 
+type GiteaApplyInput struct {
+	OauthApplyInput
+}
+
+type AzureDevOpsApplyInput struct {
+	OauthApplyInput
+	Organization string `json:"organization,omitempty"`
+}
+
 type BitbucketServerApplyInput struct {
 	OAuthToken    string `json:"oauthToken,omitempty"`
 	OAuthVerifier string `json:"oauthVerifier,omitempty"`
@@ -358,6 +358,43 @@ func (in *ApplyYamlConfig) DeepCopy() *ApplyYamlConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Artifact) DeepCopyInto(out *Artifact) {
+	*out = *in
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Artifact.
+func (in *Artifact) DeepCopy() *Artifact {
+	if in == nil {
+		return nil
+	}
+	out := new(Artifact)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArtifactDownload) DeepCopyInto(out *ArtifactDownload) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArtifactDownload.
+func (in *ArtifactDownload) DeepCopy() *ArtifactDownload {
+	if in == nil {
+		return nil
+	}
+	out := new(ArtifactDownload)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AuthAppInput) DeepCopyInto(out *AuthAppInput) {
 	*out = *in
@@ -390,6 +427,65 @@ func (in *AuthUserInput) DeepCopy() *AuthUserInput {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureDevOpsApplyInput) DeepCopyInto(out *AzureDevOpsApplyInput) {
+	*out = *in
+	out.OauthApplyInput = in.OauthApplyInput
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureDevOpsApplyInput.
+func (in *AzureDevOpsApplyInput) DeepCopy() *AzureDevOpsApplyInput {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureDevOpsApplyInput)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureDevOpsPipelineConfig) DeepCopyInto(out *AzureDevOpsPipelineConfig) {
+	*out = *in
+	in.SourceCodeProviderConfig.DeepCopyInto(&out.SourceCodeProviderConfig)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureDevOpsPipelineConfig.
+func (in *AzureDevOpsPipelineConfig) DeepCopy() *AzureDevOpsPipelineConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureDevOpsPipelineConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *AzureDevOpsPipelineConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AzureDevOpsProvider) DeepCopyInto(out *AzureDevOpsProvider) {
+	*out = *in
+	in.OauthProvider.DeepCopyInto(&out.OauthProvider)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AzureDevOpsProvider.
+func (in *AzureDevOpsProvider) DeepCopy() *AzureDevOpsProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(AzureDevOpsProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *BasicAuth) DeepCopyInto(out *BasicAuth) {
 	*out = *in
@@ -599,6 +695,27 @@ func (in *BitbucketServerRequestLoginOutput) DeepCopy() *BitbucketServerRequestL
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CacheConfig) DeepCopyInto(out *CacheConfig) {
+	*out = *in
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CacheConfig.
+func (in *CacheConfig) DeepCopy() *CacheConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(CacheConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Certificate) DeepCopyInto(out *Certificate) {
 	*out = *in
@@ -815,6 +932,65 @@ func (in *EnvFrom) DeepCopy() *EnvFrom {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GiteaApplyInput) DeepCopyInto(out *GiteaApplyInput) {
+	*out = *in
+	out.OauthApplyInput = in.OauthApplyInput
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GiteaApplyInput.
+func (in *GiteaApplyInput) DeepCopy() *GiteaApplyInput {
+	if in == nil {
+		return nil
+	}
+	out := new(GiteaApplyInput)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GiteaPipelineConfig) DeepCopyInto(out *GiteaPipelineConfig) {
+	*out = *in
+	in.SourceCodeProviderConfig.DeepCopyInto(&out.SourceCodeProviderConfig)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GiteaPipelineConfig.
+func (in *GiteaPipelineConfig) DeepCopy() *GiteaPipelineConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(GiteaPipelineConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *GiteaPipelineConfig) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *GiteaProvider) DeepCopyInto(out *GiteaProvider) {
+	*out = *in
+	in.OauthProvider.DeepCopyInto(&out.OauthProvider)
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new GiteaProvider.
+func (in *GiteaProvider) DeepCopy() *GiteaProvider {
+	if in == nil {
+		return nil
+	}
+	out := new(GiteaProvider)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GithubApplyInput) DeepCopyInto(out *GithubApplyInput) {
 	*out = *in
@@ -1351,6 +1527,13 @@ func (in *PipelineConfig) DeepCopyInto(out *PipelineConfig) {
 		*out = new(PipelineNotification)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Caches != nil {
+		in, out := &in.Caches, &out.Caches
+		*out = make([]CacheConfig, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	return
 }
 
@@ -2358,6 +2541,18 @@ func (in *Step) DeepCopyInto(out *Step) {
 		*out = new(Constraints)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Artifacts != nil {
+		in, out := &in.Artifacts, &out.Artifacts
+		*out = make([]Artifact, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ArtifactDownloads != nil {
+		in, out := &in.ArtifactDownloads, &out.ArtifactDownloads
+		*out = make([]ArtifactDownload, len(*in))
+		copy(*out, *in)
+	}
 	return
 }
 
@@ -29,16 +29,37 @@ type ClusterSpec struct {
 	EnableNetworkPolicy                  *bool          `json:"enableNetworkPolicy,omitempty" norman:"default=false"`
 
 	RedeploySystemAgentGeneration int64 `json:"redeploySystemAgentGeneration,omitempty"`
+
+	// ClusterTemplateRevisionName pins the cluster to a revision in its namespace. Changing it upgrades
+	// the cluster to another revision of the same template.
+	ClusterTemplateRevisionName string            `json:"clusterTemplateRevisionName,omitempty"`
+	ClusterTemplateAnswers      map[string]string `json:"clusterTemplateAnswers,omitempty"`
 }
 
 type ClusterStatus struct {
-	Ready              bool                                `json:"ready,omitempty"`
-	ClusterName        string                              `json:"clusterName,omitempty"`
-	ClientSecretName   string                              `json:"clientSecretName,omitempty"`
-	AgentDeployed      bool                                `json:"agentDeployed,omitempty"`
-	ObservedGeneration int64                               `json:"observedGeneration"`
-	Conditions         []genericcondition.GenericCondition `json:"conditions,omitempty"`
-	ETCDSnapshots      []rkev1.ETCDSnapshot                `json:"etcdSnapshots,omitempty"`
+	Ready               bool                                `json:"ready,omitempty"`
+	ClusterName         string                              `json:"clusterName,omitempty"`
+	ClientSecretName    string                              `json:"clientSecretName,omitempty"`
+	AgentDeployed       bool                                `json:"agentDeployed,omitempty"`
+	ObservedGeneration  int64                               `json:"observedGeneration"`
+	Conditions          []genericcondition.GenericCondition `json:"conditions,omitempty"`
+	ETCDSnapshots       []rkev1.ETCDSnapshot                `json:"etcdSnapshots,omitempty"`
+	MachineRemediations []MachineRemediation                `json:"machineRemediations,omitempty"`
+
+	// ClusterTemplateRevisionName and ClusterTemplateAnswers are the revision and answers last applied
+	// to the spec of the cluster.
+	ClusterTemplateRevisionName string            `json:"clusterTemplateRevisionName,omitempty"`
+	ClusterTemplateAnswers      map[string]string `json:"clusterTemplateAnswers,omitempty"`
+}
+
+// MachineRemediation is a machine that failed the health check of its pool and is being replaced.
+type MachineRemediation struct {
+	MachineName     string      `json:"machineName,omitempty"`
+	MachinePoolName string      `json:"machinePoolName,omitempty"`
+	NodeName        string      `json:"nodeName,omitempty"`
+	Reason          string      `json:"reason,omitempty"`
+	Message         string      `json:"message,omitempty"`
+	Time            metav1.Time `json:"time,omitempty"`
 }
 
 type ImportedConfig struct {
@@ -0,0 +1,54 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type ClusterTemplate struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ClusterTemplateSpec `json:"spec"`
+}
+
+type ClusterTemplateSpec struct {
+	DisplayName         string `json:"displayName,omitempty"`
+	Description         string `json:"description,omitempty"`
+	DefaultRevisionName string `json:"defaultRevisionName,omitempty"`
+}
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+
+type ClusterTemplateRevision struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              ClusterTemplateRevisionSpec `json:"spec"`
+}
+
+type ClusterTemplateRevisionSpec struct {
+	ClusterTemplateName string `json:"clusterTemplateName,omitempty" wrangler:"required"`
+	DisplayName         string `json:"displayName,omitempty"`
+	Enabled             *bool  `json:"enabled,omitempty"`
+
+	// Questions are the fields of ClusterConfig a cluster can override with its answers, every other
+	// field of ClusterConfig is set by the revision.
+	Questions []ClusterTemplateQuestion `json:"questions,omitempty"`
+	// ClusterConfig is the spec of the clusters created from the revision, including the rkeConfig,
+	// machine global config and machine pools.
+	ClusterConfig ClusterSpec `json:"clusterConfig,omitempty"`
+}
+
+type ClusterTemplateQuestion struct {
+	// Variable is the path of the field in ClusterConfig, e.g. rkeConfig.machinePools.0.quantity.
+	Variable    string `json:"variable,omitempty" wrangler:"required"`
+	Label       string `json:"label,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Type of the answer, one of string, int, boolean or enum.
+	Type     string   `json:"type,omitempty"`
+	Required bool     `json:"required,omitempty"`
+	Default  string   `json:"default,omitempty"`
+	Options  []string `json:"options,omitempty"`
+}
@@ -3,6 +3,7 @@
 import (
 	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
@@ -13,13 +14,49 @@ type RKEMachinePool struct {
 	EtcdRole                     bool                         `json:"etcdRole,omitempty"`
 	ControlPlaneRole             bool                         `json:"controlPlaneRole,omitempty"`
 	WorkerRole                   bool                         `json:"workerRole,omitempty"`
-	NodeConfig                   *corev1.ObjectReference      `json:"machineConfigRef,omitempty" wrangler:"required"`
+	NodeConfig                   *corev1.ObjectReference      `json:"machineConfigRef,omitempty"`
 	Name                         string                       `json:"name,omitempty" wrangler:"required"`
 	DisplayName                  string                       `json:"displayName,omitempty"`
 	Quantity                     *int32                       `json:"quantity,omitempty"`
 	RollingUpdate                *RKEMachinePoolRollingUpdate `json:"rollingUpdate,omitempty"`
 	MachineDeploymentLabels      map[string]string            `json:"machineDeploymentLabels,omitempty"`
 	MachineDeploymentAnnotations map[string]string            `json:"machineDeploymentAnnotations,omitempty"`
+
+	// MinSize and MaxSize enable cluster-autoscaler for the pool, Quantity is then only the initial
+	// size and the autoscaler adjusts the number of machines between these bounds. With a MinSize of 0
+	// the pool can be scaled down to and up from zero machines.
+	MinSize *int32 `json:"minSize,omitempty"`
+	MaxSize *int32 `json:"maxSize,omitempty"`
+
+	// UnhealthyNodeTimeout enables machine health checks for the pool. A machine is remediated, deleted and
+	// replaced, when its node is not ready for longer than this timeout.
+	UnhealthyNodeTimeout *metav1.Duration `json:"unhealthyNodeTimeout,omitempty"`
+	// NodeStartupTimeout is how long a machine can run without a node before it is remediated.
+	NodeStartupTimeout *metav1.Duration `json:"nodeStartupTimeout,omitempty"`
+	// MaxUnhealthy stops remediation when more machines of the pool are unhealthy, either a number or
+	// a percentage such as 40%. Etcd and control plane pools default to 1, or to 0 when the cluster has
+	// fewer than three etcd machines, so remediation never takes etcd below quorum.
+	MaxUnhealthy *string `json:"maxUnhealthy,omitempty"`
+	// UnhealthyRange only allows remediation when the number of unhealthy machines is within the range,
+	// e.g. [3-5]. It takes precedence over MaxUnhealthy.
+	UnhealthyRange *string `json:"unhealthyRange,omitempty"`
+
+	// FailureDomains spreads the machines of the pool across a machine config per failure domain, such
+	// as an availability zone, instead of the single machineConfigRef. Quantity is distributed evenly,
+	// also when domains are added or removed, in which case machines are moved between domains.
+	FailureDomains []RKEMachinePoolFailureDomain `json:"failureDomains,omitempty"`
+
+	// MachineProvisionMaxAttempts is how often the provisioning of a machine is tried, with an exponential
+	// backoff between attempts, before the machine is marked as failed. Whatever a failed attempt created
+	// is removed by the driver before the next attempt. Defaults to 1.
+	MachineProvisionMaxAttempts *int32 `json:"machineProvisionMaxAttempts,omitempty"`
+	// ReplaceFailedMachines deletes machines that failed to provision so the pool creates new ones.
+	ReplaceFailedMachines bool `json:"replaceFailedMachines,omitempty"`
+}
+
+type RKEMachinePoolFailureDomain struct {
+	Name       string                  `json:"name,omitempty" wrangler:"required"`
+	NodeConfig *corev1.ObjectReference `json:"machineConfigRef,omitempty" wrangler:"required"`
 }
 
 type RKEMachinePoolRollingUpdate struct {
@@ -58,8 +95,22 @@ type RKEMachinePoolRollingUpdate struct {
 type RKEConfig struct {
 	rkev1.RKEClusterSpecCommon
 
-	ETCDSnapshotCreate  *rkev1.ETCDSnapshotCreate  `json:"etcdSnapshotCreate,omitempty"`
-	ETCDSnapshotRestore *rkev1.ETCDSnapshotRestore `json:"etcdSnapshotRestore,omitempty"`
-	MachinePools        []RKEMachinePool           `json:"machinePools,omitempty"`
-	InfrastructureRef   *corev1.ObjectReference    `json:"infrastructureRef,omitempty"`
+	ETCDSnapshotCreate   *rkev1.ETCDSnapshotCreate   `json:"etcdSnapshotCreate,omitempty"`
+	ETCDSnapshotRestore  *rkev1.ETCDSnapshotRestore  `json:"etcdSnapshotRestore,omitempty"`
+	RotateCertificates   *rkev1.RotateCertificates   `json:"rotateCertificates,omitempty"`
+	RotateEncryptionKeys *rkev1.RotateEncryptionKeys `json:"rotateEncryptionKeys,omitempty"`
+	Adoption             *rkev1.Adoption             `json:"adoption,omitempty"`
+	MachinePools         []RKEMachinePool            `json:"machinePools,omitempty"`
+	InfrastructureRef    *corev1.ObjectReference     `json:"infrastructureRef,omitempty"`
+	ClusterAutoscaler    *ClusterAutoscalerConfig    `json:"clusterAutoscaler,omitempty"`
+}
+
+// ClusterAutoscalerConfig deploys cluster-autoscaler into the cluster. It uses the Cluster API provider
+// against the management cluster to scale the machine pools that have a minSize and maxSize.
+type ClusterAutoscalerConfig struct {
+	Enabled bool `json:"enabled,omitempty"`
+	// Image overrides the cluster-autoscaler-image setting.
+	Image string `json:"image,omitempty"`
+	// ExtraArgs are appended to the cluster-autoscaler command line, e.g. --scale-down-delay-after-add=5m.
+	ExtraArgs []string `json:"extraArgs,omitempty"`
 }
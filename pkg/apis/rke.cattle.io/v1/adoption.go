@@ -0,0 +1,9 @@
+package v1
+
+// Adoption takes over the management of an existing cluster that was installed by hand. The existing nodes are
+// registered like custom nodes, their configuration is converted to machineSelectorConfig entries of the cluster
+// and their services are only restarted if the configuration generated by Rancher differs. The content of file
+// parameters like private-registry is kept in a secret of the control plane instead of the cluster.
+type Adoption struct {
+	Enabled bool `json:"enabled,omitempty"`
+}
@@ -0,0 +1,21 @@
+package v1
+
+type RotateCertificates struct {
+	// Services is a list of services to rotate the certificates of, all services are rotated when empty.
+	// Valid services are admin, api-server, controller-manager, scheduler, cloud-controller, etcd,
+	// auth-proxy, kubelet, kube-proxy and the controller and server of the runtime, such as
+	// rke2-controller and rke2-server. Any other service fails the rotation.
+	Services []string `json:"services,omitempty"`
+	// Changing the Generation is the only thing required to initiate a certificate rotation.
+	Generation int64 `json:"generation,omitempty"`
+}
+
+type RotateCertificatesPhase string
+
+var (
+	RotateCertificatesPhaseStarted      RotateCertificatesPhase = "Started"
+	RotateCertificatesPhaseControlPlane RotateCertificatesPhase = "ControlPlane"
+	RotateCertificatesPhaseWorker       RotateCertificatesPhase = "Worker"
+	RotateCertificatesPhaseFinished     RotateCertificatesPhase = "Finished"
+	RotateCertificatesPhaseFailed       RotateCertificatesPhase = "Failed"
+)
@@ -23,13 +23,16 @@ type EnvVar struct {
 type RKEControlPlaneSpec struct {
 	RKEClusterSpecCommon
 
-	AgentEnvVars          []EnvVar             `json:"agentEnvVars,omitempty"`
-	ETCDSnapshotCreate    *ETCDSnapshotCreate  `json:"etcdSnapshotCreate,omitempty"`
-	ETCDSnapshotRestore   *ETCDSnapshotRestore `json:"etcdSnapshotRestore,omitempty"`
-	KubernetesVersion     string               `json:"kubernetesVersion,omitempty"`
-	ClusterName           string               `json:"clusterName,omitempty" wrangler:"required"`
-	ManagementClusterName string               `json:"managementClusterName,omitempty" wrangler:"required"`
-	UnmanagedConfig       bool                 `json:"unmanagedConfig,omitempty"`
+	AgentEnvVars          []EnvVar              `json:"agentEnvVars,omitempty"`
+	ETCDSnapshotCreate    *ETCDSnapshotCreate   `json:"etcdSnapshotCreate,omitempty"`
+	ETCDSnapshotRestore   *ETCDSnapshotRestore  `json:"etcdSnapshotRestore,omitempty"`
+	RotateCertificates    *RotateCertificates   `json:"rotateCertificates,omitempty"`
+	RotateEncryptionKeys  *RotateEncryptionKeys `json:"rotateEncryptionKeys,omitempty"`
+	Adoption              *Adoption             `json:"adoption,omitempty"`
+	KubernetesVersion     string                `json:"kubernetesVersion,omitempty"`
+	ClusterName           string                `json:"clusterName,omitempty" wrangler:"required"`
+	ManagementClusterName string                `json:"managementClusterName,omitempty" wrangler:"required"`
+	UnmanagedConfig       bool                  `json:"unmanagedConfig,omitempty"`
 }
 
 type ETCDSnapshotPhase string
@@ -43,12 +46,18 @@ type RKEControlPlaneSpec struct {
 )
 
 type RKEControlPlaneStatus struct {
-	Conditions               []genericcondition.GenericCondition `json:"conditions,omitempty"`
-	Ready                    bool                                `json:"ready,omitempty"`
-	ObservedGeneration       int64                               `json:"observedGeneration"`
-	ETCDSnapshotRestore      *ETCDSnapshotRestore                `json:"etcdSnapshotRestore,omitempty"`
-	ETCDSnapshotRestorePhase ETCDSnapshotPhase                   `json:"etcdSnapshotRestorePhase,omitempty"`
-	ETCDSnapshotCreate       *ETCDSnapshotCreate                 `json:"etcdSnapshotCreate,omitempty"`
-	ETCDSnapshotCreatePhase  ETCDSnapshotPhase                   `json:"etcdSnapshotCreatePhase,omitempty"`
-	ConfigGeneration         int64                               `json:"configGeneration,omitempty"`
+	Conditions                  []genericcondition.GenericCondition `json:"conditions,omitempty"`
+	Ready                       bool                                `json:"ready,omitempty"`
+	ObservedGeneration          int64                               `json:"observedGeneration"`
+	ETCDSnapshotRestore         *ETCDSnapshotRestore                `json:"etcdSnapshotRestore,omitempty"`
+	ETCDSnapshotRestorePhase    ETCDSnapshotPhase                   `json:"etcdSnapshotRestorePhase,omitempty"`
+	ETCDSnapshotCreate          *ETCDSnapshotCreate                 `json:"etcdSnapshotCreate,omitempty"`
+	ETCDSnapshotCreatePhase     ETCDSnapshotPhase                   `json:"etcdSnapshotCreatePhase,omitempty"`
+	ConfigGeneration            int64                               `json:"configGeneration,omitempty"`
+	RotateCertificates          *RotateCertificates                 `json:"rotateCertificates,omitempty"`
+	RotateCertificatesPhase     RotateCertificatesPhase             `json:"rotateCertificatesPhase,omitempty"`
+	RotateEncryptionKeys        *RotateEncryptionKeys               `json:"rotateEncryptionKeys,omitempty"`
+	RotateEncryptionKeysPhase   RotateEncryptionKeysPhase           `json:"rotateEncryptionKeysPhase,omitempty"`
+	RotateEncryptionKeysLeader  string                              `json:"rotateEncryptionKeysLeader,omitempty"`
+	RotateEncryptionKeysMessage string                              `json:"rotateEncryptionKeysMessage,omitempty"`
 }
@@ -0,0 +1,20 @@
+package v1
+
+type RotateEncryptionKeys struct {
+	// Changing the Generation is the only thing required to initiate a rotation of the secrets encryption key.
+	Generation int64 `json:"generation,omitempty"`
+}
+
+type RotateEncryptionKeysPhase string
+
+var (
+	RotateEncryptionKeysPhaseStarted              RotateEncryptionKeysPhase = "Started"
+	RotateEncryptionKeysPhasePrepare              RotateEncryptionKeysPhase = "Prepare"
+	RotateEncryptionKeysPhasePostPrepareRestart   RotateEncryptionKeysPhase = "PostPrepareRestart"
+	RotateEncryptionKeysPhaseRotate               RotateEncryptionKeysPhase = "Rotate"
+	RotateEncryptionKeysPhasePostRotateRestart    RotateEncryptionKeysPhase = "PostRotateRestart"
+	RotateEncryptionKeysPhaseReencrypt            RotateEncryptionKeysPhase = "Reencrypt"
+	RotateEncryptionKeysPhasePostReencryptRestart RotateEncryptionKeysPhase = "PostReencryptRestart"
+	RotateEncryptionKeysPhaseDone                 RotateEncryptionKeysPhase = "Done"
+	RotateEncryptionKeysPhaseFailed               RotateEncryptionKeysPhase = "Failed"
+)
@@ -22,6 +22,8 @@ type RKEMachineStatus struct {
 	FailureReason             string                `json:"failureReason,omitempty"`
 	FailureMessage            string                `json:"failureMessage,omitempty"`
 	Addresses                 []capi.MachineAddress `json:"addresses,omitempty"`
+	ProvisionAttempts         int32                 `json:"provisionAttempts,omitempty"`
+	ProvisionLogSecretName    string                `json:"provisionLogSecretName,omitempty"`
 }
 
 // +genclient
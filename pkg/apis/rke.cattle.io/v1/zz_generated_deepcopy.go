@@ -28,6 +28,22 @@
 	v1alpha4 "sigs.k8s.io/cluster-api/api/v1alpha4"
 )
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *Adoption) DeepCopyInto(out *Adoption) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Adoption.
+func (in *Adoption) DeepCopy() *Adoption {
+	if in == nil {
+		return nil
+	}
+	out := new(Adoption)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AuthConfig) DeepCopyInto(out *AuthConfig) {
 	*out = *in
@@ -792,6 +808,21 @@ func (in *RKEControlPlaneSpec) DeepCopyInto(out *RKEControlPlaneSpec) {
 		*out = new(ETCDSnapshotRestore)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RotateCertificates != nil {
+		in, out := &in.RotateCertificates, &out.RotateCertificates
+		*out = new(RotateCertificates)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RotateEncryptionKeys != nil {
+		in, out := &in.RotateEncryptionKeys, &out.RotateEncryptionKeys
+		*out = new(RotateEncryptionKeys)
+		**out = **in
+	}
+	if in.Adoption != nil {
+		in, out := &in.Adoption, &out.Adoption
+		*out = new(Adoption)
+		**out = **in
+	}
 	return
 }
 
@@ -823,6 +854,16 @@ func (in *RKEControlPlaneStatus) DeepCopyInto(out *RKEControlPlaneStatus) {
 		*out = new(ETCDSnapshotCreate)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.RotateCertificates != nil {
+		in, out := &in.RotateCertificates, &out.RotateCertificates
+		*out = new(RotateCertificates)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RotateEncryptionKeys != nil {
+		in, out := &in.RotateEncryptionKeys, &out.RotateEncryptionKeys
+		*out = new(RotateEncryptionKeys)
+		**out = **in
+	}
 	return
 }
 
@@ -929,3 +970,40 @@ func (in *RegistryConfig) DeepCopy() *RegistryConfig {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RotateCertificates) DeepCopyInto(out *RotateCertificates) {
+	*out = *in
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RotateCertificates.
+func (in *RotateCertificates) DeepCopy() *RotateCertificates {
+	if in == nil {
+		return nil
+	}
+	out := new(RotateCertificates)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RotateEncryptionKeys) DeepCopyInto(out *RotateEncryptionKeys) {
+	*out = *in
+	return
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RotateEncryptionKeys.
+func (in *RotateEncryptionKeys) DeepCopy() *RotateEncryptionKeys {
+	if in == nil {
+		return nil
+	}
+	out := new(RotateEncryptionKeys)
+	in.DeepCopyInto(out)
+	return out
+}
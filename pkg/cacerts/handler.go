@@ -7,8 +7,10 @@
 	"encoding/base64"
 	"net/http"
 	"strings"
+	"time"
 
 	apimgmtv3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/clusterregistrationtoken"
 	v3 "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/settings"
 	"github.com/rancher/rancher/pkg/tls"
@@ -50,9 +52,8 @@ func handler(clusterRegistrationToken v3.ClusterRegistrationTokenCache, rw http.
 	authorization := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
 
 	if authorization != "" && nonce != "" {
-		crt, err := clusterRegistrationToken.GetByIndex(tokenHash, authorization)
-		if err == nil && len(crt) >= 0 {
-			digest := hmac.New(sha512.New, []byte(crt[0].Status.Token))
+		if crt := usableToken(clusterRegistrationToken, authorization); crt != nil {
+			digest := hmac.New(sha512.New, []byte(crt.Status.Token))
 			digest.Write([]byte(nonce))
 			digest.Write([]byte{0})
 			digest.Write(bytes)
@@ -66,3 +67,17 @@ func handler(clusterRegistrationToken v3.ClusterRegistrationTokenCache, rw http.
 		_, _ = rw.Write([]byte(ca))
 	}
 }
+
+// usableToken returns the token with the hash if it can still be used to register agents, see
+// clusterregistrationtoken.Find
+func usableToken(clusterRegistrationToken v3.ClusterRegistrationTokenCache, hash string) *apimgmtv3.ClusterRegistrationToken {
+	crts, err := clusterRegistrationToken.GetByIndex(tokenHash, hash)
+	if err != nil {
+		return nil
+	}
+	crt, err := clusterregistrationtoken.Find(crts, time.Now())
+	if err != nil {
+		return nil
+	}
+	return crt
+}
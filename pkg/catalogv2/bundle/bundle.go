@@ -0,0 +1,203 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"helm.sh/helm/v3/pkg/provenance"
+	"helm.sh/helm/v3/pkg/repo"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// Dir is where bundles are extracted so they can be served as a ClusterRepo with a
+	// URL of the form file:///var/lib/rancher-data/local-catalogs/bundles/<name>. Bundles are
+	// imported by the importBundle action of ClusterRepos, which stores them in the cluster, and
+	// extracted on every Rancher server by Ensure.
+	Dir = "/var/lib/rancher-data/local-catalogs/bundles"
+
+	IndexFile  = "index.yaml"
+	ImagesFile = "images.txt"
+	ChartsDir  = "charts"
+)
+
+// ChartFunc returns the chart tarball of the given chart version
+type ChartFunc func(chartName, version string) (io.ReadCloser, error)
+
+// Selection maps chart names to a semver constraint. An empty constraint selects only the
+// latest version of the chart. An empty Selection selects the latest version of all charts.
+type Selection map[string]string
+
+// ParseSelection parses values of the form "name" or "name:constraint"
+func ParseSelection(values []string) Selection {
+	result := Selection{}
+	for _, value := range values {
+		name, constraint := value, ""
+		if i := strings.Index(value, ":"); i >= 0 {
+			name, constraint = value[:i], value[i+1:]
+		}
+		if name != "" {
+			result[name] = constraint
+		}
+	}
+	return result
+}
+
+// Validate returns an error if the selection doesn't match any version of a chart in the index, so
+// the error can be returned before a bundle is streamed.
+func Validate(index *repo.IndexFile, selection Selection) error {
+	_, err := selectVersions(index, selection)
+	return err
+}
+
+// Export writes a gzipped tarball containing the selected chart versions, an index.yaml
+// referencing them relative to the bundle root and the list of images they use.
+func Export(w io.Writer, index *repo.IndexFile, selection Selection, chart ChartFunc) error {
+	versions, err := selectVersions(index, selection)
+	if err != nil {
+		return err
+	}
+
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+
+	var (
+		newIndex = repo.NewIndexFile()
+		images   = map[string]bool{}
+	)
+
+	for _, version := range versions {
+		data, err := readChart(chart, version)
+		if err != nil {
+			return err
+		}
+
+		chartImages, err := Images(data)
+		if err != nil {
+			return fmt.Errorf("failed to find images of chart %s version %s: %w", version.Name, version.Version, err)
+		}
+		for _, image := range chartImages {
+			images[image] = true
+		}
+
+		file := path.Join(ChartsDir, fmt.Sprintf("%s-%s.tgz", version.Name, version.Version))
+		if err := writeFile(tw, file, data); err != nil {
+			return err
+		}
+
+		digest, err := provenance.Digest(bytes.NewReader(data))
+		if err != nil {
+			return err
+		}
+
+		copied := *version
+		copied.URLs = []string{file}
+		copied.Digest = digest
+		newIndex.Entries[version.Name] = append(newIndex.Entries[version.Name], &copied)
+	}
+
+	newIndex.SortEntries()
+	indexData, err := yaml.Marshal(newIndex)
+	if err != nil {
+		return err
+	}
+	if err := writeFile(tw, IndexFile, indexData); err != nil {
+		return err
+	}
+
+	imageList := make([]string, 0, len(images))
+	for image := range images {
+		imageList = append(imageList, image)
+	}
+	sort.Strings(imageList)
+	if err := writeFile(tw, ImagesFile, []byte(strings.Join(imageList, "\n")+"\n")); err != nil {
+		return err
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func selectVersions(index *repo.IndexFile, selection Selection) ([]*repo.ChartVersion, error) {
+	index.SortEntries()
+
+	if len(selection) == 0 {
+		selection = Selection{}
+		for name := range index.Entries {
+			selection[name] = ""
+		}
+	}
+
+	names := make([]string, 0, len(selection))
+	for name := range selection {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var result []*repo.ChartVersion
+	for _, name := range names {
+		versions := index.Entries[name]
+		if len(versions) == 0 {
+			return nil, fmt.Errorf("chart %s not found", name)
+		}
+
+		constraintStr := selection[name]
+		if constraintStr == "" {
+			result = append(result, versions[0])
+			continue
+		}
+
+		constraint, err := semver.NewConstraint(constraintStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version constraint %s for chart %s: %w", constraintStr, name, err)
+		}
+
+		found := false
+		for _, version := range versions {
+			v, err := semver.NewVersion(version.Version)
+			if err != nil || !constraint.Check(v) {
+				continue
+			}
+			result = append(result, version)
+			found = true
+		}
+		if !found {
+			return nil, fmt.Errorf("no version of chart %s matches %s", name, constraintStr)
+		}
+	}
+
+	return result, nil
+}
+
+func readChart(chart ChartFunc, version *repo.ChartVersion) ([]byte, error) {
+	rc, err := chart(version.Name, version.Version)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return ioutil.ReadAll(rc)
+}
+
+func writeFile(tw *tar.Writer, name string, data []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Name:    name,
+		Mode:    0644,
+		Size:    int64(len(data)),
+		ModTime: time.Now(),
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
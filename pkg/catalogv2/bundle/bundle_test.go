@@ -0,0 +1,139 @@
+package bundle
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+func TestSelectVersions(t *testing.T) {
+	index := repo.NewIndexFile()
+	for _, version := range []string{"1.0.0", "1.1.0", "2.0.0"} {
+		index.Entries["foo"] = append(index.Entries["foo"], &repo.ChartVersion{
+			Metadata: &chart.Metadata{Name: "foo", Version: version},
+		})
+	}
+	index.Entries["bar"] = append(index.Entries["bar"], &repo.ChartVersion{
+		Metadata: &chart.Metadata{Name: "bar", Version: "0.1.0"},
+	})
+
+	tests := []struct {
+		name             string
+		selection        []string
+		expectedVersions []string
+		expectedErr      bool
+	}{
+		{
+			"latest version of all charts",
+			nil,
+			[]string{"bar-0.1.0", "foo-2.0.0"},
+			false,
+		},
+		{
+			"latest version of selected chart",
+			[]string{"foo"},
+			[]string{"foo-2.0.0"},
+			false,
+		},
+		{
+			"versions matching constraint",
+			[]string{"foo:< 2.0.0"},
+			[]string{"foo-1.1.0", "foo-1.0.0"},
+			false,
+		},
+		{
+			"no version matching constraint",
+			[]string{"foo:> 3.0.0"},
+			nil,
+			true,
+		},
+		{
+			"missing chart",
+			[]string{"baz"},
+			nil,
+			true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			versions, err := selectVersions(index, ParseSelection(tt.selection))
+			if tt.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+
+			var result []string
+			for _, version := range versions {
+				result = append(result, version.Name+"-"+version.Version)
+			}
+			assert.Equal(t, tt.expectedVersions, result)
+		})
+	}
+}
+
+func TestRewriteValues(t *testing.T) {
+	tests := []struct {
+		name           string
+		values         string
+		expectedValues string
+	}{
+		{
+			"repository and tag",
+			`image:
+  repository: rancher/foo
+  tag: v1
+`,
+			`image:
+  repository: registry.local/rancher/foo
+  tag: v1
+`,
+		},
+		{
+			"image string in list",
+			`sidecars:
+  - image: rancher/bar:v2
+`,
+			`sidecars:
+  - image: registry.local/rancher/bar:v2
+`,
+		},
+		{
+			"already prefixed and repository without tag",
+			`image: registry.local/rancher/foo:v1
+source:
+  repository: https://github.com/rancher/foo
+`,
+			`image: registry.local/rancher/foo:v1
+source:
+  repository: https://github.com/rancher/foo
+`,
+		},
+		{
+			"comments are kept",
+			`# Default values for foo.
+image:
+  # The image to run
+  repository: rancher/foo
+  tag: v1 # pinned
+`,
+			`# Default values for foo.
+image:
+  # The image to run
+  repository: registry.local/rancher/foo
+  tag: v1 # pinned
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result, err := rewriteValues([]byte(tt.values), "registry.local/")
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedValues, string(result))
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"log"
+	"os"
+
+	"github.com/rancher/rancher/pkg/catalogv2/bundle"
+	"github.com/rancher/rancher/pkg/catalogv2/git"
+	helmhttp "github.com/rancher/rancher/pkg/catalogv2/http"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+const (
+	gitNamespace = "bundle"
+	gitName      = "export"
+)
+
+func main() {
+	branch := flag.String("git-branch", "", "export from a git repo instead of a Helm HTTP repo, using this branch")
+	flag.Parse()
+
+	if flag.NArg() < 2 {
+		log.Fatal("\"main.go\" requires at least 2 arguments. Usage: go run main.go [-git-branch BRANCH] [REPO_URL] [OUTPUT] [CHART[:CONSTRAINT]]...")
+	}
+
+	args := flag.Args()
+	if err := run(args[0], *branch, args[1], args[2:]); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func run(repoURL, branch, output string, charts []string) error {
+	index, chart, err := source(repoURL, branch)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return bundle.Export(f, index, bundle.ParseSelection(charts), func(chartName, version string) (io.ReadCloser, error) {
+		chartVersion, err := index.Get(chartName, version)
+		if err != nil {
+			return nil, err
+		}
+		return chart(chartVersion)
+	})
+}
+
+// source returns the index of the repo and a function reading its charts, the same way the ClusterRepo
+// controller reads HTTP and git repos.
+func source(repoURL, branch string) (*repo.IndexFile, func(*repo.ChartVersion) (io.ReadCloser, error), error) {
+	if branch == "" {
+		index, err := helmhttp.DownloadIndex(nil, repoURL, nil, false)
+		return index, func(chartVersion *repo.ChartVersion) (io.ReadCloser, error) {
+			return helmhttp.Chart(nil, repoURL, nil, false, chartVersion)
+		}, err
+	}
+
+	if _, err := git.Update(nil, gitNamespace, gitName, repoURL, branch, false); err != nil {
+		return nil, nil, err
+	}
+	index, err := git.BuildOrGetIndex(gitNamespace, gitName, repoURL)
+	return index, func(chartVersion *repo.ChartVersion) (io.ReadCloser, error) {
+		return git.Chart(gitNamespace, gitName, repoURL, chartVersion)
+	}, err
+}
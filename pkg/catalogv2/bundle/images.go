@@ -0,0 +1,116 @@
+package bundle
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+
+	"github.com/rancher/wrangler/pkg/yaml"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/engine"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Images returns the images used by the chart tarball. The chart is rendered with its default
+// values and the image fields of the resulting objects are collected. If the chart cannot be
+// rendered with its defaults, the values are searched for repository and tag pairs instead.
+func Images(chartTgz []byte) ([]string, error) {
+	chrt, err := loader.LoadArchive(bytes.NewReader(chartTgz))
+	if err != nil {
+		return nil, err
+	}
+
+	images := map[string]bool{}
+	if err := renderedImages(chrt, images); err != nil {
+		valuesImages(chrt.Values, images)
+		for _, dep := range chrt.Dependencies() {
+			valuesImages(dep.Values, images)
+		}
+	}
+
+	result := make([]string, 0, len(images))
+	for image := range images {
+		result = append(result, image)
+	}
+	sort.Strings(result)
+	return result, nil
+}
+
+func renderedImages(chrt *chart.Chart, images map[string]bool) error {
+	values, err := chartutil.ToRenderValues(chrt, nil, chartutil.ReleaseOptions{
+		Name:      chrt.Name(),
+		Namespace: "default",
+		IsInstall: true,
+	}, chartutil.DefaultCapabilities)
+	if err != nil {
+		return err
+	}
+
+	files, err := engine.Render(chrt, values)
+	if err != nil {
+		return err
+	}
+
+	for name, content := range files {
+		if !strings.HasSuffix(name, ".yaml") && !strings.HasSuffix(name, ".yml") {
+			continue
+		}
+		objs, err := yaml.ToObjects(bytes.NewBufferString(content))
+		if err != nil {
+			return err
+		}
+		for _, obj := range objs {
+			if u, ok := obj.(*unstructured.Unstructured); ok {
+				objectImages(u.Object, images)
+			}
+		}
+	}
+
+	return nil
+}
+
+func objectImages(data interface{}, images map[string]bool) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		for key, value := range v {
+			if s, ok := value.(string); ok && key == "image" && s != "" {
+				images[s] = true
+				continue
+			}
+			objectImages(value, images)
+		}
+	case []interface{}:
+		for _, value := range v {
+			objectImages(value, images)
+		}
+	}
+}
+
+func valuesImages(data interface{}, images map[string]bool) {
+	switch v := data.(type) {
+	case map[string]interface{}:
+		repository, _ := v["repository"].(string)
+		tag, _ := v["tag"].(string)
+		if repository != "" && tag != "" {
+			images[repository+":"+tag] = true
+		}
+		for _, value := range v {
+			valuesImages(value, images)
+		}
+	case []interface{}:
+		for _, value := range v {
+			valuesImages(value, images)
+		}
+	}
+}
+
+// PrefixRegistry prefixes image with registry unless it is empty or image already starts with it
+func PrefixRegistry(registry, image string) string {
+	registry = strings.TrimSuffix(registry, "/")
+	if registry == "" || image == "" || strings.HasPrefix(image, registry+"/") {
+		return image
+	}
+	return registry + "/" + image
+}
@@ -0,0 +1,98 @@
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+// Import extracts a bundle written by Export into dir, so it can be served as a ClusterRepo with a file:// URL.
+// The bundle is extracted next to dir first and only moved in place once it is complete, an existing bundle is
+// never overwritten.
+func Import(r io.Reader, dir string) error {
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("bundle %s already exists", path.Base(dir))
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(dir), 0755); err != nil {
+		return err
+	}
+
+	tmp, err := ioutil.TempDir(filepath.Dir(dir), "."+filepath.Base(dir)+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(tmp)
+
+	if err := extract(r, tmp); err != nil {
+		return err
+	}
+
+	if _, err := repo.LoadIndexFile(filepath.Join(tmp, IndexFile)); err != nil {
+		return fmt.Errorf("invalid bundle: %w", err)
+	}
+
+	return os.Rename(tmp, dir)
+}
+
+func extract(r io.Reader, dir string) error {
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return err
+	}
+	defer gz.Close()
+
+	tr := tar.NewReader(gz)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		name := path.Clean(header.Name)
+		if path.IsAbs(name) || name == ".." || strings.HasPrefix(name, "../") {
+			return fmt.Errorf("invalid path %s in bundle", header.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := writeTarget(target, tr); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported file type of %s in bundle", header.Name)
+		}
+	}
+}
+
+func writeTarget(target string, r io.Reader) error {
+	if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(target, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, r); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
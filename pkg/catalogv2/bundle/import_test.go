@@ -0,0 +1,59 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func tarball(t *testing.T, files map[string]string) *bytes.Buffer {
+	buf := &bytes.Buffer{}
+	gz := gzip.NewWriter(buf)
+	tw := tar.NewWriter(gz)
+	for name, content := range files {
+		assert.NoError(t, writeFile(tw, name, []byte(content)))
+	}
+	assert.NoError(t, tw.Close())
+	assert.NoError(t, gz.Close())
+	return buf
+}
+
+func TestImport(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "bundles", "test")
+
+	err := Import(tarball(t, map[string]string{
+		IndexFile:                 "apiVersion: v1\nentries: {}\n",
+		ImagesFile:                "rancher/foo:v1\n",
+		"charts/foo-1.0.0.tgz":    "chart",
+		"charts/nested/other.txt": "other",
+	}), dir)
+	assert.NoError(t, err)
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, "charts", "foo-1.0.0.tgz"))
+	assert.NoError(t, err)
+	assert.Equal(t, "chart", string(data))
+
+	err = Import(tarball(t, map[string]string{
+		IndexFile: "apiVersion: v1\nentries: {}\n",
+	}), dir)
+	assert.Error(t, err, "existing bundle must not be overwritten")
+
+	err = Import(tarball(t, map[string]string{
+		IndexFile:        "apiVersion: v1\nentries: {}\n",
+		"../escaped.txt": "escaped",
+	}), filepath.Join(filepath.Dir(dir), "escape"))
+	assert.Error(t, err)
+	assert.NoFileExists(t, filepath.Join(filepath.Dir(dir), "escaped.txt"))
+	assert.NoDirExists(t, filepath.Join(filepath.Dir(dir), "escape"))
+
+	err = Import(tarball(t, map[string]string{
+		ImagesFile: "rancher/foo:v1\n",
+	}), filepath.Join(filepath.Dir(dir), "noindex"))
+	assert.Error(t, err)
+	assert.NoDirExists(t, filepath.Join(filepath.Dir(dir), "noindex"))
+}
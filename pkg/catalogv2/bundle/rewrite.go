@@ -0,0 +1,131 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// RewriteImages returns a copy of the chart tarball whose values.yaml files, including the ones of
+// packaged subcharts, reference images from registry instead of their original registry.
+func RewriteImages(chartTgz []byte, registry string) ([]byte, error) {
+	if registry == "" {
+		return chartTgz, nil
+	}
+
+	gzr, err := gzip.NewReader(bytes.NewReader(chartTgz))
+	if err != nil {
+		return nil, err
+	}
+	defer gzr.Close()
+
+	buf := &bytes.Buffer{}
+	gzw := gzip.NewWriter(buf)
+	tr := tar.NewReader(gzr)
+	tw := tar.NewWriter(gzw)
+
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+
+		data, err := ioutil.ReadAll(tr)
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case path.Base(header.Name) == "values.yaml":
+			data, err = rewriteValues(data, registry)
+		case path.Base(path.Dir(header.Name)) == "charts" && strings.HasSuffix(header.Name, ".tgz"):
+			data, err = RewriteImages(data, registry)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		header.Size = int64(len(data))
+		if err := tw.WriteHeader(header); err != nil {
+			return nil, err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, err
+	}
+	if err := gzw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// rewriteValues rewrites the images of values.yaml. The document is edited as a YAML node tree so
+// the comments documenting the values are kept.
+func rewriteValues(data []byte, registry string) ([]byte, error) {
+	doc := &yaml.Node{}
+	if err := yaml.Unmarshal(data, doc); err != nil {
+		return nil, err
+	}
+	if !rewriteNode(doc, registry) {
+		return data, nil
+	}
+
+	buf := &bytes.Buffer{}
+	encoder := yaml.NewEncoder(buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(doc); err != nil {
+		return nil, err
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// rewriteNode prefixes image strings and the repository of repository and tag pairs with
+// registry and returns whether anything was changed
+func rewriteNode(node *yaml.Node, registry string) bool {
+	changed := false
+	switch node.Kind {
+	case yaml.DocumentNode, yaml.SequenceNode:
+		for _, child := range node.Content {
+			if rewriteNode(child, registry) {
+				changed = true
+			}
+		}
+	case yaml.MappingNode:
+		hasTag := false
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			if node.Content[i].Value == "tag" {
+				hasTag = true
+			}
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i].Value, node.Content[i+1]
+			if value.Kind == yaml.ScalarNode && value.Tag == "!!str" &&
+				(key == "image" || (key == "repository" && hasTag)) {
+				if prefixed := PrefixRegistry(registry, value.Value); prefixed != value.Value {
+					value.Value = prefixed
+					changed = true
+				}
+				continue
+			}
+			if rewriteNode(value, registry) {
+				changed = true
+			}
+		}
+	}
+	return changed
+}
@@ -0,0 +1,189 @@
+package bundle
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	namespaces "github.com/rancher/rancher/pkg/namespace"
+	corev1controllers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	name2 "github.com/rancher/wrangler/pkg/name"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// chunkSize keeps every ConfigMap of a stored bundle below the size limit of objects in etcd
+	chunkSize = 900 << 10
+
+	nextAnnotation = "catalog.cattle.io/next"
+	contentKey     = "content"
+
+	// uidFile records the UID of the repo a bundle was extracted for
+	uidFile = ".repo-uid"
+)
+
+// URL returns the URL of the ClusterRepo serving the imported bundle
+func URL(name string) string {
+	return "file://" + path.Join(Dir, name)
+}
+
+// Name returns the name of the imported bundle served by the repo URL, or "" if the URL is not one of an
+// imported bundle.
+func Name(repoURL string) string {
+	if !strings.HasPrefix(repoURL, "file://"+Dir+"/") {
+		return ""
+	}
+	name := strings.TrimPrefix(repoURL, "file://"+Dir+"/")
+	if name == "" || strings.Contains(name, "/") || name == "." || name == ".." {
+		return ""
+	}
+	return name
+}
+
+// Store saves the bundle read from r in ConfigMaps owned by the ClusterRepo serving it, so every Rancher server
+// can extract it with Ensure and the bundle is deleted with the repo. The ConfigMaps are written from the last to
+// the first one, so the first one only exists once the bundle is complete.
+func Store(configMaps corev1controllers.ConfigMapClient, metadata *metav1.ObjectMeta, r io.ReaderAt, size int64) error {
+	owner := metav1.OwnerReference{
+		APIVersion: catalog.SchemeGroupVersion.String(),
+		Kind:       "ClusterRepo",
+		Name:       metadata.Name,
+		UID:        metadata.UID,
+	}
+
+	chunks := int((size + chunkSize - 1) / chunkSize)
+	for i := chunks - 1; i >= 0; i-- {
+		data := make([]byte, chunkSize)
+		n, err := r.ReadAt(data, int64(i)*chunkSize)
+		if err != nil && err != io.EOF {
+			return err
+		}
+
+		next := ""
+		if i < chunks-1 {
+			next = chunkName(metadata, i+1)
+		}
+
+		_, err = configMaps.Create(&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:            chunkName(metadata, i),
+				Namespace:       namespaces.System,
+				OwnerReferences: []metav1.OwnerReference{owner},
+				Annotations: map[string]string{
+					nextAnnotation: next,
+				},
+			},
+			BinaryData: map[string][]byte{
+				contentKey: data[:n],
+			},
+		})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Ensure extracts the imported bundle served by the repo from its ConfigMaps if this Rancher server doesn't have
+// it yet. A bundle extracted for a deleted repo of the same name is replaced. Repos that don't serve an imported
+// bundle are ignored.
+func Ensure(configMaps corev1controllers.ConfigMapCache, repoURL string, metadata *metav1.ObjectMeta) error {
+	name := Name(repoURL)
+	if name == "" {
+		return nil
+	}
+
+	return ensure(configMaps, Dir, name, metadata)
+}
+
+func ensure(configMaps corev1controllers.ConfigMapCache, bundlesDir, name string, metadata *metav1.ObjectMeta) error {
+	dir := filepath.Join(bundlesDir, name)
+	if extractedFor(dir, metadata.UID) {
+		return nil
+	}
+
+	head, err := configMaps.Get(namespaces.System, chunkName(metadata, 0))
+	if err != nil {
+		return fmt.Errorf("failed to get bundle %s: %w", name, err)
+	}
+	if len(head.OwnerReferences) == 0 || head.OwnerReferences[0].UID != metadata.UID {
+		return fmt.Errorf("bundle %s is not owned by repo %s", name, metadata.Name)
+	}
+
+	if err := os.MkdirAll(bundlesDir, 0755); err != nil {
+		return err
+	}
+	staging, err := ioutil.TempDir(bundlesDir, "."+name+"-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(staging)
+
+	extracted := filepath.Join(staging, "bundle")
+	if err := Import(&chunkReader{configMaps: configMaps, next: head}, extracted); err != nil {
+		return err
+	}
+	if err := ioutil.WriteFile(filepath.Join(extracted, uidFile), []byte(metadata.UID), 0644); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dir); err == nil {
+		if err := os.Rename(dir, filepath.Join(staging, "stale")); err != nil {
+			return err
+		}
+	}
+	if err := os.Rename(extracted, dir); err != nil && !extractedFor(dir, metadata.UID) {
+		return err
+	}
+	return nil
+}
+
+// extractedFor returns whether dir holds the bundle of the repo with the given UID
+func extractedFor(dir string, uid types.UID) bool {
+	data, err := ioutil.ReadFile(filepath.Join(dir, uidFile))
+	return err == nil && string(data) == string(uid)
+}
+
+func chunkName(metadata *metav1.ObjectMeta, i int) string {
+	return name2.SafeConcatName(metadata.Name, "bundle", fmt.Sprint(i), string(metadata.UID))
+}
+
+// chunkReader reads the content of a chain of ConfigMaps one ConfigMap at a time, so a bundle is never held in
+// memory as a whole.
+type chunkReader struct {
+	configMaps corev1controllers.ConfigMapCache
+	next       *corev1.ConfigMap
+	data       []byte
+}
+
+func (c *chunkReader) Read(p []byte) (int, error) {
+	for len(c.data) == 0 {
+		if c.next == nil {
+			return 0, io.EOF
+		}
+		c.data = c.next.BinaryData[contentKey]
+
+		next := c.next.Annotations[nextAnnotation]
+		if next == "" {
+			c.next = nil
+			continue
+		}
+		cm, err := c.configMaps.Get(c.next.Namespace, next)
+		if err != nil {
+			return 0, err
+		}
+		c.next = cm
+	}
+
+	n := copy(p, c.data)
+	c.data = c.data[n:]
+	return n, nil
+}
@@ -0,0 +1,116 @@
+package bundle
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"path/filepath"
+	"testing"
+
+	namespaces "github.com/rancher/rancher/pkg/namespace"
+	corev1controllers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type fakeConfigMaps struct {
+	configMaps map[string]*corev1.ConfigMap
+}
+
+type fakeConfigMapClient struct {
+	corev1controllers.ConfigMapClient
+	*fakeConfigMaps
+}
+
+func (f fakeConfigMapClient) Create(cm *corev1.ConfigMap) (*corev1.ConfigMap, error) {
+	key := cm.Namespace + "/" + cm.Name
+	if _, ok := f.configMaps[key]; ok {
+		return nil, apierrors.NewAlreadyExists(schema.GroupResource{Resource: "configmaps"}, cm.Name)
+	}
+	f.configMaps[key] = cm
+	return cm, nil
+}
+
+type fakeConfigMapCache struct {
+	corev1controllers.ConfigMapCache
+	*fakeConfigMaps
+}
+
+func (f fakeConfigMapCache) Get(namespace, name string) (*corev1.ConfigMap, error) {
+	if cm, ok := f.configMaps[namespace+"/"+name]; ok {
+		return cm, nil
+	}
+	return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "configmaps"}, name)
+}
+
+func TestName(t *testing.T) {
+	assert.Equal(t, "test", Name(URL("test")))
+	assert.Equal(t, "", Name("https://charts.rancher.io"))
+	assert.Equal(t, "", Name("file://"+Dir+"/test/charts"))
+	assert.Equal(t, "", Name("file://"+Dir+"/"))
+}
+
+func TestStoreAndEnsure(t *testing.T) {
+	// random content doesn't compress, so the bundle is stored in more than one ConfigMap
+	chart := make([]byte, 2*chunkSize)
+	rand.New(rand.NewSource(1)).Read(chart)
+	upload := tarball(t, map[string]string{
+		IndexFile:              "apiVersion: v1\nentries: {}\n",
+		"charts/foo-1.0.0.tgz": string(chart),
+	}).Bytes()
+
+	storage := &fakeConfigMaps{configMaps: map[string]*corev1.ConfigMap{}}
+	metadata := &metav1.ObjectMeta{Name: "test", UID: "uid-1"}
+	require.NoError(t, Store(fakeConfigMapClient{fakeConfigMaps: storage}, metadata, bytes.NewReader(upload), int64(len(upload))))
+	assert.Len(t, storage.configMaps, 3)
+	head := storage.configMaps[namespaces.System+"/"+chunkName(metadata, 0)]
+	require.NotNil(t, head)
+	assert.Equal(t, metadata.UID, head.OwnerReferences[0].UID)
+	assert.Equal(t, chunkName(metadata, 1), head.Annotations[nextAnnotation])
+
+	bundlesDir := t.TempDir()
+	require.NoError(t, ensure(fakeConfigMapCache{fakeConfigMaps: storage}, bundlesDir, "test", metadata))
+	data, err := ioutil.ReadFile(filepath.Join(bundlesDir, "test", "charts", "foo-1.0.0.tgz"))
+	require.NoError(t, err)
+	assert.Equal(t, chart, data)
+
+	// an extracted bundle is kept
+	delete(storage.configMaps, namespaces.System+"/"+chunkName(metadata, 0))
+	assert.NoError(t, ensure(fakeConfigMapCache{fakeConfigMaps: storage}, bundlesDir, "test", metadata))
+}
+
+func TestEnsureReplacesBundleOfDeletedRepo(t *testing.T) {
+	bundlesDir := t.TempDir()
+	for _, uid := range []string{"uid-1", "uid-2"} {
+		storage := &fakeConfigMaps{configMaps: map[string]*corev1.ConfigMap{}}
+		metadata := &metav1.ObjectMeta{Name: "test", UID: types.UID(uid)}
+		upload := tarball(t, map[string]string{
+			IndexFile:              "apiVersion: v1\nentries: {}\n",
+			"charts/foo-1.0.0.tgz": uid,
+		}).Bytes()
+		require.NoError(t, Store(fakeConfigMapClient{fakeConfigMaps: storage}, metadata, bytes.NewReader(upload), int64(len(upload))))
+		require.NoError(t, ensure(fakeConfigMapCache{fakeConfigMaps: storage}, bundlesDir, "test", metadata))
+
+		data, err := ioutil.ReadFile(filepath.Join(bundlesDir, "test", "charts", "foo-1.0.0.tgz"))
+		require.NoError(t, err)
+		assert.Equal(t, uid, string(data))
+	}
+}
+
+func TestEnsureRequiresOwner(t *testing.T) {
+	storage := &fakeConfigMaps{configMaps: map[string]*corev1.ConfigMap{}}
+	upload := tarball(t, map[string]string{IndexFile: "apiVersion: v1\nentries: {}\n"}).Bytes()
+	require.NoError(t, Store(fakeConfigMapClient{fakeConfigMaps: storage}, &metav1.ObjectMeta{Name: "test", UID: "uid-1"},
+		bytes.NewReader(upload), int64(len(upload))))
+	storage.configMaps[namespaces.System+"/"+chunkName(&metav1.ObjectMeta{Name: "test", UID: "uid-2"}, 0)] =
+		storage.configMaps[namespaces.System+"/"+chunkName(&metav1.ObjectMeta{Name: "test", UID: "uid-1"}, 0)]
+
+	bundlesDir := t.TempDir()
+	assert.Error(t, ensure(fakeConfigMapCache{fakeConfigMaps: storage}, bundlesDir, "test", &metav1.ObjectMeta{Name: "test", UID: "uid-2"}))
+	assert.NoDirExists(t, filepath.Join(bundlesDir, "test"))
+}
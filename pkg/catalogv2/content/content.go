@@ -29,12 +29,13 @@
 )
 
 type Manager struct {
-	configMaps   corecontrollers.ConfigMapCache
-	secrets      corecontrollers.SecretCache
-	clusterRepos catalogcontrollers.ClusterRepoCache
-	discovery    discovery.DiscoveryInterface
-	IndexCache   map[string]indexCache
-	lock         sync.RWMutex
+	configMaps    corecontrollers.ConfigMapCache
+	secrets       corecontrollers.SecretCache
+	clusterRepos  catalogcontrollers.ClusterRepoCache
+	chartPolicies catalogcontrollers.ChartPolicyCache
+	discovery     discovery.DiscoveryInterface
+	IndexCache    map[string]indexCache
+	lock          sync.RWMutex
 }
 
 type indexCache struct {
@@ -46,13 +47,15 @@ func NewManager(
 	discovery discovery.DiscoveryInterface,
 	configMaps corecontrollers.ConfigMapCache,
 	secrets corecontrollers.SecretCache,
-	clusterRepos catalogcontrollers.ClusterRepoCache) *Manager {
+	clusterRepos catalogcontrollers.ClusterRepoCache,
+	chartPolicies catalogcontrollers.ChartPolicyCache) *Manager {
 	return &Manager{
-		discovery:    discovery,
-		configMaps:   configMaps,
-		secrets:      secrets,
-		clusterRepos: clusterRepos,
-		IndexCache:   map[string]indexCache{},
+		discovery:     discovery,
+		configMaps:    configMaps,
+		secrets:       secrets,
+		clusterRepos:  clusterRepos,
+		chartPolicies: chartPolicies,
+		IndexCache:    map[string]indexCache{},
 	}
 }
 
@@ -102,6 +105,16 @@ func (c *Manager) readBytes(cm *corev1.ConfigMap) ([]byte, error) {
 }
 
 func (c *Manager) Index(namespace, name string) (*repo.IndexFile, error) {
+	index, err := c.indexWithoutPolicies(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return c.filterPolicies(name, index)
+}
+
+// indexWithoutPolicies returns the index of the repo without removing the chart versions denied by ChartPolicies,
+// so a denied version can be told apart from one that doesn't exist.
+func (c *Manager) indexWithoutPolicies(namespace, name string) (*repo.IndexFile, error) {
 	r, err := c.getRepo(namespace, name)
 	if err != nil {
 		return nil, err
@@ -280,7 +293,25 @@ func (c *Manager) Chart(namespace, name, chartName, version string) (io.ReadClos
 	if err != nil {
 		return nil, err
 	}
+	return c.chart(namespace, name, index, chartName, version)
+}
+
+// SystemIndex returns the index of the repo without applying ChartPolicies. It is only used for the charts Rancher
+// installs itself, which policies meant for users must not block.
+func (c *Manager) SystemIndex(namespace, name string) (*repo.IndexFile, error) {
+	return c.indexWithoutPolicies(namespace, name)
+}
+
+// SystemChart returns the chart without applying ChartPolicies, see SystemIndex.
+func (c *Manager) SystemChart(namespace, name, chartName, version string) (io.ReadCloser, error) {
+	index, err := c.indexWithoutPolicies(namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	return c.chart(namespace, name, index, chartName, version)
+}
 
+func (c *Manager) chart(namespace, name string, index *repo.IndexFile, chartName, version string) (io.ReadCloser, error) {
 	chart, err := index.Get(chartName, version)
 	if err != nil {
 		return nil, err
@@ -0,0 +1,186 @@
+package content
+
+import (
+	"fmt"
+	"path"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/rancher/apiserver/pkg/apierror"
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/wrangler/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	"helm.sh/helm/v3/pkg/repo"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// CheckPolicy returns an error if the chart version is not allowed to be installed from the
+// repo into a namespace of the given project. An empty projectID only checks the policies
+// that apply to the whole cluster.
+func (c *Manager) CheckPolicy(namespace, name, chartName, version, projectID string) error {
+	index, err := c.indexWithoutPolicies(namespace, name)
+	if err != nil {
+		return err
+	}
+
+	chart, err := index.Get(chartName, version)
+	if err != nil {
+		return err
+	}
+
+	policies, err := c.policies(name, projectID)
+	if err != nil {
+		return err
+	}
+
+	if policy := deniedBy(policies, chart); policy != "" {
+		return apierror.NewAPIError(validation.PermissionDenied,
+			fmt.Sprintf("chart %s version %s is not allowed by chart policy %s", chart.Name, chart.Version, policy))
+	}
+
+	return nil
+}
+
+// policies returns the ChartPolicies that apply to the repo. Policies scoped to projects are
+// only returned if they list projectID.
+func (c *Manager) policies(repoName, projectID string) ([]*v1.ChartPolicy, error) {
+	if c.chartPolicies == nil {
+		return nil, nil
+	}
+
+	all, err := c.chartPolicies.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*v1.ChartPolicy
+	for _, policy := range all {
+		if len(policy.Spec.ClusterRepos) > 0 && !contains(policy.Spec.ClusterRepos, repoName) {
+			continue
+		}
+		if len(policy.Spec.ProjectIDs) > 0 && (projectID == "" || !contains(policy.Spec.ProjectIDs, projectID)) {
+			continue
+		}
+		result = append(result, policy)
+	}
+
+	return result, nil
+}
+
+func (c *Manager) filterPolicies(repoName string, index *repo.IndexFile) (*repo.IndexFile, error) {
+	policies, err := c.policies(repoName, "")
+	if err != nil || len(policies) == 0 {
+		return index, err
+	}
+
+	for rel, versions := range index.Entries {
+		newVersions := make([]*repo.ChartVersion, 0, len(versions))
+		for _, version := range versions {
+			if deniedBy(policies, version) == "" {
+				newVersions = append(newVersions, version)
+			}
+		}
+
+		if len(newVersions) == 0 {
+			delete(index.Entries, rel)
+		} else {
+			index.Entries[rel] = newVersions
+		}
+	}
+
+	return index, nil
+}
+
+// deniedBy returns the name of the first policy that does not allow the chart version, or
+// an empty string if all policies allow it.
+func deniedBy(policies []*v1.ChartPolicy, chart *repo.ChartVersion) string {
+	for _, policy := range policies {
+		if !allowed(policy.Spec, chart) {
+			return policy.Name
+		}
+	}
+	return ""
+}
+
+func allowed(spec v1.ChartPolicySpec, chart *repo.ChartVersion) bool {
+	for _, rule := range spec.Deny {
+		// a deny rule that can't be parsed denies everything, so a mistake doesn't allow what it meant to deny
+		if ok, err := ruleMatches(rule, chart); ok || err != nil {
+			return false
+		}
+	}
+
+	if len(spec.Allow) == 0 {
+		return true
+	}
+
+	for _, rule := range spec.Allow {
+		if ok, _ := ruleMatches(rule, chart); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ValidatePolicy returns an error if a rule of the policy has an invalid name pattern or version constraint.
+func ValidatePolicy(spec v1.ChartPolicySpec) error {
+	for kind, rules := range map[string][]v1.ChartRule{"allow": spec.Allow, "deny": spec.Deny} {
+		for i, rule := range rules {
+			if rule.Name != "" {
+				if _, err := path.Match(rule.Name, ""); err != nil {
+					return fmt.Errorf("%s rule %d: invalid name pattern %s: %w", kind, i, rule.Name, err)
+				}
+			}
+			if rule.Version != "" {
+				if _, err := semver.NewConstraint(rule.Version); err != nil {
+					return fmt.Errorf("%s rule %d: invalid version constraint %s: %w", kind, i, rule.Version, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// ruleMatches returns true if the chart version matches the rule, or an error if the rule can't be parsed.
+func ruleMatches(rule v1.ChartRule, chart *repo.ChartVersion) (bool, error) {
+	if rule.Name != "" {
+		ok, err := path.Match(rule.Name, chart.Name)
+		if err != nil {
+			logrus.Errorf("failed to parse chart name pattern %s: %v", rule.Name, err)
+			return false, err
+		}
+		if !ok {
+			return false, nil
+		}
+	}
+
+	if rule.Version != "" {
+		constraint, err := semver.NewConstraint(rule.Version)
+		if err != nil {
+			logrus.Errorf("failed to parse constraint version %s: %v", rule.Version, err)
+			return false, err
+		}
+		version, err := semver.NewVersion(chart.Version)
+		if err != nil || !constraint.Check(version) {
+			return false, nil
+		}
+	}
+
+	for k, v := range rule.Annotations {
+		actual, ok := chart.Annotations[k]
+		if !ok || (v != "" && v != actual) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
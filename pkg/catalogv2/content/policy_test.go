@@ -0,0 +1,166 @@
+package content
+
+import (
+	"testing"
+
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/repo"
+)
+
+func TestPolicyAllowed(t *testing.T) {
+	tests := []struct {
+		testName     string
+		spec         v1.ChartPolicySpec
+		chartName    string
+		chartVersion string
+		annotations  map[string]string
+		expectedPass bool
+	}{
+		{
+			"empty policy allows everything",
+			v1.ChartPolicySpec{},
+			"rancher-monitoring",
+			"1.0.0",
+			nil,
+			true,
+		},
+		{
+			"allow rule matching name pattern",
+			v1.ChartPolicySpec{
+				Allow: []v1.ChartRule{{Name: "rancher-*"}},
+			},
+			"rancher-monitoring",
+			"1.0.0",
+			nil,
+			true,
+		},
+		{
+			"allow rule not matching name pattern",
+			v1.ChartPolicySpec{
+				Allow: []v1.ChartRule{{Name: "rancher-*"}},
+			},
+			"wordpress",
+			"1.0.0",
+			nil,
+			false,
+		},
+		{
+			"allow rule with version out of range",
+			v1.ChartPolicySpec{
+				Allow: []v1.ChartRule{{Name: "rancher-monitoring", Version: ">= 2.0.0"}},
+			},
+			"rancher-monitoring",
+			"1.0.0",
+			nil,
+			false,
+		},
+		{
+			"deny rule takes precedence over allow rule",
+			v1.ChartPolicySpec{
+				Allow: []v1.ChartRule{{Name: "*"}},
+				Deny:  []v1.ChartRule{{Name: "rancher-monitoring", Version: "< 2.0.0"}},
+			},
+			"rancher-monitoring",
+			"1.0.0",
+			nil,
+			false,
+		},
+		{
+			"deny rule with version out of range",
+			v1.ChartPolicySpec{
+				Deny: []v1.ChartRule{{Name: "rancher-monitoring", Version: "< 1.0.0"}},
+			},
+			"rancher-monitoring",
+			"1.0.0",
+			nil,
+			true,
+		},
+		{
+			"allow rule with required annotation present",
+			v1.ChartPolicySpec{
+				Allow: []v1.ChartRule{{Annotations: map[string]string{"catalog.cattle.io/certified": "rancher"}}},
+			},
+			"rancher-monitoring",
+			"1.0.0",
+			map[string]string{"catalog.cattle.io/certified": "rancher"},
+			true,
+		},
+		{
+			"allow rule with required annotation value mismatch",
+			v1.ChartPolicySpec{
+				Allow: []v1.ChartRule{{Annotations: map[string]string{"catalog.cattle.io/certified": "rancher"}}},
+			},
+			"rancher-monitoring",
+			"1.0.0",
+			map[string]string{"catalog.cattle.io/certified": "partner"},
+			false,
+		},
+		{
+			"allow rule with required annotation of any value",
+			v1.ChartPolicySpec{
+				Allow: []v1.ChartRule{{Annotations: map[string]string{"catalog.cattle.io/certified": ""}}},
+			},
+			"rancher-monitoring",
+			"1.0.0",
+			map[string]string{"catalog.cattle.io/certified": "partner"},
+			true,
+		},
+		{
+			"deny rule with invalid name pattern denies everything",
+			v1.ChartPolicySpec{
+				Deny: []v1.ChartRule{{Name: "rancher-[monitoring"}},
+			},
+			"wordpress",
+			"1.0.0",
+			nil,
+			false,
+		},
+		{
+			"deny rule with invalid version constraint denies everything",
+			v1.ChartPolicySpec{
+				Deny: []v1.ChartRule{{Name: "rancher-monitoring", Version: ">= one"}},
+			},
+			"rancher-monitoring",
+			"1.0.0",
+			nil,
+			false,
+		},
+		{
+			"allow rule with invalid version constraint allows nothing",
+			v1.ChartPolicySpec{
+				Allow: []v1.ChartRule{{Version: ">= one"}},
+			},
+			"rancher-monitoring",
+			"1.0.0",
+			nil,
+			false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.testName, func(t *testing.T) {
+			version := &repo.ChartVersion{
+				Metadata: &chart.Metadata{
+					Name:        tt.chartName,
+					Version:     tt.chartVersion,
+					Annotations: tt.annotations,
+				},
+			}
+			assert.Equal(t, tt.expectedPass, allowed(tt.spec, version))
+		})
+	}
+}
+
+func TestValidatePolicy(t *testing.T) {
+	assert.NoError(t, ValidatePolicy(v1.ChartPolicySpec{
+		Allow: []v1.ChartRule{{Name: "rancher-*", Version: ">= 1.0.0 < 2.0.0"}},
+		Deny:  []v1.ChartRule{{Name: "rancher-monitoring"}},
+	}))
+	assert.Error(t, ValidatePolicy(v1.ChartPolicySpec{
+		Deny: []v1.ChartRule{{Name: "rancher-[monitoring"}},
+	}))
+	assert.Error(t, ValidatePolicy(v1.ChartPolicySpec{
+		Allow: []v1.ChartRule{{Version: ">= one"}},
+	}))
+}
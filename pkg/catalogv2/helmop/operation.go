@@ -18,6 +18,7 @@
 	"time"
 	"unicode/utf8"
 
+	"github.com/rancher/apiserver/pkg/apierror"
 	"github.com/rancher/apiserver/pkg/types"
 	types2 "github.com/rancher/rancher/pkg/api/steve/catalog/types"
 	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
@@ -37,6 +38,7 @@
 	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apiserver/pkg/authentication/user"
@@ -48,7 +50,8 @@
 )
 
 const (
-	helmDataPath = "/home/shell/helm"
+	helmDataPath        = "/home/shell/helm"
+	projectIDAnnotation = "field.cattle.io/projectId"
 )
 
 var (
@@ -78,6 +81,7 @@ type Operations struct {
 	clusterRepos   catalogcontrollers.ClusterRepoClient
 	ops            catalogcontrollers.OperationClient
 	pods           corev1controllers.PodClient
+	namespaces     corev1controllers.NamespaceCache
 	apps           catalogcontrollers.AppClient
 	roles          rbacv1controllers.RoleClient
 	roleBindings   rbacv1controllers.RoleBindingClient
@@ -89,13 +93,15 @@ func NewOperations(
 	catalog catalogcontrollers.Interface,
 	rbac rbacv1controllers.Interface,
 	contentManager *content.Manager,
-	pods corev1controllers.PodClient) *Operations {
+	pods corev1controllers.PodClient,
+	namespaceCache corev1controllers.NamespaceCache) *Operations {
 	return &Operations{
 		cg:             cg,
 		contentManager: contentManager,
 		namespace:      namespaces.System,
 		Impersonator:   podimpersonation.New("helm-op", cg, time.Hour, settings.FullShellImage),
 		pods:           pods,
+		namespaces:     namespaceCache,
 		clusterRepos:   catalog.ClusterRepo(),
 		ops:            catalog.Operation(),
 		apps:           catalog.App(),
@@ -119,7 +125,17 @@ func (s *Operations) Uninstall(ctx context.Context, user user.Info, namespace, n
 }
 
 func (s *Operations) Upgrade(ctx context.Context, user user.Info, namespace, name string, options io.Reader) (*catalog.Operation, error) {
-	status, cmds, err := s.getUpgradeCommand(namespace, name, options)
+	return s.upgrade(ctx, user, namespace, name, options, true)
+}
+
+// SystemUpgrade upgrades the charts Rancher installs itself. ChartPolicies only restrict the charts of users, so
+// they are not applied.
+func (s *Operations) SystemUpgrade(ctx context.Context, user user.Info, namespace, name string, options io.Reader) (*catalog.Operation, error) {
+	return s.upgrade(ctx, user, namespace, name, options, false)
+}
+
+func (s *Operations) upgrade(ctx context.Context, user user.Info, namespace, name string, options io.Reader, checkPolicy bool) (*catalog.Operation, error) {
+	status, cmds, err := s.getUpgradeCommand(namespace, name, options, checkPolicy)
 	if err != nil {
 		return nil, err
 	}
@@ -302,7 +318,7 @@ func (s *Operations) getUninstallArgs(appNamespace, appName string, body io.Read
 	return status, Commands{cmd}, nil
 }
 
-func (s *Operations) getUpgradeCommand(repoNamespace, repoName string, body io.Reader) (catalog.OperationStatus, Commands, error) {
+func (s *Operations) getUpgradeCommand(repoNamespace, repoName string, body io.Reader, checkPolicy bool) (catalog.OperationStatus, Commands, error) {
 	var (
 		upgradeArgs = &types2.ChartUpgradeAction{}
 		commands    Commands
@@ -322,8 +338,13 @@ func (s *Operations) getUpgradeCommand(repoNamespace, repoName string, body io.R
 		Namespace: namespace(upgradeArgs.Namespace),
 	}
 
+	projectID, err := s.projectID(status.Namespace, "")
+	if err != nil {
+		return status, nil, err
+	}
+
 	for _, chartUpgrade := range upgradeArgs.Charts {
-		cmd, err := s.getChartCommand(repoNamespace, repoName, chartUpgrade.ChartName, chartUpgrade.Version, chartUpgrade.Annotations, chartUpgrade.Values)
+		cmd, err := s.getChartCommand(repoNamespace, repoName, chartUpgrade.ChartName, chartUpgrade.Version, projectID, checkPolicy, chartUpgrade.Annotations, chartUpgrade.Values)
 		if err != nil {
 			return status, nil, err
 		}
@@ -542,8 +563,40 @@ func addAnnotations(data []byte, annotations map[string]string) ([]byte, error)
 	return yaml.Marshal(chartData)
 }
 
-func (s *Operations) getChartCommand(namespace, name, chartName, chartVersion string, annotations map[string]string, values map[string]interface{}) (Command, error) {
-	chart, err := s.contentManager.Chart(namespace, name, chartName, chartVersion)
+// projectID returns the project the target namespace belongs to, in the form "c-xxxxx:p-xxxxx". The project of an
+// existing namespace is always used, a different project requested in an install is rejected so the policies of
+// another project can't be used to install into the namespace.
+func (s *Operations) projectID(namespace, requested string) (string, error) {
+	requested = strings.ReplaceAll(requested, "/", ":")
+
+	ns, err := s.namespaces.Get(namespace)
+	if apierrors.IsNotFound(err) {
+		return requested, nil
+	} else if err != nil {
+		return "", err
+	}
+
+	projectID := ns.Annotations[projectIDAnnotation]
+	if requested != "" && requested != projectID {
+		return "", apierror.NewAPIError(validation.PermissionDenied,
+			fmt.Sprintf("namespace %s does not belong to project %s", namespace, requested))
+	}
+	return projectID, nil
+}
+
+func (s *Operations) getChartCommand(namespace, name, chartName, chartVersion, projectID string, checkPolicy bool, annotations map[string]string, values map[string]interface{}) (Command, error) {
+	var (
+		chart io.ReadCloser
+		err   error
+	)
+	if checkPolicy {
+		if err := s.contentManager.CheckPolicy(namespace, name, chartName, chartVersion, projectID); err != nil {
+			return Command{}, err
+		}
+		chart, err = s.contentManager.Chart(namespace, name, chartName, chartVersion)
+	} else {
+		chart, err = s.contentManager.SystemChart(namespace, name, chartName, chartVersion)
+	}
 	if err != nil {
 		return Command{}, err
 	}
@@ -588,8 +641,13 @@ func (s *Operations) getInstallCommand(repoNamespace, repoName string, body io.R
 		}
 	)
 
+	projectID, err := s.projectID(namespace(installArgs.Namespace), installArgs.ProjectID)
+	if err != nil {
+		return status, nil, err
+	}
+
 	for _, chartInstall := range installArgs.Charts {
-		cmd, err := s.getChartCommand(repoNamespace, repoName, chartInstall.ChartName, chartInstall.Version, chartInstall.Annotations, chartInstall.Values)
+		cmd, err := s.getChartCommand(repoNamespace, repoName, chartInstall.ChartName, chartInstall.Version, projectID, true, chartInstall.Annotations, chartInstall.Values)
 		if err != nil {
 			return status, nil, err
 		}
@@ -742,15 +800,30 @@ func (s *Operations) createNamespace(ctx context.Context, namespace, projectID s
 
 	annotations := map[string]string{}
 	if projectID != "" {
-		annotations["field.cattle.io/projectId"] = strings.ReplaceAll(projectID, "/", ":")
+		annotations[projectIDAnnotation] = strings.ReplaceAll(projectID, "/", ":")
 	}
 	// We just always try to create an ignore the error. This is because you might have create but not get privileges
-	_, _ = client.CoreV1().Namespaces().Create(ctx, &v1.Namespace{
+	_, err = client.CoreV1().Namespaces().Create(ctx, &v1.Namespace{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:        namespace,
 			Annotations: annotations,
 		},
 	}, metav1.CreateOptions{})
+	if apierrors.IsAlreadyExists(err) && projectID != "" {
+		// The namespace was created after the policies were checked, it must be in the project they were checked for.
+		adminClient, err := s.cg.AdminK8sInterface()
+		if err != nil {
+			return nil, err
+		}
+		ns, err := adminClient.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		if ns.Annotations[projectIDAnnotation] != annotations[projectIDAnnotation] {
+			return nil, apierror.NewAPIError(validation.PermissionDenied,
+				fmt.Sprintf("namespace %s does not belong to project %s", namespace, projectID))
+		}
+	}
 
 	if projectID == "" {
 		return client.CoreV1().Namespaces().Get(ctx, namespace, metav1.GetOptions{})
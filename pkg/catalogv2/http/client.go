@@ -3,9 +3,13 @@
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"fmt"
 	"net/http"
+	"path"
+	"strings"
 	"time"
 
+	"github.com/rancher/rancher/pkg/catalogv2/bundle"
 	corev1 "k8s.io/api/core/v1"
 )
 
@@ -46,6 +50,9 @@ func HelmClient(secret *corev1.Secret, caBundle []byte, insecureSkipTLSVerify bo
 	transport := http.DefaultTransport.(*http.Transport).Clone()
 	transport.TLSClientConfig = &tlsConfig
 	transport.TLSClientConfig.InsecureSkipVerify = insecureSkipTLSVerify
+	transport.RegisterProtocol("file", &bundleRoundTripper{
+		next: http.NewFileTransport(http.Dir("/")),
+	})
 
 	client := &http.Client{
 		Transport: transport,
@@ -62,6 +69,19 @@ func HelmClient(secret *corev1.Secret, caBundle []byte, insecureSkipTLSVerify bo
 	return client, nil
 }
 
+// bundleRoundTripper serves file:// URLs of extracted bundles so they can be used as repos in
+// air-gapped environments. Any other path on the local filesystem is refused.
+type bundleRoundTripper struct {
+	next http.RoundTripper
+}
+
+func (b *bundleRoundTripper) RoundTrip(request *http.Request) (*http.Response, error) {
+	if !strings.HasPrefix(path.Clean(request.URL.Path), bundle.Dir+"/") {
+		return nil, fmt.Errorf("file URLs must be in %s", bundle.Dir)
+	}
+	return b.next.RoundTrip(request)
+}
+
 type basicRoundTripper struct {
 	username string
 	password string
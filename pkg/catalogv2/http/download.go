@@ -14,6 +14,7 @@
 
 	"sigs.k8s.io/yaml"
 
+	"github.com/rancher/rancher/pkg/catalogv2/bundle"
 	"github.com/rancher/rancher/pkg/settings"
 	"github.com/sirupsen/logrus"
 	"helm.sh/helm/v3/pkg/repo"
@@ -101,7 +102,19 @@ func Chart(secret *corev1.Secret, repoURL string, caBundle []byte, insecureSkipT
 	defer resp.Body.Close()
 
 	data, err := ioutil.ReadAll(resp.Body)
-	return ioutil.NopCloser(bytes.NewBuffer(data)), err
+	if err != nil {
+		return nil, err
+	}
+
+	// Charts of imported bundles pull their images from the system default registry
+	if u.Scheme == "file" {
+		data, err = bundle.RewriteImages(data, settings.SystemDefaultRegistry.Get())
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return ioutil.NopCloser(bytes.NewBuffer(data)), nil
 }
 
 func DownloadIndex(secret *corev1.Secret, repoURL string, caBundle []byte, insecureSkipTLSVerify bool) (*repo.IndexFile, error) {
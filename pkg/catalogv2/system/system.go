@@ -4,8 +4,11 @@
 	"bytes"
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -20,6 +23,7 @@
 	mgmtcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
 	corev1 "github.com/rancher/rancher/pkg/generated/norman/core/v1"
 	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/wrangler/pkg/condition"
 	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	"github.com/rancher/wrangler/pkg/merr"
 	"github.com/sirupsen/logrus"
@@ -34,6 +38,14 @@
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 )
 
+const (
+	// healthTimeout is how long the workloads of a system chart have to become ready after an
+	// install or upgrade before it is rolled back
+	healthTimeout  = 5 * time.Minute
+	backoffInitial = 30 * time.Second
+	backoffMax     = 30 * time.Minute
+)
+
 var (
 	installUser = &user.DefaultInfo{
 		Name: "helm-installer",
@@ -56,6 +68,12 @@ type desired struct {
 	forceAdopt bool
 }
 
+type failure struct {
+	err      error
+	attempts int
+	next     time.Time
+}
+
 type Manager struct {
 	ctx                   context.Context
 	operation             *helmop.Operations
@@ -63,6 +81,7 @@ type Manager struct {
 	restClientGetter      genericclioptions.RESTClientGetter
 	pods                  corecontrollers.PodClient
 	desiredCharts         map[desiredKey]map[string]interface{}
+	failures              map[desiredKey]*failure
 	sync                  chan desired
 	syncLock              sync.Mutex
 	refreshIntervalChange chan struct{}
@@ -87,6 +106,7 @@ func NewManager(ctx context.Context,
 		pods:                  pods,
 		sync:                  make(chan desired, 10),
 		desiredCharts:         map[desiredKey]map[string]interface{}{},
+		failures:              map[desiredKey]*failure{},
 		refreshIntervalChange: make(chan struct{}, 1),
 		settings:              settings,
 		trigger:               make(chan struct{}, 1),
@@ -142,10 +162,13 @@ func (m *Manager) runSync() {
 			v, exists := m.desiredCharts[desired.key]
 			// newly requested or changed
 			if !exists || !equality.Semantic.DeepEqual(v, desired.values) {
+				// a changed request is tried right away even if the previous one is backing off
+				delete(m.failures, desired.key)
 				err := m.installCharts(map[desiredKey]map[string]interface{}{
 					desired.key: desired.values,
 				}, desired.forceAdopt)
-				if err == nil {
+				// charts that are backing off are retried by the periodic sync
+				if _, failed := m.failures[desired.key]; err == nil || failed {
 					m.desiredCharts[desired.key] = desired.values
 				}
 			}
@@ -164,6 +187,11 @@ func getIntervalOrDefault(interval string) time.Duration {
 func (m *Manager) installCharts(charts map[desiredKey]map[string]interface{}, forceAdopt bool) error {
 	var errs []error
 	for key, values := range charts {
+		if f, ok := m.failures[key]; ok && time.Now().Before(f.next) {
+			logrus.Debugf("Skipping system chart %s until %s after %d failed attempts", key.name, f.next, f.attempts)
+			errs = append(errs, f.err)
+			continue
+		}
 		for {
 			if err := m.install(key.namespace, key.name, key.minVersion, values, forceAdopt); err == repo.ErrNoChartName || apierrors.IsNotFound(err) {
 				logrus.Errorf("Failed to find system chart %s will try again in 5 seconds: %v", key.name, err)
@@ -171,14 +199,70 @@ func (m *Manager) installCharts(charts map[desiredKey]map[string]interface{}, fo
 				continue
 			} else if err != nil {
 				logrus.Errorf("Failed to install system chart %s: %v", key.name, err)
+				m.recordFailure(key, err)
 				errs = append(errs, err)
+			} else {
+				delete(m.failures, key)
 			}
 			break
 		}
 	}
+	m.updateCondition()
 	return merr.NewErrors(errs...)
 }
 
+// recordFailure delays the next attempt to install the chart exponentially so a broken chart
+// version is not retried in a tight loop
+func (m *Manager) recordFailure(key desiredKey, err error) {
+	f, ok := m.failures[key]
+	if !ok {
+		f = &failure{}
+		m.failures[key] = f
+	}
+	f.err = err
+	f.attempts++
+
+	delay := backoffInitial
+	for i := 1; i < f.attempts && delay < backoffMax; i++ {
+		delay *= 2
+	}
+	if delay > backoffMax {
+		delay = backoffMax
+	}
+	f.next = time.Now().Add(delay)
+}
+
+// updateCondition reports the system charts that failed to install on the rancher-charts ClusterRepo
+func (m *Manager) updateCondition() {
+	clusterRepo, err := m.clusterRepos.Cache().Get("rancher-charts")
+	if err != nil {
+		logrus.Errorf("Failed to get ClusterRepo rancher-charts: %v", err)
+		return
+	}
+
+	var messages []string
+	for key, f := range m.failures {
+		messages = append(messages, fmt.Sprintf("%s/%s: %v", key.namespace, key.name, f.err))
+	}
+	sort.Strings(messages)
+
+	var condErr error
+	if len(messages) > 0 {
+		condErr = errors.New(strings.Join(messages, "; "))
+	}
+
+	cond := condition.Cond(catalog.SystemChartsInstalled)
+	if cond.MatchesError(clusterRepo, "", condErr) {
+		return
+	}
+
+	clusterRepo = clusterRepo.DeepCopy()
+	cond.SetError(clusterRepo, "", condErr)
+	if _, err := m.clusterRepos.UpdateStatus(clusterRepo); err != nil {
+		logrus.Errorf("Failed to update status of ClusterRepo rancher-charts: %v", err)
+	}
+}
+
 func (m *Manager) Uninstall(namespace, name string) error {
 	if ok, err := m.hasStatus(namespace, name, action.ListDeployed|action.ListFailed); err != nil {
 		return err
@@ -231,7 +315,7 @@ func (m *Manager) Remove(namespace, name, minVersion string) {
 }
 
 func (m *Manager) install(namespace, name, minVersion string, values map[string]interface{}, forceAdopt bool) error {
-	index, err := m.content.Index("", "rancher-charts")
+	index, err := m.content.SystemIndex("", "rancher-charts")
 	if err != nil {
 		return err
 	}
@@ -278,12 +362,104 @@ func (m *Manager) install(namespace, name, minVersion string, values map[string]
 		return err
 	}
 
-	op, err := m.operation.Upgrade(m.ctx, installUser, "", "rancher-charts", bytes.NewBuffer(upgrade))
+	previous, err := m.deployedRevision(namespace, name)
 	if err != nil {
 		return err
 	}
 
-	return m.waitPodDone(op)
+	op, err := m.operation.SystemUpgrade(m.ctx, installUser, "", "rancher-charts", bytes.NewBuffer(upgrade))
+	if err != nil {
+		return err
+	}
+
+	if err := m.waitPodDone(op); err != nil {
+		return m.rollback(namespace, name, previous, err)
+	}
+
+	if err := m.waitReady(namespace, name); err != nil {
+		return m.rollback(namespace, name, previous, err)
+	}
+
+	return nil
+}
+
+// deployedRevision returns the revision of the currently deployed release or 0 if there is none
+func (m *Manager) deployedRevision(namespace, name string) (int, error) {
+	helmcfg := &action.Configuration{}
+	if err := helmcfg.Init(m.restClientGetter, namespace, "", logrus.Infof); err != nil {
+		return 0, err
+	}
+
+	l := action.NewList(helmcfg)
+	l.Filter = "^" + name + "$"
+	l.StateMask = action.ListDeployed
+
+	releases, err := l.Run()
+	if err != nil || len(releases) == 0 {
+		return 0, err
+	}
+
+	return releases[0].Version, nil
+}
+
+// waitReady waits for the workloads of the latest release to become ready
+func (m *Manager) waitReady(namespace, name string) error {
+	helmcfg := &action.Configuration{}
+	if err := helmcfg.Init(m.restClientGetter, namespace, "", logrus.Infof); err != nil {
+		return err
+	}
+
+	release, err := action.NewGet(helmcfg).Run(name)
+	if err != nil {
+		return err
+	}
+
+	resources, err := helmcfg.KubeClient.Build(bytes.NewBufferString(release.Manifest), false)
+	if err != nil {
+		return err
+	}
+
+	if err := helmcfg.KubeClient.Wait(resources, healthTimeout); err != nil {
+		return fmt.Errorf("%s %s did not become ready: %w", name, release.Chart.Metadata.Version, err)
+	}
+	return nil
+}
+
+// rollback rolls the release back to the previous revision after a failed install or upgrade
+// and returns the cause of the failure
+func (m *Manager) rollback(namespace, name string, previous int, cause error) error {
+	if previous == 0 {
+		return cause
+	}
+
+	helmcfg := &action.Configuration{}
+	if err := helmcfg.Init(m.restClientGetter, namespace, "", logrus.Infof); err != nil {
+		return merr.NewErrors(cause, err)
+	}
+
+	current, err := action.NewGet(helmcfg).Run(name)
+	if err != nil {
+		return merr.NewErrors(cause, err)
+	}
+
+	// Nothing to roll back if the release was not changed, and a pending release is still
+	// being worked on by the operation
+	if current.Version == previous || current.Info.Status.IsPending() {
+		return cause
+	}
+
+	logrus.Errorf("System chart %s failed, rolling back to revision %d: %v", name, previous, cause)
+
+	rollback := action.NewRollback(helmcfg)
+	rollback.Version = previous
+	rollback.Wait = true
+	rollback.Timeout = healthTimeout
+	rollback.MaxHistory = 5
+	if err := rollback.Run(name); err != nil {
+		return merr.NewErrors(cause, err)
+	}
+
+	return fmt.Errorf("rolled back %s to revision %d: %w", name, previous, cause)
 }
 
 func (m *Manager) waitPodDone(op *catalog.Operation) error {
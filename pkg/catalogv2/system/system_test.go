@@ -0,0 +1,49 @@
+package system
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRecordFailure(t *testing.T) {
+	tests := []struct {
+		name          string
+		attempts      int
+		expectedDelay time.Duration
+	}{
+		{
+			"first failure",
+			1,
+			backoffInitial,
+		},
+		{
+			"third failure",
+			3,
+			4 * backoffInitial,
+		},
+		{
+			"capped",
+			20,
+			backoffMax,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := &Manager{
+				failures: map[desiredKey]*failure{},
+			}
+			key := desiredKey{namespace: "cattle-fleet-system", name: "fleet"}
+			for i := 0; i < tt.attempts; i++ {
+				m.recordFailure(key, errors.New("failed"))
+			}
+
+			f := m.failures[key]
+			assert.Equal(t, tt.attempts, f.attempts)
+			assert.WithinDuration(t, time.Now().Add(tt.expectedDelay), f.next, time.Second)
+		})
+	}
+}
@@ -30,6 +30,8 @@
 	ClusterFieldClusterTemplateRevisionID            = "clusterTemplateRevisionId"
 	ClusterFieldComponentStatuses                    = "componentStatuses"
 	ClusterFieldConditions                           = "conditions"
+	ClusterFieldConnectionHistory                    = "connectionHistory"
+	ClusterFieldControllerOwner                      = "controllerOwner"
 	ClusterFieldCreated                              = "created"
 	ClusterFieldCreatorID                            = "creatorId"
 	ClusterFieldCurrentCisRunName                    = "currentCisRunName"
@@ -102,6 +104,8 @@ type Cluster struct {
 	ClusterTemplateRevisionID            string                         `json:"clusterTemplateRevisionId,omitempty" yaml:"clusterTemplateRevisionId,omitempty"`
 	ComponentStatuses                    []ClusterComponentStatus       `json:"componentStatuses,omitempty" yaml:"componentStatuses,omitempty"`
 	Conditions                           []ClusterCondition             `json:"conditions,omitempty" yaml:"conditions,omitempty"`
+	ConnectionHistory                    []ClusterConnection            `json:"connectionHistory,omitempty" yaml:"connectionHistory,omitempty"`
+	ControllerOwner                      string                         `json:"controllerOwner,omitempty" yaml:"controllerOwner,omitempty"`
 	Created                              string                         `json:"created,omitempty" yaml:"created,omitempty"`
 	CreatorID                            string                         `json:"creatorId,omitempty" yaml:"creatorId,omitempty"`
 	CurrentCisRunName                    string                         `json:"currentCisRunName,omitempty" yaml:"currentCisRunName,omitempty"`
@@ -0,0 +1,20 @@
+package client
+
+const (
+	ClusterConnectionType                    = "clusterConnection"
+	ClusterConnectionFieldAgentVersion       = "agentVersion"
+	ClusterConnectionFieldConnectedAt        = "connectedAt"
+	ClusterConnectionFieldDisconnectNotified = "disconnectNotified"
+	ClusterConnectionFieldDisconnectedAt     = "disconnectedAt"
+	ClusterConnectionFieldDuration           = "duration"
+	ClusterConnectionFieldRemoteAddress      = "remoteAddress"
+)
+
+type ClusterConnection struct {
+	AgentVersion       string `json:"agentVersion,omitempty" yaml:"agentVersion,omitempty"`
+	ConnectedAt        string `json:"connectedAt,omitempty" yaml:"connectedAt,omitempty"`
+	DisconnectNotified bool   `json:"disconnectNotified,omitempty" yaml:"disconnectNotified,omitempty"`
+	DisconnectedAt     string `json:"disconnectedAt,omitempty" yaml:"disconnectedAt,omitempty"`
+	Duration           string `json:"duration,omitempty" yaml:"duration,omitempty"`
+	RemoteAddress      string `json:"remoteAddress,omitempty" yaml:"remoteAddress,omitempty"`
+}
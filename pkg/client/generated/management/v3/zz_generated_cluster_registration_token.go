@@ -11,11 +11,14 @@
 	ClusterRegistrationTokenFieldCommand                    = "command"
 	ClusterRegistrationTokenFieldCreated                    = "created"
 	ClusterRegistrationTokenFieldCreatorID                  = "creatorId"
+	ClusterRegistrationTokenFieldExpired                    = "expired"
+	ClusterRegistrationTokenFieldExpiresAt                  = "expiresAt"
 	ClusterRegistrationTokenFieldInsecureCommand            = "insecureCommand"
 	ClusterRegistrationTokenFieldInsecureNodeCommand        = "insecureNodeCommand"
 	ClusterRegistrationTokenFieldInsecureWindowsNodeCommand = "insecureWindowsNodeCommand"
 	ClusterRegistrationTokenFieldLabels                     = "labels"
 	ClusterRegistrationTokenFieldManifestURL                = "manifestUrl"
+	ClusterRegistrationTokenFieldMaxUses                    = "maxUses"
 	ClusterRegistrationTokenFieldName                       = "name"
 	ClusterRegistrationTokenFieldNamespaceId                = "namespaceId"
 	ClusterRegistrationTokenFieldNodeCommand                = "nodeCommand"
@@ -26,6 +29,7 @@
 	ClusterRegistrationTokenFieldTransitioning              = "transitioning"
 	ClusterRegistrationTokenFieldTransitioningMessage       = "transitioningMessage"
 	ClusterRegistrationTokenFieldUUID                       = "uuid"
+	ClusterRegistrationTokenFieldUses                       = "uses"
 	ClusterRegistrationTokenFieldWindowsNodeCommand         = "windowsNodeCommand"
 )
 
@@ -36,11 +40,14 @@ type ClusterRegistrationToken struct {
 	Command                    string            `json:"command,omitempty" yaml:"command,omitempty"`
 	Created                    string            `json:"created,omitempty" yaml:"created,omitempty"`
 	CreatorID                  string            `json:"creatorId,omitempty" yaml:"creatorId,omitempty"`
+	Expired                    bool              `json:"expired,omitempty" yaml:"expired,omitempty"`
+	ExpiresAt                  string            `json:"expiresAt,omitempty" yaml:"expiresAt,omitempty"`
 	InsecureCommand            string            `json:"insecureCommand,omitempty" yaml:"insecureCommand,omitempty"`
 	InsecureNodeCommand        string            `json:"insecureNodeCommand,omitempty" yaml:"insecureNodeCommand,omitempty"`
 	InsecureWindowsNodeCommand string            `json:"insecureWindowsNodeCommand,omitempty" yaml:"insecureWindowsNodeCommand,omitempty"`
 	Labels                     map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
 	ManifestURL                string            `json:"manifestUrl,omitempty" yaml:"manifestUrl,omitempty"`
+	MaxUses                    int64             `json:"maxUses,omitempty" yaml:"maxUses,omitempty"`
 	Name                       string            `json:"name,omitempty" yaml:"name,omitempty"`
 	NamespaceId                string            `json:"namespaceId,omitempty" yaml:"namespaceId,omitempty"`
 	NodeCommand                string            `json:"nodeCommand,omitempty" yaml:"nodeCommand,omitempty"`
@@ -51,6 +58,7 @@ type ClusterRegistrationToken struct {
 	Transitioning              string            `json:"transitioning,omitempty" yaml:"transitioning,omitempty"`
 	TransitioningMessage       string            `json:"transitioningMessage,omitempty" yaml:"transitioningMessage,omitempty"`
 	UUID                       string            `json:"uuid,omitempty" yaml:"uuid,omitempty"`
+	Uses                       int64             `json:"uses,omitempty" yaml:"uses,omitempty"`
 	WindowsNodeCommand         string            `json:"windowsNodeCommand,omitempty" yaml:"windowsNodeCommand,omitempty"`
 }
 
@@ -72,6 +80,8 @@ type ClusterRegistrationTokenOperations interface {
 	Replace(existing *ClusterRegistrationToken) (*ClusterRegistrationToken, error)
 	ByID(id string) (*ClusterRegistrationToken, error)
 	Delete(container *ClusterRegistrationToken) error
+
+	ActionRotate(resource *ClusterRegistrationToken) error
 }
 
 func newClusterRegistrationTokenClient(apiClient *Client) *ClusterRegistrationTokenClient {
@@ -142,3 +152,8 @@ func (c *ClusterRegistrationTokenClient) ByID(id string) (*ClusterRegistrationTo
 func (c *ClusterRegistrationTokenClient) Delete(container *ClusterRegistrationToken) error {
 	return c.apiClient.Ops.DoResourceDelete(ClusterRegistrationTokenType, &container.Resource)
 }
+
+func (c *ClusterRegistrationTokenClient) ActionRotate(resource *ClusterRegistrationToken) error {
+	err := c.apiClient.Ops.DoAction(ClusterRegistrationTokenType, "rotate", &resource.Resource, nil, nil)
+	return err
+}
@@ -0,0 +1,12 @@
+package client
+
+const (
+	MessageTemplateType       = "messageTemplate"
+	MessageTemplateFieldText  = "text"
+	MessageTemplateFieldTitle = "title"
+)
+
+type MessageTemplate struct {
+	Text  string `json:"text,omitempty" yaml:"text,omitempty"`
+	Title string `json:"title,omitempty" yaml:"title,omitempty"`
+}
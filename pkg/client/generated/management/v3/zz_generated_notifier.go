@@ -12,10 +12,14 @@
 	NotifierFieldCreatorID            = "creatorId"
 	NotifierFieldDescription          = "description"
 	NotifierFieldDingtalkConfig       = "dingtalkConfig"
+	NotifierFieldGoogleChatConfig     = "googleChatConfig"
 	NotifierFieldLabels               = "labels"
 	NotifierFieldMSTeamsConfig        = "msteamsConfig"
+	NotifierFieldMatrixConfig         = "matrixConfig"
+	NotifierFieldMessageTemplate      = "messageTemplate"
 	NotifierFieldName                 = "name"
 	NotifierFieldNamespaceId          = "namespaceId"
+	NotifierFieldOpsgenieConfig       = "opsgenieConfig"
 	NotifierFieldOwnerReferences      = "ownerReferences"
 	NotifierFieldPagerdutyConfig      = "pagerdutyConfig"
 	NotifierFieldRemoved              = "removed"
@@ -24,6 +28,7 @@
 	NotifierFieldSlackConfig          = "slackConfig"
 	NotifierFieldState                = "state"
 	NotifierFieldStatus               = "status"
+	NotifierFieldTelegramConfig       = "telegramConfig"
 	NotifierFieldTransitioning        = "transitioning"
 	NotifierFieldTransitioningMessage = "transitioningMessage"
 	NotifierFieldUUID                 = "uuid"
@@ -39,10 +44,14 @@ type Notifier struct {
 	CreatorID            string            `json:"creatorId,omitempty" yaml:"creatorId,omitempty"`
 	Description          string            `json:"description,omitempty" yaml:"description,omitempty"`
 	DingtalkConfig       *DingtalkConfig   `json:"dingtalkConfig,omitempty" yaml:"dingtalkConfig,omitempty"`
+	GoogleChatConfig     *GoogleChatConfig `json:"googleChatConfig,omitempty" yaml:"googleChatConfig,omitempty"`
 	Labels               map[string]string `json:"labels,omitempty" yaml:"labels,omitempty"`
 	MSTeamsConfig        *MSTeamsConfig    `json:"msteamsConfig,omitempty" yaml:"msteamsConfig,omitempty"`
+	MatrixConfig         *MatrixConfig     `json:"matrixConfig,omitempty" yaml:"matrixConfig,omitempty"`
+	MessageTemplate      *MessageTemplate  `json:"messageTemplate,omitempty" yaml:"messageTemplate,omitempty"`
 	Name                 string            `json:"name,omitempty" yaml:"name,omitempty"`
 	NamespaceId          string            `json:"namespaceId,omitempty" yaml:"namespaceId,omitempty"`
+	OpsgenieConfig       *OpsgenieConfig   `json:"opsgenieConfig,omitempty" yaml:"opsgenieConfig,omitempty"`
 	OwnerReferences      []OwnerReference  `json:"ownerReferences,omitempty" yaml:"ownerReferences,omitempty"`
 	PagerdutyConfig      *PagerdutyConfig  `json:"pagerdutyConfig,omitempty" yaml:"pagerdutyConfig,omitempty"`
 	Removed              string            `json:"removed,omitempty" yaml:"removed,omitempty"`
@@ -51,6 +60,7 @@ type Notifier struct {
 	SlackConfig          *SlackConfig      `json:"slackConfig,omitempty" yaml:"slackConfig,omitempty"`
 	State                string            `json:"state,omitempty" yaml:"state,omitempty"`
 	Status               *NotifierStatus   `json:"status,omitempty" yaml:"status,omitempty"`
+	TelegramConfig       *TelegramConfig   `json:"telegramConfig,omitempty" yaml:"telegramConfig,omitempty"`
 	Transitioning        string            `json:"transitioning,omitempty" yaml:"transitioning,omitempty"`
 	TransitioningMessage string            `json:"transitioningMessage,omitempty" yaml:"transitioningMessage,omitempty"`
 	UUID                 string            `json:"uuid,omitempty" yaml:"uuid,omitempty"`
@@ -77,8 +87,12 @@ type NotifierOperations interface {
 	ByID(id string) (*Notifier, error)
 	Delete(container *Notifier) error
 
+	ActionPreview(resource *Notifier, input *Notification) (*NotifierPreviewOutput, error)
+
 	ActionSend(resource *Notifier, input *Notification) error
 
+	CollectionActionPreview(resource *NotifierCollection, input *Notification) (*NotifierPreviewOutput, error)
+
 	CollectionActionSend(resource *NotifierCollection, input *Notification) error
 }
 
@@ -151,11 +165,23 @@ func (c *NotifierClient) Delete(container *Notifier) error {
 	return c.apiClient.Ops.DoResourceDelete(NotifierType, &container.Resource)
 }
 
+func (c *NotifierClient) ActionPreview(resource *Notifier, input *Notification) (*NotifierPreviewOutput, error) {
+	resp := &NotifierPreviewOutput{}
+	err := c.apiClient.Ops.DoAction(NotifierType, "preview", &resource.Resource, input, resp)
+	return resp, err
+}
+
 func (c *NotifierClient) ActionSend(resource *Notifier, input *Notification) error {
 	err := c.apiClient.Ops.DoAction(NotifierType, "send", &resource.Resource, input, nil)
 	return err
 }
 
+func (c *NotifierClient) CollectionActionPreview(resource *NotifierCollection, input *Notification) (*NotifierPreviewOutput, error) {
+	resp := &NotifierPreviewOutput{}
+	err := c.apiClient.Ops.DoCollectionAction(NotifierType, "preview", &resource.Collection, input, resp)
+	return resp, err
+}
+
 func (c *NotifierClient) CollectionActionSend(resource *NotifierCollection, input *Notification) error {
 	err := c.apiClient.Ops.DoCollectionAction(NotifierType, "send", &resource.Collection, input, nil)
 	return err
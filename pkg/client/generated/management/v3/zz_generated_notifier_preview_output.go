@@ -0,0 +1,12 @@
+package client
+
+const (
+	NotifierPreviewOutputType       = "notifierPreviewOutput"
+	NotifierPreviewOutputFieldText  = "text"
+	NotifierPreviewOutputFieldTitle = "title"
+)
+
+type NotifierPreviewOutput struct {
+	Text  string `json:"text,omitempty" yaml:"text,omitempty"`
+	Title string `json:"title,omitempty" yaml:"title,omitempty"`
+}
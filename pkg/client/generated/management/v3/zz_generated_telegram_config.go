@@ -0,0 +1,16 @@
+package client
+
+const (
+	TelegramConfigType                  = "telegramConfig"
+	TelegramConfigFieldAPIURL           = "apiUrl"
+	TelegramConfigFieldBotToken         = "botToken"
+	TelegramConfigFieldDefaultRecipient = "defaultRecipient"
+	TelegramConfigFieldProxyURL         = "proxyUrl"
+)
+
+type TelegramConfig struct {
+	APIURL           string `json:"apiUrl,omitempty" yaml:"apiUrl,omitempty"`
+	BotToken         string `json:"botToken,omitempty" yaml:"botToken,omitempty"`
+	DefaultRecipient string `json:"defaultRecipient,omitempty" yaml:"defaultRecipient,omitempty"`
+	ProxyURL         string `json:"proxyUrl,omitempty" yaml:"proxyUrl,omitempty"`
+}
@@ -0,0 +1,12 @@
+package client
+
+const (
+	ArtifactType       = "artifact"
+	ArtifactFieldName  = "name"
+	ArtifactFieldPaths = "paths"
+)
+
+type Artifact struct {
+	Name  string   `json:"name,omitempty" yaml:"name,omitempty"`
+	Paths []string `json:"paths,omitempty" yaml:"paths,omitempty"`
+}
@@ -0,0 +1,14 @@
+package client
+
+const (
+	ArtifactDownloadType                       = "artifactDownload"
+	ArtifactDownloadFieldFromPreviousExecution = "fromPreviousExecution"
+	ArtifactDownloadFieldName                  = "name"
+	ArtifactDownloadFieldPath                  = "path"
+)
+
+type ArtifactDownload struct {
+	FromPreviousExecution bool   `json:"fromPreviousExecution,omitempty" yaml:"fromPreviousExecution,omitempty"`
+	Name                  string `json:"name,omitempty" yaml:"name,omitempty"`
+	Path                  string `json:"path,omitempty" yaml:"path,omitempty"`
+}
@@ -0,0 +1,22 @@
+package client
+
+const (
+	AzureDevOpsApplyInputType              = "azureDevOpsApplyInput"
+	AzureDevOpsApplyInputFieldClientID     = "clientId"
+	AzureDevOpsApplyInputFieldClientSecret = "clientSecret"
+	AzureDevOpsApplyInputFieldCode         = "code"
+	AzureDevOpsApplyInputFieldHostname     = "hostname"
+	AzureDevOpsApplyInputFieldOrganization = "organization"
+	AzureDevOpsApplyInputFieldRedirectURL  = "redirectUrl"
+	AzureDevOpsApplyInputFieldTLS          = "tls"
+)
+
+type AzureDevOpsApplyInput struct {
+	ClientID     string `json:"clientId,omitempty" yaml:"clientId,omitempty"`
+	ClientSecret string `json:"clientSecret,omitempty" yaml:"clientSecret,omitempty"`
+	Code         string `json:"code,omitempty" yaml:"code,omitempty"`
+	Hostname     string `json:"hostname,omitempty" yaml:"hostname,omitempty"`
+	Organization string `json:"organization,omitempty" yaml:"organization,omitempty"`
+	RedirectURL  string `json:"redirectUrl,omitempty" yaml:"redirectUrl,omitempty"`
+	TLS          bool   `json:"tls,omitempty" yaml:"tls,omitempty"`
+}
@@ -3,6 +3,7 @@
 const (
 	PipelineConfigType              = "pipelineConfig"
 	PipelineConfigFieldBranch       = "branch"
+	PipelineConfigFieldCaches       = "caches"
 	PipelineConfigFieldNotification = "notification"
 	PipelineConfigFieldStages       = "stages"
 	PipelineConfigFieldTimeout      = "timeout"
@@ -10,6 +11,7 @@
 
 type PipelineConfig struct {
 	Branch       *Constraint           `json:"branch,omitempty" yaml:"branch,omitempty"`
+	Caches       []CacheConfig         `json:"caches,omitempty" yaml:"caches,omitempty"`
 	Notification *PipelineNotification `json:"notification,omitempty" yaml:"notification,omitempty"`
 	Stages       []Stage               `json:"stages,omitempty" yaml:"stages,omitempty"`
 	Timeout      int64                 `json:"timeout,omitempty" yaml:"timeout,omitempty"`
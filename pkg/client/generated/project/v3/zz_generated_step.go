@@ -4,6 +4,8 @@
 	StepType                      = "step"
 	StepFieldApplyAppConfig       = "applyAppConfig"
 	StepFieldApplyYamlConfig      = "applyYamlConfig"
+	StepFieldArtifactDownloads    = "artifactDownloads"
+	StepFieldArtifacts            = "artifacts"
 	StepFieldCPULimit             = "cpuLimit"
 	StepFieldCPURequest           = "cpuRequest"
 	StepFieldEnv                  = "env"
@@ -21,6 +23,8 @@
 type Step struct {
 	ApplyAppConfig       *ApplyAppConfig       `json:"applyAppConfig,omitempty" yaml:"applyAppConfig,omitempty"`
 	ApplyYamlConfig      *ApplyYamlConfig      `json:"applyYamlConfig,omitempty" yaml:"applyYamlConfig,omitempty"`
+	ArtifactDownloads    []ArtifactDownload    `json:"artifactDownloads,omitempty" yaml:"artifactDownloads,omitempty"`
+	Artifacts            []Artifact            `json:"artifacts,omitempty" yaml:"artifacts,omitempty"`
 	CPULimit             string                `json:"cpuLimit,omitempty" yaml:"cpuLimit,omitempty"`
 	CPURequest           string                `json:"cpuRequest,omitempty" yaml:"cpuRequest,omitempty"`
 	Env                  map[string]string     `json:"env,omitempty" yaml:"env,omitempty"`
@@ -21,6 +21,7 @@
 	clusterController "github.com/rancher/rancher/pkg/controllers/managementuser"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/kontainer-engine/drivers/gke"
+	"github.com/rancher/rancher/pkg/metrics/downstream"
 	"github.com/rancher/rancher/pkg/rbac"
 	"github.com/rancher/rancher/pkg/settings"
 	"github.com/rancher/rancher/pkg/types/config"
@@ -181,7 +182,9 @@ func (m *Manager) changed(r *record, cluster *v3.Cluster, controllers, clusterOw
 }
 
 func (m *Manager) doStart(rec *record, clusterOwner bool) (exit error) {
+	start := time.Now()
 	defer func() {
+		downstream.ObserveControllerStart(rec.cluster.ClusterName, start, exit)
 		if exit == nil {
 			logrus.Infof("Starting cluster agent for %s [owner=%v]", rec.cluster.ClusterName, clusterOwner)
 		}
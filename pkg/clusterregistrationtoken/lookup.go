@@ -0,0 +1,52 @@
+// Package clusterregistrationtoken enforces the limits of cluster registration tokens for every path agents
+// register through.
+package clusterregistrationtoken
+
+import (
+	"errors"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/sirupsen/logrus"
+)
+
+// ErrNotUsable is returned if no token with the value exists, or every one of them expired or was used by its
+// maximum number of agents.
+var ErrNotUsable = errors.New("cluster registration token not found, expired or used by its maximum number of agents")
+
+// Updater updates a token, it is implemented by the wrangler and norman clients.
+type Updater interface {
+	Update(*v3.ClusterRegistrationToken) (*v3.ClusterRegistrationToken, error)
+}
+
+// Find returns the first of the candidates that is neither expired nor used by its maximum number of agents. The
+// candidates are the result of an index lookup by the value of the token, or of its hash.
+func Find(candidates []*v3.ClusterRegistrationToken, now time.Time) (*v3.ClusterRegistrationToken, error) {
+	for _, crt := range candidates {
+		if crt.Status.Token == "" {
+			continue
+		}
+		if crt.IsExpired(now) {
+			logrus.Debugf("Registration token %s/%s expired at %s", crt.Namespace, crt.Name, crt.Spec.ExpiresAt)
+			continue
+		}
+		if crt.IsExhausted() {
+			logrus.Debugf("Registration token %s/%s was used by its maximum of %d agents", crt.Namespace, crt.Name, crt.Spec.MaxUses)
+			continue
+		}
+		return crt, nil
+	}
+
+	return nil, ErrNotUsable
+}
+
+// Use counts the registration of an agent with the token. The update fails with a conflict if the token was
+// changed since it was read, so concurrent registrations can't exceed the maximum number of uses.
+func Use(crts Updater, crt *v3.ClusterRegistrationToken) (*v3.ClusterRegistrationToken, error) {
+	if crt.IsExhausted() {
+		return nil, ErrNotUsable
+	}
+	crt = crt.DeepCopy()
+	crt.Status.Uses++
+	return crts.Update(crt)
+}
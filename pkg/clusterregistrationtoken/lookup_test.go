@@ -0,0 +1,108 @@
+package clusterregistrationtoken
+
+import (
+	"testing"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeUpdater struct {
+	updated *v3.ClusterRegistrationToken
+}
+
+func (f *fakeUpdater) Update(crt *v3.ClusterRegistrationToken) (*v3.ClusterRegistrationToken, error) {
+	f.updated = crt
+	return crt, nil
+}
+
+func newToken(name, token string, expiresAt *metav1.Time, maxUses, uses int) *v3.ClusterRegistrationToken {
+	return &v3.ClusterRegistrationToken{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "c-abc",
+		},
+		Spec: v3.ClusterRegistrationTokenSpec{
+			ClusterName: "c-abc",
+			ExpiresAt:   expiresAt,
+			MaxUses:     maxUses,
+		},
+		Status: v3.ClusterRegistrationTokenStatus{
+			Token: token,
+			Uses:  uses,
+		},
+	}
+}
+
+func TestFind(t *testing.T) {
+	now := time.Now()
+	past := metav1.NewTime(now.Add(-time.Minute))
+	future := metav1.NewTime(now.Add(time.Minute))
+
+	tests := []struct {
+		name         string
+		candidates   []*v3.ClusterRegistrationToken
+		expectedName string
+	}{
+		{
+			name:         "no limits",
+			candidates:   []*v3.ClusterRegistrationToken{newToken("default", "secret", nil, 0, 10)},
+			expectedName: "default",
+		},
+		{
+			name:         "expiry in the future and uses left",
+			candidates:   []*v3.ClusterRegistrationToken{newToken("default", "secret", &future, 2, 1)},
+			expectedName: "default",
+		},
+		{
+			name:       "expired",
+			candidates: []*v3.ClusterRegistrationToken{newToken("default", "secret", &past, 0, 0)},
+		},
+		{
+			name:       "used by its maximum number of agents",
+			candidates: []*v3.ClusterRegistrationToken{newToken("default", "secret", nil, 2, 2)},
+		},
+		{
+			name: "skips unusable token with the same value",
+			candidates: []*v3.ClusterRegistrationToken{
+				newToken("expired", "secret", &past, 0, 0),
+				newToken("valid", "secret", nil, 0, 0),
+			},
+			expectedName: "valid",
+		},
+		{
+			name:       "token without value",
+			candidates: []*v3.ClusterRegistrationToken{newToken("default", "", nil, 0, 0)},
+		},
+		{
+			name: "no token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crt, err := Find(tt.candidates, now)
+			if tt.expectedName == "" {
+				assert.Equal(t, ErrNotUsable, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expectedName, crt.Name)
+		})
+	}
+}
+
+func TestUse(t *testing.T) {
+	updater := &fakeUpdater{}
+	crt := newToken("default", "secret", nil, 2, 1)
+
+	_, err := Use(updater, crt)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, updater.updated.Status.Uses)
+	assert.Equal(t, 1, crt.Status.Uses, "the cached token must not be changed")
+
+	_, err = Use(updater, updater.updated)
+	assert.Equal(t, ErrNotUsable, err)
+}
@@ -109,6 +109,7 @@ func main() {
 				Types: []interface{}{
 					capi.Machine{},
 					capi.MachineDeployment{},
+					capi.MachineSet{},
 					capi.Cluster{},
 				},
 			},
@@ -2,6 +2,7 @@
 
 import (
 	"context"
+	"time"
 
 	v32 "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/wrangler"
@@ -56,6 +57,10 @@ func (h *handler) onChange(key string, obj *v3.ClusterRegistrationToken) (_ *v3.
 		if err != nil {
 			return nil, err
 		}
+		newStatus.Expired = obj.IsExpired(time.Now())
+		if !newStatus.Expired && obj.Spec.ExpiresAt != nil {
+			h.clusterRegistrationTokenController.EnqueueAfter(obj.Namespace, obj.Name, time.Until(obj.Spec.ExpiresAt.Time))
+		}
 		if !equality.Semantic.DeepEqual(obj.Status, newStatus) {
 			obj = obj.DeepCopy()
 			obj.Status = newStatus
@@ -0,0 +1,407 @@
+package helm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/rancher/lasso/pkg/client"
+	"github.com/rancher/rancher/pkg/api/steve/catalog/types"
+	v1 "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/catalogv2/helm"
+	"github.com/rancher/rancher/pkg/catalogv2/helmop"
+	catalogv1 "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/wrangler/pkg/apply"
+	"github.com/rancher/wrangler/pkg/data/convert"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/pkg/yaml"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apiserver/pkg/authentication/serviceaccount"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+const (
+	// DriftCorrectionAnnotation when set to "true" on an App, drift is corrected by re-running
+	// the upgrade of the deployed chart version with the deployed values. The annotation is only
+	// honoured if the ClusterRepo the app was installed from has the same annotation, ClusterRepos
+	// can only be changed by cluster owners.
+	DriftCorrectionAnnotation = "catalog.cattle.io/drift-correction"
+
+	// driftCorrectionServiceAccount is created in the namespace of the release and bound to the
+	// admin role of that namespace only, the correction runs as this service account.
+	driftCorrectionServiceAccount = "helm-drift-correction"
+)
+
+type driftHandler struct {
+	ctx                 context.Context
+	apply               apply.Apply
+	sharedClientFactory client.SharedClientFactory
+	secretCache         corecontrollers.SecretCache
+	clusterRepos        catalogv1.ClusterRepoCache
+	apps                catalogv1.AppController
+	operations          *helmop.Operations
+}
+
+func RegisterDrift(ctx context.Context,
+	apply apply.Apply,
+	shareClientFactory client.SharedClientFactory,
+	secrets corecontrollers.SecretCache,
+	clusterRepos catalogv1.ClusterRepoCache,
+	apps catalogv1.AppController,
+	operations *helmop.Operations,
+) {
+	d := &driftHandler{
+		ctx:                 ctx,
+		apply:               apply,
+		sharedClientFactory: shareClientFactory,
+		secretCache:         secrets,
+		clusterRepos:        clusterRepos,
+		apps:                apps,
+		operations:          operations,
+	}
+	apps.OnChange(ctx, "helm-app-drift", d.OnAppChange)
+}
+
+func driftCheckInterval() time.Duration {
+	i, err := strconv.Atoi(settings.AppDriftCheckIntervalSeconds.Get())
+	if err != nil {
+		return 900 * time.Second
+	}
+	return time.Duration(i) * time.Second
+}
+
+func (d *driftHandler) OnAppChange(key string, app *v1.App) (*v1.App, error) {
+	if app == nil || app.DeletionTimestamp != nil {
+		return app, nil
+	}
+
+	interval := driftCheckInterval()
+	if interval <= 0 {
+		return app, nil
+	}
+
+	if app.Spec.HelmMajorVersion != 3 || app.Spec.Info == nil || app.Spec.Info.Status != v1.StatusDeployed {
+		return app, nil
+	}
+
+	if app.Status.DriftCheckTime != nil && app.Status.DriftCheckVersion == app.Spec.Version {
+		if next := app.Status.DriftCheckTime.Add(interval); time.Now().Before(next) {
+			d.apps.EnqueueAfter(app.Namespace, app.Name, time.Until(next))
+			return app, nil
+		}
+	}
+
+	drifted, err := d.drift(app)
+	if err != nil {
+		return app, err
+	}
+
+	now := metav1.Now()
+	correct := len(drifted) > 0 && d.correctionAllowed(app) &&
+		(app.Status.DriftCorrectionTime == nil || now.Sub(app.Status.DriftCorrectionTime.Time) >= interval)
+
+	app = app.DeepCopy()
+	app.Status.DriftedResources = drifted
+	app.Status.DriftCheckTime = &now
+	app.Status.DriftCheckVersion = app.Spec.Version
+	if correct {
+		app.Status.DriftCorrectionTime = &now
+	}
+
+	app, err = d.apps.UpdateStatus(app)
+	if err != nil {
+		return app, err
+	}
+
+	if correct {
+		if err := d.correct(app); err != nil {
+			logrus.Errorf("Failed to correct drift of app %s/%s: %v", app.Namespace, app.Name, err)
+		}
+	}
+
+	d.apps.EnqueueAfter(app.Namespace, app.Name, interval)
+	return app, nil
+}
+
+// drift compares the live objects to the manifest of the deployed release and returns
+// the resources that differ
+func (d *driftHandler) drift(app *v1.App) ([]v1.DriftedResource, error) {
+	secrets, err := d.secretCache.List(app.Namespace, labels.SelectorFromSet(labels.Set{
+		"owner":   "helm",
+		"name":    app.Spec.Name,
+		"version": strconv.Itoa(app.Spec.Version),
+	}))
+	if err != nil || len(secrets) == 0 {
+		return nil, err
+	}
+
+	manifest, err := helm.ToManifest(secrets[0])
+	if err != nil {
+		return nil, err
+	}
+
+	objs, err := yaml.ToObjects(bytes.NewBufferString(manifest))
+	if err != nil {
+		return nil, err
+	}
+
+	var result []v1.DriftedResource
+	for _, obj := range objs {
+		desired, ok := obj.(*unstructured.Unstructured)
+		if !ok {
+			continue
+		}
+
+		gvk := desired.GroupVersionKind()
+		c, err := d.sharedClientFactory.ForKind(gvk)
+		if err != nil {
+			return nil, err
+		}
+
+		namespaced, err := d.sharedClientFactory.IsNamespaced(gvk)
+		if err != nil {
+			return nil, err
+		}
+
+		r := v1.ReleaseResource{
+			Name: desired.GetName(),
+		}
+		if namespaced {
+			r.Namespace = desired.GetNamespace()
+			if r.Namespace == "" {
+				r.Namespace = app.Spec.Namespace
+			}
+		}
+		r.APIVersion, r.Kind = gvk.ToAPIVersionAndKind()
+
+		live := &unstructured.Unstructured{}
+		if err := c.Get(d.ctx, r.Namespace, r.Name, live, metav1.GetOptions{}); apierrors.IsNotFound(err) {
+			result = append(result, v1.DriftedResource{
+				ReleaseResource: r,
+				Missing:         true,
+			})
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		if fields := diffFields(desired.Object, live.Object); len(fields) > 0 {
+			result = append(result, v1.DriftedResource{
+				ReleaseResource: r,
+				Fields:          fields,
+			})
+		}
+	}
+
+	return result, nil
+}
+
+// sourceRepo returns the name of the ClusterRepo the app was installed from, or "" if it wasn't
+// installed from a ClusterRepo.
+func sourceRepo(app *v1.App) string {
+	if app.Spec.Chart == nil || app.Spec.Chart.Metadata == nil {
+		return ""
+	}
+	annotations := app.Spec.Chart.Metadata.Annotations
+	if annotations["catalog.cattle.io/ui-source-repo-type"] != "cluster" {
+		return ""
+	}
+	return annotations["catalog.cattle.io/ui-source-repo"]
+}
+
+// correctionAllowed returns true if both the app and the ClusterRepo it was installed from opt in
+// to drift correction.
+func (d *driftHandler) correctionAllowed(app *v1.App) bool {
+	if app.Annotations[DriftCorrectionAnnotation] != "true" {
+		return false
+	}
+	repoName := sourceRepo(app)
+	if repoName == "" {
+		return false
+	}
+	repo, err := d.clusterRepos.Get(repoName)
+	if err != nil {
+		return false
+	}
+	return repo.Annotations[DriftCorrectionAnnotation] == "true"
+}
+
+// correctionUser ensures the service account drift is corrected as exists in the namespace of
+// the app and is allowed to manage the namespace, but nothing outside of it.
+func (d *driftHandler) correctionUser(app *v1.App) (user.Info, error) {
+	err := d.apply.
+		WithSetID("helm-drift-correction-"+app.Namespace).
+		WithDefaultNamespace(app.Namespace).
+		ApplyObjects(
+			&corev1.ServiceAccount{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      driftCorrectionServiceAccount,
+					Namespace: app.Namespace,
+				},
+			},
+			&rbacv1.RoleBinding{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      driftCorrectionServiceAccount,
+					Namespace: app.Namespace,
+				},
+				RoleRef: rbacv1.RoleRef{
+					APIGroup: rbacv1.GroupName,
+					Kind:     "ClusterRole",
+					Name:     "admin",
+				},
+				Subjects: []rbacv1.Subject{
+					{
+						Kind:      rbacv1.ServiceAccountKind,
+						Name:      driftCorrectionServiceAccount,
+						Namespace: app.Namespace,
+					},
+				},
+			})
+	if err != nil {
+		return nil, err
+	}
+
+	return &user.DefaultInfo{
+		Name: serviceaccount.MakeUsername(app.Namespace, driftCorrectionServiceAccount),
+		Groups: []string{
+			"system:serviceaccounts",
+			"system:serviceaccounts:" + app.Namespace,
+			user.AllAuthenticated,
+		},
+	}, nil
+}
+
+// correct runs an upgrade to the deployed chart version with the deployed values. Only apps
+// installed from a ClusterRepo can be corrected.
+func (d *driftHandler) correct(app *v1.App) error {
+	repoName := sourceRepo(app)
+	if repoName == "" {
+		return fmt.Errorf("app %s/%s was not installed from a cluster repo", app.Namespace, app.Name)
+	}
+	metadata := app.Spec.Chart.Metadata
+
+	upgrade, err := json.Marshal(types.ChartUpgradeAction{
+		Timeout:    &metav1.Duration{Duration: 5 * time.Minute},
+		MaxHistory: 5,
+		Namespace:  app.Spec.Namespace,
+		Charts: []types.ChartUpgrade{
+			{
+				ChartName:   metadata.Name,
+				Version:     metadata.Version,
+				ReleaseName: app.Spec.Name,
+				Values:      app.Spec.Values,
+				Annotations: map[string]string{
+					"catalog.cattle.io/ui-source-repo":      repoName,
+					"catalog.cattle.io/ui-source-repo-type": "cluster",
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	correctionUser, err := d.correctionUser(app)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("Correcting drift of app %s/%s by upgrading to %s %s", app.Namespace, app.Name, metadata.Name, metadata.Version)
+	_, err = d.operations.Upgrade(d.ctx, correctionUser, "", repoName, bytes.NewBuffer(upgrade))
+	return err
+}
+
+// diffFields returns the paths of the fields set in desired whose value is different in live.
+// Fields only set in live, such as defaults and status, are ignored.
+func diffFields(desired, live map[string]interface{}) []string {
+	var fields []string
+	for k, v := range desired {
+		switch k {
+		case "apiVersion", "kind", "status", "stringData":
+			continue
+		case "metadata":
+			desiredMeta, _ := v.(map[string]interface{})
+			liveMeta, _ := live["metadata"].(map[string]interface{})
+			fields = append(fields, diffValue("metadata.labels", desiredMeta["labels"], liveMeta["labels"])...)
+			fields = append(fields, diffValue("metadata.annotations", desiredMeta["annotations"], liveMeta["annotations"])...)
+			continue
+		}
+		fields = append(fields, diffValue(k, v, live[k])...)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func diffValue(path string, desired, live interface{}) []string {
+	switch d := desired.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		if live == nil && len(d) == 0 {
+			return nil
+		}
+		l, ok := live.(map[string]interface{})
+		if !ok {
+			return []string{path}
+		}
+		var result []string
+		for k, v := range d {
+			result = append(result, diffValue(path+"."+k, v, l[k])...)
+		}
+		return result
+	case []interface{}:
+		if live == nil && len(d) == 0 {
+			return nil
+		}
+		l, ok := live.([]interface{})
+		if !ok || len(l) != len(d) {
+			return []string{path}
+		}
+		var result []string
+		for i := range d {
+			result = append(result, diffValue(fmt.Sprintf("%s[%d]", path, i), d[i], l[i])...)
+		}
+		return result
+	}
+
+	if scalarEqual(desired, live) {
+		return nil
+	}
+	return []string{path}
+}
+
+func scalarEqual(desired, live interface{}) bool {
+	desiredStr := convert.ToString(desired)
+	if live == nil {
+		// the API server drops fields set to their zero value
+		return desiredStr == "" || desiredStr == "false" || desiredStr == "0"
+	}
+
+	liveStr := convert.ToString(live)
+	if desiredStr == liveStr {
+		return true
+	}
+
+	// quantities are normalized by the API server, for example 1000m is stored as 1
+	desiredQuantity, err := resource.ParseQuantity(desiredStr)
+	if err != nil {
+		return false
+	}
+	liveQuantity, err := resource.ParseQuantity(liveStr)
+	if err != nil {
+		return false
+	}
+	return desiredQuantity.Cmp(liveQuantity) == 0
+}
@@ -0,0 +1,127 @@
+package helm
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffFields(t *testing.T) {
+	tests := []struct {
+		name           string
+		desired        map[string]interface{}
+		live           map[string]interface{}
+		expectedFields []string
+	}{
+		{
+			"no drift with defaults and status only in live",
+			map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": map[string]interface{}{
+					"name":   "test",
+					"labels": map[string]interface{}{"app": "test"},
+				},
+				"spec": map[string]interface{}{
+					"replicas": int64(1),
+				},
+			},
+			map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": map[string]interface{}{
+					"name":            "test",
+					"resourceVersion": "1234",
+					"labels":          map[string]interface{}{"app": "test"},
+				},
+				"spec": map[string]interface{}{
+					"replicas":             int64(1),
+					"revisionHistoryLimit": int64(10),
+				},
+				"status": map[string]interface{}{
+					"readyReplicas": int64(1),
+				},
+			},
+			nil,
+		},
+		{
+			"changed replicas and label",
+			map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{"app": "test"},
+				},
+				"spec": map[string]interface{}{
+					"replicas": int64(1),
+				},
+			},
+			map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{"app": "changed"},
+				},
+				"spec": map[string]interface{}{
+					"replicas": int64(3),
+				},
+			},
+			[]string{"metadata.labels.app", "spec.replicas"},
+		},
+		{
+			"changed list length",
+			map[string]interface{}{
+				"spec": map[string]interface{}{
+					"ports": []interface{}{
+						map[string]interface{}{"port": int64(80)},
+					},
+				},
+			},
+			map[string]interface{}{
+				"spec": map[string]interface{}{
+					"ports": []interface{}{
+						map[string]interface{}{"port": int64(80)},
+						map[string]interface{}{"port": int64(443)},
+					},
+				},
+			},
+			[]string{"spec.ports"},
+		},
+		{
+			"changed list item",
+			map[string]interface{}{
+				"spec": map[string]interface{}{
+					"ports": []interface{}{
+						map[string]interface{}{"port": int64(80)},
+					},
+				},
+			},
+			map[string]interface{}{
+				"spec": map[string]interface{}{
+					"ports": []interface{}{
+						map[string]interface{}{"port": int64(8080)},
+					},
+				},
+			},
+			[]string{"spec.ports[0].port"},
+		},
+		{
+			"normalized quantities and dropped zero values",
+			map[string]interface{}{
+				"spec": map[string]interface{}{
+					"cpu":         "1000m",
+					"hostNetwork": false,
+					"volumes":     []interface{}{},
+				},
+			},
+			map[string]interface{}{
+				"spec": map[string]interface{}{
+					"cpu": "1",
+				},
+			},
+			nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expectedFields, diffFields(tt.desired, tt.live))
+		})
+	}
+}
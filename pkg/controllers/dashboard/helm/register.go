@@ -19,6 +19,13 @@ func Register(ctx context.Context, wrangler *wrangler.Context) {
 		wrangler.Core.ConfigMap(),
 		wrangler.Core.Secret(),
 		wrangler.Catalog.App())
+	RegisterDrift(ctx,
+		wrangler.Apply,
+		wrangler.ControllerFactory.SharedCacheFactory().SharedClientFactory(),
+		wrangler.Core.Secret().Cache(),
+		wrangler.Catalog.ClusterRepo().Cache(),
+		wrangler.Catalog.App(),
+		wrangler.HelmOperations)
 	RegisterOperations(ctx,
 		wrangler.K8s,
 		wrangler.Core.Pod(),
@@ -10,6 +10,7 @@
 
 	catalog "github.com/rancher/rancher/pkg/apis/catalog.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/catalogv2"
+	"github.com/rancher/rancher/pkg/catalogv2/bundle"
 	"github.com/rancher/rancher/pkg/catalogv2/git"
 	helmhttp "github.com/rancher/rancher/pkg/catalogv2/http"
 	catalogcontrollers "github.com/rancher/rancher/pkg/generated/controllers/catalog.cattle.io/v1"
@@ -62,10 +63,12 @@ func RegisterRepos(ctx context.Context,
 
 func RegisterReposForFollowers(ctx context.Context,
 	secrets corev1controllers.SecretCache,
-	clusterRepos catalogcontrollers.ClusterRepoController) {
+	clusterRepos catalogcontrollers.ClusterRepoController,
+	configMapCache corev1controllers.ConfigMapCache) {
 	h := &repoHandler{
-		secrets:      secrets,
-		clusterRepos: clusterRepos,
+		secrets:        secrets,
+		clusterRepos:   clusterRepos,
+		configMapCache: configMapCache,
 	}
 
 	catalogcontrollers.RegisterClusterRepoStatusHandler(ctx, clusterRepos,
@@ -176,6 +179,10 @@ func (r *repoHandler) createOrUpdateMap(namespace, name string, index *repo.Inde
 }
 
 func (r *repoHandler) ensure(repoSpec *catalog.RepoSpec, status catalog.RepoStatus, metadata *metav1.ObjectMeta) (catalog.RepoStatus, error) {
+	if bundle.Name(repoSpec.URL) != "" {
+		return status, bundle.Ensure(r.configMapCache, repoSpec.URL, metadata)
+	}
+
 	if status.Commit == "" {
 		return status, nil
 	}
@@ -227,6 +234,9 @@ func (r *repoHandler) download(repoSpec *catalog.RepoSpec, status catalog.RepoSt
 	} else if repoSpec.URL != "" {
 		status.URL = repoSpec.URL
 		status.Branch = ""
+		if err := bundle.Ensure(r.configMapCache, repoSpec.URL, metadata); err != nil {
+			return status, err
+		}
 		index, err = helmhttp.DownloadIndex(secret, repoSpec.URL, repoSpec.CABundle, repoSpec.InsecureSkipTLSverify)
 	} else {
 		return status, nil
@@ -11,6 +11,6 @@
 
 func Register(ctx context.Context, wrangler *wrangler.Context) error {
 	feature.Register(ctx, wrangler.Mgmt.Feature())
-	helm.RegisterReposForFollowers(ctx, wrangler.Core.Secret().Cache(), wrangler.Catalog.ClusterRepo())
+	helm.RegisterReposForFollowers(ctx, wrangler.Core.Secret().Cache(), wrangler.Catalog.ClusterRepo(), wrangler.Core.ConfigMap().Cache())
 	return settings.Register(wrangler.Mgmt.Setting())
 }
@@ -2,32 +2,54 @@
 
 import (
 	"context"
+	"fmt"
 	"io/ioutil"
 	"net/http"
+	"strconv"
 	"time"
 
 	"github.com/rancher/rancher/pkg/api/steve/proxy"
 	v3 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	managementcontrollers "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/metrics/downstream"
+	"github.com/rancher/rancher/pkg/notifiers"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/rancher/pkg/tunnelserver/mcmauthorizer"
 	"github.com/rancher/rancher/pkg/wrangler"
 	"github.com/rancher/remotedialer"
 	"github.com/rancher/wrangler/pkg/condition"
+	"github.com/rancher/wrangler/pkg/schemes"
 	"github.com/rancher/wrangler/pkg/ticker"
 	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
 	apierror "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	typedcorev1 "k8s.io/client-go/kubernetes/typed/core/v1"
+	"k8s.io/client-go/tools/record"
 )
 
+// maxConnectionHistory is the number of connects and disconnects kept in the status of a cluster
+const maxConnectionHistory = 50
+
 var (
 	Connected = condition.Cond("Connected")
 )
 
 func Register(ctx context.Context, wrangler *wrangler.Context) {
+	broadcaster := record.NewBroadcaster()
+	broadcaster.StartRecordingToSink(&typedcorev1.EventSinkImpl{
+		Interface: wrangler.K8s.CoreV1().Events(""),
+	})
+
 	c := checker{
-		clusterCache: wrangler.Mgmt.Cluster().Cache(),
-		clusters:     wrangler.Mgmt.Cluster(),
-		tunnelServer: wrangler.TunnelServer,
+		ctx:           ctx,
+		clusterCache:  wrangler.Mgmt.Cluster().Cache(),
+		clusters:      wrangler.Mgmt.Cluster(),
+		nodeCache:     wrangler.Mgmt.Node().Cache(),
+		notifierCache: wrangler.Mgmt.Notifier().Cache(),
+		tunnelServer:  wrangler.TunnelServer,
+		recorder:      broadcaster.NewRecorder(schemes.All, corev1.EventSource{Component: "cluster-connected"}),
 	}
 
 	go func() {
@@ -40,9 +62,13 @@ func Register(ctx context.Context, wrangler *wrangler.Context) {
 }
 
 type checker struct {
-	clusterCache managementcontrollers.ClusterCache
-	clusters     managementcontrollers.ClusterClient
-	tunnelServer *remotedialer.Server
+	ctx           context.Context
+	clusterCache  managementcontrollers.ClusterCache
+	clusters      managementcontrollers.ClusterClient
+	nodeCache     managementcontrollers.NodeCache
+	notifierCache managementcontrollers.NotifierCache
+	tunnelServer  *remotedialer.Server
+	recorder      record.EventRecorder
 }
 
 func (c *checker) check() error {
@@ -85,27 +111,53 @@ func (c *checker) hasSession(cluster *v3.Cluster) bool {
 	return resp.StatusCode == http.StatusOK
 }
 
+// recordConnectedAgents records the number of agents of the cluster connected to this server
+func (c *checker) recordConnectedAgents(cluster *v3.Cluster) {
+	clusterAgents := 0
+	if c.tunnelServer.HasSession(cluster.Name) {
+		clusterAgents = 1
+	}
+
+	nodeAgents := 0
+	nodes, err := c.nodeCache.List(cluster.Name, labels.Everything())
+	if err != nil {
+		logrus.Debugf("failed to list nodes of cluster [%s]: %v", cluster.Name, err)
+	}
+	for _, node := range nodes {
+		if c.tunnelServer.HasSession(cluster.Name + ":" + node.Name) {
+			nodeAgents++
+		}
+	}
+
+	downstream.SetTunnelConnectedAgents(cluster.Name, clusterAgents, nodeAgents)
+}
+
 func (c *checker) checkCluster(cluster *v3.Cluster) error {
 	if cluster.Spec.Internal {
 		return nil
 	}
 
 	hasSession := c.hasSession(cluster)
+	downstream.SetClusterConnected(cluster.Name, hasSession)
+	c.recordConnectedAgents(cluster)
+
 	// The simpler condition of hasSession == Connected.IsTrue(cluster) is not
 	// used because it treat a non-existent conditions as False
 	if hasSession && Connected.IsTrue(cluster) {
 		return nil
 	} else if !hasSession && Connected.IsFalse(cluster) {
-		return nil
+		return c.notifyDisconnected(cluster)
 	}
 
 	var (
 		err error
 	)
 
+	now := time.Now().UTC()
 	for i := 0; i < 3; i++ {
 		cluster = cluster.DeepCopy()
 		Connected.SetStatusBool(cluster, hasSession)
+		recordConnection(cluster, hasSession, now)
 		_, err = c.clusters.Update(cluster)
 		if apierror.IsConflict(err) {
 			cluster, err = c.clusters.Get(cluster.Name, metav1.GetOptions{})
@@ -116,8 +168,86 @@ func (c *checker) checkCluster(cluster *v3.Cluster) error {
 		} else if err != nil {
 			return err
 		}
+
+		if hasSession {
+			c.recorder.Eventf(cluster, corev1.EventTypeNormal, "ClusterConnected", "Agent of cluster [%s] connected", cluster.Name)
+		} else {
+			c.recorder.Eventf(cluster, corev1.EventTypeWarning, "ClusterDisconnected", "Agent of cluster [%s] disconnected", cluster.Name)
+		}
 		return nil
 	}
 
 	return err
 }
+
+// recordConnection adds a connect to the connection history of the cluster or closes the
+// last connection on a disconnect
+func recordConnection(cluster *v3.Cluster, connected bool, now time.Time) {
+	history := cluster.Status.ConnectionHistory
+	if connected {
+		history = append(history, v3.ClusterConnection{
+			ConnectedAt:   now.Format(time.RFC3339),
+			AgentVersion:  cluster.Annotations[mcmauthorizer.AgentVersionAnnotation],
+			RemoteAddress: cluster.Annotations[mcmauthorizer.AgentAddressAnnotation],
+		})
+	} else if last := len(history) - 1; last >= 0 && history[last].DisconnectedAt == "" {
+		history[last].DisconnectedAt = now.Format(time.RFC3339)
+		if connectedAt, err := time.Parse(time.RFC3339, history[last].ConnectedAt); err == nil {
+			history[last].Duration = now.Sub(connectedAt).Round(time.Second).String()
+		}
+	} else {
+		history = append(history, v3.ClusterConnection{
+			DisconnectedAt: now.Format(time.RFC3339),
+		})
+	}
+
+	if len(history) > maxConnectionHistory {
+		history = history[len(history)-maxConnectionHistory:]
+	}
+	cluster.Status.ConnectionHistory = history
+}
+
+// notifyDisconnected sends a message to the notifiers of the cluster once it is disconnected
+// for longer than the cluster-disconnect-notify-minutes setting
+func (c *checker) notifyDisconnected(cluster *v3.Cluster) error {
+	minutes, err := strconv.Atoi(settings.ClusterDisconnectNotifyMinutes.Get())
+	if err != nil || minutes <= 0 {
+		return nil
+	}
+
+	last := len(cluster.Status.ConnectionHistory) - 1
+	if last < 0 || cluster.Status.ConnectionHistory[last].DisconnectNotified {
+		return nil
+	}
+	disconnectedAt, err := time.Parse(time.RFC3339, cluster.Status.ConnectionHistory[last].DisconnectedAt)
+	if err != nil || time.Since(disconnectedAt) < time.Duration(minutes)*time.Minute {
+		return nil
+	}
+
+	notifierList, err := c.notifierCache.List(cluster.Name, labels.Everything())
+	if err != nil {
+		return err
+	}
+
+	msg := &notifiers.Message{
+		Title:   fmt.Sprintf("Cluster %s is disconnected", clusterDisplayName(cluster)),
+		Content: fmt.Sprintf("The agent of cluster %s has been disconnected from Rancher since %s", clusterDisplayName(cluster), disconnectedAt.Format(time.RFC3339)),
+	}
+	for _, notifier := range notifierList {
+		if err := notifiers.SendMessage(c.ctx, notifier, "", msg, nil); err != nil {
+			logrus.Errorf("failed to notify [%s] of disconnected cluster [%s]: %v", notifier.Name, cluster.Name, err)
+		}
+	}
+
+	cluster = cluster.DeepCopy()
+	cluster.Status.ConnectionHistory[last].DisconnectNotified = true
+	_, err = c.clusters.Update(cluster)
+	return err
+}
+
+func clusterDisplayName(cluster *v3.Cluster) string {
+	if cluster.Spec.DisplayName != "" {
+		return cluster.Spec.DisplayName
+	}
+	return cluster.Name
+}
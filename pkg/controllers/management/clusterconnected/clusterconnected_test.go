@@ -0,0 +1,59 @@
+package clusterconnected
+
+import (
+	"testing"
+	"time"
+
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/tunnelserver/mcmauthorizer"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestRecordConnection(t *testing.T) {
+	cluster := &v3.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "c-abc",
+			Annotations: map[string]string{
+				mcmauthorizer.AgentVersionAnnotation: "v2.6.0",
+				mcmauthorizer.AgentAddressAnnotation: "198.51.100.1",
+			},
+		},
+	}
+	connectedAt := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	recordConnection(cluster, true, connectedAt)
+	assert.Len(t, cluster.Status.ConnectionHistory, 1)
+	assert.Equal(t, "2021-06-01T10:00:00Z", cluster.Status.ConnectionHistory[0].ConnectedAt)
+	assert.Equal(t, "v2.6.0", cluster.Status.ConnectionHistory[0].AgentVersion)
+	assert.Equal(t, "198.51.100.1", cluster.Status.ConnectionHistory[0].RemoteAddress)
+	assert.Empty(t, cluster.Status.ConnectionHistory[0].DisconnectedAt)
+
+	recordConnection(cluster, false, connectedAt.Add(90*time.Minute))
+	assert.Len(t, cluster.Status.ConnectionHistory, 1)
+	assert.Equal(t, "2021-06-01T11:30:00Z", cluster.Status.ConnectionHistory[0].DisconnectedAt)
+	assert.Equal(t, "1h30m0s", cluster.Status.ConnectionHistory[0].Duration)
+
+	// A disconnect without an open connection is recorded on its own
+	recordConnection(cluster, false, connectedAt.Add(2*time.Hour))
+	assert.Len(t, cluster.Status.ConnectionHistory, 2)
+	assert.Empty(t, cluster.Status.ConnectionHistory[1].ConnectedAt)
+	assert.Equal(t, "2021-06-01T12:00:00Z", cluster.Status.ConnectionHistory[1].DisconnectedAt)
+}
+
+func TestRecordConnectionLimit(t *testing.T) {
+	cluster := &v3.Cluster{}
+	start := time.Date(2021, 6, 1, 10, 0, 0, 0, time.UTC)
+
+	for i := 0; i < maxConnectionHistory+5; i++ {
+		recordConnection(cluster, true, start.Add(time.Duration(i)*time.Hour))
+		recordConnection(cluster, false, start.Add(time.Duration(i)*time.Hour+time.Minute))
+	}
+
+	assert.Len(t, cluster.Status.ConnectionHistory, maxConnectionHistory)
+	last := cluster.Status.ConnectionHistory[maxConnectionHistory-1]
+	assert.Equal(t, start.Add(time.Duration(maxConnectionHistory+4)*time.Hour).Format(time.RFC3339), last.ConnectedAt)
+	assert.Equal(t, "1m0s", last.Duration)
+	first := cluster.Status.ConnectionHistory[0]
+	assert.Equal(t, start.Add(5*time.Hour).Format(time.RFC3339), first.ConnectedAt)
+}
@@ -0,0 +1,65 @@
+package usercontrollers
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"sort"
+	"strconv"
+)
+
+// virtualNodes is the number of points every peer has on the ring, more points spread the
+// clusters more evenly across peers
+const virtualNodes = 128
+
+// ring assigns clusters to peers by consistent hashing. When a peer joins or leaves only the
+// clusters it gains or owned move, all other clusters keep their owner.
+type ring struct {
+	points []uint64
+	owners map[uint64]string
+}
+
+func newRing(ids []string) *ring {
+	r := &ring{
+		owners: map[uint64]string{},
+	}
+
+	for _, id := range ids {
+		for i := 0; i < virtualNodes; i++ {
+			point := hash(id + "#" + strconv.Itoa(i))
+			// on the unlikely collision the lowest ID wins so all peers agree on the owner
+			if owner, ok := r.owners[point]; ok && owner < id {
+				continue
+			} else if !ok {
+				r.points = append(r.points, point)
+			}
+			r.owners[point] = id
+		}
+	}
+
+	sort.Slice(r.points, func(i, j int) bool {
+		return r.points[i] < r.points[j]
+	})
+	return r
+}
+
+// owner returns the peer owning the key, which is the first point on the ring after the hash of
+// the key, or "" if there are no peers
+func (r *ring) owner(key string) string {
+	if len(r.points) == 0 {
+		return ""
+	}
+
+	h := hash(key)
+	i := sort.Search(len(r.points), func(i int) bool {
+		return r.points[i] >= h
+	})
+	if i == len(r.points) {
+		i = 0
+	}
+	return r.owners[r.points[i]]
+}
+
+func hash(s string) uint64 {
+	sum := sha256.Sum256([]byte(s))
+	return binary.BigEndian.Uint64(sum[:8])
+}
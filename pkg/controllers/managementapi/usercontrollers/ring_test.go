@@ -0,0 +1,57 @@
+package usercontrollers
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func clusterKeys(n int) []string {
+	var keys []string
+	for i := 0; i < n; i++ {
+		keys = append(keys, fmt.Sprintf("c-%05d", i))
+	}
+	return keys
+}
+
+func TestRingBalance(t *testing.T) {
+	a := assert.New(t)
+	r := newRing([]string{"peer-a", "peer-b", "peer-c"})
+
+	counts := map[string]int{}
+	for _, key := range clusterKeys(3000) {
+		counts[r.owner(key)]++
+	}
+
+	a.Len(counts, 3)
+	for peer, count := range counts {
+		a.InDelta(1000, count, 250, "peer %s owns %d clusters", peer, count)
+	}
+}
+
+func TestRingRebalance(t *testing.T) {
+	a := assert.New(t)
+	before := newRing([]string{"peer-a", "peer-b", "peer-c"})
+	after := newRing([]string{"peer-a", "peer-b", "peer-c", "peer-d"})
+
+	moved := 0
+	for _, key := range clusterKeys(3000) {
+		if before.owner(key) != after.owner(key) {
+			moved++
+			// a joining peer only takes clusters, it never causes moves between other peers
+			a.Equal("peer-d", after.owner(key))
+		}
+	}
+	a.InDelta(750, moved, 250)
+
+	// the order of IDs doesn't matter
+	reordered := newRing([]string{"peer-c", "peer-a", "peer-b"})
+	for _, key := range clusterKeys(100) {
+		a.Equal(before.owner(key), reordered.owner(key))
+	}
+}
+
+func TestRingEmpty(t *testing.T) {
+	assert.Equal(t, "", newRing(nil).owner("c-00000"))
+}
@@ -2,8 +2,7 @@
 
 import (
 	"context"
-	"hash/crc32"
-	"math"
+	"os"
 	"sort"
 	"sync"
 	"time"
@@ -20,12 +19,16 @@
 	tpeermanager "github.com/rancher/rancher/pkg/peermanager"
 	"github.com/rancher/rancher/pkg/types/config"
 	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/util/retry"
 )
 
 var (
 	all = "_all_"
+
+	errOwnerChanged = errors.New("controller owner changed")
 )
 
 func Register(ctx context.Context, scaledContext *config.ScaledContext, clusterManager *clustermanager.Manager) {
@@ -37,6 +40,11 @@ func Register(ctx context.Context, scaledContext *config.ScaledContext, clusterM
 		ctx:           ctx,
 		start:         time.Now(),
 	}
+	if !u.clustered {
+		// Without peers the single replica owns every cluster, it records itself as the owner all
+		// the same so a replica taking over after a restart or scale up waits for the hand over
+		u.peers.SelfID = selfID()
+	}
 
 	scaledContext.Management.Clusters("").AddHandler(ctx, "user-controllers-controller", u.sync)
 
@@ -119,13 +127,20 @@ func (u *userControllersController) peersSync() error {
 
 	var (
 		errs []error
+		r    = newRing(u.peers.IDs)
 	)
 
 	for _, cluster := range clusters {
 		if cluster.DeletionTimestamp != nil || !v33.ClusterConditionProvisioned.IsTrue(cluster) {
 			u.manager.Stop(cluster)
+			if err := u.releaseOwner(cluster); err != nil {
+				errs = append(errs, err)
+			}
 		} else {
-			amOwner := u.amOwner(u.peers, cluster)
+			amOwner, err := u.claimOwner(cluster, u.amOwner(r, u.peers, cluster))
+			if err != nil {
+				errs = append(errs, errors.Wrapf(err, "failed to claim ownership of cluster %s", cluster.Name))
+			}
 			if amOwner {
 				metrics.SetClusterOwner(u.peers.SelfID, cluster.Name)
 			} else {
@@ -134,13 +149,20 @@ func (u *userControllersController) peersSync() error {
 			if err := u.manager.Start(u.ctx, cluster, amOwner); err != nil {
 				errs = append(errs, errors.Wrapf(err, "failed to start user controllers for cluster %s", cluster.Name))
 			}
+			if !amOwner {
+				// the owner controllers are stopped now so a new owner can take over
+				if err := u.releaseOwner(cluster); err != nil {
+					errs = append(errs, err)
+				}
+			}
 		}
 	}
 
 	return types.NewErrors(errs...)
 }
 
-func (u *userControllersController) amOwner(peers tpeermanager.Peers, cluster *v3.Cluster) bool {
+// amOwner returns whether this replica should own the cluster according to the ring
+func (u *userControllersController) amOwner(r *ring, peers tpeermanager.Peers, cluster *v3.Cluster) bool {
 	if !u.clustered {
 		return true
 	}
@@ -149,15 +171,102 @@ func (u *userControllersController) amOwner(peers tpeermanager.Peers, cluster *v
 		return false
 	}
 
-	ck := crc32.ChecksumIEEE([]byte(cluster.UID))
-	if ck == math.MaxUint32 {
-		ck--
+	owner := r.owner(string(cluster.UID))
+	logrus.Debugf("%s(%v): owner %v of %v, self = %v", cluster.Name, cluster.UID, owner, peers.IDs, peers.SelfID)
+	return owner == peers.SelfID
+}
+
+// claimOwner hands the cluster over between replicas through status.controllerOwner. The
+// replica that should own the cluster only runs its owner controllers once the previous owner
+// released it or is no longer a peer, so two replicas never run them at the same time.
+func (u *userControllersController) claimOwner(cluster *v3.Cluster, shouldOwn bool) (bool, error) {
+	current := cluster.Status.ControllerOwner
+	switch {
+	case !shouldOwn:
+		return false, nil
+	case current == u.peers.SelfID:
+		return true, nil
+	case current != "" && u.isPeer(current):
+		logrus.Debugf("Waiting for %s to hand over cluster %s", current, cluster.Name)
+		return false, nil
 	}
 
-	scaled := int(ck) * len(peers.IDs) / math.MaxUint32
-	logrus.Debugf("%s(%v): (%v * %v) / %v = %v[%v] = %v, self = %v\n", cluster.Name, cluster.UID, ck,
-		uint32(len(peers.IDs)), math.MaxUint32, peers.IDs, scaled, peers.IDs[scaled], peers.SelfID)
-	return peers.IDs[scaled] == peers.SelfID
+	logrus.Infof("Taking over cluster %s from [%s]", cluster.Name, current)
+	err := setControllerOwner(u.clusters, cluster, u.peers.SelfID)
+	switch {
+	case !u.clustered:
+		// the single replica runs the owner controllers even if recording itself failed
+		return true, err
+	case err == errOwnerChanged:
+		logrus.Debugf("Cluster %s was claimed by another replica", cluster.Name)
+		return false, nil
+	case err != nil:
+		return false, err
+	}
+	return true, nil
+}
+
+// releaseOwner clears status.controllerOwner if this replica owned the cluster
+func (u *userControllersController) releaseOwner(cluster *v3.Cluster) error {
+	if cluster.Status.ControllerOwner != u.peers.SelfID || cluster.DeletionTimestamp != nil {
+		return nil
+	}
+
+	logrus.Infof("Handing over cluster %s", cluster.Name)
+	err := setControllerOwner(u.clusters, cluster, "")
+	if err == errOwnerChanged {
+		return nil
+	}
+	return err
+}
+
+type clusterClient interface {
+	Get(name string, opts metav1.GetOptions) (*v3.Cluster, error)
+	Update(*v3.Cluster) (*v3.Cluster, error)
+}
+
+// setControllerOwner changes status.controllerOwner of the cluster to owner. The update is
+// conditional on the resourceVersion of the cluster the decision was based on, on a conflict the
+// cluster is read again and only updated if its owner didn't change in the meantime.
+func setControllerOwner(clusters clusterClient, cluster *v3.Cluster, owner string) error {
+	expected := cluster.Status.ControllerOwner
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		if cluster.Status.ControllerOwner == owner {
+			return nil
+		}
+		if cluster.Status.ControllerOwner != expected {
+			return errOwnerChanged
+		}
+
+		update := cluster.DeepCopy()
+		update.Status.ControllerOwner = owner
+		_, err := clusters.Update(update)
+		if apierrors.IsConflict(err) {
+			latest, getErr := clusters.Get(cluster.Name, metav1.GetOptions{})
+			if getErr != nil {
+				return getErr
+			}
+			cluster = latest
+		}
+		return err
+	})
+}
+
+func selfID() string {
+	hostname, err := os.Hostname()
+	if err != nil {
+		return "rancher"
+	}
+	return hostname
+}
+
+func (u *userControllersController) isPeer(id string) bool {
+	for _, peer := range u.peers.IDs {
+		if peer == id {
+			return true
+		}
+	}
+	return false
 }
 
 func (u *userControllersController) cleanFinalizers(key string, cluster *v3.Cluster) error {
@@ -0,0 +1,96 @@
+package usercontrollers
+
+import (
+	"testing"
+
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// fakeClusters is a cluster client with optimistic concurrency on the resourceVersion
+type fakeClusters struct {
+	cluster *v3.Cluster
+	updates int
+	// onUpdate changes the stored cluster before the next update, like another replica would
+	onUpdate func(*v3.Cluster)
+}
+
+func (f *fakeClusters) Get(name string, opts metav1.GetOptions) (*v3.Cluster, error) {
+	return f.cluster.DeepCopy(), nil
+}
+
+func (f *fakeClusters) Update(cluster *v3.Cluster) (*v3.Cluster, error) {
+	if f.onUpdate != nil {
+		f.onUpdate(f.cluster)
+		f.onUpdate = nil
+	}
+	if cluster.ResourceVersion != f.cluster.ResourceVersion {
+		return nil, apierrors.NewConflict(schema.GroupResource{Resource: "clusters"}, cluster.Name, nil)
+	}
+	f.updates++
+	f.cluster = cluster.DeepCopy()
+	f.cluster.ResourceVersion += "1"
+	return f.cluster.DeepCopy(), nil
+}
+
+func newCluster(owner string) *v3.Cluster {
+	cluster := &v3.Cluster{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "c-abc",
+			ResourceVersion: "1",
+		},
+	}
+	cluster.Status.ControllerOwner = owner
+	return cluster
+}
+
+func TestSetControllerOwner(t *testing.T) {
+	clusters := &fakeClusters{cluster: newCluster("")}
+
+	assert.NoError(t, setControllerOwner(clusters, newCluster(""), "peer-a"))
+	assert.Equal(t, "peer-a", clusters.cluster.Status.ControllerOwner)
+	assert.Equal(t, 1, clusters.updates)
+}
+
+func TestSetControllerOwnerUnchanged(t *testing.T) {
+	clusters := &fakeClusters{cluster: newCluster("peer-a")}
+
+	assert.NoError(t, setControllerOwner(clusters, newCluster("peer-a"), "peer-a"))
+	assert.Equal(t, 0, clusters.updates)
+}
+
+func TestSetControllerOwnerRetriesConflict(t *testing.T) {
+	clusters := &fakeClusters{cluster: newCluster("")}
+	clusters.onUpdate = func(cluster *v3.Cluster) {
+		// An unrelated change bumps the resourceVersion
+		cluster.ResourceVersion = "2"
+	}
+
+	assert.NoError(t, setControllerOwner(clusters, newCluster(""), "peer-a"))
+	assert.Equal(t, "peer-a", clusters.cluster.Status.ControllerOwner)
+	assert.Equal(t, 1, clusters.updates)
+}
+
+func TestSetControllerOwnerClaimedConcurrently(t *testing.T) {
+	clusters := &fakeClusters{cluster: newCluster("")}
+	clusters.onUpdate = func(cluster *v3.Cluster) {
+		cluster.ResourceVersion = "2"
+		cluster.Status.ControllerOwner = "peer-b"
+	}
+
+	assert.Equal(t, errOwnerChanged, setControllerOwner(clusters, newCluster(""), "peer-a"))
+	assert.Equal(t, "peer-b", clusters.cluster.Status.ControllerOwner)
+	assert.Equal(t, 0, clusters.updates)
+}
+
+func TestSetControllerOwnerStale(t *testing.T) {
+	// The lister still has the previous owner, the update must not overwrite the current one
+	clusters := &fakeClusters{cluster: newCluster("peer-b")}
+	clusters.cluster.ResourceVersion = "2"
+
+	assert.Equal(t, errOwnerChanged, setControllerOwner(clusters, newCluster("peer-c"), "peer-a"))
+	assert.Equal(t, "peer-b", clusters.cluster.Status.ControllerOwner)
+}
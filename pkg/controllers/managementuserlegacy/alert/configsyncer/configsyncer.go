@@ -7,6 +7,7 @@
 	"net/url"
 	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
@@ -25,6 +26,7 @@
 	notifierutil "github.com/rancher/rancher/pkg/notifiers"
 	"github.com/rancher/rancher/pkg/project"
 	"github.com/rancher/rancher/pkg/ref"
+	"github.com/rancher/rancher/pkg/settings"
 	"github.com/rancher/rancher/pkg/types/config"
 
 	"github.com/sirupsen/logrus"
@@ -75,6 +77,10 @@ func NewConfigSyncer(ctx context.Context, cluster *config.UserContext, alertMana
 		clusterName:             cluster.ClusterName,
 		alertManager:            alertManager,
 		operatorCRDManager:      operatorCRDManager,
+		relayTokens: &relayTokens{
+			secrets:      cluster.Management.Core,
+			secretLister: cluster.Management.Core.Secrets(cluster.ClusterName).Controller().Lister(),
+		},
 	}
 }
 
@@ -92,6 +98,7 @@ type ConfigSyncer struct {
 	clusterName             string
 	alertManager            *manager.AlertManager
 	operatorCRDManager      *manager.PromOperatorCRDManager
+	relayTokens             relayTokenGetter
 }
 
 func (d *ConfigSyncer) ProjectGroupSync(key string, alert *v3.ProjectAlertGroup) (runtime.Object, error) {
@@ -241,10 +248,14 @@ func (d *ConfigSyncer) sync() error {
 		return errors.Wrapf(err, "Get secrets")
 	}
 
-	if string(configSecret.Data["alertmanager.yaml"]) != string(data) || string(configSecret.Data["notification.tmpl"]) != deployer.NotificationTmpl {
+	templates := notifierutil.Templates(settings.ServerURL.Get(), d.clusterName)
+	caCerts := settings.CACerts.Get()
+	if string(configSecret.Data["alertmanager.yaml"]) != string(data) || string(configSecret.Data["notification.tmpl"]) != templates ||
+		string(configSecret.Data[relayCAKey]) != caCerts {
 		newConfigSecret := configSecret.DeepCopy()
 		newConfigSecret.Data["alertmanager.yaml"] = data
-		newConfigSecret.Data["notification.tmpl"] = []byte(deployer.NotificationTmpl)
+		newConfigSecret.Data["notification.tmpl"] = []byte(templates)
+		newConfigSecret.Data[relayCAKey] = []byte(caCerts)
 
 		_, err = secretClient.Update(newConfigSecret)
 		if err != nil {
@@ -480,170 +491,263 @@ func (d *ConfigSyncer) appendRoute(route *alertconfig.Route, subRoute *alertconf
 func (d *ConfigSyncer) addRecipients(notifiers []*v3.Notifier, receiver *alertconfig.Receiver, recipients []v32.Recipient) bool {
 	receiverExist := false
 	for _, r := range recipients {
-		if r.NotifierName != "" {
-			notifier := d.getNotifier(r.NotifierName, notifiers)
-			if notifier == nil {
-				logrus.Debugf("Can not find the notifier %s", r.NotifierName)
-				continue
-			}
-			commonNotifierConfig := alertconfig.NotifierConfig{
-				VSendResolved: notifier.Spec.SendResolved,
-			}
-			if notifier.Spec.PagerdutyConfig != nil {
-				pagerduty := &alertconfig.PagerdutyConfig{
-					NotifierConfig: commonNotifierConfig,
-					ServiceKey:     alertconfig.Secret(notifier.Spec.PagerdutyConfig.ServiceKey),
-					Description:    `{{ template "rancher.title" . }}`,
-				}
+		if r.NotifierName == "" {
+			continue
+		}
+		notifier := d.getNotifier(r.NotifierName, notifiers)
+		if notifier == nil {
+			logrus.Debugf("Can not find the notifier %s", r.NotifierName)
+			continue
+		}
 
-				if notifierutil.IsHTTPClientConfigSet(notifier.Spec.PagerdutyConfig.HTTPClientConfig) {
-					url, err := toAlertManagerURL(notifier.Spec.PagerdutyConfig.HTTPClientConfig.ProxyURL)
-					if err != nil {
-						logrus.Errorf("Failed to parse pagerduty proxy url %s, %v", notifier.Spec.PagerdutyConfig.HTTPClientConfig.ProxyURL, err)
-						continue
-					}
-					pagerduty.HTTPConfig = &alertconfig.HTTPClientConfig{
-						ProxyURL: *url,
-					}
-				}
-				if r.Recipient != "" {
-					pagerduty.ServiceKey = alertconfig.Secret(r.Recipient)
-				}
-				receiver.PagerdutyConfigs = append(receiver.PagerdutyConfigs, pagerduty)
-				receiverExist = true
-
-			} else if notifier.Spec.WechatConfig != nil {
-				wechat := &alertconfig.WechatConfig{
-					NotifierConfig: commonNotifierConfig,
-					APISecret:      alertconfig.Secret(notifier.Spec.WechatConfig.Secret),
-					AgentID:        notifier.Spec.WechatConfig.Agent,
-					CorpID:         notifier.Spec.WechatConfig.Corp,
-					Message:        `{{ template "wechat.text" . }}`,
-				}
+		typeName := notifierutil.TypeName(&notifier.Spec)
+		build, ok := receiverBuilders[typeName]
+		if !ok {
+			logrus.Debugf("Notifier %s of type [%s] can not receive alerts", r.NotifierName, typeName)
+			continue
+		}
+		if err := build(d, receiver, notifier, r.Recipient); err != nil {
+			logrus.Errorf("Failed to add notifier %s to receiver %s: %v", r.NotifierName, receiver.Name, err)
+			continue
+		}
+		receiverExist = true
+	}
 
-				recipient := notifier.Spec.WechatConfig.DefaultRecipient
-				if r.Recipient != "" {
-					recipient = r.Recipient
-				}
+	return receiverExist
+}
 
-				switch notifier.Spec.WechatConfig.RecipientType {
-				case "tag":
-					wechat.ToTag = recipient
-				case "user":
-					wechat.ToUser = recipient
-				default:
-					wechat.ToParty = recipient
-				}
+// receiverBuilder adds the Alertmanager config of a notifier and the recipient of an alert group
+// to the receiver
+type receiverBuilder func(d *ConfigSyncer, receiver *alertconfig.Receiver, notifier *v3.Notifier, recipient string) error
+
+// receiverBuilders are keyed by the notifier types of the notifier registry. Types Alertmanager
+// has no receiver for are posted to the relay of Rancher, which sends them with the notifier.
+var receiverBuilders = map[string]receiverBuilder{
+	"slack":      addSlack,
+	"smtp":       addEmail,
+	"pagerduty":  addPagerduty,
+	"wechat":     addWechat,
+	"webhook":    relayTemplated(addWebhook),
+	"dingtalk":   relayTemplated(addWebhookReceiver),
+	"msteams":    relayTemplated(addWebhookReceiver),
+	"opsgenie":   addOpsgenie,
+	"googlechat": addRelay,
+	"telegram":   addRelay,
+	"matrix":     addRelay,
+}
 
-				if notifierutil.IsHTTPClientConfigSet(notifier.Spec.WechatConfig.HTTPClientConfig) {
-					url, err := toAlertManagerURL(notifier.Spec.WechatConfig.HTTPClientConfig.ProxyURL)
-					if err != nil {
-						logrus.Errorf("Failed to parse wechat proxy url %s, %v", notifier.Spec.WechatConfig.HTTPClientConfig.ProxyURL, err)
-						continue
-					}
-					wechat.HTTPConfig = &alertconfig.HTTPClientConfig{
-						ProxyURL: *url,
-					}
-				}
+// relayTemplated posts the alerts of notifiers with a message template to the relay, which renders
+// the template, and builds the receiver of the others with build
+func relayTemplated(build receiverBuilder) receiverBuilder {
+	return func(d *ConfigSyncer, receiver *alertconfig.Receiver, notifier *v3.Notifier, recipient string) error {
+		if notifier.Spec.MessageTemplate != nil {
+			return addRelay(d, receiver, notifier, recipient)
+		}
+		return build(d, receiver, notifier, recipient)
+	}
+}
 
-				receiver.WechatConfigs = append(receiver.WechatConfigs, wechat)
-				receiverExist = true
+func notifierConfig(notifier *v3.Notifier) alertconfig.NotifierConfig {
+	return alertconfig.NotifierConfig{
+		VSendResolved: notifier.Spec.SendResolved,
+	}
+}
 
-			} else if notifier.Spec.DingtalkConfig != nil {
-				webhookURL := webhookReceiverURL + r.NotifierName
-				dingtalk := &alertconfig.WebhookConfig{
-					NotifierConfig: commonNotifierConfig,
-					URL:            webhookURL,
-				}
+// httpConfig returns the Alertmanager HTTP config with the proxy of the notifier, or nil if the
+// notifier has none
+func httpConfig(cfg *v32.HTTPClientConfig) (*alertconfig.HTTPClientConfig, error) {
+	if !notifierutil.IsHTTPClientConfigSet(cfg) {
+		return nil, nil
+	}
+	url, err := toAlertManagerURL(cfg.ProxyURL)
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse proxy url %s", cfg.ProxyURL)
+	}
+	return &alertconfig.HTTPClientConfig{
+		ProxyURL: *url,
+	}, nil
+}
 
-				receiver.WebhookConfigs = append(receiver.WebhookConfigs, dingtalk)
-				receiverExist = true
+func addPagerduty(d *ConfigSyncer, receiver *alertconfig.Receiver, notifier *v3.Notifier, recipient string) error {
+	message := notifierutil.MessageTemplateFor(&notifier.Spec)
+	pagerduty := &alertconfig.PagerdutyConfig{
+		NotifierConfig: notifierConfig(notifier),
+		ServiceKey:     alertconfig.Secret(notifier.Spec.PagerdutyConfig.ServiceKey),
+		Description:    message.Title,
+	}
+	if recipient != "" {
+		pagerduty.ServiceKey = alertconfig.Secret(recipient)
+	}
 
-			} else if notifier.Spec.MSTeamsConfig != nil {
-				webhookURL := webhookReceiverURL + r.NotifierName
-				msTeams := &alertconfig.WebhookConfig{
-					NotifierConfig: commonNotifierConfig,
-					URL:            webhookURL,
-				}
+	httpConfig, err := httpConfig(notifier.Spec.PagerdutyConfig.HTTPClientConfig)
+	if err != nil {
+		return err
+	}
+	pagerduty.HTTPConfig = httpConfig
 
-				receiver.WebhookConfigs = append(receiver.WebhookConfigs, msTeams)
-				receiverExist = true
+	receiver.PagerdutyConfigs = append(receiver.PagerdutyConfigs, pagerduty)
+	return nil
+}
 
-			} else if notifier.Spec.WebhookConfig != nil {
-				webhook := &alertconfig.WebhookConfig{
-					NotifierConfig: commonNotifierConfig,
-					URL:            notifier.Spec.WebhookConfig.URL,
-				}
-				if r.Recipient != "" {
-					webhook.URL = r.Recipient
-				}
+func addWechat(d *ConfigSyncer, receiver *alertconfig.Receiver, notifier *v3.Notifier, recipient string) error {
+	message := notifierutil.MessageTemplateFor(&notifier.Spec)
+	wechat := &alertconfig.WechatConfig{
+		NotifierConfig: notifierConfig(notifier),
+		APISecret:      alertconfig.Secret(notifier.Spec.WechatConfig.Secret),
+		AgentID:        notifier.Spec.WechatConfig.Agent,
+		CorpID:         notifier.Spec.WechatConfig.Corp,
+		Message:        message.Text,
+	}
 
-				if notifierutil.IsHTTPClientConfigSet(notifier.Spec.WebhookConfig.HTTPClientConfig) {
-					url, err := toAlertManagerURL(notifier.Spec.WebhookConfig.HTTPClientConfig.ProxyURL)
-					if err != nil {
-						logrus.Errorf("Failed to parse webhook proxy url %s, %v", notifier.Spec.WebhookConfig.HTTPClientConfig.ProxyURL, err)
-						continue
-					}
-					webhook.HTTPConfig = &alertconfig.HTTPClientConfig{
-						ProxyURL: *url,
-					}
-				}
+	if recipient == "" {
+		recipient = notifier.Spec.WechatConfig.DefaultRecipient
+	}
+	switch notifier.Spec.WechatConfig.RecipientType {
+	case "tag":
+		wechat.ToTag = recipient
+	case "user":
+		wechat.ToUser = recipient
+	default:
+		wechat.ToParty = recipient
+	}
 
-				receiver.WebhookConfigs = append(receiver.WebhookConfigs, webhook)
-				receiverExist = true
-			} else if notifier.Spec.SlackConfig != nil {
-				slack := &alertconfig.SlackConfig{
-					NotifierConfig: commonNotifierConfig,
-					APIURL:         alertconfig.Secret(notifier.Spec.SlackConfig.URL),
-					Channel:        notifier.Spec.SlackConfig.DefaultRecipient,
-					Text:           `{{ template "slack.text" . }}`,
-					Title:          `{{ template "rancher.title" . }}`,
-					TitleLink:      "",
-					Color:          `{{ if eq (index .Alerts 0).Labels.severity "critical" }}danger{{ else if eq (index .Alerts 0).Labels.severity "warning" }}warning{{ else }}good{{ end }}`,
-				}
-				if r.Recipient != "" {
-					slack.Channel = r.Recipient
-				}
+	httpConfig, err := httpConfig(notifier.Spec.WechatConfig.HTTPClientConfig)
+	if err != nil {
+		return err
+	}
+	wechat.HTTPConfig = httpConfig
 
-				if notifierutil.IsHTTPClientConfigSet(notifier.Spec.SlackConfig.HTTPClientConfig) {
-					url, err := toAlertManagerURL(notifier.Spec.SlackConfig.HTTPClientConfig.ProxyURL)
-					if err != nil {
-						logrus.Errorf("Failed to parse slack proxy url %s, %v", notifier.Spec.SlackConfig.HTTPClientConfig.ProxyURL, err)
-						continue
-					}
-					slack.HTTPConfig = &alertconfig.HTTPClientConfig{
-						ProxyURL: *url,
-					}
-				}
-				receiver.SlackConfigs = append(receiver.SlackConfigs, slack)
-				receiverExist = true
-
-			} else if notifier.Spec.SMTPConfig != nil {
-				header := map[string]string{}
-				header["Subject"] = `{{ template "rancher.title" . }}`
-				email := &alertconfig.EmailConfig{
-					NotifierConfig: commonNotifierConfig,
-					Smarthost:      notifier.Spec.SMTPConfig.Host + ":" + strconv.Itoa(notifier.Spec.SMTPConfig.Port),
-					AuthPassword:   alertconfig.Secret(notifier.Spec.SMTPConfig.Password),
-					AuthUsername:   notifier.Spec.SMTPConfig.Username,
-					RequireTLS:     notifier.Spec.SMTPConfig.TLS,
-					To:             notifier.Spec.SMTPConfig.DefaultRecipient,
-					Headers:        header,
-					From:           notifier.Spec.SMTPConfig.Sender,
-					HTML:           `{{ template "email.text" . }}`,
-				}
-				if r.Recipient != "" {
-					email.To = r.Recipient
-				}
-				receiver.EmailConfigs = append(receiver.EmailConfigs, email)
-				receiverExist = true
-			}
+	receiver.WechatConfigs = append(receiver.WechatConfigs, wechat)
+	return nil
+}
 
-		}
+// addWebhookReceiver posts the alerts to the webhook receiver, which converts them for the notifier
+func addWebhookReceiver(d *ConfigSyncer, receiver *alertconfig.Receiver, notifier *v3.Notifier, recipient string) error {
+	receiver.WebhookConfigs = append(receiver.WebhookConfigs, &alertconfig.WebhookConfig{
+		NotifierConfig: notifierConfig(notifier),
+		URL:            webhookReceiverURL + d.clusterName + ":" + notifier.Name,
+	})
+	return nil
+}
+
+func addWebhook(d *ConfigSyncer, receiver *alertconfig.Receiver, notifier *v3.Notifier, recipient string) error {
+	webhook := &alertconfig.WebhookConfig{
+		NotifierConfig: notifierConfig(notifier),
+		URL:            notifier.Spec.WebhookConfig.URL,
+	}
+	if recipient != "" {
+		webhook.URL = recipient
 	}
 
-	return receiverExist
+	httpConfig, err := httpConfig(notifier.Spec.WebhookConfig.HTTPClientConfig)
+	if err != nil {
+		return err
+	}
+	webhook.HTTPConfig = httpConfig
+
+	receiver.WebhookConfigs = append(receiver.WebhookConfigs, webhook)
+	return nil
+}
+
+func addSlack(d *ConfigSyncer, receiver *alertconfig.Receiver, notifier *v3.Notifier, recipient string) error {
+	message := notifierutil.MessageTemplateFor(&notifier.Spec)
+	slack := &alertconfig.SlackConfig{
+		NotifierConfig: notifierConfig(notifier),
+		APIURL:         alertconfig.Secret(notifier.Spec.SlackConfig.URL),
+		Channel:        notifier.Spec.SlackConfig.DefaultRecipient,
+		Text:           message.Text,
+		Title:          message.Title,
+		TitleLink:      `{{ template "rancher.url" . }}`,
+		Color:          `{{ if eq (index .Alerts 0).Labels.severity "critical" }}danger{{ else if eq (index .Alerts 0).Labels.severity "warning" }}warning{{ else }}good{{ end }}`,
+	}
+	if recipient != "" {
+		slack.Channel = recipient
+	}
+
+	httpConfig, err := httpConfig(notifier.Spec.SlackConfig.HTTPClientConfig)
+	if err != nil {
+		return err
+	}
+	slack.HTTPConfig = httpConfig
 
+	receiver.SlackConfigs = append(receiver.SlackConfigs, slack)
+	return nil
+}
+
+func addEmail(d *ConfigSyncer, receiver *alertconfig.Receiver, notifier *v3.Notifier, recipient string) error {
+	message := notifierutil.MessageTemplateFor(&notifier.Spec)
+	email := &alertconfig.EmailConfig{
+		NotifierConfig: notifierConfig(notifier),
+		Smarthost:      notifier.Spec.SMTPConfig.Host + ":" + strconv.Itoa(notifier.Spec.SMTPConfig.Port),
+		AuthPassword:   alertconfig.Secret(notifier.Spec.SMTPConfig.Password),
+		AuthUsername:   notifier.Spec.SMTPConfig.Username,
+		RequireTLS:     notifier.Spec.SMTPConfig.TLS,
+		To:             notifier.Spec.SMTPConfig.DefaultRecipient,
+		Headers:        map[string]string{"Subject": message.Title},
+		From:           notifier.Spec.SMTPConfig.Sender,
+		HTML:           message.Text,
+	}
+	if recipient != "" {
+		email.To = recipient
+	}
+
+	receiver.EmailConfigs = append(receiver.EmailConfigs, email)
+	return nil
+}
+
+func addOpsgenie(d *ConfigSyncer, receiver *alertconfig.Receiver, notifier *v3.Notifier, recipient string) error {
+	message := notifierutil.MessageTemplateFor(&notifier.Spec)
+	opsgenie := &alertconfig.OpsGenieConfig{
+		NotifierConfig: notifierConfig(notifier),
+		APIKey:         alertconfig.Secret(notifier.Spec.OpsgenieConfig.APIKey),
+		APIHost:        notifierutil.DefaultOpsgenieAPIURL + "/",
+		Message:        message.Title,
+		Description:    message.Text,
+		Source:         "Rancher",
+		Teams:          notifier.Spec.OpsgenieConfig.DefaultRecipient,
+	}
+	if recipient != "" {
+		opsgenie.Teams = recipient
+	}
+	if apiURL := notifier.Spec.OpsgenieConfig.APIURL; apiURL != "" {
+		opsgenie.APIHost = strings.TrimSuffix(apiURL, "/") + "/"
+	}
+
+	httpConfig, err := httpConfig(notifier.Spec.OpsgenieConfig.HTTPClientConfig)
+	if err != nil {
+		return err
+	}
+	opsgenie.HTTPConfig = httpConfig
+
+	receiver.OpsGenieConfigs = append(receiver.OpsGenieConfigs, opsgenie)
+	return nil
+}
+
+// addRelay posts the alerts to the relay of Rancher, which renders the message of the notifier
+// and sends it. Alertmanager authenticates with the token in the relay secret of the notifier.
+func addRelay(d *ConfigSyncer, receiver *alertconfig.Receiver, notifier *v3.Notifier, recipient string) error {
+	serverURL := settings.ServerURL.Get()
+	if serverURL == "" {
+		return errors.New("server-url setting is not set")
+	}
+	token, err := d.relayTokens.token(notifier)
+	if err != nil {
+		return err
+	}
+
+	webhook := &alertconfig.WebhookConfig{
+		NotifierConfig: notifierConfig(notifier),
+		URL:            notifierutil.RelayURL(serverURL, d.clusterName, notifier.Name, recipient),
+		HTTPConfig: &alertconfig.HTTPClientConfig{
+			BearerToken: alertconfig.Secret(token),
+		},
+	}
+	if settings.CACerts.Get() != "" {
+		webhook.HTTPConfig.TLSConfig.CAFile = relayCAFile
+	}
+
+	receiver.WebhookConfigs = append(receiver.WebhookConfigs, webhook)
+	return nil
 }
 
 func (d *ConfigSyncer) isAppDeploy(appNamespace string) (bool, bool, error) {
@@ -0,0 +1,71 @@
+package configsyncer
+
+import (
+	v1 "github.com/rancher/rancher/pkg/generated/norman/core/v1"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	notifierutil "github.com/rancher/rancher/pkg/notifiers"
+	"github.com/rancher/wrangler/pkg/randomtoken"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// relayCAKey is the key of the CA of Rancher in the Alertmanager config secret, it is mounted
+	// at relayCAFile so Alertmanager trusts the relay
+	relayCAKey  = "rancher-cacerts.pem"
+	relayCAFile = "/etc/alertmanager/config/" + relayCAKey
+)
+
+type relayTokenGetter interface {
+	token(notifier *v3.Notifier) (string, error)
+}
+
+// relayTokens creates the tokens Alertmanager authenticates to the relay of notifiers with, they
+// are stored in the cluster namespace and removed with the notifier
+type relayTokens struct {
+	secrets      v1.SecretsGetter
+	secretLister v1.SecretLister
+}
+
+func (r *relayTokens) token(notifier *v3.Notifier) (string, error) {
+	name := notifierutil.RelaySecretName(notifier.Name)
+	secret, err := r.secretLister.Get(notifier.Namespace, name)
+	if err == nil {
+		return string(secret.Data[notifierutil.RelayTokenKey]), nil
+	} else if !apierrors.IsNotFound(err) {
+		return "", err
+	}
+
+	token, err := randomtoken.Generate()
+	if err != nil {
+		return "", err
+	}
+	secret, err = r.secrets.Secrets(notifier.Namespace).Create(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: notifier.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: "management.cattle.io/v3",
+					Kind:       "Notifier",
+					Name:       notifier.Name,
+					UID:        notifier.UID,
+				},
+			},
+		},
+		StringData: map[string]string{
+			notifierutil.RelayTokenKey: token,
+		},
+	})
+	if apierrors.IsAlreadyExists(err) {
+		secret, err = r.secrets.Secrets(notifier.Namespace).Get(name, metav1.GetOptions{})
+		if err != nil {
+			return "", err
+		}
+		return string(secret.Data[notifierutil.RelayTokenKey]), nil
+	} else if err != nil {
+		return "", err
+	}
+	return token, nil
+}
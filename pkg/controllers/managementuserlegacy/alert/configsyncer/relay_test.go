@@ -0,0 +1,144 @@
+package configsyncer
+
+import (
+	"testing"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	alertconfig "github.com/rancher/rancher/pkg/controllers/managementuserlegacy/alert/config"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	notifierutil "github.com/rancher/rancher/pkg/notifiers"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type fakeRelayTokens map[string]string
+
+func (f fakeRelayTokens) token(notifier *v3.Notifier) (string, error) {
+	return f[notifier.Name], nil
+}
+
+func TestReceiverBuildersCoverNotifierTypes(t *testing.T) {
+	for _, typeName := range notifierutil.Types() {
+		assert.Contains(t, receiverBuilders, typeName, "notifier type %s can't receive alerts", typeName)
+	}
+}
+
+func TestAddRecipientsRelay(t *testing.T) {
+	serverURL := settings.ServerURL.Get()
+	defer settings.ServerURL.Set(serverURL)
+	require.NoError(t, settings.ServerURL.Set("https://rancher.example.com"))
+
+	relayNotifiers := []*v3.Notifier{
+		newNotifier("googlechat", v32.NotifierSpec{GoogleChatConfig: &v32.GoogleChatConfig{URL: "https://chat.googleapis.com/v1/spaces/x"}}),
+		newNotifier("telegram", v32.NotifierSpec{TelegramConfig: &v32.TelegramConfig{BotToken: "bot", DefaultRecipient: "-100"}}),
+		newNotifier("matrix", v32.NotifierSpec{MatrixConfig: &v32.MatrixConfig{HomeserverURL: "https://matrix.org", AccessToken: "at", DefaultRecipient: "!room:matrix.org"}}),
+	}
+	d := &ConfigSyncer{
+		clusterName: clusterName,
+		relayTokens: fakeRelayTokens{"googlechat": "t1", "telegram": "t2", "matrix": "t3"},
+	}
+
+	receiver := &alertconfig.Receiver{Name: "group"}
+	exist := d.addRecipients(relayNotifiers, receiver, []v32.Recipient{
+		{NotifierName: clusterName + ":googlechat"},
+		{NotifierName: clusterName + ":telegram", Recipient: "-200"},
+		{NotifierName: clusterName + ":matrix"},
+	})
+
+	assert.True(t, exist)
+	require.Len(t, receiver.WebhookConfigs, 3)
+	assert.Equal(t, "https://rancher.example.com/v3/alertrelay/testCluster/googlechat", receiver.WebhookConfigs[0].URL)
+	assert.Equal(t, alertconfig.Secret("t1"), receiver.WebhookConfigs[0].HTTPConfig.BearerToken)
+	assert.Equal(t, "https://rancher.example.com/v3/alertrelay/testCluster/telegram?recipient=-200", receiver.WebhookConfigs[1].URL)
+	assert.Equal(t, alertconfig.Secret("t2"), receiver.WebhookConfigs[1].HTTPConfig.BearerToken)
+	assert.Equal(t, "https://rancher.example.com/v3/alertrelay/testCluster/matrix", receiver.WebhookConfigs[2].URL)
+}
+
+func TestAddRecipientsRelayWithoutServerURL(t *testing.T) {
+	serverURL := settings.ServerURL.Get()
+	defer settings.ServerURL.Set(serverURL)
+	require.NoError(t, settings.ServerURL.Set(""))
+
+	d := &ConfigSyncer{
+		clusterName: clusterName,
+		relayTokens: fakeRelayTokens{},
+	}
+	receiver := &alertconfig.Receiver{Name: "group"}
+	exist := d.addRecipients([]*v3.Notifier{
+		newNotifier("googlechat", v32.NotifierSpec{GoogleChatConfig: &v32.GoogleChatConfig{URL: "https://chat.googleapis.com/v1/spaces/x"}}),
+	}, receiver, []v32.Recipient{{NotifierName: clusterName + ":googlechat"}})
+
+	assert.False(t, exist)
+	assert.Empty(t, receiver.WebhookConfigs)
+}
+
+func TestAddRecipientsOpsgenie(t *testing.T) {
+	d := &ConfigSyncer{
+		clusterName: clusterName,
+	}
+	opsgenieNotifiers := []*v3.Notifier{
+		newNotifier("opsgenie", v32.NotifierSpec{OpsgenieConfig: &v32.OpsgenieConfig{APIKey: "key", DefaultRecipient: "ops"}}),
+		newNotifier("opsgenie-eu", v32.NotifierSpec{OpsgenieConfig: &v32.OpsgenieConfig{APIKey: "key", APIURL: "https://api.eu.opsgenie.com"}}),
+	}
+
+	receiver := &alertconfig.Receiver{Name: "group"}
+	exist := d.addRecipients(opsgenieNotifiers, receiver, []v32.Recipient{
+		{NotifierName: clusterName + ":opsgenie"},
+		{NotifierName: clusterName + ":opsgenie-eu", Recipient: "sre"},
+	})
+
+	assert.True(t, exist)
+	require.Len(t, receiver.OpsGenieConfigs, 2)
+	assert.Equal(t, alertconfig.Secret("key"), receiver.OpsGenieConfigs[0].APIKey)
+	assert.Equal(t, "https://api.opsgenie.com/", receiver.OpsGenieConfigs[0].APIHost)
+	assert.Equal(t, "ops", receiver.OpsGenieConfigs[0].Teams)
+	assert.NotEmpty(t, receiver.OpsGenieConfigs[0].Message)
+	assert.Equal(t, "https://api.eu.opsgenie.com/", receiver.OpsGenieConfigs[1].APIHost)
+	assert.Equal(t, "sre", receiver.OpsGenieConfigs[1].Teams)
+}
+
+func TestAddRecipientsRelayTemplated(t *testing.T) {
+	serverURL := settings.ServerURL.Get()
+	defer settings.ServerURL.Set(serverURL)
+	require.NoError(t, settings.ServerURL.Set("https://rancher.example.com"))
+
+	template := &v32.MessageTemplate{Text: `{{ .CommonLabels.alert_name }}`}
+	relayNotifiers := []*v3.Notifier{
+		newNotifier("webhook", v32.NotifierSpec{WebhookConfig: &v32.WebhookConfig{URL: "https://hooks.example.com"}}),
+		newNotifier("webhook-templated", v32.NotifierSpec{WebhookConfig: &v32.WebhookConfig{URL: "https://hooks.example.com"}, MessageTemplate: template}),
+		newNotifier("dingtalk-templated", v32.NotifierSpec{DingtalkConfig: &v32.DingtalkConfig{URL: "https://oapi.dingtalk.com"}, MessageTemplate: template}),
+		newNotifier("msteams", v32.NotifierSpec{MSTeamsConfig: &v32.MSTeamsConfig{URL: "https://outlook.office.com"}}),
+	}
+	d := &ConfigSyncer{
+		clusterName: clusterName,
+		relayTokens: fakeRelayTokens{"webhook-templated": "t1", "dingtalk-templated": "t2"},
+	}
+
+	receiver := &alertconfig.Receiver{Name: "group"}
+	exist := d.addRecipients(relayNotifiers, receiver, []v32.Recipient{
+		{NotifierName: clusterName + ":webhook"},
+		{NotifierName: clusterName + ":webhook-templated"},
+		{NotifierName: clusterName + ":dingtalk-templated"},
+		{NotifierName: clusterName + ":msteams"},
+	})
+
+	assert.True(t, exist)
+	require.Len(t, receiver.WebhookConfigs, 4)
+	assert.Equal(t, "https://hooks.example.com", receiver.WebhookConfigs[0].URL)
+	assert.Equal(t, "https://rancher.example.com/v3/alertrelay/testCluster/webhook-templated", receiver.WebhookConfigs[1].URL)
+	assert.Equal(t, alertconfig.Secret("t1"), receiver.WebhookConfigs[1].HTTPConfig.BearerToken)
+	assert.Equal(t, "https://rancher.example.com/v3/alertrelay/testCluster/dingtalk-templated", receiver.WebhookConfigs[2].URL)
+	assert.Equal(t, webhookReceiverURL+"testCluster:msteams", receiver.WebhookConfigs[3].URL)
+}
+
+func newNotifier(name string, spec v32.NotifierSpec) *v3.Notifier {
+	return &v3.Notifier{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: clusterName,
+		},
+		Spec: spec,
+	}
+}
@@ -19,8 +19,10 @@
 	projectv3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
 	monitorutil "github.com/rancher/rancher/pkg/monitoring"
 	"github.com/rancher/rancher/pkg/namespace"
+	"github.com/rancher/rancher/pkg/notifiers"
 	projectutil "github.com/rancher/rancher/pkg/project"
 	"github.com/rancher/rancher/pkg/ref"
+	"github.com/rancher/rancher/pkg/settings"
 	"github.com/rancher/rancher/pkg/systemaccount"
 	"github.com/rancher/rancher/pkg/types/config"
 
@@ -274,7 +276,7 @@ func (d *appDeployer) cleanup(appName, appTargetNamespace, systemProjectID strin
 	return false, nil
 }
 
-func (d *appDeployer) getSecret(secretName, secretNamespace string) *corev1.Secret {
+func (d *appDeployer) getSecret(secretName, secretNamespace, clusterName string) *corev1.Secret {
 	cfg := manager.GetAlertManagerDefaultConfig()
 	data, err := yaml.Marshal(cfg)
 	if err != nil {
@@ -288,7 +290,7 @@ func (d *appDeployer) getSecret(secretName, secretNamespace string) *corev1.Secr
 		},
 		Data: map[string][]byte{
 			"alertmanager.yaml": data,
-			"notification.tmpl": []byte(NotificationTmpl),
+			"notification.tmpl": []byte(notifiers.Templates(settings.ServerURL.Get(), clusterName)),
 		},
 	}
 }
@@ -307,7 +309,7 @@ func (d *appDeployer) deploy(appName, appTargetNamespace, systemProjectID string
 	}
 
 	secretName := alertutil.GetAlertManagerSecretName(appName)
-	secret := d.getSecret(secretName, appTargetNamespace)
+	secret := d.getSecret(secretName, appTargetNamespace, clusterName)
 	if _, err := d.secrets.Create(secret); err != nil && !apierrors.IsAlreadyExists(err) {
 		return false, fmt.Errorf("create secret %s:%s failed, %v", appTargetNamespace, appName, err)
 	}
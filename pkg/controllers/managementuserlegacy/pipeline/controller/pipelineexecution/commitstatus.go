@@ -0,0 +1,86 @@
+package pipelineexecution
+
+import (
+	"fmt"
+	"strings"
+
+	v3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/pipeline/providers"
+	"github.com/rancher/rancher/pkg/pipeline/remote"
+	"github.com/rancher/rancher/pkg/pipeline/remote/model"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	"github.com/rancher/rancher/pkg/ref"
+	"github.com/sirupsen/logrus"
+)
+
+const commitStatusContext = "rancher/pipeline"
+
+// reportCommitStatus reports the state of the execution on the built commit when the source code
+// provider supports commit statuses. Each state is reported once, failures are only logged so they
+// don't block the execution.
+func (l *Lifecycle) reportCommitStatus(obj *v3.PipelineExecution) {
+	state := commitState(obj.Status.ExecutionState)
+	if state == "" || obj.Spec.Commit == "" || obj.Annotations[utils.CommitStatusLabel] == obj.Status.ExecutionState {
+		return
+	}
+	if err := l.setCommitStatus(obj, state); err != nil {
+		logrus.Warnf("failed to report commit status of pipeline execution %s: %v", obj.Name, err)
+	}
+	if obj.Annotations == nil {
+		obj.Annotations = map[string]string{}
+	}
+	obj.Annotations[utils.CommitStatusLabel] = obj.Status.ExecutionState
+}
+
+func (l *Lifecycle) setCommitStatus(obj *v3.PipelineExecution, state string) error {
+	ns, name := ref.Parse(obj.Spec.PipelineName)
+	pipeline, err := l.pipelineLister.Get(ns, name)
+	if err != nil {
+		return err
+	}
+	if pipeline.Spec.SourceCodeCredentialName == "" {
+		return nil
+	}
+	ns, name = ref.Parse(pipeline.Spec.SourceCodeCredentialName)
+	credential, err := l.sourceCodeCredentialLister.Get(ns, name)
+	if err != nil {
+		return err
+	}
+	_, projID := ref.Parse(obj.Spec.ProjectName)
+	scpConfig, err := providers.GetSourceCodeProviderConfig(credential.Spec.SourceCodeType, projID)
+	if err != nil {
+		return err
+	}
+	r, err := remote.New(scpConfig)
+	if err != nil {
+		return err
+	}
+	reporter, ok := r.(model.StatusReporter)
+	if !ok {
+		return nil
+	}
+	accessToken, err := utils.EnsureAccessToken(l.sourceCodeCredentials, r, credential)
+	if err != nil {
+		return err
+	}
+	return reporter.SetCommitStatus(obj.Spec.RepositoryURL, obj.Spec.Commit, &model.CommitStatus{
+		State:       state,
+		TargetURL:   executionURL(obj),
+		Description: fmt.Sprintf("Pipeline execution #%d is %s", obj.Spec.Run, strings.ToLower(obj.Status.ExecutionState)),
+		Context:     commitStatusContext,
+	}, accessToken)
+}
+
+func commitState(executionState string) string {
+	switch executionState {
+	case utils.StateWaiting, utils.StateQueueing, utils.StatePending, utils.StateBuilding:
+		return model.CommitStatePending
+	case utils.StateSuccess:
+		return model.CommitStateSuccess
+	case utils.StateFailed:
+		return model.CommitStateFailure
+	case utils.StateAborted, utils.StateDenied:
+		return model.CommitStateError
+	}
+	return ""
+}
@@ -16,6 +16,7 @@
 	"github.com/rancher/rancher/pkg/controllers/managementuser/resourcequota"
 	images "github.com/rancher/rancher/pkg/image"
 	namespaceutil "github.com/rancher/rancher/pkg/namespace"
+	"github.com/rancher/rancher/pkg/pipeline/engine"
 	"github.com/rancher/rancher/pkg/pipeline/utils"
 	"github.com/rancher/rancher/pkg/ref"
 	"github.com/rancher/rke/pki"
@@ -42,6 +43,9 @@ func (l *Lifecycle) deploy(projectName string) error {
 	clusterID, projectID := ref.Parse(projectName)
 	ns := getPipelineNamespace(clusterID, projectID)
 	if _, err := l.namespaceLister.Get("", ns.Name); err == nil {
+		if err := l.deployJenkins(projectName); err != nil {
+			return err
+		}
 		return l.reconcileRb(projectName)
 	} else if !apierrors.IsNotFound(err) {
 		return err
@@ -124,13 +128,8 @@ func (l *Lifecycle) deploy(projectName string) error {
 	if _, err := l.networkPolicies.Create(np); err != nil && !apierrors.IsAlreadyExists(err) {
 		return errors.Wrapf(err, "Error create a pipeline networkpolicy")
 	}
-	jenkinsService := getJenkinsService(nsName)
-	if _, err := l.services.Create(jenkinsService); err != nil && !apierrors.IsAlreadyExists(err) {
-		return errors.Wrapf(err, "Error creating the jenkins service")
-	}
-	jenkinsDeployment := GetJenkinsDeployment(nsName)
-	if _, err := l.deployments.Create(jenkinsDeployment); err != nil && !apierrors.IsAlreadyExists(err) {
-		return errors.Wrapf(err, "Error creating the jenkins deployment")
+	if err := l.deployJenkins(projectName); err != nil {
+		return err
 	}
 	registryService := getRegistryService(nsName)
 	if _, err := l.services.Create(registryService); err != nil && !apierrors.IsAlreadyExists(err) {
@@ -164,6 +163,24 @@ func (l *Lifecycle) deploy(projectName string) error {
 	return l.reconcileRb(projectName)
 }
 
+// deployJenkins deploys Jenkins in the pipeline namespace if the project runs pipelines with the
+// Jenkins engine, it is deployed when a project switches to Jenkins
+func (l *Lifecycle) deployJenkins(projectName string) error {
+	if engine.Name(l.pipelineSettingLister, projectName) != utils.EngineJenkins {
+		return nil
+	}
+	nsName := utils.GetPipelineCommonName(projectName)
+	jenkinsService := getJenkinsService(nsName)
+	if _, err := l.services.Create(jenkinsService); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "Error creating the jenkins service")
+	}
+	jenkinsDeployment := GetJenkinsDeployment(nsName)
+	if _, err := l.deployments.Create(jenkinsDeployment); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrapf(err, "Error creating the jenkins deployment")
+	}
+	return nil
+}
+
 func (l *Lifecycle) waitResourceQuotaInitCondition(namespace string) error {
 	tries := 0
 	for tries <= 3 {
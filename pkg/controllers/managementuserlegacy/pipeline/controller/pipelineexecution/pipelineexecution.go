@@ -97,6 +97,7 @@ type Lifecycle struct {
 	pipelineExecutions         v3.PipelineExecutionInterface
 	pipelineSettingLister      v3.PipelineSettingLister
 	pipelineEngine             engine.PipelineEngine
+	sourceCodeCredentials      v3.SourceCodeCredentialInterface
 	sourceCodeCredentialLister v3.SourceCodeCredentialLister
 
 	DialerFactory dialer.Factory
@@ -129,7 +130,8 @@ func Register(ctx context.Context, cluster *config.UserContext) {
 	pipelineExecutions := cluster.Management.Project.PipelineExecutions("")
 	pipelineExecutionLister := pipelineExecutions.Controller().Lister()
 	pipelineSettingLister := cluster.Management.Project.PipelineSettings("").Controller().Lister()
-	sourceCodeCredentialLister := cluster.Management.Project.SourceCodeCredentials("").Controller().Lister()
+	sourceCodeCredentials := cluster.Management.Project.SourceCodeCredentials("")
+	sourceCodeCredentialLister := sourceCodeCredentials.Controller().Lister()
 	notifierLister := cluster.Management.Management.Notifiers("").Controller().Lister()
 	tokenLister := cluster.Management.Management.Tokens("").Controller().Lister()
 
@@ -159,6 +161,7 @@ func Register(ctx context.Context, cluster *config.UserContext) {
 		pipelineExecutions:         pipelineExecutions,
 		pipelineSettingLister:      pipelineSettingLister,
 		pipelineEngine:             pipelineEngine,
+		sourceCodeCredentials:      sourceCodeCredentials,
 		sourceCodeCredentialLister: sourceCodeCredentialLister,
 		notifierLister:             notifierLister,
 		tokenLister:                tokenLister,
@@ -205,6 +208,8 @@ func (l *Lifecycle) Sync(obj *v3.PipelineExecution) (runtime.Object, error) {
 		return obj, nil
 	}
 
+	l.reportCommitStatus(obj)
+
 	//doIfAbort
 	if obj.Status.ExecutionState == utils.StateAborted {
 		if err := l.doStop(obj); err != nil {
@@ -597,12 +602,7 @@ func defaultNotificationMessage(execution *v3.PipelineExecution) (string, error)
 	} else {
 		logrus.Warnf("cannot parse duration of pipeline execution %s: %v,%v", execution.Name, err1, err2)
 	}
-	buildLink := fmt.Sprintf("%s/p/%s/pipeline/pipelines/%s/run/%d",
-		settings.ServerURL.Get(),
-		execution.Spec.ProjectName,
-		execution.Spec.PipelineName,
-		execution.Spec.Run,
-	)
+	buildLink := executionURL(execution)
 	builtMessage := "Success"
 	if v32.PipelineExecutionConditionBuilt.IsFalse(execution) {
 		builtMessage = v32.PipelineExecutionConditionBuilt.GetMessage(execution)
@@ -630,6 +630,15 @@ func defaultNotificationMessage(execution *v3.PipelineExecution) (string, error)
 	return buf.String(), nil
 }
 
+func executionURL(execution *v3.PipelineExecution) string {
+	return fmt.Sprintf("%s/p/%s/pipeline/pipelines/%s/run/%d",
+		settings.ServerURL.Get(),
+		execution.Spec.ProjectName,
+		execution.Spec.PipelineName,
+		execution.Spec.Run,
+	)
+}
+
 func getRepoNameFromURL(repoURL string) string {
 	reg := regexp.MustCompile(".*/([^/]*?)/([^/]*?).git")
 	match := reg.FindStringSubmatch(repoURL)
@@ -30,6 +30,10 @@
 	utils.SettingExecutorMemoryLimit:   utils.SettingExecutorMemoryLimitDefault,
 	utils.SettingExecutorCPURequest:    utils.SettingExecutorCPURequestDefault,
 	utils.SettingExecutorCPULimit:      utils.SettingExecutorCPULimitDefault,
+	utils.SettingEngine:                utils.SettingEngineDefault,
+	utils.SettingWorkspaceSize:         utils.SettingWorkspaceSizeDefault,
+	utils.SettingCacheSizeLimit:        utils.SettingCacheSizeLimitDefault,
+	utils.SettingArtifactSizeLimit:     utils.SettingArtifactSizeLimitDefault,
 }
 
 func Register(ctx context.Context, cluster *config.UserContext) {
@@ -83,6 +87,8 @@ func (l *Syncer) addSourceCodeProviderConfigs(obj *v3.Project) error {
 		model.GitlabType:          pclient.GitlabPipelineConfigType,
 		model.BitbucketCloudType:  pclient.BitbucketCloudPipelineConfigType,
 		model.BitbucketServerType: pclient.BitbucketServerPipelineConfigType,
+		model.GiteaType:           pclient.GiteaPipelineConfigType,
+		model.AzureDevOpsType:     pclient.AzureDevOpsPipelineConfigType,
 	}
 	for name, pType := range supportedProviders {
 		if err := l.addSourceCodeProviderConfig(name, pType, false, obj); err != nil {
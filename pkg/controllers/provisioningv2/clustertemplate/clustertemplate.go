@@ -0,0 +1,180 @@
+package clustertemplate
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	v1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	rocontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	clustertemplates "github.com/rancher/rancher/pkg/provisioningv2/clustertemplate"
+	"github.com/rancher/rancher/pkg/wrangler"
+	"github.com/rancher/wrangler/pkg/condition"
+	"github.com/rancher/wrangler/pkg/relatedresource"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+const (
+	byRevision = "by-cluster-template-revision"
+
+	ClusterTemplateApplied = condition.Cond("ClusterTemplateApplied")
+)
+
+type handler struct {
+	clusters      rocontrollers.ClusterController
+	clusterCache  rocontrollers.ClusterCache
+	revisionCache rocontrollers.ClusterTemplateRevisionCache
+}
+
+func Register(ctx context.Context, clients *wrangler.Context) {
+	h := &handler{
+		clusters:      clients.Provisioning.Cluster(),
+		clusterCache:  clients.Provisioning.Cluster().Cache(),
+		revisionCache: clients.Provisioning.ClusterTemplateRevision().Cache(),
+	}
+
+	clients.Provisioning.Cluster().Cache().AddIndexer(byRevision, func(obj *v1.Cluster) ([]string, error) {
+		if obj.Spec.ClusterTemplateRevisionName == "" {
+			return nil, nil
+		}
+		return []string{obj.Namespace + "/" + obj.Spec.ClusterTemplateRevisionName}, nil
+	})
+
+	clients.Provisioning.Cluster().OnChange(ctx, "cluster-template", h.OnChange)
+	relatedresource.Watch(ctx, "cluster-template-trigger", h.revisionWatch, clients.Provisioning.Cluster(),
+		clients.Provisioning.ClusterTemplateRevision())
+}
+
+func (h *handler) revisionWatch(namespace, name string, obj runtime.Object) ([]relatedresource.Key, error) {
+	if _, ok := obj.(*v1.ClusterTemplateRevision); !ok {
+		return nil, nil
+	}
+	clusters, err := h.clusterCache.GetByIndex(byRevision, namespace+"/"+name)
+	if err != nil {
+		return nil, err
+	}
+	var result []relatedresource.Key
+	for _, cluster := range clusters {
+		result = append(result, relatedresource.Key{
+			Namespace: cluster.Namespace,
+			Name:      cluster.Name,
+		})
+	}
+	return result, nil
+}
+
+// OnChange applies the revision and answers of the spec to a cluster when they differ from the ones last
+// applied. Only the fields that differ between the rendered revisions are changed, so edits to the fields
+// the revisions don't set are kept.
+func (h *handler) OnChange(key string, cluster *v1.Cluster) (*v1.Cluster, error) {
+	if cluster == nil || cluster.DeletionTimestamp != nil {
+		return cluster, nil
+	}
+
+	if cluster.Spec.ClusterTemplateRevisionName == "" {
+		if cluster.Status.ClusterTemplateRevisionName == "" {
+			return cluster, nil
+		}
+		cluster = cluster.DeepCopy()
+		cluster.Status.ClusterTemplateRevisionName = ""
+		cluster.Status.ClusterTemplateAnswers = nil
+		return h.clusters.UpdateStatus(cluster)
+	}
+
+	if cluster.Spec.ClusterTemplateRevisionName == cluster.Status.ClusterTemplateRevisionName &&
+		equalAnswers(cluster.Spec.ClusterTemplateAnswers, cluster.Status.ClusterTemplateAnswers) {
+		return cluster, nil
+	}
+
+	spec, err := h.apply(cluster)
+	if apierrors.IsNotFound(err) || isInvalid(err) {
+		return h.setError(cluster, err)
+	} else if err != nil {
+		return cluster, err
+	}
+
+	if !reflect.DeepEqual(spec, cluster.Spec) {
+		cluster = cluster.DeepCopy()
+		cluster.Spec = spec
+		cluster, err = h.clusters.Update(cluster)
+		if err != nil {
+			return cluster, err
+		}
+	}
+
+	cluster = cluster.DeepCopy()
+	cluster.Status.ClusterTemplateRevisionName = cluster.Spec.ClusterTemplateRevisionName
+	cluster.Status.ClusterTemplateAnswers = cluster.Spec.ClusterTemplateAnswers
+	ClusterTemplateApplied.SetError(&cluster.Status, "", nil)
+	return h.clusters.UpdateStatus(cluster)
+}
+
+func (h *handler) apply(cluster *v1.Cluster) (v1.ClusterSpec, error) {
+	revision, err := h.revisionCache.Get(cluster.Namespace, cluster.Spec.ClusterTemplateRevisionName)
+	if err != nil {
+		return cluster.Spec, err
+	}
+
+	newRendered, err := clustertemplates.Render(revision, cluster.Spec.ClusterTemplateAnswers)
+	if err != nil {
+		return cluster.Spec, invalid(err)
+	}
+
+	var oldRendered map[string]interface{}
+	if cluster.Status.ClusterTemplateRevisionName != "" {
+		oldRevision, err := h.revisionCache.Get(cluster.Namespace, cluster.Status.ClusterTemplateRevisionName)
+		if apierrors.IsNotFound(err) {
+			// The applied revision is gone, apply every field of the new revision.
+			oldRevision = nil
+		} else if err != nil {
+			return cluster.Spec, err
+		}
+
+		if oldRevision != nil {
+			if oldRevision.Spec.ClusterTemplateName != revision.Spec.ClusterTemplateName {
+				return cluster.Spec, invalid(fmt.Errorf("cluster template revision [%s] is not a revision of cluster template [%s]",
+					revision.Name, oldRevision.Spec.ClusterTemplateName))
+			}
+			oldRendered, err = clustertemplates.Render(oldRevision, cluster.Status.ClusterTemplateAnswers)
+			if err != nil {
+				return cluster.Spec, invalid(err)
+			}
+		}
+	}
+
+	if revision.Name != cluster.Status.ClusterTemplateRevisionName && !clustertemplates.Enabled(revision) {
+		return cluster.Spec, invalid(fmt.Errorf("cluster template revision [%s] is disabled", revision.Name))
+	}
+
+	return clustertemplates.ApplyDiff(cluster.Spec, oldRendered, newRendered)
+}
+
+func (h *handler) setError(cluster *v1.Cluster, err error) (*v1.Cluster, error) {
+	if !ClusterTemplateApplied.IsFalse(cluster) || ClusterTemplateApplied.GetMessage(cluster) != err.Error() {
+		cluster = cluster.DeepCopy()
+		ClusterTemplateApplied.SetError(&cluster.Status, "", err)
+		return h.clusters.UpdateStatus(cluster)
+	}
+	return cluster, nil
+}
+
+func equalAnswers(a, b map[string]string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a, b)
+}
+
+type invalidError struct {
+	error
+}
+
+func invalid(err error) error {
+	return invalidError{error: err}
+}
+
+func isInvalid(err error) bool {
+	_, ok := err.(invalidError)
+	return ok
+}
@@ -4,10 +4,12 @@
 	"context"
 
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/cluster"
+	"github.com/rancher/rancher/pkg/controllers/provisioningv2/clustertemplate"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/fleetcluster"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/fleetworkspace"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/managedchart"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/rke2/bootstrap"
+	"github.com/rancher/rancher/pkg/controllers/provisioningv2/rke2/clusterautoscaler"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/rke2/dynamicschema"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/rke2/machinedrain"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/rke2/machinenodelookup"
@@ -31,6 +33,7 @@
 
 func Register(ctx context.Context, clients *wrangler.Context) error {
 	cluster.Register(ctx, clients)
+	clustertemplate.Register(ctx, clients)
 
 	if features.Fleet.Enabled() {
 		managedchart.Register(ctx, clients)
@@ -55,6 +58,9 @@ func Register(ctx context.Context, clients *wrangler.Context) error {
 		unmanaged.Register(ctx, clients)
 		rkecontrolplane.Register(ctx, clients)
 		managesystemagent.Register(ctx, clients)
+		if features.Fleet.Enabled() {
+			clusterautoscaler.Register(ctx, clients)
+		}
 		machinedrain.Register(ctx, clients)
 		machineorphan.Register(ctx, clients)
 	}
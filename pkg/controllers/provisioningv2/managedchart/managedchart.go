@@ -35,7 +35,8 @@ func Register(ctx context.Context, clients *wrangler.Context) {
 			clients.K8s.Discovery(),
 			clients.Core.ConfigMap().Cache(),
 			clients.Core.Secret().Cache(),
-			clients.Catalog.ClusterRepo().Cache()),
+			clients.Catalog.ClusterRepo().Cache(),
+			clients.Catalog.ChartPolicy().Cache()),
 		mccCache:      clients.Mgmt.ManagedChart().Cache(),
 		mccController: clients.Mgmt.ManagedChart(),
 		bundleCache:   clients.Fleet.Bundle().Cache(),
@@ -84,7 +85,8 @@ func (h *handler) OnRepoChange(key string, _ *v1.ClusterRepo) (*v1.ClusterRepo,
 }
 
 func (h *handler) OnChange(mcc *v3.ManagedChart, status v3.ManagedChartStatus) ([]runtime.Object, v3.ManagedChartStatus, error) {
-	chart, err := h.charts.Chart("", mcc.Spec.RepoName, mcc.Spec.Chart, mcc.Spec.Version)
+	// ManagedCharts are deployed by Rancher itself, ChartPolicies only restrict the charts of users
+	chart, err := h.charts.SystemChart("", mcc.Spec.RepoName, mcc.Spec.Chart, mcc.Spec.Version)
 	if err != nil {
 		return nil, status, err
 	}
@@ -0,0 +1,383 @@
+package clusterautoscaler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/rancher/fleet/pkg/apis/fleet.cattle.io/v1alpha1"
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/controllers/provisioningv2/rke2/managesystemagent"
+	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1alpha4"
+	rocontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	namespaces "github.com/rancher/rancher/pkg/namespace"
+	"github.com/rancher/rancher/pkg/provisioningv2/kubeconfig"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/rancher/pkg/wrangler"
+	"github.com/rancher/wrangler/pkg/name"
+	"github.com/rancher/wrangler/pkg/relatedresource"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+const (
+	autoscalerName           = "cluster-autoscaler"
+	managementKubeConfigName = "cluster-autoscaler-management-kubeconfig"
+	managementKubeConfigPath = "/etc/kubernetes/management"
+)
+
+type handler struct {
+	kubeconfigManager  *kubeconfig.Manager
+	machineDeployments capicontrollers.MachineDeploymentCache
+	machineSets        capicontrollers.MachineSetCache
+	machines           capicontrollers.MachineCache
+}
+
+func Register(ctx context.Context, clients *wrangler.Context) {
+	h := &handler{
+		kubeconfigManager:  kubeconfig.New(clients),
+		machineDeployments: clients.CAPI.MachineDeployment().Cache(),
+		machineSets:        clients.CAPI.MachineSet().Cache(),
+		machines:           clients.CAPI.Machine().Cache(),
+	}
+	rocontrollers.RegisterClusterGeneratingHandler(ctx, clients.Provisioning.Cluster(),
+		clients.Apply.
+			WithSetOwnerReference(false, false).
+			WithCacheTypes(clients.Fleet.Bundle(),
+				clients.Provisioning.Cluster(),
+				clients.RBAC.RoleBinding(),
+				clients.RBAC.Role()),
+		"", "manage-cluster-autoscaler", h.OnChange, nil)
+
+	// The role of the autoscaler names the machine deployments, machine sets and machines of the
+	// cluster, so it is updated when they are created
+	relatedresource.Watch(ctx, "cluster-autoscaler-trigger", func(namespace, name string, obj runtime.Object) ([]relatedresource.Key, error) {
+		if clusterName := capiClusterName(obj); clusterName != "" {
+			return []relatedresource.Key{{
+				Namespace: namespace,
+				Name:      clusterName,
+			}}, nil
+		}
+		return nil, nil
+	}, clients.Provisioning.Cluster(), clients.CAPI.MachineDeployment(), clients.CAPI.MachineSet(), clients.CAPI.Machine())
+}
+
+func capiClusterName(obj runtime.Object) string {
+	switch o := obj.(type) {
+	case *capi.MachineDeployment:
+		return o.Spec.ClusterName
+	case *capi.MachineSet:
+		return o.Spec.ClusterName
+	case *capi.Machine:
+		return o.Spec.ClusterName
+	}
+	return ""
+}
+
+// OnChange deploys cluster-autoscaler into the downstream cluster when it is enabled. The autoscaler uses
+// the Cluster API provider with a kubeconfig of the management cluster, which can only scale the machine
+// deployments and machine sets of the cluster and mark its machines for deletion.
+func (h *handler) OnChange(cluster *rancherv1.Cluster, status rancherv1.ClusterStatus) ([]runtime.Object, rancherv1.ClusterStatus, error) {
+	if cluster.Spec.RKEConfig == nil || cluster.Spec.RKEConfig.ClusterAutoscaler == nil || !cluster.Spec.RKEConfig.ClusterAutoscaler.Enabled ||
+		status.ClusterName == "" || cluster.DeletionTimestamp != nil {
+		return nil, status, nil
+	}
+
+	secret, err := h.kubeconfigManager.GetAutoscalerKubeConfig(cluster)
+	if err != nil {
+		return nil, status, err
+	}
+
+	resources, err := managesystemagent.ToResources(installer(cluster, secret.Data["value"]))
+	if err != nil {
+		return nil, status, err
+	}
+
+	rules, err := h.rules(cluster)
+	if err != nil {
+		return nil, status, err
+	}
+
+	userName := kubeconfig.GetAutoscalerUserName(cluster.Namespace, cluster.Name)
+	roleName := name.SafeConcatName(cluster.Name, autoscalerName)
+
+	return []runtime.Object{
+		&rbacv1.Role{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      roleName,
+				Namespace: cluster.Namespace,
+			},
+			Rules: rules,
+		},
+		&rbacv1.RoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      roleName,
+				Namespace: cluster.Namespace,
+			},
+			Subjects: []rbacv1.Subject{{
+				Kind:     "User",
+				APIGroup: rbacv1.GroupName,
+				Name:     userName,
+			}},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "Role",
+				Name:     roleName,
+			},
+		},
+		&v1alpha1.Bundle{
+			ObjectMeta: metav1.ObjectMeta{
+				Namespace: cluster.Namespace,
+				Name:      name.SafeConcatName(cluster.Name, "managed", autoscalerName),
+			},
+			Spec: v1alpha1.BundleSpec{
+				BundleDeploymentOptions: v1alpha1.BundleDeploymentOptions{
+					DefaultNamespace: namespaces.System,
+				},
+				Resources: resources,
+				Targets: []v1alpha1.BundleTarget{
+					{
+						ClusterName: cluster.Name,
+					},
+				},
+			},
+		},
+	}, status, nil
+}
+
+// rules allows the autoscaler to read the machines in the namespace of the cluster, but only to
+// update the ones of the cluster. The namespace is shared by the clusters of the fleet workspace.
+func (h *handler) rules(cluster *rancherv1.Cluster) ([]rbacv1.PolicyRule, error) {
+	var machineDeploymentNames, machineSetNames, machineNames []string
+
+	machineDeployments, err := h.machineDeployments.List(cluster.Namespace, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, md := range machineDeployments {
+		if md.Spec.ClusterName == cluster.Name {
+			machineDeploymentNames = append(machineDeploymentNames, md.Name)
+		}
+	}
+
+	machineSets, err := h.machineSets.List(cluster.Namespace, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, ms := range machineSets {
+		if ms.Spec.ClusterName == cluster.Name {
+			machineSetNames = append(machineSetNames, ms.Name)
+		}
+	}
+
+	machines, err := h.machines.List(cluster.Namespace, labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	for _, machine := range machines {
+		if machine.Spec.ClusterName == cluster.Name {
+			machineNames = append(machineNames, machine.Name)
+		}
+	}
+
+	rules := []rbacv1.PolicyRule{
+		{
+			Verbs:     []string{"get", "list", "watch"},
+			APIGroups: []string{"cluster.x-k8s.io"},
+			Resources: []string{"machinedeployments", "machinedeployments/scale", "machinesets", "machinesets/scale", "machines"},
+		},
+		{
+			Verbs:     []string{"get", "list", "watch"},
+			APIGroups: []string{"rke-machine.cattle.io"},
+			Resources: []string{"*"},
+		},
+	}
+	rules = appendUpdateRule(rules, []string{"machinedeployments", "machinedeployments/scale"}, machineDeploymentNames)
+	rules = appendUpdateRule(rules, []string{"machinesets", "machinesets/scale"}, machineSetNames)
+	rules = appendUpdateRule(rules, []string{"machines"}, machineNames)
+	return rules, nil
+}
+
+// appendUpdateRule allows to update the named resources. A rule without resource names would allow
+// to update all resources, so none is added if there are no names.
+func appendUpdateRule(rules []rbacv1.PolicyRule, resources, resourceNames []string) []rbacv1.PolicyRule {
+	if len(resourceNames) == 0 {
+		return rules
+	}
+	sort.Strings(resourceNames)
+	return append(rules, rbacv1.PolicyRule{
+		Verbs:         []string{"update", "patch"},
+		APIGroups:     []string{"cluster.x-k8s.io"},
+		Resources:     resources,
+		ResourceNames: resourceNames,
+	})
+}
+
+func installer(cluster *rancherv1.Cluster, kubeConfig []byte) []runtime.Object {
+	config := cluster.Spec.RKEConfig.ClusterAutoscaler
+	image := config.Image
+	if image == "" {
+		image = settings.ClusterAutoscalerImage.Get()
+	}
+
+	args := []string{
+		"/cluster-autoscaler",
+		"--cloud-provider=clusterapi",
+		"--cloud-config=" + managementKubeConfigPath + "/value",
+		fmt.Sprintf("--node-group-auto-discovery=clusterapi:namespace=%s,clusterName=%s", cluster.Namespace, cluster.Name),
+	}
+	args = append(args, config.ExtraArgs...)
+
+	labels := map[string]string{
+		"app": autoscalerName,
+	}
+
+	return []runtime.Object{
+		&corev1.ServiceAccount{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      autoscalerName,
+				Namespace: namespaces.System,
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      managementKubeConfigName,
+				Namespace: namespaces.System,
+			},
+			Data: map[string][]byte{
+				"value": kubeConfig,
+			},
+		},
+		&rbacv1.ClusterRole{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: autoscalerName,
+			},
+			Rules: []rbacv1.PolicyRule{
+				{
+					Verbs:     []string{"create", "patch"},
+					APIGroups: []string{""},
+					Resources: []string{"events", "endpoints"},
+				},
+				{
+					Verbs:     []string{"create"},
+					APIGroups: []string{""},
+					Resources: []string{"pods/eviction"},
+				},
+				{
+					Verbs:     []string{"update"},
+					APIGroups: []string{""},
+					Resources: []string{"pods/status"},
+				},
+				{
+					Verbs:         []string{"get", "update"},
+					APIGroups:     []string{""},
+					Resources:     []string{"endpoints"},
+					ResourceNames: []string{autoscalerName},
+				},
+				{
+					Verbs:     []string{"get", "list", "watch", "update"},
+					APIGroups: []string{""},
+					Resources: []string{"nodes"},
+				},
+				{
+					Verbs:     []string{"get", "list", "watch"},
+					APIGroups: []string{""},
+					Resources: []string{"namespaces", "pods", "services", "replicationcontrollers", "persistentvolumeclaims", "persistentvolumes"},
+				},
+				{
+					Verbs:     []string{"create", "get", "list", "watch", "update", "delete"},
+					APIGroups: []string{""},
+					Resources: []string{"configmaps"},
+				},
+				{
+					Verbs:     []string{"get", "list", "watch"},
+					APIGroups: []string{"apps"},
+					Resources: []string{"daemonsets", "replicasets", "statefulsets"},
+				},
+				{
+					Verbs:     []string{"get", "list", "watch"},
+					APIGroups: []string{"batch"},
+					Resources: []string{"jobs", "cronjobs"},
+				},
+				{
+					Verbs:     []string{"list", "watch"},
+					APIGroups: []string{"policy"},
+					Resources: []string{"poddisruptionbudgets"},
+				},
+				{
+					Verbs:     []string{"get", "list", "watch"},
+					APIGroups: []string{"storage.k8s.io"},
+					Resources: []string{"storageclasses", "csinodes", "csidrivers", "csistoragecapacities"},
+				},
+				{
+					Verbs:     []string{"create", "get", "update"},
+					APIGroups: []string{"coordination.k8s.io"},
+					Resources: []string{"leases"},
+				},
+			},
+		},
+		&rbacv1.ClusterRoleBinding{
+			ObjectMeta: metav1.ObjectMeta{
+				Name: autoscalerName,
+			},
+			Subjects: []rbacv1.Subject{{
+				Kind:      "ServiceAccount",
+				Name:      autoscalerName,
+				Namespace: namespaces.System,
+			}},
+			RoleRef: rbacv1.RoleRef{
+				APIGroup: rbacv1.GroupName,
+				Kind:     "ClusterRole",
+				Name:     autoscalerName,
+			},
+		},
+		&appsv1.Deployment{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      autoscalerName,
+				Namespace: namespaces.System,
+			},
+			Spec: appsv1.DeploymentSpec{
+				Selector: &metav1.LabelSelector{
+					MatchLabels: labels,
+				},
+				Template: corev1.PodTemplateSpec{
+					ObjectMeta: metav1.ObjectMeta{
+						Labels: labels,
+					},
+					Spec: corev1.PodSpec{
+						ServiceAccountName: autoscalerName,
+						Tolerations: []corev1.Toleration{{
+							Operator: corev1.TolerationOpExists,
+						}},
+						NodeSelector: map[string]string{
+							corev1.LabelOSStable: "linux",
+						},
+						Containers: []corev1.Container{{
+							Name:    autoscalerName,
+							Image:   settings.PrefixPrivateRegistry(image),
+							Command: args,
+							VolumeMounts: []corev1.VolumeMount{{
+								Name:      "management-kubeconfig",
+								MountPath: managementKubeConfigPath,
+								ReadOnly:  true,
+							}},
+						}},
+						Volumes: []corev1.Volume{{
+							Name: "management-kubeconfig",
+							VolumeSource: corev1.VolumeSource{
+								Secret: &corev1.SecretVolumeSource{
+									SecretName: managementKubeConfigName,
+								},
+							},
+						}},
+					},
+				},
+			},
+		},
+	}
+}
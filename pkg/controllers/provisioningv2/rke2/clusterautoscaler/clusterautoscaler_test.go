@@ -0,0 +1,81 @@
+package clusterautoscaler
+
+import (
+	"testing"
+
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1alpha4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+type fakeMachineDeployments struct {
+	capicontrollers.MachineDeploymentCache
+	items []*capi.MachineDeployment
+}
+
+func (f fakeMachineDeployments) List(namespace string, selector labels.Selector) ([]*capi.MachineDeployment, error) {
+	return f.items, nil
+}
+
+type fakeMachineSets struct {
+	capicontrollers.MachineSetCache
+	items []*capi.MachineSet
+}
+
+func (f fakeMachineSets) List(namespace string, selector labels.Selector) ([]*capi.MachineSet, error) {
+	return f.items, nil
+}
+
+type fakeMachines struct {
+	capicontrollers.MachineCache
+	items []*capi.Machine
+}
+
+func (f fakeMachines) List(namespace string, selector labels.Selector) ([]*capi.Machine, error) {
+	return f.items, nil
+}
+
+func TestRules(t *testing.T) {
+	cluster := &rancherv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "a", Namespace: "fleet-default"}}
+	h := &handler{
+		machineDeployments: fakeMachineDeployments{items: []*capi.MachineDeployment{
+			{ObjectMeta: metav1.ObjectMeta{Name: "a-workers"}, Spec: capi.MachineDeploymentSpec{ClusterName: "a"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "b-workers"}, Spec: capi.MachineDeploymentSpec{ClusterName: "b"}},
+		}},
+		machineSets: fakeMachineSets{items: []*capi.MachineSet{
+			{ObjectMeta: metav1.ObjectMeta{Name: "a-workers-5f6d"}, Spec: capi.MachineSetSpec{ClusterName: "a"}},
+			{ObjectMeta: metav1.ObjectMeta{Name: "b-workers-7c8b"}, Spec: capi.MachineSetSpec{ClusterName: "b"}},
+		}},
+		machines: fakeMachines{items: []*capi.Machine{
+			{ObjectMeta: metav1.ObjectMeta{Name: "b-workers-7c8b-x"}, Spec: capi.MachineSpec{ClusterName: "b"}},
+		}},
+	}
+
+	rules, err := h.rules(cluster)
+	require.NoError(t, err)
+	require.Len(t, rules, 4)
+	for _, rule := range rules[:2] {
+		assert.Equal(t, []string{"get", "list", "watch"}, rule.Verbs)
+	}
+	assert.Equal(t, rbacv1.PolicyRule{
+		Verbs:         []string{"update", "patch"},
+		APIGroups:     []string{"cluster.x-k8s.io"},
+		Resources:     []string{"machinedeployments", "machinedeployments/scale"},
+		ResourceNames: []string{"a-workers"},
+	}, rules[2])
+	assert.Equal(t, rbacv1.PolicyRule{
+		Verbs:         []string{"update", "patch"},
+		APIGroups:     []string{"cluster.x-k8s.io"},
+		Resources:     []string{"machinesets", "machinesets/scale"},
+		ResourceNames: []string{"a-workers-5f6d"},
+	}, rules[3])
+}
+
+func TestAppendUpdateRuleWithoutNames(t *testing.T) {
+	assert.Empty(t, appendUpdateRule(nil, []string{"machines"}, nil))
+}
@@ -112,6 +112,9 @@ func (h *handler) associateMachineWithNode(_ string, bootstrap *rkev1.RKEBootstr
 
 	nodeLabelSelector := metav1.LabelSelector{MatchLabels: map[string]string{planner.MachineUIDLabel: string(machine.GetUID())}}
 	nodes, err := clientset.CoreV1().Nodes().List(context.TODO(), metav1.ListOptions{LabelSelector: labels.Set(nodeLabelSelector.MatchLabels).String()})
+	if err == nil && len(nodes.Items) == 0 {
+		nodes.Items, err = labelAdoptedNode(clientset, machine)
+	}
 	if err != nil || len(nodes.Items) == 0 || nodes.Items[0].Spec.ProviderID == "" {
 		logrus.Debugf("Searching for providerID for selector %s in cluster %s/%s, machine %s: %v",
 			labels.Set(nodeLabelSelector.MatchLabels), rancherCluster.Namespace, rancherCluster.Name, machine.Name, err)
@@ -196,3 +199,34 @@ func (h *handler) updateMachine(node *corev1.Node, machine *capi.Machine, ranche
 
 	return nil
 }
+
+// labelAdoptedNode adds the machine label to the node of an adopted machine. The node registered before the
+// machine existed, so the label set in the node configuration was never applied.
+func labelAdoptedNode(clientset kubernetes.Interface, machine *capi.Machine) ([]corev1.Node, error) {
+	nodeName := machine.Labels[planner.NodeNameLabel]
+	if machine.Annotations[planner.AdoptionAnnotation] != planner.AdoptionAdopted || nodeName == "" {
+		return nil, nil
+	}
+
+	node, err := clientset.CoreV1().Nodes().Get(context.TODO(), nodeName, metav1.GetOptions{})
+	if apierror.IsNotFound(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	if owner := node.Labels[planner.MachineUIDLabel]; owner != "" && owner != string(machine.UID) {
+		return nil, fmt.Errorf("node %s is already owned by machine %s", nodeName, owner)
+	}
+
+	node = node.DeepCopy()
+	if node.Labels == nil {
+		node.Labels = map[string]string{}
+	}
+	node.Labels[planner.MachineUIDLabel] = string(machine.UID)
+	node, err = clientset.CoreV1().Nodes().Update(context.TODO(), node, metav1.UpdateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return []corev1.Node{*node}, nil
+}
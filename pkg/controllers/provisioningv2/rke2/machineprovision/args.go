@@ -4,7 +4,9 @@
 	"fmt"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
 	namespace2 "github.com/rancher/rancher/pkg/namespace"
@@ -49,6 +51,8 @@ type driverArgs struct {
 	StateSecretName     string
 	BootstrapSecretName string
 	BootstrapOptional   bool
+	JobAnnotations      map[string]string
+	RequeueAfter        time.Duration
 	Args                []string
 }
 
@@ -59,6 +63,7 @@ func MachineStateSecretName(machineName string) string {
 func (h *handler) getArgsEnvAndStatus(typeMeta meta.Type, meta metav1.Object, data data.Object, args map[string]interface{}, driver string, create bool) (driverArgs, error) {
 	var (
 		url, hash, cloudCredentialSecretName string
+		requeueAfter                         time.Duration
 	)
 
 	nd, err := h.nodeDriverCache.Get(driver)
@@ -85,7 +90,15 @@ func (h *handler) getArgsEnvAndStatus(typeMeta meta.Type, meta metav1.Object, da
 		Data: map[string][]byte{},
 	}
 
-	bootstrapName, cloudCredentialSecretName, secrets, err := h.getSecretData(meta, data, create)
+	machine, err := h.getMachine(meta)
+	if err != nil {
+		return driverArgs{}, err
+	}
+	if machine == nil && create {
+		return driverArgs{}, generic.ErrSkip
+	}
+
+	bootstrapName, cloudCredentialSecretName, secrets, err := h.getSecretData(machine, meta, data)
 	if err != nil {
 		return driverArgs{}, err
 	}
@@ -109,16 +122,32 @@ func (h *handler) getArgsEnvAndStatus(typeMeta meta.Type, meta metav1.Object, da
 		fmt.Sprintf("--secret-name=%s", secretName),
 	}
 
+	jobAnnotations := map[string]string{
+		provisionCommandAnnotation: commandRemove,
+	}
 	if create {
-		cmd = append(cmd, "create",
-			fmt.Sprintf("--driver=%s", driver),
-			fmt.Sprintf("--custom-install-script=/run/secrets/machine/value"))
-
-		rancherCluster, err := h.rancherClusterCache.Get(meta.GetNamespace(), meta.GetLabels()[CapiMachineLabel])
+		step, err := h.getProvisionStep(machine, meta)
 		if err != nil {
 			return driverArgs{}, err
 		}
-		cmd = append(cmd, toArgs(driver, args, rancherCluster.Status.ClusterName)...)
+		jobAnnotations[provisionAttemptAnnotation] = strconv.Itoa(int(step.attempt))
+		requeueAfter = step.wait
+
+		if step.cleanup {
+			jobAnnotations[provisionCommandAnnotation] = commandCleanup
+			cmd = append(cmd, "rm", "-y")
+		} else {
+			jobAnnotations[provisionCommandAnnotation] = commandCreate
+			cmd = append(cmd, "create",
+				fmt.Sprintf("--driver=%s", driver),
+				fmt.Sprintf("--custom-install-script=/run/secrets/machine/value"))
+
+			rancherCluster, err := h.rancherClusterCache.Get(meta.GetNamespace(), meta.GetLabels()[CapiMachineLabel])
+			if err != nil {
+				return driverArgs{}, err
+			}
+			cmd = append(cmd, toArgs(driver, args, rancherCluster.Status.ClusterName)...)
+		}
 	} else {
 		cmd = append(cmd, "rm", "-y")
 	}
@@ -132,6 +161,8 @@ func (h *handler) getArgsEnvAndStatus(typeMeta meta.Type, meta metav1.Object, da
 		StateSecretName:     secretName,
 		BootstrapSecretName: bootstrapName,
 		BootstrapOptional:   !create,
+		JobAnnotations:      jobAnnotations,
+		RequeueAfter:        requeueAfter,
 		Args:                cmd,
 
 		RKEMachineStatus: rkev1.RKEMachineStatus{
@@ -164,30 +195,73 @@ func (h *handler) getBootstrapSecret(machine *capi.Machine) (string, error) {
 	return d.String("status", "dataSecretName"), nil
 }
 
-func (h *handler) getSecretData(meta metav1.Object, obj data.Object, create bool) (string, string, map[string]string, error) {
-	var (
-		err     error
-		machine *capi.Machine
-		result  = map[string]string{}
-	)
-
-	oldCredential := obj.String("status", "cloudCredentialSecretName")
-	cloudCredentialSecretName := obj.String("spec", "common", "cloudCredentialSecretName")
-
+// getMachine returns the CAPI machine that owns the infra machine, if any.
+func (h *handler) getMachine(meta metav1.Object) (*capi.Machine, error) {
 	for _, ref := range meta.GetOwnerReferences() {
 		if ref.Kind != "Machine" {
 			continue
 		}
 
-		machine, err = h.machines.Get(meta.GetNamespace(), ref.Name)
-		if err != nil && !apierror.IsNotFound(err) {
-			return "", "", nil, err
+		machine, err := h.machines.Get(meta.GetNamespace(), ref.Name)
+		if apierror.IsNotFound(err) {
+			return nil, nil
 		}
+		return machine, err
 	}
+	return nil, nil
+}
 
-	if machine == nil && create {
-		return "", "", nil, generic.ErrSkip
+// getProvisionStep returns the job to run next for an infra machine that is being created.
+func (h *handler) getProvisionStep(machine *capi.Machine, meta metav1.Object) (provisionStep, error) {
+	maxAttempts, err := h.getMaxAttempts(machine)
+	if err != nil {
+		return provisionStep{}, err
+	}
+
+	job, err := h.jobs.Get(meta.GetNamespace(), getJobName(meta.GetName()))
+	if apierror.IsNotFound(err) {
+		job = nil
+	} else if err != nil {
+		return provisionStep{}, err
+	}
+	return nextProvisionStep(job, maxAttempts, time.Now()), nil
+}
+
+// getMaxAttempts returns how often the provisioning of the machine is tried, as configured on its
+// machine deployment.
+func (h *handler) getMaxAttempts(machine *capi.Machine) (int32, error) {
+	machineDeployment, err := h.getMachineDeployment(machine)
+	if err != nil || machineDeployment == nil {
+		return 1, err
+	}
+
+	value := machineDeployment.Annotations[MaxAttemptsAnnotation]
+	if value == "" {
+		return 1, nil
+	}
+	maxAttempts, err := strconv.Atoi(value)
+	if err != nil || maxAttempts < 1 {
+		return 1, nil
 	}
+	return int32(maxAttempts), nil
+}
+
+func (h *handler) getMachineDeployment(machine *capi.Machine) (*capi.MachineDeployment, error) {
+	if machine == nil || machine.Labels[capi.MachineDeploymentLabelName] == "" {
+		return nil, nil
+	}
+	machineDeployment, err := h.machineDeployments.Get(machine.Namespace, machine.Labels[capi.MachineDeploymentLabelName])
+	if apierror.IsNotFound(err) {
+		return nil, nil
+	}
+	return machineDeployment, err
+}
+
+func (h *handler) getSecretData(machine *capi.Machine, meta metav1.Object, obj data.Object) (string, string, map[string]string, error) {
+	result := map[string]string{}
+
+	oldCredential := obj.String("status", "cloudCredentialSecretName")
+	cloudCredentialSecretName := obj.String("spec", "common", "cloudCredentialSecretName")
 
 	if cloudCredentialSecretName == "" {
 		cloudCredentialSecretName = oldCredential
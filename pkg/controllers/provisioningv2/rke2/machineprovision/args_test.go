@@ -0,0 +1,67 @@
+package machineprovision
+
+import (
+	"testing"
+
+	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1alpha4"
+	"github.com/stretchr/testify/assert"
+	apierror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+type fakeMachineDeploymentCache struct {
+	capicontrollers.MachineDeploymentCache
+	items map[string]*capi.MachineDeployment
+}
+
+func (f fakeMachineDeploymentCache) Get(namespace, name string) (*capi.MachineDeployment, error) {
+	if md, ok := f.items[name]; ok {
+		return md, nil
+	}
+	return nil, apierror.NewNotFound(schema.GroupResource{Resource: "machinedeployments"}, name)
+}
+
+func TestGetMaxAttempts(t *testing.T) {
+	h := &handler{
+		machineDeployments: fakeMachineDeploymentCache{items: map[string]*capi.MachineDeployment{
+			"unset":    {},
+			"three":    {ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{MaxAttemptsAnnotation: "3"}}},
+			"zero":     {ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{MaxAttemptsAnnotation: "0"}}},
+			"negative": {ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{MaxAttemptsAnnotation: "-2"}}},
+			"invalid":  {ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{MaxAttemptsAnnotation: "three"}}},
+		}},
+	}
+
+	machine := func(machineDeployment string) *capi.Machine {
+		m := &capi.Machine{ObjectMeta: metav1.ObjectMeta{Namespace: "fleet-default", Labels: map[string]string{}}}
+		if machineDeployment != "" {
+			m.Labels[capi.MachineDeploymentLabelName] = machineDeployment
+		}
+		return m
+	}
+
+	tests := []struct {
+		name    string
+		machine *capi.Machine
+		want    int32
+	}{
+		{name: "no machine", want: 1},
+		{name: "no machine deployment", machine: machine(""), want: 1},
+		{name: "machine deployment not found", machine: machine("missing"), want: 1},
+		{name: "annotation not set", machine: machine("unset"), want: 1},
+		{name: "annotation set", machine: machine("three"), want: 3},
+		{name: "zero", machine: machine("zero"), want: 1},
+		{name: "negative", machine: machine("negative"), want: 1},
+		{name: "invalid", machine: machine("invalid"), want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := h.getMaxAttempts(tt.machine)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -23,6 +23,7 @@
 	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	"github.com/rancher/wrangler/pkg/generic"
 	"github.com/rancher/wrangler/pkg/summary"
+	"github.com/sirupsen/logrus"
 	batchv1 "k8s.io/api/batch/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierror "k8s.io/apimachinery/pkg/api/errors"
@@ -31,6 +32,7 @@
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
 	"sigs.k8s.io/cluster-api/errors"
 )
 
@@ -40,11 +42,15 @@ type handler struct {
 	jobs                batchcontrollers.JobCache
 	pods                corecontrollers.PodCache
 	secrets             corecontrollers.SecretCache
+	secretClient        corecontrollers.SecretClient
 	machines            capicontrollers.MachineCache
+	machineClient       capicontrollers.MachineClient
+	machineDeployments  capicontrollers.MachineDeploymentCache
 	namespaces          corecontrollers.NamespaceCache
 	nodeDriverCache     mgmtcontrollers.NodeDriverCache
 	dynamic             *dynamic.Controller
 	rancherClusterCache ranchercontrollers.ClusterCache
+	k8s                 kubernetes.Interface
 }
 
 func Register(ctx context.Context, clients *wrangler.Context) {
@@ -60,11 +66,15 @@ func Register(ctx context.Context, clients *wrangler.Context) {
 		pods:                clients.Core.Pod().Cache(),
 		jobs:                clients.Batch.Job().Cache(),
 		secrets:             clients.Core.Secret().Cache(),
+		secretClient:        clients.Core.Secret(),
 		machines:            clients.CAPI.Machine().Cache(),
+		machineClient:       clients.CAPI.Machine(),
+		machineDeployments:  clients.CAPI.MachineDeployment().Cache(),
 		nodeDriverCache:     clients.Mgmt.NodeDriver().Cache(),
 		namespaces:          clients.Core.Namespace().Cache(),
 		dynamic:             clients.Dynamic,
 		rancherClusterCache: clients.Provisioning.Cluster().Cache(),
+		k8s:                 clients.K8s,
 	}
 
 	removeHandler := generic.NewRemoveHandler("machine-provision-remove", clients.Dynamic.Update, h.OnRemove)
@@ -117,11 +127,41 @@ func (h *handler) OnJobChange(key string, job *batchv1.Job) (*batchv1.Job, error
 		return job, err
 	}
 
-	newStatus, err := h.getMachineStatus(job)
-	if err != nil {
-		return job, err
+	command := job.Annotations[provisionCommandAnnotation]
+	failed := condition.Cond("Failed").IsTrue(job)
+	retry := false
+	if failed && command != commandRemove && meta.GetDeletionTimestamp() == nil {
+		retry, err = h.willRetry(meta, job)
+		if err != nil {
+			return job, err
+		}
+	}
+
+	var newStatus rkev1.RKEMachineStatus
+	// the machine is still being provisioned while a failed attempt is cleaned up and retried
+	if command != commandCleanup && !retry {
+		newStatus, err = h.getMachineStatus(job)
+		if err != nil {
+			return job, err
+		}
 	}
 	newStatus.JobName = job.Name
+	if command != commandRemove {
+		newStatus.ProvisionAttempts = jobAttempt(job)
+	}
+
+	if failed || job.Status.CompletionTime != nil {
+		newStatus.ProvisionLogSecretName, err = h.saveLogs(job, infraMachine.GetObjectKind().GroupVersionKind(), meta)
+		if err != nil {
+			return job, err
+		}
+	}
+
+	if failed && command != commandCleanup && !retry && meta.GetDeletionTimestamp() == nil {
+		if err := h.replaceFailedMachine(meta); err != nil {
+			return job, err
+		}
+	}
 
 	if _, err := h.patchStatus(infraMachine, d, newStatus); err != nil {
 		return job, err
@@ -136,6 +176,40 @@ func (h *handler) OnJobChange(key string, job *batchv1.Job) (*batchv1.Job, error
 	return job, nil
 }
 
+// willRetry returns whether the provisioning of the machine is tried again after the failed job.
+func (h *handler) willRetry(meta metav1.Object, job *batchv1.Job) (bool, error) {
+	machine, err := h.getMachine(meta)
+	if err != nil {
+		return false, err
+	}
+	maxAttempts, err := h.getMaxAttempts(machine)
+	if err != nil {
+		return false, err
+	}
+	return nextProvisionStep(job, maxAttempts, time.Now()).cleanup, nil
+}
+
+// replaceFailedMachine deletes the machine of an infra machine that failed to provision if its machine
+// deployment replaces failed machines, the machine set then creates a new machine.
+func (h *handler) replaceFailedMachine(meta metav1.Object) error {
+	machine, err := h.getMachine(meta)
+	if err != nil || machine == nil || machine.DeletionTimestamp != nil {
+		return err
+	}
+
+	machineDeployment, err := h.getMachineDeployment(machine)
+	if err != nil || machineDeployment == nil || machineDeployment.Annotations[ReplaceFailedAnnotation] != "true" {
+		return err
+	}
+
+	logrus.Infof("[machineprovision] deleting machine %s/%s that failed to provision", machine.Namespace, machine.Name)
+	err = h.machineClient.Delete(machine.Namespace, machine.Name, &metav1.DeleteOptions{})
+	if apierror.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
 func (h *handler) getMachineStatus(job *batchv1.Job) (rkev1.RKEMachineStatus, error) {
 	if job.Status.CompletionTime != nil {
 		return rkev1.RKEMachineStatus{
@@ -225,7 +299,9 @@ func (h *handler) OnRemove(_ string, obj runtime.Object) (runtime.Object, error)
 		return nil, err
 	}
 
-	if condition.Cond("Failed").IsTrue(job) || job.Status.CompletionTime != nil {
+	// the job may still be the one that created the machine until it is replaced by the one removing it
+	if job.Annotations[provisionCommandAnnotation] == commandRemove &&
+		(condition.Cond("Failed").IsTrue(job) || job.Status.CompletionTime != nil) {
 		return obj, nil
 	}
 
@@ -289,6 +365,12 @@ func (h *handler) run(obj runtime.Object, create bool) (runtime.Object, error) {
 		return nil, err
 	}
 
+	if dArgs.RequeueAfter > 0 {
+		if err := h.dynamic.EnqueueAfter(obj.GetObjectKind().GroupVersionKind(), meta.GetNamespace(), meta.GetName(), dArgs.RequeueAfter); err != nil {
+			return nil, err
+		}
+	}
+
 	if create {
 		return h.patchStatus(obj, d, dArgs.RKEMachineStatus)
 	}
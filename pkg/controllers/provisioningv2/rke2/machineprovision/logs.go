@@ -0,0 +1,128 @@
+package machineprovision
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+
+	name2 "github.com/rancher/wrangler/pkg/name"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	jobUIDAnnotation = "rke.cattle.io/job-uid"
+	// maxProvisionLogBytes bounds the size of the log kept per machine, the oldest output is dropped first.
+	maxProvisionLogBytes = 256 * 1024
+)
+
+func MachineProvisionLogSecretName(machineName string) string {
+	return name2.SafeConcatName(machineName, "machine", "provision", "log")
+}
+
+// saveLogs appends the output of every pod of a finished job to the provision log of the machine, so it
+// is kept after the job and its pods are removed.
+func (h *handler) saveLogs(job *batchv1.Job, gvk schema.GroupVersionKind, meta metav1.Object) (string, error) {
+	secretName := MachineProvisionLogSecretName(meta.GetName())
+	secret, err := h.secrets.Get(job.Namespace, secretName)
+	if apierror.IsNotFound(err) {
+		secret = nil
+	} else if err != nil {
+		return "", err
+	} else if secret.Annotations[jobUIDAnnotation] == string(job.UID) {
+		return secretName, nil
+	}
+
+	sel, err := metav1.LabelSelectorAsSelector(job.Spec.Selector)
+	if err != nil {
+		return "", err
+	}
+	pods, err := h.pods.List(job.Namespace, sel)
+	if err != nil {
+		return "", err
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		return pods[i].CreationTimestamp.Before(&pods[j].CreationTimestamp)
+	})
+
+	buf := &bytes.Buffer{}
+	if secret != nil {
+		buf.Write(secret.Data["log"])
+	}
+	command := job.Annotations[provisionCommandAnnotation]
+	if command == "" {
+		command = commandCreate
+	}
+	for _, pod := range pods {
+		if command == commandRemove {
+			fmt.Fprintf(buf, "--- job %s %s (pod %s) ---\n", job.Name, command, pod.Name)
+		} else {
+			fmt.Fprintf(buf, "--- job %s %s attempt %d (pod %s) ---\n", job.Name, command, jobAttempt(job), pod.Name)
+		}
+		logs, err := h.k8s.CoreV1().Pods(pod.Namespace).GetLogs(pod.Name, &corev1.PodLogOptions{
+			Container: "machine",
+		}).DoRaw(h.ctx)
+		if err != nil {
+			fmt.Fprintf(buf, "failed to read logs: %v\n", err)
+			continue
+		}
+		buf.Write(logs)
+		if len(logs) > 0 && logs[len(logs)-1] != '\n' {
+			buf.WriteByte('\n')
+		}
+	}
+
+	log := truncateLog(buf.Bytes(), maxProvisionLogBytes)
+
+	if secret == nil {
+		_, err = h.secretClient.Create(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      secretName,
+				Namespace: job.Namespace,
+				Annotations: map[string]string{
+					jobUIDAnnotation: string(job.UID),
+				},
+				OwnerReferences: []metav1.OwnerReference{{
+					APIVersion: gvk.GroupVersion().String(),
+					Kind:       gvk.Kind,
+					Name:       meta.GetName(),
+					UID:        meta.GetUID(),
+				}},
+			},
+			Type: "rke.cattle.io/machine-provision-log",
+			Data: map[string][]byte{
+				"log": log,
+			},
+		})
+		return secretName, err
+	}
+
+	secret = secret.DeepCopy()
+	if secret.Annotations == nil {
+		secret.Annotations = map[string]string{}
+	}
+	secret.Annotations[jobUIDAnnotation] = string(job.UID)
+	secret.Data = map[string][]byte{
+		"log": log,
+	}
+	_, err = h.secretClient.Update(secret)
+	return secretName, err
+}
+
+// truncateLog drops the oldest output of a log that is over max bytes, starting at a line so the log
+// doesn't begin in the middle of one.
+func truncateLog(log []byte, max int) []byte {
+	if len(log) <= max {
+		return log
+	}
+	start := len(log) - max
+	if log[start-1] != '\n' {
+		if i := bytes.IndexByte(log[start:], '\n'); i >= 0 && start+i < len(log)-1 {
+			start += i + 1
+		}
+	}
+	return log[start:]
+}
@@ -0,0 +1,122 @@
+package machineprovision
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+type fakeSecretCache struct {
+	corecontrollers.SecretCache
+	secret *corev1.Secret
+}
+
+func (f fakeSecretCache) Get(namespace, name string) (*corev1.Secret, error) {
+	if f.secret == nil || f.secret.Name != name {
+		return nil, apierror.NewNotFound(schema.GroupResource{Resource: "secrets"}, name)
+	}
+	return f.secret, nil
+}
+
+type fakeSecretClient struct {
+	corecontrollers.SecretClient
+	saved *corev1.Secret
+}
+
+func (f *fakeSecretClient) Create(secret *corev1.Secret) (*corev1.Secret, error) {
+	f.saved = secret
+	return secret, nil
+}
+
+func (f *fakeSecretClient) Update(secret *corev1.Secret) (*corev1.Secret, error) {
+	f.saved = secret
+	return secret, nil
+}
+
+type fakePodCache struct {
+	corecontrollers.PodCache
+	pods []*corev1.Pod
+}
+
+func (f fakePodCache) List(namespace string, selector labels.Selector) ([]*corev1.Pod, error) {
+	return f.pods, nil
+}
+
+func TestTruncateLog(t *testing.T) {
+	assert.Equal(t, "a\nb\n", string(truncateLog([]byte("a\nb\n"), 10)))
+	// the partial first line is dropped
+	assert.Equal(t, "ccc\n", string(truncateLog([]byte("aaa\nbbb\nccc\n"), 6)))
+	assert.Equal(t, "bbb\nccc\n", string(truncateLog([]byte("aaa\nbbb\nccc\n"), 8)))
+	// a single line is cut
+	assert.Equal(t, "cdef", string(truncateLog([]byte("abcdef"), 4)))
+}
+
+func TestSaveLogsTruncates(t *testing.T) {
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "m-machine-provision-abcde", Namespace: "fleet-default"}}
+	job := newJob(commandCreate, "2")
+	job.UID = "job-2"
+	job.Spec.Selector = &metav1.LabelSelector{MatchLabels: map[string]string{"job-name": job.Name}}
+
+	oldLog := bytes.Repeat([]byte("previous attempt output\n"), maxProvisionLogBytes/24+1)
+	existing := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        MachineProvisionLogSecretName("m"),
+			Namespace:   "fleet-default",
+			Annotations: map[string]string{jobUIDAnnotation: "job-1"},
+		},
+		Data: map[string][]byte{"log": oldLog},
+	}
+
+	secretClient := &fakeSecretClient{}
+	h := &handler{
+		ctx:          context.Background(),
+		secrets:      fakeSecretCache{secret: existing},
+		secretClient: secretClient,
+		pods:         fakePodCache{pods: []*corev1.Pod{pod}},
+		k8s:          fake.NewSimpleClientset(pod),
+	}
+
+	meta := &metav1.ObjectMeta{Name: "m", Namespace: "fleet-default"}
+	secretName, err := h.saveLogs(job, schema.GroupVersionKind{Group: "rke-machine.cattle.io", Version: "v1", Kind: "Amazonec2Machine"}, meta)
+	require.NoError(t, err)
+	assert.Equal(t, existing.Name, secretName)
+	require.NotNil(t, secretClient.saved)
+
+	log := string(secretClient.saved.Data["log"])
+	assert.LessOrEqual(t, len(log), maxProvisionLogBytes)
+	assert.True(t, strings.HasPrefix(log, "previous attempt output\n"), "the log starts at a line")
+	assert.True(t, strings.HasSuffix(log, "--- job m-machine-provision create attempt 2 (pod m-machine-provision-abcde) ---\nfake logs\n"))
+	assert.Equal(t, "job-2", secretClient.saved.Annotations[jobUIDAnnotation])
+	// the existing secret in the cache is not modified
+	assert.Equal(t, oldLog, existing.Data["log"])
+}
+
+func TestSaveLogsOncePerJob(t *testing.T) {
+	job := newJob(commandCreate, "1")
+	job.UID = "job-1"
+	secretClient := &fakeSecretClient{}
+	h := &handler{
+		secrets: fakeSecretCache{secret: &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:        MachineProvisionLogSecretName("m"),
+				Annotations: map[string]string{jobUIDAnnotation: "job-1"},
+			},
+		}},
+		secretClient: secretClient,
+	}
+
+	_, err := h.saveLogs(job, schema.GroupVersionKind{}, &metav1.ObjectMeta{Name: "m"})
+	require.NoError(t, err)
+	assert.Nil(t, secretClient.saved)
+}
@@ -0,0 +1,94 @@
+package machineprovision
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/rancher/wrangler/pkg/condition"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// provisionAttemptAnnotation and provisionCommandAnnotation on a provisioning job record the attempt it
+	// belongs to and whether it creates the machine, cleans up after a failed attempt or removes the machine.
+	provisionAttemptAnnotation = "rke.cattle.io/provision-attempt"
+	provisionCommandAnnotation = "rke.cattle.io/provision-command"
+
+	commandCreate  = "create"
+	commandCleanup = "cleanup"
+	commandRemove  = "remove"
+
+	// the backoff between attempts matches the one of the job controller
+	initialRetryBackoff = 10 * time.Second
+	maxRetryBackoff     = 6 * time.Minute
+)
+
+// provisionStep is the job a machine that is being created runs next.
+type provisionStep struct {
+	attempt int32
+	cleanup bool
+	// wait is how long the cleanup job is kept before the next attempt starts
+	wait time.Duration
+}
+
+// nextProvisionStep returns the job to run for a machine that is being created, given its current job.
+// Create is not idempotent, the state secret of a failed attempt already has the machine and whatever
+// the driver created for it. A failed attempt is therefore followed by a cleanup job that runs rm -y
+// with the same state, and the next attempt only starts once the cleanup finished and the backoff passed.
+func nextProvisionStep(job *batchv1.Job, maxAttempts int32, now time.Time) provisionStep {
+	if job == nil {
+		return provisionStep{attempt: 1}
+	}
+
+	attempt := jobAttempt(job)
+	if job.Annotations[provisionCommandAnnotation] == commandCleanup {
+		finished, ok := jobFinished(job)
+		if !ok {
+			return provisionStep{attempt: attempt, cleanup: true}
+		}
+		if wait := finished.Add(retryBackoff(attempt)).Sub(now); wait > 0 {
+			return provisionStep{attempt: attempt, cleanup: true, wait: wait}
+		}
+		return provisionStep{attempt: attempt + 1}
+	}
+
+	if condition.Cond("Failed").IsTrue(job) && attempt < maxAttempts {
+		return provisionStep{attempt: attempt, cleanup: true}
+	}
+	return provisionStep{attempt: attempt}
+}
+
+// jobAttempt returns the attempt of a provisioning job, jobs created before attempts were recorded are
+// the first one.
+func jobAttempt(job *batchv1.Job) int32 {
+	attempt, err := strconv.Atoi(job.Annotations[provisionAttemptAnnotation])
+	if err != nil || attempt < 1 {
+		return 1
+	}
+	return int32(attempt)
+}
+
+// jobFinished returns when the job completed or failed.
+func jobFinished(job *batchv1.Job) (time.Time, bool) {
+	if job.Status.CompletionTime != nil {
+		return job.Status.CompletionTime.Time, true
+	}
+	for _, cond := range job.Status.Conditions {
+		if cond.Type == batchv1.JobFailed && cond.Status == corev1.ConditionTrue {
+			return cond.LastTransitionTime.Time, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func retryBackoff(attempt int32) time.Duration {
+	backoff := initialRetryBackoff
+	for i := int32(1); i < attempt && backoff < maxRetryBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxRetryBackoff {
+		return maxRetryBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,124 @@
+package machineprovision
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	batchv1 "k8s.io/api/batch/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newJob(command string, attempt string) *batchv1.Job {
+	return &batchv1.Job{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "m-machine-provision",
+			Namespace: "fleet-default",
+			Annotations: map[string]string{
+				provisionCommandAnnotation: command,
+				provisionAttemptAnnotation: attempt,
+			},
+		},
+	}
+}
+
+func failed(job *batchv1.Job, at time.Time) *batchv1.Job {
+	job.Status.Conditions = append(job.Status.Conditions, batchv1.JobCondition{
+		Type:               batchv1.JobFailed,
+		Status:             corev1.ConditionTrue,
+		LastTransitionTime: metav1.NewTime(at),
+	})
+	return job
+}
+
+func completed(job *batchv1.Job, at time.Time) *batchv1.Job {
+	completionTime := metav1.NewTime(at)
+	job.Status.CompletionTime = &completionTime
+	return job
+}
+
+func TestNextProvisionStep(t *testing.T) {
+	now := time.Now()
+
+	tests := []struct {
+		name        string
+		job         *batchv1.Job
+		maxAttempts int32
+		want        provisionStep
+	}{
+		{
+			name:        "first attempt",
+			maxAttempts: 3,
+			want:        provisionStep{attempt: 1},
+		},
+		{
+			name:        "attempt running",
+			job:         newJob(commandCreate, "2"),
+			maxAttempts: 3,
+			want:        provisionStep{attempt: 2},
+		},
+		{
+			name:        "attempt succeeded",
+			job:         completed(newJob(commandCreate, "1"), now),
+			maxAttempts: 3,
+			want:        provisionStep{attempt: 1},
+		},
+		{
+			name:        "failed attempt is cleaned up",
+			job:         failed(newJob(commandCreate, "1"), now),
+			maxAttempts: 3,
+			want:        provisionStep{attempt: 1, cleanup: true},
+		},
+		{
+			name:        "last attempt failed",
+			job:         failed(newJob(commandCreate, "3"), now),
+			maxAttempts: 3,
+			want:        provisionStep{attempt: 3},
+		},
+		{
+			name:        "job without attempt",
+			job:         failed(&batchv1.Job{}, now),
+			maxAttempts: 1,
+			want:        provisionStep{attempt: 1},
+		},
+		{
+			name:        "cleanup running",
+			job:         newJob(commandCleanup, "1"),
+			maxAttempts: 3,
+			want:        provisionStep{attempt: 1, cleanup: true},
+		},
+		{
+			name:        "cleanup waits for the backoff",
+			job:         completed(newJob(commandCleanup, "2"), now.Add(-5*time.Second)),
+			maxAttempts: 3,
+			want:        provisionStep{attempt: 2, cleanup: true, wait: 15 * time.Second},
+		},
+		{
+			name:        "failed cleanup waits for the backoff",
+			job:         failed(newJob(commandCleanup, "1"), now),
+			maxAttempts: 3,
+			want:        provisionStep{attempt: 1, cleanup: true, wait: 10 * time.Second},
+		},
+		{
+			name:        "next attempt after the backoff",
+			job:         completed(newJob(commandCleanup, "2"), now.Add(-time.Minute)),
+			maxAttempts: 3,
+			want:        provisionStep{attempt: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, nextProvisionStep(tt.job, tt.maxAttempts, now))
+		})
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	assert.Equal(t, 10*time.Second, retryBackoff(1))
+	assert.Equal(t, 20*time.Second, retryBackoff(2))
+	assert.Equal(t, 160*time.Second, retryBackoff(5))
+	assert.Equal(t, 6*time.Minute, retryBackoff(7))
+	assert.Equal(t, 6*time.Minute, retryBackoff(100))
+}
@@ -16,6 +16,11 @@
 	InfraMachineKind    = "rke.cattle.io/infra-machine-kind"
 	InfraMachineName    = "rke.cattle.io/infra-machine-name"
 
+	// MaxAttemptsAnnotation and ReplaceFailedAnnotation on the machine deployment of a machine configure how
+	// often its provisioning is tried and whether the machine is deleted when all attempts failed.
+	MaxAttemptsAnnotation   = "rke.cattle.io/machine-provision-max-attempts"
+	ReplaceFailedAnnotation = "rke.cattle.io/replace-failed-machines"
+
 	pathToMachineFiles = "/path/to/machine/files"
 )
 
@@ -134,10 +139,12 @@ func (h *handler) objects(ready bool, typeMeta metav1.Type, meta metav1.Object,
 	}
 	job := &batchv1.Job{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      saName,
-			Namespace: meta.GetNamespace(),
+			Name:        saName,
+			Namespace:   meta.GetNamespace(),
+			Annotations: args.JobAnnotations,
 		},
 		Spec: batchv1.JobSpec{
+			// a failed attempt is retried by a new job, after its state is cleaned up
 			BackoffLimit: &[]int32{0}[0],
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
@@ -7,6 +7,7 @@
 	"encoding/json"
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -35,6 +36,8 @@
 	Provisioned         = condition.Cond("Provisioned")
 	InfrastructureReady = condition.Cond(capi.InfrastructureReadyCondition)
 	BootstrapReady      = condition.Cond(capi.BootstrapReadyCondition)
+
+	preflightRetryInterval = 2 * time.Minute
 )
 
 type handler struct {
@@ -201,6 +204,11 @@ func (h *handler) OnChange(key string, machine *capi.Machine) (*capi.Machine, er
 		return machine, err
 	}
 
+	machine, err = h.setPreflightCondition(machine, plan)
+	if err != nil {
+		return machine, err
+	}
+
 	// This is a temporary solution until RKE2 Windows nodes support system-agent functionality.
 	if os, ok := machine.GetLabels()["cattle.io/os"]; ok && os == "windows" {
 		return h.setMachineCondition(machine, Provisioned, corev1.ConditionTrue, "WindowsNode", "windows nodes don't currently support plans")
@@ -265,15 +273,61 @@ func (h *handler) setMachineCondition(machine *capi.Machine, cond condition.Cond
 			machine.Status.Conditions = append(machine.Status.Conditions, newCond)
 		}
 
-		_, err := h.machines.UpdateStatus(machine)
+		updated, err := h.machines.UpdateStatus(machine)
 		if err != nil {
 			return machine, err
 		}
+		return updated, nil
 	}
 
 	return machine, nil
 }
 
+// setPreflightCondition reports the result of the preflight checks of a new custom machine. Failed checks are run
+// again every preflightRetryInterval so the machine joins once the node has been fixed.
+func (h *handler) setPreflightCondition(machine *capi.Machine, nodePlan *plan.Node) (*capi.Machine, error) {
+	if nodePlan == nil {
+		return machine, nil
+	}
+	if !planner.IsPreflightPlan(nodePlan.Plan) {
+		// The planner only replaces the preflight plan once the checks passed, which may happen before the
+		// result was seen here.
+		if planner.PreflightReady.GetStatus(machine) == "" || planner.PreflightReady.IsTrue(machine) {
+			return machine, nil
+		}
+		return h.setMachineCondition(machine, planner.PreflightReady, corev1.ConditionTrue, planner.PreflightPassedStatus, "preflight checks passed")
+	}
+
+	status, reason, message := planner.GetPreflightStatusReasonMessage(nodePlan)
+	if status == corev1.ConditionFalse && planner.PreflightReady.IsFalse(machine) && planner.PreflightReady.GetMessage(machine) == message {
+		var lastTransition time.Time
+		for _, c := range machine.Status.Conditions {
+			if string(c.Type) == string(planner.PreflightReady) {
+				lastTransition = c.LastTransitionTime.Time
+			}
+		}
+		if wait := preflightRetryInterval - time.Since(lastTransition); wait > 0 {
+			h.machines.EnqueueAfter(machine.Namespace, machine.Name, wait)
+			return machine, nil
+		}
+
+		attempt, _ := strconv.Atoi(machine.Annotations[planner.PreflightAttemptAnnotation])
+		machine = machine.DeepCopy()
+		if machine.Annotations == nil {
+			machine.Annotations = map[string]string{}
+		}
+		machine.Annotations[planner.PreflightAttemptAnnotation] = strconv.Itoa(attempt + 1)
+		updated, err := h.machines.Update(machine)
+		if err != nil {
+			return machine, err
+		}
+		machine = updated
+		status, reason, message = corev1.ConditionUnknown, planner.PreflightPlanStatus, planner.PreflightPlanStatusMessage
+	}
+
+	return h.setMachineCondition(machine, planner.PreflightReady, status, reason, message)
+}
+
 func (h *handler) getInfraMachineState(capiMachine *capi.Machine) (status corev1.ConditionStatus, reason, message, providerID string, err error) {
 	if capiMachine.Status.FailureReason != nil && capiMachine.Status.FailureMessage != nil {
 		return corev1.ConditionFalse, "MachineCreateFailed",
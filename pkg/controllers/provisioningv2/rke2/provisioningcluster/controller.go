@@ -22,6 +22,7 @@
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
 )
 
 const (
@@ -31,25 +32,32 @@
 )
 
 type handler struct {
-	dynamic           *dynamic.Controller
-	dynamicSchema     mgmtcontroller.DynamicSchemaCache
-	clusterCache      rocontrollers.ClusterCache
-	clusterController rocontrollers.ClusterController
-	secretCache       corecontrollers.SecretCache
-	secretClient      corecontrollers.SecretClient
-	capiClusters      capicontrollers.ClusterCache
-	rkeControlPlane   rkecontroller.RKEControlPlaneCache
+	dynamic            *dynamic.Controller
+	dynamicSchema      mgmtcontroller.DynamicSchemaCache
+	clusterCache       rocontrollers.ClusterCache
+	clusterController  rocontrollers.ClusterController
+	secretCache        corecontrollers.SecretCache
+	secretClient       corecontrollers.SecretClient
+	capiClusters       capicontrollers.ClusterCache
+	machineDeployments capicontrollers.MachineDeploymentCache
+	// machineDeploymentClient deletes the machine deployments of removed failure domains
+	machineDeploymentClient capicontrollers.MachineDeploymentClient
+	machines                capicontrollers.MachineCache
+	rkeControlPlane         rkecontroller.RKEControlPlaneCache
 }
 
 func Register(ctx context.Context, clients *wrangler.Context) {
 	h := handler{
-		dynamic:           clients.Dynamic,
-		secretCache:       clients.Core.Secret().Cache(),
-		secretClient:      clients.Core.Secret(),
-		clusterCache:      clients.Provisioning.Cluster().Cache(),
-		clusterController: clients.Provisioning.Cluster(),
-		capiClusters:      clients.CAPI.Cluster().Cache(),
-		rkeControlPlane:   clients.RKE.RKEControlPlane().Cache(),
+		dynamic:                 clients.Dynamic,
+		secretCache:             clients.Core.Secret().Cache(),
+		secretClient:            clients.Core.Secret(),
+		clusterCache:            clients.Provisioning.Cluster().Cache(),
+		clusterController:       clients.Provisioning.Cluster(),
+		capiClusters:            clients.CAPI.Cluster().Cache(),
+		machineDeployments:      clients.CAPI.MachineDeployment().Cache(),
+		machineDeploymentClient: clients.CAPI.MachineDeployment(),
+		machines:                clients.CAPI.Machine().Cache(),
+		rkeControlPlane:         clients.RKE.RKEControlPlane().Cache(),
 	}
 
 	if features.MCM.Enabled() {
@@ -83,8 +91,21 @@ func Register(ctx context.Context, clients *wrangler.Context) {
 				Name:      cp.Spec.ClusterName,
 			}}, nil
 		}
+		if machine, ok := obj.(*capi.Machine); ok && machine.Labels[capi.ClusterLabelName] != "" {
+			return []relatedresource.Key{{
+				Namespace: namespace,
+				Name:      machine.Labels[capi.ClusterLabelName],
+			}}, nil
+		}
+		// the deployments of removed failure domains are deleted once the other deployments are ready
+		if machineDeployment, ok := obj.(*capi.MachineDeployment); ok && machineDeployment.Labels[failureDomainPoolLabel] != "" {
+			return []relatedresource.Key{{
+				Namespace: namespace,
+				Name:      machineDeployment.Spec.ClusterName,
+			}}, nil
+		}
 		return nil, nil
-	}, clients.Provisioning.Cluster(), clients.RKE.RKEControlPlane())
+	}, clients.Provisioning.Cluster(), clients.RKE.RKEControlPlane(), clients.CAPI.Machine(), clients.CAPI.MachineDeployment())
 }
 
 func byNodeInfraIndex(obj *rancherv1.Cluster) ([]string, error) {
@@ -94,6 +115,11 @@ func byNodeInfraIndex(obj *rancherv1.Cluster) ([]string, error) {
 
 	var result []string
 	for _, np := range obj.Spec.RKEConfig.MachinePools {
+		for _, failureDomain := range np.FailureDomains {
+			if failureDomain.NodeConfig != nil {
+				result = append(result, toInfraRefKey(*failureDomain.NodeConfig, obj.Namespace))
+			}
+		}
 		if np.NodeConfig == nil {
 			continue
 		}
@@ -167,8 +193,17 @@ func (h *handler) OnRancherClusterChange(obj *rancherv1.Cluster, status rancherv
 		return nil, status, err
 	}
 
-	objs, err := objects(obj, h.dynamic, h.dynamicSchema, h.secretCache)
-	return objs, status, err
+	status, err = h.updateMachineRemediationStatus(obj, status)
+	if err != nil {
+		return nil, status, err
+	}
+
+	objs, err := objects(obj, h.dynamic, h.dynamicSchema, h.secretCache, h.machineDeployments)
+	if err != nil {
+		return nil, status, err
+	}
+
+	return objs, status, h.removeFailureDomains(obj, objs)
 }
 
 func (h *handler) updateClusterProvisioningStatus(cluster *rancherv1.Cluster, status rancherv1.ClusterStatus) (rancherv1.ClusterStatus, error) {
@@ -0,0 +1,76 @@
+package provisioningcluster
+
+import (
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	apierror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+// removeFailureDomains deletes the machine deployments of removed failure domains, which are not pruned with
+// the other objects of the cluster. The machines of a removed domain are added to the remaining domains of the
+// pool first, so its deployment is only deleted once the deployments of the pool have all their replicas ready.
+// The deployments of a removed pool are deleted right away.
+func (h *handler) removeFailureDomains(cluster *rancherv1.Cluster, objs []runtime.Object) error {
+	desired := map[string][]*capi.MachineDeployment{}
+	desiredNames := map[string]bool{}
+	for _, obj := range objs {
+		machineDeployment, ok := obj.(*capi.MachineDeployment)
+		if !ok {
+			continue
+		}
+		pool := machineDeployment.Labels[failureDomainPoolLabel]
+		if pool == "" {
+			pool = machineDeployment.Name
+		}
+		desired[pool] = append(desired[pool], machineDeployment)
+		desiredNames[machineDeployment.Name] = true
+	}
+
+	machineDeployments, err := h.machineDeployments.List(cluster.Namespace, labels.Everything())
+	if err != nil {
+		return err
+	}
+	for _, machineDeployment := range machineDeployments {
+		pool := machineDeployment.Labels[failureDomainPoolLabel]
+		if pool == "" || machineDeployment.Spec.ClusterName != cluster.Name || desiredNames[machineDeployment.Name] ||
+			machineDeployment.DeletionTimestamp != nil {
+			continue
+		}
+		ready, err := h.deploymentsReady(desired[pool])
+		if err != nil {
+			return err
+		}
+		if !ready {
+			continue
+		}
+		err = h.machineDeploymentClient.Delete(machineDeployment.Namespace, machineDeployment.Name, &metav1.DeleteOptions{})
+		if err != nil && !apierror.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// deploymentsReady returns true if the machine deployments have all the replicas they are supposed to have ready
+func (h *handler) deploymentsReady(desired []*capi.MachineDeployment) (bool, error) {
+	for _, machineDeployment := range desired {
+		existing, err := h.machineDeployments.Get(machineDeployment.Namespace, machineDeployment.Name)
+		if apierror.IsNotFound(err) {
+			return false, nil
+		} else if err != nil {
+			return false, err
+		}
+		var replicas int32
+		if machineDeployment.Spec.Replicas != nil {
+			replicas = *machineDeployment.Spec.Replicas
+		}
+		if existing.Spec.Replicas == nil || *existing.Spec.Replicas != replicas || existing.Status.ReadyReplicas < replicas ||
+			existing.Status.UpdatedReplicas < replicas {
+			return false, nil
+		}
+	}
+	return true, nil
+}
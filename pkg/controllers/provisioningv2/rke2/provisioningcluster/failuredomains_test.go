@@ -0,0 +1,101 @@
+package provisioningcluster
+
+import (
+	"testing"
+
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1alpha4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+type fakeMachineDeploymentClient struct {
+	capicontrollers.MachineDeploymentClient
+	deleted []string
+}
+
+func (f *fakeMachineDeploymentClient) Delete(namespace, name string, options *metav1.DeleteOptions) error {
+	f.deleted = append(f.deleted, name)
+	return nil
+}
+
+func readyMachineDeployment(name string, replicas int32, failureDomain string) *capi.MachineDeployment {
+	md := newMachineDeployment(name, replicas, failureDomain)
+	md.Status.ReadyReplicas = replicas
+	md.Status.UpdatedReplicas = replicas
+	return md
+}
+
+func TestRemoveFailureDomains(t *testing.T) {
+	cluster := &rancherv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "fleet-default"}}
+	desired := []runtime.Object{
+		newMachineDeployment("c-pool-b", 3, "b"),
+		newMachineDeployment("c-pool-c", 3, "c"),
+	}
+
+	tests := []struct {
+		name     string
+		existing []*capi.MachineDeployment
+		desired  []runtime.Object
+		deleted  []string
+	}{
+		{
+			name: "remaining domains are scaling up",
+			existing: []*capi.MachineDeployment{
+				readyMachineDeployment("c-pool", 2, "a"),
+				readyMachineDeployment("c-pool-b", 2, "b"),
+				readyMachineDeployment("c-pool-c", 2, "c"),
+			},
+			desired: desired,
+		},
+		{
+			name: "remaining domains are ready",
+			existing: []*capi.MachineDeployment{
+				readyMachineDeployment("c-pool", 2, "a"),
+				readyMachineDeployment("c-pool-b", 3, "b"),
+				readyMachineDeployment("c-pool-c", 3, "c"),
+			},
+			desired: desired,
+			deleted: []string{"c-pool"},
+		},
+		{
+			name: "pool was removed",
+			existing: []*capi.MachineDeployment{
+				readyMachineDeployment("c-pool", 2, "a"),
+				readyMachineDeployment("c-pool-b", 2, "b"),
+			},
+			deleted: []string{"c-pool", "c-pool-b"},
+		},
+		{
+			name: "deployment without failure domain",
+			existing: []*capi.MachineDeployment{
+				readyMachineDeployment("c-other", 2, ""),
+			},
+		},
+		{
+			name: "deployment of another cluster",
+			existing: []*capi.MachineDeployment{
+				func() *capi.MachineDeployment {
+					md := readyMachineDeployment("d-pool", 2, "a")
+					md.Spec.ClusterName = "d"
+					return md
+				}(),
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cache := fakeMachineDeploymentCache{items: map[string]*capi.MachineDeployment{}}
+			for _, md := range tt.existing {
+				cache.items[md.Name] = md
+			}
+			client := &fakeMachineDeploymentClient{}
+			h := &handler{machineDeployments: cache, machineDeploymentClient: client}
+			require.NoError(t, h.removeFailureDomains(cluster, tt.desired))
+			assert.ElementsMatch(t, tt.deleted, client.deleted)
+		})
+	}
+}
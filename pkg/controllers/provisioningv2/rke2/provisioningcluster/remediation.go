@@ -0,0 +1,98 @@
+package provisioningcluster
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	"github.com/rancher/wrangler/pkg/condition"
+	"github.com/rancher/wrangler/pkg/name"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+const MachinesHealthy = condition.Cond("MachinesHealthy")
+
+// updateMachineRemediationStatus lists the machines that failed the health check of their pool and are
+// remediated by the machine set controller.
+func (h *handler) updateMachineRemediationStatus(cluster *rancherv1.Cluster, status rancherv1.ClusterStatus) (rancherv1.ClusterStatus, error) {
+	if !hasMachineHealthChecks(cluster) && MachinesHealthy.GetStatus(&status) == "" {
+		status.MachineRemediations = nil
+		return status, nil
+	}
+
+	machines, err := h.machines.List(cluster.Namespace, labels.SelectorFromSet(map[string]string{
+		capi.ClusterLabelName: cluster.Name,
+	}))
+	if err != nil {
+		return status, err
+	}
+
+	machinePools := map[string]string{}
+	for _, machinePool := range cluster.Spec.RKEConfig.MachinePools {
+		machinePools[name.SafeConcatName(cluster.Name, machinePool.Name)] = machinePool.Name
+		for _, failureDomain := range machinePool.FailureDomains {
+			machinePools[name.SafeConcatName(cluster.Name, machinePool.Name, failureDomain.Name)] = machinePool.Name
+		}
+	}
+
+	var remediations []rancherv1.MachineRemediation
+	for _, machine := range machines {
+		healthCheck := getMachineCondition(machine, capi.MachineHealthCheckSuccededCondition)
+		if healthCheck == nil || healthCheck.Status != corev1.ConditionFalse {
+			continue
+		}
+		remediation := rancherv1.MachineRemediation{
+			MachineName:     machine.Name,
+			MachinePoolName: machinePools[machine.Labels[capi.MachineDeploymentLabelName]],
+			Reason:          healthCheck.Reason,
+			Message:         healthCheck.Message,
+			Time:            healthCheck.LastTransitionTime,
+		}
+		if machine.Status.NodeRef != nil {
+			remediation.NodeName = machine.Status.NodeRef.Name
+		}
+		remediations = append(remediations, remediation)
+	}
+
+	sort.Slice(remediations, func(i, j int) bool {
+		return remediations[i].MachineName < remediations[j].MachineName
+	})
+	status.MachineRemediations = remediations
+
+	if len(remediations) == 0 {
+		MachinesHealthy.SetStatus(&status, "True")
+		MachinesHealthy.Reason(&status, "")
+		MachinesHealthy.Message(&status, "")
+		return status, nil
+	}
+
+	var names []string
+	for _, remediation := range remediations {
+		names = append(names, remediation.MachineName)
+	}
+	MachinesHealthy.SetStatus(&status, "False")
+	MachinesHealthy.Reason(&status, "Remediating")
+	MachinesHealthy.Message(&status, fmt.Sprintf("remediating unhealthy machine(s) %s", strings.Join(names, ",")))
+	return status, nil
+}
+
+func hasMachineHealthChecks(cluster *rancherv1.Cluster) bool {
+	for _, machinePool := range cluster.Spec.RKEConfig.MachinePools {
+		if machinePool.UnhealthyNodeTimeout != nil && machinePool.UnhealthyNodeTimeout.Duration > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+func getMachineCondition(machine *capi.Machine, conditionType capi.ConditionType) *capi.Condition {
+	for i := range machine.Status.Conditions {
+		if machine.Status.Conditions[i].Type == conditionType {
+			return &machine.Status.Conditions[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,120 @@
+package provisioningcluster
+
+import (
+	"testing"
+	"time"
+
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1alpha4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+type fakeMachineCache struct {
+	capicontrollers.MachineCache
+	machines []*capi.Machine
+}
+
+func (f *fakeMachineCache) List(namespace string, selector labels.Selector) ([]*capi.Machine, error) {
+	var result []*capi.Machine
+	for _, machine := range f.machines {
+		if machine.Namespace == namespace && selector.Matches(labels.Set(machine.Labels)) {
+			result = append(result, machine)
+		}
+	}
+	return result, nil
+}
+
+func newRemediationMachine(name, deployment string, healthCheck corev1.ConditionStatus) *capi.Machine {
+	machine := &capi.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "fleet-default",
+			Labels: map[string]string{
+				capi.ClusterLabelName:           "c",
+				capi.MachineDeploymentLabelName: deployment,
+			},
+		},
+		Status: capi.MachineStatus{
+			NodeRef: &corev1.ObjectReference{Name: name + "-node"},
+		},
+	}
+	if healthCheck != "" {
+		machine.Status.Conditions = capi.Conditions{{
+			Type:    capi.MachineHealthCheckSuccededCondition,
+			Status:  healthCheck,
+			Reason:  capi.UnhealthyNodeConditionReason,
+			Message: "Condition Ready on node is reporting status False for more than 5m0s",
+		}}
+	}
+	return machine
+}
+
+func newRemediationCluster() *rancherv1.Cluster {
+	pool := newFailureDomainPool(3, "a", "b")
+	pool.UnhealthyNodeTimeout = &metav1.Duration{Duration: 5 * time.Minute}
+	return &rancherv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "fleet-default"},
+		Spec: rancherv1.ClusterSpec{
+			RKEConfig: &rancherv1.RKEConfig{MachinePools: []rancherv1.RKEMachinePool{pool}},
+		},
+	}
+}
+
+func TestUpdateMachineRemediationStatus(t *testing.T) {
+	machines := &fakeMachineCache{machines: []*capi.Machine{
+		newRemediationMachine("m3", "c-pool-b", corev1.ConditionFalse),
+		newRemediationMachine("m1", "c-pool", corev1.ConditionFalse),
+		newRemediationMachine("m2", "c-pool", corev1.ConditionTrue),
+		newRemediationMachine("m4", "c-pool-b", ""),
+	}}
+	h := &handler{machines: machines}
+
+	status, err := h.updateMachineRemediationStatus(newRemediationCluster(), rancherv1.ClusterStatus{})
+	require.NoError(t, err)
+	require.Len(t, status.MachineRemediations, 2)
+	assert.Equal(t, "m1", status.MachineRemediations[0].MachineName)
+	assert.Equal(t, "m1-node", status.MachineRemediations[0].NodeName)
+	assert.Equal(t, "pool", status.MachineRemediations[0].MachinePoolName)
+	assert.Equal(t, capi.UnhealthyNodeConditionReason, status.MachineRemediations[0].Reason)
+	assert.Equal(t, "m3", status.MachineRemediations[1].MachineName)
+	assert.Equal(t, "pool", status.MachineRemediations[1].MachinePoolName, "machines of failure domains belong to their pool")
+	assert.Equal(t, "False", MachinesHealthy.GetStatus(&status))
+	assert.Equal(t, "Remediating", MachinesHealthy.GetReason(&status))
+	assert.Equal(t, "remediating unhealthy machine(s) m1,m3", MachinesHealthy.GetMessage(&status))
+
+	// once the machines are replaced the cluster is healthy again
+	machines.machines = []*capi.Machine{newRemediationMachine("m5", "c-pool", corev1.ConditionTrue)}
+	status, err = h.updateMachineRemediationStatus(newRemediationCluster(), status)
+	require.NoError(t, err)
+	assert.Empty(t, status.MachineRemediations)
+	assert.Equal(t, "True", MachinesHealthy.GetStatus(&status))
+	assert.Equal(t, "", MachinesHealthy.GetMessage(&status))
+}
+
+func TestUpdateMachineRemediationStatusWithoutHealthChecks(t *testing.T) {
+	h := &handler{machines: &fakeMachineCache{machines: []*capi.Machine{
+		newRemediationMachine("m1", "c-pool", corev1.ConditionFalse),
+	}}}
+	cluster := newRemediationCluster()
+	cluster.Spec.RKEConfig.MachinePools[0].UnhealthyNodeTimeout = nil
+
+	// a cluster that never had health checks doesn't get the condition
+	status, err := h.updateMachineRemediationStatus(cluster, rancherv1.ClusterStatus{
+		MachineRemediations: []rancherv1.MachineRemediation{{MachineName: "m1"}},
+	})
+	require.NoError(t, err)
+	assert.Nil(t, status.MachineRemediations)
+	assert.Equal(t, "", MachinesHealthy.GetStatus(&status))
+
+	// a cluster that has the condition keeps updating it after its health checks are disabled
+	MachinesHealthy.SetStatus(&status, "False")
+	status, err = h.updateMachineRemediationStatus(cluster, status)
+	require.NoError(t, err)
+	require.Len(t, status.MachineRemediations, 1)
+	assert.Equal(t, "m1", status.MachineRemediations[0].MachineName)
+}
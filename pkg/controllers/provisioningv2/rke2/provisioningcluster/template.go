@@ -5,12 +5,15 @@
 	"encoding/hex"
 	"encoding/json"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/rancher/lasso/pkg/dynamic"
 	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
 	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
 	"github.com/rancher/rancher/pkg/controllers/provisioningv2/rke2/machineprovision"
+	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1alpha4"
 	mgmtcontroller "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/provisioningv2/rke2/planner"
 	"github.com/rancher/wrangler/pkg/apply"
@@ -26,9 +29,18 @@
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
 )
 
+const (
+	// failureDomainPoolLabel is the name of the pool deployment of the machine deployments of failure domains
+	failureDomainPoolLabel = "rke.cattle.io/failure-domain-pool"
+
+	autoscalerMinSizeAnnotation = "cluster.x-k8s.io/cluster-api-autoscaler-node-group-min-size"
+	autoscalerMaxSizeAnnotation = "cluster.x-k8s.io/cluster-api-autoscaler-node-group-max-size"
+)
+
 func getInfraRef(rkeCluster *rkev1.RKECluster) *corev1.ObjectReference {
 	gvk, _ := gvk.Get(rkeCluster)
 	infraRef := &corev1.ObjectReference{
@@ -38,7 +50,8 @@ func getInfraRef(rkeCluster *rkev1.RKECluster) *corev1.ObjectReference {
 	return infraRef
 }
 
-func objects(cluster *rancherv1.Cluster, dynamic *dynamic.Controller, dynamicSchema mgmtcontroller.DynamicSchemaCache, secrets v1.SecretCache) (result []runtime.Object, _ error) {
+func objects(cluster *rancherv1.Cluster, dynamic *dynamic.Controller, dynamicSchema mgmtcontroller.DynamicSchemaCache, secrets v1.SecretCache,
+	machineDeploymentCache capicontrollers.MachineDeploymentCache) (result []runtime.Object, _ error) {
 	infraRef := cluster.Spec.RKEConfig.InfrastructureRef
 	if infraRef == nil {
 		rkeCluster := rkeCluster(cluster)
@@ -52,7 +65,7 @@ func objects(cluster *rancherv1.Cluster, dynamic *dynamic.Controller, dynamicSch
 	capiCluster := capiCluster(cluster, rkeControlPlane, infraRef)
 	result = append(result, capiCluster)
 
-	machineDeployments, err := machineDeployments(cluster, capiCluster, dynamic, dynamicSchema, secrets)
+	machineDeployments, err := machineDeployments(cluster, capiCluster, dynamic, dynamicSchema, secrets, machineDeploymentCache)
 	if err != nil {
 		return nil, err
 	}
@@ -208,7 +221,7 @@ func createMachineTemplateHash(dataMap map[string]interface{}) string {
 }
 
 func machineDeployments(cluster *rancherv1.Cluster, capiCluster *capi.Cluster, dynamic *dynamic.Controller,
-	dynamicSchema mgmtcontroller.DynamicSchemaCache, secrets v1.SecretCache) (result []runtime.Object, _ error) {
+	dynamicSchema mgmtcontroller.DynamicSchemaCache, secrets v1.SecretCache, machineDeploymentCache capicontrollers.MachineDeploymentCache) (result []runtime.Object, _ error) {
 	bootstrapName := name.SafeConcatName(cluster.Name, "bootstrap", "template")
 
 	if dynamicSchema == nil {
@@ -234,10 +247,11 @@ func machineDeployments(cluster *rancherv1.Cluster, capiCluster *capi.Cluster, d
 
 	machinePoolNames := map[string]bool{}
 	for _, machinePool := range cluster.Spec.RKEConfig.MachinePools {
-		if machinePool.Quantity != nil && *machinePool.Quantity == 0 {
+		// an autoscaled pool keeps its deployment at zero machines so cluster-autoscaler can scale it up
+		if machinePool.Quantity != nil && *machinePool.Quantity == 0 && !isAutoscaled(machinePool) {
 			continue
 		}
-		if machinePool.Name == "" || machinePool.NodeConfig == nil || machinePool.NodeConfig.Name == "" || machinePool.NodeConfig.Kind == "" {
+		if machinePool.Name == "" || (len(machinePool.FailureDomains) == 0 && !validNodeConfig(machinePool.NodeConfig)) {
 			return nil, fmt.Errorf("invalid machinePool [%s] missing name or valid config", machinePool.Name)
 		}
 		if !machinePool.EtcdRole &&
@@ -251,112 +265,451 @@ func machineDeployments(cluster *rancherv1.Cluster, capiCluster *capi.Cluster, d
 		}
 		machinePoolNames[machinePool.Name] = true
 
-		var (
-			machinePoolName = name.SafeConcatName(cluster.Name, machinePool.Name)
-			infraRef        corev1.ObjectReference
-		)
+		if err := validateAutoscaling(machinePool); err != nil {
+			return nil, err
+		}
+
+		if err := validateFailureDomains(machinePool); err != nil {
+			return nil, err
+		}
 
-		if machinePool.NodeConfig.APIVersion == "" || machinePool.NodeConfig.APIVersion == "rke-machine-config.cattle.io/v1" {
-			machineTemplate, err := toMachineTemplate(machinePoolName, cluster, machinePool, dynamic, dynamicSchema, secrets)
-			if err != nil {
-				return nil, err
+		deployments, err := poolDeployments(cluster, machinePool, machineDeploymentCache)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, deployment := range deployments {
+			var (
+				machinePoolName = deployment.name
+				machinePool     = deployment.machinePool
+				infraRef        corev1.ObjectReference
+			)
+
+			if machinePool.NodeConfig.APIVersion == "" || machinePool.NodeConfig.APIVersion == "rke-machine-config.cattle.io/v1" {
+				machineTemplate, err := toMachineTemplate(machinePoolName, cluster, machinePool, dynamic, dynamicSchema, secrets)
+				if err != nil {
+					return nil, err
+				}
+
+				result = append(result, machineTemplate)
+				infraRef = corev1.ObjectReference{
+					APIVersion: machineTemplate.GetAPIVersion(),
+					Kind:       machineTemplate.GetKind(),
+					Namespace:  machineTemplate.GetNamespace(),
+					Name:       machineTemplate.GetName(),
+				}
+			} else {
+				infraRef = *machinePool.NodeConfig
 			}
 
-			result = append(result, machineTemplate)
-			infraRef = corev1.ObjectReference{
-				APIVersion: machineTemplate.GetAPIVersion(),
-				Kind:       machineTemplate.GetKind(),
-				Namespace:  machineTemplate.GetNamespace(),
-				Name:       machineTemplate.GetName(),
+			machineDeploymentLabels := map[string]string{}
+			for k, v := range machinePool.Labels {
+				machineDeploymentLabels[k] = v
+			}
+			for k, v := range machinePool.MachineDeploymentLabels {
+				machineDeploymentLabels[k] = v
 			}
-		} else {
-			infraRef = *machinePool.NodeConfig
-		}
 
-		machineDeploymentLabels := map[string]string{}
-		for k, v := range machinePool.Labels {
-			machineDeploymentLabels[k] = v
-		}
-		for k, v := range machinePool.MachineDeploymentLabels {
-			machineDeploymentLabels[k] = v
-		}
+			machineDeploymentAnnotations := map[string]string{}
+			for k, v := range machinePool.MachineDeploymentAnnotations {
+				machineDeploymentAnnotations[k] = v
+			}
+			if machinePool.MachineProvisionMaxAttempts != nil {
+				machineDeploymentAnnotations[machineprovision.MaxAttemptsAnnotation] = strconv.Itoa(int(*machinePool.MachineProvisionMaxAttempts))
+			}
+			if machinePool.ReplaceFailedMachines {
+				machineDeploymentAnnotations[machineprovision.ReplaceFailedAnnotation] = "true"
+			}
 
-		machineDeployment := &capi.MachineDeployment{
-			ObjectMeta: metav1.ObjectMeta{
-				Namespace:   cluster.Namespace,
-				Name:        machinePoolName,
-				Labels:      machineDeploymentLabels,
-				Annotations: machinePool.MachineDeploymentAnnotations,
-			},
-			Spec: capi.MachineDeploymentSpec{
-				ClusterName: capiCluster.Name,
-				Replicas:    machinePool.Quantity,
-				Template: capi.MachineTemplateSpec{
-					ObjectMeta: capi.ObjectMeta{
-						Labels: map[string]string{
-							capi.ClusterLabelName:           capiCluster.Name,
-							capi.MachineDeploymentLabelName: machinePoolName,
+			replicas := machinePool.Quantity
+			if isAutoscaled(machinePool) {
+				machineDeploymentAnnotations[autoscalerMinSizeAnnotation] = strconv.Itoa(int(*machinePool.MinSize))
+				machineDeploymentAnnotations[autoscalerMaxSizeAnnotation] = strconv.Itoa(int(*machinePool.MaxSize))
+				autoscaled, err := autoscaledReplicas(machineDeploymentCache, cluster.Namespace, machinePoolName, machinePool)
+				if err != nil {
+					return nil, err
+				}
+				replicas = autoscaled
+			}
+
+			machineDeployment := &capi.MachineDeployment{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace:   cluster.Namespace,
+					Name:        machinePoolName,
+					Labels:      machineDeploymentLabels,
+					Annotations: machineDeploymentAnnotations,
+				},
+				Spec: capi.MachineDeploymentSpec{
+					ClusterName: capiCluster.Name,
+					Replicas:    replicas,
+					Template: capi.MachineTemplateSpec{
+						ObjectMeta: capi.ObjectMeta{
+							Labels: map[string]string{
+								capi.ClusterLabelName:           capiCluster.Name,
+								capi.MachineDeploymentLabelName: machinePoolName,
+							},
+							Annotations: map[string]string{},
 						},
-						Annotations: map[string]string{},
-					},
-					Spec: capi.MachineSpec{
-						ClusterName: capiCluster.Name,
-						Bootstrap: capi.Bootstrap{
-							ConfigRef: &corev1.ObjectReference{
-								Kind:       "RKEBootstrapTemplate",
-								Namespace:  cluster.Namespace,
-								Name:       bootstrapName,
-								APIVersion: "rke.cattle.io/v1",
+						Spec: capi.MachineSpec{
+							ClusterName: capiCluster.Name,
+							Bootstrap: capi.Bootstrap{
+								ConfigRef: &corev1.ObjectReference{
+									Kind:       "RKEBootstrapTemplate",
+									Namespace:  cluster.Namespace,
+									Name:       bootstrapName,
+									APIVersion: "rke.cattle.io/v1",
+								},
 							},
+							InfrastructureRef: infraRef,
 						},
-						InfrastructureRef: infraRef,
 					},
-				},
-				Paused: machinePool.Paused,
-			},
-		}
-		if machinePool.RollingUpdate != nil {
-			machineDeployment.Spec.Strategy = &capi.MachineDeploymentStrategy{
-				Type: capi.RollingUpdateMachineDeploymentStrategyType,
-				RollingUpdate: &capi.MachineRollingUpdateDeployment{
-					MaxUnavailable: machinePool.RollingUpdate.MaxUnavailable,
-					MaxSurge:       machinePool.RollingUpdate.MaxSurge,
+					Paused: machinePool.Paused,
 				},
 			}
-		}
+			if deployment.failureDomain != "" {
+				failureDomain := deployment.failureDomain
+				machineDeployment.Spec.Template.Spec.FailureDomain = &failureDomain
+				// removeFailureDomains deletes the deployment once its domain is removed and the machines
+				// of the other domains are ready
+				machineDeployment.Labels[failureDomainPoolLabel] = name.SafeConcatName(cluster.Name, machinePool.Name)
+				machineDeployment.Labels[apply.LabelPrune] = "false"
+			}
+			if machinePool.RollingUpdate != nil {
+				machineDeployment.Spec.Strategy = &capi.MachineDeploymentStrategy{
+					Type: capi.RollingUpdateMachineDeploymentStrategyType,
+					RollingUpdate: &capi.MachineRollingUpdateDeployment{
+						MaxUnavailable: machinePool.RollingUpdate.MaxUnavailable,
+						MaxSurge:       machinePool.RollingUpdate.MaxSurge,
+					},
+				}
+			}
+
+			if machinePool.EtcdRole {
+				machineDeployment.Spec.Template.Labels[planner.EtcdRoleLabel] = "true"
+			}
 
-		if machinePool.EtcdRole {
-			machineDeployment.Spec.Template.Labels[planner.EtcdRoleLabel] = "true"
+			if machinePool.ControlPlaneRole {
+				machineDeployment.Spec.Template.Labels[planner.ControlPlaneRoleLabel] = "true"
+				machineDeployment.Spec.Template.Labels[capi.MachineControlPlaneLabelName] = "true"
+			}
+
+			if machinePool.WorkerRole {
+				machineDeployment.Spec.Template.Labels[planner.WorkerRoleLabel] = "true"
+			}
+
+			if len(machinePool.Labels) > 0 {
+				for k, v := range machinePool.Labels {
+					machineDeployment.Spec.Template.Labels[k] = v
+				}
+				if err := assign(machineDeployment.Spec.Template.Annotations, planner.LabelsAnnotation, machinePool.Labels); err != nil {
+					return nil, err
+				}
+			}
+
+			if len(machinePool.Taints) > 0 {
+				if err := assign(machineDeployment.Spec.Template.Annotations, planner.TaintsAnnotation, machinePool.Taints); err != nil {
+					return nil, err
+				}
+			}
+
+			result = append(result, machineDeployment)
 		}
 
-		if machinePool.ControlPlaneRole {
-			machineDeployment.Spec.Template.Labels[planner.ControlPlaneRoleLabel] = "true"
-			machineDeployment.Spec.Template.Labels[capi.MachineControlPlaneLabelName] = "true"
+		machineHealthCheck, err := machineHealthCheck(cluster, capiCluster, machinePool, deployments)
+		if err != nil {
+			return nil, err
 		}
+		if machineHealthCheck != nil {
+			result = append(result, machineHealthCheck)
+		}
+	}
+
+	return result, nil
+}
+
+type poolDeployment struct {
+	name          string
+	failureDomain string
+	machinePool   rancherv1.RKEMachinePool
+}
+
+// poolDeployments returns a machine deployment per failure domain of the pool, each with the machine config
+// of the domain and its share of the quantity, or a single deployment when the pool has no failure domains.
+// One failure domain keeps the deployment named after the pool, so adding failure domains to a pool rolls its
+// machines over instead of deleting them. The deployments of removed domains are handled by
+// removeFailureDomains.
+func poolDeployments(cluster *rancherv1.Cluster, machinePool rancherv1.RKEMachinePool, machineDeploymentCache capicontrollers.MachineDeploymentCache) ([]poolDeployment, error) {
+	machinePoolName := name.SafeConcatName(cluster.Name, machinePool.Name)
+	if len(machinePool.FailureDomains) == 0 {
+		return []poolDeployment{{
+			name:        machinePoolName,
+			machinePool: machinePool,
+		}}, nil
+	}
 
-		if machinePool.WorkerRole {
-			machineDeployment.Spec.Template.Labels[planner.WorkerRoleLabel] = "true"
+	poolFailureDomain, err := poolFailureDomain(cluster, machinePool, machineDeploymentCache)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		deployments []poolDeployment
+		current     []int32
+	)
+	for _, failureDomain := range machinePool.FailureDomains {
+		deployment := poolDeployment{
+			name:          name.SafeConcatName(cluster.Name, machinePool.Name, failureDomain.Name),
+			failureDomain: failureDomain.Name,
+			machinePool:   machinePool,
+		}
+		if failureDomain.Name == poolFailureDomain {
+			deployment.name = machinePoolName
 		}
+		deployment.machinePool.NodeConfig = failureDomain.NodeConfig
+		deployments = append(deployments, deployment)
 
-		if len(machinePool.Labels) > 0 {
-			for k, v := range machinePool.Labels {
-				machineDeployment.Spec.Template.Labels[k] = v
-			}
-			if err := assign(machineDeployment.Spec.Template.Annotations, planner.LabelsAnnotation, machinePool.Labels); err != nil {
+		var replicas int32
+		if machineDeploymentCache != nil {
+			machineDeployment, err := machineDeploymentCache.Get(cluster.Namespace, deployment.name)
+			if err != nil && !apierror.IsNotFound(err) {
 				return nil, err
+			} else if err == nil && machineDeployment.Spec.Replicas != nil {
+				replicas = *machineDeployment.Spec.Replicas
 			}
 		}
+		current = append(current, replicas)
+	}
 
-		if len(machinePool.Taints) > 0 {
-			if err := assign(machineDeployment.Spec.Template.Annotations, planner.TaintsAnnotation, machinePool.Taints); err != nil {
-				return nil, err
+	quantity := int32(1)
+	if machinePool.Quantity != nil {
+		quantity = *machinePool.Quantity
+	}
+	for i, replicas := range distributeReplicas(quantity, current) {
+		replicas := replicas
+		deployments[i].machinePool.Quantity = &replicas
+	}
+
+	return deployments, nil
+}
+
+// poolFailureDomain returns the failure domain of the machine deployment named after the pool. That is the
+// domain the deployment already has, or the first domain if it has none because the pool had no failure
+// domains before. A new pool starts with the first domain. The result is not a domain of the pool if the
+// deployment belongs to a removed domain, or was deleted with it.
+func poolFailureDomain(cluster *rancherv1.Cluster, machinePool rancherv1.RKEMachinePool, machineDeploymentCache capicontrollers.MachineDeploymentCache) (string, error) {
+	first := machinePool.FailureDomains[0].Name
+	if machineDeploymentCache == nil {
+		return first, nil
+	}
+
+	machineDeployment, err := machineDeploymentCache.Get(cluster.Namespace, name.SafeConcatName(cluster.Name, machinePool.Name))
+	if apierror.IsNotFound(err) {
+		for _, failureDomain := range machinePool.FailureDomains {
+			_, err := machineDeploymentCache.Get(cluster.Namespace, name.SafeConcatName(cluster.Name, machinePool.Name, failureDomain.Name))
+			if err == nil {
+				return "", nil
+			} else if !apierror.IsNotFound(err) {
+				return "", err
 			}
 		}
+		return first, nil
+	} else if err != nil {
+		return "", err
+	}
 
-		result = append(result, machineDeployment)
+	if machineDeployment.Spec.Template.Spec.FailureDomain == nil {
+		return first, nil
 	}
+	return *machineDeployment.Spec.Template.Spec.FailureDomain, nil
+}
 
-	return result, nil
+// distributeReplicas spreads the quantity evenly across failure domains, so the domains are always within
+// one machine of each other, including a domain that was just added. The machines that don't divide evenly
+// go to the domains that currently have the most replicas, which keeps the number of machines that have to
+// move between domains as small as possible.
+func distributeReplicas(quantity int32, current []int32) []int32 {
+	result := make([]int32, len(current))
+	if len(current) == 0 {
+		return result
+	}
+
+	base, remainder := quantity/int32(len(current)), quantity%int32(len(current))
+	order := make([]int, len(current))
+	for i := range order {
+		order[i] = i
+		result[i] = base
+	}
+	sort.SliceStable(order, func(i, j int) bool {
+		return current[order[i]] > current[order[j]]
+	})
+	for _, i := range order[:remainder] {
+		result[i]++
+	}
+
+	return result
+}
+
+func validNodeConfig(nodeConfig *corev1.ObjectReference) bool {
+	return nodeConfig != nil && nodeConfig.Name != "" && nodeConfig.Kind != ""
+}
+
+func validateFailureDomains(machinePool rancherv1.RKEMachinePool) error {
+	if len(machinePool.FailureDomains) == 0 {
+		return nil
+	}
+	if isAutoscaled(machinePool) {
+		return fmt.Errorf("machinePool [%s] with failureDomains can not be autoscaled", machinePool.Name)
+	}
+	failureDomains := map[string]bool{}
+	for _, failureDomain := range machinePool.FailureDomains {
+		if failureDomain.Name == "" || !validNodeConfig(failureDomain.NodeConfig) {
+			return fmt.Errorf("invalid failureDomain [%s] of machinePool [%s] missing name or valid config", failureDomain.Name, machinePool.Name)
+		}
+		if failureDomains[failureDomain.Name] {
+			return fmt.Errorf("duplicate failureDomain name [%s] used in machinePool [%s]", failureDomain.Name, machinePool.Name)
+		}
+		failureDomains[failureDomain.Name] = true
+	}
+	return nil
+}
+
+// machineHealthCheck returns the health check of the machines of a pool, which is only enabled when the
+// pool has an unhealthy node timeout. It covers the deployments of all failure domains of the pool, so
+// MaxUnhealthy and UnhealthyRange apply to the whole pool. Without either of them, CAPI would remediate any
+// number of unhealthy machines, so etcd and control plane pools default to a MaxUnhealthy that can't take
+// etcd below quorum.
+func machineHealthCheck(cluster *rancherv1.Cluster, capiCluster *capi.Cluster, machinePool rancherv1.RKEMachinePool, deployments []poolDeployment) (*capi.MachineHealthCheck, error) {
+	if machinePool.UnhealthyNodeTimeout == nil || machinePool.UnhealthyNodeTimeout.Duration <= 0 {
+		return nil, nil
+	}
+
+	selector := metav1.LabelSelector{}
+	if len(deployments) == 1 {
+		selector.MatchLabels = map[string]string{
+			capi.MachineDeploymentLabelName: deployments[0].name,
+		}
+	} else {
+		var names []string
+		for _, deployment := range deployments {
+			names = append(names, deployment.name)
+		}
+		selector.MatchExpressions = []metav1.LabelSelectorRequirement{
+			{
+				Key:      capi.MachineDeploymentLabelName,
+				Operator: metav1.LabelSelectorOpIn,
+				Values:   names,
+			},
+		}
+	}
+
+	healthCheck := &capi.MachineHealthCheck{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster.Namespace,
+			Name:      name.SafeConcatName(cluster.Name, machinePool.Name),
+		},
+		Spec: capi.MachineHealthCheckSpec{
+			ClusterName: capiCluster.Name,
+			Selector:    selector,
+			UnhealthyConditions: []capi.UnhealthyCondition{
+				{
+					Type:    corev1.NodeReady,
+					Status:  corev1.ConditionFalse,
+					Timeout: *machinePool.UnhealthyNodeTimeout,
+				},
+				{
+					Type:    corev1.NodeReady,
+					Status:  corev1.ConditionUnknown,
+					Timeout: *machinePool.UnhealthyNodeTimeout,
+				},
+			},
+			UnhealthyRange:     machinePool.UnhealthyRange,
+			NodeStartupTimeout: machinePool.NodeStartupTimeout,
+		},
+	}
+
+	if machinePool.MaxUnhealthy != nil {
+		maxUnhealthy := intstr.Parse(*machinePool.MaxUnhealthy)
+		if maxUnhealthy.Type == intstr.String && !strings.HasSuffix(maxUnhealthy.StrVal, "%") {
+			return nil, fmt.Errorf("invalid maxUnhealthy [%s] for machinePool [%s], must be a number or a percentage", *machinePool.MaxUnhealthy, machinePool.Name)
+		}
+		healthCheck.Spec.MaxUnhealthy = &maxUnhealthy
+	} else if machinePool.UnhealthyRange == nil && (machinePool.EtcdRole || machinePool.ControlPlaneRole) {
+		maxUnhealthy := intstr.FromInt(quorumSafeMaxUnhealthy(cluster, machinePool))
+		healthCheck.Spec.MaxUnhealthy = &maxUnhealthy
+	}
+
+	return healthCheck, nil
+}
+
+// quorumSafeMaxUnhealthy is the number of unhealthy machines an etcd or control plane pool may remediate at
+// once. Remediation deletes the machine, so an etcd pool only remediates when the remaining members keep
+// quorum, which needs at least three members, and never more than one machine at a time.
+func quorumSafeMaxUnhealthy(cluster *rancherv1.Cluster, machinePool rancherv1.RKEMachinePool) int {
+	if !machinePool.EtcdRole {
+		return 1
+	}
+
+	var members int32
+	for _, machinePool := range cluster.Spec.RKEConfig.MachinePools {
+		if !machinePool.EtcdRole {
+			continue
+		}
+		if machinePool.Quantity == nil {
+			members++
+		} else {
+			members += *machinePool.Quantity
+		}
+	}
+	if members < 3 {
+		return 0
+	}
+	return 1
+}
+
+func isAutoscaled(machinePool rancherv1.RKEMachinePool) bool {
+	return machinePool.MinSize != nil || machinePool.MaxSize != nil
+}
+
+func validateAutoscaling(machinePool rancherv1.RKEMachinePool) error {
+	if !isAutoscaled(machinePool) {
+		return nil
+	}
+	if machinePool.MinSize == nil || machinePool.MaxSize == nil {
+		return fmt.Errorf("both minSize and maxSize must be set to autoscale machinePool [%s]", machinePool.Name)
+	}
+	if *machinePool.MinSize < 0 || *machinePool.MaxSize < *machinePool.MinSize {
+		return fmt.Errorf("invalid minSize [%d] and maxSize [%d] for machinePool [%s]", *machinePool.MinSize, *machinePool.MaxSize, machinePool.Name)
+	}
+	if machinePool.EtcdRole || machinePool.ControlPlaneRole {
+		return fmt.Errorf("only worker machinePools can be autoscaled, machinePool [%s] has the etcd or control-plane role", machinePool.Name)
+	}
+	return nil
+}
+
+// autoscaledReplicas keeps the replicas cluster-autoscaler set on an existing MachineDeployment so they
+// are not reverted to the quantity of the pool. The quantity is only used for a new MachineDeployment.
+// The result is always within the bounds of the pool.
+func autoscaledReplicas(machineDeploymentCache capicontrollers.MachineDeploymentCache, namespace, name string, machinePool rancherv1.RKEMachinePool) (*int32, error) {
+	replicas := *machinePool.MinSize
+	if machinePool.Quantity != nil {
+		replicas = *machinePool.Quantity
+	}
+
+	if machineDeploymentCache != nil {
+		machineDeployment, err := machineDeploymentCache.Get(namespace, name)
+		if err != nil && !apierror.IsNotFound(err) {
+			return nil, err
+		} else if err == nil && machineDeployment.Spec.Replicas != nil {
+			replicas = *machineDeployment.Spec.Replicas
+		}
+	}
+
+	if replicas < *machinePool.MinSize {
+		replicas = *machinePool.MinSize
+	} else if replicas > *machinePool.MaxSize {
+		replicas = *machinePool.MaxSize
+	}
+	return &replicas, nil
 }
 
 func assign(labels map[string]string, key string, value interface{}) error {
@@ -390,6 +743,9 @@ func rkeControlPlane(cluster *rancherv1.Cluster) *rkev1.RKEControlPlane {
 			RKEClusterSpecCommon:  *cluster.Spec.RKEConfig.RKEClusterSpecCommon.DeepCopy(),
 			ETCDSnapshotRestore:   cluster.Spec.RKEConfig.ETCDSnapshotRestore.DeepCopy(),
 			ETCDSnapshotCreate:    cluster.Spec.RKEConfig.ETCDSnapshotCreate.DeepCopy(),
+			RotateCertificates:    cluster.Spec.RKEConfig.RotateCertificates.DeepCopy(),
+			RotateEncryptionKeys:  cluster.Spec.RKEConfig.RotateEncryptionKeys.DeepCopy(),
+			Adoption:              cluster.Spec.RKEConfig.Adoption.DeepCopy(),
 			KubernetesVersion:     cluster.Spec.KubernetesVersion,
 			ManagementClusterName: cluster.Status.ClusterName,
 			AgentEnvVars:          cluster.Spec.AgentEnvVars,
@@ -0,0 +1,343 @@
+package provisioningcluster
+
+import (
+	"testing"
+	"time"
+
+	rancherv1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1alpha4"
+	mgmtcontroller "github.com/rancher/rancher/pkg/generated/controllers/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+type fakeMachineDeploymentCache struct {
+	capicontrollers.MachineDeploymentCache
+	items map[string]*capi.MachineDeployment
+}
+
+func (f fakeMachineDeploymentCache) Get(namespace, name string) (*capi.MachineDeployment, error) {
+	if md, ok := f.items[name]; ok {
+		return md, nil
+	}
+	return nil, apierror.NewNotFound(schema.GroupResource{Resource: "machinedeployments"}, name)
+}
+
+func (f fakeMachineDeploymentCache) List(namespace string, selector labels.Selector) ([]*capi.MachineDeployment, error) {
+	var result []*capi.MachineDeployment
+	for _, md := range f.items {
+		result = append(result, md)
+	}
+	return result, nil
+}
+
+func newMachineDeployment(name string, replicas int32, failureDomain string) *capi.MachineDeployment {
+	md := &capi.MachineDeployment{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "fleet-default", Labels: map[string]string{}},
+		Spec: capi.MachineDeploymentSpec{
+			ClusterName: "c",
+			Replicas:    &replicas,
+		},
+	}
+	if failureDomain != "" {
+		md.Spec.Template.Spec.FailureDomain = &failureDomain
+		md.Labels[failureDomainPoolLabel] = "c-pool"
+	}
+	return md
+}
+
+func newFailureDomainPool(quantity int32, domains ...string) rancherv1.RKEMachinePool {
+	pool := rancherv1.RKEMachinePool{Quantity: &quantity}
+	pool.Name = "pool"
+	for _, domain := range domains {
+		pool.FailureDomains = append(pool.FailureDomains, rancherv1.RKEMachinePoolFailureDomain{
+			Name:       domain,
+			NodeConfig: &corev1.ObjectReference{Kind: "Amazonec2Config", Name: domain},
+		})
+	}
+	return pool
+}
+
+func deploymentReplicas(deployments []poolDeployment) map[string]int32 {
+	result := map[string]int32{}
+	for _, deployment := range deployments {
+		result[deployment.name] = *deployment.machinePool.Quantity
+	}
+	return result
+}
+
+func TestDistributeReplicas(t *testing.T) {
+	tests := []struct {
+		name     string
+		quantity int32
+		current  []int32
+		want     []int32
+	}{
+		{name: "new pool", quantity: 5, current: []int32{0, 0, 0}, want: []int32{2, 2, 1}},
+		{name: "unchanged", quantity: 4, current: []int32{2, 1, 1}, want: []int32{2, 1, 1}},
+		{name: "scale up adds to the smallest domain", quantity: 5, current: []int32{2, 1, 1}, want: []int32{2, 2, 1}},
+		{name: "new domain is filled", quantity: 6, current: []int32{3, 3, 0}, want: []int32{2, 2, 2}},
+		{name: "scale up after adding a domain", quantity: 7, current: []int32{3, 3, 0}, want: []int32{3, 2, 2}},
+		{name: "uneven domains are rebalanced", quantity: 4, current: []int32{3, 1}, want: []int32{2, 2}},
+		{name: "remainder stays on the largest domain", quantity: 5, current: []int32{1, 4}, want: []int32{2, 3}},
+		{name: "scale down removes from the largest domain", quantity: 3, current: []int32{1, 2, 1}, want: []int32{1, 1, 1}},
+		{name: "scale down keeps the smaller domains", quantity: 2, current: []int32{1, 3, 0}, want: []int32{1, 1, 0}},
+		{name: "scale to zero", quantity: 0, current: []int32{1, 1}, want: []int32{0, 0}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, distributeReplicas(tt.quantity, tt.current))
+		})
+	}
+}
+
+func TestPoolDeploymentsNewPool(t *testing.T) {
+	cluster := &rancherv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "fleet-default"}}
+	deployments, err := poolDeployments(cluster, newFailureDomainPool(3, "a", "b"), fakeMachineDeploymentCache{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int32{"c-pool": 2, "c-pool-b": 1}, deploymentReplicas(deployments))
+	assert.Equal(t, "a", deployments[0].failureDomain)
+	assert.Equal(t, "a", deployments[0].machinePool.NodeConfig.Name)
+}
+
+func TestPoolDeploymentsAddFailureDomains(t *testing.T) {
+	// the pool had no failure domains, its deployment is kept for the first domain and the machines are
+	// spread evenly over both domains
+	cluster := &rancherv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "fleet-default"}}
+	cache := fakeMachineDeploymentCache{items: map[string]*capi.MachineDeployment{
+		"c-pool": newMachineDeployment("c-pool", 3, ""),
+	}}
+	deployments, err := poolDeployments(cluster, newFailureDomainPool(4, "a", "b"), cache)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int32{"c-pool": 2, "c-pool-b": 2}, deploymentReplicas(deployments))
+}
+
+func TestPoolDeploymentsReorderFailureDomains(t *testing.T) {
+	cluster := &rancherv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "fleet-default"}}
+	cache := fakeMachineDeploymentCache{items: map[string]*capi.MachineDeployment{
+		"c-pool":   newMachineDeployment("c-pool", 2, "a"),
+		"c-pool-b": newMachineDeployment("c-pool-b", 2, "b"),
+	}}
+	deployments, err := poolDeployments(cluster, newFailureDomainPool(4, "b", "a"), cache)
+	require.NoError(t, err)
+	assert.Equal(t, "c-pool-b", deployments[0].name)
+	assert.Equal(t, "c-pool", deployments[1].name)
+	assert.Equal(t, "a", deployments[1].failureDomain)
+}
+
+func TestPoolDeploymentsRemoveFailureDomain(t *testing.T) {
+	// the machines of the removed domain are added to the remaining ones, and no domain takes over the
+	// deployment of the removed one
+	cluster := &rancherv1.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "fleet-default"}}
+	cache := fakeMachineDeploymentCache{items: map[string]*capi.MachineDeployment{
+		"c-pool":   newMachineDeployment("c-pool", 2, "a"),
+		"c-pool-b": newMachineDeployment("c-pool-b", 2, "b"),
+		"c-pool-c": newMachineDeployment("c-pool-c", 2, "c"),
+	}}
+	deployments, err := poolDeployments(cluster, newFailureDomainPool(6, "b", "c"), cache)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int32{"c-pool-b": 3, "c-pool-c": 3}, deploymentReplicas(deployments))
+
+	// once the deployment of the removed domain is deleted the names stay the same
+	delete(cache.items, "c-pool")
+	deployments, err = poolDeployments(cluster, newFailureDomainPool(6, "b", "c"), cache)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int32{"c-pool-b": 3, "c-pool-c": 3}, deploymentReplicas(deployments))
+}
+
+func newHealthCheckCluster(pools ...rancherv1.RKEMachinePool) *rancherv1.Cluster {
+	return &rancherv1.Cluster{
+		ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "fleet-default"},
+		Spec: rancherv1.ClusterSpec{
+			RKEConfig: &rancherv1.RKEConfig{MachinePools: pools},
+		},
+	}
+}
+
+func newHealthCheckPool(quantity int32, etcd, controlPlane bool) rancherv1.RKEMachinePool {
+	pool := rancherv1.RKEMachinePool{
+		Quantity:             &quantity,
+		EtcdRole:             etcd,
+		ControlPlaneRole:     controlPlane,
+		WorkerRole:           !etcd && !controlPlane,
+		UnhealthyNodeTimeout: &metav1.Duration{Duration: 5 * time.Minute},
+	}
+	pool.Name = "pool"
+	return pool
+}
+
+func TestMachineHealthCheckMaxUnhealthy(t *testing.T) {
+	percent := "40%"
+	unhealthyRange := "[1-2]"
+	tests := []struct {
+		name       string
+		pool       func() rancherv1.RKEMachinePool
+		otherPools []rancherv1.RKEMachinePool
+		want       *intstr.IntOrString
+	}{
+		{
+			name: "worker pool keeps the default",
+			pool: func() rancherv1.RKEMachinePool { return newHealthCheckPool(5, false, false) },
+		},
+		{
+			name: "etcd pool with quorum remediates one machine",
+			pool: func() rancherv1.RKEMachinePool { return newHealthCheckPool(3, true, true) },
+			want: intOrStringPtr(intstr.FromInt(1)),
+		},
+		{
+			name: "single etcd machine is not remediated",
+			pool: func() rancherv1.RKEMachinePool { return newHealthCheckPool(1, true, false) },
+			want: intOrStringPtr(intstr.FromInt(0)),
+		},
+		{
+			name:       "etcd members of other pools count for quorum",
+			pool:       func() rancherv1.RKEMachinePool { return newHealthCheckPool(1, true, false) },
+			otherPools: []rancherv1.RKEMachinePool{newHealthCheckPool(2, true, false)},
+			want:       intOrStringPtr(intstr.FromInt(1)),
+		},
+		{
+			name: "control plane pool remediates one machine",
+			pool: func() rancherv1.RKEMachinePool { return newHealthCheckPool(1, false, true) },
+			want: intOrStringPtr(intstr.FromInt(1)),
+		},
+		{
+			name: "maxUnhealthy overrides the default",
+			pool: func() rancherv1.RKEMachinePool {
+				pool := newHealthCheckPool(3, true, false)
+				pool.MaxUnhealthy = &percent
+				return pool
+			},
+			want: intOrStringPtr(intstr.FromString("40%")),
+		},
+		{
+			name: "unhealthyRange replaces the default",
+			pool: func() rancherv1.RKEMachinePool {
+				pool := newHealthCheckPool(3, true, false)
+				pool.UnhealthyRange = &unhealthyRange
+				return pool
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pool := tt.pool()
+			cluster := newHealthCheckCluster(append([]rancherv1.RKEMachinePool{pool}, tt.otherPools...)...)
+			deployments, err := poolDeployments(cluster, pool, nil)
+			require.NoError(t, err)
+
+			healthCheck, err := machineHealthCheck(cluster, &capi.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c"}}, pool, deployments)
+			require.NoError(t, err)
+			require.NotNil(t, healthCheck)
+			assert.Equal(t, tt.want, healthCheck.Spec.MaxUnhealthy)
+		})
+	}
+}
+
+func TestMachineHealthCheck(t *testing.T) {
+	capiCluster := &capi.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c"}}
+
+	pool := newHealthCheckPool(3, false, false)
+	pool.UnhealthyNodeTimeout = nil
+	cluster := newHealthCheckCluster(pool)
+	healthCheck, err := machineHealthCheck(cluster, capiCluster, pool, []poolDeployment{{name: "c-pool"}})
+	require.NoError(t, err)
+	assert.Nil(t, healthCheck, "no health check without an unhealthy node timeout")
+
+	pool = newHealthCheckPool(3, false, false)
+	healthCheck, err = machineHealthCheck(cluster, capiCluster, pool, []poolDeployment{{name: "c-pool"}})
+	require.NoError(t, err)
+	assert.Equal(t, "c-pool", healthCheck.Name)
+	assert.Equal(t, map[string]string{capi.MachineDeploymentLabelName: "c-pool"}, healthCheck.Spec.Selector.MatchLabels)
+	require.Len(t, healthCheck.Spec.UnhealthyConditions, 2)
+	assert.Equal(t, 5*time.Minute, healthCheck.Spec.UnhealthyConditions[0].Timeout.Duration)
+
+	invalid := "two"
+	pool.MaxUnhealthy = &invalid
+	_, err = machineHealthCheck(cluster, capiCluster, pool, []poolDeployment{{name: "c-pool"}})
+	assert.Error(t, err)
+}
+
+func TestMachineHealthCheckFailureDomains(t *testing.T) {
+	// a single health check covers all domains, so MaxUnhealthy applies to the whole pool
+	pool := newFailureDomainPool(3, "a", "b", "c")
+	pool.EtcdRole = true
+	pool.UnhealthyNodeTimeout = &metav1.Duration{Duration: 5 * time.Minute}
+	cluster := newHealthCheckCluster(pool)
+	deployments, err := poolDeployments(cluster, pool, fakeMachineDeploymentCache{})
+	require.NoError(t, err)
+
+	healthCheck, err := machineHealthCheck(cluster, &capi.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c"}}, pool, deployments)
+	require.NoError(t, err)
+	assert.Equal(t, "c-pool", healthCheck.Name)
+	assert.Empty(t, healthCheck.Spec.Selector.MatchLabels)
+	assert.Equal(t, []metav1.LabelSelectorRequirement{{
+		Key:      capi.MachineDeploymentLabelName,
+		Operator: metav1.LabelSelectorOpIn,
+		Values:   []string{"c-pool", "c-pool-b", "c-pool-c"},
+	}}, healthCheck.Spec.Selector.MatchExpressions)
+	assert.Equal(t, intOrStringPtr(intstr.FromInt(1)), healthCheck.Spec.MaxUnhealthy)
+}
+
+func intOrStringPtr(value intstr.IntOrString) *intstr.IntOrString {
+	return &value
+}
+
+type fakeDynamicSchemaCache struct {
+	mgmtcontroller.DynamicSchemaCache
+}
+
+func TestMachineDeploymentsScaleFromZero(t *testing.T) {
+	var zero, max int32 = 0, 3
+	autoscaled := rancherv1.RKEMachinePool{
+		Quantity:   &zero,
+		WorkerRole: true,
+		MinSize:    &zero,
+		MaxSize:    &max,
+	}
+	autoscaled.Name = "autoscaled"
+	autoscaled.NodeConfig = &corev1.ObjectReference{
+		APIVersion: "infrastructure.cluster.x-k8s.io/v1alpha4",
+		Kind:       "DockerMachineTemplate",
+		Name:       "autoscaled",
+	}
+	empty := autoscaled
+	empty.Name = "empty"
+	empty.MinSize, empty.MaxSize = nil, nil
+
+	cluster := newHealthCheckCluster(autoscaled, empty)
+	capiCluster := &capi.Cluster{ObjectMeta: metav1.ObjectMeta{Name: "c"}}
+	objs, err := machineDeployments(cluster, capiCluster, nil, fakeDynamicSchemaCache{}, nil, fakeMachineDeploymentCache{})
+	require.NoError(t, err)
+
+	var deployments []*capi.MachineDeployment
+	for _, obj := range objs {
+		if md, ok := obj.(*capi.MachineDeployment); ok {
+			deployments = append(deployments, md)
+		}
+	}
+	// the pool without autoscaling and no machines has no deployment
+	require.Len(t, deployments, 1)
+	assert.Equal(t, "c-autoscaled", deployments[0].Name)
+	assert.Equal(t, int32(0), *deployments[0].Spec.Replicas)
+	assert.Equal(t, "0", deployments[0].Annotations[autoscalerMinSizeAnnotation])
+	assert.Equal(t, "3", deployments[0].Annotations[autoscalerMaxSizeAnnotation])
+
+	// replicas set by cluster-autoscaler are kept
+	scaled := newMachineDeployment("c-autoscaled", 2, "")
+	objs, err = machineDeployments(cluster, capiCluster, nil, fakeDynamicSchemaCache{}, nil, fakeMachineDeploymentCache{
+		items: map[string]*capi.MachineDeployment{"c-autoscaled": scaled},
+	})
+	require.NoError(t, err)
+	for _, obj := range objs {
+		if md, ok := obj.(*capi.MachineDeployment); ok {
+			assert.Equal(t, int32(2), *md.Spec.Replicas)
+		}
+	}
+}
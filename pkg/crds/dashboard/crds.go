@@ -79,6 +79,11 @@ func List(cfg *rest.Config) (_ []crd.CRD, err error) {
 				WithCategories("catalog").
 				WithColumn("URL", ".spec.url")
 		}),
+		newCRD(&catalogv1.ChartPolicy{}, func(c crd.CRD) crd.CRD {
+			c.NonNamespace = true
+			return c.
+				WithCategories("catalog")
+		}),
 		newCRD(&catalogv1.Operation{}, func(c crd.CRD) crd.CRD {
 			return c.
 				WithStatus().
@@ -48,6 +48,18 @@ func provisioning() []crd.CRD {
 				WithColumn("Ready", ".status.ready").
 				WithColumn("Kubeconfig", ".status.clientSecretName")
 		}),
+		newRancherCRD(&v1.ClusterTemplate{}, func(c crd.CRD) crd.CRD {
+			c.Status = false
+			return c.
+				WithColumn("Display Name", ".spec.displayName").
+				WithColumn("Default Revision", ".spec.defaultRevisionName")
+		}),
+		newRancherCRD(&v1.ClusterTemplateRevision{}, func(c crd.CRD) crd.CRD {
+			c.Status = false
+			return c.
+				WithColumn("Template", ".spec.clusterTemplateName").
+				WithColumn("Display Name", ".spec.displayName")
+		}),
 	}
 }
 
@@ -13,6 +13,7 @@
 	"github.com/rancher/norman/types/slice"
 	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/metrics/downstream"
 	"github.com/rancher/rancher/pkg/types/config"
 	"github.com/rancher/rancher/pkg/types/config/dialer"
 	"github.com/rancher/rancher/pkg/wrangler"
@@ -47,15 +48,47 @@ type Factory struct {
 
 func (f *Factory) ClusterDialer(clusterName string) (dialer.Dialer, error) {
 	return func(ctx context.Context, network, address string) (net.Conn, error) {
+		start := time.Now()
 		d, err := f.clusterDialer(clusterName, address)
 		if err != nil {
 			logrus.Debugf(WaitForAgentError, clusterName)
+			downstream.ObserveDial(clusterName, start, err)
 			return nil, err
 		}
-		return d(ctx, network, address)
+		conn, err := d(ctx, network, address)
+		downstream.ObserveDial(clusterName, start, err)
+		if err != nil {
+			return nil, err
+		}
+		if bytes := downstream.NewDialBytes(clusterName); bytes != nil {
+			return &meteredConn{Conn: conn, bytes: bytes}, nil
+		}
+		return conn, nil
 	}, nil
 }
 
+type bytesCounter interface {
+	Add(in, out int)
+}
+
+// meteredConn records the bytes read from and written to a downstream cluster
+type meteredConn struct {
+	net.Conn
+	bytes bytesCounter
+}
+
+func (c *meteredConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.bytes.Add(n, 0)
+	return n, err
+}
+
+func (c *meteredConn) Write(b []byte) (int, error) {
+	n, err := c.Conn.Write(b)
+	c.bytes.Add(0, n)
+	return n, err
+}
+
 func IsCloudDriver(cluster *v3.Cluster) bool {
 	return !cluster.Spec.Internal &&
 		cluster.Status.Driver != "" &&
@@ -0,0 +1,50 @@
+package dialer
+
+import (
+	"io"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeCounter struct {
+	in, out int
+}
+
+func (f *fakeCounter) Add(in, out int) {
+	f.in += in
+	f.out += out
+}
+
+func TestMeteredConn(t *testing.T) {
+	local, remote := net.Pipe()
+	defer remote.Close()
+
+	counter := &fakeCounter{}
+	conn := &meteredConn{Conn: local, bytes: counter}
+
+	go func() {
+		buf := make([]byte, 5)
+		io.ReadFull(remote, buf)
+		remote.Write([]byte("hello world"))
+	}()
+
+	n, err := conn.Write([]byte("12345"))
+	require.NoError(t, err)
+	assert.Equal(t, 5, n)
+
+	buf := make([]byte, 11)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "hello world", string(buf))
+
+	assert.Equal(t, 11, counter.in)
+	assert.Equal(t, 5, counter.out)
+
+	conn.Close()
+	_, err = conn.Read(buf)
+	assert.Error(t, err)
+	assert.Equal(t, 11, counter.in)
+}
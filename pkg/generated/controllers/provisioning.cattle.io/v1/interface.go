@@ -31,6 +31,8 @@ func init() {
 
 type Interface interface {
 	Cluster() ClusterController
+	ClusterTemplate() ClusterTemplateController
+	ClusterTemplateRevision() ClusterTemplateRevisionController
 }
 
 func New(controllerFactory controller.SharedControllerFactory) Interface {
@@ -46,3 +48,9 @@ type version struct {
 func (c *version) Cluster() ClusterController {
 	return NewClusterController(schema.GroupVersionKind{Group: "provisioning.cattle.io", Version: "v1", Kind: "Cluster"}, "clusters", true, c.controllerFactory)
 }
+func (c *version) ClusterTemplate() ClusterTemplateController {
+	return NewClusterTemplateController(schema.GroupVersionKind{Group: "provisioning.cattle.io", Version: "v1", Kind: "ClusterTemplate"}, "clustertemplates", true, c.controllerFactory)
+}
+func (c *version) ClusterTemplateRevision() ClusterTemplateRevisionController {
+	return NewClusterTemplateRevisionController(schema.GroupVersionKind{Group: "provisioning.cattle.io", Version: "v1", Kind: "ClusterTemplateRevision"}, "clustertemplaterevisions", true, c.controllerFactory)
+}
@@ -9,14 +9,10 @@
 	"github.com/rancher/rancher/pkg/kontainer-engine/types"
 )
 
-var Drivers map[string]types.Driver
-
-func init() {
-	Drivers = map[string]types.Driver{
-		"googlekubernetesengine":        gke.NewDriver(),
-		"azurekubernetesservice":        aks.NewDriver(),
-		"amazonelasticcontainerservice": eks.NewDriver(),
-		"import":                        kubeimport.NewDriver(),
-		"rke":                           rke.NewDriver(),
-	}
+var Drivers = map[string]types.Driver{
+	"googlekubernetesengine":        gke.NewDriver(),
+	"azurekubernetesservice":        aks.NewDriver(),
+	"amazonelasticcontainerservice": eks.NewDriver(),
+	"import":                        kubeimport.NewDriver(),
+	"rke":                           rke.NewDriver(),
 }
@@ -0,0 +1,9 @@
+// +build test
+
+package drivers
+
+import "github.com/rancher/rancher/pkg/kontainer-engine/drivers/simulated"
+
+func init() {
+	Drivers["simulated"] = simulated.NewDriver()
+}
@@ -0,0 +1,579 @@
+package simulated
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rancher/rancher/pkg/kontainer-engine/drivers/options"
+	"github.com/rancher/rancher/pkg/kontainer-engine/drivers/util"
+	"github.com/rancher/rancher/pkg/kontainer-engine/store"
+	"github.com/rancher/rancher/pkg/kontainer-engine/types"
+	"github.com/sirupsen/logrus"
+	"gopkg.in/yaml.v2"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+)
+
+// Operations that can be configured to fail with the failOperations option
+const (
+	CreateOperation             = "create"
+	UpdateOperation             = "update"
+	PostCheckOperation          = "postCheck"
+	RemoveOperation             = "remove"
+	SetVersionOperation         = "setVersion"
+	SetClusterSizeOperation     = "setClusterSize"
+	ETCDSaveOperation           = "etcdSave"
+	ETCDRestoreOperation        = "etcdRestore"
+	ETCDRemoveSnapshotOperation = "etcdRemoveSnapshot"
+)
+
+const defaultKubernetesVersion = "v1.20.8"
+
+// Driver simulates a hosted Kubernetes provider in memory so the cluster lifecycle can be exercised
+// without cloud credentials. Clusters only exist for the lifetime of the process. If a kubeconfig is
+// given, for example of a local fake API server or envtest, it is used as the endpoint of the
+// simulated cluster.
+type Driver struct {
+	driverCapabilities types.Capabilities
+
+	lock     sync.Mutex
+	clusters map[string]*cluster
+	failures map[string]int64
+}
+
+type state struct {
+	Name              string
+	KubernetesVersion string
+	NodeCount         int64
+	KubeConfig        string
+	// Latency is added to every operation to simulate a slow provider
+	Latency time.Duration
+	// FailOperations are the operations that return an error
+	FailOperations []string
+	// FailureCount is how many times each operation in FailOperations fails before it succeeds,
+	// 0 means it always fails
+	FailureCount int64
+}
+
+type cluster struct {
+	KubernetesVersion string
+	NodeCount         int64
+	Snapshots         map[string]snapshot
+}
+
+type snapshot struct {
+	KubernetesVersion string
+	NodeCount         int64
+}
+
+func NewDriver() types.Driver {
+	driver := &Driver{
+		driverCapabilities: types.Capabilities{
+			Capabilities: make(map[int64]bool),
+		},
+		clusters: map[string]*cluster{},
+		failures: map[string]int64{},
+	}
+
+	driver.driverCapabilities.AddCapability(types.GetVersionCapability)
+	driver.driverCapabilities.AddCapability(types.SetVersionCapability)
+	driver.driverCapabilities.AddCapability(types.GetClusterSizeCapability)
+	driver.driverCapabilities.AddCapability(types.SetClusterSizeCapability)
+	driver.driverCapabilities.AddCapability(types.EtcdBackupCapability)
+
+	return driver
+}
+
+func (d *Driver) GetCapabilities(ctx context.Context) (*types.Capabilities, error) {
+	return &d.driverCapabilities, nil
+}
+
+func (d *Driver) GetK8SCapabilities(ctx context.Context, opts *types.DriverOptions) (*types.K8SCapabilities, error) {
+	return &types.K8SCapabilities{}, nil
+}
+
+func getDriverOptions() *types.DriverFlags {
+	driverFlag := types.DriverFlags{
+		Options: make(map[string]*types.Flag),
+	}
+	driverFlag.Options["name"] = &types.Flag{
+		Type:  types.StringType,
+		Usage: "the name of the cluster",
+	}
+	driverFlag.Options["kubernetesVersion"] = &types.Flag{
+		Type:  types.StringType,
+		Usage: "the kubernetes version of the cluster",
+		Default: &types.Default{
+			DefaultString: defaultKubernetesVersion,
+		},
+	}
+	driverFlag.Options["nodeCount"] = &types.Flag{
+		Type:  types.IntType,
+		Usage: "the number of nodes of the cluster",
+		Default: &types.Default{
+			DefaultInt: 3,
+		},
+	}
+	driverFlag.Options["kubeConfig"] = &types.Flag{
+		Type:  types.StringType,
+		Usage: "the contents of the kubeconfig file of an API server backing the cluster",
+	}
+	driverFlag.Options["latency"] = &types.Flag{
+		Type:  types.StringType,
+		Usage: "the duration every operation takes, for example 30s",
+	}
+	driverFlag.Options["failOperations"] = &types.Flag{
+		Type:  types.StringSliceType,
+		Usage: "the operations that fail: create, update, postCheck, remove, setVersion, setClusterSize, etcdSave, etcdRestore, etcdRemoveSnapshot",
+	}
+	driverFlag.Options["failureCount"] = &types.Flag{
+		Type:  types.IntType,
+		Usage: "how many times each operation in failOperations fails before it succeeds, 0 to always fail",
+	}
+	return &driverFlag
+}
+
+func (d *Driver) GetDriverCreateOptions(ctx context.Context) (*types.DriverFlags, error) {
+	return getDriverOptions(), nil
+}
+
+func (d *Driver) GetDriverUpdateOptions(ctx context.Context) (*types.DriverFlags, error) {
+	return getDriverOptions(), nil
+}
+
+func getStateFromOptions(driverOptions *types.DriverOptions) (state, error) {
+	s := state{
+		Name:              options.GetValueFromDriverOptions(driverOptions, types.StringType, "name").(string),
+		KubernetesVersion: options.GetValueFromDriverOptions(driverOptions, types.StringType, "kubernetesVersion", "kubernetes-version").(string),
+		NodeCount:         options.GetValueFromDriverOptions(driverOptions, types.IntType, "nodeCount", "node-count").(int64),
+		KubeConfig:        options.GetValueFromDriverOptions(driverOptions, types.StringType, "kubeConfig", "kube-config").(string),
+		FailOperations:    options.GetValueFromDriverOptions(driverOptions, types.StringSliceType, "failOperations", "fail-operations").(*types.StringSlice).Value,
+		FailureCount:      options.GetValueFromDriverOptions(driverOptions, types.IntType, "failureCount", "failure-count").(int64),
+	}
+
+	if latency := options.GetValueFromDriverOptions(driverOptions, types.StringType, "latency").(string); latency != "" {
+		duration, err := time.ParseDuration(latency)
+		if err != nil {
+			return s, fmt.Errorf("invalid latency %s: %v", latency, err)
+		}
+		s.Latency = duration
+	}
+
+	if s.Name == "" {
+		return s, fmt.Errorf("cluster name is required")
+	}
+
+	return s, nil
+}
+
+func storeState(info *types.ClusterInfo, state state) error {
+	data, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	if info.Metadata == nil {
+		info.Metadata = map[string]string{}
+	}
+
+	info.Metadata["state"] = string(data)
+	return nil
+}
+
+func getState(info *types.ClusterInfo) (state, error) {
+	state := state{}
+
+	err := json.Unmarshal([]byte(info.Metadata["state"]), &state)
+	if err != nil {
+		logrus.Errorf("Error encountered while marshalling state: %v", err)
+	}
+
+	return state, err
+}
+
+// simulate waits for the configured latency and returns an error if the operation is configured to fail
+func (d *Driver) simulate(ctx context.Context, state state, operation string) error {
+	if state.Latency > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(state.Latency):
+		}
+	}
+
+	for _, failOperation := range state.FailOperations {
+		if failOperation != operation {
+			continue
+		}
+
+		d.lock.Lock()
+		key := state.Name + "/" + operation
+		d.failures[key]++
+		count := d.failures[key]
+		d.lock.Unlock()
+
+		if state.FailureCount == 0 || count <= state.FailureCount {
+			return fmt.Errorf("[simulated] injected failure %d of %s for cluster %s", count, operation, state.Name)
+		}
+	}
+
+	return nil
+}
+
+func (d *Driver) getCluster(name string) (*cluster, error) {
+	c, ok := d.clusters[name]
+	if !ok {
+		return nil, fmt.Errorf("[simulated] cluster %s not found", name)
+	}
+	return c, nil
+}
+
+func (d *Driver) Create(ctx context.Context, opts *types.DriverOptions, _ *types.ClusterInfo) (*types.ClusterInfo, error) {
+	state, err := getStateFromOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("[simulated] creating cluster %s", state.Name)
+
+	if err := d.simulate(ctx, state, CreateOperation); err != nil {
+		return nil, err
+	}
+
+	info := &types.ClusterInfo{}
+	if err := storeState(info, state); err != nil {
+		return nil, err
+	}
+
+	if state.KubeConfig != "" {
+		if err := setEndpoint(info, state.KubeConfig); err != nil {
+			return nil, err
+		}
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	if _, ok := d.clusters[state.Name]; !ok {
+		d.clusters[state.Name] = &cluster{
+			KubernetesVersion: state.KubernetesVersion,
+			NodeCount:         state.NodeCount,
+			Snapshots:         map[string]snapshot{},
+		}
+	}
+	info.Version = d.clusters[state.Name].KubernetesVersion
+	info.NodeCount = d.clusters[state.Name].NodeCount
+
+	logrus.Infof("[simulated] cluster %s created", state.Name)
+	return info, nil
+}
+
+func setEndpoint(info *types.ClusterInfo, kubeConfig string) error {
+	config := &store.KubeConfig{}
+	if err := yaml.Unmarshal([]byte(kubeConfig), config); err != nil {
+		return fmt.Errorf("error unmarshalling kubeconfig: %v", err)
+	}
+
+	if len(config.Clusters) == 0 || len(config.Users) == 0 {
+		return fmt.Errorf("kubeconfig has no clusters or users")
+	}
+
+	info.Endpoint = config.Clusters[0].Cluster.Server
+	info.RootCaCertificate = config.Clusters[0].Cluster.CertificateAuthorityData
+	info.ClientCertificate = config.Users[0].User.ClientCertificateData
+	info.ClientKey = config.Users[0].User.ClientKeyData
+	info.Username = config.Users[0].User.Username
+	info.Password = config.Users[0].User.Password
+	return nil
+}
+
+func (d *Driver) Update(ctx context.Context, info *types.ClusterInfo, opts *types.DriverOptions) (*types.ClusterInfo, error) {
+	oldState, err := getState(info)
+	if err != nil {
+		return nil, err
+	}
+
+	newState, err := getStateFromOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+	newState.Name = oldState.Name
+
+	logrus.Infof("[simulated] updating cluster %s", newState.Name)
+
+	if err := d.simulate(ctx, newState, UpdateOperation); err != nil {
+		return nil, err
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	c, err := d.getCluster(newState.Name)
+	if err != nil {
+		return nil, err
+	}
+	if newState.KubernetesVersion != "" {
+		c.KubernetesVersion = newState.KubernetesVersion
+	}
+	if newState.NodeCount > 0 {
+		c.NodeCount = newState.NodeCount
+	}
+
+	if err := storeState(info, newState); err != nil {
+		return nil, err
+	}
+	info.Version = c.KubernetesVersion
+	info.NodeCount = c.NodeCount
+
+	logrus.Infof("[simulated] cluster %s updated", newState.Name)
+	return info, nil
+}
+
+func (d *Driver) PostCheck(ctx context.Context, info *types.ClusterInfo) (*types.ClusterInfo, error) {
+	state, err := getState(info)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.simulate(ctx, state, PostCheckOperation); err != nil {
+		return nil, err
+	}
+
+	if state.KubeConfig == "" {
+		info.ServiceAccountToken = base64.StdEncoding.EncodeToString([]byte("simulated-" + state.Name))
+		return info, nil
+	}
+
+	clientset, err := getClientset(info)
+	if err != nil {
+		return nil, err
+	}
+
+	info.ServiceAccountToken, err = util.GenerateServiceAccountToken(clientset)
+	if err != nil {
+		return nil, err
+	}
+
+	logrus.Infof("[simulated] post-check of cluster %s completed successfully", state.Name)
+	return info, nil
+}
+
+func getClientset(info *types.ClusterInfo) (kubernetes.Interface, error) {
+	capem, err := base64.StdEncoding.DecodeString(info.RootCaCertificate)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding root ca certificate: %v", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(info.ClientKey)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding client key: %v", err)
+	}
+
+	cert, err := base64.StdEncoding.DecodeString(info.ClientCertificate)
+	if err != nil {
+		return nil, fmt.Errorf("error decoding client certificate: %v", err)
+	}
+
+	return kubernetes.NewForConfig(&rest.Config{
+		Host:     info.Endpoint,
+		Username: info.Username,
+		Password: info.Password,
+		TLSClientConfig: rest.TLSClientConfig{
+			CAData:   capem,
+			KeyData:  key,
+			CertData: cert,
+		},
+	})
+}
+
+func (d *Driver) Remove(ctx context.Context, info *types.ClusterInfo) error {
+	state, err := getState(info)
+	if err != nil {
+		return err
+	}
+
+	logrus.Infof("[simulated] removing cluster %s", state.Name)
+
+	if err := d.simulate(ctx, state, RemoveOperation); err != nil {
+		return err
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	delete(d.clusters, state.Name)
+	for _, operation := range state.FailOperations {
+		delete(d.failures, state.Name+"/"+operation)
+	}
+	return nil
+}
+
+func (d *Driver) GetVersion(ctx context.Context, info *types.ClusterInfo) (*types.KubernetesVersion, error) {
+	state, err := getState(info)
+	if err != nil {
+		return nil, err
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	c, err := d.getCluster(state.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &types.KubernetesVersion{Version: c.KubernetesVersion}, nil
+}
+
+func (d *Driver) SetVersion(ctx context.Context, info *types.ClusterInfo, version *types.KubernetesVersion) error {
+	state, err := getState(info)
+	if err != nil {
+		return err
+	}
+
+	if err := d.simulate(ctx, state, SetVersionOperation); err != nil {
+		return err
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	c, err := d.getCluster(state.Name)
+	if err != nil {
+		return err
+	}
+	c.KubernetesVersion = version.Version
+	return nil
+}
+
+func (d *Driver) GetClusterSize(ctx context.Context, info *types.ClusterInfo) (*types.NodeCount, error) {
+	state, err := getState(info)
+	if err != nil {
+		return nil, err
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	c, err := d.getCluster(state.Name)
+	if err != nil {
+		return nil, err
+	}
+	return &types.NodeCount{Count: c.NodeCount}, nil
+}
+
+func (d *Driver) SetClusterSize(ctx context.Context, info *types.ClusterInfo, count *types.NodeCount) error {
+	state, err := getState(info)
+	if err != nil {
+		return err
+	}
+
+	if err := d.simulate(ctx, state, SetClusterSizeOperation); err != nil {
+		return err
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	c, err := d.getCluster(state.Name)
+	if err != nil {
+		return err
+	}
+	c.NodeCount = count.Count
+	return nil
+}
+
+func (d *Driver) ETCDSave(ctx context.Context, info *types.ClusterInfo, opts *types.DriverOptions, snapshotName string) error {
+	state, err := getState(info)
+	if err != nil {
+		return err
+	}
+
+	if err := d.simulate(ctx, state, ETCDSaveOperation); err != nil {
+		return err
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	c, err := d.getCluster(state.Name)
+	if err != nil {
+		return err
+	}
+	c.Snapshots[snapshotName] = snapshot{
+		KubernetesVersion: c.KubernetesVersion,
+		NodeCount:         c.NodeCount,
+	}
+	return nil
+}
+
+func (d *Driver) ETCDRestore(ctx context.Context, info *types.ClusterInfo, opts *types.DriverOptions, snapshotName string) (*types.ClusterInfo, error) {
+	state, err := getState(info)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := d.simulate(ctx, state, ETCDRestoreOperation); err != nil {
+		return nil, err
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	c, err := d.getCluster(state.Name)
+	if err != nil {
+		return nil, err
+	}
+	s, ok := c.Snapshots[snapshotName]
+	if !ok {
+		return nil, fmt.Errorf("[simulated] snapshot %s of cluster %s not found", snapshotName, state.Name)
+	}
+	c.KubernetesVersion = s.KubernetesVersion
+	c.NodeCount = s.NodeCount
+
+	info.Version = c.KubernetesVersion
+	info.NodeCount = c.NodeCount
+	return info, nil
+}
+
+func (d *Driver) ETCDRemoveSnapshot(ctx context.Context, info *types.ClusterInfo, opts *types.DriverOptions, snapshotName string) error {
+	state, err := getState(info)
+	if err != nil {
+		return err
+	}
+
+	if err := d.simulate(ctx, state, ETCDRemoveSnapshotOperation); err != nil {
+		return err
+	}
+
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	c, err := d.getCluster(state.Name)
+	if err != nil {
+		return err
+	}
+	delete(c.Snapshots, snapshotName)
+	return nil
+}
+
+func (d *Driver) RemoveLegacyServiceAccount(ctx context.Context, info *types.ClusterInfo) error {
+	state, err := getState(info)
+	if err != nil {
+		return err
+	}
+
+	if state.KubeConfig == "" {
+		return nil
+	}
+
+	clientset, err := getClientset(info)
+	if err != nil {
+		return err
+	}
+
+	return util.DeleteLegacyServiceAccountAndRoleBinding(clientset)
+}
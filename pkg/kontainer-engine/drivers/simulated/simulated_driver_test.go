@@ -0,0 +1,82 @@
+package simulated
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rancher/rancher/pkg/kontainer-engine/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func driverOptions(name string, failOperations ...string) *types.DriverOptions {
+	return &types.DriverOptions{
+		StringOptions: map[string]string{
+			"name":              name,
+			"kubernetesVersion": "v1.20.8",
+		},
+		IntOptions: map[string]int64{
+			"nodeCount":    3,
+			"failureCount": 1,
+		},
+		StringSliceOptions: map[string]*types.StringSlice{
+			"failOperations": {Value: failOperations},
+		},
+	}
+}
+
+func TestLifecycle(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+	driver := NewDriver()
+
+	info, err := driver.Create(ctx, driverOptions("test"), nil)
+	a.NoError(err)
+	a.Equal("v1.20.8", info.Version)
+	a.Equal(int64(3), info.NodeCount)
+
+	info, err = driver.PostCheck(ctx, info)
+	a.NoError(err)
+	a.NotEmpty(info.ServiceAccountToken)
+
+	a.NoError(driver.ETCDSave(ctx, info, nil, "snapshot"))
+	a.NoError(driver.SetVersion(ctx, info, &types.KubernetesVersion{Version: "v1.21.2"}))
+	a.NoError(driver.SetClusterSize(ctx, info, &types.NodeCount{Count: 5}))
+
+	version, err := driver.GetVersion(ctx, info)
+	a.NoError(err)
+	a.Equal("v1.21.2", version.Version)
+
+	info, err = driver.ETCDRestore(ctx, info, nil, "snapshot")
+	a.NoError(err)
+	a.Equal("v1.20.8", info.Version)
+
+	size, err := driver.GetClusterSize(ctx, info)
+	a.NoError(err)
+	a.Equal(int64(3), size.Count)
+
+	a.NoError(driver.Remove(ctx, info))
+	_, err = driver.GetVersion(ctx, info)
+	a.Error(err)
+}
+
+func TestInjectedFailures(t *testing.T) {
+	a := assert.New(t)
+	ctx := context.Background()
+	driver := NewDriver()
+
+	// the first create fails and the retry succeeds as failureCount is 1
+	_, err := driver.Create(ctx, driverOptions("test", CreateOperation, UpdateOperation), nil)
+	a.Error(err)
+
+	info, err := driver.Create(ctx, driverOptions("test", CreateOperation, UpdateOperation), nil)
+	a.NoError(err)
+
+	_, err = driver.Update(ctx, info, driverOptions("test", CreateOperation, UpdateOperation))
+	a.Error(err)
+
+	opts := driverOptions("test", CreateOperation, UpdateOperation)
+	opts.IntOptions["nodeCount"] = 4
+	info, err = driver.Update(ctx, info, opts)
+	a.NoError(err)
+	a.Equal(int64(4), info.NodeCount)
+}
@@ -0,0 +1,13 @@
+// +build test
+
+package service
+
+import "github.com/rancher/rancher/pkg/kontainer-engine/drivers/simulated"
+
+// SimulatedDriverName is only registered in builds with the test tag, the simulated driver keeps its
+// clusters in memory and must never be used in production.
+const SimulatedDriverName = "simulated"
+
+func init() {
+	Drivers[SimulatedDriverName] = simulated.NewDriver()
+}
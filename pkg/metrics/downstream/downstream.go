@@ -0,0 +1,195 @@
+// Package downstream has the metrics of the connections from Rancher to downstream clusters. It
+// doesn't depend on the rest of Rancher so the tunnel, dialer and cluster manager can record them.
+package downstream
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	prometheusMetrics = false
+
+	tunnelConnects = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "tunnel",
+			Name:      "agent_connects_total",
+			Help:      "Total number of authorized tunnel connections of cluster and node agents, including reconnects",
+		},
+		[]string{"cluster", "agent"},
+	)
+
+	tunnelConnectedAgents = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "tunnel",
+			Name:      "connected_agents",
+			Help:      "Number of cluster and node agents with a tunnel session to this Rancher server",
+		},
+		[]string{"cluster", "agent"},
+	)
+
+	dialDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "cluster_dialer",
+			Name:      "dial_duration_seconds",
+			Help:      "Duration of dials to downstream clusters",
+			Buckets:   prometheus.ExponentialBuckets(0.005, 4, 8),
+		},
+		[]string{"cluster"},
+	)
+
+	dialFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "cluster_dialer",
+			Name:      "dial_failures_total",
+			Help:      "Total number of failed dials to downstream clusters",
+		},
+		[]string{"cluster"},
+	)
+
+	dialBytes = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "cluster_dialer",
+			Name:      "bytes_total",
+			Help:      "Total bytes sent to (out) and received from (in) downstream clusters over dialed connections",
+		},
+		[]string{"cluster", "direction"},
+	)
+
+	controllerStartDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "cluster_manager",
+			Name:      "controller_start_duration_seconds",
+			Help:      "Duration of starting the controllers of a downstream cluster",
+			Buckets:   []float64{1, 5, 15, 30, 60, 120, 300, 600},
+		},
+		[]string{"cluster"},
+	)
+
+	controllerStartFailures = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "cluster_manager",
+			Name:      "controller_start_failures_total",
+			Help:      "Total number of failures to start the controllers of a downstream cluster",
+		},
+		[]string{"cluster"},
+	)
+
+	clusterConnected = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "cluster_connected",
+			Name:      "connected",
+			Help:      "Result of the last connectivity check of a downstream cluster, 1 if its agent responded and 0 otherwise",
+		},
+		[]string{"cluster"},
+	)
+
+	// Collectors are the metrics with a cluster label, metrics of deleted clusters are removed
+	// by their cluster label
+	Collectors = []interface{}{
+		tunnelConnects, tunnelConnectedAgents, dialDuration, dialFailures, dialBytes,
+		controllerStartDuration, controllerStartFailures, clusterConnected,
+	}
+)
+
+// Register registers the downstream connection metrics for Prometheus
+func Register() {
+	prometheusMetrics = true
+
+	for _, collector := range Collectors {
+		prometheus.MustRegister(collector.(prometheus.Collector))
+	}
+}
+
+// IncTunnelConnects counts an authorized tunnel connection of the cluster agent or a node agent
+func IncTunnelConnects(clusterName string, node bool) {
+	if prometheusMetrics {
+		tunnelConnects.With(prometheus.Labels{
+			"cluster": clusterName,
+			"agent":   agentLabel(node),
+		}).Inc()
+	}
+}
+
+// SetTunnelConnectedAgents sets the number of cluster and node agents connected for a cluster
+func SetTunnelConnectedAgents(clusterName string, clusterAgents, nodeAgents int) {
+	if prometheusMetrics {
+		tunnelConnectedAgents.With(prometheus.Labels{
+			"cluster": clusterName,
+			"agent":   agentLabel(false),
+		}).Set(float64(clusterAgents))
+		tunnelConnectedAgents.With(prometheus.Labels{
+			"cluster": clusterName,
+			"agent":   agentLabel(true),
+		}).Set(float64(nodeAgents))
+	}
+}
+
+// ObserveDial records the duration and result of a dial to a cluster
+func ObserveDial(clusterName string, start time.Time, err error) {
+	if prometheusMetrics {
+		dialDuration.With(prometheus.Labels{"cluster": clusterName}).Observe(time.Since(start).Seconds())
+		if err != nil {
+			dialFailures.With(prometheus.Labels{"cluster": clusterName}).Inc()
+		}
+	}
+}
+
+// DialBytes counts the bytes received from (in) and sent to (out) a cluster over one dialed
+// connection. Its counters are resolved once so reads and writes don't look up the labels.
+type DialBytes struct {
+	in  prometheus.Counter
+	out prometheus.Counter
+}
+
+// NewDialBytes returns the byte counters for a connection dialed to a cluster, or nil if metrics
+// are disabled
+func NewDialBytes(clusterName string) *DialBytes {
+	if !prometheusMetrics {
+		return nil
+	}
+	return &DialBytes{
+		in:  dialBytes.With(prometheus.Labels{"cluster": clusterName, "direction": "in"}),
+		out: dialBytes.With(prometheus.Labels{"cluster": clusterName, "direction": "out"}),
+	}
+}
+
+// Add adds bytes received from (in) and sent to (out) the cluster
+func (d *DialBytes) Add(in, out int) {
+	if in > 0 {
+		d.in.Add(float64(in))
+	}
+	if out > 0 {
+		d.out.Add(float64(out))
+	}
+}
+
+// ObserveControllerStart records the duration and result of starting the controllers of a cluster
+func ObserveControllerStart(clusterName string, start time.Time, err error) {
+	if prometheusMetrics {
+		if err != nil {
+			controllerStartFailures.With(prometheus.Labels{"cluster": clusterName}).Inc()
+			return
+		}
+		controllerStartDuration.With(prometheus.Labels{"cluster": clusterName}).Observe(time.Since(start).Seconds())
+	}
+}
+
+// SetClusterConnected records the result of the connectivity check of a cluster
+func SetClusterConnected(clusterName string, connected bool) {
+	if prometheusMetrics {
+		value := float64(0)
+		if connected {
+			value = 1
+		}
+		clusterConnected.With(prometheus.Labels{"cluster": clusterName}).Set(value)
+	}
+}
+
+func agentLabel(node bool) string {
+	if node {
+		return "node"
+	}
+	return "cluster"
+}
@@ -0,0 +1,31 @@
+package downstream
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDialBytesDisabled(t *testing.T) {
+	assert.Nil(t, NewDialBytes("c-disabled"))
+}
+
+func TestDialBytes(t *testing.T) {
+	prometheusMetrics = true
+	defer func() {
+		prometheusMetrics = false
+	}()
+
+	first := NewDialBytes("c-abc")
+	second := NewDialBytes("c-abc")
+	first.Add(10, 0)
+	first.Add(0, 3)
+	second.Add(5, 7)
+	second.Add(0, 0)
+
+	assert.Equal(t, float64(15), testutil.ToFloat64(dialBytes.With(prometheus.Labels{"cluster": "c-abc", "direction": "in"})))
+	assert.Equal(t, float64(10), testutil.ToFloat64(dialBytes.With(prometheus.Labels{"cluster": "c-abc", "direction": "out"})))
+	assert.Equal(t, float64(0), testutil.ToFloat64(dialBytes.With(prometheus.Labels{"cluster": "c-other", "direction": "in"})))
+}
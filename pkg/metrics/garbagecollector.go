@@ -8,6 +8,7 @@
 	dto "github.com/prometheus/client_model/go"
 	v1 "github.com/rancher/rancher/pkg/generated/norman/core/v1"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/metrics/downstream"
 	"github.com/rancher/rancher/pkg/settings"
 	rm "github.com/rancher/remotedialer/metrics"
 	"github.com/sirupsen/logrus"
@@ -85,6 +86,7 @@ func (gc *metricGarbageCollector) metricGarbageCollection() {
 	buildObservedLabelMaps(targetMetricsByNameForClientKey, "clientkey", observedLabelsMap)
 	buildObservedLabelMaps(targetMetricsByIPForPeer, "peer", observedLabelsMap)
 	buildObservedLabelMaps([]interface{}{clusterOwner}, "cluster", observedLabelsMap)
+	buildObservedLabelMaps(downstream.Collectors, "cluster", observedLabelsMap)
 
 	removedCount := removeMetricsForDeletedResource(observedLabelsMap, observedResourceNames)
 
@@ -152,6 +154,12 @@ func removeMetricsForDeletedResource(observedMetrics map[string]map[interface{}]
 					} else {
 						logrus.Errorf("[metrics-garbage-collector] failed to delete %T metrics related to %s: %v", v, m, label)
 					}
+				case *prometheus.HistogramVec:
+					if v.Delete(label) {
+						removedCount++
+					} else {
+						logrus.Errorf("[metrics-garbage-collector] failed to delete %T metrics related to %s: %v", v, m, label)
+					}
 				default:
 					logrus.Errorf("[metrics-garbage-collector] saw unknown Metric definition %T", v)
 				}
@@ -27,6 +27,7 @@
 	k8sProxyPkg "github.com/rancher/rancher/pkg/k8sproxy"
 	"github.com/rancher/rancher/pkg/metrics"
 	"github.com/rancher/rancher/pkg/multiclustermanager/whitelist"
+	"github.com/rancher/rancher/pkg/notifiers"
 	"github.com/rancher/rancher/pkg/pipeline/hooks"
 	"github.com/rancher/rancher/pkg/rbac"
 	"github.com/rancher/rancher/pkg/rkenodeconfigserver"
@@ -41,7 +42,10 @@ func router(ctx context.Context, localClusterEnabled bool, tunnelAuthorizer *mcm
 		k8sProxy             = k8sProxyPkg.New(scaledContext, scaledContext.Dialer, clusterManager)
 		connectHandler       = scaledContext.Dialer.(*rancherdialer.Factory).TunnelServer
 		connectConfigHandler = rkenodeconfigserver.Handler(tunnelAuthorizer, scaledContext)
-		clusterImport        = clusterregistrationtokens.ClusterImport{Clusters: scaledContext.Management.Clusters("")}
+		clusterImport        = clusterregistrationtokens.ClusterImport{
+			Clusters:                       scaledContext.Management.Clusters(""),
+			ClusterRegistrationTokenLister: scaledContext.Management.ClusterRegistrationTokens("").Controller().Lister(),
+		}
 	)
 
 	tokenAPI, err := tokens.NewAPIHandler(ctx, scaledContext, norman.ConfigureAPIUI)
@@ -74,6 +78,8 @@ func router(ctx context.Context, localClusterEnabled bool, tunnelAuthorizer *mcm
 	unauthed.Handle("/v3/connect/config", connectConfigHandler)
 	unauthed.Handle("/v3/connect", connectHandler)
 	unauthed.Handle("/v3/connect/register", connectHandler)
+	unauthed.Handle("/v3/connect/credential", http.HandlerFunc(tunnelAuthorizer.ServeCredential))
+	unauthed.Handle("/v3/connect/certificate", http.HandlerFunc(tunnelAuthorizer.ServeCertificate))
 	unauthed.Handle("/v3/import/{token}_{clusterId}.yaml", http.HandlerFunc(clusterImport.ClusterImportHandler))
 	unauthed.Handle("/v3/settings/cacerts", managementAPI).MatcherFunc(onlyGet)
 	unauthed.Handle("/v3/settings/first-login", managementAPI).MatcherFunc(onlyGet)
@@ -85,6 +91,11 @@ func router(ctx context.Context, localClusterEnabled bool, tunnelAuthorizer *mcm
 	unauthed.PathPrefix("/v1-{prefix}-release/release").Handler(channelserver.NewHandler(ctx))
 	unauthed.PathPrefix("/v1-saml").Handler(saml.AuthHandler())
 	unauthed.PathPrefix("/v3-public").Handler(publicAPI)
+	unauthed.PathPrefix(notifiers.RelayPath).Handler(&notifiers.Relay{
+		Notifiers:     scaledContext.Management.Notifiers("").Controller().Lister(),
+		Secrets:       scaledContext.Core.Secrets("").Controller().Lister(),
+		DialerFactory: scaledContext.Dialer,
+	})
 
 	// Authenticated routes
 	authed := mux.NewRouter()
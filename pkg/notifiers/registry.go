@@ -0,0 +1,146 @@
+package notifiers
+
+import (
+	"context"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/types/config/dialer"
+)
+
+// sender sends messages with one type of notifier
+type sender struct {
+	name string
+	// configured returns true if the spec configures this type of notifier
+	configured func(spec *v32.NotifierSpec) bool
+	send       func(ctx context.Context, spec *v32.NotifierSpec, recipient string, msg *Message, dialer dialer.Dialer) error
+}
+
+// senders are the supported notifier types, SendMessage uses the first one configured by the
+// notifier
+var senders []sender
+
+func register(name string, configured func(spec *v32.NotifierSpec) bool,
+	send func(ctx context.Context, spec *v32.NotifierSpec, recipient string, msg *Message, dialer dialer.Dialer) error) {
+	senders = append(senders, sender{
+		name:       name,
+		configured: configured,
+		send:       send,
+	})
+}
+
+// TypeName returns the name of the notifier type configured by the spec or "" if none is
+func TypeName(spec *v32.NotifierSpec) string {
+	for _, s := range senders {
+		if s.configured(spec) {
+			return s.name
+		}
+	}
+	return ""
+}
+
+// Types returns the names of the supported notifier types
+func Types() []string {
+	var result []string
+	for _, s := range senders {
+		result = append(result, s.name)
+	}
+	return result
+}
+
+func init() {
+	register("slack", func(spec *v32.NotifierSpec) bool {
+		return spec.SlackConfig != nil
+	}, func(ctx context.Context, spec *v32.NotifierSpec, recipient string, msg *Message, dialer dialer.Dialer) error {
+		if recipient == "" {
+			recipient = spec.SlackConfig.DefaultRecipient
+		}
+		return TestSlack(spec.SlackConfig.URL, recipient, msg.Content, spec.SlackConfig.HTTPClientConfig, dialer)
+	})
+
+	register("smtp", func(spec *v32.NotifierSpec) bool {
+		return spec.SMTPConfig != nil
+	}, func(ctx context.Context, spec *v32.NotifierSpec, recipient string, msg *Message, dialer dialer.Dialer) error {
+		s := spec.SMTPConfig
+		if recipient == "" {
+			recipient = s.DefaultRecipient
+		}
+		return TestEmail(ctx, s.Host, s.Password, s.Username, int(s.Port), s.TLS, msg.Title, msg.Content, recipient, s.Sender, dialer)
+	})
+
+	register("pagerduty", func(spec *v32.NotifierSpec) bool {
+		return spec.PagerdutyConfig != nil
+	}, func(ctx context.Context, spec *v32.NotifierSpec, recipient string, msg *Message, dialer dialer.Dialer) error {
+		return TestPagerduty(spec.PagerdutyConfig.ServiceKey, msg.Content, spec.PagerdutyConfig.HTTPClientConfig, dialer)
+	})
+
+	register("wechat", func(spec *v32.NotifierSpec) bool {
+		return spec.WechatConfig != nil
+	}, func(ctx context.Context, spec *v32.NotifierSpec, recipient string, msg *Message, dialer dialer.Dialer) error {
+		s := spec.WechatConfig
+		if recipient == "" {
+			recipient = s.DefaultRecipient
+		}
+		return TestWechat(s.Secret, s.Agent, s.Corp, s.RecipientType, recipient, msg.Content, s.HTTPClientConfig, dialer)
+	})
+
+	register("webhook", func(spec *v32.NotifierSpec) bool {
+		return spec.WebhookConfig != nil
+	}, func(ctx context.Context, spec *v32.NotifierSpec, recipient string, msg *Message, dialer dialer.Dialer) error {
+		url := spec.WebhookConfig.URL
+		if recipient != "" {
+			url = recipient
+		}
+		if spec.MessageTemplate != nil {
+			return sendWebhookMessage(url, msg, spec.WebhookConfig.HTTPClientConfig, dialer)
+		}
+		return TestWebhook(url, msg.Content, spec.WebhookConfig.HTTPClientConfig, dialer)
+	})
+
+	register("dingtalk", func(spec *v32.NotifierSpec) bool {
+		return spec.DingtalkConfig != nil
+	}, func(ctx context.Context, spec *v32.NotifierSpec, recipient string, msg *Message, dialer dialer.Dialer) error {
+		return TestDingtalk(spec.DingtalkConfig.URL, spec.DingtalkConfig.Secret, msg.Content, spec.DingtalkConfig.HTTPClientConfig, dialer)
+	})
+
+	register("msteams", func(spec *v32.NotifierSpec) bool {
+		return spec.MSTeamsConfig != nil
+	}, func(ctx context.Context, spec *v32.NotifierSpec, recipient string, msg *Message, dialer dialer.Dialer) error {
+		return TestMicrosoftTeams(spec.MSTeamsConfig.URL, msg.Content, spec.MSTeamsConfig.HTTPClientConfig, dialer)
+	})
+
+	register("opsgenie", func(spec *v32.NotifierSpec) bool {
+		return spec.OpsgenieConfig != nil
+	}, func(ctx context.Context, spec *v32.NotifierSpec, recipient string, msg *Message, dialer dialer.Dialer) error {
+		s := spec.OpsgenieConfig
+		if recipient == "" {
+			recipient = s.DefaultRecipient
+		}
+		return TestOpsgenie(s.APIURL, s.APIKey, recipient, msg.Title, msg.Content, s.HTTPClientConfig, dialer)
+	})
+
+	register("googlechat", func(spec *v32.NotifierSpec) bool {
+		return spec.GoogleChatConfig != nil
+	}, func(ctx context.Context, spec *v32.NotifierSpec, recipient string, msg *Message, dialer dialer.Dialer) error {
+		return TestGoogleChat(spec.GoogleChatConfig.URL, msg.Content, spec.GoogleChatConfig.HTTPClientConfig, dialer)
+	})
+
+	register("telegram", func(spec *v32.NotifierSpec) bool {
+		return spec.TelegramConfig != nil
+	}, func(ctx context.Context, spec *v32.NotifierSpec, recipient string, msg *Message, dialer dialer.Dialer) error {
+		s := spec.TelegramConfig
+		if recipient == "" {
+			recipient = s.DefaultRecipient
+		}
+		return TestTelegram(s.APIURL, s.BotToken, recipient, msg.Content, s.HTTPClientConfig, dialer)
+	})
+
+	register("matrix", func(spec *v32.NotifierSpec) bool {
+		return spec.MatrixConfig != nil
+	}, func(ctx context.Context, spec *v32.NotifierSpec, recipient string, msg *Message, dialer dialer.Dialer) error {
+		s := spec.MatrixConfig
+		if recipient == "" {
+			recipient = s.DefaultRecipient
+		}
+		return TestMatrix(s.HomeserverURL, s.AccessToken, recipient, msg.Content, s.HTTPClientConfig, dialer)
+	})
+}
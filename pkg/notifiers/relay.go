@@ -0,0 +1,138 @@
+package notifiers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	v1 "github.com/rancher/rancher/pkg/generated/norman/core/v1"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/rancher/pkg/types/config/dialer"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// RelayPath is where Alertmanager posts the notifications of notifier types it has no
+	// receiver for, Rancher renders the message of the notifier and sends it
+	RelayPath = "/v3/alertrelay/"
+	// RelayTokenKey is the key of the token in the relay secret of a notifier
+	RelayTokenKey = "token"
+
+	relaySecretPrefix  = "alertrelay-"
+	maxRelayBodyLength = 1 << 20
+)
+
+// RelaySecretName returns the name of the secret in the cluster namespace with the token
+// Alertmanager authenticates to the relay of the notifier with
+func RelaySecretName(notifierName string) string {
+	return relaySecretPrefix + notifierName
+}
+
+// RelayURL returns the URL Alertmanager posts the notifications for the recipient of a notifier to
+func RelayURL(serverURL, clusterName, notifierName, recipient string) string {
+	u := strings.TrimSuffix(serverURL, "/") + RelayPath + url.PathEscape(clusterName) + "/" + url.PathEscape(notifierName)
+	if recipient != "" {
+		u += "?" + url.Values{"recipient": []string{recipient}}.Encode()
+	}
+	return u
+}
+
+// Relay sends the notifications Alertmanager posts for notifier types it has no receiver for
+type Relay struct {
+	Notifiers     v3.NotifierLister
+	Secrets       v1.SecretLister
+	DialerFactory dialer.Factory
+}
+
+func (r *Relay) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	parts := strings.Split(strings.TrimPrefix(req.URL.Path, RelayPath), "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		rw.WriteHeader(http.StatusNotFound)
+		return
+	}
+	clusterName, notifierName := parts[0], parts[1]
+
+	if !r.authorized(req, clusterName, notifierName) {
+		rw.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	notifier, err := r.Notifiers.Get(clusterName, notifierName)
+	if apierrors.IsNotFound(err) {
+		rw.WriteHeader(http.StatusNotFound)
+		return
+	} else if err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	data := &TemplateData{}
+	if err := json.NewDecoder(io.LimitReader(req.Body, maxRelayBodyLength)).Decode(data); err != nil {
+		http.Error(rw, fmt.Sprintf("invalid notification: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	msg, err := RenderMessage(&notifier.Spec, settings.ServerURL.Get(), clusterName, data)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	clusterDialer, err := r.DialerFactory.ClusterDialer(clusterName)
+	if err != nil {
+		http.Error(rw, err.Error(), http.StatusServiceUnavailable)
+		return
+	}
+	if err := SendMessage(req.Context(), notifier, req.URL.Query().Get("recipient"), msg, clusterDialer); err != nil {
+		logrus.Errorf("Failed to relay notification of notifier %s/%s: %v", clusterName, notifierName, err)
+		http.Error(rw, err.Error(), http.StatusBadGateway)
+		return
+	}
+	rw.WriteHeader(http.StatusOK)
+}
+
+// authorized checks the bearer token of the request against the relay secret of the notifier
+func (r *Relay) authorized(req *http.Request, clusterName, notifierName string) bool {
+	token := strings.TrimPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if token == "" {
+		return false
+	}
+	secret, err := r.Secrets.Get(clusterName, RelaySecretName(notifierName))
+	if err != nil {
+		return false
+	}
+	expected := secret.Data[RelayTokenKey]
+	return len(expected) > 0 && subtle.ConstantTimeCompare(expected, []byte(token)) == 1
+}
+
+// RenderMessage renders the message template of the notifier for a notification
+func RenderMessage(spec *v32.NotifierSpec, serverURL, clusterName string, data *TemplateData) (*Message, error) {
+	tmpl, err := parseMessageTemplate(Templates(serverURL, clusterName), MessageTemplateFor(spec))
+	if err != nil {
+		return nil, err
+	}
+	title, err := execute(tmpl, "title", data)
+	if err != nil {
+		return nil, err
+	}
+	text, err := execute(tmpl, "text", data)
+	if err != nil {
+		return nil, err
+	}
+	return &Message{
+		Title:   strings.TrimSpace(title),
+		Content: strings.TrimSpace(text),
+	}, nil
+}
@@ -0,0 +1,115 @@
+package notifiers
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	v1fakes "github.com/rancher/rancher/pkg/generated/norman/core/v1/fakes"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	v3fakes "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3/fakes"
+	"github.com/rancher/rancher/pkg/types/config/dialer"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type fakeDialerFactory struct {
+	dialer.Factory
+}
+
+func (f fakeDialerFactory) ClusterDialer(clusterName string) (dialer.Dialer, error) {
+	return nil, nil
+}
+
+const relayNotification = `{
+  "receiver": "c-abc:node-alert",
+  "status": "firing",
+  "alerts": [{"status": "firing", "labels": {"alert_name": "High CPU usage", "severity": "critical"}}],
+  "groupLabels": {"rule_id": "c-abc:node-alert_high-cpu"},
+  "commonLabels": {"alert_name": "High CPU usage", "severity": "critical"},
+  "commonAnnotations": {}
+}`
+
+func newRelay(chatURL string) *Relay {
+	return &Relay{
+		Notifiers: &v3fakes.NotifierListerMock{
+			GetFunc: func(namespace, name string) (*v3.Notifier, error) {
+				if namespace != "c-abc" || name != "chat" {
+					return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "notifiers"}, name)
+				}
+				return &v3.Notifier{
+					ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+					Spec: v32.NotifierSpec{
+						GoogleChatConfig: &v32.GoogleChatConfig{URL: chatURL},
+						MessageTemplate: &v32.MessageTemplate{
+							Text: `{{ .Status }}: {{ .CommonLabels.alert_name }}`,
+						},
+					},
+				}, nil
+			},
+		},
+		Secrets: &v1fakes.SecretListerMock{
+			GetFunc: func(namespace, name string) (*corev1.Secret, error) {
+				if namespace != "c-abc" || name != RelaySecretName("chat") {
+					return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, name)
+				}
+				return &corev1.Secret{Data: map[string][]byte{RelayTokenKey: []byte("secret-token")}}, nil
+			},
+		},
+		DialerFactory: fakeDialerFactory{},
+	}
+}
+
+func TestRelay(t *testing.T) {
+	var sent map[string]string
+	chat := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		json.NewDecoder(req.Body).Decode(&sent)
+	}))
+	defer chat.Close()
+	relay := newRelay(chat.URL)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		token  string
+		body   string
+		status int
+	}{
+		{name: "sends", method: http.MethodPost, path: "/v3/alertrelay/c-abc/chat", token: "secret-token", body: relayNotification, status: http.StatusOK},
+		{name: "wrong token", method: http.MethodPost, path: "/v3/alertrelay/c-abc/chat", token: "other", body: relayNotification, status: http.StatusUnauthorized},
+		{name: "no token", method: http.MethodPost, path: "/v3/alertrelay/c-abc/chat", body: relayNotification, status: http.StatusUnauthorized},
+		{name: "token of another notifier", method: http.MethodPost, path: "/v3/alertrelay/c-abc/other", token: "secret-token", body: relayNotification, status: http.StatusUnauthorized},
+		{name: "invalid path", method: http.MethodPost, path: "/v3/alertrelay/c-abc", token: "secret-token", body: relayNotification, status: http.StatusNotFound},
+		{name: "invalid body", method: http.MethodPost, path: "/v3/alertrelay/c-abc/chat", token: "secret-token", body: "{", status: http.StatusBadRequest},
+		{name: "get", method: http.MethodGet, path: "/v3/alertrelay/c-abc/chat", token: "secret-token", status: http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sent = nil
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+			rw := httptest.NewRecorder()
+			relay.ServeHTTP(rw, req)
+			assert.Equal(t, tt.status, rw.Code)
+			if tt.status == http.StatusOK {
+				assert.Equal(t, "firing: High CPU usage", sent["text"])
+			} else {
+				assert.Nil(t, sent)
+			}
+		})
+	}
+}
+
+func TestRelayURL(t *testing.T) {
+	assert.Equal(t, "https://rancher.example.com/v3/alertrelay/c-abc/chat", RelayURL("https://rancher.example.com/", "c-abc", "chat", ""))
+	assert.Equal(t, "https://rancher.example.com/v3/alertrelay/c-abc/chat?recipient=%21room%3Amatrix.org", RelayURL("https://rancher.example.com", "c-abc", "chat", "!room:matrix.org"))
+}
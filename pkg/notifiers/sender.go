@@ -29,7 +29,12 @@
 	"github.com/rancher/rancher/pkg/types/config/dialer"
 )
 
-const contentTypeJSON = "application/json"
+const (
+	contentTypeJSON = "application/json"
+
+	// DefaultOpsgenieAPIURL is the API of Opsgenie notifiers without an API URL
+	DefaultOpsgenieAPIURL = "https://api.opsgenie.com"
+)
 
 type Message struct {
 	Title   string
@@ -50,50 +55,67 @@ type dingtalkResponse struct {
 	Errmsg  string `json:"errmsg"`
 }
 
-func SendMessage(ctx context.Context, notifier *v3.Notifier, recipient string, msg *Message, dialer dialer.Dialer) error {
-	if notifier.Spec.SlackConfig != nil {
-		if recipient == "" {
-			recipient = notifier.Spec.SlackConfig.DefaultRecipient
-		}
-		return TestSlack(notifier.Spec.SlackConfig.URL, recipient, msg.Content, notifier.Spec.SlackConfig.HTTPClientConfig, dialer)
-	}
+type dingtalkMessage struct {
+	MsgType string       `json:"msgtype"`
+	Text    dingtalkText `json:"text"`
+	At      dingtalkAt   `json:"at"`
+}
 
-	if notifier.Spec.SMTPConfig != nil {
-		s := notifier.Spec.SMTPConfig
-		if recipient == "" {
-			recipient = s.DefaultRecipient
-		}
-		return TestEmail(ctx, s.Host, s.Password, s.Username, int(s.Port), s.TLS, msg.Title, msg.Content, recipient, s.Sender, dialer)
-	}
+type dingtalkText struct {
+	Content string `json:"content"`
+}
 
-	if notifier.Spec.PagerdutyConfig != nil {
-		return TestPagerduty(notifier.Spec.PagerdutyConfig.ServiceKey, msg.Content, notifier.Spec.PagerdutyConfig.HTTPClientConfig, dialer)
-	}
+type dingtalkAt struct {
+	IsAtAll bool `json:"isAtAll"`
+}
 
-	if notifier.Spec.WechatConfig != nil {
-		s := notifier.Spec.WechatConfig
-		if recipient == "" {
-			recipient = s.DefaultRecipient
-		}
-		return TestWechat(notifier.Spec.WechatConfig.Secret, notifier.Spec.WechatConfig.Agent, notifier.Spec.WechatConfig.Corp, notifier.Spec.WechatConfig.RecipientType,
-			recipient, msg.Content, notifier.Spec.WechatConfig.HTTPClientConfig, dialer)
-	}
+type msteamsMessage struct {
+	Text string `json:"text"`
+}
 
-	if notifier.Spec.WebhookConfig != nil {
-		return TestWebhook(notifier.Spec.WebhookConfig.URL, msg.Content, notifier.Spec.WebhookConfig.HTTPClientConfig, dialer)
-	}
+// webhookMessage is posted to webhooks with a message template instead of the alerts
+type webhookMessage struct {
+	Title string `json:"title,omitempty"`
+	Text  string `json:"text"`
+}
 
-	if notifier.Spec.DingtalkConfig != nil {
-		return TestDingtalk(notifier.Spec.DingtalkConfig.URL, notifier.Spec.DingtalkConfig.Secret, msg.Content, notifier.Spec.DingtalkConfig.HTTPClientConfig, dialer)
-	}
+type telegramResponse struct {
+	OK          bool   `json:"ok"`
+	Description string `json:"description"`
+}
+
+type opsgenieResponder struct {
+	Name string `json:"name"`
+	Type string `json:"type"`
+}
+
+type opsgenieAlert struct {
+	Message     string              `json:"message"`
+	Description string              `json:"description,omitempty"`
+	Source      string              `json:"source,omitempty"`
+	Responders  []opsgenieResponder `json:"responders,omitempty"`
+}
 
-	if notifier.Spec.MSTeamsConfig != nil {
-		return TestMicrosoftTeams(notifier.Spec.MSTeamsConfig.URL, msg.Content, notifier.Spec.MSTeamsConfig.HTTPClientConfig, dialer)
+func SendMessage(ctx context.Context, notifier *v3.Notifier, recipient string, msg *Message, dialer dialer.Dialer) error {
+	for _, s := range senders {
+		if s.configured(&notifier.Spec) {
+			return s.send(ctx, &notifier.Spec, recipient, msg, dialer)
+		}
 	}
 
 	return errors.New("Notifier not configured")
 }
 
+// Notify renders the message template of the notifier for the alerts of a notification and
+// sends the message
+func Notify(ctx context.Context, notifier *v3.Notifier, recipient, serverURL, clusterName string, data *TemplateData, dialer dialer.Dialer) error {
+	msg, err := RenderMessage(&notifier.Spec, serverURL, clusterName, data)
+	if err != nil {
+		return err
+	}
+	return SendMessage(ctx, notifier, recipient, msg, dialer)
+}
+
 func TestPagerduty(key, msg string, cfg *v32.HTTPClientConfig, dialer dialer.Dialer) error {
 	if msg == "" {
 		msg = "Pagerduty setting validated"
@@ -229,10 +251,14 @@ func TestDingtalk(url, secret, msg string, cfg *v32.HTTPClientConfig, dialer dia
 		msg = "Dingtalk setting validated"
 	}
 
-	content := `{"msgtype": "text",
-		"text": {"content": "` + msg + `"},
-		"at": {"isAtAll": true}
-	}`
+	content, err := json.Marshal(dingtalkMessage{
+		MsgType: "text",
+		Text:    dingtalkText{Content: msg},
+		At:      dingtalkAt{IsAtAll: true},
+	})
+	if err != nil {
+		return err
+	}
 
 	url = getDingtalkURL(url, secret)
 
@@ -241,7 +267,7 @@ func TestDingtalk(url, secret, msg string, cfg *v32.HTTPClientConfig, dialer dia
 		return err
 	}
 
-	req, err := http.NewRequest("POST", url, strings.NewReader(content))
+	req, err := http.NewRequest("POST", url, bytes.NewReader(content))
 	if err != nil {
 		return err
 	}
@@ -279,18 +305,75 @@ func TestMicrosoftTeams(url, msg string, cfg *v32.HTTPClientConfig, dialer diale
 		msg = "MicrosoftTeams setting validated"
 	}
 
-	content := `{"text":"` + msg + `"}`
+	content, err := json.Marshal(msteamsMessage{Text: msg})
+	if err != nil {
+		return err
+	}
+
+	client, err := NewClientFromConfig(cfg, dialer)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest("POST", url, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentTypeJSON)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("HTTP status code is %d, not included in the 2xx success HTTP status codes", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func TestOpsgenie(apiURL, key, team, title, msg string, cfg *v32.HTTPClientConfig, dialer dialer.Dialer) error {
+	if msg == "" {
+		msg = "Opsgenie setting validated"
+	}
+	if title == "" {
+		title = msg
+	}
+	// the message of an alert is limited to 130 characters, the full text goes to the description
+	if len(title) > 130 {
+		title = title[:130]
+	}
+	if apiURL == "" {
+		apiURL = DefaultOpsgenieAPIURL
+	}
+
+	alert := &opsgenieAlert{
+		Message:     title,
+		Description: msg,
+		Source:      "rancher",
+	}
+	if team != "" {
+		alert.Responders = []opsgenieResponder{{Name: team, Type: "team"}}
+	}
+
+	var buf bytes.Buffer
+	if err := json.NewEncoder(&buf).Encode(alert); err != nil {
+		return err
+	}
 
 	client, err := NewClientFromConfig(cfg, dialer)
 	if err != nil {
 		return err
 	}
 
-	req, err := http.NewRequest("POST", url, strings.NewReader(content))
+	req, err := http.NewRequest(http.MethodPost, strings.TrimSuffix(apiURL, "/")+"/v2/alerts", &buf)
 	if err != nil {
 		return err
 	}
 	req.Header.Set("Content-Type", contentTypeJSON)
+	req.Header.Set("Authorization", "GenieKey "+key)
 
 	resp, err := client.Do(req)
 	if err != nil {
@@ -305,6 +388,122 @@ func TestMicrosoftTeams(url, msg string, cfg *v32.HTTPClientConfig, dialer diale
 	return nil
 }
 
+func TestGoogleChat(url, msg string, cfg *v32.HTTPClientConfig, dialer dialer.Dialer) error {
+	if msg == "" {
+		msg = "Google Chat setting validated"
+	}
+
+	data, err := json.Marshal(map[string]string{"text": msg})
+	if err != nil {
+		return err
+	}
+
+	client, err := NewClientFromConfig(cfg, dialer)
+	if err != nil {
+		return err
+	}
+
+	resp, err := post(client, url, contentTypeJSON, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("HTTP status code is %d, not included in the 2xx success HTTP status codes", resp.StatusCode)
+	}
+
+	return nil
+}
+
+func TestTelegram(apiURL, token, chatID, msg string, cfg *v32.HTTPClientConfig, dialer dialer.Dialer) error {
+	if msg == "" {
+		msg = "Telegram setting validated"
+	}
+	if apiURL == "" {
+		apiURL = "https://api.telegram.org"
+	}
+
+	data, err := json.Marshal(map[string]string{
+		"chat_id": chatID,
+		"text":    msg,
+	})
+	if err != nil {
+		return err
+	}
+
+	client, err := NewClientFromConfig(cfg, dialer)
+	if err != nil {
+		return err
+	}
+
+	resp, err := post(client, fmt.Sprintf("%s/bot%s/sendMessage", strings.TrimSuffix(apiURL, "/"), token), contentTypeJSON, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	respBytes, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+
+	var tgResp telegramResponse
+	if err := json.Unmarshal(respBytes, &tgResp); err != nil {
+		return fmt.Errorf("HTTP status code is %d, invalid response: %v", resp.StatusCode, err)
+	}
+
+	if !tgResp.OK {
+		return fmt.Errorf("Failed to send Telegram message. %s", tgResp.Description)
+	}
+
+	return nil
+}
+
+func TestMatrix(homeserverURL, token, roomID, msg string, cfg *v32.HTTPClientConfig, dialer dialer.Dialer) error {
+	if msg == "" {
+		msg = "Matrix setting validated"
+	}
+
+	data, err := json.Marshal(map[string]string{
+		"msgtype": "m.text",
+		"body":    msg,
+	})
+	if err != nil {
+		return err
+	}
+
+	client, err := NewClientFromConfig(cfg, dialer)
+	if err != nil {
+		return err
+	}
+
+	// the transaction ID makes retries of the same request idempotent
+	txnID := strconv.FormatInt(time.Now().UnixNano(), 10)
+	endpoint := fmt.Sprintf("%s/_matrix/client/r0/rooms/%s/send/m.room.message/%s",
+		strings.TrimSuffix(homeserverURL, "/"), url.PathEscape(roomID), txnID)
+
+	req, err := http.NewRequest(http.MethodPut, endpoint, bytes.NewBuffer(data))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", contentTypeJSON)
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		respBytes, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("HTTP status code is %d, not included in the 2xx success HTTP status codes, response: %s", resp.StatusCode, respBytes)
+	}
+
+	return nil
+}
+
 func TestWebhook(url, msg string, cfg *v32.HTTPClientConfig, dialer dialer.Dialer) error {
 	if msg == "" {
 		msg = "Webhook setting validated"
@@ -340,6 +539,34 @@ func TestWebhook(url, msg string, cfg *v32.HTTPClientConfig, dialer dialer.Diale
 	return nil
 }
 
+// sendWebhookMessage posts the rendered message of a webhook with a message template
+func sendWebhookMessage(url string, msg *Message, cfg *v32.HTTPClientConfig, dialer dialer.Dialer) error {
+	content, err := json.Marshal(webhookMessage{
+		Title: msg.Title,
+		Text:  msg.Content,
+	})
+	if err != nil {
+		return err
+	}
+
+	client, err := NewClientFromConfig(cfg, dialer)
+	if err != nil {
+		return err
+	}
+
+	resp, err := post(client, url, contentTypeJSON, bytes.NewReader(content))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return fmt.Errorf("HTTP status code is %d, not included in the 2xx success HTTP status codes", resp.StatusCode)
+	}
+
+	return nil
+}
+
 func TestSlack(url, channel, msg string, cfg *v32.HTTPClientConfig, dialer dialer.Dialer) error {
 	if msg == "" {
 		msg = "Slack setting validated"
@@ -1,10 +1,16 @@
 package notifiers
 
 import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
 	"testing"
 
 	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
 
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -39,3 +45,151 @@ type testCase struct {
 	}
 
 }
+
+func TestSendMessageNotConfigured(t *testing.T) {
+	err := SendMessage(context.Background(), &v3.Notifier{}, "", &Message{}, nil)
+	assert.EqualError(t, err, "Notifier not configured")
+}
+
+func TestSendMessageGoogleChat(t *testing.T) {
+	assert := assert.New(t)
+
+	var body map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.NoError(json.NewDecoder(req.Body).Decode(&body))
+	}))
+	defer server.Close()
+
+	notifier := &v3.Notifier{
+		Spec: v32.NotifierSpec{
+			GoogleChatConfig: &v32.GoogleChatConfig{URL: server.URL},
+		},
+	}
+	assert.NoError(SendMessage(context.Background(), notifier, "", &Message{Content: "hello"}, nil))
+	assert.Equal("hello", body["text"])
+}
+
+func TestTelegramMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	var (
+		path string
+		body map[string]string
+		ok   = true
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		path = req.URL.Path
+		assert.NoError(json.NewDecoder(req.Body).Decode(&body))
+		json.NewEncoder(rw).Encode(telegramResponse{OK: ok, Description: "chat not found"})
+	}))
+	defer server.Close()
+
+	assert.NoError(TestTelegram(server.URL, "123:abc", "-42", "", nil, nil))
+	assert.Equal("/bot123:abc/sendMessage", path)
+	assert.Equal("-42", body["chat_id"])
+	assert.Equal("Telegram setting validated", body["text"])
+
+	ok = false
+	assert.EqualError(TestTelegram(server.URL, "123:abc", "-42", "", nil, nil), "Failed to send Telegram message. chat not found")
+}
+
+func TestMatrixMessage(t *testing.T) {
+	assert := assert.New(t)
+
+	var req *http.Request
+	var body map[string]string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		req = r
+		assert.NoError(json.NewDecoder(r.Body).Decode(&body))
+	}))
+	defer server.Close()
+
+	assert.NoError(TestMatrix(server.URL+"/", "secret", "!room:example.com", "hello", nil, nil))
+	assert.Equal(http.MethodPut, req.Method)
+	assert.True(strings.HasPrefix(req.URL.Path, "/_matrix/client/r0/rooms/!room:example.com/send/m.room.message/"))
+	assert.Equal("Bearer secret", req.Header.Get("Authorization"))
+	assert.Equal("m.text", body["msgtype"])
+	assert.Equal("hello", body["body"])
+}
+
+func TestOpsgenieAlert(t *testing.T) {
+	assert := assert.New(t)
+
+	var req *http.Request
+	var alert opsgenieAlert
+	status := http.StatusAccepted
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		req = r
+		alert = opsgenieAlert{}
+		assert.NoError(json.NewDecoder(r.Body).Decode(&alert))
+		rw.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	assert.NoError(TestOpsgenie(server.URL, "key", "ops", "title", "content", nil, nil))
+	assert.Equal("/v2/alerts", req.URL.Path)
+	assert.Equal("GenieKey key", req.Header.Get("Authorization"))
+	assert.Equal(opsgenieAlert{
+		Message:     "title",
+		Description: "content",
+		Source:      "rancher",
+		Responders:  []opsgenieResponder{{Name: "ops", Type: "team"}},
+	}, alert)
+
+	status = http.StatusUnauthorized
+	assert.Error(TestOpsgenie(server.URL, "key", "", "", "", nil, nil))
+	assert.Equal("Opsgenie setting validated", alert.Message)
+	assert.Empty(alert.Responders)
+}
+
+func TestNotifyRendersTemplate(t *testing.T) {
+	assert := assert.New(t)
+
+	var body webhookMessage
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.NoError(json.NewDecoder(req.Body).Decode(&body))
+	}))
+	defer server.Close()
+
+	notifier := &v3.Notifier{
+		Spec: v32.NotifierSpec{
+			WebhookConfig: &v32.WebhookConfig{URL: server.URL},
+			MessageTemplate: &v32.MessageTemplate{
+				Title: `{{ .CommonLabels.alert_name }}`,
+				Text:  `"{{ .CommonLabels.node_name }}"`,
+			},
+		},
+	}
+	assert.NoError(Notify(context.Background(), notifier, "", "", "c-sample", SampleData(), nil))
+	assert.Equal(webhookMessage{Title: "High CPU usage", Text: `"worker-1"`}, body)
+}
+
+func TestDingtalkMessageEscaped(t *testing.T) {
+	assert := assert.New(t)
+
+	var body dingtalkMessage
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.NoError(json.NewDecoder(req.Body).Decode(&body))
+		rw.Write([]byte(`{"errcode":0}`))
+	}))
+	defer server.Close()
+
+	msg := "node \"worker-1\"\nis down"
+	assert.NoError(TestDingtalk(server.URL, "", msg, nil, nil))
+	assert.Equal(msg, body.Text.Content)
+	assert.True(body.At.IsAtAll)
+}
+
+func TestMicrosoftTeamsMessageEscaped(t *testing.T) {
+	assert := assert.New(t)
+
+	var body msteamsMessage
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		assert.NoError(json.NewDecoder(req.Body).Decode(&body))
+	}))
+	defer server.Close()
+
+	msg := "node \"worker-1\"\nis down"
+	assert.NoError(TestMicrosoftTeams(server.URL, msg, nil, nil))
+	assert.Equal(msg, body.Text)
+}
@@ -0,0 +1,213 @@
+package notifiers
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+)
+
+// defaultTemplates are the messages of the notifier types if the notifier has no template, the
+// named templates are defined in NotificationTmpl
+var defaultTemplates = map[string]v32.MessageTemplate{
+	"slack": {
+		Title: `{{ template "rancher.title" . }}`,
+		Text:  `{{ template "slack.text" . }}`,
+	},
+	"smtp": {
+		Title: `{{ template "rancher.title" . }}`,
+		Text:  `{{ template "email.text" . }}`,
+	},
+	"pagerduty": {
+		Title: `{{ template "rancher.title" . }}`,
+	},
+	"wechat": {
+		Text: `{{ template "wechat.text" . }}`,
+	},
+}
+
+// fallbackTemplate is the message of notifier types without a default template
+var fallbackTemplate = v32.MessageTemplate{
+	Title: `{{ template "rancher.title" . }}`,
+	Text:  `{{ template "slack.text" . }}`,
+}
+
+// templateFuncs are the functions Alertmanager provides to templates
+var templateFuncs = template.FuncMap{
+	"toUpper": strings.ToUpper,
+	"toLower": strings.ToLower,
+	"title":   strings.Title,
+	"join": func(sep string, s []string) string {
+		return strings.Join(s, sep)
+	},
+	"match": regexp.MatchString,
+	"safeHtml": func(text string) string {
+		return text
+	},
+	"reReplaceAll": func(pattern, repl, text string) (string, error) {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return "", err
+		}
+		return re.ReplaceAllString(text, repl), nil
+	},
+	"stringSlice": func(s ...string) []string {
+		return s
+	},
+}
+
+// KV is a set of labels or annotations
+type KV map[string]string
+
+// Alert is an alert of a notification
+type Alert struct {
+	Status       string
+	Labels       KV
+	Annotations  KV
+	StartsAt     time.Time
+	EndsAt       time.Time
+	GeneratorURL string
+}
+
+// Alerts is a list of alerts
+type Alerts []Alert
+
+// Firing returns the alerts that are firing
+func (as Alerts) Firing() []Alert {
+	return as.withStatus("firing")
+}
+
+// Resolved returns the alerts that are resolved
+func (as Alerts) Resolved() []Alert {
+	return as.withStatus("resolved")
+}
+
+func (as Alerts) withStatus(status string) []Alert {
+	var result []Alert
+	for _, a := range as {
+		if a.Status == status {
+			result = append(result, a)
+		}
+	}
+	return result
+}
+
+// TemplateData is the data Alertmanager executes the templates of a notification with
+type TemplateData struct {
+	Receiver          string
+	Status            string
+	Alerts            Alerts
+	GroupLabels       KV
+	CommonLabels      KV
+	CommonAnnotations KV
+	ExternalURL       string
+}
+
+// Templates returns the named templates available to the messages of notifiers in a cluster
+func Templates(serverURL, clusterName string) string {
+	url := ""
+	if serverURL != "" {
+		url = strings.TrimSuffix(serverURL, "/") + "/c/" + clusterName
+	}
+	return NotificationTmpl + fmt.Sprintf("\n{{- define \"rancher.url\" -}}\n%s\n{{- end -}}\n", url)
+}
+
+// MessageTemplateFor returns the message template of the notifier, fields that are not set
+// by the notifier use the default of its type
+func MessageTemplateFor(spec *v32.NotifierSpec) v32.MessageTemplate {
+	result, ok := defaultTemplates[TypeName(spec)]
+	if !ok {
+		result = fallbackTemplate
+	}
+	if spec.MessageTemplate != nil {
+		if spec.MessageTemplate.Title != "" {
+			result.Title = spec.MessageTemplate.Title
+		}
+		if spec.MessageTemplate.Text != "" {
+			result.Text = spec.MessageTemplate.Text
+		}
+	}
+	return result
+}
+
+// ValidateMessageTemplate returns an error if the title or text of the template can't be parsed
+func ValidateMessageTemplate(t *v32.MessageTemplate) error {
+	if t == nil {
+		return nil
+	}
+	_, err := parseMessageTemplate(Templates("", ""), *t)
+	return err
+}
+
+// Preview renders the messages of the notifier for sample alerts
+func Preview(spec *v32.NotifierSpec, serverURL, clusterName string) (*v32.NotifierPreviewOutput, error) {
+	msg, err := RenderMessage(spec, serverURL, clusterName, SampleData())
+	if err != nil {
+		return nil, err
+	}
+
+	return &v32.NotifierPreviewOutput{
+		Title: msg.Title,
+		Text:  msg.Content,
+	}, nil
+}
+
+func parseMessageTemplate(templates string, t v32.MessageTemplate) (*template.Template, error) {
+	tmpl, err := template.New("").Option("missingkey=zero").Funcs(templateFuncs).Parse(templates)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := tmpl.New("title").Parse(t.Title); err != nil {
+		return nil, fmt.Errorf("invalid title template: %v", err)
+	}
+	if _, err := tmpl.New("text").Parse(t.Text); err != nil {
+		return nil, fmt.Errorf("invalid text template: %v", err)
+	}
+	return tmpl, nil
+}
+
+func execute(tmpl *template.Template, name string, data *TemplateData) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.ExecuteTemplate(&buf, name, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// SampleData is a notification of a node alert as Alertmanager sends it
+func SampleData() *TemplateData {
+	labels := KV{
+		"alert_name":    "High CPU usage",
+		"alert_type":    "nodeCPU",
+		"cluster_name":  "sample-cluster",
+		"cpu_threshold": "80",
+		"group_id":      "c-sample:node-alert",
+		"node_name":     "worker-1",
+		"rule_id":       "c-sample:node-alert_high-cpu",
+		"severity":      "critical",
+		"total_cpu":     "4000",
+		"used_cpu":      "3600",
+	}
+
+	return &TemplateData{
+		Receiver: "c-sample:node-alert",
+		Status:   "firing",
+		Alerts: Alerts{
+			{
+				Status:   "firing",
+				Labels:   labels,
+				StartsAt: time.Now().Add(-5 * time.Minute),
+			},
+		},
+		GroupLabels: KV{
+			"node_name": labels["node_name"],
+			"rule_id":   labels["rule_id"],
+		},
+		CommonLabels:      labels,
+		CommonAnnotations: KV{},
+	}
+}
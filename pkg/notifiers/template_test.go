@@ -0,0 +1,69 @@
+package notifiers
+
+import (
+	"testing"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMessageTemplateFor(t *testing.T) {
+	assert := assert.New(t)
+
+	spec := &v32.NotifierSpec{SMTPConfig: &v32.SMTPConfig{}}
+	assert.Equal(defaultTemplates["smtp"], MessageTemplateFor(spec))
+
+	spec.MessageTemplate = &v32.MessageTemplate{Title: "custom"}
+	assert.Equal(v32.MessageTemplate{
+		Title: "custom",
+		Text:  defaultTemplates["smtp"].Text,
+	}, MessageTemplateFor(spec))
+
+	assert.Equal(fallbackTemplate, MessageTemplateFor(&v32.NotifierSpec{TelegramConfig: &v32.TelegramConfig{}}))
+}
+
+func TestPreviewDefault(t *testing.T) {
+	assert := assert.New(t)
+
+	output, err := Preview(&v32.NotifierSpec{SlackConfig: &v32.SlackConfig{}}, "https://rancher.example.com/", "c-sample")
+	assert.NoError(err)
+	assert.Equal("The CPU usage on the node worker-1 is over 80%", output.Title)
+	assert.Contains(output.Text, "Alert Name: High CPU usage")
+	assert.Contains(output.Text, "Used CPU: 3600 m")
+}
+
+func TestPreviewCustom(t *testing.T) {
+	assert := assert.New(t)
+
+	spec := &v32.NotifierSpec{
+		SlackConfig: &v32.SlackConfig{},
+		MessageTemplate: &v32.MessageTemplate{
+			Title: `[{{ .CommonLabels.severity | toUpper }}] {{ .CommonLabels.alert_name }}`,
+			Text:  `{{ range .Alerts.Firing }}{{ .Labels.cluster_name }}/{{ .Labels.node_name }}{{ end }} {{ template "rancher.url" . }}`,
+		},
+	}
+	output, err := Preview(spec, "https://rancher.example.com/", "c-sample")
+	assert.NoError(err)
+	assert.Equal("[CRITICAL] High CPU usage", output.Title)
+	assert.Equal("sample-cluster/worker-1 https://rancher.example.com/c/c-sample", output.Text)
+}
+
+func TestValidateMessageTemplate(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.NoError(ValidateMessageTemplate(nil))
+	assert.NoError(ValidateMessageTemplate(&v32.MessageTemplate{Title: `{{ template "rancher.title" . }}`}))
+	assert.Error(ValidateMessageTemplate(&v32.MessageTemplate{Title: `{{ .CommonLabels.severity`}))
+	assert.Error(ValidateMessageTemplate(&v32.MessageTemplate{Text: `{{ unknownFunc . }}`}))
+}
+
+func TestPreviewInvalidPattern(t *testing.T) {
+	spec := &v32.NotifierSpec{
+		SlackConfig: &v32.SlackConfig{},
+		MessageTemplate: &v32.MessageTemplate{
+			Text: `{{ reReplaceAll "(" "" .CommonLabels.node_name }}`,
+		},
+	}
+	_, err := Preview(spec, "", "c-sample")
+	assert.Error(t, err)
+}
@@ -3,6 +3,9 @@
 import (
 	v3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
 	"github.com/rancher/rancher/pkg/pipeline/engine/jenkins"
+	"github.com/rancher/rancher/pkg/pipeline/engine/kubernetes"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	"github.com/rancher/rancher/pkg/ref"
 	"github.com/rancher/rancher/pkg/types/config"
 )
 
@@ -17,7 +20,8 @@ type PipelineEngine interface {
 
 func New(cluster *config.UserContext, useCache bool) PipelineEngine {
 	serviceLister := cluster.Core.Services("").Controller().Lister()
-	podLister := cluster.Core.Pods("").Controller().Lister()
+	pods := cluster.Core.Pods("")
+	podLister := pods.Controller().Lister()
 	secrets := cluster.Core.Secrets("")
 	secretLister := secrets.Controller().Lister()
 	managementSecretLister := cluster.Management.Core.Secrets("").Controller().Lister()
@@ -27,7 +31,7 @@ func New(cluster *config.UserContext, useCache bool) PipelineEngine {
 	pipelineSettingLister := cluster.Management.Project.PipelineSettings("").Controller().Lister()
 	dialer := cluster.Management.Dialer
 
-	engine := &jenkins.Engine{
+	jenkinsEngine := &jenkins.Engine{
 		UseCache:                   useCache,
 		ServiceLister:              serviceLister,
 		PodLister:                  podLister,
@@ -42,5 +46,90 @@ func New(cluster *config.UserContext, useCache bool) PipelineEngine {
 		Dialer:      dialer,
 		ClusterName: cluster.ClusterName,
 	}
-	return engine
+	kubernetesEngine := &kubernetes.Engine{
+		UseCache:                   useCache,
+		K8sClient:                  cluster.K8sClient,
+		ServiceLister:              serviceLister,
+		Pods:                       pods,
+		PodLister:                  podLister,
+		PersistentVolumeClaims:     cluster.Core.PersistentVolumeClaims(""),
+		Secrets:                    secrets,
+		SecretLister:               secretLister,
+		ManagementSecretLister:     managementSecretLister,
+		SourceCodeCredentials:      sourceCodeCredentials,
+		SourceCodeCredentialLister: sourceCodeCredentialLister,
+		PipelineLister:             pipelineLister,
+		PipelineSettingLister:      pipelineSettingLister,
+
+		Dialer:      dialer,
+		ClusterName: cluster.ClusterName,
+	}
+	return &projectEngine{
+		pipelineSettingLister: pipelineSettingLister,
+		engines: map[string]PipelineEngine{
+			utils.EngineJenkins:    jenkinsEngine,
+			utils.EngineKubernetes: kubernetesEngine,
+		},
+	}
+}
+
+// Name returns the name of the engine selected by the engine pipeline setting of the project
+func Name(pipelineSettingLister v3.PipelineSettingLister, projectName string) string {
+	_, projectID := ref.Parse(projectName)
+	setting, err := pipelineSettingLister.Get(projectID, utils.SettingEngine)
+	if err != nil || setting.Value == "" {
+		return utils.SettingEngineDefault
+	}
+	return setting.Value
+}
+
+// projectEngine runs each execution with the engine of its project. The engine is recorded on the
+// execution when it starts so changing the setting doesn't affect running executions.
+type projectEngine struct {
+	pipelineSettingLister v3.PipelineSettingLister
+	engines               map[string]PipelineEngine
+}
+
+func (p *projectEngine) engineName(execution *v3.PipelineExecution) string {
+	name := execution.Annotations[utils.PipelineEngineLabel]
+	if name == "" {
+		name = Name(p.pipelineSettingLister, execution.Spec.ProjectName)
+	}
+	if _, ok := p.engines[name]; !ok {
+		return utils.EngineJenkins
+	}
+	return name
+}
+
+func (p *projectEngine) engineFor(execution *v3.PipelineExecution) PipelineEngine {
+	return p.engines[p.engineName(execution)]
+}
+
+func (p *projectEngine) PreCheck(execution *v3.PipelineExecution) (bool, error) {
+	return p.engineFor(execution).PreCheck(execution)
+}
+
+func (p *projectEngine) RunPipelineExecution(execution *v3.PipelineExecution) error {
+	name := p.engineName(execution)
+	if execution.Annotations == nil {
+		execution.Annotations = map[string]string{}
+	}
+	execution.Annotations[utils.PipelineEngineLabel] = name
+	return p.engines[name].RunPipelineExecution(execution)
+}
+
+func (p *projectEngine) RerunExecution(execution *v3.PipelineExecution) error {
+	return p.engineFor(execution).RerunExecution(execution)
+}
+
+func (p *projectEngine) StopExecution(execution *v3.PipelineExecution) error {
+	return p.engineFor(execution).StopExecution(execution)
+}
+
+func (p *projectEngine) GetStepLog(execution *v3.PipelineExecution, stage int, step int) (string, error) {
+	return p.engineFor(execution).GetStepLog(execution, stage, step)
+}
+
+func (p *projectEngine) SyncExecution(execution *v3.PipelineExecution) (bool, error) {
+	return p.engineFor(execution).SyncExecution(execution)
 }
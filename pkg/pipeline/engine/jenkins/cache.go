@@ -0,0 +1,218 @@
+package jenkins
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strings"
+
+	v33 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	images "github.com/rancher/rancher/pkg/image"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	"github.com/rancher/rancher/pkg/ref"
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	minioAlias       = "pipeline"
+	latestArtifacts  = "latest"
+	restoreCacheName = "restore-cache"
+	saveCacheName    = "save-cache"
+)
+
+var hashFilesRe = regexp.MustCompile(`hashFiles\(([^)]*)\)`)
+
+// usesStore returns whether the execution restores caches or passes artifacts, which are kept in
+// the minio store of the project pipeline namespace.
+func (c *jenkinsPipelineConverter) usesStore() bool {
+	return utils.UsesStore(c.execution.Spec.PipelineConfig)
+}
+
+// readOnlyStore returns whether the execution may only read caches and the latest artifacts. Pull
+// requests run the code of their author, so they can't replace what the builds of the
+// repository restore.
+func (c *jenkinsPipelineConverter) readOnlyStore() bool {
+	return c.execution.Spec.Event == utils.WebhookEventPullRequest
+}
+
+func (c *jenkinsPipelineConverter) getCacheContainer() (v1.Container, error) {
+	container := v1.Container{
+		Name:    utils.CacheContainerName,
+		Image:   images.Resolve(v33.ToolsSystemImages.PipelineSystemImages.MinioClient),
+		TTY:     true,
+		Command: []string{"cat"},
+		Env: []v1.EnvVar{
+			{
+				Name:  "MINIO_ACCESS_KEY",
+				Value: utils.PipelineSecretDefaultUser,
+			},
+			{
+				Name: "MINIO_SECRET_KEY",
+				ValueFrom: &v1.EnvVarSource{SecretKeyRef: &v1.SecretKeySelector{
+					LocalObjectReference: v1.LocalObjectReference{
+						Name: utils.PipelineSecretName,
+					},
+					Key: utils.PipelineSecretTokenKey,
+				}},
+			},
+			{
+				Name:  "MC_HOST_" + minioAlias,
+				Value: fmt.Sprintf("http://$(MINIO_ACCESS_KEY):$(MINIO_SECRET_KEY)@%s:%d", utils.MinioName, utils.MinioPort),
+			},
+		},
+	}
+	for k, v := range utils.GetEnvVarMap(c.execution) {
+		container.Env = append(container.Env, v1.EnvVar{Name: k, Value: v})
+	}
+	err := injectResources(&container, utils.PipelineToolsCPULimitDefault, utils.PipelineToolsCPURequestDefault, utils.PipelineToolsMemoryLimitDefault, utils.PipelineToolsMemoryRequestDefault)
+	return container, err
+}
+
+// cacheKeyExpr turns a cache key template into a shell expression. Pipeline environment variables
+// are substituted and hashFiles(path, ...) is replaced with a checksum of the files content. The
+// values of the variables, like the branch, are escaped so they stay literal.
+func (c *jenkinsPipelineConverter) cacheKeyExpr(key string) string {
+	envs := utils.GetEnvVarMap(c.execution)
+	var buffer bytes.Buffer
+	last := 0
+	for _, loc := range hashFilesRe.FindAllStringSubmatchIndex(key, -1) {
+		buffer.WriteString(shellEscape(substituteEnvVar(envs, key[last:loc[0]])))
+		var files []string
+		for _, f := range strings.Split(substituteEnvVar(envs, key[loc[2]:loc[3]]), ",") {
+			if f = strings.Trim(strings.TrimSpace(f), `"'`); f != "" {
+				files = append(files, quotePath(f))
+			}
+		}
+		fmt.Fprintf(&buffer, "$(cat %s 2>/dev/null | sha256sum | cut -c1-16)", strings.Join(files, " "))
+		last = loc[1]
+	}
+	buffer.WriteString(shellEscape(substituteEnvVar(envs, key[last:])))
+	return buffer.String()
+}
+
+func (c *jenkinsPipelineConverter) cacheObject(key string) string {
+	_, pipelineID := ref.Parse(c.execution.Spec.PipelineName)
+	return fmt.Sprintf("%s/%s/%s/%s.tar.gz", minioAlias, utils.MinioCacheBucket, pipelineID, key)
+}
+
+func (c *jenkinsPipelineConverter) artifactObject(name string, previous bool) string {
+	_, pipelineID := ref.Parse(c.execution.Spec.PipelineName)
+	run := fmt.Sprint(c.execution.Spec.Run)
+	if previous {
+		run = latestArtifacts
+	}
+	return fmt.Sprintf("%s/%s/%s/%s/%s.tar.gz", minioAlias, utils.MinioArtifactBucket, pipelineID, run, name)
+}
+
+// convertRestoreCacheStage restores the caches right after the source code is cloned. A missing
+// cache is not an error.
+func (c *jenkinsPipelineConverter) convertRestoreCacheStage() string {
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "mc mb --ignore-existing %s/%s\n", minioAlias, utils.MinioCacheBucket)
+	for i, cache := range c.execution.Spec.PipelineConfig.Caches {
+		archive := fmt.Sprintf("/tmp/cache-%d.tar.gz", i)
+		object := c.cacheObject(`${KEY}`)
+		fmt.Fprintf(&buffer, "KEY=\"%s\"\n", c.cacheKeyExpr(cache.Key))
+		fmt.Fprintf(&buffer, "if mc cp \"%s\" %s; then tar -xzf %s; rm -f %s; else echo \"cache ${KEY} not found\"; fi\n", object, archive, archive, archive)
+	}
+	return fmt.Sprintf(storeStageBlock, restoreCacheName, utils.CacheContainerName, buffer.String())
+}
+
+// convertSaveCacheStage saves the caches when all stages succeed. Existing keys are not
+// overwritten and caches over the project size limit are skipped. Executions with a read-only
+// store don't save caches.
+func (c *jenkinsPipelineConverter) convertSaveCacheStage() string {
+	var buffer bytes.Buffer
+	for i, cache := range c.execution.Spec.PipelineConfig.Caches {
+		archive := fmt.Sprintf("/tmp/cache-%d.tar.gz", i)
+		object := c.cacheObject(`${KEY}`)
+		fmt.Fprintf(&buffer, "KEY=\"%s\"\n", c.cacheKeyExpr(cache.Key))
+		fmt.Fprintf(&buffer, "if mc stat \"%s\" >/dev/null 2>&1; then echo \"cache ${KEY} is up to date\"\n", object)
+		fmt.Fprintf(&buffer, "elif tar -czf %s %s; then\n", archive, quotePaths(cache.Paths))
+		fmt.Fprintf(&buffer, "  if [ \"$(wc -c < %s)\" -gt %d ]; then echo \"cache ${KEY} exceeds the size limit of %d bytes, skipped\"; else mc cp %s \"%s\"; fi\n",
+			archive, c.opts.cacheSizeLimit, c.opts.cacheSizeLimit, archive, object)
+		fmt.Fprintf(&buffer, "fi\nrm -f %s\n", archive)
+	}
+	return fmt.Sprintf(storeStageBlock, saveCacheName, utils.CacheContainerName, buffer.String())
+}
+
+// getArtifactDownloadCommand extracts the artifacts a step depends on into the workspace.
+func (c *jenkinsPipelineConverter) getArtifactDownloadCommand(stageOrdinal int, stepOrdinal int) string {
+	step := c.execution.Spec.PipelineConfig.Stages[stageOrdinal].Steps[stepOrdinal]
+	if len(step.ArtifactDownloads) == 0 {
+		return ""
+	}
+	var buffer bytes.Buffer
+	for _, download := range step.ArtifactDownloads {
+		archive := fmt.Sprintf("/tmp/step-%d-%d-%s.tar.gz", stageOrdinal, stepOrdinal, download.Name)
+		path := download.Path
+		if path == "" {
+			path = "."
+		}
+		fmt.Fprintf(&buffer, "mkdir -p %s\nmc cp \"%s\" %s\ntar -xzf %s -C %s\nrm -f %s\n",
+			quotePath(path), c.artifactObject(download.Name, download.FromPreviousExecution), archive, archive, quotePath(path), archive)
+	}
+	return fmt.Sprintf(storeCommandBlock, utils.CacheContainerName, buffer.String())
+}
+
+// getArtifactUploadCommand uploads the artifacts of a step for the execution and, unless the store
+// is read-only, as the latest ones of the pipeline. Artifacts over the project size limit fail the
+// step.
+func (c *jenkinsPipelineConverter) getArtifactUploadCommand(stageOrdinal int, stepOrdinal int) string {
+	step := c.execution.Spec.PipelineConfig.Stages[stageOrdinal].Steps[stepOrdinal]
+	if len(step.Artifacts) == 0 {
+		return ""
+	}
+	var buffer bytes.Buffer
+	fmt.Fprintf(&buffer, "mc mb --ignore-existing %s/%s\n", minioAlias, utils.MinioArtifactBucket)
+	for _, artifact := range step.Artifacts {
+		archive := fmt.Sprintf("/tmp/step-%d-%d-%s.tar.gz", stageOrdinal, stepOrdinal, artifact.Name)
+		fmt.Fprintf(&buffer, "tar -czf %s %s\n", archive, quotePaths(artifact.Paths))
+		fmt.Fprintf(&buffer, "if [ \"$(wc -c < %s)\" -gt %d ]; then echo \"artifact %s exceeds the size limit of %d bytes\"; rm -f %s; exit 1; fi\n",
+			archive, c.opts.artifactSizeLimit, artifact.Name, c.opts.artifactSizeLimit, archive)
+		fmt.Fprintf(&buffer, "mc cp %s \"%s\"\n", archive, c.artifactObject(artifact.Name, false))
+		if !c.readOnlyStore() {
+			fmt.Fprintf(&buffer, "mc cp %s \"%s\"\n", archive, c.artifactObject(artifact.Name, true))
+		}
+		fmt.Fprintf(&buffer, "rm -f %s\n", archive)
+	}
+	return fmt.Sprintf(storeCommandBlock, utils.CacheContainerName, buffer.String())
+}
+
+func quotePaths(paths []string) string {
+	quoted := make([]string, 0, len(paths))
+	for _, p := range paths {
+		quoted = append(quoted, quotePath(p))
+	}
+	return strings.Join(quoted, " ")
+}
+
+func quotePath(path string) string {
+	return `"` + shellEscape(path) + `"`
+}
+
+var (
+	// shellReplacer escapes the characters that are special in a double-quoted shell string
+	shellReplacer = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "$", `\$`, "`", "\\`")
+	// groovyReplacer escapes the characters that are special in a triple-quoted Groovy string
+	groovyReplacer = strings.NewReplacer(`\`, `\\`, "'", `\'`)
+)
+
+// shellEscape escapes s for a double-quoted shell string in the shell blocks of the Jenkinsfile
+func shellEscape(s string) string {
+	return groovyReplacer.Replace(shellReplacer.Replace(s))
+}
+
+const storeStageBlock = `stage('%s'){
+  container(name: '%s') {
+    sh '''
+%s'''
+  }
+}
+`
+
+const storeCommandBlock = `container(name: '%s') {
+        sh '''
+%s'''
+      }
+`
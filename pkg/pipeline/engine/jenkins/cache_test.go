@@ -0,0 +1,88 @@
+package jenkins
+
+import (
+	"strings"
+	"testing"
+
+	v32 "github.com/rancher/rancher/pkg/apis/project.cattle.io/v3"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newCacheTestConverter(config v32.PipelineConfig) *jenkinsPipelineConverter {
+	execution := &v3.PipelineExecution{
+		ObjectMeta: metav1.ObjectMeta{Name: "p-abc-2", Namespace: "p-xyz"},
+		Spec: v32.PipelineExecutionSpec{
+			ProjectName:    "c-abc:p-xyz",
+			PipelineName:   "p-xyz:p-abc",
+			Run:            2,
+			Branch:         "main",
+			PipelineConfig: config,
+		},
+	}
+	return &jenkinsPipelineConverter{
+		execution: execution,
+		opts: &executeOptions{
+			cacheSizeLimit:    1024,
+			artifactSizeLimit: 2048,
+		},
+	}
+}
+
+func TestCacheKeyExpr(t *testing.T) {
+	c := newCacheTestConverter(v32.PipelineConfig{})
+	assert.Equal(t, "go-main-$(cat \"go.sum\" \"go.mod\" 2>/dev/null | sha256sum | cut -c1-16)",
+		c.cacheKeyExpr("go-${CICD_GIT_BRANCH}-hashFiles(go.sum, 'go.mod')"))
+	assert.Equal(t, "static", c.cacheKeyExpr("static"))
+
+	// the branch is chosen by the author of a pull request
+	c.execution.Spec.Branch = `x"$(id)'''` + "`" + `\`
+	assert.Equal(t, `go-x\\"\\$(id)\'\'\'\\`+"`"+`\\\\-$(cat "go.sum" 2>/dev/null | sha256sum | cut -c1-16)`,
+		c.cacheKeyExpr("go-${CICD_GIT_BRANCH}-hashFiles(go.sum)"))
+}
+
+func TestArtifactCommands(t *testing.T) {
+	c := newCacheTestConverter(v32.PipelineConfig{
+		Stages: []v32.Stage{
+			{Steps: []v32.Step{{SourceCodeConfig: &v32.SourceCodeConfig{}}}},
+			{Steps: []v32.Step{{Artifacts: []v32.Artifact{{Name: "bin", Paths: []string{"bin"}}}}}},
+			{Steps: []v32.Step{{ArtifactDownloads: []v32.ArtifactDownload{{Name: "bin"}, {Name: "dist", Path: "web", FromPreviousExecution: true}}}}},
+		},
+	})
+	assert.True(t, c.usesStore())
+	assert.Equal(t, "", c.getArtifactUploadCommand(0, 0))
+
+	upload := c.getArtifactUploadCommand(1, 0)
+	assert.Contains(t, upload, "-gt 2048")
+	assert.Contains(t, upload, "pipeline/pipeline-artifacts/p-abc/2/bin.tar.gz")
+	assert.Contains(t, upload, "pipeline/pipeline-artifacts/p-abc/latest/bin.tar.gz")
+
+	c.execution.Spec.Event = utils.WebhookEventPullRequest
+	upload = c.getArtifactUploadCommand(1, 0)
+	assert.Contains(t, upload, "pipeline/pipeline-artifacts/p-abc/2/bin.tar.gz")
+	assert.NotContains(t, upload, "latest")
+
+	download := c.getArtifactDownloadCommand(2, 0)
+	assert.True(t, strings.HasPrefix(download, "container(name: 'cache')"))
+	assert.Contains(t, download, "pipeline/pipeline-artifacts/p-abc/2/bin.tar.gz")
+	assert.Contains(t, download, "pipeline/pipeline-artifacts/p-abc/latest/dist.tar.gz")
+	assert.Contains(t, download, "-C \"web\"")
+}
+
+func TestCacheStages(t *testing.T) {
+	c := newCacheTestConverter(v32.PipelineConfig{
+		Caches: []v32.CacheConfig{{Key: "npm-hashFiles(package-lock.json)", Paths: []string{"node_modules"}}},
+	})
+	assert.True(t, c.usesStore())
+	assert.Contains(t, c.convertRestoreCacheStage(), "stage('restore-cache')")
+	save := c.convertSaveCacheStage()
+	assert.Contains(t, save, "tar -czf /tmp/cache-0.tar.gz \"node_modules\"")
+	assert.Contains(t, save, "-gt 1024")
+	assert.Contains(t, save, "pipeline/pipeline-cache/p-abc/${KEY}.tar.gz")
+	assert.False(t, c.readOnlyStore())
+
+	c.execution.Spec.Event = utils.WebhookEventPullRequest
+	assert.True(t, c.readOnlyStore())
+}
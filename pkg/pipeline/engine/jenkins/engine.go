@@ -161,6 +161,12 @@ func (j *Engine) RunPipelineExecution(execution *v3.PipelineExecution) error {
 }
 
 func (j *Engine) preparePipeline(execution *v3.PipelineExecution) error {
+	return PrepareRegistryCredentials(execution, j.ManagementSecretLister, j.Secrets)
+}
+
+// PrepareRegistryCredentials stores the credentials of the registries the publish image steps of
+// the execution push to in the pipeline namespace
+func PrepareRegistryCredentials(execution *v3.PipelineExecution, managementSecretLister v1.SecretLister, secrets v1.SecretInterface) error {
 	var registry string
 	for _, stage := range execution.Spec.PipelineConfig.Stages {
 		for _, step := range stage.Steps {
@@ -172,7 +178,7 @@ func (j *Engine) preparePipeline(execution *v3.PipelineExecution) error {
 					_, projectID := ref.Parse(execution.Spec.ProjectName)
 					registry = fmt.Sprintf("%s.%s-pipeline", utils.LocalRegistry, projectID)
 				}
-				if err := j.prepareRegistryCredential(execution, registry); err != nil {
+				if err := prepareRegistryCredential(execution, registry, managementSecretLister, secrets); err != nil {
 					return err
 				}
 			}
@@ -181,14 +187,14 @@ func (j *Engine) preparePipeline(execution *v3.PipelineExecution) error {
 	return nil
 }
 
-func (j *Engine) prepareRegistryCredential(execution *v3.PipelineExecution, registry string) error {
-	secrets, err := j.ManagementSecretLister.List(execution.Namespace, labels.Everything())
+func prepareRegistryCredential(execution *v3.PipelineExecution, registry string, managementSecretLister v1.SecretLister, secrets v1.SecretInterface) error {
+	registrySecrets, err := managementSecretLister.List(execution.Namespace, labels.Everything())
 	if err != nil {
 		return err
 	}
 	username := ""
 	password := ""
-	for _, s := range secrets {
+	for _, s := range registrySecrets {
 		if s.Type == "kubernetes.io/dockerconfigjson" {
 			m := map[string]interface{}{}
 			if err := json.Unmarshal(s.Data[".dockerconfigjson"], &m); err != nil {
@@ -227,9 +233,9 @@ func (j *Engine) prepareRegistryCredential(execution *v3.PipelineExecution, regi
 			utils.PublishSecretPwKey:   []byte(password),
 		},
 	}
-	_, err = j.Secrets.Create(secret)
+	_, err = secrets.Create(secret)
 	if apierrors.IsAlreadyExists(err) {
-		if _, err := j.Secrets.Update(secret); err != nil {
+		if _, err := secrets.Update(secret); err != nil {
 			return err
 		}
 		return nil
@@ -35,6 +35,8 @@ type executeOptions struct {
 	executorMemoryLimit   string
 	executorCPURequest    string
 	executorCPULimit      string
+	cacheSizeLimit        int64
+	artifactSizeLimit     int64
 }
 
 func initJenkinsPipelineConverter(execution *v3.PipelineExecution, pipelineSettingLister v3.PipelineSettingLister, secretLister apiv1.SecretLister) (*jenkinsPipelineConverter, error) {
@@ -75,6 +77,14 @@ func initJenkinsPipelineConverter(execution *v3.PipelineExecution, pipelineSetti
 	if err := validateQuantity(cpuLimitSetting.Value); err != nil {
 		return nil, errors.Wrap(err, "invalid executor cpu limit config")
 	}
+	cacheSizeLimit, err := getSizeLimitSetting(pipelineSettingLister, projectID, utils.SettingCacheSizeLimit)
+	if err != nil {
+		return nil, err
+	}
+	artifactSizeLimit, err := getSizeLimitSetting(pipelineSettingLister, projectID, utils.SettingArtifactSizeLimit)
+	if err != nil {
+		return nil, err
+	}
 	opts := &executeOptions{
 		gitCaCerts:            cacertSetting.Value,
 		imagePullSecretNames:  secretNames,
@@ -82,6 +92,8 @@ func initJenkinsPipelineConverter(execution *v3.PipelineExecution, pipelineSetti
 		executorMemoryLimit:   getPipelineSettingValue(memoryLimitSetting),
 		executorCPURequest:    getPipelineSettingValue(cpuRequestSetting),
 		executorCPULimit:      getPipelineSettingValue(cpuLimitSetting),
+		cacheSizeLimit:        cacheSizeLimit,
+		artifactSizeLimit:     artifactSizeLimit,
 	}
 	return &jenkinsPipelineConverter{
 		execution: execution.DeepCopy(),
@@ -89,6 +101,18 @@ func initJenkinsPipelineConverter(execution *v3.PipelineExecution, pipelineSetti
 	}, nil
 }
 
+func getSizeLimitSetting(pipelineSettingLister v3.PipelineSettingLister, projectID string, name string) (int64, error) {
+	setting, err := pipelineSettingLister.Get(projectID, name)
+	if err != nil {
+		return 0, err
+	}
+	quantity, err := resource.ParseQuantity(getPipelineSettingValue(setting))
+	if err != nil {
+		return 0, errors.Wrapf(err, "invalid %s config", name)
+	}
+	return quantity.Value(), nil
+}
+
 func validateQuantity(value string) error {
 	if value == "" {
 		return nil
@@ -127,6 +151,10 @@ func (c *jenkinsPipelineConverter) convertStep(stageOrdinal int, stepOrdinal int
 	stepName := fmt.Sprintf("step-%d-%d", stageOrdinal, stepOrdinal)
 
 	command := c.getJenkinsStepCommand(stageOrdinal, stepOrdinal)
+	stage := c.execution.Spec.PipelineConfig.Stages[stageOrdinal]
+	if utils.MatchAll(stage.When, c.execution) && utils.MatchAll(stage.Steps[stepOrdinal].When, c.execution) {
+		command = c.getArtifactDownloadCommand(stageOrdinal, stepOrdinal) + command + "\n" + c.getArtifactUploadCommand(stageOrdinal, stepOrdinal)
+	}
 
 	return fmt.Sprintf(stepBlock, stepName, stepName, stepName, command)
 }
@@ -155,6 +183,9 @@ func (c *jenkinsPipelineConverter) convertPipelineExecutionToPipelineScript() (s
 	for j, stage := range c.execution.Spec.PipelineConfig.Stages {
 		pipelinebuffer.WriteString(c.convertStage(j))
 		pipelinebuffer.WriteString("\n")
+		if j == 0 && len(c.execution.Spec.PipelineConfig.Caches) > 0 {
+			pipelinebuffer.WriteString(c.convertRestoreCacheStage())
+		}
 		for k := range stage.Steps {
 			container, err := c.getStepContainer(j, k)
 			if err != nil {
@@ -168,6 +199,16 @@ func (c *jenkinsPipelineConverter) convertPipelineExecutionToPipelineScript() (s
 		return "", err
 	}
 	pod.Spec.Containers = append(pod.Spec.Containers, agentContainer)
+	if c.usesStore() {
+		cacheContainer, err := c.getCacheContainer()
+		if err != nil {
+			return "", err
+		}
+		pod.Spec.Containers = append(pod.Spec.Containers, cacheContainer)
+	}
+	if len(c.execution.Spec.PipelineConfig.Caches) > 0 && !c.readOnlyStore() {
+		pipelinebuffer.WriteString(c.convertSaveCacheStage())
+	}
 	timeout := utils.DefaultTimeout
 	if c.execution.Spec.PipelineConfig.Timeout > 0 {
 		timeout = c.execution.Spec.PipelineConfig.Timeout
@@ -0,0 +1,47 @@
+package jenkins
+
+import (
+	"github.com/pkg/errors"
+	apiv1 "github.com/rancher/rancher/pkg/generated/norman/core/v1"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	v1 "k8s.io/api/core/v1"
+)
+
+// NewStepPod returns a pod running a single step of the execution for engines that don't use Jenkins
+// agents. The pod has the container of the step as it is in the Jenkins build pod, with the volumes,
+// image pull secrets and git CA cert of the build pod. The container keeps its idle command, callers
+// set the command of the step.
+func NewStepPod(execution *v3.PipelineExecution, pipelineSettingLister v3.PipelineSettingLister, secretLister apiv1.SecretLister, stageOrdinal int, stepOrdinal int) (*v1.Pod, error) {
+	if err := utils.ValidPipelineConfig(execution.Spec.PipelineConfig); err != nil {
+		return nil, err
+	}
+	stages := execution.Spec.PipelineConfig.Stages
+	if len(stages) <= stageOrdinal || len(stages[stageOrdinal].Steps) <= stepOrdinal {
+		return nil, errors.New("invalid step index")
+	}
+
+	converter, err := initJenkinsPipelineConverter(execution, pipelineSettingLister, secretLister)
+	if err != nil {
+		return nil, err
+	}
+	parsePreservedEnvVar(converter.execution)
+	container, err := converter.getStepContainer(stageOrdinal, stepOrdinal)
+	if err != nil {
+		return nil, err
+	}
+
+	pod := converter.getBasePodTemplate()
+	pod.Spec.Containers = []v1.Container{container}
+	if converter.opts.gitCaCerts != "" {
+		converter.injectGitCaCert(pod)
+		if stages[stageOrdinal].Steps[stepOrdinal].SourceCodeConfig != nil {
+			//the clone step checks out the code itself instead of the agent
+			converter.injectGitCaCertToContainer(&pod.Spec.Containers[0])
+		}
+	}
+	if len(converter.opts.imagePullSecretNames) > 0 {
+		converter.configImagePullSecrets(pod)
+	}
+	return pod, nil
+}
@@ -0,0 +1,493 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/pkg/errors"
+	v32 "github.com/rancher/rancher/pkg/apis/project.cattle.io/v3"
+	v1 "github.com/rancher/rancher/pkg/generated/norman/core/v1"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/pipeline/engine/jenkins"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	"github.com/rancher/rancher/pkg/types/config/dialer"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Engine runs every step of a pipeline execution in its own pod in the pipeline namespace of the
+// project. The steps of a stage run in parallel and the stages run one after another, all steps
+// share the workspace volume of the execution. Pipelines with caches or artifacts are rejected.
+type Engine struct {
+	// UseCache affects resources that is not cached in follower instances of HA mode
+	UseCache      bool
+	K8sClient     kubernetes.Interface
+	HTTPClient    *http.Client
+	ServiceLister v1.ServiceLister
+	Pods          v1.PodInterface
+	PodLister     v1.PodLister
+
+	PersistentVolumeClaims     v1.PersistentVolumeClaimInterface
+	Secrets                    v1.SecretInterface
+	SecretLister               v1.SecretLister
+	ManagementSecretLister     v1.SecretLister
+	SourceCodeCredentials      v3.SourceCodeCredentialInterface
+	SourceCodeCredentialLister v3.SourceCodeCredentialLister
+	PipelineLister             v3.PipelineLister
+	PipelineSettingLister      v3.PipelineSettingLister
+
+	ClusterName string
+	Dialer      dialer.Factory
+}
+
+func (e *Engine) PreCheck(execution *v3.PipelineExecution) (bool, error) {
+	//the pipeline namespace is ready once the pipeline secret is deployed
+	ns := utils.GetPipelineCommonName(execution.Spec.ProjectName)
+	_, err := e.getSecret(ns, utils.PipelineSecretName)
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func (e *Engine) RunPipelineExecution(execution *v3.PipelineExecution) error {
+	logrus.Debug("start RunPipelineExecution")
+	if err := utils.ValidPipelineConfig(execution.Spec.PipelineConfig); err != nil {
+		return err
+	}
+	if utils.UsesStore(execution.Spec.PipelineConfig) {
+		return errors.New("caches and artifacts are not supported by the kubernetes pipeline engine, use the jenkins engine")
+	}
+	if err := jenkins.PrepareRegistryCredentials(execution, e.ManagementSecretLister, e.Secrets); err != nil {
+		return err
+	}
+	if err := e.prepareGitCredential(execution); err != nil {
+		return err
+	}
+	if err := e.prepareWorkspace(execution); err != nil {
+		return err
+	}
+	_, err := e.SyncExecution(execution)
+	return err
+}
+
+func (e *Engine) RerunExecution(execution *v3.PipelineExecution) error {
+	if err := e.deleteStepPods(execution); err != nil {
+		return err
+	}
+	for i := range execution.Status.Stages {
+		stage := &execution.Status.Stages[i]
+		stage.State = utils.StateWaiting
+		stage.Started = ""
+		stage.Ended = ""
+		for j := range stage.Steps {
+			stage.Steps[j] = v32.StepStatus{State: utils.StateWaiting}
+		}
+	}
+	execution.Status.ExecutionState = utils.StateWaiting
+	execution.Status.Ended = ""
+	return e.RunPipelineExecution(execution)
+}
+
+func (e *Engine) StopExecution(execution *v3.PipelineExecution) error {
+	//keep the logs of steps that are stopped
+	for i, stage := range execution.Status.Stages {
+		for j, step := range stage.Steps {
+			if step.State == utils.StateBuilding {
+				if err := e.saveStepLogToMinio(execution, i, j); err != nil {
+					logrus.Warnf("failed to save the log of step %d-%d of pipeline execution %s: %v", i, j, execution.Name, err)
+				}
+			}
+		}
+	}
+	if err := e.deleteStepPods(execution); err != nil {
+		return err
+	}
+
+	ns := utils.GetPipelineCommonName(execution.Spec.ProjectName)
+	if err := e.PersistentVolumeClaims.DeleteNamespaced(ns, workspaceName(execution), &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if err := e.Secrets.DeleteNamespaced(ns, gitCredentialName(execution), &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+func (e *Engine) GetStepLog(execution *v3.PipelineExecution, stage int, step int) (string, error) {
+	if len(execution.Status.Stages) <= stage || len(execution.Status.Stages[stage].Steps) <= step {
+		return "", errors.New("invalid step index")
+	}
+	switch execution.Status.Stages[stage].Steps[step].State {
+	case "", utils.StateWaiting, utils.StateSkipped:
+		return "", nil
+	case utils.StateBuilding:
+		return e.getStepLogFromPod(execution, stage, step)
+	}
+	return e.getStepLogFromMinioStore(execution, stage, step)
+}
+
+func (e *Engine) SyncExecution(execution *v3.PipelineExecution) (bool, error) {
+	if utils.IsFinishState(execution.Status.ExecutionState) {
+		return false, nil
+	}
+
+	updated := false
+	finished := true
+	for i := range execution.Status.Stages {
+		stage := &execution.Status.Stages[i]
+		if stage.State == utils.StateSuccess || stage.State == utils.StateSkipped {
+			continue
+		}
+		if stage.State == utils.StateWaiting {
+			if err := e.startStage(execution, i); err != nil {
+				return false, err
+			}
+			updated = true
+		} else {
+			stageUpdated, err := e.syncStage(execution, i)
+			if err != nil {
+				return false, err
+			}
+			updated = updated || stageUpdated
+		}
+		if stage.State != utils.StateSuccess && stage.State != utils.StateSkipped {
+			finished = false
+			break
+		}
+	}
+
+	if utils.IsFinishState(execution.Status.ExecutionState) {
+		return updated, nil
+	}
+	if finished {
+		execution.Labels[utils.PipelineFinishLabel] = "true"
+		execution.Status.ExecutionState = utils.StateSuccess
+		if execution.Status.Ended == "" {
+			execution.Status.Ended = now()
+		}
+		v32.PipelineExecutionConditionProvisioned.True(execution)
+		v32.PipelineExecutionConditionBuilt.True(execution)
+		return true, nil
+	}
+	if timeout := getTimeout(execution); isTimedOut(execution, timeout) {
+		e.abortBuildingSteps(execution, now())
+		execution.Status.ExecutionState = utils.StateFailed
+		v32.PipelineExecutionConditionBuilt.False(execution)
+		v32.PipelineExecutionConditionBuilt.Message(execution, fmt.Sprintf("Timeout after %d minutes", timeout))
+		return true, nil
+	}
+	return updated, nil
+}
+
+func (e *Engine) startStage(execution *v3.PipelineExecution, stageOrdinal int) error {
+	stage := execution.Spec.PipelineConfig.Stages[stageOrdinal]
+	startTime := now()
+	for j, step := range stage.Steps {
+		if !utils.MatchAll(stage.When, execution) || !utils.MatchAll(step.When, execution) {
+			skipStep(execution, stageOrdinal, j, startTime)
+			continue
+		}
+		if err := e.createStepPod(execution, stageOrdinal, j); err != nil {
+			return err
+		}
+		buildingStep(execution, stageOrdinal, j, startTime)
+	}
+	return nil
+}
+
+func (e *Engine) syncStage(execution *v3.PipelineExecution, stageOrdinal int) (bool, error) {
+	updated := false
+	ns := utils.GetPipelineCommonName(execution.Spec.ProjectName)
+	for j, step := range execution.Status.Stages[stageOrdinal].Steps {
+		if step.State != utils.StateBuilding {
+			continue
+		}
+		pod, err := e.getPod(ns, stepPodName(execution, stageOrdinal, j))
+		if apierrors.IsNotFound(err) {
+			//not in the cache yet
+			continue
+		} else if err != nil {
+			return false, err
+		}
+
+		switch pod.Status.Phase {
+		case corev1.PodSucceeded:
+			started, ended := stepTimes(pod)
+			successStep(execution, stageOrdinal, j, started, ended)
+			if err := e.saveStepLogToMinio(execution, stageOrdinal, j); err != nil {
+				return false, err
+			}
+			updated = true
+		case corev1.PodFailed:
+			started, ended := stepTimes(pod)
+			if err := e.failStep(execution, stageOrdinal, j, started, ended); err != nil {
+				return false, err
+			}
+			return true, nil
+		case corev1.PodRunning:
+			if !v32.PipelineExecutionConditionProvisioned.IsTrue(execution) {
+				v32.PipelineExecutionConditionProvisioned.True(execution)
+				updated = true
+			}
+		case corev1.PodPending:
+			if message := pendingMessage(pod); message != "" && v32.PipelineExecutionConditionProvisioned.IsUnknown(execution) &&
+				v32.PipelineExecutionConditionProvisioned.GetMessage(execution) != message {
+				v32.PipelineExecutionConditionProvisioned.Message(execution, message)
+				updated = true
+			}
+		}
+	}
+	return updated, nil
+}
+
+func (e *Engine) createStepPod(execution *v3.PipelineExecution, stage int, step int) error {
+	pod, err := e.newStepPod(execution, stage, step)
+	if err != nil {
+		return err
+	}
+	if _, err := e.Pods.Create(pod); err != nil && !apierrors.IsAlreadyExists(err) {
+		return err
+	}
+	return nil
+}
+
+func (e *Engine) deleteStepPods(execution *v3.PipelineExecution) error {
+	ns := utils.GetPipelineCommonName(execution.Spec.ProjectName)
+	set := labels.Set{
+		utils.LabelKeyApp:       utils.JenkinsName,
+		utils.LabelKeyExecution: execution.Name,
+	}
+	var pods []*corev1.Pod
+	if e.UseCache {
+		cached, err := e.PodLister.List(ns, set.AsSelector())
+		if err != nil {
+			return err
+		}
+		pods = cached
+	} else {
+		list, err := e.Pods.List(metav1.ListOptions{LabelSelector: set.String()})
+		if err != nil {
+			return err
+		}
+		for i := range list.Items {
+			if list.Items[i].Namespace == ns {
+				pods = append(pods, &list.Items[i])
+			}
+		}
+	}
+	for _, pod := range pods {
+		if err := e.Pods.DeleteNamespaced(ns, pod.Name, &metav1.DeleteOptions{}); err != nil && !apierrors.IsNotFound(err) && !apierrors.IsGone(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *Engine) failStep(execution *v3.PipelineExecution, stage int, step int, started string, ended string) error {
+	execution.Status.Stages[stage].Steps[step].State = utils.StateFailed
+	execution.Status.Stages[stage].State = utils.StateFailed
+	if execution.Status.ExecutionState != utils.StateAborted {
+		execution.Status.ExecutionState = utils.StateFailed
+		v32.PipelineExecutionConditionBuilt.False(execution)
+		v32.PipelineExecutionConditionBuilt.Message(execution, fmt.Sprintf("Got FAILED status in '%s' stage", execution.Spec.PipelineConfig.Stages[stage].Name))
+	}
+	setStepTimes(execution, stage, step, started, ended)
+	if execution.Status.Stages[stage].Ended == "" {
+		execution.Status.Stages[stage].Ended = ended
+	}
+	if execution.Status.Ended == "" {
+		execution.Status.Ended = ended
+	}
+	if err := e.saveStepLogToMinio(execution, stage, step); err != nil {
+		return err
+	}
+
+	//clean waiting status of other stages/steps
+	for i := range execution.Status.Stages {
+		stage := &execution.Status.Stages[i]
+		if stage.State == utils.StateWaiting {
+			stage.State = ""
+		}
+		for j := range stage.Steps {
+			if stage.Steps[j].State == utils.StateWaiting {
+				stage.Steps[j].State = ""
+			}
+		}
+	}
+	e.abortBuildingSteps(execution, ended)
+	return nil
+}
+
+// abortBuildingSteps marks building steps as aborted and keeps their logs, the pods of the steps are
+// deleted when the execution finishes
+func (e *Engine) abortBuildingSteps(execution *v3.PipelineExecution, ended string) {
+	for i := range execution.Status.Stages {
+		for j := range execution.Status.Stages[i].Steps {
+			if execution.Status.Stages[i].Steps[j].State != utils.StateBuilding {
+				continue
+			}
+			if err := e.saveStepLogToMinio(execution, i, j); err != nil {
+				logrus.Warnf("failed to save the log of step %d-%d of pipeline execution %s: %v", i, j, execution.Name, err)
+			}
+			execution.Status.Stages[i].Steps[j].State = utils.StateAborted
+			execution.Status.Stages[i].Steps[j].Ended = ended
+		}
+	}
+}
+
+func (e *Engine) getPod(ns, name string) (*corev1.Pod, error) {
+	if e.UseCache {
+		return e.PodLister.Get(ns, name)
+	}
+	return e.Pods.GetNamespaced(ns, name, metav1.GetOptions{})
+}
+
+func (e *Engine) getSecret(ns, name string) (*corev1.Secret, error) {
+	if e.UseCache {
+		return e.SecretLister.Get(ns, name)
+	}
+	return e.Secrets.GetNamespaced(ns, name, metav1.GetOptions{})
+}
+
+func (e *Engine) getStepLogFromPod(execution *v3.PipelineExecution, stage int, step int) (string, error) {
+	ns := utils.GetPipelineCommonName(execution.Spec.ProjectName)
+	opts := &corev1.PodLogOptions{
+		Container: stepContainerName(stage, step),
+	}
+	content, err := e.K8sClient.CoreV1().Pods(ns).GetLogs(stepPodName(execution, stage, step), opts).DoRaw(context.TODO())
+	if apierrors.IsNotFound(err) {
+		return "", nil
+	}
+	if apierrors.IsBadRequest(err) {
+		//the container of the step is not started yet
+		return "", nil
+	}
+	return string(content), err
+}
+
+func buildingStep(execution *v3.PipelineExecution, stage int, step int, started string) {
+	execution.Status.Stages[stage].Steps[step].State = utils.StateBuilding
+	if execution.Status.Stages[stage].Steps[step].Started == "" {
+		execution.Status.Stages[stage].Steps[step].Started = started
+	}
+	if execution.Status.Stages[stage].State == utils.StateWaiting {
+		execution.Status.Stages[stage].State = utils.StateBuilding
+	}
+	if execution.Status.Stages[stage].Started == "" {
+		execution.Status.Stages[stage].Started = started
+	}
+	if execution.Status.ExecutionState == utils.StateWaiting {
+		execution.Status.ExecutionState = utils.StateBuilding
+	}
+
+	stageName := execution.Spec.PipelineConfig.Stages[stage].Name
+	message := fmt.Sprintf("Running '%s' stage", stageName)
+	v32.PipelineExecutionConditionBuilt.CreateUnknownIfNotExists(execution)
+	v32.PipelineExecutionConditionBuilt.Message(execution, message)
+}
+
+func successStep(execution *v3.PipelineExecution, stage int, step int, started string, ended string) {
+	execution.Status.Stages[stage].Steps[step].State = utils.StateSuccess
+	setStepTimes(execution, stage, step, started, ended)
+	if utils.IsStageSuccess(execution.Status.Stages[stage]) {
+		execution.Status.Stages[stage].State = utils.StateSuccess
+		execution.Status.Stages[stage].Ended = ended
+		if stage == len(execution.Status.Stages)-1 {
+			execution.Status.Ended = ended
+		}
+	}
+}
+
+func skipStep(execution *v3.PipelineExecution, stage int, step int, ended string) {
+	execution.Status.Stages[stage].Steps[step].State = utils.StateSkipped
+
+	skipStage := true
+	for _, curStep := range execution.Status.Stages[stage].Steps {
+		if curStep.State != utils.StateSkipped {
+			skipStage = false
+		}
+	}
+	if skipStage {
+		execution.Status.Stages[stage].State = utils.StateSkipped
+	} else if utils.IsStageSuccess(execution.Status.Stages[stage]) {
+		execution.Status.Stages[stage].State = utils.StateSuccess
+		execution.Status.Stages[stage].Ended = ended
+	}
+}
+
+func setStepTimes(execution *v3.PipelineExecution, stage int, step int, started string, ended string) {
+	if execution.Status.Stages[stage].Steps[step].Started == "" {
+		execution.Status.Stages[stage].Steps[step].Started = started
+	}
+	execution.Status.Stages[stage].Steps[step].Ended = ended
+	if execution.Status.Stages[stage].Started == "" {
+		execution.Status.Stages[stage].Started = started
+	}
+}
+
+// stepTimes returns the time the container of the step started and finished
+func stepTimes(pod *corev1.Pod) (string, string) {
+	started, ended := now(), now()
+	for _, status := range pod.Status.ContainerStatuses {
+		if terminated := status.State.Terminated; terminated != nil {
+			if !terminated.StartedAt.IsZero() {
+				started = terminated.StartedAt.Format(time.RFC3339)
+			}
+			if !terminated.FinishedAt.IsZero() {
+				ended = terminated.FinishedAt.Format(time.RFC3339)
+			}
+		}
+	}
+	return started, ended
+}
+
+// pendingMessage returns why the container of a pending step doesn't run yet
+func pendingMessage(pod *corev1.Pod) string {
+	for _, status := range pod.Status.ContainerStatuses {
+		if waiting := status.State.Waiting; waiting != nil && waiting.Reason != "" {
+			if waiting.Message != "" {
+				return fmt.Sprintf("%s: %s", waiting.Reason, waiting.Message)
+			}
+			return waiting.Reason
+		}
+	}
+	for _, cond := range pod.Status.Conditions {
+		if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse && cond.Message != "" {
+			return cond.Message
+		}
+	}
+	return "Waiting for executors to be ready"
+}
+
+func getTimeout(execution *v3.PipelineExecution) int {
+	if execution.Spec.PipelineConfig.Timeout > 0 {
+		return execution.Spec.PipelineConfig.Timeout
+	}
+	return utils.DefaultTimeout
+}
+
+// isTimedOut returns true if the execution runs longer than the timeout in minutes, the time in the
+// queue doesn't count
+func isTimedOut(execution *v3.PipelineExecution, timeout int) bool {
+	if len(execution.Status.Stages) == 0 || execution.Status.Stages[0].Started == "" {
+		return false
+	}
+	started, err := time.Parse(time.RFC3339, execution.Status.Stages[0].Started)
+	if err != nil {
+		return false
+	}
+	return time.Since(started) > time.Duration(timeout)*time.Minute
+}
+
+func now() string {
+	return time.Now().Format(time.RFC3339)
+}
@@ -0,0 +1,103 @@
+package kubernetes
+
+import (
+	"testing"
+	"time"
+
+	v32 "github.com/rancher/rancher/pkg/apis/project.cattle.io/v3"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func newExecution(steps ...int) *v3.PipelineExecution {
+	execution := &v3.PipelineExecution{}
+	execution.Status.ExecutionState = utils.StateWaiting
+	for i, n := range steps {
+		execution.Spec.PipelineConfig.Stages = append(execution.Spec.PipelineConfig.Stages, v32.Stage{
+			Name:  string(rune('a' + i)),
+			Steps: make([]v32.Step, n),
+		})
+		stage := v32.StageStatus{State: utils.StateWaiting}
+		for j := 0; j < n; j++ {
+			stage.Steps = append(stage.Steps, v32.StepStatus{State: utils.StateWaiting})
+		}
+		execution.Status.Stages = append(execution.Status.Stages, stage)
+	}
+	return execution
+}
+
+func TestStepStates(t *testing.T) {
+	a := assert.New(t)
+	execution := newExecution(1, 2)
+
+	buildingStep(execution, 0, 0, "t0")
+	a.Equal(utils.StateBuilding, execution.Status.ExecutionState)
+	a.Equal(utils.StateBuilding, execution.Status.Stages[0].State)
+	a.Equal("t0", execution.Status.Stages[0].Started)
+
+	successStep(execution, 0, 0, "t0", "t1")
+	a.Equal(utils.StateSuccess, execution.Status.Stages[0].State)
+	a.Equal("t1", execution.Status.Stages[0].Ended)
+
+	skipStep(execution, 1, 0, "t2")
+	a.Equal(utils.StateWaiting, execution.Status.Stages[1].State)
+	buildingStep(execution, 1, 1, "t2")
+	a.Equal(utils.StateBuilding, execution.Status.Stages[1].State)
+	successStep(execution, 1, 1, "t2", "t3")
+	a.Equal(utils.StateSuccess, execution.Status.Stages[1].State)
+	a.Equal("t3", execution.Status.Ended)
+
+	execution = newExecution(2)
+	skipStep(execution, 0, 0, "t0")
+	skipStep(execution, 0, 1, "t0")
+	a.Equal(utils.StateSkipped, execution.Status.Stages[0].State)
+}
+
+func TestIsTimedOut(t *testing.T) {
+	a := assert.New(t)
+	execution := newExecution(1)
+	a.False(isTimedOut(execution, 1))
+
+	execution.Status.Stages[0].Started = time.Now().Add(-30 * time.Second).Format(time.RFC3339)
+	a.False(isTimedOut(execution, 1))
+
+	execution.Status.Stages[0].Started = time.Now().Add(-2 * time.Minute).Format(time.RFC3339)
+	a.True(isTimedOut(execution, 1))
+}
+
+func TestPendingMessage(t *testing.T) {
+	a := assert.New(t)
+	pod := &corev1.Pod{}
+	a.Equal("Waiting for executors to be ready", pendingMessage(pod))
+
+	pod.Status.Conditions = []corev1.PodCondition{{
+		Type:    corev1.PodScheduled,
+		Status:  corev1.ConditionFalse,
+		Message: "0/3 nodes are available",
+	}}
+	a.Equal("0/3 nodes are available", pendingMessage(pod))
+
+	pod.Status.ContainerStatuses = []corev1.ContainerStatus{{
+		State: corev1.ContainerState{
+			Waiting: &corev1.ContainerStateWaiting{
+				Reason:  "ErrImagePull",
+				Message: "image not found",
+			},
+		},
+	}}
+	a.Equal("ErrImagePull: image not found", pendingMessage(pod))
+}
+
+func TestRunPipelineExecutionRejectsStore(t *testing.T) {
+	execution := &v3.PipelineExecution{}
+	execution.Spec.PipelineConfig = v32.PipelineConfig{
+		Stages: []v32.Stage{
+			{Steps: []v32.Step{{SourceCodeConfig: &v32.SourceCodeConfig{}}}},
+			{Steps: []v32.Step{{Artifacts: []v32.Artifact{{Name: "bin", Paths: []string{"bin"}}}}}},
+		},
+	}
+	assert.EqualError(t, (&Engine{}).RunPipelineExecution(execution),
+		"caches and artifacts are not supported by the kubernetes pipeline engine, use the jenkins engine")
+}
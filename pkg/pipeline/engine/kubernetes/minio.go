@@ -0,0 +1,97 @@
+package kubernetes
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	"github.com/sirupsen/logrus"
+)
+
+// The logs of finished steps are kept in the minio store of the pipeline namespace, with the same
+// names the Jenkins engine uses
+
+func (e *Engine) getMinioClient(ns string) (*minio.Client, error) {
+	svc, err := e.ServiceLister.Get(ns, utils.MinioName)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s:%d", svc.Spec.ClusterIP, utils.MinioPort)
+
+	secret, err := e.getSecret(ns, utils.PipelineSecretName)
+	if err != nil || secret.Data == nil {
+		return nil, fmt.Errorf("error get minio token - %v", err)
+	}
+	token := string(secret.Data[utils.PipelineSecretTokenKey])
+
+	if e.HTTPClient == nil {
+		dial, err := e.Dialer.ClusterDialer(e.ClusterName)
+		if err != nil {
+			return nil, err
+		}
+		e.HTTPClient = &http.Client{
+			Transport: &http.Transport{
+				DialContext: dial,
+			},
+			Timeout: 15 * time.Second,
+		}
+	}
+	return minio.New(url, &minio.Options{
+		Creds:        credentials.NewStaticV4(utils.PipelineSecretDefaultUser, token, ""),
+		Secure:       false,
+		BucketLookup: minio.BucketLookupAuto,
+		Transport:    e.HTTPClient.Transport,
+	})
+}
+
+func (e *Engine) getStepLogFromMinioStore(execution *v3.PipelineExecution, stage int, step int) (string, error) {
+	logName := fmt.Sprintf("%s-%d-%d", execution.Name, stage, step)
+	ns := utils.GetPipelineCommonName(execution.Spec.ProjectName)
+	client, err := e.getMinioClient(ns)
+	if err != nil {
+		return "", err
+	}
+
+	reader, err := client.GetObject(context.TODO(), utils.MinioLogBucket, logName, minio.GetObjectOptions{})
+	if err != nil {
+		return "", err
+	}
+	content, err := ioutil.ReadAll(reader)
+	if err != nil {
+		return "", err
+	}
+	return string(content), nil
+}
+
+func (e *Engine) saveStepLogToMinio(execution *v3.PipelineExecution, stage int, step int) error {
+	logName := fmt.Sprintf("%s-%d-%d", execution.Name, stage, step)
+	ns := utils.GetPipelineCommonName(execution.Spec.ProjectName)
+	client, err := e.getMinioClient(ns)
+	if err != nil {
+		return err
+	}
+	exists, err := client.BucketExists(context.TODO(), utils.MinioLogBucket)
+	if err != nil {
+		logrus.Error(err)
+	}
+	if !exists {
+		makeBucketOpt := minio.MakeBucketOptions{Region: utils.MinioBucketLocation}
+		if err := client.MakeBucket(context.TODO(), utils.MinioLogBucket, makeBucketOpt); err != nil {
+			return err
+		}
+	}
+
+	message, err := e.getStepLogFromPod(execution, stage, step)
+	if err != nil {
+		return err
+	}
+	_, err = client.PutObject(context.TODO(), utils.MinioLogBucket, logName, strings.NewReader(message), int64(len(message)), minio.PutObjectOptions{})
+	return err
+}
@@ -0,0 +1,219 @@
+package kubernetes
+
+import (
+	"fmt"
+
+	"github.com/pkg/errors"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/pipeline/engine/jenkins"
+	"github.com/rancher/rancher/pkg/pipeline/providers"
+	"github.com/rancher/rancher/pkg/pipeline/remote"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	"github.com/rancher/rancher/pkg/ref"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	workspaceVolumeName = "workspace"
+	workspacePath       = "/workspace"
+	gitUsernameKey      = "username"
+	gitPasswordKey      = "password"
+)
+
+// cloneScript checks out the ref of the execution into the workspace with the credential of the
+// pipeline, the credential environment variables are empty for public repositories
+const cloneScript = `git init -q .
+git config credential.helper '!f() { echo "username=${GIT_USERNAME}"; echo "password=${GIT_PASSWORD}"; }; f'
+git fetch "${CICD_GIT_URL}" "+${CICD_GIT_REF}:refs/remotes/local/temp"
+git checkout -q local/temp`
+
+func stepPodName(execution *v3.PipelineExecution, stage int, step int) string {
+	return fmt.Sprintf("%s-%s", execution.Name, stepContainerName(stage, step))
+}
+
+func stepContainerName(stage int, step int) string {
+	return fmt.Sprintf("step-%d-%d", stage, step)
+}
+
+func workspaceName(execution *v3.PipelineExecution) string {
+	return fmt.Sprintf("%s-workspace", execution.Name)
+}
+
+func gitCredentialName(execution *v3.PipelineExecution) string {
+	return fmt.Sprintf("%s-git", execution.Name)
+}
+
+func (e *Engine) newStepPod(execution *v3.PipelineExecution, stageOrdinal int, stepOrdinal int) (*corev1.Pod, error) {
+	pod, err := jenkins.NewStepPod(execution, e.PipelineSettingLister, e.SecretLister, stageOrdinal, stepOrdinal)
+	if err != nil {
+		return nil, err
+	}
+	pod.Name = stepPodName(execution, stageOrdinal, stepOrdinal)
+	pod.Spec.RestartPolicy = corev1.RestartPolicyNever
+	deadline := int64(getTimeout(execution) * 60)
+	pod.Spec.ActiveDeadlineSeconds = &deadline
+	//the workspace volume can only be attached to one node
+	pod.Spec.Affinity = &corev1.Affinity{
+		PodAffinity: &corev1.PodAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: []corev1.PodAffinityTerm{
+				{
+					LabelSelector: &metav1.LabelSelector{
+						MatchLabels: map[string]string{
+							utils.LabelKeyExecution: execution.Name,
+						},
+					},
+					TopologyKey: "kubernetes.io/hostname",
+				},
+			},
+		},
+	}
+	pod.Spec.Volumes = append(pod.Spec.Volumes, corev1.Volume{
+		Name: workspaceVolumeName,
+		VolumeSource: corev1.VolumeSource{
+			PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{
+				ClaimName: workspaceName(execution),
+			},
+		},
+	})
+
+	container := &pod.Spec.Containers[0]
+	container.TTY = false
+	container.WorkingDir = workspacePath
+	container.VolumeMounts = append(container.VolumeMounts, corev1.VolumeMount{
+		Name:      workspaceVolumeName,
+		MountPath: workspacePath,
+		SubPath:   workspaceVolumeName,
+	})
+
+	step := execution.Spec.PipelineConfig.Stages[stageOrdinal].Steps[stepOrdinal]
+	if step.SourceCodeConfig != nil {
+		container.Command = []string{"sh", "-ec", cloneScript}
+		container.Env = append(container.Env,
+			gitCredentialEnvVar(execution, "GIT_USERNAME", gitUsernameKey),
+			gitCredentialEnvVar(execution, "GIT_PASSWORD", gitPasswordKey))
+	} else if step.RunScriptConfig != nil {
+		container.Command = []string{"sh", "-xec", step.RunScriptConfig.ShellScript}
+	} else if step.PublishImageConfig != nil {
+		container.Command = []string{"/usr/local/bin/dockerd-entrypoint.sh", "/bin/drone-docker"}
+	} else if step.ApplyYamlConfig != nil {
+		container.Command = []string{"kube-apply"}
+	} else if step.PublishCatalogConfig != nil {
+		container.Command = []string{"publish-catalog"}
+	} else if step.ApplyAppConfig != nil {
+		container.Command = []string{"apply-app"}
+	} else {
+		return nil, errors.New("invalid step: no step config")
+	}
+	return pod, nil
+}
+
+func gitCredentialEnvVar(execution *v3.PipelineExecution, name string, key string) corev1.EnvVar {
+	optional := true
+	return corev1.EnvVar{
+		Name: name,
+		ValueFrom: &corev1.EnvVarSource{SecretKeyRef: &corev1.SecretKeySelector{
+			LocalObjectReference: corev1.LocalObjectReference{
+				Name: gitCredentialName(execution),
+			},
+			Key:      key,
+			Optional: &optional,
+		}},
+	}
+}
+
+// prepareWorkspace creates the volume shared by the steps of the execution
+func (e *Engine) prepareWorkspace(execution *v3.PipelineExecution) error {
+	_, projectID := ref.Parse(execution.Spec.ProjectName)
+	size := utils.SettingWorkspaceSizeDefault
+	if setting, err := e.PipelineSettingLister.Get(projectID, utils.SettingWorkspaceSize); err == nil && setting.Value != "" {
+		size = setting.Value
+	} else if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	quantity, err := resource.ParseQuantity(size)
+	if err != nil {
+		return errors.Wrapf(err, "invalid workspace size %q", size)
+	}
+
+	pvc := &corev1.PersistentVolumeClaim{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      workspaceName(execution),
+			Namespace: utils.GetPipelineCommonName(execution.Spec.ProjectName),
+			Labels: map[string]string{
+				utils.LabelKeyExecution: execution.Name,
+			},
+		},
+		Spec: corev1.PersistentVolumeClaimSpec{
+			AccessModes: []corev1.PersistentVolumeAccessMode{corev1.ReadWriteOnce},
+			Resources: corev1.ResourceRequirements{
+				Requests: corev1.ResourceList{
+					corev1.ResourceStorage: quantity,
+				},
+			},
+		},
+	}
+	if _, err := e.PersistentVolumeClaims.Create(pvc); err != nil && !apierrors.IsAlreadyExists(err) {
+		return errors.Wrap(err, "Error creating the pipeline workspace")
+	}
+	return nil
+}
+
+// prepareGitCredential stores the credential the clone step uses to check out the code in the
+// pipeline namespace
+func (e *Engine) prepareGitCredential(execution *v3.PipelineExecution) error {
+	ns, name := ref.Parse(execution.Spec.PipelineName)
+	pipeline, err := e.PipelineLister.Get(ns, name)
+	if err != nil {
+		return err
+	}
+	credentialID := pipeline.Spec.SourceCodeCredentialName
+	if credentialID == "" {
+		return nil
+	}
+	ns, name = ref.Parse(credentialID)
+	credential, err := e.SourceCodeCredentialLister.Get(ns, name)
+	if err != nil {
+		return err
+	}
+
+	_, projID := ref.Parse(execution.Spec.ProjectName)
+	scpConfig, err := providers.GetSourceCodeProviderConfig(credential.Spec.SourceCodeType, projID)
+	if err != nil {
+		return err
+	}
+	remote, err := remote.New(scpConfig)
+	if err != nil {
+		return err
+	}
+	password := credential.Spec.AccessToken
+	if credential.Spec.GitCloneToken != "" {
+		password = credential.Spec.GitCloneToken
+	}
+	if accessToken, err := utils.EnsureAccessToken(e.SourceCodeCredentials, remote, credential); err != nil {
+		return err
+	} else if accessToken != credential.Spec.AccessToken {
+		password = accessToken
+	}
+
+	secret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      gitCredentialName(execution),
+			Namespace: utils.GetPipelineCommonName(execution.Spec.ProjectName),
+			Labels: map[string]string{
+				utils.LabelKeyExecution: execution.Name,
+			},
+		},
+		Data: map[string][]byte{
+			gitUsernameKey: []byte(credential.Spec.GitLoginName),
+			gitPasswordKey: []byte(password),
+		},
+	}
+	_, err = e.Secrets.Create(secret)
+	if apierrors.IsAlreadyExists(err) {
+		_, err = e.Secrets.Update(secret)
+	}
+	return err
+}
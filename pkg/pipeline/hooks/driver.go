@@ -44,4 +44,16 @@ func RegisterDrivers(Management *config.ScaledContext) {
 		SourceCodeCredentials:      sourceCodeCredentials,
 		SourceCodeCredentialLister: sourceCodeCredentialLister,
 	}
+	Drivers[drivers.GiteaWebhookHeader] = drivers.GiteaDriver{
+		PipelineLister:             pipelineLister,
+		PipelineExecutions:         pipelineExecutions,
+		SourceCodeCredentials:      sourceCodeCredentials,
+		SourceCodeCredentialLister: sourceCodeCredentialLister,
+	}
+	Drivers[drivers.AzureDevOpsWebhookHeader] = drivers.AzureDevOpsDriver{
+		PipelineLister:             pipelineLister,
+		PipelineExecutions:         pipelineExecutions,
+		SourceCodeCredentials:      sourceCodeCredentials,
+		SourceCodeCredentialLister: sourceCodeCredentialLister,
+	}
 }
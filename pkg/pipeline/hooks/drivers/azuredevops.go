@@ -0,0 +1,158 @@
+package drivers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/pipeline/remote/azuredevops"
+	"github.com/rancher/rancher/pkg/pipeline/remote/model"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	"github.com/rancher/rancher/pkg/ref"
+)
+
+const (
+	// AzureDevOpsWebhookHeader is set on the service hook subscriptions with the pipeline token
+	// as Azure DevOps doesn't sign the payloads
+	AzureDevOpsWebhookHeader      = azuredevops.WebhookHeader
+	azureDevOpsPushEvent          = "git.push"
+	azureDevOpsPrCreatedEvent     = "git.pullrequest.created"
+	azureDevOpsPrUpdatedEvent     = "git.pullrequest.updated"
+	azureDevOpsStateActive        = "active"
+	azureDevOpsPullRequestPattern = "refs/pull/%d/merge"
+)
+
+type AzureDevOpsDriver struct {
+	PipelineLister             v3.PipelineLister
+	PipelineExecutions         v3.PipelineExecutionInterface
+	SourceCodeCredentials      v3.SourceCodeCredentialInterface
+	SourceCodeCredentialLister v3.SourceCodeCredentialLister
+}
+
+func (a AzureDevOpsDriver) Execute(req *http.Request) (int, error) {
+	var token string
+	if token = req.Header.Get(AzureDevOpsWebhookHeader); len(token) == 0 {
+		return http.StatusUnprocessableEntity, errors.New("azure devops webhook missing token")
+	}
+
+	pipelineID := req.URL.Query().Get("pipelineId")
+	ns, name := ref.Parse(pipelineID)
+	pipeline, err := a.PipelineLister.Get(ns, name)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+	body, err := ioutil.ReadAll(req.Body)
+	if err != nil {
+		return http.StatusUnprocessableEntity, err
+	}
+
+	if subtle.ConstantTimeCompare([]byte(pipeline.Status.Token), []byte(token)) != 1 {
+		return http.StatusUnprocessableEntity, errors.New("azure devops webhook invalid token")
+	}
+
+	if pipeline.Status.PipelineState == "inactive" {
+		return http.StatusUnavailableForLegalReasons, errors.New("pipeline is not active")
+	}
+
+	payload := &azuredevops.EventPayload{}
+	if err := json.Unmarshal(body, payload); err != nil {
+		return http.StatusUnprocessableEntity, err
+	}
+
+	info := &model.BuildInfo{}
+	switch payload.EventType {
+	case azureDevOpsPushEvent:
+		info, err = azureDevOpsParsePushPayload(payload.Resource)
+	case azureDevOpsPrCreatedEvent, azureDevOpsPrUpdatedEvent:
+		info, err = azureDevOpsParsePullRequestPayload(payload.Resource)
+	default:
+		return http.StatusUnprocessableEntity, fmt.Errorf("not trigger for event:%s", payload.EventType)
+	}
+	if err != nil {
+		return http.StatusUnprocessableEntity, err
+	}
+
+	return validateAndGeneratePipelineExecution(a.PipelineExecutions, a.SourceCodeCredentials, a.SourceCodeCredentialLister, info, pipeline)
+}
+
+func azureDevOpsParsePushPayload(raw []byte) (*model.BuildInfo, error) {
+	resource := &azuredevops.PushResource{}
+	if err := json.Unmarshal(raw, resource); err != nil {
+		return nil, err
+	}
+	if len(resource.RefUpdates) == 0 {
+		return nil, errors.New("no ref updated")
+	}
+	refUpdate := resource.RefUpdates[0]
+	if refUpdate.NewObjectID == emptyCommit {
+		return nil, fmt.Errorf("no trigger for deleting %s", refUpdate.Name)
+	}
+
+	info := &model.BuildInfo{}
+	info.TriggerType = utils.TriggerTypeWebhook
+	info.Commit = refUpdate.NewObjectID
+	info.Ref = refUpdate.Name
+	if resource.Repository != nil {
+		info.HTMLLink = fmt.Sprintf("%s/commit/%s", resource.Repository.RemoteURL, refUpdate.NewObjectID)
+	}
+	if len(resource.Commits) > 0 {
+		info.Message = resource.Commits[0].Comment
+		if resource.Commits[0].Author != nil {
+			info.Email = resource.Commits[0].Author.Email
+		}
+	}
+	if resource.PushedBy != nil {
+		info.AvatarURL = resource.PushedBy.ImageURL
+		info.Author = resource.PushedBy.DisplayName
+		info.Sender = resource.PushedBy.UniqueName
+	}
+
+	if strings.HasPrefix(refUpdate.Name, RefsTagPrefix) {
+		info.Event = utils.WebhookEventTag
+		info.Branch = strings.TrimPrefix(refUpdate.Name, RefsTagPrefix)
+		info.Message = "tag " + info.Branch
+	} else {
+		info.Event = utils.WebhookEventPush
+		info.Branch = strings.TrimPrefix(refUpdate.Name, RefsBranchPrefix)
+	}
+	return info, nil
+}
+
+func azureDevOpsParsePullRequestPayload(raw []byte) (*model.BuildInfo, error) {
+	resource := &azuredevops.PullRequestResource{}
+	if err := json.Unmarshal(raw, resource); err != nil {
+		return nil, err
+	}
+	if resource.Status != azureDevOpsStateActive {
+		return nil, fmt.Errorf("no trigger for %s pull requests", resource.Status)
+	}
+	// Azure DevOps only provides the merge ref of pull requests, which points to the last merge commit.
+	// There is none while the pull request has merge conflicts.
+	if resource.LastMergeCommit == nil {
+		return nil, errors.New("no merge commit in pull request")
+	}
+
+	info := &model.BuildInfo{}
+	info.TriggerType = utils.TriggerTypeWebhook
+	info.Event = utils.WebhookEventPullRequest
+	info.Branch = strings.TrimPrefix(resource.TargetRefName, RefsBranchPrefix)
+	info.Ref = fmt.Sprintf(azureDevOpsPullRequestPattern, resource.PullRequestID)
+	if resource.Repository != nil {
+		info.HTMLLink = fmt.Sprintf("%s/pullrequest/%d", resource.Repository.RemoteURL, resource.PullRequestID)
+	}
+	info.Title = resource.Title
+	info.Message = resource.Title
+	info.Commit = resource.LastMergeCommit.CommitID
+	if resource.CreatedBy != nil {
+		info.Author = resource.CreatedBy.DisplayName
+		info.AvatarURL = resource.CreatedBy.ImageURL
+		info.Email = resource.CreatedBy.UniqueName
+		info.Sender = resource.CreatedBy.UniqueName
+	}
+	return info, nil
+}
@@ -0,0 +1,116 @@
+package drivers
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v3 "github.com/rancher/rancher/pkg/apis/project.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3/fakes"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVerifyGiteaWebhookSignature(t *testing.T) {
+	a := assert.New(t)
+	body := []byte(`{"ref":"refs/heads/master"}`)
+	mac := hmac.New(sha256.New, []byte("token"))
+	mac.Write(body)
+	signature := hex.EncodeToString(mac.Sum(nil))
+
+	a.True(verifyGiteaWebhookSignature([]byte("token"), signature, body))
+	a.False(verifyGiteaWebhookSignature([]byte("other"), signature, body))
+	a.False(verifyGiteaWebhookSignature([]byte("token"), "invalid", body))
+}
+
+func TestGiteaParsePushPayload(t *testing.T) {
+	a := assert.New(t)
+	info, err := giteaParsePushPayload([]byte(`{
+		"ref": "refs/tags/v1.0",
+		"after": "abc",
+		"head_commit": {"id": "abc", "message": "release", "url": "https://gitea.com/o/r/commit/abc"},
+		"sender": {"login": "user"}
+	}`))
+	a.Nil(err)
+	a.Equal(utils.WebhookEventTag, info.Event)
+	a.Equal("v1.0", info.Branch)
+	a.Equal("abc", info.Commit)
+	a.Equal("user", info.Author)
+
+	_, err = giteaParsePushPayload([]byte(`{"ref": "refs/heads/master", "after": "` + emptyCommit + `"}`))
+	a.NotNil(err)
+}
+
+func TestAzureDevOpsParsePayloads(t *testing.T) {
+	a := assert.New(t)
+	info, err := azureDevOpsParsePushPayload([]byte(`{
+		"refUpdates": [{"name": "refs/heads/dev", "newObjectId": "abc"}],
+		"commits": [{"commitId": "abc", "comment": "fix", "author": {"email": "user@example.com"}}],
+		"repository": {"remoteUrl": "https://dev.azure.com/org/project/_git/repo"},
+		"pushedBy": {"displayName": "User", "uniqueName": "user@example.com"}
+	}`))
+	a.Nil(err)
+	a.Equal(utils.WebhookEventPush, info.Event)
+	a.Equal("dev", info.Branch)
+	a.Equal("fix", info.Message)
+	a.Equal("https://dev.azure.com/org/project/_git/repo/commit/abc", info.HTMLLink)
+
+	info, err = azureDevOpsParsePullRequestPayload([]byte(`{
+		"pullRequestId": 7,
+		"status": "active",
+		"title": "feature",
+		"targetRefName": "refs/heads/master",
+		"sourceRefName": "refs/heads/feature",
+		"lastMergeSourceCommit": {"commitId": "abc"},
+		"lastMergeCommit": {"commitId": "def"}
+	}`))
+	a.Nil(err)
+	a.Equal(utils.WebhookEventPullRequest, info.Event)
+	a.Equal("master", info.Branch)
+	a.Equal("refs/pull/7/merge", info.Ref)
+	a.Equal("def", info.Commit)
+
+	_, err = azureDevOpsParsePullRequestPayload([]byte(`{"status": "completed"}`))
+	a.NotNil(err)
+
+	// pull requests with merge conflicts have no merge commit
+	_, err = azureDevOpsParsePullRequestPayload([]byte(`{
+		"pullRequestId": 7,
+		"status": "active",
+		"lastMergeSourceCommit": {"commitId": "abc"}
+	}`))
+	a.NotNil(err)
+}
+
+func TestAzureDevOpsExecuteToken(t *testing.T) {
+	a := assert.New(t)
+	driver := AzureDevOpsDriver{
+		PipelineLister: &fakes.PipelineListerMock{
+			GetFunc: func(namespace string, name string) (*v3.Pipeline, error) {
+				pipeline := &v3.Pipeline{}
+				pipeline.Status.Token = "token"
+				pipeline.Status.PipelineState = "inactive"
+				return pipeline, nil
+			},
+		},
+	}
+	execute := func(token string) int {
+		req := httptest.NewRequest(http.MethodPost, "/hooks?pipelineId=p-abc:p-xyz", strings.NewReader(`{}`))
+		if token != "" {
+			req.Header.Set(AzureDevOpsWebhookHeader, token)
+		}
+		code, err := driver.Execute(req)
+		a.NotNil(err)
+		return code
+	}
+
+	a.Equal(http.StatusUnprocessableEntity, execute(""))
+	a.Equal(http.StatusUnprocessableEntity, execute("other"))
+	a.Equal(http.StatusUnprocessableEntity, execute("tokenx"))
+	// a valid token passes the check and fails on the inactive pipeline
+	a.Equal(http.StatusUnavailableForLegalReasons, execute("token"))
+}
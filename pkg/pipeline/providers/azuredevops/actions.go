@@ -0,0 +1,148 @@
+package azuredevops
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+
+	v32 "github.com/rancher/rancher/pkg/apis/project.cattle.io/v3"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/norman/api/access"
+	"github.com/rancher/norman/httperror"
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+	client "github.com/rancher/rancher/pkg/client/generated/project/v3"
+	"github.com/rancher/rancher/pkg/pipeline/remote/model"
+	"github.com/rancher/rancher/pkg/ref"
+)
+
+const (
+	azureDevOpsAuthorizeURL = "https://app.vssps.visualstudio.com/oauth2/authorize"
+	azureDevOpsScopes       = "vso.code_full vso.code_status vso.hooks_write vso.profile"
+	actionDisable           = "disable"
+	actionTestAndApply      = "testAndApply"
+	actionLogin             = "login"
+)
+
+func (a *AdProvider) Formatter(apiContext *types.APIContext, resource *types.RawResource) {
+	if convert.ToBool(resource.Values["enabled"]) {
+		resource.AddAction(apiContext, actionDisable)
+	}
+
+	resource.AddAction(apiContext, actionTestAndApply)
+}
+
+func (a *AdProvider) ActionHandler(actionName string, action *types.Action, request *types.APIContext) error {
+	if actionName == actionTestAndApply {
+		return a.testAndApply(actionName, action, request)
+	} else if actionName == actionDisable {
+		return a.DisableAction(request, a.GetName())
+	}
+
+	return httperror.NewAPIError(httperror.ActionNotAvailable, "")
+}
+
+func (a *AdProvider) providerFormatter(apiContext *types.APIContext, resource *types.RawResource) {
+	resource.AddAction(apiContext, actionLogin)
+}
+
+func (a *AdProvider) providerActionHandler(actionName string, action *types.Action, request *types.APIContext) error {
+	if actionName == actionLogin {
+		return a.authuser(request)
+	}
+
+	return httperror.NewAPIError(httperror.ActionNotAvailable, "")
+}
+
+func formAzureDevOpsRedirectURLFromMap(config map[string]interface{}) string {
+	clientID := convert.ToString(config[client.AzureDevOpsPipelineConfigFieldClientID])
+	return fmt.Sprintf("%s?client_id=%s&response_type=Assertion&scope=%s", azureDevOpsAuthorizeURL, clientID, url.QueryEscape(azureDevOpsScopes))
+}
+
+func (a *AdProvider) testAndApply(actionName string, action *types.Action, apiContext *types.APIContext) error {
+	applyInput := &v32.AzureDevOpsApplyInput{}
+
+	if err := json.NewDecoder(apiContext.Request.Body).Decode(applyInput); err != nil {
+		return httperror.NewAPIError(httperror.InvalidBodyContent,
+			fmt.Sprintf("Failed to parse body: %v", err))
+	}
+
+	ns, _ := ref.Parse(apiContext.ID)
+	pConfig, err := a.GetProviderConfig(ns)
+	if err != nil {
+		return err
+	}
+	storedAzureDevOpsPipelineConfig, ok := pConfig.(*v32.AzureDevOpsPipelineConfig)
+	if !ok {
+		return fmt.Errorf("Failed to get azure devops provider config")
+	}
+	toUpdate := storedAzureDevOpsPipelineConfig.DeepCopy()
+	toUpdate.Organization = applyInput.Organization
+	toUpdate.ClientID = applyInput.ClientID
+	toUpdate.ClientSecret = applyInput.ClientSecret
+	toUpdate.RedirectURL = applyInput.RedirectURL
+
+	//oauth and add user
+	userName := apiContext.Request.Header.Get("Impersonate-User")
+	sourceCodeCredential, err := a.AuthAddAccount(userName, applyInput.Code, toUpdate, toUpdate.ProjectName, model.AzureDevOpsType)
+	if err != nil {
+		return err
+	}
+	if _, err = a.RefreshReposByCredentialAndConfig(sourceCodeCredential, toUpdate); err != nil {
+		return err
+	}
+
+	toUpdate.Enabled = true
+	//update azure devops pipeline config
+	if _, err = a.SourceCodeProviderConfigs.ObjectClient().Update(toUpdate.Name, toUpdate); err != nil {
+		return err
+	}
+
+	apiContext.WriteResponse(http.StatusOK, nil)
+	return nil
+}
+
+func (a *AdProvider) authuser(apiContext *types.APIContext) error {
+	authUserInput := v32.AuthUserInput{}
+	requestBytes, err := ioutil.ReadAll(apiContext.Request.Body)
+	if err != nil {
+		return err
+	}
+	if err := json.Unmarshal(requestBytes, &authUserInput); err != nil {
+		return err
+	}
+
+	ns, _ := ref.Parse(apiContext.ID)
+	pConfig, err := a.GetProviderConfig(ns)
+	if err != nil {
+		return err
+	}
+	config, ok := pConfig.(*v32.AzureDevOpsPipelineConfig)
+	if !ok {
+		return fmt.Errorf("Failed to get azure devops provider config")
+	}
+	if !config.Enabled {
+		return errors.New("azure devops oauth app is not configured")
+	}
+
+	//oauth and add user
+	userName := apiContext.Request.Header.Get("Impersonate-User")
+	account, err := a.AuthAddAccount(userName, authUserInput.Code, config, config.ProjectName, model.AzureDevOpsType)
+	if err != nil {
+		return err
+	}
+	data := map[string]interface{}{}
+	if err := access.ByID(apiContext, apiContext.Version, client.SourceCodeCredentialType, account.Name, &data); err != nil {
+		return err
+	}
+
+	if _, err := a.RefreshReposByCredentialAndConfig(account, config); err != nil {
+		return err
+	}
+
+	apiContext.WriteResponse(http.StatusOK, data)
+	return nil
+}
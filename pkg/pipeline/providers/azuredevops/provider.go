@@ -0,0 +1,73 @@
+package azuredevops
+
+import (
+	"fmt"
+
+	v32 "github.com/rancher/rancher/pkg/apis/project.cattle.io/v3"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/rancher/norman/store/subtype"
+	"github.com/rancher/norman/types"
+	client "github.com/rancher/rancher/pkg/client/generated/project/v3"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/pipeline/providers/common"
+	"github.com/rancher/rancher/pkg/pipeline/remote/model"
+	schema "github.com/rancher/rancher/pkg/schemas/project.cattle.io/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type AdProvider struct {
+	common.BaseProvider
+}
+
+func (a *AdProvider) CustomizeSchemas(schemas *types.Schemas) {
+	scpConfigBaseSchema := schemas.Schema(&schema.Version, client.SourceCodeProviderConfigType)
+	configSchema := schemas.Schema(&schema.Version, client.AzureDevOpsPipelineConfigType)
+	configSchema.ActionHandler = a.ActionHandler
+	configSchema.Formatter = a.Formatter
+	configSchema.Store = subtype.NewSubTypeStore(client.AzureDevOpsPipelineConfigType, scpConfigBaseSchema.Store)
+
+	providerBaseSchema := schemas.Schema(&schema.Version, client.SourceCodeProviderType)
+	providerSchema := schemas.Schema(&schema.Version, client.AzureDevOpsProviderType)
+	providerSchema.Formatter = a.providerFormatter
+	providerSchema.ActionHandler = a.providerActionHandler
+	providerSchema.Store = subtype.NewSubTypeStore(client.AzureDevOpsProviderType, providerBaseSchema.Store)
+}
+
+func (a *AdProvider) GetName() string {
+	return model.AzureDevOpsType
+}
+
+func (a *AdProvider) TransformToSourceCodeProvider(config map[string]interface{}) map[string]interface{} {
+	m := a.BaseProvider.TransformToSourceCodeProvider(config, client.AzureDevOpsProviderType)
+	m[client.AzureDevOpsProviderFieldRedirectURL] = formAzureDevOpsRedirectURLFromMap(config)
+	return m
+}
+
+func (a *AdProvider) GetProviderConfig(projectID string) (interface{}, error) {
+	scpConfigObj, err := a.SourceCodeProviderConfigs.ObjectClient().UnstructuredClient().GetNamespaced(projectID, model.AzureDevOpsType, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve AzureDevOpsConfig, error: %v", err)
+	}
+
+	u, ok := scpConfigObj.(runtime.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("failed to retrieve AzureDevOpsConfig, cannot read k8s Unstructured data")
+	}
+	storedAzureDevOpsPipelineConfigMap := u.UnstructuredContent()
+
+	storedAzureDevOpsPipelineConfig := &v32.AzureDevOpsPipelineConfig{}
+	if err := mapstructure.Decode(storedAzureDevOpsPipelineConfigMap, storedAzureDevOpsPipelineConfig); err != nil {
+		return nil, fmt.Errorf("failed to decode the config, error: %v", err)
+	}
+
+	objectMeta, err := common.ObjectMetaFromUnstructureContent(storedAzureDevOpsPipelineConfigMap)
+	if err != nil {
+		return nil, err
+	}
+	storedAzureDevOpsPipelineConfig.ObjectMeta = *objectMeta
+	storedAzureDevOpsPipelineConfig.APIVersion = "project.cattle.io/v3"
+	storedAzureDevOpsPipelineConfig.Kind = v3.SourceCodeProviderConfigGroupVersionKind.Kind
+	return storedAzureDevOpsPipelineConfig, nil
+}
@@ -0,0 +1,95 @@
+package gitea
+
+import (
+	"fmt"
+
+	v32 "github.com/rancher/rancher/pkg/apis/project.cattle.io/v3"
+
+	"github.com/mitchellh/mapstructure"
+	"github.com/rancher/norman/store/subtype"
+	"github.com/rancher/norman/types"
+	"github.com/rancher/norman/types/convert"
+	client "github.com/rancher/rancher/pkg/client/generated/project/v3"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/pipeline/providers/common"
+	"github.com/rancher/rancher/pkg/pipeline/remote/model"
+	schema "github.com/rancher/rancher/pkg/schemas/project.cattle.io/v3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+type GtProvider struct {
+	common.BaseProvider
+}
+
+func (g *GtProvider) CustomizeSchemas(schemas *types.Schemas) {
+	scpConfigBaseSchema := schemas.Schema(&schema.Version, client.SourceCodeProviderConfigType)
+	configSchema := schemas.Schema(&schema.Version, client.GiteaPipelineConfigType)
+	configSchema.ActionHandler = g.ActionHandler
+	configSchema.Formatter = g.Formatter
+	configSchema.Store = subtype.NewSubTypeStore(client.GiteaPipelineConfigType, scpConfigBaseSchema.Store)
+
+	providerBaseSchema := schemas.Schema(&schema.Version, client.SourceCodeProviderType)
+	providerSchema := schemas.Schema(&schema.Version, client.GiteaProviderType)
+	providerSchema.Formatter = g.providerFormatter
+	providerSchema.ActionHandler = g.providerActionHandler
+	providerSchema.Store = subtype.NewSubTypeStore(client.GiteaProviderType, providerBaseSchema.Store)
+}
+
+func (g *GtProvider) GetName() string {
+	return model.GiteaType
+}
+
+func (g *GtProvider) TransformToSourceCodeProvider(config map[string]interface{}) map[string]interface{} {
+	m := g.BaseProvider.TransformToSourceCodeProvider(config, client.GiteaProviderType)
+	m[client.GiteaProviderFieldRedirectURL] = formGiteaRedirectURLFromMap(config)
+	return m
+}
+
+func (g *GtProvider) GetProviderConfig(projectID string) (interface{}, error) {
+	scpConfigObj, err := g.SourceCodeProviderConfigs.ObjectClient().UnstructuredClient().GetNamespaced(projectID, model.GiteaType, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to retrieve GiteaConfig, error: %v", err)
+	}
+
+	u, ok := scpConfigObj.(runtime.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("failed to retrieve GiteaConfig, cannot read k8s Unstructured data")
+	}
+	storedGiteaPipelineConfigMap := u.UnstructuredContent()
+
+	storedGiteaPipelineConfig := &v32.GiteaPipelineConfig{}
+	if err := mapstructure.Decode(storedGiteaPipelineConfigMap, storedGiteaPipelineConfig); err != nil {
+		return nil, fmt.Errorf("failed to decode the config, error: %v", err)
+	}
+
+	objectMeta, err := common.ObjectMetaFromUnstructureContent(storedGiteaPipelineConfigMap)
+	if err != nil {
+		return nil, err
+	}
+	storedGiteaPipelineConfig.ObjectMeta = *objectMeta
+	storedGiteaPipelineConfig.APIVersion = "project.cattle.io/v3"
+	storedGiteaPipelineConfig.Kind = v3.SourceCodeProviderConfigGroupVersionKind.Kind
+	return storedGiteaPipelineConfig, nil
+}
+
+func formGiteaRedirectURLFromMap(config map[string]interface{}) string {
+	hostname := convert.ToString(config[client.GiteaPipelineConfigFieldHostname])
+	clientID := convert.ToString(config[client.GiteaPipelineConfigFieldClientID])
+	tls := convert.ToBool(config[client.GiteaPipelineConfigFieldTLS])
+	return giteaRedirectURL(hostname, clientID, tls)
+}
+
+func giteaRedirectURL(hostname, clientID string, tls bool) string {
+	redirect := ""
+	if hostname != "" {
+		scheme := "http://"
+		if tls {
+			scheme = "https://"
+		}
+		redirect = scheme + hostname
+	} else {
+		redirect = giteaDefaultHostName
+	}
+	return fmt.Sprintf("%s/login/oauth/authorize?client_id=%s&response_type=code", redirect, clientID)
+}
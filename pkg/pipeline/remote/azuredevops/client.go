@@ -0,0 +1,555 @@
+package azuredevops
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	v32 "github.com/rancher/rancher/pkg/apis/project.cattle.io/v3"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/norman/httperror"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/pipeline/remote/model"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	"github.com/rancher/rancher/pkg/ref"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	apiEndpoint      = "https://dev.azure.com/%s"
+	profileEndpoint  = "https://app.vssps.visualstudio.com/_apis/profile/profiles/me"
+	tokenURL         = "https://app.vssps.visualstudio.com/oauth2/token"
+	apiVersion       = "6.0"
+	cloneUserName    = "oauth2"
+	assertionType    = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+	jwtBearerGrant   = "urn:ietf:params:oauth:grant-type:jwt-bearer"
+	refreshGrant     = "refresh_token"
+	statusGenre      = "rancher-pipeline"
+	refsHeadsPrefix  = "refs/heads/"
+	WebhookHeader    = "X-Azure-DevOps-Token"
+	hookConsumerID   = "webHooks"
+	hookConsumerAct  = "httpRequest"
+	hookPublisherID  = "tfs"
+	hookResourceVer  = "1.0"
+	pushEventType    = "git.push"
+	prCreatedEvent   = "git.pullrequest.created"
+	prUpdatedEvent   = "git.pullrequest.updated"
+	changeTypeAdd    = "add"
+	changeTypeEdit   = "edit"
+	contentTypeRaw   = "rawtext"
+	stateSucceeded   = "succeeded"
+	stateFailed      = "failed"
+	statePending     = "pending"
+	stateError       = "error"
+	defaultUserAgent = "rancher-pipeline"
+)
+
+type client struct {
+	Organization string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	API          string
+}
+
+func New(config *v32.AzureDevOpsPipelineConfig) (model.Remote, error) {
+	if config == nil {
+		return nil, errors.New("empty azure devops config")
+	}
+	if config.Organization == "" {
+		return nil, errors.New("empty azure devops organization")
+	}
+	adClient := &client{
+		Organization: config.Organization,
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		RedirectURL:  config.RedirectURL,
+		API:          fmt.Sprintf(apiEndpoint, url.PathEscape(config.Organization)),
+	}
+	return adClient, nil
+}
+
+func (c *client) Type() string {
+	return model.AzureDevOpsType
+}
+
+func (c *client) Login(code string) (*v3.SourceCodeCredential, error) {
+	token, err := c.exchange(jwtBearerGrant, code)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := c.getProfile(token.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	cred := convertProfile(profile)
+	cred.Spec.HTMLURL = c.API
+	setToken(cred, token)
+	return cred, nil
+}
+
+func (c *client) Refresh(cred *v3.SourceCodeCredential) (bool, error) {
+	if cred == nil {
+		return false, errors.New("cannot refresh empty credentials")
+	}
+	token, err := c.exchange(refreshGrant, cred.Spec.RefreshToken)
+	if err != nil {
+		return false, err
+	}
+	setToken(cred, token)
+	return true, nil
+}
+
+// exchange gets an access token with either an authorization code or a refresh token. Azure DevOps
+// expects the client secret as a jwt assertion instead of the standard oauth2 client credentials.
+func (c *client) exchange(grantType string, assertion string) (*Token, error) {
+	data := url.Values{}
+	data.Set("client_assertion_type", assertionType)
+	data.Set("client_assertion", c.ClientSecret)
+	data.Set("grant_type", grantType)
+	data.Set("assertion", assertion)
+	data.Set("redirect_uri", c.RedirectURL)
+
+	header := map[string]string{"Content-Type": "application/x-www-form-urlencoded"}
+	b, err := doRequestToAzureDevOps(http.MethodPost, tokenURL, "", header, strings.NewReader(data.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	token := &Token{}
+	if err := json.Unmarshal(b, token); err != nil {
+		return nil, err
+	}
+	if token.AccessToken == "" {
+		return nil, fmt.Errorf("Fail to get accesstoken with oauth config")
+	}
+	return token, nil
+}
+
+func setToken(cred *v3.SourceCodeCredential, token *Token) {
+	expiresIn, _ := token.ExpiresIn.Int64()
+	cred.Spec.AccessToken = token.AccessToken
+	cred.Spec.RefreshToken = token.RefreshToken
+	cred.Spec.Expiry = time.Now().Add(time.Duration(expiresIn) * time.Second).Format(time.RFC3339)
+}
+
+func (c *client) Repos(account *v3.SourceCodeCredential) ([]v3.SourceCodeRepository, error) {
+	if account == nil {
+		return nil, fmt.Errorf("empty account")
+	}
+	b, err := getFromAzureDevOps(c.API+"/_apis/git/repositories", account.Spec.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	repos := &RepositoryList{}
+	if err := json.Unmarshal(b, repos); err != nil {
+		return nil, err
+	}
+
+	return convertRepos(repos.Value), nil
+}
+
+func (c *client) CreateHook(pipeline *v3.Pipeline, accessToken string) (string, error) {
+	repo, err := c.getRepository(pipeline.Spec.RepositoryURL, accessToken)
+	if err != nil {
+		return "", err
+	}
+	if repo.Project == nil {
+		return "", fmt.Errorf("no project found for repository %s", repo.Name)
+	}
+	hookURL := fmt.Sprintf("%s/hooks?pipelineId=%s", settings.ServerURL.Get(), ref.Ref(pipeline))
+
+	var id string
+	for _, eventType := range []string{pushEventType, prCreatedEvent, prUpdatedEvent} {
+		subscription := &Subscription{
+			PublisherID:      hookPublisherID,
+			EventType:        eventType,
+			ResourceVersion:  hookResourceVer,
+			ConsumerID:       hookConsumerID,
+			ConsumerActionID: hookConsumerAct,
+			PublisherInputs: map[string]string{
+				"projectId":  repo.Project.ID,
+				"repository": repo.ID,
+			},
+			ConsumerInputs: map[string]string{
+				"url":         hookURL,
+				"httpHeaders": fmt.Sprintf("%s:%s", WebhookHeader, pipeline.Status.Token),
+			},
+		}
+		b, err := json.Marshal(subscription)
+		if err != nil {
+			return "", err
+		}
+		resp, err := doRequestToAzureDevOps(http.MethodPost, c.API+"/_apis/hooks/subscriptions", accessToken, jsonHeader(), bytes.NewReader(b))
+		if err != nil {
+			return "", err
+		}
+		if err := json.Unmarshal(resp, subscription); err != nil {
+			return "", err
+		}
+		if id == "" {
+			id = subscription.ID
+		}
+	}
+
+	return id, nil
+}
+
+func (c *client) DeleteHook(pipeline *v3.Pipeline, accessToken string) error {
+	b, err := getFromAzureDevOps(c.API+"/_apis/hooks/subscriptions", accessToken)
+	if err != nil {
+		return err
+	}
+	subscriptions := &SubscriptionList{}
+	if err := json.Unmarshal(b, subscriptions); err != nil {
+		return err
+	}
+	for _, subscription := range subscriptions.Value {
+		if !strings.HasSuffix(subscription.ConsumerInputs["url"], fmt.Sprintf("hooks?pipelineId=%s", ref.Ref(pipeline))) {
+			continue
+		}
+		url := fmt.Sprintf("%s/_apis/hooks/subscriptions/%s", c.API, subscription.ID)
+		if _, err := doRequestToAzureDevOps(http.MethodDelete, url, accessToken, nil, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *client) getFileFromRepo(filename string, repoAPI string, branch string, accessToken string) (*Item, error) {
+	q := url.Values{}
+	q.Set("path", "/"+filename)
+	q.Set("includeContent", "true")
+	q.Set("$format", "json")
+	if branch != "" {
+		q.Set("versionDescriptor.version", branch)
+		q.Set("versionDescriptor.versionType", "branch")
+	}
+	b, err := getFromAzureDevOps(fmt.Sprintf("%s/items?%s", repoAPI, q.Encode()), accessToken)
+	if err != nil {
+		return nil, err
+	}
+	item := &Item{}
+	if err := json.Unmarshal(b, item); err != nil {
+		return nil, err
+	}
+	return item, nil
+}
+
+func (c *client) GetPipelineFileInRepo(repoURL string, branch string, accessToken string) ([]byte, error) {
+	repoAPI, err := c.getRepoAPI(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	item, err := c.getFileFromRepo(utils.PipelineFileYml, repoAPI, branch, accessToken)
+	if err != nil {
+		//look for both suffix
+		item, err = c.getFileFromRepo(utils.PipelineFileYaml, repoAPI, branch, accessToken)
+	}
+	if err != nil {
+		logrus.Debugf("error GetPipelineFileInRepo - %v", err)
+		return nil, nil
+	}
+	if item.Content != "" {
+		return []byte(item.Content), nil
+	}
+	return nil, nil
+}
+
+func (c *client) SetPipelineFileInRepo(repoURL string, branch string, accessToken string, content []byte) error {
+	repoAPI, err := c.getRepoAPI(repoURL)
+	if err != nil {
+		return err
+	}
+	currentItem, err := c.getFileFromRepo(utils.PipelineFileYml, repoAPI, branch, accessToken)
+	currentFileName := utils.PipelineFileYml
+	if err != nil {
+		if httpErr, ok := err.(*httperror.APIError); !ok || httpErr.Code.Status != http.StatusNotFound {
+			return err
+		}
+		//look for both suffix
+		currentItem, err = c.getFileFromRepo(utils.PipelineFileYaml, repoAPI, branch, accessToken)
+		if err != nil {
+			if httpErr, ok := err.(*httperror.APIError); !ok || httpErr.Code.Status != http.StatusNotFound {
+				return err
+			}
+		} else {
+			currentFileName = utils.PipelineFileYaml
+		}
+	}
+
+	head, err := c.getBranchRef(repoAPI, branch, accessToken)
+	if err != nil {
+		return err
+	}
+	message := "Create .rancher-pipeline.yml file"
+	changeType := changeTypeAdd
+	if currentItem != nil {
+		//update pipeline file
+		message = fmt.Sprintf("Update %s file", currentFileName)
+		changeType = changeTypeEdit
+	}
+	push := &Push{
+		RefUpdates: []RefUpdate{{
+			Name:        head.Name,
+			OldObjectID: head.ObjectID,
+		}},
+		Commits: []Change{{
+			Comment: message,
+			Changes: []ItemChange{{
+				ChangeType: changeType,
+				Item:       ItemPath{Path: "/" + currentFileName},
+				NewContent: &NewContent{
+					Content:     string(content),
+					ContentType: contentTypeRaw,
+				},
+			}},
+		}},
+	}
+	b, err := json.Marshal(push)
+	if err != nil {
+		return err
+	}
+	_, err = doRequestToAzureDevOps(http.MethodPost, repoAPI+"/pushes", accessToken, jsonHeader(), bytes.NewReader(b))
+	return err
+}
+
+func (c *client) GetBranches(repoURL string, accessToken string) ([]string, error) {
+	repoAPI, err := c.getRepoAPI(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	refs, err := c.getRefs(repoAPI, "heads/", accessToken)
+	if err != nil {
+		return nil, err
+	}
+	var result []string
+	for _, r := range refs {
+		result = append(result, strings.TrimPrefix(r.Name, refsHeadsPrefix))
+	}
+	return result, nil
+}
+
+func (c *client) GetHeadInfo(repoURL string, branch string, accessToken string) (*model.BuildInfo, error) {
+	repoAPI, err := c.getRepoAPI(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	head, err := c.getBranchRef(repoAPI, branch, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	b, err := getFromAzureDevOps(fmt.Sprintf("%s/commits/%s", repoAPI, head.ObjectID), accessToken)
+	if err != nil {
+		return nil, err
+	}
+	commit := &Commit{}
+	if err := json.Unmarshal(b, commit); err != nil {
+		return nil, err
+	}
+
+	info := &model.BuildInfo{}
+	info.Commit = commit.CommitID
+	info.Ref = head.Name
+	info.Branch = branch
+	info.Message = commit.Comment
+	info.HTMLLink = commit.RemoteURL
+	if commit.Author != nil {
+		info.Author = commit.Author.Name
+		info.Email = commit.Author.Email
+	}
+	return info, nil
+}
+
+func (c *client) SetCommitStatus(repoURL string, commit string, status *model.CommitStatus, accessToken string) error {
+	repoAPI, err := c.getRepoAPI(repoURL)
+	if err != nil {
+		return err
+	}
+	gitStatus := &GitStatus{
+		State:       convertCommitState(status.State),
+		Description: status.Description,
+		TargetURL:   status.TargetURL,
+		Context: GitStatusContext{
+			Name:  status.Context,
+			Genre: statusGenre,
+		},
+	}
+	b, err := json.Marshal(gitStatus)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/commits/%s/statuses", repoAPI, commit)
+	_, err = doRequestToAzureDevOps(http.MethodPost, url, accessToken, jsonHeader(), bytes.NewReader(b))
+	return err
+}
+
+func convertCommitState(state string) string {
+	switch state {
+	case model.CommitStateSuccess:
+		return stateSucceeded
+	case model.CommitStateFailure:
+		return stateFailed
+	case model.CommitStateError:
+		return stateError
+	}
+	return statePending
+}
+
+func (c *client) getRepository(repoURL string, accessToken string) (*Repository, error) {
+	repoAPI, err := c.getRepoAPI(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	b, err := getFromAzureDevOps(repoAPI, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	repo := &Repository{}
+	if err := json.Unmarshal(b, repo); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+func (c *client) getBranchRef(repoAPI string, branch string, accessToken string) (*Ref, error) {
+	refs, err := c.getRefs(repoAPI, "heads/"+branch, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range refs {
+		if r.Name == refsHeadsPrefix+branch {
+			return &r, nil
+		}
+	}
+	return nil, httperror.NewAPIError(httperror.NotFound, fmt.Sprintf("branch %s not found", branch))
+}
+
+func (c *client) getRefs(repoAPI string, filter string, accessToken string) ([]Ref, error) {
+	b, err := getFromAzureDevOps(fmt.Sprintf("%s/refs?filter=%s", repoAPI, url.QueryEscape(filter)), accessToken)
+	if err != nil {
+		return nil, err
+	}
+	refs := &RefList{}
+	if err := json.Unmarshal(b, refs); err != nil {
+		return nil, err
+	}
+	return refs.Value, nil
+}
+
+// getRepoAPI returns the git API endpoint of the repository. Repository URLs look like
+// https://{organization}@dev.azure.com/{organization}/{project}/_git/{repository}
+func (c *client) getRepoAPI(repoURL string) (string, error) {
+	project, repo, err := getProjectRepoFromURL(repoURL)
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s/%s/_apis/git/repositories/%s", c.API, project, repo), nil
+}
+
+func (c *client) getProfile(accessToken string) (*Profile, error) {
+	b, err := getFromAzureDevOps(profileEndpoint, accessToken)
+	if err != nil {
+		return nil, err
+	}
+	profile := &Profile{}
+	if err := json.Unmarshal(b, profile); err != nil {
+		return nil, err
+	}
+	return profile, nil
+}
+
+func convertProfile(profile *Profile) *v3.SourceCodeCredential {
+	if profile == nil {
+		return nil
+	}
+	cred := &v3.SourceCodeCredential{}
+	cred.Spec.SourceCodeType = model.AzureDevOpsType
+
+	cred.Spec.LoginName = profile.EmailAddress
+	if cred.Spec.LoginName == "" {
+		cred.Spec.LoginName = profile.PublicAlias
+	}
+	cred.Spec.GitLoginName = cloneUserName
+	cred.Spec.DisplayName = profile.DisplayName
+
+	return cred
+}
+
+func convertRepos(repos []Repository) []v3.SourceCodeRepository {
+	result := []v3.SourceCodeRepository{}
+	for _, repo := range repos {
+		r := v3.SourceCodeRepository{}
+		r.Spec.URL = repo.RemoteURL
+		r.Spec.DefaultBranch = strings.TrimPrefix(repo.DefaultBranch, refsHeadsPrefix)
+		r.Spec.Permissions.Admin = true
+		r.Spec.Permissions.Pull = true
+		r.Spec.Permissions.Push = true
+		result = append(result, r)
+	}
+	return result
+}
+
+func jsonHeader() map[string]string {
+	return map[string]string{"Content-Type": "application/json"}
+}
+
+func getFromAzureDevOps(url string, accessToken string) ([]byte, error) {
+	return doRequestToAzureDevOps(http.MethodGet, url, accessToken, nil, nil)
+}
+
+func doRequestToAzureDevOps(method string, url string, accessToken string, header map[string]string, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+	if url != tokenURL {
+		q := req.URL.Query()
+		q.Set("api-version", apiVersion)
+		req.URL.RawQuery = q.Encode()
+	}
+	if accessToken != "" {
+		req.Header.Add("Authorization", "Bearer "+accessToken)
+	}
+	req.Header.Add("Accept", "application/json")
+	req.Header.Add("User-Agent", defaultUserAgent)
+	for k, v := range header {
+		req.Header.Set(k, v)
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	// Check the status code
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+		var body bytes.Buffer
+		io.Copy(&body, resp.Body)
+		return nil, httperror.NewAPIErrorLong(resp.StatusCode, "", body.String())
+	}
+	return ioutil.ReadAll(resp.Body)
+}
+
+func getProjectRepoFromURL(repoURL string) (string, string, error) {
+	u, err := url.Parse(repoURL)
+	if err != nil {
+		return "", "", err
+	}
+	parts := strings.Split(strings.Trim(u.EscapedPath(), "/"), "/")
+	for i := 1; i < len(parts)-1; i++ {
+		if parts[i] == "_git" {
+			return parts[i-1], strings.TrimSuffix(parts[i+1], ".git"), nil
+		}
+	}
+	return "", "", fmt.Errorf("error getting project/repo from gitrepoUrl:%v", repoURL)
+}
@@ -0,0 +1,232 @@
+package azuredevops
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	v3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/pipeline/remote/model"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetProjectRepoFromURL(t *testing.T) {
+	a := assert.New(t)
+
+	project, repo, err := getProjectRepoFromURL("https://org@dev.azure.com/org/my%20project/_git/repo")
+	a.Nil(err)
+	a.Equal("my%20project", project)
+	a.Equal("repo", repo)
+
+	project, repo, err = getProjectRepoFromURL("https://org.visualstudio.com/project/_git/repo.git")
+	a.Nil(err)
+	a.Equal("project", project)
+	a.Equal("repo", repo)
+
+	_, _, err = getProjectRepoFromURL("https://dev.azure.com/org/project/repo")
+	a.NotNil(err)
+}
+
+func TestConvertCommitState(t *testing.T) {
+	a := assert.New(t)
+	a.Equal(stateSucceeded, convertCommitState("success"))
+	a.Equal(stateFailed, convertCommitState("failure"))
+	a.Equal(stateError, convertCommitState("error"))
+	a.Equal(statePending, convertCommitState("pending"))
+}
+
+const testRepoURL = "https://org@dev.azure.com/org/project/_git/repo"
+
+// newTestClient returns a client using a fake Azure DevOps API that serves the handlers by path
+func newTestClient(t *testing.T, handlers map[string]http.HandlerFunc) *client {
+	mux := http.NewServeMux()
+	for path, handler := range handlers {
+		mux.HandleFunc(path, handler)
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return &client{API: server.URL}
+}
+
+func TestGetBranches(t *testing.T) {
+	a := assert.New(t)
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"/project/_apis/git/repositories/repo/refs": func(rw http.ResponseWriter, req *http.Request) {
+			a.Equal("heads/", req.URL.Query().Get("filter"))
+			a.Equal(apiVersion, req.URL.Query().Get("api-version"))
+			a.Equal("Bearer token", req.Header.Get("Authorization"))
+			rw.Write([]byte(`{"count": 2, "value": [{"name": "refs/heads/master"}, {"name": "refs/heads/feature/x"}]}`))
+		},
+	})
+
+	branches, err := c.GetBranches(testRepoURL, "token")
+	a.Nil(err)
+	a.Equal([]string{"master", "feature/x"}, branches)
+}
+
+func TestGetHeadInfo(t *testing.T) {
+	a := assert.New(t)
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"/project/_apis/git/repositories/repo/refs": func(rw http.ResponseWriter, req *http.Request) {
+			if req.URL.Query().Get("filter") != "heads/dev" {
+				rw.Write([]byte(`{"count": 0, "value": []}`))
+				return
+			}
+			rw.Write([]byte(`{"count": 2, "value": [{"name": "refs/heads/dev-2", "objectId": "xyz"}, {"name": "refs/heads/dev", "objectId": "abc"}]}`))
+		},
+		"/project/_apis/git/repositories/repo/commits/abc": func(rw http.ResponseWriter, req *http.Request) {
+			rw.Write([]byte(`{"commitId": "abc", "comment": "fix", "remoteUrl": "https://dev.azure.com/org/project/_git/repo/commit/abc", "author": {"name": "User", "email": "user@example.com"}}`))
+		},
+	})
+
+	info, err := c.GetHeadInfo(testRepoURL, "dev", "token")
+	a.Nil(err)
+	a.Equal("abc", info.Commit)
+	a.Equal("refs/heads/dev", info.Ref)
+	a.Equal("dev", info.Branch)
+	a.Equal("fix", info.Message)
+	a.Equal("User", info.Author)
+	a.Equal("user@example.com", info.Email)
+	a.Equal("https://dev.azure.com/org/project/_git/repo/commit/abc", info.HTMLLink)
+
+	_, err = c.GetHeadInfo(testRepoURL, "missing", "token")
+	a.NotNil(err)
+}
+
+func TestGetPipelineFileInRepo(t *testing.T) {
+	a := assert.New(t)
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"/project/_apis/git/repositories/repo/items": func(rw http.ResponseWriter, req *http.Request) {
+			a.Equal("dev", req.URL.Query().Get("versionDescriptor.version"))
+			if req.URL.Query().Get("path") != "/"+utils.PipelineFileYaml {
+				http.NotFound(rw, req)
+				return
+			}
+			rw.Write([]byte(`{"path": "/.rancher-pipeline.yaml", "content": "stages: []"}`))
+		},
+	})
+
+	content, err := c.GetPipelineFileInRepo(testRepoURL, "dev", "token")
+	a.Nil(err)
+	a.Equal("stages: []", string(content))
+}
+
+func TestSetPipelineFileInRepo(t *testing.T) {
+	for _, existing := range []bool{false, true} {
+		a := assert.New(t)
+		var push Push
+		c := newTestClient(t, map[string]http.HandlerFunc{
+			"/project/_apis/git/repositories/repo/items": func(rw http.ResponseWriter, req *http.Request) {
+				if !existing || req.URL.Query().Get("path") != "/"+utils.PipelineFileYml {
+					http.NotFound(rw, req)
+					return
+				}
+				rw.Write([]byte(`{"path": "/.rancher-pipeline.yml", "content": "stages: []"}`))
+			},
+			"/project/_apis/git/repositories/repo/refs": func(rw http.ResponseWriter, req *http.Request) {
+				rw.Write([]byte(`{"count": 1, "value": [{"name": "refs/heads/master", "objectId": "abc"}]}`))
+			},
+			"/project/_apis/git/repositories/repo/pushes": func(rw http.ResponseWriter, req *http.Request) {
+				a.Equal(http.MethodPost, req.Method)
+				a.Nil(json.NewDecoder(req.Body).Decode(&push))
+				rw.WriteHeader(http.StatusCreated)
+			},
+		})
+
+		a.Nil(c.SetPipelineFileInRepo(testRepoURL, "master", "token", []byte("stages: [build]")))
+		a.Equal([]RefUpdate{{Name: "refs/heads/master", OldObjectID: "abc"}}, push.RefUpdates)
+		if a.Len(push.Commits, 1) && a.Len(push.Commits[0].Changes, 1) {
+			change := push.Commits[0].Changes[0]
+			a.Equal("/"+utils.PipelineFileYml, change.Item.Path)
+			a.Equal("stages: [build]", change.NewContent.Content)
+			if existing {
+				a.Equal(changeTypeEdit, change.ChangeType)
+			} else {
+				a.Equal(changeTypeAdd, change.ChangeType)
+			}
+		}
+	}
+}
+
+func TestSetCommitStatus(t *testing.T) {
+	a := assert.New(t)
+	var status GitStatus
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"/project/_apis/git/repositories/repo/commits/abc/statuses": func(rw http.ResponseWriter, req *http.Request) {
+			a.Equal(http.MethodPost, req.Method)
+			a.Equal("application/json", req.Header.Get("Content-Type"))
+			a.Nil(json.NewDecoder(req.Body).Decode(&status))
+			rw.WriteHeader(http.StatusCreated)
+		},
+	})
+
+	a.Nil(c.SetCommitStatus(testRepoURL, "abc", &model.CommitStatus{
+		State:       model.CommitStateSuccess,
+		Description: "build succeeded",
+		TargetURL:   "https://rancher/p-xyz",
+		Context:     "rancher",
+	}, "token"))
+	a.Equal(GitStatus{
+		State:       stateSucceeded,
+		Description: "build succeeded",
+		TargetURL:   "https://rancher/p-xyz",
+		Context: GitStatusContext{
+			Name:  "rancher",
+			Genre: statusGenre,
+		},
+	}, status)
+}
+
+func TestCreateAndDeleteHook(t *testing.T) {
+	a := assert.New(t)
+	pipeline := &v3.Pipeline{}
+	pipeline.Namespace = "p-abc"
+	pipeline.Name = "p-xyz"
+	pipeline.Spec.RepositoryURL = testRepoURL
+	pipeline.Status.Token = "secret"
+
+	var subscriptions []Subscription
+	var deleted []string
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"/project/_apis/git/repositories/repo": func(rw http.ResponseWriter, req *http.Request) {
+			rw.Write([]byte(`{"id": "repo-id", "name": "repo", "project": {"id": "project-id"}}`))
+		},
+		"/_apis/hooks/subscriptions": func(rw http.ResponseWriter, req *http.Request) {
+			if req.Method == http.MethodGet {
+				json.NewEncoder(rw).Encode(SubscriptionList{Count: len(subscriptions), Value: append(subscriptions, Subscription{
+					ID:             "other",
+					ConsumerInputs: map[string]string{"url": "https://rancher/hooks?pipelineId=p-abc:p-other"},
+				})})
+				return
+			}
+			subscription := Subscription{}
+			a.Nil(json.NewDecoder(req.Body).Decode(&subscription))
+			subscription.ID = fmt.Sprint(len(subscriptions))
+			subscriptions = append(subscriptions, subscription)
+			json.NewEncoder(rw).Encode(subscription)
+		},
+		"/_apis/hooks/subscriptions/": func(rw http.ResponseWriter, req *http.Request) {
+			a.Equal(http.MethodDelete, req.Method)
+			deleted = append(deleted, strings.TrimPrefix(req.URL.Path, "/_apis/hooks/subscriptions/"))
+		},
+	})
+
+	id, err := c.CreateHook(pipeline, "token")
+	a.Nil(err)
+	a.Equal("0", id)
+	a.Len(subscriptions, 3)
+	for i, eventType := range []string{pushEventType, prCreatedEvent, prUpdatedEvent} {
+		a.Equal(eventType, subscriptions[i].EventType)
+		a.Equal("project-id", subscriptions[i].PublisherInputs["projectId"])
+		a.Equal("repo-id", subscriptions[i].PublisherInputs["repository"])
+		a.True(strings.HasSuffix(subscriptions[i].ConsumerInputs["url"], "/hooks?pipelineId=p-abc:p-xyz"))
+		a.Equal(WebhookHeader+":secret", subscriptions[i].ConsumerInputs["httpHeaders"])
+	}
+
+	a.Nil(c.DeleteHook(pipeline, "token"))
+	a.Equal([]string{"0", "1", "2"}, deleted)
+}
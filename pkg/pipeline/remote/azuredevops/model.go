@@ -0,0 +1,157 @@
+package azuredevops
+
+import "encoding/json"
+
+type Token struct {
+	AccessToken  string      `json:"access_token"`
+	TokenType    string      `json:"token_type"`
+	ExpiresIn    json.Number `json:"expires_in"`
+	RefreshToken string      `json:"refresh_token"`
+}
+
+type Profile struct {
+	ID           string `json:"id"`
+	DisplayName  string `json:"displayName"`
+	PublicAlias  string `json:"publicAlias"`
+	EmailAddress string `json:"emailAddress"`
+}
+
+type Project struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+type Repository struct {
+	ID            string   `json:"id"`
+	Name          string   `json:"name"`
+	URL           string   `json:"url"`
+	RemoteURL     string   `json:"remoteUrl"`
+	WebURL        string   `json:"webUrl"`
+	DefaultBranch string   `json:"defaultBranch"`
+	Project       *Project `json:"project"`
+}
+
+type RepositoryList struct {
+	Count int          `json:"count"`
+	Value []Repository `json:"value"`
+}
+
+type Ref struct {
+	Name     string `json:"name"`
+	ObjectID string `json:"objectId"`
+}
+
+type RefList struct {
+	Count int   `json:"count"`
+	Value []Ref `json:"value"`
+}
+
+type GitUserDate struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+	Date  string `json:"date"`
+}
+
+type Commit struct {
+	CommitID  string       `json:"commitId"`
+	Comment   string       `json:"comment"`
+	Author    *GitUserDate `json:"author"`
+	Committer *GitUserDate `json:"committer"`
+	URL       string       `json:"url"`
+	RemoteURL string       `json:"remoteUrl"`
+}
+
+type Item struct {
+	ObjectID string `json:"objectId"`
+	CommitID string `json:"commitId"`
+	Path     string `json:"path"`
+	Content  string `json:"content"`
+}
+
+type Push struct {
+	RefUpdates []RefUpdate `json:"refUpdates"`
+	Commits    []Change    `json:"commits"`
+}
+
+type RefUpdate struct {
+	Name        string `json:"name"`
+	OldObjectID string `json:"oldObjectId"`
+	NewObjectID string `json:"newObjectId,omitempty"`
+}
+
+type Change struct {
+	Comment string       `json:"comment"`
+	Changes []ItemChange `json:"changes"`
+}
+
+type ItemChange struct {
+	ChangeType string      `json:"changeType"`
+	Item       ItemPath    `json:"item"`
+	NewContent *NewContent `json:"newContent"`
+}
+
+type ItemPath struct {
+	Path string `json:"path"`
+}
+
+type NewContent struct {
+	Content     string `json:"content"`
+	ContentType string `json:"contentType"`
+}
+
+type Subscription struct {
+	ID               string            `json:"id,omitempty"`
+	PublisherID      string            `json:"publisherId"`
+	EventType        string            `json:"eventType"`
+	ResourceVersion  string            `json:"resourceVersion"`
+	ConsumerID       string            `json:"consumerId"`
+	ConsumerActionID string            `json:"consumerActionId"`
+	PublisherInputs  map[string]string `json:"publisherInputs"`
+	ConsumerInputs   map[string]string `json:"consumerInputs"`
+}
+
+type SubscriptionList struct {
+	Count int            `json:"count"`
+	Value []Subscription `json:"value"`
+}
+
+type GitStatus struct {
+	State       string           `json:"state"`
+	Description string           `json:"description,omitempty"`
+	TargetURL   string           `json:"targetUrl,omitempty"`
+	Context     GitStatusContext `json:"context"`
+}
+
+type GitStatusContext struct {
+	Name  string `json:"name"`
+	Genre string `json:"genre"`
+}
+
+type EventPayload struct {
+	EventType string          `json:"eventType"`
+	Resource  json.RawMessage `json:"resource"`
+}
+
+type Identity struct {
+	DisplayName string `json:"displayName"`
+	UniqueName  string `json:"uniqueName"`
+	ImageURL    string `json:"imageUrl"`
+}
+
+type PushResource struct {
+	Commits    []Commit    `json:"commits"`
+	RefUpdates []RefUpdate `json:"refUpdates"`
+	Repository *Repository `json:"repository"`
+	PushedBy   *Identity   `json:"pushedBy"`
+}
+
+type PullRequestResource struct {
+	PullRequestID   int         `json:"pullRequestId"`
+	Status          string      `json:"status"`
+	CreatedBy       *Identity   `json:"createdBy"`
+	Title           string      `json:"title"`
+	SourceRefName   string      `json:"sourceRefName"`
+	TargetRefName   string      `json:"targetRefName"`
+	LastMergeCommit *Commit     `json:"lastMergeCommit"`
+	Repository      *Repository `json:"repository"`
+}
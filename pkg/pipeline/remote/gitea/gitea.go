@@ -0,0 +1,503 @@
+package gitea
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	v32 "github.com/rancher/rancher/pkg/apis/project.cattle.io/v3"
+
+	"github.com/pkg/errors"
+	"github.com/rancher/norman/httperror"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/pipeline/remote/model"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	"github.com/rancher/rancher/pkg/ref"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/sirupsen/logrus"
+	"github.com/tomnomnom/linkheader"
+	"golang.org/x/oauth2"
+)
+
+const (
+	defaultGiteaHost = "gitea.com"
+	giteaAPI         = "%s%s/api/v1"
+	maxPerPage       = "50"
+)
+
+type client struct {
+	Scheme       string
+	Host         string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	API          string
+}
+
+func New(config *v32.GiteaPipelineConfig) (model.Remote, error) {
+	if config == nil {
+		return nil, errors.New("empty gitea config")
+	}
+	gtClient := &client{
+		ClientID:     config.ClientID,
+		ClientSecret: config.ClientSecret,
+		RedirectURL:  config.RedirectURL,
+	}
+	if config.Hostname != "" && config.Hostname != defaultGiteaHost {
+		gtClient.Host = config.Hostname
+		if config.TLS {
+			gtClient.Scheme = "https://"
+		} else {
+			gtClient.Scheme = "http://"
+		}
+	} else {
+		gtClient.Scheme = "https://"
+		gtClient.Host = defaultGiteaHost
+	}
+	gtClient.API = fmt.Sprintf(giteaAPI, gtClient.Scheme, gtClient.Host)
+	return gtClient, nil
+}
+
+func (c *client) Type() string {
+	return model.GiteaType
+}
+
+func (c *client) oauthConfig() *oauth2.Config {
+	return &oauth2.Config{
+		ClientID:     c.ClientID,
+		ClientSecret: c.ClientSecret,
+		RedirectURL:  c.RedirectURL,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  fmt.Sprintf("%s%s/login/oauth/authorize", c.Scheme, c.Host),
+			TokenURL: fmt.Sprintf("%s%s/login/oauth/access_token", c.Scheme, c.Host),
+		},
+	}
+}
+
+func (c *client) Login(code string) (*v3.SourceCodeCredential, error) {
+	token, err := c.oauthConfig().Exchange(oauth2.NoContext, code)
+	if err != nil {
+		return nil, err
+	} else if strings.ToLower(token.TokenType) != "bearer" || token.AccessToken == "" {
+		return nil, fmt.Errorf("Fail to get accesstoken with oauth config")
+	}
+
+	user, err := c.getUser(token.AccessToken)
+	if err != nil {
+		return nil, err
+	}
+	cred := convertUser(user)
+	cred.Spec.HTMLURL = fmt.Sprintf("%s%s/%s", c.Scheme, c.Host, user.UserName)
+	cred.Spec.AccessToken = token.AccessToken
+	cred.Spec.RefreshToken = token.RefreshToken
+	cred.Spec.Expiry = token.Expiry.Format(time.RFC3339)
+	return cred, nil
+}
+
+func (c *client) Refresh(cred *v3.SourceCodeCredential) (bool, error) {
+	if cred == nil {
+		return false, errors.New("cannot refresh empty credentials")
+	}
+	source := c.oauthConfig().TokenSource(
+		oauth2.NoContext, &oauth2.Token{RefreshToken: cred.Spec.RefreshToken})
+
+	token, err := source.Token()
+	if err != nil || len(token.AccessToken) == 0 {
+		return false, err
+	}
+
+	cred.Spec.AccessToken = token.AccessToken
+	cred.Spec.RefreshToken = token.RefreshToken
+	cred.Spec.Expiry = token.Expiry.Format(time.RFC3339)
+
+	return true, nil
+}
+
+func (c *client) Repos(account *v3.SourceCodeCredential) ([]v3.SourceCodeRepository, error) {
+	if account == nil {
+		return nil, fmt.Errorf("empty account")
+	}
+	responseBodies, err := paginateGitea(account.Spec.AccessToken, c.API+"/user/repos")
+	if err != nil {
+		return nil, err
+	}
+
+	var repos []Repository
+	for _, b := range responseBodies {
+		var reposObj []Repository
+		if err := json.Unmarshal(b, &reposObj); err != nil {
+			return nil, err
+		}
+		repos = append(repos, reposObj...)
+	}
+
+	return convertRepos(repos), nil
+}
+
+func (c *client) CreateHook(pipeline *v3.Pipeline, accessToken string) (string, error) {
+	owner, repo, err := getOwnerRepoFromURL(pipeline.Spec.RepositoryURL)
+	if err != nil {
+		return "", err
+	}
+	hookURL := fmt.Sprintf("%s/hooks?pipelineId=%s", settings.ServerURL.Get(), ref.Ref(pipeline))
+	hook := &Hook{
+		Type: "gitea",
+		Config: map[string]string{
+			"url":          hookURL,
+			"content_type": "json",
+			"secret":       pipeline.Status.Token,
+		},
+		Events: []string{"push", "pull_request"},
+		Active: true,
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/hooks", c.API, owner, repo)
+	b, err := doRequestToGitea(http.MethodPost, url, accessToken, hook)
+	if err != nil {
+		return "", err
+	}
+	if err := json.Unmarshal(b, hook); err != nil {
+		return "", err
+	}
+
+	return strconv.FormatInt(hook.ID, 10), nil
+}
+
+func (c *client) DeleteHook(pipeline *v3.Pipeline, accessToken string) error {
+	owner, repo, err := getOwnerRepoFromURL(pipeline.Spec.RepositoryURL)
+	if err != nil {
+		return err
+	}
+	hook, err := c.getHook(pipeline, accessToken)
+	if err != nil {
+		return err
+	}
+	if hook != nil {
+		url := fmt.Sprintf("%s/repos/%s/%s/hooks/%d", c.API, owner, repo, hook.ID)
+		if _, err := doRequestToGitea(http.MethodDelete, url, accessToken, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (c *client) getHook(pipeline *v3.Pipeline, accessToken string) (*Hook, error) {
+	owner, repo, err := getOwnerRepoFromURL(pipeline.Spec.RepositoryURL)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/hooks", c.API, owner, repo)
+	responseBodies, err := paginateGitea(accessToken, url)
+	if err != nil {
+		return nil, err
+	}
+	for _, b := range responseBodies {
+		var hooks []Hook
+		if err := json.Unmarshal(b, &hooks); err != nil {
+			return nil, err
+		}
+		for _, hook := range hooks {
+			if strings.HasSuffix(hook.Config["url"], fmt.Sprintf("hooks?pipelineId=%s", ref.Ref(pipeline))) {
+				return &hook, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func (c *client) getFileFromRepo(filename string, owner string, repo string, ref string, accessToken string) (*ContentsResponse, error) {
+	apiURL := fmt.Sprintf("%s/repos/%s/%s/contents/%s?ref=%s", c.API, owner, repo, filename, url.QueryEscape(ref))
+	b, err := getFromGitea(accessToken, apiURL)
+	if err != nil {
+		return nil, err
+	}
+	file := &ContentsResponse{}
+	if err := json.Unmarshal(b, file); err != nil {
+		return nil, err
+	}
+	return file, nil
+}
+
+func (c *client) GetPipelineFileInRepo(repoURL string, ref string, accessToken string) ([]byte, error) {
+	owner, repo, err := getOwnerRepoFromURL(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	file, err := c.getFileFromRepo(utils.PipelineFileYml, owner, repo, ref, accessToken)
+	if err != nil {
+		//look for both suffix
+		file, err = c.getFileFromRepo(utils.PipelineFileYaml, owner, repo, ref, accessToken)
+	}
+	if err != nil {
+		logrus.Debugf("error GetPipelineFileInRepo - %v", err)
+		return nil, nil
+	}
+	if file.Content != "" {
+		return base64.StdEncoding.DecodeString(file.Content)
+	}
+	return nil, nil
+}
+
+func (c *client) SetPipelineFileInRepo(repoURL string, branch string, accessToken string, content []byte) error {
+	owner, repo, err := getOwnerRepoFromURL(repoURL)
+	if err != nil {
+		return err
+	}
+	currentFile, err := c.getFileFromRepo(utils.PipelineFileYml, owner, repo, branch, accessToken)
+	currentFileName := utils.PipelineFileYml
+	if err != nil {
+		if httpErr, ok := err.(*httperror.APIError); !ok || httpErr.Code.Status != http.StatusNotFound {
+			return err
+		}
+		//look for both suffix
+		currentFile, err = c.getFileFromRepo(utils.PipelineFileYaml, owner, repo, branch, accessToken)
+		if err != nil {
+			if httpErr, ok := err.(*httperror.APIError); !ok || httpErr.Code.Status != http.StatusNotFound {
+				return err
+			}
+		} else {
+			currentFileName = utils.PipelineFileYaml
+		}
+	}
+
+	url := fmt.Sprintf("%s/repos/%s/%s/contents/%s", c.API, owner, repo, currentFileName)
+	method := http.MethodPost
+	option := &FileOptions{
+		Branch:  branch,
+		Message: "Create .rancher-pipeline.yml file",
+		Content: base64.StdEncoding.EncodeToString(content),
+	}
+	if currentFile != nil {
+		//update pipeline file
+		method = http.MethodPut
+		option.Message = fmt.Sprintf("Update %s file", currentFileName)
+		option.SHA = currentFile.SHA
+	}
+
+	_, err = doRequestToGitea(method, url, accessToken, option)
+	return err
+}
+
+func (c *client) GetBranches(repoURL string, accessToken string) ([]string, error) {
+	owner, repo, err := getOwnerRepoFromURL(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/branches", c.API, owner, repo)
+	responseBodies, err := paginateGitea(accessToken, url)
+	if err != nil {
+		return nil, err
+	}
+
+	var result []string
+	for _, b := range responseBodies {
+		var branches []Branch
+		if err := json.Unmarshal(b, &branches); err != nil {
+			return nil, err
+		}
+		for _, branch := range branches {
+			result = append(result, branch.Name)
+		}
+	}
+	return result, nil
+}
+
+func (c *client) GetHeadInfo(repoURL string, branch string, accessToken string) (*model.BuildInfo, error) {
+	owner, repo, err := getOwnerRepoFromURL(repoURL)
+	if err != nil {
+		return nil, err
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/branches/%s", c.API, owner, repo, branch)
+	b, err := getFromGitea(accessToken, url)
+	if err != nil {
+		return nil, err
+	}
+	branchObj := &Branch{}
+	if err := json.Unmarshal(b, branchObj); err != nil {
+		return nil, err
+	}
+	if branchObj.Commit == nil {
+		return nil, errors.New("no commit found")
+	}
+
+	info := &model.BuildInfo{}
+	info.Commit = branchObj.Commit.ID
+	info.Ref = "refs/heads/" + branch
+	info.Branch = branch
+	info.Message = branchObj.Commit.Message
+	info.HTMLLink = branchObj.Commit.URL
+	if branchObj.Commit.Author != nil {
+		info.Author = branchObj.Commit.Author.UserName
+		info.Email = branchObj.Commit.Author.Email
+	}
+	user, err := c.getUser(accessToken)
+	if err != nil {
+		return nil, err
+	}
+	info.AvatarURL = user.AvatarURL
+
+	return info, nil
+}
+
+func (c *client) SetCommitStatus(repoURL string, commit string, status *model.CommitStatus, accessToken string) error {
+	owner, repo, err := getOwnerRepoFromURL(repoURL)
+	if err != nil {
+		return err
+	}
+	url := fmt.Sprintf("%s/repos/%s/%s/statuses/%s", c.API, owner, repo, commit)
+	_, err = doRequestToGitea(http.MethodPost, url, accessToken, &CommitStatus{
+		State:       status.State,
+		TargetURL:   status.TargetURL,
+		Description: status.Description,
+		Context:     status.Context,
+	})
+	return err
+}
+
+func (c *client) getUser(accessToken string) (*User, error) {
+	b, err := getFromGitea(accessToken, c.API+"/user")
+	if err != nil {
+		return nil, err
+	}
+	user := &User{}
+	if err := json.Unmarshal(b, user); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+func convertUser(giteaUser *User) *v3.SourceCodeCredential {
+	if giteaUser == nil {
+		return nil
+	}
+	cred := &v3.SourceCodeCredential{}
+	cred.Spec.SourceCodeType = model.GiteaType
+
+	cred.Spec.AvatarURL = giteaUser.AvatarURL
+	cred.Spec.LoginName = giteaUser.UserName
+	cred.Spec.GitLoginName = giteaUser.UserName
+	cred.Spec.DisplayName = giteaUser.FullName
+	if cred.Spec.DisplayName == "" {
+		cred.Spec.DisplayName = giteaUser.UserName
+	}
+
+	return cred
+}
+
+func convertRepos(repos []Repository) []v3.SourceCodeRepository {
+	result := []v3.SourceCodeRepository{}
+	for _, repo := range repos {
+		r := v3.SourceCodeRepository{}
+		r.Spec.URL = repo.CloneURL
+		r.Spec.DefaultBranch = repo.DefaultBranch
+		if repo.Permissions != nil {
+			r.Spec.Permissions.Admin = repo.Permissions.Admin
+			r.Spec.Permissions.Pull = repo.Permissions.Pull
+			r.Spec.Permissions.Push = repo.Permissions.Push
+		}
+		result = append(result, r)
+	}
+	return result
+}
+
+func getFromGitea(accessToken string, url string) ([]byte, error) {
+	b, _, err := doRequestWithHeader(http.MethodGet, url, accessToken, nil)
+	return b, err
+}
+
+func doRequestToGitea(method string, url string, accessToken string, opt interface{}) ([]byte, error) {
+	b, _, err := doRequestWithHeader(method, url, accessToken, opt)
+	return b, err
+}
+
+func doRequestWithHeader(method string, url string, accessToken string, opt interface{}) ([]byte, http.Header, error) {
+	var body io.Reader
+	if opt != nil {
+		b, err := json.Marshal(opt)
+		if err != nil {
+			return nil, nil, err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	client := &http.Client{
+		Timeout: 30 * time.Second,
+	}
+	//set to max page size to reduce query time
+	if method == http.MethodGet {
+		q := req.URL.Query()
+		if q.Get("limit") == "" {
+			q.Set("limit", maxPerPage)
+		}
+		req.URL.RawQuery = q.Encode()
+	}
+	if accessToken != "" {
+		req.Header.Add("Authorization", "Bearer "+accessToken)
+	}
+	if opt != nil {
+		req.Header.Add("Content-Type", "application/json")
+	}
+	req.Header.Add("Accept", "application/json")
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+	// Check the status code
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusBadRequest {
+		var body bytes.Buffer
+		io.Copy(&body, resp.Body)
+		return nil, nil, httperror.NewAPIErrorLong(resp.StatusCode, "", body.String())
+	}
+	r, err := ioutil.ReadAll(resp.Body)
+	return r, resp.Header, err
+}
+
+func paginateGitea(accessToken string, url string) ([][]byte, error) {
+	var responseBodies [][]byte
+	var nextURL = url
+	for nextURL != "" {
+		body, header, err := doRequestWithHeader(http.MethodGet, nextURL, accessToken, nil)
+		if err != nil {
+			return nil, err
+		}
+		responseBodies = append(responseBodies, body)
+		nextURL = nextGiteaPage(header)
+	}
+
+	return responseBodies, nil
+}
+
+func nextGiteaPage(header http.Header) string {
+	link := header.Get("link")
+	if link != "" {
+		links := linkheader.Parse(link)
+		for _, l := range links {
+			if l.Rel == "next" {
+				return l.URL
+			}
+		}
+	}
+	return ""
+}
+
+func getOwnerRepoFromURL(repoURL string) (string, string, error) {
+	reg := regexp.MustCompile(".*/([^/]*?)/([^/]*?).git")
+	match := reg.FindStringSubmatch(repoURL)
+	if len(match) != 3 {
+		return "", "", fmt.Errorf("error getting owner/repo from gitrepoUrl:%v", repoURL)
+	}
+	return match[1], match[2], nil
+}
@@ -0,0 +1,219 @@
+package gitea
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v32 "github.com/rancher/rancher/pkg/apis/project.cattle.io/v3"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/project.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/pipeline/remote/model"
+	"github.com/rancher/rancher/pkg/pipeline/utils"
+	"github.com/stretchr/testify/assert"
+)
+
+const testRepoURL = "https://gitea.example.com/owner/repo.git"
+
+// newTestClient returns a client using a fake Gitea API that serves the handlers by path
+func newTestClient(t *testing.T, handlers map[string]http.HandlerFunc) *client {
+	mux := http.NewServeMux()
+	for path, handler := range handlers {
+		mux.HandleFunc(path, handler)
+	}
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+	return &client{API: server.URL}
+}
+
+func TestNew(t *testing.T) {
+	a := assert.New(t)
+
+	remote, err := New(&v32.GiteaPipelineConfig{})
+	a.Nil(err)
+	a.Equal("https://gitea.com/api/v1", remote.(*client).API)
+
+	remote, err = New(&v32.GiteaPipelineConfig{Hostname: "gitea.example.com:3000"})
+	a.Nil(err)
+	a.Equal("http://gitea.example.com:3000/api/v1", remote.(*client).API)
+
+	_, err = New(nil)
+	a.NotNil(err)
+}
+
+func TestGetOwnerRepoFromURL(t *testing.T) {
+	a := assert.New(t)
+
+	owner, repo, err := getOwnerRepoFromURL(testRepoURL)
+	a.Nil(err)
+	a.Equal("owner", owner)
+	a.Equal("repo", repo)
+
+	_, _, err = getOwnerRepoFromURL("https://gitea.example.com/owner")
+	a.NotNil(err)
+}
+
+func TestGetBranches(t *testing.T) {
+	a := assert.New(t)
+	var c *client
+	c = newTestClient(t, map[string]http.HandlerFunc{
+		"/repos/owner/repo/branches": func(rw http.ResponseWriter, req *http.Request) {
+			a.Equal("Bearer token", req.Header.Get("Authorization"))
+			a.Equal(maxPerPage, req.URL.Query().Get("limit"))
+			if req.URL.Query().Get("page") == "2" {
+				rw.Write([]byte(`[{"name": "dev"}]`))
+				return
+			}
+			rw.Header().Set("Link", fmt.Sprintf(`<%s/repos/owner/repo/branches?page=2>; rel="next"`, c.API))
+			rw.Write([]byte(`[{"name": "master"}]`))
+		},
+	})
+
+	branches, err := c.GetBranches(testRepoURL, "token")
+	a.Nil(err)
+	a.Equal([]string{"master", "dev"}, branches)
+}
+
+func TestGetHeadInfo(t *testing.T) {
+	a := assert.New(t)
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"/repos/owner/repo/branches/dev": func(rw http.ResponseWriter, req *http.Request) {
+			rw.Write([]byte(`{"name": "dev", "commit": {"id": "abc", "message": "fix", "url": "https://gitea.example.com/owner/repo/commit/abc", "author": {"username": "user", "email": "user@example.com"}}}`))
+		},
+		"/user": func(rw http.ResponseWriter, req *http.Request) {
+			rw.Write([]byte(`{"login": "user", "avatar_url": "https://gitea.example.com/avatar"}`))
+		},
+	})
+
+	info, err := c.GetHeadInfo(testRepoURL, "dev", "token")
+	a.Nil(err)
+	a.Equal("abc", info.Commit)
+	a.Equal("refs/heads/dev", info.Ref)
+	a.Equal("dev", info.Branch)
+	a.Equal("fix", info.Message)
+	a.Equal("user", info.Author)
+	a.Equal("user@example.com", info.Email)
+	a.Equal("https://gitea.example.com/avatar", info.AvatarURL)
+
+	_, err = c.GetHeadInfo(testRepoURL, "missing", "token")
+	a.NotNil(err)
+}
+
+func TestGetPipelineFileInRepo(t *testing.T) {
+	a := assert.New(t)
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"/repos/owner/repo/contents/" + utils.PipelineFileYaml: func(rw http.ResponseWriter, req *http.Request) {
+			a.Equal("dev", req.URL.Query().Get("ref"))
+			json.NewEncoder(rw).Encode(ContentsResponse{
+				Content: base64.StdEncoding.EncodeToString([]byte("stages: []")),
+			})
+		},
+	})
+
+	content, err := c.GetPipelineFileInRepo(testRepoURL, "dev", "token")
+	a.Nil(err)
+	a.Equal("stages: []", string(content))
+}
+
+func TestSetPipelineFileInRepo(t *testing.T) {
+	for _, existing := range []bool{false, true} {
+		a := assert.New(t)
+		var method string
+		var options FileOptions
+		c := newTestClient(t, map[string]http.HandlerFunc{
+			"/repos/owner/repo/contents/" + utils.PipelineFileYml: func(rw http.ResponseWriter, req *http.Request) {
+				if req.Method == http.MethodGet {
+					if !existing {
+						http.NotFound(rw, req)
+						return
+					}
+					rw.Write([]byte(`{"sha": "abc"}`))
+					return
+				}
+				method = req.Method
+				a.Nil(json.NewDecoder(req.Body).Decode(&options))
+				rw.WriteHeader(http.StatusCreated)
+			},
+		})
+
+		a.Nil(c.SetPipelineFileInRepo(testRepoURL, "master", "token", []byte("stages: [build]")))
+		a.Equal("master", options.Branch)
+		a.Equal(base64.StdEncoding.EncodeToString([]byte("stages: [build]")), options.Content)
+		if existing {
+			a.Equal(http.MethodPut, method)
+			a.Equal("abc", options.SHA)
+		} else {
+			a.Equal(http.MethodPost, method)
+			a.Equal("", options.SHA)
+		}
+	}
+}
+
+func TestSetCommitStatus(t *testing.T) {
+	a := assert.New(t)
+	var status CommitStatus
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"/repos/owner/repo/statuses/abc": func(rw http.ResponseWriter, req *http.Request) {
+			a.Equal(http.MethodPost, req.Method)
+			a.Equal("application/json", req.Header.Get("Content-Type"))
+			a.Nil(json.NewDecoder(req.Body).Decode(&status))
+			rw.WriteHeader(http.StatusCreated)
+		},
+	})
+
+	a.Nil(c.SetCommitStatus(testRepoURL, "abc", &model.CommitStatus{
+		State:       model.CommitStateSuccess,
+		Description: "build succeeded",
+		TargetURL:   "https://rancher/p-xyz",
+		Context:     "rancher",
+	}, "token"))
+	a.Equal(CommitStatus{
+		State:       model.CommitStateSuccess,
+		Description: "build succeeded",
+		TargetURL:   "https://rancher/p-xyz",
+		Context:     "rancher",
+	}, status)
+}
+
+func TestCreateAndDeleteHook(t *testing.T) {
+	a := assert.New(t)
+	pipeline := &v3.Pipeline{}
+	pipeline.Namespace = "p-abc"
+	pipeline.Name = "p-xyz"
+	pipeline.Spec.RepositoryURL = testRepoURL
+	pipeline.Status.Token = "secret"
+
+	var created Hook
+	var deleted []string
+	c := newTestClient(t, map[string]http.HandlerFunc{
+		"/repos/owner/repo/hooks": func(rw http.ResponseWriter, req *http.Request) {
+			if req.Method == http.MethodGet {
+				json.NewEncoder(rw).Encode([]Hook{
+					{ID: 1, Config: map[string]string{"url": "https://rancher/hooks?pipelineId=p-abc:p-other"}},
+					created,
+				})
+				return
+			}
+			a.Nil(json.NewDecoder(req.Body).Decode(&created))
+			created.ID = 2
+			json.NewEncoder(rw).Encode(created)
+		},
+		"/repos/owner/repo/hooks/": func(rw http.ResponseWriter, req *http.Request) {
+			a.Equal(http.MethodDelete, req.Method)
+			deleted = append(deleted, req.URL.Path)
+		},
+	})
+
+	id, err := c.CreateHook(pipeline, "token")
+	a.Nil(err)
+	a.Equal("2", id)
+	a.Equal("gitea", created.Type)
+	a.Equal("secret", created.Config["secret"])
+	a.Equal("json", created.Config["content_type"])
+	a.Equal([]string{"push", "pull_request"}, created.Events)
+
+	a.Nil(c.DeleteHook(pipeline, "token"))
+	a.Equal([]string{"/repos/owner/repo/hooks/2"}, deleted)
+}
@@ -5,4 +5,13 @@
 	GithubType          = "github"
 	BitbucketCloudType  = "bitbucketcloud"
 	BitbucketServerType = "bitbucketserver"
+	GiteaType           = "gitea"
+	AzureDevOpsType     = "azuredevops"
+)
+
+const (
+	CommitStatePending = "pending"
+	CommitStateSuccess = "success"
+	CommitStateFailure = "failure"
+	CommitStateError   = "error"
 )
@@ -28,3 +28,15 @@ type Remote interface {
 type Refresher interface {
 	Refresh(cred *v3.SourceCodeCredential) (bool, error)
 }
+
+// StatusReporter is implemented by remotes that can report the state of pipeline executions on commits
+type StatusReporter interface {
+	SetCommitStatus(repoURL string, commit string, status *CommitStatus, accessToken string) error
+}
+
+type CommitStatus struct {
+	State       string
+	TargetURL   string
+	Description string
+	Context     string
+}
@@ -5,8 +5,10 @@
 
 	v32 "github.com/rancher/rancher/pkg/apis/project.cattle.io/v3"
 
+	"github.com/rancher/rancher/pkg/pipeline/remote/azuredevops"
 	"github.com/rancher/rancher/pkg/pipeline/remote/bitbucketcloud"
 	"github.com/rancher/rancher/pkg/pipeline/remote/bitbucketserver"
+	"github.com/rancher/rancher/pkg/pipeline/remote/gitea"
 	"github.com/rancher/rancher/pkg/pipeline/remote/github"
 	"github.com/rancher/rancher/pkg/pipeline/remote/gitlab"
 	"github.com/rancher/rancher/pkg/pipeline/remote/model"
@@ -25,6 +27,10 @@ func New(config interface{}) (model.Remote, error) {
 		return bitbucketcloud.New(config)
 	case *v32.BitbucketServerPipelineConfig:
 		return bitbucketserver.New(config)
+	case *v32.GiteaPipelineConfig:
+		return gitea.New(config)
+	case *v32.AzureDevOpsPipelineConfig:
+		return azuredevops.New(config)
 	}
 
 	return nil, errors.New("unsupported remote type")
@@ -38,6 +38,9 @@
 	MinioName                      = "minio"
 	MinioBucketLocation            = "local"
 	MinioLogBucket                 = "pipeline-logs"
+	MinioCacheBucket               = "pipeline-cache"
+	MinioArtifactBucket            = "pipeline-artifacts"
+	CacheContainerName             = "cache"
 	NetWorkPolicyName              = "pipeline-np"
 	LabelKeyApp                    = "app"
 	LabelKeyJenkins                = "jenkins"
@@ -73,6 +76,8 @@
 	PipelineFinishLabel    = "pipeline.project.cattle.io/finish"
 	LocalRegistryPortLabel = "pipeline.project.cattle.io/local-registry-port"
 	PipelineNamespaceLabel = "pipeline.project.cattle.io/pipeline-namespace"
+	PipelineEngineLabel    = "pipeline.project.cattle.io/engine"
+	CommitStatusLabel      = "pipeline.project.cattle.io/commit-status"
 
 	PipelineFileYml  = ".rancher-pipeline.yml"
 	PipelineFileYaml = ".rancher-pipeline.yaml"
@@ -107,6 +112,17 @@
 	SettingExecutorCPURequestDefault    = "10m"
 	SettingExecutorCPULimit             = "executor-cpu-limit"
 	SettingExecutorCPULimitDefault      = "1"
+	SettingEngine                       = "engine"
+	SettingEngineDefault                = EngineJenkins
+	SettingWorkspaceSize                = "workspace-size"
+	SettingWorkspaceSizeDefault         = "1Gi"
+	SettingCacheSizeLimit               = "cache-size-limit"
+	SettingCacheSizeLimitDefault        = "1Gi"
+	SettingArtifactSizeLimit            = "artifact-size-limit"
+	SettingArtifactSizeLimitDefault     = "500Mi"
+
+	EngineJenkins    = "jenkins"
+	EngineKubernetes = "kubernetes"
 
 	PipelineToolsMemoryRequestDefault = "10Mi"
 	PipelineToolsMemoryLimitDefault   = "100Mi"
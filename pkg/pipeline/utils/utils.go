@@ -3,6 +3,7 @@
 import (
 	"encoding/json"
 	"fmt"
+	"regexp"
 	"strconv"
 	"strings"
 	"time"
@@ -159,9 +160,65 @@ func ValidPipelineConfig(config v32.PipelineConfig) error {
 		config.Stages[0].Steps[0].SourceCodeConfig == nil {
 		return fmt.Errorf("invalid definition for pipeline: expect souce code step at the start")
 	}
+	for _, cache := range config.Caches {
+		if cache.Key == "" || len(cache.Paths) == 0 {
+			return fmt.Errorf("invalid definition for pipeline: expect key and paths in caches")
+		}
+	}
+	return validArtifacts(config)
+}
+
+var artifactNameRegexp = regexp.MustCompile(`^[a-zA-Z0-9][a-zA-Z0-9._-]*$`)
+
+// validArtifacts checks that artifact names are unique and that downloads within an execution
+// refer to an artifact uploaded by an earlier stage.
+func validArtifacts(config v32.PipelineConfig) error {
+	uploaded := map[string]bool{}
+	for _, stage := range config.Stages {
+		for _, step := range stage.Steps {
+			for _, download := range step.ArtifactDownloads {
+				if !artifactNameRegexp.MatchString(download.Name) {
+					return fmt.Errorf("invalid definition for pipeline: invalid artifact name %q", download.Name)
+				}
+				if !download.FromPreviousExecution && !uploaded[download.Name] {
+					return fmt.Errorf("invalid definition for pipeline: artifact %q is not uploaded by a previous stage", download.Name)
+				}
+			}
+		}
+		for _, step := range stage.Steps {
+			for _, artifact := range step.Artifacts {
+				if !artifactNameRegexp.MatchString(artifact.Name) {
+					return fmt.Errorf("invalid definition for pipeline: invalid artifact name %q", artifact.Name)
+				}
+				if len(artifact.Paths) == 0 {
+					return fmt.Errorf("invalid definition for pipeline: expect paths in artifact %q", artifact.Name)
+				}
+				if uploaded[artifact.Name] {
+					return fmt.Errorf("invalid definition for pipeline: duplicate artifact %q", artifact.Name)
+				}
+				uploaded[artifact.Name] = true
+			}
+		}
+	}
 	return nil
 }
 
+// UsesStore returns whether the pipeline restores caches or passes artifacts, which are kept in
+// the minio store of the project pipeline namespace.
+func UsesStore(config v32.PipelineConfig) bool {
+	if len(config.Caches) > 0 {
+		return true
+	}
+	for _, stage := range config.Stages {
+		for _, step := range stage.Steps {
+			if len(step.Artifacts) > 0 || len(step.ArtifactDownloads) > 0 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
 func GetPipelineCommonName(projectName string) string {
 	_, p := ref.Parse(projectName)
 	return p + PipelineNamespaceSuffix
@@ -0,0 +1,246 @@
+package clustertemplate
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	v1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	"github.com/rancher/wrangler/pkg/data/convert"
+)
+
+var templateFields = []string{
+	"clusterTemplateRevisionName",
+	"clusterTemplateAnswers",
+}
+
+// Enabled returns whether new clusters can use the revision or be upgraded to it.
+func Enabled(revision *v1.ClusterTemplateRevision) bool {
+	return revision.Spec.Enabled == nil || *revision.Spec.Enabled
+}
+
+// Render returns the cluster config of the revision with the answers to its questions applied. Answers
+// to unknown questions are an error so a cluster can only override the fields the revision allows.
+func Render(revision *v1.ClusterTemplateRevision, answers map[string]string) (map[string]interface{}, error) {
+	data, err := convert.EncodeToMap(revision.Spec.ClusterConfig)
+	if err != nil {
+		return nil, err
+	}
+	for _, field := range templateFields {
+		delete(data, field)
+	}
+
+	questions := map[string]bool{}
+	for _, question := range revision.Spec.Questions {
+		questions[question.Variable] = true
+
+		answer, ok := answers[question.Variable]
+		if !ok || answer == "" {
+			if question.Default == "" {
+				if question.Required {
+					return nil, fmt.Errorf("missing answer for required question [%s] of cluster template revision [%s]", question.Variable, revision.Name)
+				}
+				continue
+			}
+			answer = question.Default
+		}
+
+		value, err := convertAnswer(question, answer)
+		if err != nil {
+			return nil, err
+		}
+		if err := setValue(data, strings.Split(question.Variable, "."), value); err != nil {
+			return nil, fmt.Errorf("failed to answer question [%s] of cluster template revision [%s]: %w", question.Variable, revision.Name, err)
+		}
+	}
+
+	for variable := range answers {
+		if !questions[variable] {
+			return nil, fmt.Errorf("[%s] is not a question of cluster template revision [%s]", variable, revision.Name)
+		}
+	}
+
+	return data, nil
+}
+
+// ApplyDiff applies the changes between two rendered revisions to the spec of a cluster. Fields the
+// revisions don't set keep the value of the cluster and lists, like the machine pools, are replaced as a
+// whole when they differ. An empty old revision applies everything the new revision sets.
+func ApplyDiff(spec v1.ClusterSpec, oldRendered, newRendered map[string]interface{}) (v1.ClusterSpec, error) {
+	if oldRendered == nil {
+		oldRendered = map[string]interface{}{}
+	}
+
+	oldBytes, err := json.Marshal(oldRendered)
+	if err != nil {
+		return spec, err
+	}
+	newBytes, err := json.Marshal(newRendered)
+	if err != nil {
+		return spec, err
+	}
+	patch, err := jsonpatch.CreateMergePatch(oldBytes, newBytes)
+	if err != nil {
+		return spec, err
+	}
+
+	current, err := json.Marshal(spec)
+	if err != nil {
+		return spec, err
+	}
+	merged, err := jsonpatch.MergePatch(current, patch)
+	if err != nil {
+		return spec, err
+	}
+
+	var result v1.ClusterSpec
+	if err := json.Unmarshal(merged, &result); err != nil {
+		return spec, err
+	}
+	result.ClusterTemplateRevisionName = spec.ClusterTemplateRevisionName
+	result.ClusterTemplateAnswers = spec.ClusterTemplateAnswers
+	return result, nil
+}
+
+func convertAnswer(question v1.ClusterTemplateQuestion, answer string) (interface{}, error) {
+	switch question.Type {
+	case "int":
+		value, err := strconv.ParseInt(answer, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("answer [%s] to question [%s] is not an int", answer, question.Variable)
+		}
+		return value, nil
+	case "boolean":
+		value, err := strconv.ParseBool(answer)
+		if err != nil {
+			return nil, fmt.Errorf("answer [%s] to question [%s] is not a boolean", answer, question.Variable)
+		}
+		return value, nil
+	case "enum":
+		for _, option := range question.Options {
+			if option == answer {
+				return answer, nil
+			}
+		}
+		return nil, fmt.Errorf("answer [%s] to question [%s] must be one of %v", answer, question.Variable, question.Options)
+	default:
+		return answer, nil
+	}
+}
+
+// setValue sets the field at path, numeric parts of the path index into lists.
+func setValue(data interface{}, path []string, value interface{}) error {
+	key := path[0]
+	switch obj := data.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			obj[key] = value
+			return nil
+		}
+		child, ok := obj[key]
+		if !ok || child == nil {
+			if _, err := strconv.Atoi(path[1]); err == nil {
+				return fmt.Errorf("list [%s] is not set", key)
+			}
+			child = map[string]interface{}{}
+			obj[key] = child
+		}
+		return setValue(child, path[1:], value)
+	case []interface{}:
+		i, err := strconv.Atoi(key)
+		if err != nil || i < 0 || i >= len(obj) {
+			return fmt.Errorf("invalid index [%s] of list with %d items", key, len(obj))
+		}
+		if len(path) == 1 {
+			obj[i] = value
+			return nil
+		}
+		return setValue(obj[i], path[1:], value)
+	default:
+		return fmt.Errorf("field [%s] is not an object or a list", key)
+	}
+}
+
+// ValidateTemplatedFields returns an error if the spec doesn't have the values the revision sets for the
+// fields that are not questions of the revision.
+func ValidateTemplatedFields(spec v1.ClusterSpec, revision *v1.ClusterTemplateRevision) error {
+	fixed, err := convert.EncodeToMap(revision.Spec.ClusterConfig)
+	if err != nil {
+		return err
+	}
+	for _, field := range templateFields {
+		delete(fixed, field)
+	}
+	for _, question := range revision.Spec.Questions {
+		deleteValue(fixed, strings.Split(question.Variable, "."))
+	}
+
+	actual, err := convert.EncodeToMap(spec)
+	if err != nil {
+		return err
+	}
+	return compare("", fixed, actual, revision.Name)
+}
+
+func compare(path string, fixed, actual interface{}, revisionName string) error {
+	switch fixedValue := fixed.(type) {
+	case map[string]interface{}:
+		actualValue, _ := actual.(map[string]interface{})
+		for key, value := range fixedValue {
+			if err := compare(joinPath(path, key), value, actualValue[key], revisionName); err != nil {
+				return err
+			}
+		}
+		return nil
+	case []interface{}:
+		actualValue, _ := actual.([]interface{})
+		if len(actualValue) != len(fixedValue) {
+			return fmt.Errorf("field [%s] is set by cluster template revision [%s] and must have %d items", path, revisionName, len(fixedValue))
+		}
+		for i, value := range fixedValue {
+			if err := compare(joinPath(path, strconv.Itoa(i)), value, actualValue[i], revisionName); err != nil {
+				return err
+			}
+		}
+		return nil
+	case nil:
+		// a question of the revision
+		return nil
+	default:
+		if !reflect.DeepEqual(fixed, actual) {
+			return fmt.Errorf("field [%s] is set by cluster template revision [%s] and can not be changed", path, revisionName)
+		}
+		return nil
+	}
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+func deleteValue(data interface{}, path []string) {
+	switch obj := data.(type) {
+	case map[string]interface{}:
+		if len(path) == 1 {
+			delete(obj, path[0])
+		} else if child, ok := obj[path[0]]; ok {
+			deleteValue(child, path[1:])
+		}
+	case []interface{}:
+		i, err := strconv.Atoi(path[0])
+		if err != nil || i < 0 || i >= len(obj) {
+			return
+		}
+		if len(path) == 1 {
+			obj[i] = nil
+		} else {
+			deleteValue(obj[i], path[1:])
+		}
+	}
+}
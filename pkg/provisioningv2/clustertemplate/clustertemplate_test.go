@@ -0,0 +1,79 @@
+package clustertemplate
+
+import (
+	"testing"
+
+	v1 "github.com/rancher/rancher/pkg/apis/provisioning.cattle.io/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func newRevision(kubernetesVersion string, quantity int32) *v1.ClusterTemplateRevision {
+	return &v1.ClusterTemplateRevision{
+		Spec: v1.ClusterTemplateRevisionSpec{
+			Questions: []v1.ClusterTemplateQuestion{
+				{
+					Variable: "rkeConfig.machinePools.0.quantity",
+					Type:     "int",
+					Default:  "1",
+				},
+			},
+			ClusterConfig: v1.ClusterSpec{
+				KubernetesVersion: kubernetesVersion,
+				RKEConfig: &v1.RKEConfig{
+					MachinePools: []v1.RKEMachinePool{
+						{
+							Name:     "pool",
+							Quantity: &quantity,
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestRender(t *testing.T) {
+	revision := newRevision("v1.21.4+rke2r2", 1)
+
+	rendered, err := Render(revision, map[string]string{"rkeConfig.machinePools.0.quantity": "3"})
+	assert.NoError(t, err)
+	pools := rendered["rkeConfig"].(map[string]interface{})["machinePools"].([]interface{})
+	assert.Equal(t, int64(3), pools[0].(map[string]interface{})["quantity"])
+
+	_, err = Render(revision, map[string]string{"rkeConfig.machinePools.0.quantity": "three"})
+	assert.Error(t, err)
+
+	_, err = Render(revision, map[string]string{"kubernetesVersion": "v1.20.0+rke2r1"})
+	assert.Error(t, err)
+}
+
+func TestApplyDiff(t *testing.T) {
+	oldRendered, err := Render(newRevision("v1.21.4+rke2r2", 1), nil)
+	assert.NoError(t, err)
+	newRendered, err := Render(newRevision("v1.21.5+rke2r1", 1), nil)
+	assert.NoError(t, err)
+
+	spec := newRevision("v1.21.4+rke2r2", 1).Spec.ClusterConfig
+	spec.DefaultPodSecurityPolicyTemplateName = "restricted"
+	spec.ClusterTemplateRevisionName = "revision"
+
+	result, err := ApplyDiff(spec, oldRendered, newRendered)
+	assert.NoError(t, err)
+	assert.Equal(t, "v1.21.5+rke2r1", result.KubernetesVersion)
+	assert.Equal(t, "restricted", result.DefaultPodSecurityPolicyTemplateName)
+	assert.Equal(t, "revision", result.ClusterTemplateRevisionName)
+}
+
+func TestValidateTemplatedFields(t *testing.T) {
+	revision := newRevision("v1.21.4+rke2r2", 1)
+
+	spec := newRevision("v1.21.4+rke2r2", 5).Spec.ClusterConfig
+	assert.NoError(t, ValidateTemplatedFields(spec, revision))
+
+	spec.KubernetesVersion = "v1.20.0+rke2r1"
+	assert.Error(t, ValidateTemplatedFields(spec, revision))
+
+	spec = newRevision("v1.21.4+rke2r2", 1).Spec.ClusterConfig
+	spec.RKEConfig.MachinePools = append(spec.RKEConfig.MachinePools, v1.RKEMachinePool{Name: "other"})
+	assert.Error(t, ValidateTemplatedFields(spec, revision))
+}
@@ -64,19 +64,22 @@ func getKubeConfigSecretName(clusterName string) string {
 	return clusterName + "-kubeconfig"
 }
 
-func (m *Manager) getToken(clusterNamespace, clusterName string) (string, error) {
-	kubeConfigSecretName := getKubeConfigSecretName(clusterName)
-	if token, err := m.getSavedToken(clusterNamespace, kubeConfigSecretName); err != nil || token != "" {
+func getAutoscalerKubeConfigSecretName(clusterName string) string {
+	return clusterName + "-autoscaler-kubeconfig"
+}
+
+func (m *Manager) getToken(kubeConfigNamespace, kubeConfigSecretName, principalID string) (string, error) {
+	if token, err := m.getSavedToken(kubeConfigNamespace, kubeConfigSecretName); err != nil || token != "" {
 		return token, err
 	}
 
 	// Need to be careful about caches being out of sync since we are dealing with multiple objects that
 	// arent eventually consistent (because we delete and create the token for the user)
-	if token, err := m.getSavedTokenNoCache(clusterNamespace, kubeConfigSecretName); err != nil || token != "" {
+	if token, err := m.getSavedTokenNoCache(kubeConfigNamespace, kubeConfigSecretName); err != nil || token != "" {
 		return token, err
 	}
 
-	userName, err := m.EnsureUser(clusterNamespace, clusterName)
+	userName, err := m.ensureUserForPrincipal(principalID)
 	if err != nil {
 		return "", err
 	}
@@ -85,7 +88,10 @@ func (m *Manager) getToken(clusterNamespace, clusterName string) (string, error)
 }
 
 func (m *Manager) EnsureUser(clusterNamespace, clusterName string) (string, error) {
-	principalID := getPrincipalID(clusterNamespace, clusterName)
+	return m.ensureUserForPrincipal(getPrincipalID(clusterNamespace, clusterName))
+}
+
+func (m *Manager) ensureUserForPrincipal(principalID string) (string, error) {
 	userName := getUserNameForPrincipal(principalID)
 	return userName, m.createUser(principalID, userName)
 }
@@ -131,6 +137,16 @@ func getPrincipalID(clusterNamespace, clusterName string) string {
 	return fmt.Sprintf("system://provisioning/%s/%s", clusterNamespace, clusterName)
 }
 
+func getAutoscalerPrincipalID(clusterNamespace, clusterName string) string {
+	return getPrincipalID(clusterNamespace, clusterName) + "/autoscaler"
+}
+
+// GetAutoscalerUserName returns the user cluster-autoscaler of the cluster authenticates as against the
+// management cluster.
+func GetAutoscalerUserName(clusterNamespace, clusterName string) string {
+	return getUserNameForPrincipal(getAutoscalerPrincipalID(clusterNamespace, clusterName))
+}
+
 func (m *Manager) createUser(principalID, userName string) error {
 	_, err := m.userCache.Get(userName)
 	if apierror.IsNotFound(err) {
@@ -219,7 +235,7 @@ func (m *Manager) GetCRTBForAdmin(cluster *v1.Cluster, status v1.ClusterStatus)
 	}, nil
 }
 
-func (m *Manager) getKubeConfigData(cluster *v1.Cluster, secretName, managementClusterName string) (map[string][]byte, error) {
+func (m *Manager) getKubeConfigData(cluster *v1.Cluster, secretName, managementClusterName, principalID string) (map[string][]byte, error) {
 	secret, err := m.secretCache.Get(cluster.Namespace, secretName)
 	if err == nil {
 		if secret.Data == nil || secret.Data["token"] == nil || len(secret.OwnerReferences) == 0 {
@@ -244,7 +260,7 @@ func (m *Manager) getKubeConfigData(cluster *v1.Cluster, secretName, managementC
 		return secret.Data, nil
 	}
 
-	tokenValue, err := m.getToken(cluster.Namespace, cluster.Name)
+	tokenValue, err := m.getToken(cluster.Namespace, secretName, principalID)
 	if err != nil {
 		return nil, err
 	}
@@ -319,7 +335,26 @@ func (m *Manager) GetKubeConfig(cluster *v1.Cluster, status v1.ClusterStatus) (*
 		secretName = getKubeConfigSecretName(cluster.Name)
 	)
 
-	data, err := m.getKubeConfigData(cluster, secretName, status.ClusterName)
+	data, err := m.getKubeConfigData(cluster, secretName, status.ClusterName, getPrincipalID(cluster.Namespace, cluster.Name))
+	if err != nil {
+		return nil, err
+	}
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: cluster.Namespace,
+			Name:      secretName,
+		},
+		Data: data,
+	}, nil
+}
+
+// GetAutoscalerKubeConfig returns the kubeconfig cluster-autoscaler of the cluster uses to scale the
+// machine deployments in the management cluster.
+func (m *Manager) GetAutoscalerKubeConfig(cluster *v1.Cluster) (*corev1.Secret, error) {
+	secretName := getAutoscalerKubeConfigSecretName(cluster.Name)
+
+	data, err := m.getKubeConfigData(cluster, secretName, "local", getAutoscalerPrincipalID(cluster.Namespace, cluster.Name))
 	if err != nil {
 		return nil, err
 	}
@@ -7,7 +7,9 @@
 	"fmt"
 	"net/http"
 	"strings"
+	"time"
 
+	"github.com/rancher/rancher/pkg/clusterregistrationtoken"
 	corev1 "k8s.io/api/core/v1"
 	apierror "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -37,16 +39,25 @@ func (r *RKE2ConfigServer) findMachineByClusterToken(req *http.Request) (string,
 		return "", "", err
 	}
 
-	data := dataFromHeaders(req)
-
-	if len(tokens) == 0 {
+	crt, err := clusterregistrationtoken.Find(tokens, time.Now())
+	if err == clusterregistrationtoken.ErrNotUsable {
 		return "", "", nil
+	} else if err != nil {
+		return "", "", err
 	}
 
+	data := dataFromHeaders(req)
+
 	secretName := machineRequestSecretName(machineID)
-	secret, err := r.secretsCache.Get(tokens[0].Namespace, secretName)
+	secret, err := r.secretsCache.Get(crt.Namespace, secretName)
 	if apierror.IsNotFound(err) {
-		secret, err = r.createSecret(tokens[0].Namespace, secretName, data)
+		// Only a new machine counts as a use of the token, agents retrying the request don't
+		if _, err := clusterregistrationtoken.Use(r.clusterTokens, crt); err == clusterregistrationtoken.ErrNotUsable {
+			return "", "", nil
+		} else if err != nil {
+			return "", "", err
+		}
+		secret, err = r.createSecret(crt.Namespace, secretName, data)
 	}
 	if err != nil {
 		return "", "", err
@@ -0,0 +1,802 @@
+package planner
+
+import (
+	// for go:embed
+	_ "embed"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	capicontrollers "github.com/rancher/rancher/pkg/generated/controllers/cluster.x-k8s.io/v1alpha4"
+	provisioningcontrollers "github.com/rancher/rancher/pkg/generated/controllers/provisioning.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/wrangler"
+	"github.com/rancher/wrangler/pkg/data/convert"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/pkg/name"
+	"github.com/rancher/wrangler/pkg/slice"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// AdoptionAnnotation is set on the machines of an adopted cluster once their node has been read, it is either
+	// AdoptionAdopted for an existing node or AdoptionNew for a node that had nothing installed.
+	AdoptionAnnotation = "rke.cattle.io/adoption"
+	AdoptionAdopted    = "adopted"
+	AdoptionNew        = "new"
+
+	AdoptionPlanStatus        = "Adopting"
+	AdoptionPlanStatusMessage = "reading configuration of existing node"
+
+	SecretTypeAdoption = "rke.cattle.io/adoption"
+
+	adoptionInstructionName = "adoption"
+	takeoverInstructionName = "adoption-takeover"
+	adoptionCompleteLine    = "adoption complete"
+
+	// takeoverScript moves the configuration of the adopted node out of the way, so Rancher's configuration is the
+	// only one left. If the configuration generated by Rancher is equivalent the restart stamp of the installer is
+	// set to the one of the plan, the installer only restarts the service if the stamp it stored differs.
+	takeoverScript = `dir=/etc/rancher/${ADOPTION_RUNTIME}
+moved=false
+for file in "${dir}/config.yaml" "${dir}"/config.yaml.d/*.yaml "${dir}"/config.yaml.d/*.yml; do
+    if [ ! -f "${file}" ] || [ "$(basename "${file}")" = "50-rancher.yaml" ]; then
+        continue
+    fi
+    mv "${file}" "${file}.adopted"
+    moved=true
+done
+if [ "${moved}" = true ] && [ -n "${ADOPTION_RESTART_STAMP}" ]; then
+    echo "${ADOPTION_RESTART_STAMP}" > "/var/lib/rancher/${ADOPTION_RUNTIME}/restart_stamp"
+fi`
+)
+
+var (
+	//go:embed adoption.sh
+	adoptionScript string
+
+	// adoptionManagedKeys are set by the planner itself and are not part of the generated config.
+	adoptionManagedKeys = []string{
+		"token",
+		"agent-token",
+		"server",
+		"node-label",
+		"node-taint",
+		"cluster-init",
+	}
+
+	// adoptionCredentialKeys can hold credentials, like the S3 keys for etcd snapshots or a datastore endpoint with a
+	// password. Like file parameters they are kept in the adoption secret instead of the machineSelectorConfig of the
+	// cluster.
+	adoptionCredentialKeys = []string{
+		"etcd-s3-access-key",
+		"etcd-s3-secret-key",
+		"datastore-endpoint",
+	}
+)
+
+// AdoptedNode is the configuration read from an existing node.
+type AdoptedNode struct {
+	Installed   bool                   `json:"installed,omitempty"`
+	NodeName    string                 `json:"nodeName,omitempty"`
+	Version     string                 `json:"version,omitempty"`
+	ServerToken string                 `json:"serverToken,omitempty"`
+	AgentToken  string                 `json:"agentToken,omitempty"`
+	Config      map[string]interface{} `json:"config,omitempty"`
+}
+
+type adoption struct {
+	store        *PlanStore
+	secrets      corecontrollers.SecretClient
+	secretCache  corecontrollers.SecretCache
+	machines     capicontrollers.MachineClient
+	clusters     provisioningcontrollers.ClusterClient
+	clusterCache provisioningcontrollers.ClusterCache
+}
+
+func newAdoption(clients *wrangler.Context, store *PlanStore) *adoption {
+	return &adoption{
+		store:        store,
+		secrets:      clients.Core.Secret(),
+		secretCache:  clients.Core.Secret().Cache(),
+		machines:     clients.CAPI.Machine(),
+		clusters:     clients.Provisioning.Cluster(),
+		clusterCache: clients.Provisioning.Cluster().Cache(),
+	}
+}
+
+func adoptionEnabled(controlPlane *rkev1.RKEControlPlane) bool {
+	return controlPlane.Spec.Adoption != nil && controlPlane.Spec.Adoption.Enabled
+}
+
+func adoptionSecretName(controlPlane *rkev1.RKEControlPlane) string {
+	return name.SafeConcatName(controlPlane.Name, "adoption")
+}
+
+// IsAdoptionPlan returns true if the node plan only reads the configuration of an existing node.
+func IsAdoptionPlan(nodePlan plan.NodePlan) bool {
+	return len(nodePlan.Instructions) == 1 && nodePlan.Instructions[0].Name == adoptionInstructionName
+}
+
+func isAdopted(machine *capi.Machine) bool {
+	return machine.Annotations[AdoptionAnnotation] == AdoptionAdopted
+}
+
+// needsAdoption returns true for custom machines that have not been given a plan yet, or are still being read.
+func needsAdoption(entry planEntry) bool {
+	if entry.Machine.Spec.InfrastructureRef.Kind != "CustomMachine" ||
+		entry.Machine.Labels["cattle.io/os"] == "windows" ||
+		entry.Machine.DeletionTimestamp != nil ||
+		entry.Machine.Annotations[AdoptionAnnotation] != "" {
+		return false
+	}
+	return entry.Plan == nil || IsAdoptionPlan(entry.Plan.Plan)
+}
+
+// Adopt reads the configuration of the existing nodes of an adopted cluster before the planner manages them. The
+// tokens of the existing cluster are used as the cluster tokens and the configuration of every node is added as
+// a machineSelectorConfig entry to the cluster.
+func (a *adoption) Adopt(controlPlane *rkev1.RKEControlPlane, clusterPlan *plan.Plan) error {
+	if !adoptionEnabled(controlPlane) {
+		return nil
+	}
+
+	nodes, err := a.getAdoptedNodes(controlPlane)
+	if err != nil {
+		return err
+	}
+
+	var (
+		reading []string
+		adopted []string
+	)
+
+	for _, entry := range collect(clusterPlan, func(*capi.Machine) bool { return true }) {
+		if !needsAdoption(entry) {
+			continue
+		}
+
+		if node, ok := nodes[entry.Machine.Name]; ok {
+			if err := a.markMachine(entry.Machine, node); err != nil {
+				return err
+			}
+			adopted = append(adopted, entry.Machine.Name)
+			continue
+		}
+
+		reading = append(reading, entry.Machine.Name)
+		nodePlan := adoptionPlan(controlPlane)
+		if entry.Plan == nil || !equality.Semantic.DeepEqual(entry.Plan.Plan, nodePlan) {
+			if err := a.store.UpdatePlan(entry.Machine, nodePlan, 0); err != nil {
+				return err
+			}
+			continue
+		}
+		if !entry.Plan.InSync {
+			continue
+		}
+
+		node, complete, err := ParseAdoptionOutput(entry.Plan.Output[adoptionInstructionName], GetRuntime(controlPlane.Spec.KubernetesVersion))
+		if err != nil {
+			return fmt.Errorf("reading configuration of machine %s: %w", entry.Machine.Name, err)
+		} else if !complete {
+			continue
+		}
+		if err := a.saveAdoptedNode(controlPlane, entry.Machine.Name, node); err != nil {
+			return err
+		}
+	}
+
+	if reading = atMostThree(reading); len(reading) > 0 {
+		return ErrWaiting("reading configuration of adopted node(s) " + strings.Join(reading, ","))
+	}
+	if adopted = atMostThree(adopted); len(adopted) > 0 {
+		return ErrWaiting("adopting node(s) " + strings.Join(adopted, ","))
+	}
+
+	if err := a.ensureTokens(controlPlane, clusterPlan, nodes); err != nil {
+		return err
+	}
+
+	return a.ensureConfig(controlPlane, clusterPlan, nodes)
+}
+
+func adoptionPlan(controlPlane *rkev1.RKEControlPlane) plan.NodePlan {
+	return plan.NodePlan{
+		Instructions: []plan.Instruction{
+			{
+				Name:       adoptionInstructionName,
+				Command:    "sh",
+				SaveOutput: true,
+				Args:       []string{"-c", adoptionScript},
+				Env: []string{
+					fmt.Sprintf("ADOPTION_RUNTIME=%s", GetRuntime(controlPlane.Spec.KubernetesVersion)),
+					fmt.Sprintf("ADOPTION_FILE_PARAMS=%s", strings.Join(fileParams, " ")),
+				},
+			},
+		},
+	}
+}
+
+func (a *adoption) getAdoptedNodes(controlPlane *rkev1.RKEControlPlane) (map[string]AdoptedNode, error) {
+	result := map[string]AdoptedNode{}
+	secret, err := a.secretCache.Get(controlPlane.Namespace, adoptionSecretName(controlPlane))
+	if apierror.IsNotFound(err) {
+		return result, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for machineName, data := range secret.Data {
+		var node AdoptedNode
+		if err := json.Unmarshal(data, &node); err != nil {
+			return nil, err
+		}
+		result[machineName] = node
+	}
+	return result, nil
+}
+
+func (a *adoption) getAdoptedNode(controlPlane *rkev1.RKEControlPlane, machineName string) (AdoptedNode, bool, error) {
+	nodes, err := a.getAdoptedNodes(controlPlane)
+	if err != nil {
+		return AdoptedNode{}, false, err
+	}
+	node, ok := nodes[machineName]
+	return node, ok, nil
+}
+
+func (a *adoption) saveAdoptedNode(controlPlane *rkev1.RKEControlPlane, machineName string, node AdoptedNode) error {
+	data, err := json.Marshal(node)
+	if err != nil {
+		return err
+	}
+
+	secret, err := a.secrets.Get(controlPlane.Namespace, adoptionSecretName(controlPlane), metav1.GetOptions{})
+	if apierror.IsNotFound(err) {
+		_, err = a.secrets.Create(&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      adoptionSecretName(controlPlane),
+				Namespace: controlPlane.Namespace,
+				OwnerReferences: []metav1.OwnerReference{
+					{
+						APIVersion: "rke.cattle.io/v1",
+						Kind:       "RKEControlPlane",
+						Name:       controlPlane.Name,
+						UID:        controlPlane.UID,
+					},
+				},
+			},
+			Data: map[string][]byte{
+				machineName: data,
+			},
+			Type: SecretTypeAdoption,
+		})
+		return err
+	} else if err != nil {
+		return err
+	}
+
+	if secret.Data == nil {
+		secret.Data = map[string][]byte{}
+	}
+	secret.Data[machineName] = data
+	_, err = a.secrets.Update(secret)
+	return err
+}
+
+// markMachine records on the machine whether it was an existing node. The node name of an existing node is added
+// as label so the generated config can select it.
+func (a *adoption) markMachine(machine *capi.Machine, node AdoptedNode) error {
+	machine = machine.DeepCopy()
+	if machine.Annotations == nil {
+		machine.Annotations = map[string]string{}
+	}
+	if machine.Labels == nil {
+		machine.Labels = map[string]string{}
+	}
+
+	if node.Installed {
+		machine.Annotations[AdoptionAnnotation] = AdoptionAdopted
+		if machine.Labels[NodeNameLabel] == "" && len(validation.IsValidLabelValue(node.NodeName)) == 0 {
+			machine.Labels[NodeNameLabel] = node.NodeName
+		}
+	} else {
+		machine.Annotations[AdoptionAnnotation] = AdoptionNew
+	}
+
+	_, err := a.machines.Update(machine)
+	return err
+}
+
+// ensureTokens creates the cluster state secret with the tokens of the first existing server node that was read,
+// so the nodes managed by Rancher keep using the tokens of the existing cluster.
+func (a *adoption) ensureTokens(controlPlane *rkev1.RKEControlPlane, clusterPlan *plan.Plan, nodes map[string]AdoptedNode) error {
+	var (
+		serverToken, agentToken string
+	)
+	for _, entry := range collect(clusterPlan, isControlPlaneEtcd) {
+		if node, ok := nodes[entry.Machine.Name]; ok && isAdopted(entry.Machine) && node.ServerToken != "" {
+			serverToken, agentToken = node.ServerToken, node.AgentToken
+			break
+		}
+	}
+
+	secret, err := a.secretCache.Get(controlPlane.Namespace, rkeStateSecretName(controlPlane))
+	if apierror.IsNotFound(err) {
+		if serverToken == "" {
+			return ErrWaiting("waiting for an existing server node to be registered for adoption")
+		}
+		_, err := a.secrets.Create(newRKEStateSecret(controlPlane, serverToken, agentToken))
+		if err != nil && !apierror.IsAlreadyExists(err) {
+			return err
+		}
+		return ErrWaiting("importing tokens of adopted cluster")
+	} else if err != nil {
+		return err
+	}
+
+	if serverToken != "" && tokenPassword(string(secret.Data["serverToken"])) != tokenPassword(serverToken) {
+		return fmt.Errorf("the token of the adopted nodes doesn't match the cluster token, adoption must be enabled when the cluster is created")
+	}
+	return nil
+}
+
+// tokenPassword returns the password of a token in the K10<ca hash>::server:<password> format, or the token itself.
+func tokenPassword(token string) string {
+	if strings.HasPrefix(token, "K10") {
+		if i := strings.LastIndex(token, ":"); i >= 0 {
+			return token[i+1:]
+		}
+	}
+	return token
+}
+
+// ensureConfig adds the configuration of every adopted node as machineSelectorConfig entry to the cluster and waits
+// for it to be passed to the control plane. File parameters and credentials are left out, the planner reads them from
+// the adoption secret.
+func (a *adoption) ensureConfig(controlPlane *rkev1.RKEControlPlane, clusterPlan *plan.Plan, nodes map[string]AdoptedNode) error {
+	var (
+		missing []rkev1.RKESystemConfig
+		waiting []string
+	)
+
+	for _, entry := range collect(clusterPlan, isAdopted) {
+		node, ok := nodes[entry.Machine.Name]
+		if !ok {
+			continue
+		}
+		systemConfig := rkev1.RKESystemConfig{
+			MachineLabelSelector: adoptionSelector(entry.Machine),
+			Config: rkev1.GenericMap{
+				Data: withoutAdoptionSecrets(node.Config),
+			},
+		}
+		if hasSelectorConfig(controlPlane.Spec.MachineSelectorConfig, systemConfig.MachineLabelSelector) {
+			continue
+		}
+		missing = append(missing, systemConfig)
+		waiting = append(waiting, entry.Machine.Name)
+	}
+
+	if len(missing) == 0 {
+		return nil
+	}
+
+	cluster, err := a.clusterCache.Get(controlPlane.Namespace, controlPlane.Spec.ClusterName)
+	if err != nil {
+		return err
+	}
+	if cluster.Spec.RKEConfig == nil {
+		return fmt.Errorf("cluster %s/%s has no rkeConfig", cluster.Namespace, cluster.Name)
+	}
+
+	cluster = cluster.DeepCopy()
+	updated := false
+	for _, systemConfig := range missing {
+		if hasSelectorConfig(cluster.Spec.RKEConfig.MachineSelectorConfig, systemConfig.MachineLabelSelector) {
+			continue
+		}
+		cluster.Spec.RKEConfig.MachineSelectorConfig = append(cluster.Spec.RKEConfig.MachineSelectorConfig, systemConfig)
+		updated = true
+	}
+	if updated {
+		if _, err := a.clusters.Update(cluster); err != nil {
+			return err
+		}
+	}
+
+	return ErrWaiting("generating configuration of adopted node(s) " + strings.Join(atMostThree(waiting), ","))
+}
+
+// withoutAdoptionSecrets returns the config without the file parameters and credentials, which must not be copied
+// to the cluster.
+func withoutAdoptionSecrets(config map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for k, v := range config {
+		if !slice.ContainsString(fileParams, k) && !slice.ContainsString(adoptionCredentialKeys, k) {
+			result[k] = v
+		}
+	}
+	return result
+}
+
+func adoptionSelector(machine *capi.Machine) *metav1.LabelSelector {
+	if nodeName := machine.Labels[NodeNameLabel]; nodeName != "" {
+		return &metav1.LabelSelector{
+			MatchLabels: map[string]string{
+				NodeNameLabel: nodeName,
+			},
+		}
+	}
+	return &metav1.LabelSelector{
+		MatchLabels: map[string]string{
+			MachineIDLabel: machine.Labels[MachineIDLabel],
+		},
+	}
+}
+
+func hasSelectorConfig(configs []rkev1.RKESystemConfig, selector *metav1.LabelSelector) bool {
+	for _, config := range configs {
+		if equality.Semantic.DeepEqual(config.MachineLabelSelector, selector) {
+			return true
+		}
+	}
+	return false
+}
+
+// addTakeoverInstruction adds the instruction that hands the configuration of an adopted node over to Rancher. It
+// must be called after the install instruction was added because it reuses its restart stamp.
+func (p *Planner) addTakeoverInstruction(nodePlan plan.NodePlan, controlPlane *rkev1.RKEControlPlane, machine *capi.Machine) (plan.NodePlan, error) {
+	if !adoptionEnabled(controlPlane) || !isAdopted(machine) {
+		return nodePlan, nil
+	}
+
+	node, ok, err := p.adoption.getAdoptedNode(controlPlane, machine.Name)
+	if err != nil || !ok {
+		return nodePlan, err
+	}
+
+	generated, err := p.generatedConfig(controlPlane, machine)
+	if err != nil {
+		return nodePlan, err
+	}
+
+	var stamp string
+	if AdoptionEquivalent(nodePlan, controlPlane, node, generated) {
+		stamp = installRestartStamp(nodePlan)
+	}
+
+	instruction := plan.Instruction{
+		Name:    takeoverInstructionName,
+		Command: "sh",
+		Args:    []string{"-c", takeoverScript},
+		Env: []string{
+			fmt.Sprintf("ADOPTION_RUNTIME=%s", GetRuntime(controlPlane.Spec.KubernetesVersion)),
+			fmt.Sprintf("ADOPTION_RESTART_STAMP=%s", stamp),
+		},
+	}
+	nodePlan.Instructions = append([]plan.Instruction{instruction}, nodePlan.Instructions...)
+	return nodePlan, nil
+}
+
+// generatedConfig returns the config the planner adds to the config of the machine on its own, like the addresses of
+// the machine, the components disabled for its roles and the etcd snapshot settings of the control plane.
+func (p *Planner) generatedConfig(controlPlane *rkev1.RKEControlPlane, machine *capi.Machine) (map[string]interface{}, error) {
+	config := map[string]interface{}{}
+	addDefaults(config, controlPlane, machine)
+	if _, err := p.addETCD(config, controlPlane, machine); err != nil {
+		return nil, err
+	}
+	addRoleConfig(config, controlPlane, machine, false, "")
+	addLocalClusterAuthenticationEndpointConfig(config, controlPlane, machine)
+	addAddresses(p.secretCache, config, machine)
+	return config, nil
+}
+
+// addAdoptedSecrets sets the file parameters and credentials of an adopted node that are not set by the cluster.
+// They are kept in the adoption secret instead of the machineSelectorConfig of the cluster, like the credentials in
+// registries.yaml or the S3 keys for etcd snapshots.
+func (a *adoption) addAdoptedSecrets(config map[string]interface{}, controlPlane *rkev1.RKEControlPlane, machine *capi.Machine) error {
+	if !isAdopted(machine) {
+		return nil
+	}
+
+	node, ok, err := a.getAdoptedNode(controlPlane, machine.Name)
+	if err != nil || !ok {
+		return err
+	}
+
+	for _, key := range append(fileParams, adoptionCredentialKeys...) {
+		if _, ok := config[key]; ok {
+			continue
+		}
+		if value, ok := node.Config[key]; ok {
+			config[key] = value
+		}
+	}
+	return nil
+}
+
+func installRestartStamp(nodePlan plan.NodePlan) string {
+	for _, instruction := range nodePlan.Instructions {
+		for _, env := range instruction.Env {
+			if strings.HasPrefix(env, "RESTART_STAMP=") {
+				return strings.TrimPrefix(env, "RESTART_STAMP=")
+			}
+		}
+	}
+	return ""
+}
+
+// AdoptionEquivalent returns true if the node plan installs the same version with the same configuration the
+// adopted node is already running. The generated config is the config the planner adds on its own, its keys and
+// list values are only compared if the adopted node sets them too.
+func AdoptionEquivalent(nodePlan plan.NodePlan, controlPlane *rkev1.RKEControlPlane, node AdoptedNode, generated map[string]interface{}) bool {
+	if node.Version != controlPlane.Spec.KubernetesVersion {
+		return false
+	}
+
+	files := map[string]string{}
+	for _, file := range nodePlan.Files {
+		content, err := base64.StdEncoding.DecodeString(file.Content)
+		if err != nil {
+			return false
+		}
+		files[file.Path] = string(content)
+	}
+
+	desired := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(files[fmt.Sprintf(ConfigYamlFileName, GetRuntime(controlPlane.Spec.KubernetesVersion))]), &desired); err != nil {
+		return false
+	}
+	for _, fileParam := range fileParams {
+		if path, ok := desired[fileParam]; ok {
+			desired[fileParam] = files[convert.ToString(path)]
+		}
+	}
+
+	current := map[string]interface{}{}
+	for k, v := range node.Config {
+		current[k] = v
+	}
+
+	for _, config := range []map[string]interface{}{desired, current} {
+		for _, key := range adoptionManagedKeys {
+			delete(config, key)
+		}
+		if _, ok := config["node-name"]; !ok && node.NodeName != "" {
+			config["node-name"] = node.NodeName
+		}
+	}
+
+	for key, value := range generated {
+		if key == "node-name" {
+			continue
+		}
+		currentValue, ok := current[key]
+		if !ok {
+			delete(desired, key)
+			continue
+		}
+		if values, isList := value.([]string); isList {
+			desired[key] = withoutGenerated(convert.ToStringSlice(desired[key]), values, convert.ToStringSlice(currentValue))
+		}
+	}
+
+	return reflect.DeepEqual(normalizeConfig(desired), normalizeConfig(current))
+}
+
+// withoutGenerated removes the generated values the current config doesn't have from the desired values.
+func withoutGenerated(desired, generated, current []string) []string {
+	var result []string
+	for _, value := range desired {
+		if slice.ContainsString(generated, value) && !slice.ContainsString(current, value) {
+			continue
+		}
+		result = append(result, value)
+	}
+	return result
+}
+
+func normalizeConfig(config map[string]interface{}) map[string]interface{} {
+	result := map[string]interface{}{}
+	for k, v := range config {
+		switch t := v.(type) {
+		case nil:
+		case []interface{}:
+			if len(t) > 0 {
+				result[k] = convert.ToStringSlice(t)
+			}
+		case []string:
+			if len(t) > 0 {
+				result[k] = t
+			}
+		default:
+			if s := convert.ToString(t); s != "" {
+				result[k] = s
+			}
+		}
+	}
+	return result
+}
+
+// ParseAdoptionOutput parses the saved output of the adoption instruction. It returns false if the output is
+// incomplete.
+func ParseAdoptionOutput(output []byte, runtime string) (AdoptedNode, bool, error) {
+	var (
+		node     AdoptedNode
+		complete bool
+		configs  = map[string]string{}
+		files    = map[string]string{}
+	)
+
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == adoptionCompleteLine {
+			complete = true
+			continue
+		}
+
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+
+		value, err := base64.StdEncoding.DecodeString(fields[len(fields)-1])
+		switch fields[0] {
+		case "installed":
+			node.Installed = fields[1] == "true"
+			continue
+		case "hostname", "version", "token", "agent-token":
+			if err != nil {
+				return node, false, fmt.Errorf("invalid %s: %w", fields[0], err)
+			}
+		case "config", "file":
+			if err != nil || len(fields) != 3 {
+				return node, false, fmt.Errorf("invalid %s %s", fields[0], fields[1])
+			}
+		default:
+			continue
+		}
+
+		str := strings.TrimSpace(string(value))
+		switch fields[0] {
+		case "hostname":
+			node.NodeName = str
+		case "version":
+			node.Version = str
+		case "token":
+			node.ServerToken = str
+		case "agent-token":
+			node.AgentToken = str
+		case "config":
+			configs[fields[1]] = string(value)
+		case "file":
+			files[fields[1]] = string(value)
+		}
+	}
+
+	if !complete || !node.Installed {
+		return node, complete, nil
+	}
+
+	config, err := mergeConfigFiles(configs)
+	if err != nil {
+		return node, false, err
+	}
+
+	if nodeName := convert.ToString(config["node-name"]); nodeName != "" {
+		node.NodeName = nodeName
+	}
+	if node.ServerToken == "" {
+		node.ServerToken = convert.ToString(config["token"])
+	}
+	if node.AgentToken == "" {
+		node.AgentToken = convert.ToString(config["agent-token"])
+	}
+	if node.AgentToken == "" {
+		node.AgentToken = node.ServerToken
+	}
+
+	node.Config = adoptedConfig(config, files, runtime)
+	return node, true, nil
+}
+
+// mergeConfigFiles merges the config files the same way the runtime does, config.yaml is read first followed by
+// the files in config.yaml.d in lexical order. A key ending in + appends to the value of the previous files.
+func mergeConfigFiles(configs map[string]string) (map[string]interface{}, error) {
+	var paths []string
+	for path := range configs {
+		paths = append(paths, path)
+	}
+	sort.Slice(paths, func(i, j int) bool {
+		if strings.HasSuffix(paths[i], "/config.yaml") != strings.HasSuffix(paths[j], "/config.yaml") {
+			return strings.HasSuffix(paths[i], "/config.yaml")
+		}
+		return paths[i] < paths[j]
+	})
+
+	result := map[string]interface{}{}
+	for _, path := range paths {
+		values := map[string]interface{}{}
+		if err := yaml.Unmarshal([]byte(configs[path]), &values); err != nil {
+			return nil, fmt.Errorf("invalid config file %s: %w", path, err)
+		}
+		for k, v := range values {
+			if strings.HasSuffix(k, "+") {
+				k = strings.TrimSuffix(k, "+")
+				result[k] = append(toInterfaceSlice(result[k]), toInterfaceSlice(v)...)
+				continue
+			}
+			result[k] = v
+		}
+	}
+	return result, nil
+}
+
+func toInterfaceSlice(v interface{}) []interface{} {
+	switch t := v.(type) {
+	case nil:
+		return nil
+	case []interface{}:
+		return t
+	default:
+		return []interface{}{t}
+	}
+}
+
+// adoptedConfig converts the config of an adopted node to the config the planner renders. Keys managed by the
+// planner are removed, file parameters are replaced by the content of the file and defaults of the runtime that
+// differ from the defaults of Rancher are set explicitly.
+func adoptedConfig(config map[string]interface{}, files map[string]string, runtime string) map[string]interface{} {
+	result := map[string]interface{}{}
+	for k, v := range config {
+		switch t := v.(type) {
+		case bool, string:
+			result[k] = t
+		case []interface{}:
+			result[k] = t
+		default:
+			result[k] = convert.ToString(t)
+		}
+	}
+
+	for _, key := range adoptionManagedKeys {
+		delete(result, key)
+	}
+
+	for _, fileParam := range fileParams {
+		if _, ok := result[fileParam]; !ok {
+			continue
+		}
+		if content, ok := files[fileParam]; ok {
+			result[fileParam] = content
+		} else {
+			delete(result, fileParam)
+		}
+	}
+
+	if _, ok := result["private-registry"]; !ok && files["registries"] != "" {
+		result["private-registry"] = files["registries"]
+	}
+
+	if runtime == RuntimeRKE2 {
+		if _, ok := result["cni"]; !ok {
+			// Rancher defaults to calico while RKE2 itself defaults to canal
+			result["cni"] = "canal"
+		}
+	}
+
+	return result
+}
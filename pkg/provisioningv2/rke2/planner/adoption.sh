@@ -0,0 +1,76 @@
+#!/bin/sh
+#
+# Reads the configuration of an existing node that is adopted into a cluster. Every value is printed base64
+# encoded on one line "<key> [<name>] <value>" so the planner can parse the saved output. The script doesn't
+# change anything on the node.
+#
+# Environment:
+#   ADOPTION_RUNTIME      rke2 or k3s
+#   ADOPTION_FILE_PARAMS  space separated config keys whose value is the path of a file to read
+
+b64() {
+    base64 | tr -d '\n'
+}
+
+value() {
+    printf '%s' "$1" | b64
+}
+
+runtime=${ADOPTION_RUNTIME}
+config_dir=/etc/rancher/${runtime}
+data_dir=/var/lib/rancher/${runtime}
+
+if [ ! -d "${data_dir}/agent" ]; then
+    echo "installed false"
+    echo "adoption complete"
+    exit 0
+fi
+echo "installed true"
+
+echo "hostname $(value "$(hostname | tr '[:upper:]' '[:lower:]')")"
+
+for bin in "$(command -v "${runtime}")" /usr/local/bin/${runtime} /opt/${runtime}/bin/${runtime}; do
+    if [ -n "${bin}" ] && [ -x "${bin}" ]; then
+        echo "version $(value "$("${bin}" --version 2>/dev/null | awk 'NR==1 {print $3}')")"
+        break
+    fi
+done
+
+for file in "${data_dir}/server/token" "${data_dir}/server/agent-token"; do
+    if [ -s "${file}" ]; then
+        echo "$(basename "${file}") $(b64 < "${file}")"
+    fi
+done
+
+configs=""
+if [ -f "${config_dir}/config.yaml" ]; then
+    configs="${config_dir}/config.yaml"
+fi
+for file in "${config_dir}"/config.yaml.d/*.yaml "${config_dir}"/config.yaml.d/*.yml; do
+    if [ -f "${file}" ]; then
+        configs="${configs} ${file}"
+    fi
+done
+
+for file in ${configs}; do
+    echo "config ${file} $(b64 < "${file}")"
+done
+
+for param in ${ADOPTION_FILE_PARAMS}; do
+    path=""
+    for file in ${configs}; do
+        found=$(sed -n "s/^${param}:[[:space:]]*//p" "${file}" | tr -d "\"'")
+        if [ -n "${found}" ]; then
+            path=${found}
+        fi
+    done
+    if [ -n "${path}" ] && [ -f "${path}" ]; then
+        echo "file ${param} $(b64 < "${path}")"
+    fi
+done
+
+if [ -f "${config_dir}/registries.yaml" ]; then
+    echo "file registries $(b64 < "${config_dir}/registries.yaml")"
+fi
+
+echo "adoption complete"
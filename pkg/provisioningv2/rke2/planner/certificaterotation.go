@@ -0,0 +1,201 @@
+package planner
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	rkecontroller "github.com/rancher/rancher/pkg/generated/controllers/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/wrangler"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/pkg/slice"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+type certificateRotation struct {
+	controlPlane rkecontroller.RKEControlPlaneClient
+	secrets      corecontrollers.SecretCache
+	store        *PlanStore
+}
+
+func newCertificateRotation(clients *wrangler.Context, store *PlanStore) *certificateRotation {
+	return &certificateRotation{
+		controlPlane: clients.RKE.RKEControlPlane(),
+		secrets:      clients.Core.Secret().Cache(),
+		store:        store,
+	}
+}
+
+func (r *certificateRotation) setState(controlPlane *rkev1.RKEControlPlane, spec *rkev1.RotateCertificates, phase rkev1.RotateCertificatesPhase) error {
+	controlPlane = controlPlane.DeepCopy()
+	controlPlane.Status.RotateCertificatesPhase = phase
+	controlPlane.Status.RotateCertificates = spec
+	_, err := r.controlPlane.UpdateStatus(controlPlane)
+	if err != nil {
+		return err
+	}
+	return ErrWaiting("refreshing certificate rotation state")
+}
+
+func (r *certificateRotation) resetRotateCertificatesState(controlPlane *rkev1.RKEControlPlane) error {
+	if controlPlane.Status.RotateCertificates == nil && controlPlane.Status.RotateCertificatesPhase == "" {
+		return nil
+	}
+	return r.setState(controlPlane, nil, "")
+}
+
+// RotateCertificates rotates the certificates of the control plane and etcd nodes, then restarts the
+// agents on worker nodes so they get new client certificates. Nodes are handled within the upgrade
+// concurrency of their tier.
+func (r *certificateRotation) RotateCertificates(controlPlane *rkev1.RKEControlPlane, clusterPlan *plan.Plan) error {
+	if !Provisioned.IsTrue(controlPlane) && controlPlane.Status.RotateCertificatesPhase == "" {
+		return nil
+	}
+
+	spec := controlPlane.Spec.RotateCertificates
+	if spec == nil {
+		return r.resetRotateCertificatesState(controlPlane)
+	}
+
+	if controlPlane.Status.RotateCertificates == nil || !equality.Semantic.DeepEqual(*spec, *controlPlane.Status.RotateCertificates) {
+		return r.setState(controlPlane, spec, rkev1.RotateCertificatesPhaseStarted)
+	}
+
+	switch controlPlane.Status.RotateCertificatesPhase {
+	case rkev1.RotateCertificatesPhaseStarted:
+		if err := validateRotateCertificates(controlPlane.Spec.KubernetesVersion, spec); err != nil {
+			return r.failOnError(controlPlane, spec, err)
+		}
+		return r.setState(controlPlane, spec, rkev1.RotateCertificatesPhaseControlPlane)
+	case rkev1.RotateCertificatesPhaseControlPlane:
+		if err := rollout(r.store, controlPlane, clusterPlan, "rotate certificates", "control plane", isControlPlaneEtcd, r.rotateServerPlan,
+			controlPlane.Spec.UpgradeStrategy.ControlPlaneConcurrency); err != nil {
+			return r.failOnError(controlPlane, spec, err)
+		}
+		return r.setState(controlPlane, spec, rkev1.RotateCertificatesPhaseWorker)
+	case rkev1.RotateCertificatesPhaseWorker:
+		if restartsAgents(controlPlane.Spec.KubernetesVersion, spec) {
+			if err := rollout(r.store, controlPlane, clusterPlan, "rotate certificates", "worker", isOnlyWorker, r.restartAgentPlan,
+				controlPlane.Spec.UpgradeStrategy.WorkerConcurrency); err != nil {
+				return r.failOnError(controlPlane, spec, err)
+			}
+		}
+		return r.setState(controlPlane, spec, rkev1.RotateCertificatesPhaseFinished)
+	case rkev1.RotateCertificatesPhaseFailed:
+		fallthrough
+	case rkev1.RotateCertificatesPhaseFinished:
+		return nil
+	default:
+		return r.setState(controlPlane, spec, rkev1.RotateCertificatesPhaseStarted)
+	}
+}
+
+func (r *certificateRotation) failOnError(controlPlane *rkev1.RKEControlPlane, spec *rkev1.RotateCertificates, err error) error {
+	var errWaiting ErrWaiting
+	if errors.As(err, &errWaiting) {
+		return err
+	}
+	if setErr := r.setState(controlPlane, spec, rkev1.RotateCertificatesPhaseFailed); !errors.As(setErr, &errWaiting) {
+		return setErr
+	}
+	return err
+}
+
+func (r *certificateRotation) rotateServerPlan(controlPlane *rkev1.RKEControlPlane, entry planEntry) (plan.NodePlan, error) {
+	unit := GetRuntimeServerUnit(controlPlane.Spec.KubernetesVersion)
+	args := []string{
+		"certificate",
+		"rotate",
+	}
+	for _, service := range controlPlane.Spec.RotateCertificates.Services {
+		args = append(args, "--service", service)
+	}
+
+	return commonNodePlan(r.secrets, controlPlane, plan.NodePlan{
+		Instructions: []plan.Instruction{
+			{
+				Name:    "shutdown",
+				Command: "systemctl",
+				Args:    []string{"stop", unit},
+			},
+			{
+				Name:    "rotate",
+				Command: GetRuntimeCommand(controlPlane.Spec.KubernetesVersion),
+				Env:     rotationEnv(controlPlane),
+				Args:    args,
+			},
+			{
+				Name:    "restart",
+				Command: "systemctl",
+				Args:    []string{"start", unit},
+			},
+		},
+		Probes: entry.Plan.Plan.Probes,
+	})
+}
+
+func (r *certificateRotation) restartAgentPlan(controlPlane *rkev1.RKEControlPlane, entry planEntry) (plan.NodePlan, error) {
+	return commonNodePlan(r.secrets, controlPlane, plan.NodePlan{
+		Instructions: []plan.Instruction{
+			{
+				Name:    "restart",
+				Command: "systemctl",
+				Env:     rotationEnv(controlPlane),
+				Args:    []string{"restart", GetRuntimeAgentUnit(controlPlane.Spec.KubernetesVersion)},
+			},
+		},
+		Probes: entry.Plan.Plan.Probes,
+	})
+}
+
+// rotationEnv makes the plans of each rotation distinct so a new generation runs again on nodes that
+// still have the plan of the previous one.
+func rotationEnv(controlPlane *rkev1.RKEControlPlane) []string {
+	return []string{
+		"ROTATE_CERTIFICATES_GENERATION=" + strconv.FormatInt(controlPlane.Spec.RotateCertificates.Generation, 10),
+	}
+}
+
+// certificateServices are the services RKE2 and K3s can rotate the certificates of, besides the
+// controller and server services named after the runtime.
+var certificateServices = []string{
+	"admin",
+	"api-server",
+	"controller-manager",
+	"scheduler",
+	"cloud-controller",
+	"etcd",
+	"auth-proxy",
+	"kubelet",
+	"kube-proxy",
+}
+
+// validateRotateCertificates returns an error if a service is not one the runtime can rotate the
+// certificates of, which would otherwise only fail once the rotation runs on the first node.
+func validateRotateCertificates(kubernetesVersion string, spec *rkev1.RotateCertificates) error {
+	runtime := GetRuntime(kubernetesVersion)
+	for _, service := range spec.Services {
+		if !slice.ContainsString(certificateServices, service) && service != runtime+"-controller" && service != runtime+"-server" {
+			return fmt.Errorf("invalid service %s to rotate certificates of, must be one of %s,%s-controller,%s-server",
+				service, strings.Join(certificateServices, ","), runtime, runtime)
+		}
+	}
+	return nil
+}
+
+// restartsAgents returns whether the rotated services include certificates used by the agents, which
+// are the client certificates of the kubelet, kube-proxy and the controller of the runtime.
+func restartsAgents(kubernetesVersion string, spec *rkev1.RotateCertificates) bool {
+	if len(spec.Services) == 0 {
+		return true
+	}
+	for _, service := range spec.Services {
+		if service == "kubelet" || service == "kube-proxy" || service == GetRuntime(kubernetesVersion)+"-controller" {
+			return true
+		}
+	}
+	return false
+}
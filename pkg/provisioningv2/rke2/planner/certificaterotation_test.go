@@ -0,0 +1,327 @@
+package planner
+
+import (
+	"errors"
+	"testing"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	rkecontroller "github.com/rancher/rancher/pkg/generated/controllers/rke.cattle.io/v1"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	apierror "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+type fakeControlPlaneClient struct {
+	rkecontroller.RKEControlPlaneClient
+	updated *rkev1.RKEControlPlane
+}
+
+func (f *fakeControlPlaneClient) UpdateStatus(controlPlane *rkev1.RKEControlPlane) (*rkev1.RKEControlPlane, error) {
+	f.updated = controlPlane
+	return controlPlane, nil
+}
+
+type fakeSecretClient struct {
+	corecontrollers.SecretClient
+	secrets map[string]*corev1.Secret
+}
+
+func (f *fakeSecretClient) Get(namespace, name string, _ metav1.GetOptions) (*corev1.Secret, error) {
+	if secret, ok := f.secrets[namespace+"/"+name]; ok {
+		return secret, nil
+	}
+	return nil, apierror.NewNotFound(corev1.Resource("secrets"), name)
+}
+
+func (f *fakeSecretClient) Update(secret *corev1.Secret) (*corev1.Secret, error) {
+	f.secrets[secret.Namespace+"/"+secret.Name] = secret
+	return secret, nil
+}
+
+func newRotationMachine(name string, roles ...string) *capi.Machine {
+	machine := &capi.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "fleet-default",
+			Labels:    map[string]string{},
+		},
+		Spec: capi.MachineSpec{
+			Bootstrap: capi.Bootstrap{
+				ConfigRef: &corev1.ObjectReference{Kind: "RKEBootstrap", Name: name},
+			},
+		},
+	}
+	for _, role := range roles {
+		machine.Labels[role] = "true"
+	}
+	return machine
+}
+
+// rotationNode is the node of a machine in a cluster plan for certificate rotation tests
+type rotationNode struct {
+	machine *capi.Machine
+	// applied is whether the node already has the plan of the rotation
+	applied bool
+	failed  bool
+}
+
+func TestRotateCertificatesPhases(t *testing.T) {
+	var (
+		all     = &rkev1.RotateCertificates{Generation: 1}
+		etcd    = &rkev1.RotateCertificates{Generation: 1, Services: []string{"etcd"}}
+		invalid = &rkev1.RotateCertificates{Generation: 1, Services: []string{"etcd", "kubelett"}}
+		server  = newRotationMachine("server", ControlPlaneRoleLabel, EtcdRoleLabel)
+		worker  = newRotationMachine("worker", WorkerRoleLabel)
+	)
+
+	tests := []struct {
+		name        string
+		provisioned bool
+		spec        *rkev1.RotateCertificates
+		status      *rkev1.RotateCertificates
+		phase       rkev1.RotateCertificatesPhase
+		nodes       []rotationNode
+		// wantPhase is the phase after the call, or "" if the status must not be updated
+		wantPhase rkev1.RotateCertificatesPhase
+		// wantReset is whether the state of the rotation is removed from the status
+		wantReset   bool
+		wantWaiting bool
+		wantErr     bool
+		// wantPlans are the machines that are given the plan of the rotation
+		wantPlans []string
+	}{
+		{
+			name: "not provisioned",
+			spec: all,
+		},
+		{
+			name:        "no rotation",
+			provisioned: true,
+		},
+		{
+			name:        "new generation starts",
+			provisioned: true,
+			spec:        all,
+			wantPhase:   rkev1.RotateCertificatesPhaseStarted,
+			wantWaiting: true,
+		},
+		{
+			name:        "new generation restarts a finished rotation",
+			provisioned: true,
+			spec:        &rkev1.RotateCertificates{Generation: 2},
+			status:      all,
+			phase:       rkev1.RotateCertificatesPhaseFinished,
+			wantPhase:   rkev1.RotateCertificatesPhaseStarted,
+			wantWaiting: true,
+		},
+		{
+			name:        "started moves to control plane",
+			provisioned: true,
+			spec:        all,
+			status:      all,
+			phase:       rkev1.RotateCertificatesPhaseStarted,
+			wantPhase:   rkev1.RotateCertificatesPhaseControlPlane,
+			wantWaiting: true,
+		},
+		{
+			name:        "unknown service fails",
+			provisioned: true,
+			spec:        invalid,
+			status:      invalid,
+			phase:       rkev1.RotateCertificatesPhaseStarted,
+			wantPhase:   rkev1.RotateCertificatesPhaseFailed,
+			wantErr:     true,
+		},
+		{
+			name:        "control plane rotates servers",
+			provisioned: true,
+			spec:        all,
+			status:      all,
+			phase:       rkev1.RotateCertificatesPhaseControlPlane,
+			nodes:       []rotationNode{{machine: server}, {machine: worker}},
+			wantWaiting: true,
+			wantPlans:   []string{"server"},
+		},
+		{
+			name:        "control plane moves to worker once servers are rotated",
+			provisioned: true,
+			spec:        all,
+			status:      all,
+			phase:       rkev1.RotateCertificatesPhaseControlPlane,
+			nodes:       []rotationNode{{machine: server, applied: true}, {machine: worker}},
+			wantPhase:   rkev1.RotateCertificatesPhaseWorker,
+			wantWaiting: true,
+		},
+		{
+			name:        "failed server fails the rotation",
+			provisioned: true,
+			spec:        all,
+			status:      all,
+			phase:       rkev1.RotateCertificatesPhaseControlPlane,
+			nodes:       []rotationNode{{machine: server, applied: true, failed: true}},
+			wantPhase:   rkev1.RotateCertificatesPhaseFailed,
+			wantErr:     true,
+		},
+		{
+			name:        "worker restarts agents",
+			provisioned: true,
+			spec:        all,
+			status:      all,
+			phase:       rkev1.RotateCertificatesPhaseWorker,
+			nodes:       []rotationNode{{machine: server, applied: true}, {machine: worker}},
+			wantWaiting: true,
+			wantPlans:   []string{"worker"},
+		},
+		{
+			name:        "worker finishes once agents are restarted",
+			provisioned: true,
+			spec:        all,
+			status:      all,
+			phase:       rkev1.RotateCertificatesPhaseWorker,
+			nodes:       []rotationNode{{machine: worker, applied: true}},
+			wantPhase:   rkev1.RotateCertificatesPhaseFinished,
+			wantWaiting: true,
+		},
+		{
+			name:        "worker skips agents without agent certificates",
+			provisioned: true,
+			spec:        etcd,
+			status:      etcd,
+			phase:       rkev1.RotateCertificatesPhaseWorker,
+			nodes:       []rotationNode{{machine: worker}},
+			wantPhase:   rkev1.RotateCertificatesPhaseFinished,
+			wantWaiting: true,
+		},
+		{
+			name:        "finished",
+			provisioned: true,
+			spec:        all,
+			status:      all,
+			phase:       rkev1.RotateCertificatesPhaseFinished,
+			nodes:       []rotationNode{{machine: worker}},
+		},
+		{
+			name:        "failed stays failed",
+			provisioned: true,
+			spec:        all,
+			status:      all,
+			phase:       rkev1.RotateCertificatesPhaseFailed,
+			nodes:       []rotationNode{{machine: server}},
+		},
+		{
+			name:        "removed rotation resets the state",
+			provisioned: true,
+			status:      all,
+			phase:       rkev1.RotateCertificatesPhaseFinished,
+			wantReset:   true,
+			wantWaiting: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controlPlane := &rkev1.RKEControlPlane{
+				ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "fleet-default"},
+				Spec: rkev1.RKEControlPlaneSpec{
+					KubernetesVersion:  "v1.21.4+rke2r2",
+					RotateCertificates: tt.spec,
+				},
+				Status: rkev1.RKEControlPlaneStatus{
+					RotateCertificates:      tt.status,
+					RotateCertificatesPhase: tt.phase,
+				},
+			}
+			if tt.provisioned {
+				Provisioned.True(controlPlane)
+			}
+
+			controlPlanes := &fakeControlPlaneClient{}
+			secrets := &fakeSecretClient{secrets: map[string]*corev1.Secret{}}
+			r := &certificateRotation{
+				controlPlane: controlPlanes,
+				store:        &PlanStore{secrets: secrets},
+			}
+
+			clusterPlan := &plan.Plan{
+				Machines: map[string]*capi.Machine{},
+				Nodes:    map[string]*plan.Node{},
+			}
+			for _, node := range tt.nodes {
+				clusterPlan.Machines[node.machine.Name] = node.machine
+				secrets.secrets["fleet-default/"+PlanSecretFromBootstrapName(node.machine.Name)] = &corev1.Secret{
+					ObjectMeta: metav1.ObjectMeta{Name: PlanSecretFromBootstrapName(node.machine.Name), Namespace: "fleet-default"},
+				}
+				entry := planEntry{Machine: node.machine, Plan: &plan.Node{InSync: true}}
+				if node.applied {
+					planFunc := r.rotateServerPlan
+					if isOnlyWorker(node.machine) {
+						planFunc = r.restartAgentPlan
+					}
+					nodePlan, err := planFunc(controlPlane, entry)
+					require.NoError(t, err)
+					entry.Plan.Plan = nodePlan
+					entry.Plan.Failed = node.failed
+				}
+				clusterPlan.Nodes[node.machine.Name] = entry.Plan
+			}
+
+			err := r.RotateCertificates(controlPlane, clusterPlan)
+
+			var errWaiting ErrWaiting
+			switch {
+			case tt.wantErr:
+				assert.Error(t, err)
+				assert.False(t, errors.As(err, &errWaiting), "expected a failure, got %v", err)
+			case tt.wantWaiting:
+				assert.True(t, errors.As(err, &errWaiting), "expected to wait, got %v", err)
+			default:
+				assert.NoError(t, err)
+			}
+
+			if tt.wantPhase == "" && !tt.wantReset {
+				assert.Nil(t, controlPlanes.updated, "status must not be updated")
+			} else {
+				require.NotNil(t, controlPlanes.updated)
+				assert.Equal(t, tt.wantPhase, controlPlanes.updated.Status.RotateCertificatesPhase)
+				assert.Equal(t, tt.spec, controlPlanes.updated.Status.RotateCertificates)
+			}
+
+			var plans []string
+			for _, node := range tt.nodes {
+				if secrets.secrets["fleet-default/"+PlanSecretFromBootstrapName(node.machine.Name)].Data != nil {
+					plans = append(plans, node.machine.Name)
+				}
+			}
+			assert.Equal(t, tt.wantPlans, plans)
+		})
+	}
+}
+
+func TestValidateRotateCertificates(t *testing.T) {
+	assert.NoError(t, validateRotateCertificates("v1.21.4+rke2r2", &rkev1.RotateCertificates{}))
+	assert.NoError(t, validateRotateCertificates("v1.21.4+rke2r2", &rkev1.RotateCertificates{
+		Services: []string{"api-server", "etcd", "rke2-controller", "rke2-server"},
+	}))
+	assert.NoError(t, validateRotateCertificates("v1.21.4+k3s1", &rkev1.RotateCertificates{
+		Services: []string{"k3s-controller", "kubelet"},
+	}))
+	assert.Error(t, validateRotateCertificates("v1.21.4+k3s1", &rkev1.RotateCertificates{
+		Services: []string{"rke2-controller"},
+	}), "services of the other runtime are invalid")
+	assert.Error(t, validateRotateCertificates("v1.21.4+rke2r2", &rkev1.RotateCertificates{
+		Services: []string{"apiserver"},
+	}))
+}
+
+func TestRestartsAgents(t *testing.T) {
+	assert.True(t, restartsAgents("v1.21.4+rke2r2", &rkev1.RotateCertificates{}))
+	assert.True(t, restartsAgents("v1.21.4+rke2r2", &rkev1.RotateCertificates{Services: []string{"etcd", "kube-proxy"}}))
+	assert.True(t, restartsAgents("v1.21.4+rke2r2", &rkev1.RotateCertificates{Services: []string{"rke2-controller"}}))
+	assert.True(t, restartsAgents("v1.21.4+k3s1", &rkev1.RotateCertificates{Services: []string{"k3s-controller"}}))
+	assert.False(t, restartsAgents("v1.21.4+rke2r2", &rkev1.RotateCertificates{Services: []string{"etcd", "api-server", "rke2-server"}}))
+}
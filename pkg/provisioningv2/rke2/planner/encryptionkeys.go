@@ -0,0 +1,243 @@
+package planner
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	rkecontroller "github.com/rancher/rancher/pkg/generated/controllers/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/wrangler"
+	"github.com/rancher/wrangler/pkg/data/convert"
+	corecontrollers "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+const (
+	encryptionStagePrepare           = "prepare"
+	encryptionStageRotate            = "rotate"
+	encryptionStageReencryptFinished = "reencrypt_finished"
+
+	// encryptionStageTimeout is how long a node has to report the expected rotation stage after a command
+	// or a restart before the rotation fails
+	encryptionStageTimeout = 600
+)
+
+type encryptionKeyRotation struct {
+	controlPlane rkecontroller.RKEControlPlaneClient
+	secrets      corecontrollers.SecretCache
+	store        *PlanStore
+}
+
+func newEncryptionKeyRotation(clients *wrangler.Context, store *PlanStore) *encryptionKeyRotation {
+	return &encryptionKeyRotation{
+		controlPlane: clients.RKE.RKEControlPlane(),
+		secrets:      clients.Core.Secret().Cache(),
+		store:        store,
+	}
+}
+
+func (e *encryptionKeyRotation) setState(controlPlane *rkev1.RKEControlPlane, spec *rkev1.RotateEncryptionKeys, phase rkev1.RotateEncryptionKeysPhase, leader, message string) error {
+	controlPlane = controlPlane.DeepCopy()
+	controlPlane.Status.RotateEncryptionKeys = spec
+	controlPlane.Status.RotateEncryptionKeysPhase = phase
+	controlPlane.Status.RotateEncryptionKeysLeader = leader
+	controlPlane.Status.RotateEncryptionKeysMessage = message
+	_, err := e.controlPlane.UpdateStatus(controlPlane)
+	if err != nil {
+		return err
+	}
+	return ErrWaiting("refreshing encryption key rotation state")
+}
+
+func (e *encryptionKeyRotation) setPhase(controlPlane *rkev1.RKEControlPlane, phase rkev1.RotateEncryptionKeysPhase) error {
+	return e.setState(controlPlane, controlPlane.Spec.RotateEncryptionKeys, phase, controlPlane.Status.RotateEncryptionKeysLeader, "")
+}
+
+func (e *encryptionKeyRotation) fail(controlPlane *rkev1.RKEControlPlane, err error) error {
+	var errWaiting ErrWaiting
+	if errors.As(err, &errWaiting) {
+		return err
+	}
+	if setErr := e.setState(controlPlane, controlPlane.Spec.RotateEncryptionKeys, rkev1.RotateEncryptionKeysPhaseFailed,
+		controlPlane.Status.RotateEncryptionKeysLeader, err.Error()); !errors.As(setErr, &errWaiting) {
+		return setErr
+	}
+	return err
+}
+
+func (e *encryptionKeyRotation) resetRotateEncryptionKeysState(controlPlane *rkev1.RKEControlPlane) error {
+	if controlPlane.Status.RotateEncryptionKeys == nil && controlPlane.Status.RotateEncryptionKeysPhase == "" {
+		return nil
+	}
+	return e.setState(controlPlane, nil, "", "", "")
+}
+
+// RotateEncryptionKeys rotates the secrets encryption key with the prepare, rotate and reencrypt
+// commands of the runtime. Each command runs on a single control plane node, the leader, and is
+// followed by a restart of the other control plane nodes. Every node must report the expected
+// rotation stage before the next phase starts, otherwise the rotation fails.
+func (e *encryptionKeyRotation) RotateEncryptionKeys(controlPlane *rkev1.RKEControlPlane, clusterPlan *plan.Plan) error {
+	if !Provisioned.IsTrue(controlPlane) && controlPlane.Status.RotateEncryptionKeysPhase == "" {
+		return nil
+	}
+
+	spec := controlPlane.Spec.RotateEncryptionKeys
+	if spec == nil {
+		return e.resetRotateEncryptionKeysState(controlPlane)
+	}
+
+	if controlPlane.Status.RotateEncryptionKeys == nil || !equality.Semantic.DeepEqual(*spec, *controlPlane.Status.RotateEncryptionKeys) {
+		return e.setState(controlPlane, spec, rkev1.RotateEncryptionKeysPhaseStarted, "", "")
+	}
+
+	switch controlPlane.Status.RotateEncryptionKeysPhase {
+	case rkev1.RotateEncryptionKeysPhaseStarted:
+		if !secretsEncryptionEnabled(controlPlane) {
+			return e.fail(controlPlane, errors.New("secrets encryption is not enabled"))
+		}
+		leader := encryptionLeader(clusterPlan)
+		if leader == "" {
+			return ErrWaiting("waiting for a control plane node to rotate the encryption key on")
+		}
+		return e.setState(controlPlane, spec, rkev1.RotateEncryptionKeysPhasePrepare, leader, "")
+	case rkev1.RotateEncryptionKeysPhasePrepare:
+		return e.leaderPhase(controlPlane, clusterPlan, encryptionStagePrepare, encryptionStagePrepare, rkev1.RotateEncryptionKeysPhasePostPrepareRestart)
+	case rkev1.RotateEncryptionKeysPhasePostPrepareRestart:
+		return e.restartPhase(controlPlane, clusterPlan, encryptionStagePrepare, rkev1.RotateEncryptionKeysPhaseRotate)
+	case rkev1.RotateEncryptionKeysPhaseRotate:
+		return e.leaderPhase(controlPlane, clusterPlan, "rotate", encryptionStageRotate, rkev1.RotateEncryptionKeysPhasePostRotateRestart)
+	case rkev1.RotateEncryptionKeysPhasePostRotateRestart:
+		return e.restartPhase(controlPlane, clusterPlan, encryptionStageRotate, rkev1.RotateEncryptionKeysPhaseReencrypt)
+	case rkev1.RotateEncryptionKeysPhaseReencrypt:
+		return e.leaderPhase(controlPlane, clusterPlan, "reencrypt", encryptionStageReencryptFinished, rkev1.RotateEncryptionKeysPhasePostReencryptRestart)
+	case rkev1.RotateEncryptionKeysPhasePostReencryptRestart:
+		return e.restartPhase(controlPlane, clusterPlan, encryptionStageReencryptFinished, rkev1.RotateEncryptionKeysPhaseDone)
+	case rkev1.RotateEncryptionKeysPhaseFailed:
+		fallthrough
+	case rkev1.RotateEncryptionKeysPhaseDone:
+		return nil
+	default:
+		return e.setState(controlPlane, spec, rkev1.RotateEncryptionKeysPhaseStarted, "", "")
+	}
+}
+
+// leaderPhase runs a secrets-encrypt command on the leader, restarts it and waits for the stage.
+func (e *encryptionKeyRotation) leaderPhase(controlPlane *rkev1.RKEControlPlane, clusterPlan *plan.Plan, command, stage string, next rkev1.RotateEncryptionKeysPhase) error {
+	leader := controlPlane.Status.RotateEncryptionKeysLeader
+	if _, ok := clusterPlan.Machines[leader]; !ok {
+		return e.fail(controlPlane, fmt.Errorf("machine %s rotating the encryption key no longer exists", leader))
+	}
+
+	err := rollout(e.store, controlPlane, clusterPlan, "run secrets-encrypt "+command, "control plane", isMachine(leader),
+		func(controlPlane *rkev1.RKEControlPlane, entry planEntry) (plan.NodePlan, error) {
+			instructions := []plan.Instruction{{
+				Name:    "secrets-encrypt-" + command,
+				Command: GetRuntimeCommand(controlPlane.Spec.KubernetesVersion),
+				Env:     encryptionEnv(controlPlane),
+				Args:    []string{"secrets-encrypt", command},
+			}}
+			if stage == encryptionStageReencryptFinished {
+				// reencryption runs in the background and has to finish before the restart
+				instructions = append(instructions, waitForEncryptionStage(controlPlane, stage))
+			}
+			return e.restartPlan(controlPlane, entry, instructions, stage)
+		}, "1")
+	if err != nil {
+		return e.fail(controlPlane, err)
+	}
+	return e.setPhase(controlPlane, next)
+}
+
+// restartPhase restarts the other control plane nodes within the upgrade concurrency so they load
+// the encryption configuration written by the leader.
+func (e *encryptionKeyRotation) restartPhase(controlPlane *rkev1.RKEControlPlane, clusterPlan *plan.Plan, stage string, next rkev1.RotateEncryptionKeysPhase) error {
+	leader := controlPlane.Status.RotateEncryptionKeysLeader
+	followers := func(machine *capi.Machine) bool {
+		return isControlPlane(machine) && machine.Name != leader
+	}
+
+	err := rollout(e.store, controlPlane, clusterPlan, "restart for encryption key rotation", "control plane", followers,
+		func(controlPlane *rkev1.RKEControlPlane, entry planEntry) (plan.NodePlan, error) {
+			return e.restartPlan(controlPlane, entry, nil, stage)
+		}, controlPlane.Spec.UpgradeStrategy.ControlPlaneConcurrency)
+	if err != nil {
+		return e.fail(controlPlane, err)
+	}
+	return e.setPhase(controlPlane, next)
+}
+
+func (e *encryptionKeyRotation) restartPlan(controlPlane *rkev1.RKEControlPlane, entry planEntry, instructions []plan.Instruction, stage string) (plan.NodePlan, error) {
+	instructions = append(instructions,
+		plan.Instruction{
+			Name:    "restart",
+			Command: "systemctl",
+			Env:     encryptionEnv(controlPlane),
+			Args:    []string{"restart", GetRuntimeServerUnit(controlPlane.Spec.KubernetesVersion)},
+		},
+		waitForEncryptionStage(controlPlane, stage))
+
+	return commonNodePlan(e.secrets, controlPlane, plan.NodePlan{
+		Instructions: instructions,
+		Probes:       entry.Plan.Plan.Probes,
+	})
+}
+
+func waitForEncryptionStage(controlPlane *rkev1.RKEControlPlane, stage string) plan.Instruction {
+	return plan.Instruction{
+		Name:    "wait-" + stage,
+		Command: "sh",
+		Args: []string{
+			"-c",
+			fmt.Sprintf("timeout %d sh -c 'until %s secrets-encrypt status | grep -q \"Current Rotation Stage: %s\"; do sleep 5; done'",
+				encryptionStageTimeout, GetRuntimeCommand(controlPlane.Spec.KubernetesVersion), stage),
+		},
+	}
+}
+
+// encryptionEnv makes the plans of each rotation distinct so a new generation runs again on nodes
+// that still have the plan of the previous one.
+func encryptionEnv(controlPlane *rkev1.RKEControlPlane) []string {
+	return []string{
+		"ROTATE_ENCRYPTION_KEYS_GENERATION=" + strconv.FormatInt(controlPlane.Spec.RotateEncryptionKeys.Generation, 10),
+		"ROTATE_ENCRYPTION_KEYS_PHASE=" + string(controlPlane.Status.RotateEncryptionKeysPhase),
+	}
+}
+
+// encryptionLeader returns the init node when it runs the control plane, otherwise the first
+// provisioned control plane machine.
+func encryptionLeader(clusterPlan *plan.Plan) string {
+	var names []string
+	for _, entry := range collect(clusterPlan, isControlPlane) {
+		if entry.Plan == nil {
+			continue
+		}
+		if isInitNode(entry.Machine) {
+			return entry.Machine.Name
+		}
+		names = append(names, entry.Machine.Name)
+	}
+	if len(names) == 0 {
+		return ""
+	}
+	sort.Strings(names)
+	return names[0]
+}
+
+// secretsEncryptionEnabled returns whether secrets encryption is on, it is always enabled for RKE2 and
+// opt-in for K3s.
+func secretsEncryptionEnabled(controlPlane *rkev1.RKEControlPlane) bool {
+	if GetRuntime(controlPlane.Spec.KubernetesVersion) == RuntimeRKE2 {
+		return true
+	}
+	return convert.ToBool(controlPlane.Spec.MachineGlobalConfig.Data["secrets-encryption"])
+}
+
+func isMachine(name string) roleFilter {
+	return func(machine *capi.Machine) bool {
+		return machine.Name == name
+	}
+}
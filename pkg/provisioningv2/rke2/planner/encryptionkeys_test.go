@@ -0,0 +1,279 @@
+package planner
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+// encryptionTest runs an encryption key rotation against fake nodes that apply the plans they are given
+type encryptionTest struct {
+	t             *testing.T
+	rotation      *encryptionKeyRotation
+	controlPlanes *fakeControlPlaneClient
+	secrets       *fakeSecretClient
+	controlPlane  *rkev1.RKEControlPlane
+	clusterPlan   *plan.Plan
+}
+
+func newEncryptionTest(t *testing.T, kubernetesVersion string, machines ...*capi.Machine) *encryptionTest {
+	e := &encryptionTest{
+		t:             t,
+		controlPlanes: &fakeControlPlaneClient{},
+		secrets:       &fakeSecretClient{secrets: map[string]*corev1.Secret{}},
+		controlPlane: &rkev1.RKEControlPlane{
+			ObjectMeta: metav1.ObjectMeta{Name: "c", Namespace: "fleet-default"},
+			Spec: rkev1.RKEControlPlaneSpec{
+				KubernetesVersion:    kubernetesVersion,
+				RotateEncryptionKeys: &rkev1.RotateEncryptionKeys{Generation: 1},
+			},
+		},
+		clusterPlan: &plan.Plan{
+			Machines: map[string]*capi.Machine{},
+			Nodes:    map[string]*plan.Node{},
+		},
+	}
+	Provisioned.True(e.controlPlane)
+	e.rotation = &encryptionKeyRotation{
+		controlPlane: e.controlPlanes,
+		store:        &PlanStore{secrets: e.secrets},
+	}
+
+	for _, machine := range machines {
+		e.clusterPlan.Machines[machine.Name] = machine
+		e.clusterPlan.Nodes[machine.Name] = &plan.Node{InSync: true}
+		e.secrets.secrets["fleet-default/"+PlanSecretFromBootstrapName(machine.Name)] = &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: PlanSecretFromBootstrapName(machine.Name), Namespace: "fleet-default"},
+		}
+	}
+	return e
+}
+
+// run runs the rotation once and keeps the status it sets, like the next reconcile would see it
+func (e *encryptionTest) run() error {
+	e.controlPlanes.updated = nil
+	err := e.rotation.RotateEncryptionKeys(e.controlPlane, e.clusterPlan)
+	if e.controlPlanes.updated != nil {
+		e.controlPlane = e.controlPlanes.updated
+	}
+	return err
+}
+
+// runAndExpectPhase runs the rotation and expects it to move to the phase
+func (e *encryptionTest) runAndExpectPhase(phase rkev1.RotateEncryptionKeysPhase) {
+	e.t.Helper()
+	err := e.run()
+	var errWaiting ErrWaiting
+	require.True(e.t, errors.As(err, &errWaiting), "expected to wait, got %v", err)
+	require.NotNil(e.t, e.controlPlanes.updated, "expected the phase to change to %s", phase)
+	require.Equal(e.t, phase, e.controlPlane.Status.RotateEncryptionKeysPhase)
+}
+
+// runAndExpectPlans runs the rotation and expects it to wait for the machines that are given a new plan,
+// returns the instructions of the plans by machine
+func (e *encryptionTest) runAndExpectPlans(machines ...string) map[string][]string {
+	e.t.Helper()
+	before := e.plans()
+	err := e.run()
+	var errWaiting ErrWaiting
+	require.True(e.t, errors.As(err, &errWaiting), "expected to wait, got %v", err)
+	require.Nil(e.t, e.controlPlanes.updated, "the phase must not change while nodes apply their plan")
+
+	result := map[string][]string{}
+	for name, nodePlan := range e.plans() {
+		if equalPlans(before[name], nodePlan) {
+			continue
+		}
+		for _, instruction := range nodePlan.Instructions {
+			result[name] = append(result[name], instruction.Name)
+		}
+	}
+	var changed []string
+	for name := range result {
+		changed = append(changed, name)
+	}
+	assert.ElementsMatch(e.t, machines, changed)
+	return result
+}
+
+// apply makes every node apply its plan
+func (e *encryptionTest) apply(failed bool) {
+	for name, nodePlan := range e.plans() {
+		e.clusterPlan.Nodes[name] = &plan.Node{Plan: nodePlan, InSync: !failed, Failed: failed}
+	}
+}
+
+func (e *encryptionTest) plans() map[string]plan.NodePlan {
+	result := map[string]plan.NodePlan{}
+	for name := range e.clusterPlan.Machines {
+		data := e.secrets.secrets["fleet-default/"+PlanSecretFromBootstrapName(name)].Data["plan"]
+		if data == nil {
+			continue
+		}
+		var nodePlan plan.NodePlan
+		require.NoError(e.t, json.Unmarshal(data, &nodePlan))
+		result[name] = nodePlan
+	}
+	return result
+}
+
+func equalPlans(a, b plan.NodePlan) bool {
+	aData, _ := json.Marshal(a)
+	bData, _ := json.Marshal(b)
+	return string(aData) == string(bData)
+}
+
+func newEncryptionMachines() []*capi.Machine {
+	return []*capi.Machine{
+		newRotationMachine("cp1", ControlPlaneRoleLabel, EtcdRoleLabel, InitNodeLabel),
+		newRotationMachine("cp2", ControlPlaneRoleLabel, EtcdRoleLabel),
+		newRotationMachine("etcd", EtcdRoleLabel),
+		newRotationMachine("worker", WorkerRoleLabel),
+	}
+}
+
+func TestRotateEncryptionKeysPhases(t *testing.T) {
+	e := newEncryptionTest(t, "v1.21.4+rke2r2", newEncryptionMachines()...)
+
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhaseStarted)
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhasePrepare)
+	assert.Equal(t, "cp1", e.controlPlane.Status.RotateEncryptionKeysLeader, "the init node leads the rotation")
+
+	// prepare runs on the leader, then the other control plane nodes are restarted
+	plans := e.runAndExpectPlans("cp1")
+	assert.Equal(t, []string{"secrets-encrypt-prepare", "restart", "wait-prepare"}, plans["cp1"])
+	e.apply(false)
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhasePostPrepareRestart)
+	plans = e.runAndExpectPlans("cp2")
+	assert.Equal(t, []string{"restart", "wait-prepare"}, plans["cp2"])
+	e.apply(false)
+
+	// rotate
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhaseRotate)
+	plans = e.runAndExpectPlans("cp1")
+	assert.Equal(t, []string{"secrets-encrypt-rotate", "restart", "wait-rotate"}, plans["cp1"])
+	e.apply(false)
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhasePostRotateRestart)
+	plans = e.runAndExpectPlans("cp2")
+	assert.Equal(t, []string{"restart", "wait-rotate"}, plans["cp2"])
+	e.apply(false)
+
+	// reencrypt waits for the reencryption to finish before the leader is restarted
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhaseReencrypt)
+	plans = e.runAndExpectPlans("cp1")
+	assert.Equal(t, []string{"secrets-encrypt-reencrypt", "wait-reencrypt_finished", "restart", "wait-reencrypt_finished"}, plans["cp1"])
+	e.apply(false)
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhasePostReencryptRestart)
+	plans = e.runAndExpectPlans("cp2")
+	assert.Equal(t, []string{"restart", "wait-reencrypt_finished"}, plans["cp2"])
+	e.apply(false)
+
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhaseDone)
+	assert.NoError(t, e.run())
+	assert.Nil(t, e.controlPlanes.updated)
+
+	// a new generation starts over
+	e.controlPlane.Spec.RotateEncryptionKeys = &rkev1.RotateEncryptionKeys{Generation: 2}
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhaseStarted)
+	assert.Equal(t, "", e.controlPlane.Status.RotateEncryptionKeysLeader)
+
+	// removing the rotation resets its state
+	e.controlPlane.Spec.RotateEncryptionKeys = nil
+	e.runAndExpectPhase("")
+	assert.Nil(t, e.controlPlane.Status.RotateEncryptionKeys)
+}
+
+func TestRotateEncryptionKeysLeaderFails(t *testing.T) {
+	// the wait for the stage times out on the node, which fails its plan
+	e := newEncryptionTest(t, "v1.21.4+rke2r2", newEncryptionMachines()...)
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhaseStarted)
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhasePrepare)
+	e.runAndExpectPlans("cp1")
+	e.apply(true)
+
+	err := e.run()
+	var errWaiting ErrWaiting
+	require.Error(t, err)
+	assert.False(t, errors.As(err, &errWaiting))
+	assert.Equal(t, rkev1.RotateEncryptionKeysPhaseFailed, e.controlPlane.Status.RotateEncryptionKeysPhase)
+	assert.Equal(t, "failed to run secrets-encrypt prepare on control plane machine(s) cp1", e.controlPlane.Status.RotateEncryptionKeysMessage)
+
+	// a failed rotation stays failed until the next generation
+	assert.NoError(t, e.run())
+	assert.Nil(t, e.controlPlanes.updated)
+}
+
+func TestRotateEncryptionKeysRestartFails(t *testing.T) {
+	e := newEncryptionTest(t, "v1.21.4+rke2r2", newEncryptionMachines()...)
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhaseStarted)
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhasePrepare)
+	e.runAndExpectPlans("cp1")
+	e.apply(false)
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhasePostPrepareRestart)
+	e.runAndExpectPlans("cp2")
+	e.apply(true)
+
+	assert.Error(t, e.run())
+	assert.Equal(t, rkev1.RotateEncryptionKeysPhaseFailed, e.controlPlane.Status.RotateEncryptionKeysPhase)
+	assert.Equal(t, "failed to restart for encryption key rotation on control plane machine(s) cp2", e.controlPlane.Status.RotateEncryptionKeysMessage)
+}
+
+func TestRotateEncryptionKeysLeaderRemoved(t *testing.T) {
+	e := newEncryptionTest(t, "v1.21.4+rke2r2", newEncryptionMachines()...)
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhaseStarted)
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhasePrepare)
+	delete(e.clusterPlan.Machines, "cp1")
+	delete(e.clusterPlan.Nodes, "cp1")
+
+	assert.Error(t, e.run())
+	assert.Equal(t, rkev1.RotateEncryptionKeysPhaseFailed, e.controlPlane.Status.RotateEncryptionKeysPhase)
+	assert.Equal(t, "machine cp1 rotating the encryption key no longer exists", e.controlPlane.Status.RotateEncryptionKeysMessage)
+}
+
+func TestRotateEncryptionKeysStarted(t *testing.T) {
+	// K3s only rotates when secrets encryption is enabled
+	e := newEncryptionTest(t, "v1.21.4+k3s1", newEncryptionMachines()...)
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhaseStarted)
+	assert.Error(t, e.run())
+	assert.Equal(t, rkev1.RotateEncryptionKeysPhaseFailed, e.controlPlane.Status.RotateEncryptionKeysPhase)
+	assert.Equal(t, "secrets encryption is not enabled", e.controlPlane.Status.RotateEncryptionKeysMessage)
+
+	e = newEncryptionTest(t, "v1.21.4+k3s1", newEncryptionMachines()...)
+	e.controlPlane.Spec.MachineGlobalConfig.Data = map[string]interface{}{"secrets-encryption": true}
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhaseStarted)
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhasePrepare)
+
+	// without a provisioned control plane node the rotation waits for one
+	e = newEncryptionTest(t, "v1.21.4+rke2r2", newRotationMachine("cp1", ControlPlaneRoleLabel))
+	e.clusterPlan.Nodes["cp1"] = nil
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhaseStarted)
+	err := e.run()
+	var errWaiting ErrWaiting
+	assert.True(t, errors.As(err, &errWaiting), "expected to wait, got %v", err)
+	assert.Nil(t, e.controlPlanes.updated)
+
+	// without an init node the first control plane node leads
+	e = newEncryptionTest(t, "v1.21.4+rke2r2", newRotationMachine("cp2", ControlPlaneRoleLabel), newRotationMachine("cp1", ControlPlaneRoleLabel))
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhaseStarted)
+	e.runAndExpectPhase(rkev1.RotateEncryptionKeysPhasePrepare)
+	assert.Equal(t, "cp1", e.controlPlane.Status.RotateEncryptionKeysLeader)
+}
+
+func TestWaitForEncryptionStage(t *testing.T) {
+	controlPlane := &rkev1.RKEControlPlane{Spec: rkev1.RKEControlPlaneSpec{KubernetesVersion: "v1.21.4+k3s1"}}
+	instruction := waitForEncryptionStage(controlPlane, encryptionStageRotate)
+	assert.Equal(t, "wait-rotate", instruction.Name)
+	assert.Equal(t, "sh", instruction.Command)
+	assert.Equal(t, []string{
+		"-c",
+		`timeout 600 sh -c 'until k3s secrets-encrypt status | grep -q "Current Rotation Stage: rotate"; do sleep 5; done'`,
+	}, instruction.Args)
+}
@@ -135,6 +135,9 @@ type Planner struct {
 	locker                        locker.Locker
 	etcdRestore                   *etcdRestore
 	etcdCreate                    *etcdCreate
+	certificateRotation           *certificateRotation
+	encryptionKeyRotation         *encryptionKeyRotation
+	adoption                      *adoption
 	etcdArgs                      s3Args
 }
 
@@ -157,6 +160,9 @@ func New(ctx context.Context, clients *wrangler.Context) *Planner {
 		kubeconfig:                    kubeconfig.New(clients),
 		etcdRestore:                   newETCDRestore(clients, store),
 		etcdCreate:                    newETCDCreate(clients, store),
+		certificateRotation:           newCertificateRotation(clients, store),
+		encryptionKeyRotation:         newEncryptionKeyRotation(clients, store),
+		adoption:                      newAdoption(clients, store),
 		etcdArgs: s3Args{
 			prefix:      "etcd-",
 			secretCache: clients.Core.Secret().Cache(),
@@ -195,6 +201,10 @@ func (p *Planner) Process(controlPlane *rkev1.RKEControlPlane) error {
 		return err
 	}
 
+	if err := p.adoption.Adopt(controlPlane, plan); err != nil {
+		return err
+	}
+
 	controlPlane, secret, err := p.generateSecrets(controlPlane, plan)
 	if err != nil {
 		return err
@@ -220,6 +230,14 @@ func (p *Planner) Process(controlPlane *rkev1.RKEControlPlane) error {
 		return ErrWaiting(errMsg)
 	}
 
+	if err := p.certificateRotation.RotateCertificates(controlPlane, plan); err != nil {
+		return err
+	}
+
+	if err := p.encryptionKeyRotation.RotateEncryptionKeys(controlPlane, plan); err != nil {
+		return err
+	}
+
 	if _, err := p.electInitNode(controlPlane, plan); err != nil {
 		return err
 	}
@@ -437,7 +455,7 @@ func calculateConcurrency(maxUnavailable string, entries []planEntry, exclude ro
 		if !exclude(entry.Machine) {
 			count++
 		}
-		if entry.Plan != nil && !entry.Plan.InSync {
+		if entry.Plan != nil && !entry.Plan.InSync && !isPendingEntry(entry) {
 			unavailable++
 		}
 	}
@@ -476,12 +494,14 @@ func (p *Planner) reconcile(controlPlane *rkev1.RKEControlPlane, secret plan.Sec
 	required bool,
 	include, exclude roleFilter, maxUnavailable string, joinServer string, drainOptions rkev1.DrainOptions) error {
 	var (
-		outOfSync   []string
-		nonReady    []string
-		errMachines []string
-		draining    []string
-		uncordoned  []string
-		messages    = map[string]string{}
+		outOfSync       []string
+		nonReady        []string
+		errMachines     []string
+		draining        []string
+		uncordoned      []string
+		preflighting    []string
+		preflightFailed []string
+		messages        = map[string]string{}
 	)
 
 	entries := collect(clusterPlan, include)
@@ -506,12 +526,23 @@ func (p *Planner) reconcile(controlPlane *rkev1.RKEControlPlane, secret plan.Sec
 		}
 		messages[entry.Machine.Name] = strings.Join(summary.Message, ", ")
 
+		if status, message, err := p.preflight(controlPlane, clusterPlan, entry, joinServer); err != nil {
+			return err
+		} else if status == corev1.ConditionUnknown {
+			preflighting = append(preflighting, entry.Machine.Name)
+			continue
+		} else if status == corev1.ConditionFalse {
+			preflightFailed = append(preflightFailed, entry.Machine.Name)
+			messages[entry.Machine.Name] = message
+			continue
+		}
+
 		plan, err := p.desiredPlan(controlPlane, secret, entry, isInitNode(entry.Machine), joinServer)
 		if err != nil {
 			return err
 		}
 
-		if entry.Plan == nil {
+		if entry.Plan == nil || isPendingEntry(entry) {
 			outOfSync = append(outOfSync, entry.Machine.Name)
 			if err := p.store.UpdatePlan(entry.Machine, plan, 0); err != nil {
 				return err
@@ -573,6 +604,17 @@ func (p *Planner) reconcile(controlPlane *rkev1.RKEControlPlane, secret plan.Sec
 		return ErrWaiting("uncordoning " + tierName + " node(s) " + strings.Join(uncordoned, ",") + detailMessage(uncordoned, messages))
 	}
 
+	preflighting = atMostThree(preflighting)
+	if len(preflighting) > 0 {
+		return ErrWaiting("running preflight checks on " + tierName + " node(s) " + strings.Join(preflighting, ","))
+	}
+
+	preflightFailed = atMostThree(preflightFailed)
+	if len(preflightFailed) > 0 {
+		// a node that fails its preflight checks is not part of the cluster yet, so don't block the others
+		return errIgnore("preflight checks failing on " + tierName + " machine(s) " + strings.Join(preflightFailed, ",") + detailMessage(preflightFailed, messages))
+	}
+
 	nonReady = atMostThree(nonReady)
 	if len(nonReady) > 0 {
 		// we want these errors to get reported, but not block the process
@@ -1015,6 +1057,10 @@ func (p *Planner) addConfigFile(nodePlan plan.NodePlan, controlPlane *rkev1.RKEC
 	}
 	nodePlan.Files = append(nodePlan.Files, files...)
 
+	if err := p.adoption.addAdoptedSecrets(config, controlPlane, machine); err != nil {
+		return nodePlan, err
+	}
+
 	files, err = p.addETCD(config, controlPlane, machine)
 	if err != nil {
 		return nodePlan, err
@@ -1103,6 +1149,11 @@ func (p *Planner) desiredPlan(controlPlane *rkev1.RKEControlPlane, secret plan.S
 		return nodePlan, err
 	}
 
+	nodePlan, err = p.addTakeoverInstruction(nodePlan, controlPlane, entry.Machine)
+	if err != nil {
+		return nodePlan, err
+	}
+
 	if initNode && IsOnlyEtcd(entry.Machine) {
 		nodePlan, err = p.addInitNodeInstruction(nodePlan, controlPlane, entry.Machine)
 		if err != nil {
@@ -1169,6 +1220,12 @@ type planEntry struct {
 	Plan    *plan.Node
 }
 
+// isPendingEntry returns true if the machine has only been given a preflight or adoption plan and not its real
+// plan yet.
+func isPendingEntry(entry planEntry) bool {
+	return entry.Plan != nil && (IsPreflightPlan(entry.Plan.Plan) || IsAdoptionPlan(entry.Plan.Plan))
+}
+
 func collect(plan *plan.Plan, include func(*capi.Machine) bool) (result []planEntry) {
 	for name, machine := range plan.Machines {
 		if !include(machine) {
@@ -1197,12 +1254,38 @@ func (p *Planner) generateSecrets(controlPlane *rkev1.RKEControlPlane, fullPlan
 	return controlPlane, secret, nil
 }
 
+func rkeStateSecretName(controlPlane *rkev1.RKEControlPlane) string {
+	return name.SafeConcatName(controlPlane.Name, "rke", "state")
+}
+
+func newRKEStateSecret(controlPlane *rkev1.RKEControlPlane, serverToken, agentToken string) *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      rkeStateSecretName(controlPlane),
+			Namespace: controlPlane.Namespace,
+			OwnerReferences: []metav1.OwnerReference{
+				{
+					APIVersion: "rke.cattle.io/v1",
+					Kind:       "RKEControlPlane",
+					Name:       controlPlane.Name,
+					UID:        controlPlane.UID,
+				},
+			},
+		},
+		Data: map[string][]byte{
+			"serverToken": []byte(serverToken),
+			"agentToken":  []byte(agentToken),
+		},
+		Type: "rke.cattle.io/cluster-state",
+	}
+}
+
 func (p *Planner) ensureRKEStateSecret(controlPlane *rkev1.RKEControlPlane, fullPlan *plan.Plan) (string, plan.Secret, error) {
 	if controlPlane.Spec.UnmanagedConfig {
 		return "", plan.Secret{}, nil
 	}
 
-	name := name.SafeConcatName(controlPlane.Name, "rke", "state")
+	name := rkeStateSecretName(controlPlane)
 	secret, err := p.secretCache.Get(controlPlane.Namespace, name)
 	if apierror.IsNotFound(err) {
 		serverToken, err := randomtoken.Generate()
@@ -1215,27 +1298,7 @@ func (p *Planner) ensureRKEStateSecret(controlPlane *rkev1.RKEControlPlane, full
 			return "", plan.Secret{}, err
 		}
 
-		secret := &corev1.Secret{
-			ObjectMeta: metav1.ObjectMeta{
-				Name:      name,
-				Namespace: controlPlane.Namespace,
-				OwnerReferences: []metav1.OwnerReference{
-					{
-						APIVersion: "rke.cattle.io/v1",
-						Kind:       "RKEControlPlane",
-						Name:       controlPlane.Name,
-						UID:        controlPlane.UID,
-					},
-				},
-			},
-			Data: map[string][]byte{
-				"serverToken": []byte(serverToken),
-				"agentToken":  []byte(agentToken),
-			},
-			Type: "rke.cattle.io/cluster-state",
-		}
-
-		_, err = p.secretClient.Create(secret)
+		_, err = p.secretClient.Create(newRKEStateSecret(controlPlane, serverToken, agentToken))
 		return name, plan.Secret{
 			ServerToken: serverToken,
 			AgentToken:  agentToken,
@@ -0,0 +1,232 @@
+package planner
+
+import (
+	// for go:embed
+	_ "embed"
+	"fmt"
+	"net"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/rancher/wrangler/pkg/condition"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+const (
+	PreflightReady = condition.Cond("PreflightReady")
+
+	// PreflightAttemptAnnotation is bumped to run the preflight checks of a machine again.
+	PreflightAttemptAnnotation = "rke.cattle.io/preflight-attempt"
+
+	PreflightPlanStatus        = "Preflight"
+	PreflightPlanStatusMessage = "running preflight checks"
+	PreflightPassedStatus      = "PreflightPassed"
+	PreflightFailedStatus      = "PreflightFailed"
+
+	preflightInstructionName = "preflight"
+	preflightCompleteLine    = "preflight complete"
+	preflightMinDiskMB       = 4096
+)
+
+//go:embed preflight.sh
+var preflightScript string
+
+type PreflightCheck struct {
+	Name    string
+	Status  string
+	Message string
+}
+
+type PreflightResult struct {
+	// Complete is false if the output is missing or was cut off.
+	Complete bool
+	Checks   []PreflightCheck
+}
+
+// Passed returns true if every check of a complete run passed, warnings don't fail the checks.
+func (r PreflightResult) Passed() bool {
+	if !r.Complete {
+		return false
+	}
+	for _, check := range r.Checks {
+		if check.Status == "FAIL" {
+			return false
+		}
+	}
+	return true
+}
+
+// Message summarizes the failed checks, or the warnings if no check failed.
+func (r PreflightResult) Message() string {
+	if !r.Complete {
+		return "preflight checks did not complete"
+	}
+
+	var failed, warnings []string
+	for _, check := range r.Checks {
+		switch check.Status {
+		case "FAIL":
+			failed = append(failed, check.Name+": "+check.Message)
+		case "WARN":
+			warnings = append(warnings, check.Name+": "+check.Message)
+		}
+	}
+
+	if len(failed) > 0 {
+		return "failed preflight checks: " + strings.Join(failed, "; ")
+	} else if len(warnings) > 0 {
+		return "preflight checks passed with warnings: " + strings.Join(warnings, "; ")
+	}
+	return "preflight checks passed"
+}
+
+// ParsePreflightOutput parses the saved output of the preflight instruction.
+func ParsePreflightOutput(output []byte) PreflightResult {
+	var result PreflightResult
+	for _, line := range strings.Split(string(output), "\n") {
+		line = strings.TrimSpace(line)
+		if line == preflightCompleteLine {
+			result.Complete = true
+			continue
+		}
+
+		status, rest := splitOnce(line, " ")
+		if status != "PASS" && status != "WARN" && status != "FAIL" {
+			continue
+		}
+		name, message := splitOnce(rest, ": ")
+		result.Checks = append(result.Checks, PreflightCheck{
+			Name:    name,
+			Status:  status,
+			Message: message,
+		})
+	}
+	return result
+}
+
+func splitOnce(s, sep string) (string, string) {
+	parts := strings.SplitN(s, sep, 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
+
+// IsPreflightPlan returns true if the node plan only runs the preflight checks.
+func IsPreflightPlan(nodePlan plan.NodePlan) bool {
+	return len(nodePlan.Instructions) == 1 && nodePlan.Instructions[0].Name == preflightInstructionName
+}
+
+// GetPreflightStatusReasonMessage returns the state of the preflight checks of a node that has the preflight plan
+// assigned.
+func GetPreflightStatusReasonMessage(node *plan.Node) (corev1.ConditionStatus, string, string) {
+	if node == nil || !IsPreflightPlan(node.Plan) || !node.InSync {
+		return corev1.ConditionUnknown, PreflightPlanStatus, PreflightPlanStatusMessage
+	}
+	result := ParsePreflightOutput(node.Output[preflightInstructionName])
+	if result.Passed() {
+		return corev1.ConditionTrue, PreflightPassedStatus, result.Message()
+	}
+	return corev1.ConditionFalse, PreflightFailedStatus, result.Message()
+}
+
+// needsPreflight returns true for custom machines that have not been given their first plan yet. Machines
+// created by Rancher run on images that are known to work, existing and adopted nodes are never checked again.
+func needsPreflight(entry planEntry) bool {
+	if entry.Machine.Spec.InfrastructureRef.Kind != "CustomMachine" ||
+		entry.Machine.Labels["cattle.io/os"] == "windows" ||
+		entry.Machine.DeletionTimestamp != nil {
+		return false
+	}
+	if isAdopted(entry.Machine) {
+		return false
+	}
+	return entry.Plan == nil || isPendingEntry(entry)
+}
+
+// preflight assigns the preflight plan to a new custom machine and returns the state of its checks. The machine
+// can be given its real plan once the status is true.
+func (p *Planner) preflight(controlPlane *rkev1.RKEControlPlane, clusterPlan *plan.Plan, entry planEntry, joinServer string) (corev1.ConditionStatus, string, error) {
+	if !needsPreflight(entry) {
+		return corev1.ConditionTrue, "", nil
+	}
+
+	nodePlan := preflightPlan(controlPlane, clusterPlan, entry, joinServer, p.getControlPlaneJoinURL(clusterPlan))
+	if entry.Plan == nil || !equality.Semantic.DeepEqual(entry.Plan.Plan, nodePlan) {
+		return corev1.ConditionUnknown, PreflightPlanStatusMessage, p.store.UpdatePlan(entry.Machine, nodePlan, 0)
+	}
+
+	status, _, message := GetPreflightStatusReasonMessage(entry.Plan)
+	return status, message, nil
+}
+
+func preflightPlan(controlPlane *rkev1.RKEControlPlane, clusterPlan *plan.Plan, entry planEntry, joinServer, controlPlaneJoinServer string) plan.NodePlan {
+	return plan.NodePlan{
+		Instructions: []plan.Instruction{
+			{
+				Name:       preflightInstructionName,
+				Command:    "sh",
+				SaveOutput: true,
+				Args:       []string{"-c", preflightScript},
+				Env: []string{
+					fmt.Sprintf("PREFLIGHT_RUNTIME=%s", GetRuntime(controlPlane.Spec.KubernetesVersion)),
+					fmt.Sprintf("PREFLIGHT_ENDPOINTS=%s", strings.Join(preflightEndpoints(controlPlane, clusterPlan, entry, joinServer, controlPlaneJoinServer), " ")),
+					fmt.Sprintf("PREFLIGHT_MIN_DISK_MB=%d", preflightMinDiskMB),
+					fmt.Sprintf("PREFLIGHT_ATTEMPT=%s", entry.Machine.Annotations[PreflightAttemptAnnotation]),
+				},
+			},
+		},
+	}
+}
+
+// preflightEndpoints returns the ports of existing servers the machine must be able to reach: the supervisor of the
+// join server, the API server of a control plane node and, for etcd machines, the etcd client and peer ports of the
+// other etcd members. The join server of etcd and control plane machines is the init node, which may be etcd only
+// and not run an API server.
+func preflightEndpoints(controlPlane *rkev1.RKEControlPlane, clusterPlan *plan.Plan, entry planEntry, joinServer, controlPlaneJoinServer string) []string {
+	endpoints := map[string]bool{}
+
+	if host := joinURLHost(joinServer); host != "" {
+		endpoints[net.JoinHostPort(host, strconv.Itoa(GetRuntimeSupervisorPort(controlPlane.Spec.KubernetesVersion)))] = true
+	}
+	if host := joinURLHost(controlPlaneJoinServer); host != "" {
+		endpoints[net.JoinHostPort(host, "6443")] = true
+	}
+
+	if isEtcd(entry.Machine) {
+		for _, other := range collect(clusterPlan, isEtcd) {
+			if other.Machine.Name == entry.Machine.Name || other.Plan == nil || isPendingEntry(other) {
+				continue
+			}
+			address := other.Machine.Annotations[InternalAddressAnnotation]
+			if address == "" {
+				address = other.Machine.Annotations[AddressAnnotation]
+			}
+			if address == "" {
+				continue
+			}
+			endpoints[net.JoinHostPort(address, "2379")] = true
+			endpoints[net.JoinHostPort(address, "2380")] = true
+		}
+	}
+
+	var result []string
+	for endpoint := range endpoints {
+		result = append(result, endpoint)
+	}
+	sort.Strings(result)
+	return result
+}
+
+func joinURLHost(joinURL string) string {
+	u, err := url.Parse(joinURL)
+	if err != nil {
+		return ""
+	}
+	return u.Hostname()
+}
@@ -0,0 +1,148 @@
+#!/bin/sh
+#
+# Preflight checks run on a custom node before it is joined to the cluster. Every check prints one line
+# "<PASS|WARN|FAIL> <check>: <message>" and the script always exits 0 so the result is saved by the
+# system-agent, the planner parses the output.
+#
+# Environment:
+#   PREFLIGHT_RUNTIME      rke2 or k3s, the distribution that will be installed
+#   PREFLIGHT_ENDPOINTS    space separated host:port pairs of existing servers that must be reachable
+#   PREFLIGHT_MIN_DISK_MB  minimum free space in MiB for /var/lib/rancher
+
+result() {
+    echo "$1 $2: $3"
+}
+
+check_kernel_modules() {
+    missing=""
+    for module in overlay br_netfilter; do
+        if [ -d "/sys/module/${module}" ] || grep -qs "^${module} " /proc/modules; then
+            continue
+        fi
+        if command -v modprobe >/dev/null 2>&1 && modprobe -n "${module}" >/dev/null 2>&1; then
+            continue
+        fi
+        missing="${missing} ${module}"
+    done
+    if [ -n "${missing}" ]; then
+        result FAIL kernel-modules "required kernel modules are not available:${missing}"
+    else
+        result PASS kernel-modules "overlay and br_netfilter are available"
+    fi
+}
+
+check_swap() {
+    if [ -r /proc/swaps ] && [ "$(grep -vc '^Filename' /proc/swaps)" -gt 0 ]; then
+        result FAIL swap "swap is enabled, disable it with swapoff -a and remove it from /etc/fstab"
+    else
+        result PASS swap "swap is disabled"
+    fi
+}
+
+check_ports() {
+    if [ -z "${PREFLIGHT_ENDPOINTS}" ]; then
+        result PASS ports "no existing servers to check"
+        return
+    fi
+    if ! command -v curl >/dev/null 2>&1; then
+        result WARN ports "curl is not installed, unable to check connectivity to existing servers"
+        return
+    fi
+    unreachable=""
+    for endpoint in ${PREFLIGHT_ENDPOINTS}; do
+        # Only a refused connection (7) or a timeout (28) means the port is blocked, TLS and HTTP errors
+        # mean the server answered.
+        curl -ks -o /dev/null --connect-timeout 5 --max-time 10 "https://${endpoint}/"
+        case $? in
+            7|28) unreachable="${unreachable} ${endpoint}" ;;
+        esac
+    done
+    if [ -n "${unreachable}" ]; then
+        result FAIL ports "unable to connect to existing servers:${unreachable}"
+    else
+        result PASS ports "existing servers are reachable"
+    fi
+}
+
+check_time_sync() {
+    if command -v timedatectl >/dev/null 2>&1; then
+        synced=$(timedatectl show -p NTPSynchronized --value 2>/dev/null)
+        if [ -z "${synced}" ]; then
+            synced=$(timedatectl status 2>/dev/null | sed -n 's/.*synchronized: *//p')
+        fi
+        case "${synced}" in
+            yes)
+                result PASS time-sync "system clock is synchronized"
+                return
+                ;;
+            no)
+                result FAIL time-sync "system clock is not synchronized, enable NTP (for example with timedatectl set-ntp true)"
+                return
+                ;;
+        esac
+    fi
+    if command -v chronyc >/dev/null 2>&1 && chronyc tracking 2>/dev/null | grep -q '^Leap status *: Normal'; then
+        result PASS time-sync "system clock is synchronized"
+        return
+    fi
+    result WARN time-sync "unable to determine if the system clock is synchronized"
+}
+
+check_disk_space() {
+    dir=/var/lib/rancher
+    while [ ! -d "${dir}" ]; do
+        dir=$(dirname "${dir}")
+    done
+    available=$(df -Pk "${dir}" 2>/dev/null | awk 'NR==2 {print int($4 / 1024)}')
+    if [ -z "${available}" ]; then
+        result WARN disk-space "unable to determine free space of ${dir}"
+    elif [ "${available}" -lt "${PREFLIGHT_MIN_DISK_MB:-0}" ]; then
+        result FAIL disk-space "${available}MiB free for /var/lib/rancher, at least ${PREFLIGHT_MIN_DISK_MB}MiB are required"
+    else
+        result PASS disk-space "${available}MiB free for /var/lib/rancher"
+    fi
+}
+
+service_active() {
+    if command -v systemctl >/dev/null 2>&1; then
+        systemctl is-active --quiet "$1" 2>/dev/null
+        return
+    fi
+    pgrep -x "$1" >/dev/null 2>&1
+}
+
+check_runtimes() {
+    case "${PREFLIGHT_RUNTIME}" in
+        rke2) conflicts="k3s k3s-agent" ;;
+        *) conflicts="rke2-server rke2-agent" ;;
+    esac
+    running=""
+    for service in ${conflicts}; do
+        if service_active "${service}"; then
+            running="${running} ${service}"
+        fi
+    done
+    if [ -n "${running}" ]; then
+        result FAIL runtimes "conflicting Kubernetes distribution is running:${running}, uninstall it before registering the node"
+        return
+    fi
+    running=""
+    for service in docker containerd; do
+        if service_active "${service}"; then
+            running="${running} ${service}"
+        fi
+    done
+    if [ -n "${running}" ]; then
+        result WARN runtimes "container runtime is running next to ${PREFLIGHT_RUNTIME}:${running}"
+    else
+        result PASS runtimes "no conflicting container runtimes"
+    fi
+}
+
+check_kernel_modules
+check_swap
+check_ports
+check_time_sync
+check_disk_space
+check_runtimes
+echo "preflight complete"
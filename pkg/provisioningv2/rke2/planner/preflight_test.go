@@ -0,0 +1,185 @@
+package planner
+
+import (
+	"testing"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	capi "sigs.k8s.io/cluster-api/api/v1alpha4"
+)
+
+func TestParsePreflightOutput(t *testing.T) {
+	result := ParsePreflightOutput([]byte(`PASS kernel-modules: overlay and br_netfilter are available
+PASS swap: swap is disabled
+WARN time-sync: unable to determine if the system clock is synchronized
+preflight complete
+`))
+	assert.True(t, result.Passed())
+	assert.Len(t, result.Checks, 3)
+	assert.Equal(t, "preflight checks passed with warnings: time-sync: unable to determine if the system clock is synchronized", result.Message())
+
+	result = ParsePreflightOutput([]byte(`PASS kernel-modules: overlay and br_netfilter are available
+FAIL swap: swap is enabled
+FAIL ports: unable to connect to existing servers: 10.0.0.1:9345
+preflight complete
+`))
+	assert.False(t, result.Passed())
+	assert.Equal(t, "failed preflight checks: swap: swap is enabled; ports: unable to connect to existing servers: 10.0.0.1:9345", result.Message())
+
+	result = ParsePreflightOutput([]byte("PASS swap: swap is disabled\n"))
+	assert.False(t, result.Passed())
+	assert.Equal(t, "preflight checks did not complete", result.Message())
+}
+
+func newPreflightMachine(name, address string, roles ...string) *capi.Machine {
+	machine := &capi.Machine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Labels:      map[string]string{},
+			Annotations: map[string]string{},
+		},
+		Spec: capi.MachineSpec{
+			InfrastructureRef: corev1.ObjectReference{Kind: "CustomMachine"},
+		},
+	}
+	if address != "" {
+		machine.Annotations[AddressAnnotation] = address
+		machine.Annotations[JoinURLAnnotation] = "https://" + address + ":9345"
+	}
+	for _, role := range roles {
+		machine.Labels[role] = "true"
+	}
+	return machine
+}
+
+func newPreflightClusterPlan(machines ...*capi.Machine) *plan.Plan {
+	clusterPlan := &plan.Plan{
+		Machines: map[string]*capi.Machine{},
+		Nodes:    map[string]*plan.Node{},
+	}
+	for _, machine := range machines {
+		clusterPlan.Machines[machine.Name] = machine
+		if machine.Annotations[AddressAnnotation] != "" {
+			clusterPlan.Nodes[machine.Name] = &plan.Node{
+				Plan: plan.NodePlan{Instructions: []plan.Instruction{{Name: "install"}}},
+			}
+		}
+	}
+	return clusterPlan
+}
+
+func TestPreflightEndpoints(t *testing.T) {
+	controlPlane := &rkev1.RKEControlPlane{Spec: rkev1.RKEControlPlaneSpec{KubernetesVersion: "v1.21.4+rke2r2"}}
+	// split roles, the init node only runs etcd
+	initNode := newPreflightMachine("etcd-1", "10.0.0.1", EtcdRoleLabel, InitNodeLabel)
+	controlPlaneNode := newPreflightMachine("cp-1", "10.0.0.2", ControlPlaneRoleLabel)
+	pendingEtcd := newPreflightMachine("etcd-3", "", EtcdRoleLabel)
+	newEtcd := newPreflightMachine("etcd-2", "", EtcdRoleLabel)
+	newControlPlane := newPreflightMachine("cp-2", "", ControlPlaneRoleLabel)
+	newWorker := newPreflightMachine("worker-1", "", WorkerRoleLabel)
+
+	clusterPlan := newPreflightClusterPlan(initNode, controlPlaneNode, pendingEtcd, newEtcd, newControlPlane, newWorker)
+	clusterPlan.Nodes[pendingEtcd.Name] = &plan.Node{
+		Plan: plan.NodePlan{Instructions: []plan.Instruction{{Name: preflightInstructionName}}},
+	}
+	p := &Planner{}
+	initJoinURL := "https://10.0.0.1:9345"
+	controlPlaneJoinURL := p.getControlPlaneJoinURL(clusterPlan)
+	assert.Equal(t, "https://10.0.0.2:9345", controlPlaneJoinURL)
+
+	tests := []struct {
+		name       string
+		machine    *capi.Machine
+		joinServer string
+		want       []string
+	}{
+		{
+			name:       "etcd",
+			machine:    newEtcd,
+			joinServer: initJoinURL,
+			want:       []string{"10.0.0.1:2379", "10.0.0.1:2380", "10.0.0.1:9345", "10.0.0.2:6443"},
+		},
+		{
+			name:       "control plane",
+			machine:    newControlPlane,
+			joinServer: initJoinURL,
+			want:       []string{"10.0.0.1:9345", "10.0.0.2:6443"},
+		},
+		{
+			name:       "worker",
+			machine:    newWorker,
+			joinServer: controlPlaneJoinURL,
+			want:       []string{"10.0.0.2:6443", "10.0.0.2:9345"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			entry := planEntry{Machine: tt.machine, Plan: clusterPlan.Nodes[tt.machine.Name]}
+			assert.Equal(t, tt.want, preflightEndpoints(controlPlane, clusterPlan, entry, tt.joinServer, controlPlaneJoinURL))
+		})
+	}
+
+	// the API server is not probed before a control plane node joined
+	clusterPlan = newPreflightClusterPlan(initNode, newControlPlane)
+	entry := planEntry{Machine: newControlPlane}
+	assert.Equal(t, []string{"10.0.0.1:9345"},
+		preflightEndpoints(controlPlane, clusterPlan, entry, initJoinURL, p.getControlPlaneJoinURL(clusterPlan)))
+}
+
+func TestNeedsPreflight(t *testing.T) {
+	preflightNode := &plan.Node{Plan: plan.NodePlan{Instructions: []plan.Instruction{{Name: preflightInstructionName}}}}
+	adoptionNode := &plan.Node{Plan: plan.NodePlan{Instructions: []plan.Instruction{{Name: adoptionInstructionName}}}}
+	installedNode := &plan.Node{Plan: plan.NodePlan{Instructions: []plan.Instruction{{Name: "install"}}}}
+
+	machine := func(mutate func(*capi.Machine)) *capi.Machine {
+		m := newPreflightMachine("m", "", WorkerRoleLabel)
+		if mutate != nil {
+			mutate(m)
+		}
+		return m
+	}
+	now := metav1.Now()
+
+	tests := []struct {
+		name  string
+		entry planEntry
+		want  bool
+	}{
+		{name: "new custom machine", entry: planEntry{Machine: machine(nil)}, want: true},
+		{name: "preflight plan", entry: planEntry{Machine: machine(nil), Plan: preflightNode}, want: true},
+		{name: "adoption plan", entry: planEntry{Machine: machine(nil), Plan: adoptionNode}, want: true},
+		{name: "real plan", entry: planEntry{Machine: machine(nil), Plan: installedNode}},
+		{
+			name: "machine created by rancher",
+			entry: planEntry{Machine: machine(func(m *capi.Machine) {
+				m.Spec.InfrastructureRef.Kind = "Amazonec2Machine"
+			})},
+		},
+		{
+			name: "windows",
+			entry: planEntry{Machine: machine(func(m *capi.Machine) {
+				m.Labels["cattle.io/os"] = "windows"
+			})},
+		},
+		{
+			name: "deleting",
+			entry: planEntry{Machine: machine(func(m *capi.Machine) {
+				m.DeletionTimestamp = &now
+			})},
+		},
+		{
+			name: "adopted",
+			entry: planEntry{Machine: machine(func(m *capi.Machine) {
+				m.Annotations[AdoptionAnnotation] = AdoptionAdopted
+			}), Plan: adoptionNode},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, needsPreflight(tt.entry))
+		})
+	}
+}
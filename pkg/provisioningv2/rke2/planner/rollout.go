@@ -0,0 +1,72 @@
+package planner
+
+import (
+	"fmt"
+	"strings"
+
+	rkev1 "github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1"
+	"github.com/rancher/rancher/pkg/apis/rke.cattle.io/v1/plan"
+	"k8s.io/apimachinery/pkg/api/equality"
+)
+
+type nodePlanFunc func(controlPlane *rkev1.RKEControlPlane, entry planEntry) (plan.NodePlan, error)
+
+// rollout assigns the plan returned by planFunc to the nodes matching include, at most maxUnavailable
+// at a time, and returns nil once all of them applied it. Nodes that fail to apply the plan fail the
+// rollout.
+func rollout(store *PlanStore, controlPlane *rkev1.RKEControlPlane, clusterPlan *plan.Plan, action, tierName string,
+	include roleFilter, planFunc nodePlanFunc, maxUnavailable string) error {
+	entries := collect(clusterPlan, include)
+
+	concurrency, _, err := calculateConcurrency(maxUnavailable, entries, none)
+	if err != nil {
+		return err
+	}
+
+	var (
+		updating []string
+		failed   []string
+		pending  []planEntry
+		plans    []plan.NodePlan
+	)
+
+	for _, entry := range entries {
+		if entry.Plan == nil {
+			// nodes without a plan are not provisioned yet and will get the current configuration
+			continue
+		}
+		nodePlan, err := planFunc(controlPlane, entry)
+		if err != nil {
+			return err
+		}
+		if !equality.Semantic.DeepEqual(entry.Plan.Plan, nodePlan) {
+			pending = append(pending, entry)
+			plans = append(plans, nodePlan)
+		} else if entry.Plan.Failed {
+			failed = append(failed, entry.Machine.Name)
+		} else if !entry.Plan.InSync {
+			updating = append(updating, entry.Machine.Name)
+		}
+	}
+
+	failed = atMostThree(failed)
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to %s on %s machine(s) %s", action, tierName, strings.Join(failed, ","))
+	}
+
+	for i, entry := range pending {
+		if concurrency != 0 && len(updating) >= concurrency {
+			break
+		}
+		if err := store.UpdatePlan(entry.Machine, plans[i], 3); err != nil {
+			return err
+		}
+		updating = append(updating, entry.Machine.Name)
+	}
+
+	updating = atMostThree(updating)
+	if len(updating) > 0 {
+		return ErrWaiting(fmt.Sprintf("waiting to %s on %s node(s) %s", action, tierName, strings.Join(updating, ",")))
+	}
+	return nil
+}
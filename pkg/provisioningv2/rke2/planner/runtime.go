@@ -20,6 +20,13 @@ func GetRuntimeServerUnit(kubernetesVersion string) string {
 	return RuntimeRKE2 + "-server"
 }
 
+func GetRuntimeAgentUnit(kubernetesVersion string) string {
+	if GetRuntime(kubernetesVersion) == RuntimeK3S {
+		return RuntimeK3S + "-agent"
+	}
+	return RuntimeRKE2 + "-agent"
+}
+
 func GetRuntimeEnv(kubernetesVersion string) string {
 	return strings.ToUpper(GetRuntime(kubernetesVersion))
 }
@@ -129,6 +129,14 @@ func GetPlanStatusReasonMessage(machine *capi.Machine, plan *plan.Node) (corev1.
 		return corev1.ConditionUnknown, NoAgentPlanStatus, NoAgentPlanStatusMessage
 	case len(plan.Plan.Instructions) == 0:
 		return corev1.ConditionUnknown, NoPlanPlanStatus, noPlanMessage(machine)
+	case IsAdoptionPlan(plan.Plan):
+		return corev1.ConditionUnknown, AdoptionPlanStatus, AdoptionPlanStatusMessage
+	case IsPreflightPlan(plan.Plan):
+		status, reason, message := GetPreflightStatusReasonMessage(plan)
+		if status == corev1.ConditionTrue {
+			return corev1.ConditionUnknown, WaitingPlanStatus, message
+		}
+		return status, reason, message
 	case plan.Plan.Error != "":
 		return corev1.ConditionFalse, ErrorStatus, plan.Plan.Error
 	case !plan.Healthy:
@@ -55,22 +55,23 @@
 const encryptionConfigUpdate = "provisioner.cattle.io/encrypt-migrated"
 
 type Options struct {
-	ACMEDomains       cli.StringSlice
-	AddLocal          string
-	Embedded          bool
-	BindHost          string
-	HTTPListenPort    int
-	HTTPSListenPort   int
-	K8sMode           string
-	Debug             bool
-	Trace             bool
-	NoCACerts         bool
-	AuditLogPath      string
-	AuditLogMaxage    int
-	AuditLogMaxsize   int
-	AuditLogMaxbackup int
-	AuditLevel        int
-	Features          string
+	ACMEDomains        cli.StringSlice
+	AddLocal           string
+	Embedded           bool
+	BindHost           string
+	HTTPListenPort     int
+	HTTPSListenPort    int
+	AgentTLSListenPort int
+	K8sMode            string
+	Debug              bool
+	Trace              bool
+	NoCACerts          bool
+	AuditLogPath       string
+	AuditLogMaxage     int
+	AuditLogMaxsize    int
+	AuditLogMaxbackup  int
+	AuditLevel         int
+	Features           string
 }
 
 type Rancher struct {
@@ -271,6 +272,7 @@ func (r *Rancher) ListenAndServe(ctx context.Context) error {
 		r.opts.BindHost,
 		r.opts.HTTPSListenPort,
 		r.opts.HTTPListenPort,
+		r.opts.AgentTLSListenPort,
 		r.opts.ACMEDomains,
 		r.opts.NoCACerts); err != nil {
 		return err
@@ -240,7 +240,9 @@ func clusterTypes(schemas *types.Schemas) *types.Schemas {
 			m.Drop{Field: "systemImages"},
 		).
 		MustImport(&Version, v3.Cluster{}).
-		MustImport(&Version, v3.ClusterRegistrationToken{}).
+		MustImportAndCustomize(&Version, v3.ClusterRegistrationToken{}, func(schema *types.Schema) {
+			schema.ResourceActions[v3.ClusterRegistrationTokenActionRotate] = types.Action{}
+		}).
 		MustImport(&Version, v3.GenerateKubeConfigOutput{}).
 		MustImport(&Version, v3.ImportClusterYamlInput{}).
 		MustImport(&Version, v3.RotateCertificateInput{}).
@@ -749,16 +751,25 @@ func alertTypes(schema *types.Schemas) *types.Schemas {
 		MustImport(&Version, v3.ClusterAlert{}).
 		MustImport(&Version, v3.ProjectAlert{}).
 		MustImport(&Version, v3.Notification{}).
+		MustImport(&Version, v3.NotifierPreviewOutput{}).
 		MustImportAndCustomize(&Version, v3.Notifier{}, func(schema *types.Schema) {
 			schema.CollectionActions = map[string]types.Action{
 				"send": {
 					Input: "notification",
 				},
+				"preview": {
+					Input:  "notification",
+					Output: "notifierPreviewOutput",
+				},
 			}
 			schema.ResourceActions = map[string]types.Action{
 				"send": {
 					Input: "notification",
 				},
+				"preview": {
+					Input:  "notification",
+					Output: "notifierPreviewOutput",
+				},
 			}
 		}).
 		MustImport(&Version, v3.AlertStatus{}).
@@ -865,12 +865,16 @@ func pipelineTypes(schema *types.Schemas) *types.Schemas {
 		MustImport(&Version, v3.BitbucketServerApplyInput{}).
 		MustImport(&Version, v3.BitbucketServerRequestLoginInput{}).
 		MustImport(&Version, v3.BitbucketServerRequestLoginOutput{}).
+		MustImport(&Version, v3.GiteaApplyInput{}).
+		MustImport(&Version, v3.AzureDevOpsApplyInput{}).
 		MustImportAndCustomize(&Version, v3.SourceCodeProvider{}, func(schema *types.Schema) {
 			schema.CollectionMethods = []string{http.MethodGet}
 		}).
 		MustImportAndCustomize(&Version, v3.GithubProvider{}, baseProviderCustomizeFunc).
 		MustImportAndCustomize(&Version, v3.GitlabProvider{}, baseProviderCustomizeFunc).
 		MustImportAndCustomize(&Version, v3.BitbucketCloudProvider{}, baseProviderCustomizeFunc).
+		MustImportAndCustomize(&Version, v3.GiteaProvider{}, baseProviderCustomizeFunc).
+		MustImportAndCustomize(&Version, v3.AzureDevOpsProvider{}, baseProviderCustomizeFunc).
 		MustImportAndCustomize(&Version, v3.BitbucketServerProvider{}, func(schema *types.Schema) {
 			schema.BaseType = "sourceCodeProvider"
 			schema.ResourceActions = map[string]types.Action{
@@ -937,6 +941,28 @@ func pipelineTypes(schema *types.Schemas) *types.Schemas {
 		schema.CollectionMethods = []string{}
 		schema.ResourceMethods = []string{http.MethodGet, http.MethodPut}
 	}).
+		MustImportAndCustomize(&Version, v3.GiteaPipelineConfig{}, func(schema *types.Schema) {
+			schema.BaseType = "sourceCodeProviderConfig"
+			schema.ResourceActions = map[string]types.Action{
+				"disable": {},
+				"testAndApply": {
+					Input: "giteaApplyInput",
+				},
+			}
+			schema.CollectionMethods = []string{}
+			schema.ResourceMethods = []string{http.MethodGet, http.MethodPut}
+		}).
+		MustImportAndCustomize(&Version, v3.AzureDevOpsPipelineConfig{}, func(schema *types.Schema) {
+			schema.BaseType = "sourceCodeProviderConfig"
+			schema.ResourceActions = map[string]types.Action{
+				"disable": {},
+				"testAndApply": {
+					Input: "azureDevOpsApplyInput",
+				},
+			}
+			schema.CollectionMethods = []string{}
+			schema.ResourceMethods = []string{http.MethodGet, http.MethodPut}
+		}).
 		MustImportAndCustomize(&Version, v3.Pipeline{}, func(schema *types.Schema) {
 			schema.ResourceActions = map[string]types.Action{
 				"activate":   {},
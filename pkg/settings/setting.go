@@ -19,9 +19,11 @@
 	provider       Provider
 	InjectDefaults string
 
+	AgentCertificateMode              = NewSetting("agent-certificate-mode", "optional") // optional or required
 	AgentImage                        = NewSetting("agent-image", "rancher/rancher-agent:master-head")
 	AgentRolloutTimeout               = NewSetting("agent-rollout-timeout", "300s")
 	AgentRolloutWait                  = NewSetting("agent-rollout-wait", "true")
+	AgentTLSAddress                   = NewSetting("agent-tls-address", "")
 	AuthImage                         = NewSetting("auth-image", v32.ToolsSystemImages.AuthSystemImages.KubeAPIAuth)
 	AuthTokenMaxTTLMinutes            = NewSetting("auth-token-max-ttl-minutes", "0") // never expire
 	AuthorizationCacheTTLSeconds      = NewSetting("authorization-cache-ttl-seconds", "10")
@@ -32,6 +34,7 @@
 	CLIURLLinux                       = NewSetting("cli-url-linux", "https://releases.rancher.com/cli/v1.0.0-alpha8/rancher-linux-amd64-v1.0.0-alpha8.tar.gz")
 	CLIURLWindows                     = NewSetting("cli-url-windows", "https://releases.rancher.com/cli/v1.0.0-alpha8/rancher-windows-386-v1.0.0-alpha8.zip")
 	ClusterControllerStartCount       = NewSetting("cluster-controller-start-count", "50")
+	ClusterDisconnectNotifyMinutes    = NewSetting("cluster-disconnect-notify-minutes", "0") // 0 disables notifying the notifiers of a cluster when it stays disconnected
 	EngineInstallURL                  = NewSetting("engine-install-url", "https://releases.rancher.com/install-docker/20.10.sh")
 	EngineISOURL                      = NewSetting("engine-iso-url", "https://releases.rancher.com/os/latest/rancheros-vmware.iso")
 	EngineNewestVersion               = NewSetting("engine-newest-version", "v17.12.0")
@@ -73,6 +76,7 @@
 	TelemetryOpt                      = NewSetting("telemetry-opt", "")
 	TLSMinVersion                     = NewSetting("tls-min-version", "1.2")
 	TLSCiphers                        = NewSetting("tls-ciphers", "TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305")
+	TrustedProxies                    = NewSetting("trusted-proxies", "") // comma separated addresses and CIDRs whose X-Forwarded-For header is honoured
 	UIBanners                         = NewSetting("ui-banners", "{}")
 	UIBrand                           = NewSetting("ui-brand", "")
 	UIDefaultLanding                  = NewSetting("ui-default-landing", "vue")
@@ -112,7 +116,9 @@
 	GKEUpstreamRefresh                = NewSetting("gke-refresh", "300")
 	HideLocalCluster                  = NewSetting("hide-local-cluster", "false")
 	MachineProvisionImage             = NewSetting("machine-provision-image", "rancher/machine:v0.15.0-rancher69")
+	ClusterAutoscalerImage            = NewSetting("cluster-autoscaler-image", "rancher/mirrored-autoscaling-cluster-autoscaler:v1.22.1")
 	SystemFeatureChartRefreshSeconds  = NewSetting("system-feature-chart-refresh-seconds", "900")
+	AppDriftCheckIntervalSeconds      = NewSetting("app-drift-check-interval-seconds", "900") // 0 disables drift detection of catalog v2 apps
 
 	FleetMinVersion          = NewSetting("fleet-min-version", "")
 	RancherWebhookMinVersion = NewSetting("rancher-webhook-min-version", "")
@@ -3,6 +3,7 @@
 import (
 	"context"
 	"crypto/tls"
+	"crypto/x509"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -36,6 +37,9 @@
 	rancherCertFile    = "/etc/rancher/ssl/cert.pem"
 	rancherKeyFile     = "/etc/rancher/ssl/key.pem"
 	rancherCACertsFile = "/etc/rancher/ssl/cacerts.pem"
+
+	// AgentCAName is the secret of the CA that issues the client certificates of cluster and node agents
+	AgentCAName = "tls-rancher-agent"
 )
 
 type internalAPI struct{}
@@ -44,7 +48,7 @@ type internalAPI struct{
 	InternalAPI = internalAPI{}
 )
 
-func ListenAndServe(ctx context.Context, restConfig *rest.Config, handler http.Handler, bindHost string, httpsPort, httpPort int, acmeDomains []string, noCACerts bool) error {
+func ListenAndServe(ctx context.Context, restConfig *rest.Config, handler http.Handler, bindHost string, httpsPort, httpPort, agentTLSPort int, acmeDomains []string, noCACerts bool) error {
 	restConfig = rest.CopyConfig(restConfig)
 	restConfig.Timeout = 10 * time.Minute
 	opts := &server.ListenOpts{}
@@ -66,6 +70,15 @@ func ListenAndServe(ctx context.Context, restConfig *rest.Config, handler http.H
 
 	migrateConfig(ctx, restConfig, opts)
 
+	var agentOpts *server.ListenOpts
+	if httpsPort != 0 && agentTLSPort != 0 {
+		agentCA, _, err := kubernetes.LoadOrGenCA(core.Core().V1().Secret(), namespace.System, AgentCAName)
+		if err != nil {
+			return err
+		}
+		agentOpts = agentListenOpts(opts, agentCA)
+	}
+
 	backoff := wait.Backoff{
 		Duration: 100 * time.Millisecond,
 		Factor:   2,
@@ -88,6 +101,21 @@ func ListenAndServe(ctx context.Context, restConfig *rest.Config, handler http.H
 		return errors.Wrap(err, "failed to ListenAndServe")
 	}
 
+	if agentOpts != nil {
+		err = wait.ExponentialBackoff(backoff, func() (bool, error) {
+			if err := server.ListenAndServe(ctx, agentTLSPort, 0, AgentHandler(handler), agentOpts); err != nil {
+				if apierrors.IsAlreadyExists(err) {
+					return false, nil
+				}
+				return false, err
+			}
+			return true, nil
+		})
+		if err != nil {
+			return errors.Wrap(err, "failed to ListenAndServe for agents")
+		}
+	}
+
 	internalPort := 0
 	if httpsPort != 0 {
 		internalPort = httpsPort + 1
@@ -134,6 +162,34 @@ func ListenAndServe(ctx context.Context, restConfig *rest.Config, handler http.H
 
 }
 
+// agentListenOpts returns the options of the listener cluster and node agents connect the
+// tunnel to with their client certificate. It serves the same certificate as the main listener,
+// client certificates are only requested there so browsers are never prompted for one.
+func agentListenOpts(opts *server.ListenOpts, agentCA *x509.Certificate) *server.ListenOpts {
+	agentOpts := *opts
+	agentOpts.NoRedirect = true
+	agentOpts.TLSListenerConfig.TLSConfig = opts.TLSListenerConfig.TLSConfig.Clone()
+
+	clientCAs := x509.NewCertPool()
+	clientCAs.AddCert(agentCA)
+	// Enforcing the client certificate is up to the tunnel authorizer, it depends on the
+	// agent-certificate-mode setting and agents renew expired certificates here as well
+	agentOpts.TLSListenerConfig.TLSConfig.ClientAuth = tls.VerifyClientCertIfGiven
+	agentOpts.TLSListenerConfig.TLSConfig.ClientCAs = clientCAs
+	return &agentOpts
+}
+
+// AgentHandler only serves the agent connect endpoints, everything else is not found
+func AgentHandler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path != "/v3/connect" && !strings.HasPrefix(req.URL.Path, "/v3/connect/") {
+			http.NotFound(rw, req)
+			return
+		}
+		next.ServeHTTP(rw, req)
+	})
+}
+
 func migrateConfig(ctx context.Context, restConfig *rest.Config, opts *server.ListenOpts) {
 	c, err := dynamic.NewForConfig(restConfig)
 	if err != nil {
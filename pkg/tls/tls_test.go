@@ -0,0 +1,66 @@
+package tls
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/dynamiclistener"
+	"github.com/rancher/dynamiclistener/server"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAgentListenOpts(t *testing.T) {
+	opts := &server.ListenOpts{
+		CertName: "tls-rancher-internal",
+		TLSListenerConfig: dynamiclistener.Config{
+			TLSConfig: &tls.Config{MinVersion: tls.VersionTLS12},
+		},
+	}
+	agentCA := &x509.Certificate{
+		Subject:    pkix.Name{CommonName: "agent-ca"},
+		RawSubject: []byte("agent-ca"),
+	}
+
+	agentOpts := agentListenOpts(opts, agentCA)
+
+	assert.Equal(t, tls.VerifyClientCertIfGiven, agentOpts.TLSListenerConfig.TLSConfig.ClientAuth)
+	assert.Len(t, agentOpts.TLSListenerConfig.TLSConfig.ClientCAs.Subjects(), 1)
+	assert.Equal(t, uint16(tls.VersionTLS12), agentOpts.TLSListenerConfig.TLSConfig.MinVersion)
+	assert.Equal(t, "tls-rancher-internal", agentOpts.CertName)
+	assert.True(t, agentOpts.NoRedirect)
+
+	// The main listener never requests client certificates
+	assert.Equal(t, tls.NoClientCert, opts.TLSListenerConfig.TLSConfig.ClientAuth)
+	assert.Nil(t, opts.TLSListenerConfig.TLSConfig.ClientCAs)
+	assert.False(t, opts.NoRedirect)
+}
+
+func TestAgentHandler(t *testing.T) {
+	handler := AgentHandler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.WriteHeader(http.StatusOK)
+	}))
+
+	tests := []struct {
+		path   string
+		status int
+	}{
+		{path: "/v3/connect", status: http.StatusOK},
+		{path: "/v3/connect/register", status: http.StatusOK},
+		{path: "/v3/connect/certificate", status: http.StatusOK},
+		{path: "/v3/connectors", status: http.StatusNotFound},
+		{path: "/v3/settings/cacerts", status: http.StatusNotFound},
+		{path: "/dashboard/", status: http.StatusNotFound},
+		{path: "/", status: http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			assert.Equal(t, tt.status, rw.Code)
+		})
+	}
+}
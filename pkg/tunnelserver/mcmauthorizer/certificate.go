@@ -0,0 +1,299 @@
+package mcmauthorizer
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/rancher/dynamiclistener/storage/kubernetes"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/namespace"
+	"github.com/rancher/rancher/pkg/settings"
+	"github.com/rancher/rancher/pkg/tls"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/cert"
+)
+
+const (
+	// certificateValidity is the lifetime of agent certificates, agents renew them once two
+	// thirds of it passed
+	certificateValidity = 30 * 24 * time.Hour
+
+	agentCertificateLabel      = "cattle.io/agent-certificate"
+	agentCertificateAnnotation = "cattle.io/agent-certificate-cn"
+	agentCertificatePrefix     = "agent-cert-"
+
+	// AgentTLSAddress is the response header with the address of the listener agents connect the
+	// tunnel to with their certificate
+	AgentTLSAddress = "X-Cattle-Agent-Tls-Address"
+
+	// certificateModeRequired rejects tunnels of agents that didn't authenticate with their
+	// client certificate
+	certificateModeRequired = "required"
+)
+
+// identity is the cluster and, for node agents, the node an agent authenticated as
+type identity struct {
+	cluster *v3.Cluster
+	// nodeName is the node the client certificate or the credential of the agent is bound to, a
+	// deleted node can't be enrolled again with them
+	nodeName string
+	// certificate is true if the agent authenticated with a client certificate
+	certificate bool
+	// credentialAgent is the agent the credential the agent authenticated with was issued to
+	credentialAgent string
+	// registrationToken is the registration token the agent authenticated with, enrollments with it count as a use
+	registrationToken *v3.ClusterRegistrationToken
+}
+
+// ServeCertificate signs the PEM encoded certificate request in the body for an authorized
+// agent. Every certificate is recorded in a secret owned by the node or cluster of the agent,
+// deleting those revokes the certificate. A certificate issued to an agent that authenticated with the registration
+// token counts as a use of the token.
+func (t *Authorizer) ServeCertificate(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	client, ok, err := t.Authorize(req)
+	if err != nil && err != ErrClusterNotFound {
+		rw.WriteHeader(http.StatusInternalServerError)
+		rw.Write([]byte(err.Error()))
+		return
+	} else if !ok || client == nil {
+		rw.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	certPEM, err := t.issueCertificate(client, req)
+	if err != nil {
+		rw.WriteHeader(http.StatusBadRequest)
+		rw.Write([]byte(err.Error()))
+		return
+	}
+
+	if address := settings.AgentTLSAddress.Get(); address != "" {
+		rw.Header().Set(AgentTLSAddress, address)
+	}
+	rw.Header().Set("Content-Type", "application/x-pem-file")
+	rw.Write(certPEM)
+}
+
+func (t *Authorizer) issueCertificate(client *Client, req *http.Request) ([]byte, error) {
+	body, err := ioutil.ReadAll(io.LimitReader(req.Body, 64*1024))
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(body)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return nil, errors.New("invalid input, expected a PEM encoded certificate request")
+	}
+
+	csr, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if err := csr.CheckSignature(); err != nil {
+		return nil, err
+	}
+
+	caCert, caKey, err := t.agentCA()
+	if err != nil {
+		return nil, err
+	}
+
+	serial, err := rand.Int(rand.Reader, new(big.Int).SetInt64(math.MaxInt64))
+	if err != nil {
+		return nil, err
+	}
+
+	cn := client.Cluster.Name
+	owner := metav1.OwnerReference{
+		APIVersion: "management.cattle.io/v3",
+		Kind:       "Cluster",
+		Name:       client.Cluster.Name,
+		UID:        client.Cluster.UID,
+	}
+	if client.Node != nil {
+		cn = client.Cluster.Name + ":" + client.Node.Name
+		owner = metav1.OwnerReference{
+			APIVersion: "management.cattle.io/v3",
+			Kind:       "Node",
+			Name:       client.Node.Name,
+			UID:        client.Node.UID,
+		}
+	}
+
+	now := time.Now()
+	template := &x509.Certificate{
+		SerialNumber: serial,
+		Subject: pkix.Name{
+			CommonName: cn,
+		},
+		NotBefore:   now.Add(-time.Minute).UTC(),
+		NotAfter:    now.Add(certificateValidity).UTC(),
+		KeyUsage:    x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, caCert, csr.PublicKey, caKey)
+	if err != nil {
+		return nil, err
+	}
+
+	// The record has to exist before the agent gets the certificate, otherwise it could
+	// connect with a certificate that is considered revoked
+	_, err = t.secrets.Create(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      certificateRecordName(serial),
+			Namespace: client.Cluster.Name,
+			Labels: map[string]string{
+				agentCertificateLabel: "true",
+			},
+			Annotations: map[string]string{
+				agentCertificateAnnotation: cn,
+			},
+			OwnerReferences: []metav1.OwnerReference{owner},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Revoke the certificate that is renewed
+	if peer := peerCertificate(req); peer != nil && peer.Subject.CommonName == cn {
+		err := t.secrets.DeleteNamespaced(client.Cluster.Name, certificateRecordName(peer.SerialNumber), &metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			logrus.Warnf("Failed to revoke renewed agent certificate %s of %s: %v", peer.SerialNumber.Text(16), cn, err)
+		}
+	}
+
+	logrus.Infof("Issued agent certificate %s for %s valid until %s", serial.Text(16), cn, template.NotAfter)
+	return pem.EncodeToMemory(&pem.Block{
+		Type:  cert.CertificateBlockType,
+		Bytes: der,
+	}), nil
+}
+
+// getIdentityByCertificate returns the identity of an agent that presented a valid client
+// certificate or nil if it didn't present one
+func (t *Authorizer) getIdentityByCertificate(req *http.Request) (*identity, error) {
+	peer := peerCertificate(req)
+	if peer == nil {
+		return nil, nil
+	}
+
+	caCert, _, err := t.agentCA()
+	if err != nil {
+		return nil, err
+	}
+
+	roots := x509.NewCertPool()
+	roots.AddCert(caCert)
+	if _, err := peer.Verify(x509.VerifyOptions{
+		Roots:     roots,
+		KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}); err != nil {
+		logrus.Debugf("Authorize: invalid agent certificate %s: %v", peer.Subject.CommonName, err)
+		return nil, nil
+	}
+
+	clusterName, nodeName := splitCommonName(peer.Subject.CommonName)
+	record, err := t.secretLister.Get(clusterName, certificateRecordName(peer.SerialNumber))
+	if apierrors.IsNotFound(err) {
+		logrus.Debugf("Authorize: agent certificate %s of %s is revoked", peer.SerialNumber.Text(16), peer.Subject.CommonName)
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if record.Labels[agentCertificateLabel] != "true" || record.Annotations[agentCertificateAnnotation] != peer.Subject.CommonName {
+		return nil, nil
+	}
+
+	cluster, err := t.clusterLister.Get("", clusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &identity{
+		cluster:     cluster,
+		nodeName:    nodeName,
+		certificate: true,
+	}, nil
+}
+
+// certificateMissing returns true if the request opens a tunnel without a client certificate while
+// the mode requires one. Agents enroll and fetch their configuration with the registration token
+// or their credential in any mode.
+func certificateMissing(mode string, req *http.Request, id *identity) bool {
+	if mode != certificateModeRequired || id.certificate {
+		return false
+	}
+	return isTunnel(req)
+}
+
+func isTunnel(req *http.Request) bool {
+	return strings.HasSuffix(req.URL.Path, "/connect") || strings.HasSuffix(req.URL.Path, "/register")
+}
+
+// isEnrollment returns true for requests that may create the node of a node agent
+func isEnrollment(req *http.Request) bool {
+	return strings.HasSuffix(req.URL.Path, "/register") || strings.HasSuffix(req.URL.Path, "/certificate")
+}
+
+func isCertificateRequest(req *http.Request) bool {
+	return strings.HasSuffix(req.URL.Path, "/certificate")
+}
+
+// agentCA loads the CA that signs agent certificates, it is created on first use
+func (t *Authorizer) agentCA() (*x509.Certificate, crypto.Signer, error) {
+	t.caLock.Lock()
+	defer t.caLock.Unlock()
+
+	if t.caCert != nil {
+		return t.caCert, t.caKey, nil
+	}
+
+	caCert, caKey, err := kubernetes.LoadOrGenCA(t.secretClient, namespace.System, tls.AgentCAName)
+	if err != nil {
+		return nil, nil, fmt.Errorf("loading agent CA: %w", err)
+	}
+
+	t.caCert, t.caKey = caCert, caKey
+	return caCert, caKey, nil
+}
+
+func peerCertificate(req *http.Request) *x509.Certificate {
+	if req.TLS == nil || len(req.TLS.PeerCertificates) == 0 {
+		return nil
+	}
+	return req.TLS.PeerCertificates[0]
+}
+
+func certificateRecordName(serial *big.Int) string {
+	return agentCertificatePrefix + serial.Text(16)
+}
+
+func splitCommonName(cn string) (string, string) {
+	parts := strings.SplitN(cn, ":", 2)
+	if len(parts) == 1 {
+		return parts[0], ""
+	}
+	return parts[0], parts[1]
+}
@@ -0,0 +1,56 @@
+package mcmauthorizer
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCertificateMissing(t *testing.T) {
+	tokenID := &identity{}
+	certificateID := &identity{certificate: true}
+
+	tests := []struct {
+		name    string
+		mode    string
+		path    string
+		id      *identity
+		missing bool
+	}{
+		{name: "optional tunnel with token", mode: "optional", path: "/v3/connect", id: tokenID},
+		{name: "optional register with token", mode: "optional", path: "/v3/connect/register", id: tokenID},
+		{name: "unset mode with token", mode: "", path: "/v3/connect", id: tokenID},
+		{name: "required tunnel with token", mode: "required", path: "/v3/connect", id: tokenID, missing: true},
+		{name: "required register with token", mode: "required", path: "/v3/connect/register", id: tokenID, missing: true},
+		{name: "required tunnel with certificate", mode: "required", path: "/v3/connect", id: certificateID},
+		{name: "required register with certificate", mode: "required", path: "/v3/connect/register", id: certificateID},
+		{name: "required enrollment with token", mode: "required", path: "/v3/connect/certificate", id: tokenID},
+		{name: "required credential with token", mode: "required", path: "/v3/connect/credential", id: tokenID},
+		{name: "required config with token", mode: "required", path: "/v3/connect/config", id: tokenID},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			assert.Equal(t, tt.missing, certificateMissing(tt.mode, req, tt.id))
+		})
+	}
+}
+
+func TestIsEnrollment(t *testing.T) {
+	assert.True(t, isEnrollment(httptest.NewRequest(http.MethodGet, "/v3/connect/register", nil)))
+	assert.True(t, isEnrollment(httptest.NewRequest(http.MethodPost, "/v3/connect/certificate", nil)))
+	assert.False(t, isEnrollment(httptest.NewRequest(http.MethodGet, "/v3/connect", nil)))
+	assert.False(t, isEnrollment(httptest.NewRequest(http.MethodGet, "/v3/connect/config", nil)))
+}
+
+func TestSplitCommonName(t *testing.T) {
+	cluster, node := splitCommonName("c-abc:m-xyz")
+	assert.Equal(t, "c-abc", cluster)
+	assert.Equal(t, "m-xyz", node)
+
+	cluster, node = splitCommonName("c-abc")
+	assert.Equal(t, "c-abc", cluster)
+	assert.Empty(t, node)
+}
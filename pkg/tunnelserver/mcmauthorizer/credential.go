@@ -0,0 +1,229 @@
+package mcmauthorizer
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"strings"
+	"time"
+
+	client "github.com/rancher/rancher/pkg/client/generated/management/v3"
+	"github.com/rancher/rancher/pkg/clusterregistrationtoken"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/wrangler/pkg/randomtoken"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// AgentCredential is the header agents send the credential they were issued at their first
+	// connect in. Unlike the registration token it does not expire and survives token rotation.
+	AgentCredential = "X-API-Tunnel-Agent-Credential"
+
+	agentCredentialLabel      = "cattle.io/agent-credential"
+	agentCredentialAnnotation = "cattle.io/agent"
+	// agentCredentialNodeAnnotation is the name of the node a credential of a node agent is bound to
+	agentCredentialNodeAnnotation = "cattle.io/agent-node"
+	agentCredentialPrefix         = "agent-credential-"
+	agentCredentialHashKey        = "hash"
+)
+
+// ServeCredential exchanges a valid registration token for a credential of the agent. Every
+// exchange counts as a use of the registration token. The credential of a node agent is bound to
+// its node, which is created if it doesn't exist yet, and is deleted with it.
+func (t *Authorizer) ServeCredential(rw http.ResponseWriter, req *http.Request) {
+	if req.Method != http.MethodPost {
+		rw.WriteHeader(http.StatusMethodNotAllowed)
+		return
+	}
+
+	credential, err := t.issueCredential(req)
+	if apierrors.IsConflict(err) {
+		rw.WriteHeader(http.StatusConflict)
+		return
+	} else if err == ErrClusterNotFound {
+		rw.WriteHeader(http.StatusUnauthorized)
+		return
+	} else if err != nil {
+		rw.WriteHeader(http.StatusInternalServerError)
+		rw.Write([]byte(err.Error()))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "text/plain")
+	rw.Write([]byte(credential))
+}
+
+func (t *Authorizer) issueCredential(req *http.Request) (string, error) {
+	crt, err := t.getRegistrationToken(req.Header.Get(Token))
+	if err != nil {
+		return "", err
+	}
+
+	cluster, err := t.clusterLister.Get("", crt.Spec.ClusterName)
+	if err != nil {
+		return "", err
+	}
+
+	input, err := t.readInput(cluster, req)
+	if err != nil {
+		return "", err
+	}
+
+	agent := credentialAgent(input.Node)
+
+	// Count the use first so concurrent registrations can't exceed the maximum
+	if crt, err = clusterregistrationtoken.Use(t.crts, crt); err == clusterregistrationtoken.ErrNotUsable {
+		return "", ErrClusterNotFound
+	} else if err != nil {
+		return "", err
+	}
+
+	annotations := map[string]string{
+		agentCredentialAnnotation: agent,
+	}
+	owner := metav1.OwnerReference{
+		APIVersion: "management.cattle.io/v3",
+		Kind:       "Cluster",
+		Name:       cluster.Name,
+		UID:        cluster.UID,
+	}
+	if input.Node != nil {
+		node, err := t.getOrCreateNode(cluster, input.Node, req)
+		if err != nil {
+			return "", err
+		}
+		annotations[agentCredentialNodeAnnotation] = node.Name
+		owner = metav1.OwnerReference{
+			APIVersion: "management.cattle.io/v3",
+			Kind:       "Node",
+			Name:       node.Name,
+			UID:        node.UID,
+		}
+	}
+
+	secret, err := randomtoken.Generate()
+	if err != nil {
+		return "", err
+	}
+
+	hash := sha256.Sum256([]byte(secret))
+	hashHex := hex.EncodeToString(hash[:])
+	_, err = t.secrets.Create(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      agentCredentialPrefix + hashHex[:16],
+			Namespace: cluster.Name,
+			Labels: map[string]string{
+				agentCredentialLabel: "true",
+			},
+			Annotations:     annotations,
+			OwnerReferences: []metav1.OwnerReference{owner},
+		},
+		Data: map[string][]byte{
+			agentCredentialHashKey: []byte(hashHex),
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	logrus.Infof("Issued credential for agent %s of cluster %s using registration token %s/%s", agent, cluster.Name, crt.Namespace, crt.Name)
+	return cluster.Name + ":" + secret, nil
+}
+
+// getOrCreateNode returns the node of a node agent that requests a credential, the node is created if it doesn't
+// exist yet. A node that is being deleted can't enroll again.
+func (t *Authorizer) getOrCreateNode(cluster *v3.Cluster, inNode *client.Node, req *http.Request) (*v3.Node, error) {
+	node, err := t.getMachine(cluster, inNode)
+	if apierrors.IsNotFound(err) {
+		return t.createNode(inNode, cluster, req)
+	} else if err != nil {
+		return nil, err
+	}
+	if node.DeletionTimestamp != nil {
+		return nil, ErrClusterNotFound
+	}
+	return node, nil
+}
+
+// getIdentityByCredential returns the identity of an agent that authenticated with a credential issued by
+// issueCredential. Credentials of node agents are bound to the name of their node.
+func (t *Authorizer) getIdentityByCredential(credential string) (*identity, error) {
+	cluster, stored, err := t.getClusterByCredential(credential)
+	if err != nil || cluster == nil {
+		return nil, err
+	}
+	return &identity{
+		cluster:         cluster,
+		nodeName:        stored.Annotations[agentCredentialNodeAnnotation],
+		credentialAgent: stored.Annotations[agentCredentialAnnotation],
+	}, nil
+}
+
+// getClusterByCredential returns the cluster of a credential issued by issueCredential and the secret it is stored
+// in.
+func (t *Authorizer) getClusterByCredential(credential string) (*v3.Cluster, *corev1.Secret, error) {
+	clusterName, secret := splitCredential(credential)
+	if clusterName == "" || secret == "" {
+		return nil, nil, ErrClusterNotFound
+	}
+
+	hash := sha256.Sum256([]byte(secret))
+	hashHex := hex.EncodeToString(hash[:])
+
+	stored, err := t.secretLister.Get(clusterName, agentCredentialPrefix+hashHex[:16])
+	if apierrors.IsNotFound(err) {
+		return nil, nil, ErrClusterNotFound
+	} else if err != nil {
+		return nil, nil, err
+	}
+
+	if stored.Labels[agentCredentialLabel] != "true" ||
+		subtle.ConstantTimeCompare(stored.Data[agentCredentialHashKey], []byte(hashHex)) != 1 {
+		return nil, nil, ErrClusterNotFound
+	}
+
+	cluster, err := t.clusterLister.Get("", clusterName)
+	return cluster, stored, err
+}
+
+// credentialAgent returns the agent a credential is issued to, "cluster" for the cluster agent and
+// "node/<hostname>" for node agents. A credential can only be used by the agent it was issued to, so a node
+// can't claim the hostname of another node once the credentials are issued.
+func credentialAgent(node *client.Node) string {
+	if node == nil {
+		return "cluster"
+	}
+	return "node/" + node.RequestedHostname
+}
+
+func splitCredential(credential string) (string, string) {
+	i := strings.Index(credential, ":")
+	if i < 0 {
+		return "", ""
+	}
+	return credential[:i], credential[i+1:]
+}
+
+// getRegistrationToken returns the registration token with the given value if it is neither
+// expired nor used by its maximum number of agents
+func (t *Authorizer) getRegistrationToken(token string) (*v3.ClusterRegistrationToken, error) {
+	objs, err := t.crtIndexer.ByIndex(crtKeyIndex, token)
+	if err != nil {
+		return nil, err
+	}
+
+	candidates := make([]*v3.ClusterRegistrationToken, 0, len(objs))
+	for _, obj := range objs {
+		candidates = append(candidates, obj.(*v3.ClusterRegistrationToken))
+	}
+
+	crt, err := clusterregistrationtoken.Find(candidates, time.Now())
+	if err == clusterregistrationtoken.ErrNotUsable {
+		return nil, ErrClusterNotFound
+	}
+	return crt, err
+}
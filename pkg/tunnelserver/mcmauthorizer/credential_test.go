@@ -0,0 +1,91 @@
+package mcmauthorizer
+
+import (
+	"net/http"
+	"testing"
+
+	client "github.com/rancher/rancher/pkg/client/generated/management/v3"
+	corefakes "github.com/rancher/rancher/pkg/generated/norman/core/v1/fakes"
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// withSecrets stores the secrets the authorizer creates in a map
+func withSecrets(auth *Authorizer) map[string]*corev1.Secret {
+	secrets := map[string]*corev1.Secret{}
+	auth.secrets = &corefakes.SecretInterfaceMock{
+		CreateFunc: func(secret *corev1.Secret) (*corev1.Secret, error) {
+			secrets[secret.Namespace+"/"+secret.Name] = secret
+			return secret, nil
+		},
+	}
+	auth.secretLister = &corefakes.SecretListerMock{
+		GetFunc: func(namespace, name string) (*corev1.Secret, error) {
+			if secret, ok := secrets[namespace+"/"+name]; ok {
+				return secret, nil
+			}
+			return nil, apierrors.NewNotFound(corev1.Resource("secrets"), name)
+		},
+	}
+	return secrets
+}
+
+func TestNodeCredentialBoundToNode(t *testing.T) {
+	auth, nodes := newTestAuthorizer(0)
+	secrets := withSecrets(auth)
+
+	credential, err := auth.issueCredential(newNodeRequest(t, "/v3/connect/credential", "node1"))
+	assert.NoError(t, err)
+	assert.Len(t, nodes, 1)
+	assert.Len(t, secrets, 1)
+
+	var node string
+	for name := range nodes {
+		node = name
+	}
+	for _, secret := range secrets {
+		assert.Equal(t, "Node", secret.OwnerReferences[0].Kind)
+		assert.Equal(t, node, secret.OwnerReferences[0].Name)
+		assert.Equal(t, node, secret.Annotations[agentCredentialNodeAnnotation])
+	}
+
+	credentialRequest := func(path, hostname string) *http.Request {
+		req := newNodeRequest(t, path, hostname)
+		req.Header.Del(Token)
+		req.Header.Set(AgentCredential, credential)
+		return req
+	}
+
+	_, ok, err := auth.Authorize(credentialRequest("/v3/connect/register", "node1"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	_, ok, err = auth.Authorize(credentialRequest("/v3/connect/register", "node2"))
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	delete(nodes, node)
+	for _, path := range []string{"/v3/connect/register", "/v3/connect/certificate"} {
+		_, ok, err = auth.Authorize(credentialRequest(path, "node1"))
+		assert.NoError(t, err)
+		assert.False(t, ok)
+	}
+	assert.Empty(t, nodes)
+}
+
+func TestCredentialAgent(t *testing.T) {
+	assert.Equal(t, "cluster", credentialAgent(nil))
+	assert.Equal(t, "node/node1", credentialAgent(&client.Node{RequestedHostname: "node1"}))
+	assert.NotEqual(t, credentialAgent(&client.Node{RequestedHostname: "node1"}), credentialAgent(&client.Node{RequestedHostname: "node2"}))
+}
+
+func TestSplitCredential(t *testing.T) {
+	cluster, secret := splitCredential("c-abc:secret:with:colons")
+	assert.Equal(t, "c-abc", cluster)
+	assert.Equal(t, "secret:with:colons", secret)
+
+	cluster, secret = splitCredential("nocolon")
+	assert.Empty(t, cluster)
+	assert.Empty(t, secret)
+}
@@ -1,24 +1,33 @@
 package mcmauthorizer
 
 import (
+	"crypto"
 	"crypto/md5"
+	"crypto/x509"
 	"encoding/base64"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"net"
 	"net/http"
 	"reflect"
 	"strings"
+	"sync"
 
 	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/clusterregistrationtoken"
 	"github.com/rancher/rancher/pkg/kontainerdriver"
+	"github.com/rancher/rancher/pkg/metrics/downstream"
 
 	"github.com/rancher/norman/types/convert"
 	client "github.com/rancher/rancher/pkg/client/generated/management/v3"
+	corev1 "github.com/rancher/rancher/pkg/generated/norman/core/v1"
 	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/settings"
 	"github.com/rancher/rancher/pkg/taints"
 	"github.com/rancher/rancher/pkg/types/config"
+	wranglercorev1 "github.com/rancher/wrangler/pkg/generated/controllers/core/v1"
 	"github.com/sirupsen/logrus"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -32,6 +41,13 @@
 
 	Token  = "X-API-Tunnel-Token"
 	Params = "X-API-Tunnel-Params"
+	// AgentVersion is the header agents send their version in
+	AgentVersion = "X-API-Tunnel-Agent-Version"
+
+	// AgentVersionAnnotation and AgentAddressAnnotation on the cluster are the version and
+	// address of the cluster agent that last connected
+	AgentVersionAnnotation = "cattle.io/agent-version"
+	AgentAddressAnnotation = "cattle.io/agent-address"
 )
 
 var (
@@ -66,6 +82,10 @@ func NewAuthorizer(context *config.ScaledContext) *Authorizer {
 		machines:              context.Management.Nodes(""),
 		clusters:              context.Management.Clusters(""),
 		KontainerDriverLister: context.Management.KontainerDrivers("").Controller().Lister(),
+		crts:                  context.Management.ClusterRegistrationTokens(""),
+		secrets:               context.Core.Secrets(""),
+		secretLister:          context.Core.Secrets("").Controller().Lister(),
+		secretClient:          context.Wrangler.Core.Secret(),
 	}
 	context.Management.ClusterRegistrationTokens("").Controller().Informer().AddIndexers(map[string]cache.IndexFunc{
 		crtKeyIndex: auth.crtIndex,
@@ -84,6 +104,14 @@ type Authorizer struct {
 	machines              v3.NodeInterface
 	clusters              v3.ClusterInterface
 	KontainerDriverLister v3.KontainerDriverLister
+	crts                  v3.ClusterRegistrationTokenInterface
+	secrets               corev1.SecretInterface
+	secretLister          corev1.SecretLister
+	secretClient          wranglercorev1.SecretClient
+
+	caLock sync.Mutex
+	caCert *x509.Certificate
+	caKey  crypto.Signer
 }
 
 type Client struct {
@@ -96,6 +124,9 @@ type Client struct {
 
 func (t *Authorizer) AuthorizeTunnel(req *http.Request) (string, bool, error) {
 	client, ok, err := t.Authorize(req)
+	if ok && client != nil && client.Cluster != nil {
+		downstream.IncTunnelConnects(client.Cluster.Name, client.Node != nil)
+	}
 	if client != nil && client.Node != nil {
 		return client.Cluster.Name + ":" + client.Node.Name, ok, err
 	} else if client != nil && client.Cluster != nil {
@@ -107,14 +138,15 @@ func (t *Authorizer) AuthorizeTunnel(req *http.Request) (string, bool, error) {
 
 func (t *Authorizer) Authorize(req *http.Request) (*Client, bool, error) {
 	token := req.Header.Get(Token)
-	if token == "" {
-		logrus.Debugf("Authorize: Token header [%s] is empty", Token)
-		return nil, false, nil
+	id, err := t.authenticate(req, token)
+	if err != nil || id == nil {
+		return nil, false, err
 	}
+	cluster := id.cluster
 
-	cluster, err := t.getClusterByToken(token)
-	if err != nil || cluster == nil {
-		return nil, false, err
+	if certificateMissing(settings.AgentCertificateMode.Get(), req, id) {
+		logrus.Debugf("Authorize: agents of cluster [%s] have to connect with their client certificate", cluster.Name)
+		return nil, false, nil
 	}
 
 	input, err := t.readInput(cluster, req)
@@ -122,13 +154,38 @@ func (t *Authorizer) Authorize(req *http.Request) (*Client, bool, error) {
 		return nil, false, err
 	}
 
+	if id.credentialAgent != "" && id.credentialAgent != credentialAgent(input.Node) {
+		logrus.Debugf("Authorize: credential of agent [%s] can't be used by agent [%s] in cluster [%s]", id.credentialAgent, credentialAgent(input.Node), cluster.Name)
+		return nil, false, nil
+	}
+
+	// Issuing a certificate counts as a use of the registration token, like issuing a credential or creating the
+	// node of a node agent in authorizeNode
+	if isCertificateRequest(req) {
+		if err := t.useRegistrationToken(id); err != nil {
+			return nil, false, err
+		}
+	}
+
 	if input.Node != nil {
-		register := strings.HasSuffix(req.URL.Path, "/register")
+		register := isEnrollment(req)
+
+		if id.certificate && id.nodeName == "" {
+			logrus.Debugf("Authorize: certificate of cluster [%s] can't be used by node agents", cluster.Name)
+			return nil, false, nil
+		}
 
-		node, ok, err := t.authorizeNode(register, cluster, input.Node, req)
+		node, ok, err := t.authorizeNode(register, id, input.Node, req)
 		if err != nil {
 			return nil, false, err
 		}
+		if !ok {
+			return nil, false, nil
+		}
+		if id.nodeName != "" && node.Name != id.nodeName {
+			logrus.Debugf("Authorize: certificate or credential of node [%s] can't be used by node [%s] in cluster [%s]", id.nodeName, node.Name, cluster.Name)
+			return nil, false, nil
+		}
 		if register && node.Status.NodeConfig != nil && input.Node.CustomConfig != nil {
 			node = node.DeepCopy()
 			node.Status.NodeConfig.Address = input.Node.CustomConfig.Address
@@ -145,6 +202,15 @@ func (t *Authorizer) Authorize(req *http.Request) (*Client, bool, error) {
 	}
 
 	if input.Cluster != nil {
+		if id.certificate && id.nodeName != "" {
+			logrus.Debugf("Authorize: certificate of node [%s] can't be used by the cluster agent of [%s]", id.nodeName, cluster.Name)
+			return nil, false, nil
+		}
+
+		if strings.HasSuffix(req.URL.Path, "/connect") || strings.HasSuffix(req.URL.Path, "/register") {
+			cluster = t.recordAgent(cluster, req)
+		}
+
 		cluster, ok, err := t.authorizeCluster(cluster, input.Cluster, req)
 		return &Client{
 			Cluster: cluster,
@@ -156,6 +222,65 @@ func (t *Authorizer) Authorize(req *http.Request) (*Client, bool, error) {
 	return nil, false, nil
 }
 
+// authenticate returns the identity of the agent from its client certificate, its credential or
+// the registration token, in that order
+func (t *Authorizer) authenticate(req *http.Request, token string) (*identity, error) {
+	id, err := t.getIdentityByCertificate(req)
+	if err != nil || id != nil {
+		return id, err
+	}
+
+	var crt *v3.ClusterRegistrationToken
+	if credential := req.Header.Get(AgentCredential); credential != "" {
+		id, err := t.getIdentityByCredential(credential)
+		if err == nil {
+			return id, nil
+		}
+		if err != ErrClusterNotFound || token == "" {
+			return nil, err
+		}
+		logrus.Debugf("Authorize: invalid agent credential, falling back to registration token")
+		crt, err = t.getRegistrationToken(token)
+		if err != nil {
+			return nil, err
+		}
+	} else if token != "" {
+		crt, err = t.getRegistrationToken(token)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		logrus.Debugf("Authorize: Token header [%s] is empty", Token)
+		return nil, nil
+	}
+
+	cluster, err := t.clusterLister.Get("", crt.Spec.ClusterName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &identity{
+		cluster:           cluster,
+		registrationToken: crt,
+	}, nil
+}
+
+// useRegistrationToken counts an enrollment with the registration token the agent authenticated with, if any. It
+// is counted once per request.
+func (t *Authorizer) useRegistrationToken(id *identity) error {
+	if id.registrationToken == nil {
+		return nil
+	}
+	_, err := clusterregistrationtoken.Use(t.crts, id.registrationToken)
+	if err == clusterregistrationtoken.ErrNotUsable {
+		return ErrClusterNotFound
+	} else if err != nil {
+		return err
+	}
+	id.registrationToken = nil
+	return nil
+}
+
 func (t *Authorizer) getMachine(cluster *v3.Cluster, inNode *client.Node) (*v3.Node, error) {
 	machineName := machineName(inNode)
 	logrus.Tracef("getMachine: looking up machine [%s] in cluster [%s]", machineName, cluster.Name)
@@ -187,12 +312,21 @@ func (t *Authorizer) getMachine(cluster *v3.Cluster, inNode *client.Node) (*v3.N
 	return machine, err
 }
 
-func (t *Authorizer) authorizeNode(register bool, cluster *v3.Cluster, inNode *client.Node, req *http.Request) (*v3.Node, bool, error) {
+func (t *Authorizer) authorizeNode(register bool, id *identity, inNode *client.Node, req *http.Request) (*v3.Node, bool, error) {
+	cluster := id.cluster
 	machine, err := t.getMachine(cluster, inNode)
+	if id.nodeName != "" && (apierrors.IsNotFound(err) || (machine != nil && machine.DeletionTimestamp != nil)) {
+		// The certificate or credential of a deleted node can't enroll it again
+		logrus.Debugf("Authorize: node [%s] of cluster [%s] was deleted", id.nodeName, cluster.Name)
+		return nil, false, nil
+	}
 	if apierrors.IsNotFound(err) {
 		if !register {
 			return nil, false, err
 		}
+		if err := t.useRegistrationToken(id); err != nil {
+			return nil, false, err
+		}
 		machine, err = t.createNode(inNode, cluster, req)
 		if err != nil {
 			return nil, false, err
@@ -318,6 +452,98 @@ func (t *Authorizer) authorizeCluster(cluster *v3.Cluster, inCluster *cluster, r
 	return cluster, true, err
 }
 
+// recordAgent annotates the cluster with the version and address of the connecting cluster agent
+func (t *Authorizer) recordAgent(cluster *v3.Cluster, req *http.Request) *v3.Cluster {
+	version := req.Header.Get(AgentVersion)
+	address := remoteAddress(req)
+	if cluster.Annotations[AgentVersionAnnotation] == version && cluster.Annotations[AgentAddressAnnotation] == address {
+		return cluster
+	}
+
+	updated := cluster.DeepCopy()
+	if updated.Annotations == nil {
+		updated.Annotations = map[string]string{}
+	}
+	updated.Annotations[AgentVersionAnnotation] = version
+	updated.Annotations[AgentAddressAnnotation] = address
+	updated, err := t.clusters.Update(updated)
+	if err != nil {
+		logrus.Debugf("Failed to record agent of cluster [%s]: %v", cluster.Name, err)
+		return cluster
+	}
+	return updated
+}
+
+// remoteAddress returns the IP of the client. X-Forwarded-For is only honoured for connections
+// from the proxies in the trusted-proxies setting.
+func remoteAddress(req *http.Request) string {
+	return clientAddress(req, trustedProxies(settings.TrustedProxies.Get()))
+}
+
+// clientAddress returns the last address of the chain of the connection and X-Forwarded-For that
+// isn't a trusted proxy
+func clientAddress(req *http.Request, trusted []*net.IPNet) string {
+	address, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		address = req.RemoteAddr
+	}
+	if !isTrusted(address, trusted) {
+		return address
+	}
+
+	forwarded := strings.Split(strings.Join(req.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(forwarded) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(forwarded[i])
+		if net.ParseIP(hop) == nil {
+			break
+		}
+		address = hop
+		if !isTrusted(hop, trusted) {
+			break
+		}
+	}
+	return address
+}
+
+func isTrusted(address string, trusted []*net.IPNet) bool {
+	ip := net.ParseIP(address)
+	if ip == nil {
+		return false
+	}
+	for _, ipNet := range trusted {
+		if ipNet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// trustedProxies parses a comma separated list of addresses and CIDRs, invalid entries are
+// ignored
+func trustedProxies(value string) []*net.IPNet {
+	var result []*net.IPNet
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		if !strings.Contains(entry, "/") {
+			if ip := net.ParseIP(entry); ip != nil && ip.To4() != nil {
+				entry += "/32"
+			} else {
+				entry += "/128"
+			}
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			logrus.Debugf("Ignoring invalid trusted proxy [%s]: %v", entry, err)
+			continue
+		}
+		result = append(result, ipNet)
+	}
+	return result
+}
+
 func (t *Authorizer) readInput(cluster *v3.Cluster, req *http.Request) (*input, error) {
 	params := req.Header.Get(Params)
 	var input input
@@ -361,20 +587,6 @@ func machineName(machine *client.Node) string {
 	return machineNameMD5
 }
 
-func (t *Authorizer) getClusterByToken(token string) (*v3.Cluster, error) {
-	keys, err := t.crtIndexer.ByIndex(crtKeyIndex, token)
-	if err != nil {
-		return nil, err
-	}
-
-	for _, obj := range keys {
-		crt := obj.(*v3.ClusterRegistrationToken)
-		return t.clusterLister.Get("", crt.Spec.ClusterName)
-	}
-
-	return nil, ErrClusterNotFound
-}
-
 func (t *Authorizer) crtIndex(obj interface{}) ([]string, error) {
 	crt := obj.(*v3.ClusterRegistrationToken)
 	if crt.Status.Token == "" {
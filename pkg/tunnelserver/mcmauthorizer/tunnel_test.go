@@ -0,0 +1,224 @@
+package mcmauthorizer
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	v32 "github.com/rancher/rancher/pkg/apis/management.cattle.io/v3"
+	client "github.com/rancher/rancher/pkg/client/generated/management/v3"
+	v3 "github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3"
+	"github.com/rancher/rancher/pkg/generated/norman/management.cattle.io/v3/fakes"
+	"github.com/stretchr/testify/assert"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/tools/cache"
+)
+
+type fakeRegistrationTokens struct {
+	v3.ClusterRegistrationTokenInterface
+	indexer cache.Indexer
+}
+
+func (f *fakeRegistrationTokens) Update(crt *v32.ClusterRegistrationToken) (*v32.ClusterRegistrationToken, error) {
+	return crt, f.indexer.Update(crt)
+}
+
+// newTestAuthorizer returns an authorizer for cluster c-abc with the registration token "secret" that can be used
+// maxUses times, and the nodes it creates
+func newTestAuthorizer(maxUses int) (*Authorizer, map[string]*v32.Node) {
+	nodes := map[string]*v32.Node{}
+	auth := &Authorizer{
+		crtIndexer:  cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
+		nodeIndexer: cache.NewIndexer(cache.MetaNamespaceKeyFunc, cache.Indexers{}),
+		clusterLister: &fakes.ClusterListerMock{
+			GetFunc: func(namespace, name string) (*v32.Cluster, error) {
+				return &v32.Cluster{ObjectMeta: metav1.ObjectMeta{Name: name}}, nil
+			},
+		},
+		machineLister: &fakes.NodeListerMock{
+			GetFunc: func(namespace, name string) (*v32.Node, error) {
+				if node, ok := nodes[name]; ok {
+					return node, nil
+				}
+				return nil, apierrors.NewNotFound(v32.Resource("nodes"), name)
+			},
+			ListFunc: func(namespace string, selector labels.Selector) ([]*v32.Node, error) {
+				return nil, nil
+			},
+		},
+		machines: &fakes.NodeInterfaceMock{
+			CreateFunc: func(node *v32.Node) (*v32.Node, error) {
+				nodes[node.Name] = node
+				return node, nil
+			},
+			UpdateFunc: func(node *v32.Node) (*v32.Node, error) {
+				nodes[node.Name] = node
+				return node, nil
+			},
+		},
+	}
+	auth.crtIndexer.AddIndexers(cache.Indexers{crtKeyIndex: auth.crtIndex})
+	auth.nodeIndexer.AddIndexers(cache.Indexers{nodeKeyIndex: auth.nodeIndex})
+	auth.crts = &fakeRegistrationTokens{indexer: auth.crtIndexer}
+	auth.crtIndexer.Add(&v32.ClusterRegistrationToken{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "default-token",
+			Namespace: "c-abc",
+		},
+		Spec: v32.ClusterRegistrationTokenSpec{
+			ClusterName: "c-abc",
+			MaxUses:     maxUses,
+		},
+		Status: v32.ClusterRegistrationTokenStatus{
+			Token: "secret",
+		},
+	})
+	return auth, nodes
+}
+
+func newNodeRequest(t *testing.T, path, hostname string) *http.Request {
+	params, err := json.Marshal(input{
+		Node: &client.Node{
+			RequestedHostname: hostname,
+			CustomConfig: &client.CustomConfig{
+				Address: "10.0.0.1",
+			},
+		},
+	})
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, path, nil)
+	req.Header.Set(Token, "secret")
+	req.Header.Set(Params, base64.StdEncoding.EncodeToString(params))
+	return req
+}
+
+func TestAuthorizeSingleUseToken(t *testing.T) {
+	auth, nodes := newTestAuthorizer(1)
+
+	c, ok, err := auth.Authorize(newNodeRequest(t, "/v3/connect/register", "node1"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "node1", c.Node.Spec.RequestedHostname)
+	assert.Len(t, nodes, 1)
+
+	_, ok, err = auth.Authorize(newNodeRequest(t, "/v3/connect/register", "node2"))
+	assert.Equal(t, ErrClusterNotFound, err)
+	assert.False(t, ok)
+	assert.Len(t, nodes, 1)
+
+	_, ok, err = auth.Authorize(newNodeRequest(t, "/v3/connect/certificate", "node1"))
+	assert.Equal(t, ErrClusterNotFound, err)
+	assert.False(t, ok)
+}
+
+func TestAuthorizeCountsNewNodesAndCertificates(t *testing.T) {
+	auth, nodes := newTestAuthorizer(3)
+
+	for _, path := range []string{"/v3/connect/register", "/v3/connect/register", "/v3/connect/config"} {
+		_, ok, err := auth.Authorize(newNodeRequest(t, path, "node1"))
+		assert.NoError(t, err)
+		assert.True(t, ok)
+	}
+	assert.Len(t, nodes, 1)
+
+	_, ok, err := auth.Authorize(newNodeRequest(t, "/v3/connect/certificate", "node1"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+
+	_, ok, err = auth.Authorize(newNodeRequest(t, "/v3/connect/certificate", "node2"))
+	assert.NoError(t, err)
+	assert.True(t, ok)
+	assert.Len(t, nodes, 2)
+
+	obj, exists, err := auth.crtIndexer.GetByKey("c-abc/default-token")
+	assert.NoError(t, err)
+	assert.True(t, exists)
+	assert.Equal(t, 3, obj.(*v32.ClusterRegistrationToken).Status.Uses)
+}
+
+func TestClientAddress(t *testing.T) {
+	trusted := trustedProxies("10.42.0.0/16, 192.168.1.10,invalid, fd00::/8")
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  []string
+		expected   string
+	}{
+		{
+			name:       "no proxy",
+			remoteAddr: "203.0.113.5:51234",
+			expected:   "203.0.113.5",
+		},
+		{
+			name:       "untrusted client forging the header",
+			remoteAddr: "203.0.113.5:51234",
+			forwarded:  []string{"198.51.100.1"},
+			expected:   "203.0.113.5",
+		},
+		{
+			name:       "trusted proxy",
+			remoteAddr: "10.42.3.7:443",
+			forwarded:  []string{"198.51.100.1"},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "trusted proxy appends to a forged header",
+			remoteAddr: "10.42.3.7:443",
+			forwarded:  []string{"1.2.3.4, 198.51.100.1"},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "chain of trusted proxies",
+			remoteAddr: "10.42.3.7:443",
+			forwarded:  []string{"1.2.3.4, 198.51.100.1, 192.168.1.10"},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "header split over multiple lines",
+			remoteAddr: "10.42.3.7:443",
+			forwarded:  []string{"1.2.3.4", "198.51.100.1"},
+			expected:   "198.51.100.1",
+		},
+		{
+			name:       "only trusted addresses",
+			remoteAddr: "10.42.3.7:443",
+			forwarded:  []string{"10.42.0.1"},
+			expected:   "10.42.0.1",
+		},
+		{
+			name:       "invalid hop",
+			remoteAddr: "10.42.3.7:443",
+			forwarded:  []string{"198.51.100.1, unknown"},
+			expected:   "10.42.3.7",
+		},
+		{
+			name:       "trusted IPv6 proxy",
+			remoteAddr: "[fd00::1]:443",
+			forwarded:  []string{"2001:db8::1"},
+			expected:   "2001:db8::1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v3/connect", nil)
+			req.RemoteAddr = tt.remoteAddr
+			for _, value := range tt.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			assert.Equal(t, tt.expected, clientAddress(req, trusted))
+		})
+	}
+}
+
+func TestTrustedProxies(t *testing.T) {
+	assert.Empty(t, trustedProxies(""))
+	assert.Len(t, trustedProxies("10.0.0.0/8,invalid,10.0.0.1"), 2)
+	assert.True(t, isTrusted("10.0.0.1", trustedProxies("10.0.0.1")))
+	assert.False(t, isTrusted("10.0.0.2", trustedProxies("10.0.0.1")))
+}
@@ -276,13 +276,15 @@ func NewContext(ctx context.Context, clientConfig clientcmd.ClientConfig, restCo
 		steveControllers.K8s.Discovery(),
 		steveControllers.Core.ConfigMap().Cache(),
 		steveControllers.Core.Secret().Cache(),
-		helm.Catalog().V1().ClusterRepo().Cache())
+		helm.Catalog().V1().ClusterRepo().Cache(),
+		helm.Catalog().V1().ChartPolicy().Cache())
 
 	helmop := helmop.NewOperations(cg,
 		helm.Catalog().V1(),
 		rbac.Rbac().V1(),
 		content,
-		steveControllers.Core.Pod())
+		steveControllers.Core.Pod(),
+		steveControllers.Core.Namespace().Cache())
 
 	restClientGetter := &SimpleRESTClientGetter{
 		ClientConfig:    clientConfig,